@@ -1,16 +1,20 @@
 package authservices
 
 import (
-	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/repository"
 	tokenServices "decentragri-app-cx-server/token.services"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// users is the account store the auth services read and write
+var users = repository.NewUserRepository()
+
 // CheckDevBypass checks if the request has a valid dev bypass token
 // Returns true if bypass is valid, false otherwise
 func CheckDevBypass(c *fiber.Ctx) bool {
@@ -22,14 +26,14 @@ func CheckDevBypass(c *fiber.Ctx) bool {
 	// Check for bypass token in header
 	bypassHeader := c.Get("X-Dev-Bypass-Token")
 	if bypassHeader == devBypassToken {
-		fmt.Println("Dev bypass token used for request:", c.Method(), c.Path())
+		slog.WarnContext(c.UserContext(), "dev bypass token used", "method", c.Method(), "path", c.Path())
 		return true
 	}
 
 	// Check for bypass token in query parameter (alternative method)
 	bypassQuery := c.Query("dev_bypass_token")
 	if bypassQuery == devBypassToken {
-		fmt.Println("Dev bypass token used for request:", c.Method(), c.Path())
+		slog.WarnContext(c.UserContext(), "dev bypass token used", "method", c.Method(), "path", c.Path())
 		return true
 	}
 
@@ -81,29 +85,14 @@ func AuthenticateWallet(request AuthenticateWalletRequest) (AuthenticateWalletRe
 		return AuthenticateWalletResponse{}, errors.New("signature verification failed")
 	}
 
-	// Check if user exists
-	query := `MATCH (u:User {username: $username})`
-	params := map[string]any{"username": request.WalletAddress}
-	records, err := memgraph.ExecuteRead(query, params)
+	// Create the user unless they exist; concurrent logins share one node
+	isNewUser, err := users.CreateByUsername(repository.User{
+		Username:      request.WalletAddress,
+		WalletAddress: request.WalletAddress,
+		DeviceID:      request.DeviceId,
+	})
 	if err != nil {
-		return AuthenticateWalletResponse{}, errors.New("database error: " + err.Error())
-	}
-
-	isNewUser := len(records) == 0
-
-	// If new user, create them
-	if isNewUser {
-		createQuery := `CREATE (u:User {
-			username: $username,
-			createdAt: timestamp(),
-			walletAddress: $walletAddress,
-			deviceId: $deviceId})
-		RETURN u.username AS username`
-		createParams := map[string]any{"username": request.WalletAddress, "walletAddress": request.WalletAddress, "deviceId": request.DeviceId}
-		_, err = memgraph.ExecuteWrite(createQuery, createParams)
-		if err != nil {
-			return AuthenticateWalletResponse{}, errors.New("failed to create user: " + err.Error())
-		}
+		return AuthenticateWalletResponse{}, err
 	}
 
 	// Generate tokens for both new and existing users
@@ -149,6 +138,17 @@ func RefreshSession(refreshToken string) (tokenServices.TokenScheme, error) {
 	return *tokens, nil
 }
 
+// DeleteAccount soft-deletes the caller's account. Its tokens stop working at once and
+// signing in again before the purge restores it.
+func DeleteAccount(username string) error {
+	return users.Delete(username, username)
+}
+
+// RestoreAccount undoes the deletion of an account that has not been purged yet
+func RestoreAccount(username string) error {
+	return users.Restore(username)
+}
+
 // VerifyGoogleToken verifies the Google ID token with Google's servers
 func VerifyGoogleToken(idToken string) (*GoogleTokenInfo, error) {
 	if idToken == "" {
@@ -218,14 +218,20 @@ func AuthenticateGoogle(request AuthenticateGoogleRequest) (AuthenticateGoogleRe
 	username := tokenInfo.Sub
 
 	// Check if user exists in database
-	query := `MATCH (u:User {googleId: $googleId})`
-	params := map[string]any{"googleId": tokenInfo.Sub}
-	records, err := memgraph.ExecuteRead(query, params)
-	if err != nil {
+	_, err = users.FindByGoogleID(tokenInfo.Sub)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		// Signing in again restores a deleted account rather than creating another
+		if restoreErr := users.RestoreByGoogleID(tokenInfo.Sub); restoreErr == nil {
+			err = nil
+		} else if !errors.Is(restoreErr, repository.ErrUserNotFound) {
+			err = restoreErr
+		}
+	}
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
 		return AuthenticateGoogleResponse{}, fmt.Errorf("database error: %w", err)
 	}
 
-	isNewUser := len(records) == 0
+	isNewUser := err != nil
 	var walletAddress string
 
 	// If new user, create them
@@ -235,58 +241,34 @@ func AuthenticateGoogle(request AuthenticateGoogleRequest) (AuthenticateGoogleRe
 			return AuthenticateGoogleResponse{}, fmt.Errorf("failed to create wallet: %w", err)
 		}
 
-		createQuery := `CREATE (u:User {
-			username: $username,
-			googleId: $googleId,
-			email: $email,
-			name: $name,
-			picture: $picture,
-			createdAt: timestamp(),
-			deviceId: $deviceId,
-			walletAddress: $walletAddress,
-			authProvider: 'google'
-		}) RETURN u.username AS username`
-
-		createParams := map[string]any{
-			"username":      walletAddress,
-			"googleId":      tokenInfo.Sub,
-			"email":         tokenInfo.Email,
-			"name":          tokenInfo.Name,
-			"picture":       tokenInfo.Picture,
-			"deviceId":      request.DeviceId,
-			"walletAddress": walletAddress,
-		}
-
-		_, err = memgraph.ExecuteWrite(createQuery, createParams)
+		user, created, err := users.CreateByGoogleID(repository.User{
+			Username:      walletAddress,
+			WalletAddress: walletAddress,
+			GoogleID:      tokenInfo.Sub,
+			Email:         tokenInfo.Email,
+			Name:          tokenInfo.Name,
+			Picture:       tokenInfo.Picture,
+			DeviceID:      request.DeviceId,
+			AuthProvider:  "google",
+		})
 		if err != nil {
-			return AuthenticateGoogleResponse{}, fmt.Errorf("failed to create user: %w", err)
+			return AuthenticateGoogleResponse{}, err
 		}
+		// A concurrent login created the account first; use its wallet
+		isNewUser = created
+		walletAddress = user.WalletAddress
 	} else {
 		// Update existing user's info and get wallet address
-		updateQuery := `MATCH (u:User {googleId: $googleId})
-			SET u.email = $email, u.name = $name, u.picture = $picture, u.deviceId = $deviceId
-			RETURN u.walletAddress AS walletAddress`
-
-		updateParams := map[string]any{
-			"googleId": tokenInfo.Sub,
-			"email":    tokenInfo.Email,
-			"name":     tokenInfo.Name,
-			"picture":  tokenInfo.Picture,
-			"deviceId": request.DeviceId,
-		}
-
-		records, err := memgraph.ExecuteRead(updateQuery, updateParams)
+		user, err := users.UpdateGoogleProfile(tokenInfo.Sub, repository.GoogleProfile{
+			Email:    tokenInfo.Email,
+			Name:     tokenInfo.Name,
+			Picture:  tokenInfo.Picture,
+			DeviceID: request.DeviceId,
+		})
 		if err != nil {
-			return AuthenticateGoogleResponse{}, fmt.Errorf("failed to update user: %w", err)
-		}
-
-		if len(records) > 0 {
-			if addr, ok := records[0].Get("walletAddress"); ok {
-				if walletAddr, ok := addr.(string); ok {
-					walletAddress = walletAddr
-				}
-			}
+			return AuthenticateGoogleResponse{}, err
 		}
+		walletAddress = user.WalletAddress
 	}
 
 	// Generate JWT tokens
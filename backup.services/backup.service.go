@@ -0,0 +1,300 @@
+package backupservices
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+	memgraph "decentragri-app-cx-server/db"
+)
+
+const (
+	// backupPageSize is how many nodes one export query reads
+	backupPageSize = 1000
+	// defaultBackupInterval is how often scheduled backups run
+	defaultBackupInterval = 24 * time.Hour
+)
+
+// Backup formats
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+var (
+	// ErrBackupDisabled is returned when no backup bucket is configured
+	ErrBackupDisabled = errors.New("backup storage is not configured")
+	// ErrBackupInProgress is returned while another backup runs on this instance
+	ErrBackupInProgress = errors.New("a backup is already running")
+	// ErrInvalidBackupFormat is returned for a format other than json or csv
+	ErrInvalidBackupFormat = errors.New("format must be json or csv")
+)
+
+// backupDataset is an exported slice of the graph. Query pages it by internal node ID,
+// so nodes missing their key property are exported too: it takes $after (null for the
+// first page) and $limit, and returns cursor (the node ID), key, properties, and links
+// ordered by cursor.
+type backupDataset struct {
+	Name  string
+	Query string
+}
+
+// backupDatasets is the core graph a backup exports. Soft-deleted nodes are included so
+// they can still be restored from a backup.
+var backupDatasets = []backupDataset{
+	{
+		Name: "users",
+		Query: `MATCH (n:User) WHERE $after IS NULL OR id(n) > $after
+			WITH n ORDER BY id(n) LIMIT $limit
+			RETURN id(n) AS cursor, n.username AS key, properties(n) AS properties, {} AS links`,
+	},
+	{
+		Name: "farms",
+		Query: `MATCH (n:Farm) WHERE $after IS NULL OR id(n) > $after
+			WITH n ORDER BY id(n) LIMIT $limit
+			OPTIONAL MATCH (n)-[g:GRANTS]->(c:User)
+			WITH n, collect(CASE WHEN c IS NULL THEN null ELSE {username: c.username, role: g.role} END) AS collaborators
+			RETURN id(n) AS cursor, n.id AS key, properties(n) AS properties, {collaborators: collaborators} AS links
+			ORDER BY cursor`,
+	},
+	{
+		Name: "plant_scans",
+		Query: `MATCH (f:Farm)-[:HAS_PLANT_SCAN]->(n:PlantScan) WHERE $after IS NULL OR id(n) > $after
+			WITH f, n ORDER BY id(n) LIMIT $limit
+			RETURN id(n) AS cursor, n.id AS key, properties(n) AS properties, {farmId: f.id} AS links`,
+	},
+	{
+		Name: "purchases",
+		Query: `MATCH (n:Purchase) WHERE $after IS NULL OR id(n) > $after
+			WITH n ORDER BY id(n) LIMIT $limit
+			OPTIONAL MATCH (u:User)-[:MADE_PURCHASE]->(n)
+			RETURN id(n) AS cursor, n.id AS key, properties(n) AS properties, {buyer: u.username} AS links
+			ORDER BY cursor`,
+	},
+}
+
+// backupMu keeps one backup running per instance
+var backupMu sync.Mutex
+
+// Enabled reports whether a backup bucket is configured
+func Enabled() bool {
+	return loadObjectStore() != nil
+}
+
+// ExportGraph dumps users, farms, plant scans, and purchases to the backup bucket as
+// one object per dataset under <prefix><id>/, in format (json, the default, or csv),
+// followed by manifest.json. trigger records who started it.
+func ExportGraph(ctx context.Context, format, trigger string) (*BackupManifest, error) {
+	store := loadObjectStore()
+	if store == nil {
+		return nil, ErrBackupDisabled
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = FormatJSON
+	}
+	if format != FormatJSON && format != FormatCSV {
+		return nil, ErrInvalidBackupFormat
+	}
+	if !backupMu.TryLock() {
+		return nil, ErrBackupInProgress
+	}
+	defer backupMu.Unlock()
+
+	start := time.Now().UTC()
+	manifest := &BackupManifest{
+		ID:        start.Format("20060102T150405Z"),
+		Format:    format,
+		Trigger:   trigger,
+		StartedAt: start.Unix(),
+		Datasets:  make([]BackupDataset, 0, len(backupDatasets)),
+	}
+
+	for _, dataset := range backupDatasets {
+		records, err := exportDataset(ctx, dataset)
+		if err != nil {
+			return nil, err
+		}
+		body, contentType, err := encodeRecords(records, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", dataset.Name, err)
+		}
+		object := manifest.ID + "/" + dataset.Name + "." + format
+		if err := store.put(ctx, object, contentType, body); err != nil {
+			return nil, err
+		}
+		unkeyed := 0
+		for _, record := range records {
+			if record.Key == "" {
+				unkeyed++
+			}
+		}
+		if unkeyed > 0 {
+			slog.Warn("backup exported records without a key", "backupId", manifest.ID, "dataset", dataset.Name, "records", unkeyed)
+		}
+		manifest.Datasets = append(manifest.Datasets, BackupDataset{
+			Name:    dataset.Name,
+			Object:  store.prefix + object,
+			Records: len(records),
+			Unkeyed: unkeyed,
+			Bytes:   len(body),
+		})
+	}
+
+	manifest.CompletedAt = time.Now().Unix()
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode backup manifest: %w", err)
+	}
+	if err := store.put(ctx, manifest.ID+"/manifest.json", "application/json", body); err != nil {
+		return nil, err
+	}
+
+	slog.Info("graph backup complete", "backupId", manifest.ID, "trigger", trigger, "elapsed", time.Since(start).Round(time.Second))
+	return manifest, nil
+}
+
+// exportDataset reads every node of dataset, a page at a time
+func exportDataset(ctx context.Context, dataset backupDataset) ([]BackupRecord, error) {
+	records := make([]BackupRecord, 0)
+	var after any
+	for {
+		page, err := memgraph.ExecuteReadContext(ctx, dataset.Query, map[string]any{"after": after, "limit": backupPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", dataset.Name, err)
+		}
+		for _, record := range page {
+			item := BackupRecord{Key: memgraph.String(record, "key")}
+			if v, ok := record.Get("properties"); ok {
+				item.Properties, _ = v.(map[string]any)
+			}
+			if v, ok := record.Get("links"); ok {
+				if links, _ := v.(map[string]any); len(links) > 0 {
+					item.Links = links
+				}
+			}
+			records = append(records, item)
+		}
+		if len(page) < backupPageSize {
+			return records, nil
+		}
+		after = memgraph.Int64(page[len(page)-1], "cursor")
+	}
+}
+
+// encodeRecords renders records as a JSON array, or as CSV with a key column, one
+// column per property, and a links.<name> column per link. Lists and maps are written
+// to CSV cells as JSON.
+func encodeRecords(records []BackupRecord, format string) ([]byte, string, error) {
+	if format == FormatJSON {
+		body, err := json.Marshal(records)
+		return body, "application/json", err
+	}
+
+	propertySet, linkSet := make(map[string]bool), make(map[string]bool)
+	for _, record := range records {
+		for name := range record.Properties {
+			propertySet[name] = true
+		}
+		for name := range record.Links {
+			linkSet[name] = true
+		}
+	}
+	properties, links := sortedKeys(propertySet), sortedKeys(linkSet)
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	header := append([]string{"key"}, properties...)
+	for _, name := range links {
+		header = append(header, "links."+name)
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, "", err
+	}
+	for _, record := range records {
+		row := make([]string, 0, len(header))
+		row = append(row, record.Key)
+		for _, name := range properties {
+			row = append(row, csvCell(record.Properties[name]))
+		}
+		for _, name := range links {
+			row = append(row, csvCell(record.Links[name]))
+		}
+		if err := writer.Write(row); err != nil {
+			return nil, "", err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), "text/csv", writer.Error()
+}
+
+// csvCell formats a property value for a CSV cell
+func csvCell(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case int64, float64, bool:
+		return fmt.Sprint(value)
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// StartBackupScheduler exports the graph every BACKUP_INTERVAL (default 24h) in
+// BACKUP_FORMAT (default json). A Redis claim per interval makes each run happen once
+// across instances. The scheduler is disabled when no backup bucket is configured.
+// It blocks, so run it in a goroutine.
+func StartBackupScheduler() {
+	if !Enabled() {
+		slog.Info("graph backups disabled: BACKUP_S3_ENDPOINT, BACKUP_S3_BUCKET, or credentials not set")
+		return
+	}
+	interval := defaultBackupInterval
+	if v := os.Getenv("BACKUP_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Hour {
+			interval = parsed
+		}
+	}
+	format := os.Getenv("BACKUP_FORMAT")
+
+	slog.Info("graph backup scheduler started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		window := time.Now().Truncate(interval).Unix()
+		claimed, err := cache.SetNX(fmt.Sprintf("graph_backup_run:%d", window), true, interval)
+		if err == nil && !claimed {
+			continue
+		}
+		if _, err := ExportGraph(context.Background(), format, "scheduled"); err != nil {
+			slog.Warn("scheduled graph backup failed", "error", err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package backupservices
+
+// BackupRecord is one exported node: its key, every property, and the relationships
+// needed to rebuild it, such as the farm a plant scan belongs to. Key is empty for a
+// node missing its key property; its other properties are still exported.
+type BackupRecord struct {
+	Key        string         `json:"key"`
+	Properties map[string]any `json:"properties"`
+	Links      map[string]any `json:"links,omitempty"`
+}
+
+// BackupDataset describes one exported object of a backup
+type BackupDataset struct {
+	Name    string `json:"name"`
+	Object  string `json:"object"`
+	Records int    `json:"records"`
+	Unkeyed int    `json:"unkeyed"` // Records exported with an empty key, for review
+	Bytes   int    `json:"bytes"`
+}
+
+// BackupManifest describes a completed backup. It is uploaded last as manifest.json,
+// so a backup without one is incomplete.
+type BackupManifest struct {
+	ID          string          `json:"id"`
+	Format      string          `json:"format"`
+	Trigger     string          `json:"trigger"`
+	StartedAt   int64           `json:"startedAt"`
+	CompletedAt int64           `json:"completedAt"`
+	Datasets    []BackupDataset `json:"datasets"`
+}
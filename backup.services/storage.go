@@ -0,0 +1,127 @@
+package backupservices
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// objectStore writes objects to an S3-compatible bucket (AWS S3, MinIO, R2, ...) with
+// path-style URLs and Signature Version 4
+type objectStore struct {
+	endpoint  string
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	prefix    string
+	client    *http.Client
+}
+
+// loadObjectStore reads the backup bucket from BACKUP_S3_ENDPOINT, BACKUP_S3_BUCKET,
+// BACKUP_S3_ACCESS_KEY_ID, and BACKUP_S3_SECRET_ACCESS_KEY, with optional
+// BACKUP_S3_REGION (default us-east-1) and BACKUP_S3_PREFIX (default backups/).
+// It returns nil when the bucket is not fully configured.
+func loadObjectStore() *objectStore {
+	store := &objectStore{
+		endpoint:  strings.TrimRight(os.Getenv("BACKUP_S3_ENDPOINT"), "/"),
+		bucket:    os.Getenv("BACKUP_S3_BUCKET"),
+		region:    os.Getenv("BACKUP_S3_REGION"),
+		accessKey: os.Getenv("BACKUP_S3_ACCESS_KEY_ID"),
+		secretKey: os.Getenv("BACKUP_S3_SECRET_ACCESS_KEY"),
+		prefix:    os.Getenv("BACKUP_S3_PREFIX"),
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}
+	if store.endpoint == "" || store.bucket == "" || store.accessKey == "" || store.secretKey == "" {
+		return nil
+	}
+	if store.region == "" {
+		store.region = "us-east-1"
+	}
+	if store.prefix == "" {
+		store.prefix = "backups/"
+	}
+	if !strings.HasSuffix(store.prefix, "/") {
+		store.prefix += "/"
+	}
+	return store
+}
+
+// put uploads body as the object at key under the configured prefix
+func (s *objectStore) put(ctx context.Context, key, contentType string, body []byte) error {
+	segments := strings.Split(s.prefix+key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut,
+		s.endpoint+"/"+url.PathEscape(s.bucket)+"/"+strings.Join(segments, "/"), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to upload %s: status %d: %s", key, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// sign adds the Signature Version 4 headers for req with body at now
+func (s *objectStore) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
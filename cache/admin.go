@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// adminScanBatch is how many keys one SCAN step of the admin tools asks for
+const adminScanBatch = 1000
+
+// ErrKeyNotFound is returned when inspecting a key that does not exist
+var ErrKeyNotFound = errors.New("cache key not found")
+
+// ErrCacheUnavailable is returned by the admin tools when Redis is not connected
+var ErrCacheUnavailable = errors.New("redis client not available")
+
+// ErrPrefixRequired is returned when flushing without a prefix
+var ErrPrefixRequired = errors.New("prefix is required")
+
+// PrefixCount is the number of keys sharing a prefix
+type PrefixCount struct {
+	Prefix string `json:"prefix"`
+	Keys   int64  `json:"keys"`
+}
+
+// KeyStats summarizes the keys under a prefix, grouped by their next segment
+type KeyStats struct {
+	Prefix string        `json:"prefix"`
+	Total  int64         `json:"total"`
+	Groups []PrefixCount `json:"groups"`
+}
+
+// KeyInfo describes one cached key
+type KeyInfo struct {
+	Key        string `json:"key"`
+	Type       string `json:"type"`
+	TTLSeconds int64  `json:"ttlSeconds"` // -1 when the key never expires
+	SizeBytes  int64  `json:"sizeBytes"`  // Memory Redis reports for the key and its value
+}
+
+// CountKeys counts the keys starting with prefix, grouped by the segment that follows it
+// up to the next ':' (so "" groups by namespace: image, farm_scans, ...), largest first
+func CountKeys(ctx context.Context, prefix string) (*KeyStats, error) {
+	if RedisClient == nil {
+		return nil, ErrCacheUnavailable
+	}
+
+	counts := make(map[string]int64)
+	var total int64
+	err := scanKeys(ctx, escapePattern(prefix)+"*", adminScanBatch, func(keys []string) error {
+		for _, key := range keys {
+			rest := strings.TrimPrefix(key, prefix)
+			group, _, nested := strings.Cut(rest, ":")
+			if nested {
+				group += ":"
+			}
+			counts[prefix+group]++
+			total++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &KeyStats{Prefix: prefix, Total: total, Groups: make([]PrefixCount, 0, len(counts))}
+	for group, keys := range counts {
+		stats.Groups = append(stats.Groups, PrefixCount{Prefix: group, Keys: keys})
+	}
+	sort.Slice(stats.Groups, func(i, j int) bool {
+		if stats.Groups[i].Keys != stats.Groups[j].Keys {
+			return stats.Groups[i].Keys > stats.Groups[j].Keys
+		}
+		return stats.Groups[i].Prefix < stats.Groups[j].Prefix
+	})
+	return stats, nil
+}
+
+// InspectKey returns a key's type, remaining TTL, and memory size
+func InspectKey(ctx context.Context, key string) (*KeyInfo, error) {
+	if RedisClient == nil {
+		return nil, ErrCacheUnavailable
+	}
+
+	ctx, cancel := opContext(ctx)
+	defer cancel()
+	pipe := RedisClient.Pipeline()
+	keyType := pipe.Type(ctx, key)
+	ttl := pipe.TTL(ctx, key)
+	size := pipe.MemoryUsage(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	if keyType.Val() == "none" {
+		return nil, ErrKeyNotFound
+	}
+
+	info := &KeyInfo{Key: key, Type: keyType.Val(), TTLSeconds: -1, SizeBytes: size.Val()}
+	if d := ttl.Val(); d > 0 {
+		info.TTLSeconds = int64(d / time.Second)
+	}
+	return info, nil
+}
+
+// FlushPrefix deletes every key starting with prefix and returns how many were removed.
+// The prefix must be non-empty so the whole cache cannot be dropped by accident.
+func FlushPrefix(ctx context.Context, prefix string) (int64, error) {
+	if RedisClient == nil {
+		return 0, ErrCacheUnavailable
+	}
+	if strings.TrimSpace(prefix) == "" {
+		return 0, ErrPrefixRequired
+	}
+
+	var deleted int64
+	err := scanKeys(ctx, escapePattern(prefix)+"*", adminScanBatch, func(keys []string) error {
+		unlinkCtx, cancel := opContext(ctx)
+		defer cancel()
+		n, err := RedisClient.Unlink(unlinkCtx, keys...).Result()
+		deleted += n
+		return err
+	})
+	if err != nil {
+		return deleted, err
+	}
+
+	slog.Info("flushed cache keys with prefix", "deleted", deleted, "prefix", prefix)
+	return deleted, nil
+}
+
+// scanKeys walks the keys matching pattern and passes each non-empty SCAN page to fn.
+// Every SCAN step gets its own operation timeout, so a large keyspace can take several
+// steps while a stalled Redis still fails fast; the walk stops when ctx ends.
+func scanKeys(ctx context.Context, pattern string, count int64, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stepCtx, cancel := opContext(ctx)
+		keys, next, err := RedisClient.Scan(stepCtx, cursor, pattern, count).Result()
+		cancel()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// escapePattern escapes Redis glob characters so a prefix matches literally
+func escapePattern(prefix string) string {
+	var b strings.Builder
+	for _, r := range prefix {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Encodings of values stored with SetBytes, written as the value's first byte
+const (
+	bytesRaw  byte = 0x00
+	bytesGzip byte = 0x01
+)
+
+// SetBytes stores binary data such as images as-is rather than JSON-marshaled, which
+// would base64-inflate it by a third. The data is gzipped when that makes it smaller,
+// as it does for uncompressed formats; JPEG and WebP are stored raw.
+func SetBytes(key string, data []byte, expiration time.Duration) error {
+	if RedisClient == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	value := append([]byte{bytesRaw}, data...)
+	var compressed bytes.Buffer
+	compressed.WriteByte(bytesGzip)
+	zw, _ := gzip.NewWriterLevel(&compressed, gzip.BestSpeed)
+	if _, err := zw.Write(data); err == nil && zw.Close() == nil && compressed.Len() < len(value) {
+		value = compressed.Bytes()
+	}
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	return degrade("SET", RedisClient.Set(ctx, key, value, expiration).Err())
+}
+
+// GetBytes retrieves data stored with SetBytes. Values written by Set before the key
+// moved to SetBytes (a JSON base64 string) are still read.
+func GetBytes(key string) ([]byte, error) {
+	if RedisClient == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	value, err := RedisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, degrade("GET", err)
+	}
+	return decodeBytes(key, value)
+}
+
+// GetBytesMulti retrieves many SetBytes values in one MGET round trip. Keys that are
+// missing or unreadable are left out of the result, so callers fetch just those.
+func GetBytesMulti(keys []string) (map[string][]byte, error) {
+	if RedisClient == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+	found := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return found, nil
+	}
+
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	values, err := RedisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, degrade("MGET", err)
+	}
+	for i, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if data, err := decodeBytes(keys[i], []byte(raw)); err == nil && len(data) > 0 {
+			found[keys[i]] = data
+		}
+	}
+	return found, nil
+}
+
+// decodeBytes unpacks a value stored by SetBytes, or by Set before the key moved to SetBytes
+func decodeBytes(key string, value []byte) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, fmt.Errorf("empty value for key %s", key)
+	}
+
+	switch value[0] {
+	case bytesRaw:
+		return value[1:], nil
+	case bytesGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(value[1:]))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	case '"', 'n':
+		var data []byte
+		if err := json.Unmarshal(value, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unknown encoding for key %s", key)
+	}
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// encodingGob marks a value written by SetGob. JSON values never start with this byte,
+// so Get can tell the two apart and read either.
+const encodingGob byte = 0x02
+
+func init() {
+	// Decoded JSON metadata (NFT properties and attributes) holds these in interface fields
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// SetGob stores a value gob-encoded rather than as JSON, optionally under tags like
+// SetWithTags. Gob is cheaper to encode and decode and smaller for large structs such
+// as portfolios and listings with embedded images, where JSON base64-inflates bytes.
+// Values gob cannot encode are stored as JSON instead. Read them back with Get.
+func SetGob(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	if RedisClient == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(encodingGob)
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		slog.Warn("cannot gob-encode, storing it as JSON", "key", key, "error", err)
+		return SetWithTags(key, value, expiration, tags...)
+	}
+
+	return setTagged(key, buf.Bytes(), expiration, tags)
+}
+
+// decodeValue unmarshals a stored value into dest, gob or JSON depending on its marker
+func decodeValue(value []byte, dest interface{}) error {
+	if len(value) > 0 && value[0] == encodingGob {
+		return gob.NewDecoder(bytes.NewReader(value[1:])).Decode(dest)
+	}
+	return json.Unmarshal(value, dest)
+}
@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invalidation topics published when data cached by several modules changes
+const (
+	// TopicPlotOwnership fires when farm plots change hands (purchase, sale, transfer).
+	// Wallets lists the parties whose cached views are stale.
+	TopicPlotOwnership = "plot_ownership"
+	// TopicKeys fires when cache keys are deleted. Keys lists them.
+	TopicKeys = "keys"
+)
+
+// invalidationChannel is the Redis channel invalidations are relayed to other instances on
+const invalidationChannel = "cache_invalidation"
+
+// instanceID tells this instance's own relayed invalidations apart from other instances'
+var instanceID = uuid.NewString()
+
+// InvalidationEvent describes what changed so subscribers can drop the affected keys
+type InvalidationEvent struct {
+	Topic   string   `json:"topic"`
+	Wallets []string `json:"wallets,omitempty"`
+	Keys    []string `json:"keys,omitempty"`
+	Origin  string   `json:"origin"` // Instance that published the event
+}
+
+// invalidationHandlers holds the subscribers registered per topic: shared handlers clear
+// Redis and run once, on the publishing instance; local handlers clear in-process copies
+// and run on every instance
+var invalidationHandlers = struct {
+	sync.RWMutex
+	byTopic map[string][]func(InvalidationEvent)
+	local   map[string][]func(InvalidationEvent)
+}{
+	byTopic: make(map[string][]func(InvalidationEvent)),
+	local:   make(map[string][]func(InvalidationEvent)),
+}
+
+// OnInvalidate registers a handler for a topic. Modules register at startup so that a
+// change published by one module clears the caches owned by another without the two
+// importing each other. The handler runs only on the instance that published the
+// change, which suits caches shared through Redis.
+func OnInvalidate(topic string, handler func(InvalidationEvent)) {
+	invalidationHandlers.Lock()
+	defer invalidationHandlers.Unlock()
+	invalidationHandlers.byTopic[topic] = append(invalidationHandlers.byTopic[topic], handler)
+}
+
+// OnLocalInvalidate registers a handler for data an instance keeps in its own memory.
+// It runs on every instance: directly on the publisher, and on the others when the event
+// arrives over Redis pub/sub.
+func OnLocalInvalidate(topic string, handler func(InvalidationEvent)) {
+	invalidationHandlers.Lock()
+	defer invalidationHandlers.Unlock()
+	invalidationHandlers.local[topic] = append(invalidationHandlers.local[topic], handler)
+}
+
+// Invalidate runs every handler registered for the event's topic, then relays the event
+// to the other instances. Handlers run synchronously so the caches are clear by the
+// time the caller responds; a panicking handler is logged and does not stop the others.
+func Invalidate(event InvalidationEvent) {
+	invalidationHandlers.RLock()
+	handlers := make([]func(InvalidationEvent), 0, len(invalidationHandlers.byTopic[event.Topic])+len(invalidationHandlers.local[event.Topic]))
+	handlers = append(handlers, invalidationHandlers.byTopic[event.Topic]...)
+	handlers = append(handlers, invalidationHandlers.local[event.Topic]...)
+	invalidationHandlers.RUnlock()
+	runInvalidationHandlers(event, handlers)
+
+	event.Origin = instanceID
+	if err := Publish(invalidationChannel, event); err != nil && RedisClient != nil {
+		slog.Warn("failed to relay invalidation to other instances", "topic", event.Topic, "error", err)
+	}
+}
+
+// InvalidateKeys deletes keys from Redis and tells every instance to drop its local
+// copies of them
+func InvalidateKeys(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	var err error
+	if RedisClient != nil {
+		ctx, cancel := opContext(context.Background())
+		defer cancel()
+		err = degrade("DEL", RedisClient.Del(ctx, keys...).Err())
+	}
+	Invalidate(InvalidationEvent{Topic: TopicKeys, Keys: keys})
+	return err
+}
+
+// StartInvalidationRelay applies invalidations published by other instances to this
+// instance's local handlers. Without Redis there are no other instances to hear from and
+// the relay exits. It blocks, so run it in a goroutine.
+func StartInvalidationRelay() {
+	sub := Subscribe(invalidationChannel)
+	if sub == nil {
+		slog.Info("cache invalidation relay disabled: Redis unavailable")
+		return
+	}
+	defer sub.Close()
+
+	slog.Info("cache invalidation relay started")
+
+	for msg := range sub.Channel() {
+		var event InvalidationEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			slog.Warn("invalid cache invalidation event", "error", err)
+			continue
+		}
+		if event.Origin == instanceID {
+			continue
+		}
+
+		invalidationHandlers.RLock()
+		handlers := invalidationHandlers.local[event.Topic]
+		invalidationHandlers.RUnlock()
+		runInvalidationHandlers(event, handlers)
+	}
+}
+
+// runInvalidationHandlers calls each handler with the event, recovering from panics
+func runInvalidationHandlers(event InvalidationEvent, handlers []func(InvalidationEvent)) {
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Warn("invalidation handler panicked", "topic", event.Topic, "panic", r)
+				}
+			}()
+			handler(event)
+		}()
+	}
+}
+
+// InvalidatePlotOwnership publishes a plot ownership change for the given wallets.
+// Empty addresses are ignored.
+func InvalidatePlotOwnership(wallets ...string) {
+	parties := make([]string, 0, len(wallets))
+	for _, wallet := range wallets {
+		if wallet = strings.TrimSpace(wallet); wallet != "" {
+			parties = append(parties, wallet)
+		}
+	}
+	Invalidate(InvalidationEvent{Topic: TopicPlotOwnership, Wallets: parties})
+}
+
+// DeletePattern removes every key matching a Redis glob pattern using SCAN
+func DeletePattern(ctx context.Context, pattern string) error {
+	if RedisClient == nil {
+		return nil
+	}
+
+	return scanKeys(ctx, pattern, 100, func(keys []string) error {
+		delCtx, cancel := opContext(ctx)
+		defer cancel()
+		return RedisClient.Del(delCtx, keys...).Err()
+	})
+}
+
+// Cache tags group keys by what they depend on, so a change can drop every related key
+// without knowing the formats of the keys that were written
+const (
+	// TagListings covers the marketplace listings of every chain and contract
+	TagListings = "listings"
+)
+
+// UserTag covers data cached for a wallet. Addresses are lower-cased since events and
+// JWTs may format the same wallet differently.
+func UserTag(address string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(address))
+}
+
+// FarmTag covers data derived from a farm's scans and readings
+func FarmTag(farmName string) string {
+	return "farm:" + farmName
+}
+
+// tagKey names the Redis set tracking the keys stored under a tag
+func tagKey(tag string) string {
+	return "cache_tag:" + tag
+}
+
+// SetWithTags stores a value like Set and records its key under each tag, so that
+// InvalidateTag can drop it without scanning the keyspace. A tag's set lives as long as
+// its longest-lived key.
+func SetWithTags(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	if RedisClient == nil {
+		return fmt.Errorf("redis client not available")
+	}
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return setTagged(key, jsonValue, expiration, tags)
+}
+
+// setTagged stores an encoded value and records its key under each tag in one transaction
+func setTagged(key string, value []byte, expiration time.Duration, tags []string) error {
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	pipe := RedisClient.TxPipeline()
+	pipe.Set(ctx, key, value, expiration)
+	for _, tag := range tags {
+		index := tagKey(tag)
+		pipe.SAdd(ctx, index, key)
+		pipe.ExpireNX(ctx, index, expiration)
+		pipe.ExpireGT(ctx, index, expiration)
+	}
+	_, err := pipe.Exec(ctx)
+	return degrade("SET", err)
+}
+
+// InvalidateTag removes every key stored under any of the tags, and the tags' sets
+func InvalidateTag(tags ...string) error {
+	if RedisClient == nil {
+		return nil
+	}
+
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	stale := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		index := tagKey(tag)
+		keys, err := RedisClient.SMembers(ctx, index).Result()
+		if err != nil {
+			return degrade("SMEMBERS", err)
+		}
+		stale = append(stale, keys...)
+		stale = append(stale, index)
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	if err := degrade("DEL", RedisClient.Del(ctx, stale...).Err()); err != nil {
+		return err
+	}
+	Invalidate(InvalidationEvent{Topic: TopicKeys, Keys: stale})
+	return nil
+}
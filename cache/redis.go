@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"time"
@@ -13,10 +13,14 @@ import (
 )
 
 var RedisClient *redis.Client
+
+// ctx is used for long-lived operations such as subscriptions, which must not time out
 var ctx = context.Background()
 
 // InitRedis initializes the Redis connection
 func InitRedis() {
+	opTimeout = loadOpTimeout()
+
 	addr := os.Getenv("REDIS_ADDR")
 	if addr == "" {
 		addr = "localhost:6379"
@@ -42,17 +46,23 @@ func InitRedis() {
 
 	_, err := RedisClient.Ping(ctx).Result()
 	if err != nil {
-		log.Printf("Warning: Failed to connect to Redis: %v", err)
-		log.Println("Server will continue without caching. Install and start Redis for optimal performance.")
+		slog.Warn("failed to connect to Redis", "error", err)
+		slog.Info("server will continue without caching; install and start Redis for optimal performance")
 		RedisClient = nil
 		return
 	}
 
-	log.Println("Connected to Redis successfully")
+	slog.Info("connected to Redis")
 }
 
 // Set stores a value in Redis with expiration
 func Set(key string, value interface{}, expiration time.Duration) error {
+	return SetContext(context.Background(), key, value, expiration)
+}
+
+// SetContext stores a value in Redis with expiration, giving up when ctx is done or the
+// operation timeout passes
+func SetContext(parent context.Context, key string, value interface{}, expiration time.Duration) error {
 	if RedisClient == nil {
 		return fmt.Errorf("redis client not available")
 	}
@@ -60,34 +70,118 @@ func Set(key string, value interface{}, expiration time.Duration) error {
 	if err != nil {
 		return err
 	}
-	return RedisClient.Set(ctx, key, jsonValue, expiration).Err()
+	ctx, cancel := opContext(parent)
+	defer cancel()
+	return degrade("SET", RedisClient.Set(ctx, key, jsonValue, expiration).Err())
 }
 
 // Get retrieves a value from Redis and unmarshals it
 func Get(key string, dest interface{}) error {
+	return GetContext(context.Background(), key, dest)
+}
+
+// GetContext retrieves a value from Redis and unmarshals it, whether it was stored as
+// JSON or with SetGob. A timed-out read returns an error, which callers treat as a miss.
+func GetContext(parent context.Context, key string, dest interface{}) error {
 	if RedisClient == nil {
 		return fmt.Errorf("redis client not available")
 	}
-	value, err := RedisClient.Get(ctx, key).Result()
+	ctx, cancel := opContext(parent)
+	defer cancel()
+	value, err := RedisClient.Get(ctx, key).Bytes()
 	if err != nil {
-		return err
+		return degrade("GET", err)
 	}
-	return json.Unmarshal([]byte(value), dest)
+	return decodeValue(value, dest)
 }
 
 // Delete removes a key from Redis
 func Delete(key string) error {
+	return DeleteContext(context.Background(), key)
+}
+
+// DeleteContext removes a key from Redis
+func DeleteContext(parent context.Context, key string) error {
 	if RedisClient == nil {
 		return fmt.Errorf("redis client not available")
 	}
-	return RedisClient.Del(ctx, key).Err()
+	ctx, cancel := opContext(parent)
+	defer cancel()
+	return degrade("DEL", RedisClient.Del(ctx, key).Err())
 }
 
 // Exists checks if a key exists in Redis
 func Exists(key string) bool {
+	return ExistsContext(context.Background(), key)
+}
+
+// ExistsContext checks if a key exists in Redis; a failed check reports false
+func ExistsContext(parent context.Context, key string) bool {
 	if RedisClient == nil {
 		return false
 	}
-	result, _ := RedisClient.Exists(ctx, key).Result()
+	ctx, cancel := opContext(parent)
+	defer cancel()
+	result, err := RedisClient.Exists(ctx, key).Result()
+	degrade("EXISTS", err)
 	return result > 0
 }
+
+// SetNX stores a value only if the key does not already exist.
+// It returns true when the value was stored.
+func SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return SetNXContext(context.Background(), key, value, expiration)
+}
+
+// SetNXContext stores a value only if the key does not already exist.
+// It returns true when the value was stored.
+func SetNXContext(parent context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	if RedisClient == nil {
+		return false, fmt.Errorf("redis client not available")
+	}
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := opContext(parent)
+	defer cancel()
+	stored, err := RedisClient.SetNX(ctx, key, jsonValue, expiration).Result()
+	return stored, degrade("SETNX", err)
+}
+
+// Publish sends a JSON-encoded message to a Redis pub/sub channel
+func Publish(channel string, value interface{}) error {
+	if RedisClient == nil {
+		return fmt.Errorf("redis client not available")
+	}
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	return degrade("PUBLISH", RedisClient.Publish(ctx, channel, jsonValue).Err())
+}
+
+// Subscribe opens a Redis pub/sub subscription. It returns nil when Redis is unavailable;
+// callers must Close the subscription when done.
+func Subscribe(channels ...string) *redis.PubSub {
+	if RedisClient == nil {
+		return nil
+	}
+	return RedisClient.Subscribe(ctx, channels...)
+}
+
+// Enqueue appends a JSON-encoded job to a Redis list used as a work queue
+func Enqueue(queue string, value interface{}) error {
+	if RedisClient == nil {
+		return fmt.Errorf("redis client not available")
+	}
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	return degrade("LPUSH", RedisClient.LPush(ctx, queue, jsonValue).Err())
+}
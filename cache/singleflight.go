@@ -0,0 +1,15 @@
+package cache
+
+import "golang.org/x/sync/singleflight"
+
+// loads coalesces concurrent fetches of the same missing key
+var loads singleflight.Group
+
+// Load runs fetch for a cache miss on key, making concurrent callers that miss the same
+// key wait for that one fetch and share its result instead of each refetching. fetch is
+// expected to store what it loads so later callers hit the cache. The shared value is
+// returned to every waiter, so callers must copy it before modifying it.
+func Load(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	value, err, _ := loads.Do(key, fetch)
+	return value, err
+}
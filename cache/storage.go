@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// Storage adapts Redis to fiber.Storage under a key prefix, so middleware state such as
+// rate limit counters is shared by every instance instead of living in one process
+type Storage struct {
+	prefix string
+}
+
+// NewStorage returns Redis-backed storage for Fiber middleware, or nil when Redis is
+// unavailable so the middleware falls back to its in-memory store
+func NewStorage(prefix string) fiber.Storage {
+	if RedisClient == nil {
+		return nil
+	}
+	return &Storage{prefix: prefix}
+}
+
+// Get returns the value for key, or nil when it does not exist
+func (s *Storage) Get(key string) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	value, err := RedisClient.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return value, degrade("GET", err)
+}
+
+// Set stores value for key; a zero expiration keeps it until deleted
+func (s *Storage) Set(key string, value []byte, expiration time.Duration) error {
+	if len(key) == 0 || len(value) == 0 {
+		return nil
+	}
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	return degrade("SET", RedisClient.Set(ctx, s.prefix+key, value, expiration).Err())
+}
+
+// Delete removes key
+func (s *Storage) Delete(key string) error {
+	if len(key) == 0 {
+		return nil
+	}
+	ctx, cancel := opContext(context.Background())
+	defer cancel()
+	return degrade("DEL", RedisClient.Del(ctx, s.prefix+key).Err())
+}
+
+// Reset removes every key under the storage's prefix
+func (s *Storage) Reset() error {
+	return DeletePattern(context.Background(), s.prefix+"*")
+}
+
+// Close is a no-op; the shared Redis client outlives the storage
+func (s *Storage) Close() error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultOpTimeout bounds a single cache operation unless CACHE_OP_TIMEOUT overrides it
+const defaultOpTimeout = 500 * time.Millisecond
+
+// degradedLogInterval limits how often cache timeouts are logged while Redis is slow
+const degradedLogInterval = 10 * time.Second
+
+// opTimeout is the per-operation deadline, set from CACHE_OP_TIMEOUT by InitRedis
+var opTimeout = defaultOpTimeout
+
+// lastDegradedLog is when a cache timeout was last logged, in Unix nanoseconds
+var lastDegradedLog atomic.Int64
+
+// loadOpTimeout reads CACHE_OP_TIMEOUT, defaulting to 500ms
+func loadOpTimeout() time.Duration {
+	if v := os.Getenv("CACHE_OP_TIMEOUT"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultOpTimeout
+}
+
+// opContext derives the context for one cache operation: it ends with the caller's
+// context or after the operation timeout, whichever comes first. A stalled Redis then
+// costs a request at most the timeout instead of blocking it.
+func opContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	return context.WithTimeout(parent, opTimeout)
+}
+
+// degrade passes err through, logging timeouts and cancellations at most once per
+// interval. Callers already treat cache errors as misses, so a slow Redis degrades to
+// uncached responses rather than failed ones.
+func degrade(op string, err error) error {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		now := time.Now().UnixNano()
+		last := lastDegradedLog.Load()
+		if now-last >= int64(degradedLogInterval) && lastDegradedLog.CompareAndSwap(last, now) {
+			slog.Warn("cache operation timed out, serving without cache", "op", op, "timeout", opTimeout, "error", err)
+		}
+	}
+	return err
+}
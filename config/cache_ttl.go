@@ -0,0 +1,130 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache TTL classes. Each class covers one kind of cached data and can be overridden
+// with CACHE_TTL_<CLASS>, e.g. CACHE_TTL_LISTINGS=2m.
+const (
+	TTLPortfolio         = "portfolio"          // Portfolio summary, P&L, and allocation
+	TTLPortfolioFull     = "portfolio_full"     // Complete portfolio with images
+	TTLPortfolioPartial  = "portfolio_partial"  // Complete portfolio with a failed contract
+	TTLPortfolioNFTs     = "portfolio_nfts"     // Owned NFTs
+	TTLPortfolioActivity = "portfolio_activity" // Wallet activity feed
+	TTLPortfolioHistory  = "portfolio_history"  // Portfolio value history
+	TTLListings          = "listings"           // Marketplace listings
+	TTLListingFlags      = "listing_flags"      // Hidden listing and verified farm sets
+	TTLPlatformFees      = "platform_fees"      // Marketplace platform fee
+	TTLImages            = "images"             // Original image bytes
+	TTLImageVariants     = "image_variants"     // Resized images and WebP thumbnails
+	TTLFarmList          = "farm_list"          // Farm list
+	TTLFarmScans         = "farm_scans"         // Farm scan pages and reading series
+	TTLFarmHealth        = "farm_health"        // Farm health scores for yield projections
+	TTLIrrigation        = "irrigation"         // Irrigation plans
+	TTLWeather           = "weather"            // Weather forecasts
+	TTLOutbreaks         = "outbreaks"          // Outbreak heatmaps
+	TTLRecommendations   = "recommendations"    // Graph recommendation scores
+	TTLPriceHistory      = "price_history"      // Token price history
+	TTLFxRates           = "fx_rates"           // Fiat exchange rates
+)
+
+// cacheTTLDefaults holds each class's TTL when no override is set
+var cacheTTLDefaults = map[string]time.Duration{
+	TTLPortfolio:         3 * time.Minute,
+	TTLPortfolioFull:     5 * time.Minute,
+	TTLPortfolioPartial:  30 * time.Second,
+	TTLPortfolioNFTs:     5 * time.Minute,
+	TTLPortfolioActivity: 1 * time.Minute,
+	TTLPortfolioHistory:  10 * time.Minute,
+	TTLListings:          5 * time.Minute,
+	TTLListingFlags:      5 * time.Minute,
+	TTLPlatformFees:      1 * time.Hour,
+	TTLImages:            1 * time.Hour,
+	TTLImageVariants:     24 * time.Hour,
+	TTLFarmList:          5 * time.Minute,
+	TTLFarmScans:         5 * time.Minute,
+	TTLFarmHealth:        30 * time.Minute,
+	TTLIrrigation:        30 * time.Minute,
+	TTLWeather:           30 * time.Minute,
+	TTLOutbreaks:         10 * time.Minute,
+	TTLRecommendations:   10 * time.Minute,
+	TTLPriceHistory:      5 * time.Minute,
+	TTLFxRates:           1 * time.Hour,
+}
+
+// CacheTTLEntry describes one class of the effective TTL policy
+type CacheTTLEntry struct {
+	Class      string `json:"class"`
+	TTL        string `json:"ttl"`
+	Default    string `json:"default"`
+	EnvVar     string `json:"envVar"`
+	Overridden bool   `json:"overridden"`
+}
+
+// cacheTTLs is the effective policy, resolved from the environment on first use
+var cacheTTLs struct {
+	once     sync.Once
+	byClass  map[string]time.Duration
+	override map[string]bool
+}
+
+// CacheTTL returns how long data of a TTL class is cached
+func CacheTTL(class string) time.Duration {
+	loadCacheTTLs()
+	if ttl, ok := cacheTTLs.byClass[class]; ok {
+		return ttl
+	}
+	slog.Warn("unknown cache TTL class, caching for 1 minute", "class", class)
+	return time.Minute
+}
+
+// CacheTTLPolicy lists the effective TTL of every class, by class name
+func CacheTTLPolicy() []CacheTTLEntry {
+	loadCacheTTLs()
+	entries := make([]CacheTTLEntry, 0, len(cacheTTLDefaults))
+	for class, def := range cacheTTLDefaults {
+		entries = append(entries, CacheTTLEntry{
+			Class:      class,
+			TTL:        cacheTTLs.byClass[class].String(),
+			Default:    def.String(),
+			EnvVar:     cacheTTLEnvVar(class),
+			Overridden: cacheTTLs.override[class],
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Class < entries[j].Class })
+	return entries
+}
+
+// loadCacheTTLs applies CACHE_TTL_<CLASS> overrides to the defaults, once
+func loadCacheTTLs() {
+	cacheTTLs.once.Do(func() {
+		cacheTTLs.byClass = make(map[string]time.Duration, len(cacheTTLDefaults))
+		cacheTTLs.override = make(map[string]bool)
+		for class, def := range cacheTTLDefaults {
+			cacheTTLs.byClass[class] = def
+			env := cacheTTLEnvVar(class)
+			v := os.Getenv(env)
+			if v == "" {
+				continue
+			}
+			ttl, err := time.ParseDuration(v)
+			if err != nil || ttl < time.Second {
+				slog.Warn("ignoring cache TTL override: must be a duration of at least 1s", "env", env, "value", v)
+				continue
+			}
+			cacheTTLs.byClass[class] = ttl
+			cacheTTLs.override[class] = true
+		}
+	})
+}
+
+// cacheTTLEnvVar names the variable overriding a class's TTL
+func cacheTTLEnvVar(class string) string {
+	return "CACHE_TTL_" + strings.ToUpper(class)
+}
@@ -6,6 +6,7 @@ const (
 	FarmPlotContractAddress = "0xF2F3ca589d7d2e7b73Bf6461c5028b4f382f1655"
 	AdminWallet = "0xE37D4e372c004ff76c1415d3C711B7dD1BbCCCeB"
 	DAGRIContractAddress = "0xC16512472E334DbB7D896ee09bBe70fa4464F90E"
+	USDCContractAddress = "0x75faf114eafb1BDbe2F0316DF893fd58CE46AA4d"
 	TreasuryWallet = "0x984785A89BF95cb3d5Df4E45F670081944d8D547"
 	MarketPlaceContractAddress = "0x3F32B096D519dd29bdbea154387784c373f89687"
 	EngineCloudBaseURL = "https://engine.decentragri.com"
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Memgraph driver pool defaults, matching the driver's own
+const (
+	defaultMemgraphMaxConnections     = 100
+	defaultMemgraphAcquisitionTimeout = time.Minute
+)
+
+// MemgraphPoolConfig sizes the Memgraph driver's connection pool
+type MemgraphPoolConfig struct {
+	// MaxConnections caps open connections to the database (MEMGRAPH_MAX_CONNECTIONS)
+	MaxConnections int
+	// AcquisitionTimeout bounds the wait for a free connection when the pool is
+	// exhausted (MEMGRAPH_ACQUISITION_TIMEOUT)
+	AcquisitionTimeout time.Duration
+}
+
+// MemgraphPool reads the pool settings from the environment, falling back to the
+// defaults for unset or invalid values
+func MemgraphPool() MemgraphPoolConfig {
+	pool := MemgraphPoolConfig{
+		MaxConnections:     defaultMemgraphMaxConnections,
+		AcquisitionTimeout: defaultMemgraphAcquisitionTimeout,
+	}
+	if v := os.Getenv("MEMGRAPH_MAX_CONNECTIONS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			pool.MaxConnections = parsed
+		}
+	}
+	if v := os.Getenv("MEMGRAPH_ACQUISITION_TIMEOUT"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			pool.AcquisitionTimeout = parsed
+		}
+	}
+	return pool
+}
@@ -0,0 +1,16 @@
+package config
+
+import "os"
+
+// DevBypassToken is the placeholder token handed to services for requests authenticated
+// through the dev bypass instead of a JWT
+const DevBypassToken = "dev_bypass_authorized"
+
+// DevBypassWallet returns the wallet that dev bypass requests act as. It defaults to the
+// treasury wallet and can be changed per environment with DEV_BYPASS_WALLET.
+func DevBypassWallet() string {
+	if wallet := os.Getenv("DEV_BYPASS_WALLET"); wallet != "" {
+		return wallet
+	}
+	return TreasuryWallet
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// defaultServiceName names this service in traces unless OTEL_SERVICE_NAME overrides it
+const defaultServiceName = "decentragri-app-cx-server"
+
+// InitTracing installs an OpenTelemetry tracer provider that exports spans over OTLP/HTTP
+// when OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set. The
+// exporter, sampler, and resource read the other standard OTEL_* variables. Without an
+// endpoint spans are not recorded. The returned function flushes pending spans.
+func InitTracing() func(context.Context) error {
+	noop := func(context.Context) error { return nil }
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		slog.Info("tracing disabled: OTEL_EXPORTER_OTLP_ENDPOINT not set")
+		return noop
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		slog.Warn("tracing disabled: failed to create OTLP exporter", "error", err)
+		return noop
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", defaultServiceName)),
+		resource.WithFromEnv(), // OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES win
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		slog.Warn("incomplete tracing resource", "error", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	slog.Info("tracing enabled: exporting spans over OTLP")
+	return provider.Shutdown
+}
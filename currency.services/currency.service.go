@@ -0,0 +1,231 @@
+// Package currencyservices provides fiat currency conversion for the Decentragri platform.
+// Token prices are quoted in USD by the price provider; this package converts those
+// values into the user's preferred fiat currency using cached FX rates.
+//
+// The service supports:
+//   - FX rate fetching from a configurable provider (FX_RATES_API_URL)
+//   - Redis caching of FX rates to avoid hitting the provider on every request
+//   - Per-user preferred currency stored on the (:User) node
+package currencyservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultCurrency is used when neither the request nor the user specifies a currency
+const DefaultCurrency = "USD"
+
+// fxRatesCacheKey is the Redis key holding the latest USD-based FX rates
+const fxRatesCacheKey = "fx_rates:USD"
+
+// SupportedCurrencies lists the fiat currencies that can be used for valuation
+var SupportedCurrencies = map[string]string{
+	"USD": "US Dollar",
+	"EUR": "Euro",
+	"PHP": "Philippine Peso",
+	"GBP": "British Pound",
+	"JPY": "Japanese Yen",
+	"SGD": "Singapore Dollar",
+	"AUD": "Australian Dollar",
+	"CAD": "Canadian Dollar",
+	"IDR": "Indonesian Rupiah",
+	"INR": "Indian Rupee",
+	"KRW": "South Korean Won",
+}
+
+// NormalizeCurrency upper-cases and validates a currency code.
+// An empty code is returned unchanged so callers can fall back to a default.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return "", nil
+	}
+	if _, ok := SupportedCurrencies[code]; !ok {
+		return "", fmt.Errorf("unsupported currency: %s", code)
+	}
+	return code, nil
+}
+
+// GetFXRates returns USD-based exchange rates, served from cache when available
+func GetFXRates() (*FXRates, error) {
+	var cached FXRates
+	if cache.Exists(fxRatesCacheKey) {
+		if err := cache.Get(fxRatesCacheKey, &cached); err == nil && len(cached.Rates) > 0 {
+			return &cached, nil
+		}
+	}
+
+	url := os.Getenv("FX_RATES_API_URL")
+	if url == "" {
+		url = "https://open.er-api.com/v6/latest/USD"
+	}
+
+	req := fiber.Get(url)
+	status, body, errs := req.Bytes()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to fetch FX rates: %v", errs[0])
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("FX rate request failed with status %d: %s", status, string(body))
+	}
+
+	var apiResp exchangeRateAPIResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode FX rates: %w", err)
+	}
+	if len(apiResp.Rates) == 0 {
+		return nil, fmt.Errorf("no FX rates returned by provider")
+	}
+
+	rates := &FXRates{
+		Base:      DefaultCurrency,
+		Rates:     make(map[string]float64, len(SupportedCurrencies)),
+		FetchedAt: time.Now().Unix(),
+	}
+	for code := range SupportedCurrencies {
+		if rate, ok := apiResp.Rates[code]; ok {
+			rates.Rates[code] = rate
+		}
+	}
+	rates.Rates[DefaultCurrency] = 1
+
+	// Cache FX rates for 1 hour; providers only refresh a few times per day
+	cache.Set(fxRatesCacheKey, rates, config.CacheTTL(config.TTLFxRates))
+
+	return rates, nil
+}
+
+// GetRate returns the USD -> currency exchange rate
+func GetRate(currency string) (float64, error) {
+	if currency == "" || currency == DefaultCurrency {
+		return 1, nil
+	}
+
+	rates, err := GetFXRates()
+	if err != nil {
+		return 0, err
+	}
+
+	rate, ok := rates.Rates[currency]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no FX rate available for %s", currency)
+	}
+	return rate, nil
+}
+
+// ConvertFromUSD converts a USD amount into the given currency
+func ConvertFromUSD(amountUSD float64, currency string) (FiatAmount, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	rate, err := GetRate(currency)
+	if err != nil {
+		return FiatAmount{}, err
+	}
+
+	return FiatAmount{
+		Currency: currency,
+		Amount:   amountUSD * rate,
+		Rate:     rate,
+	}, nil
+}
+
+// GetUserCurrency reads the preferred currency stored on the user's node
+func GetUserCurrency(username string) (string, error) {
+	query := `MATCH (u:User {username: $username}) RETURN u.preferredCurrency AS preferredCurrency`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"username": username})
+	if err != nil {
+		return "", fmt.Errorf("failed to read currency preference: %w", err)
+	}
+	if len(records) == 0 {
+		return DefaultCurrency, nil
+	}
+
+	if val, ok := records[0].Get("preferredCurrency"); ok {
+		if s, ok := val.(string); ok && s != "" {
+			return s, nil
+		}
+	}
+	return DefaultCurrency, nil
+}
+
+// ResolveCurrency picks the currency for a request: explicit parameter first,
+// then the user's stored preference, then USD.
+func ResolveCurrency(requested, username string) (string, error) {
+	currency, err := NormalizeCurrency(requested)
+	if err != nil {
+		return "", err
+	}
+	if currency != "" {
+		return currency, nil
+	}
+
+	if username == "" {
+		return DefaultCurrency, nil
+	}
+
+	preferred, err := GetUserCurrency(username)
+	if err != nil {
+		// Preference lookup failures should not block valuation
+		return DefaultCurrency, nil
+	}
+	return preferred, nil
+}
+
+// GetPreferredCurrency returns the authenticated user's currency preference
+func GetPreferredCurrency(token string) (*CurrencyPreference, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired token: %w", err)
+	}
+
+	currency, err := GetUserCurrency(username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CurrencyPreference{
+		Currency:  currency,
+		Supported: SupportedCurrencies,
+	}, nil
+}
+
+// SetPreferredCurrency stores the authenticated user's currency preference on the User node
+func SetPreferredCurrency(token, currency string) (*CurrencyPreference, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired token: %w", err)
+	}
+
+	currency, err = NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if currency == "" {
+		return nil, fmt.Errorf("currency is required")
+	}
+
+	query := `MATCH (u:User {username: $username})
+		SET u.preferredCurrency = $currency, u.updatedAt = timestamp()`
+	params := map[string]any{"username": username, "currency": currency}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return nil, fmt.Errorf("failed to save currency preference: %w", err)
+	}
+
+	return &CurrencyPreference{
+		Currency:  currency,
+		Supported: SupportedCurrencies,
+	}, nil
+}
@@ -0,0 +1,34 @@
+package currencyservices
+
+// FXRates holds exchange rates relative to a base currency (always USD)
+type FXRates struct {
+	Base      string             `json:"base"`      // Base currency the rates are quoted against
+	Rates     map[string]float64 `json:"rates"`     // Currency code -> units per 1 base
+	FetchedAt int64              `json:"fetchedAt"` // Unix timestamp of when rates were fetched
+}
+
+// FiatAmount represents a value expressed in a fiat currency
+type FiatAmount struct {
+	Currency string  `json:"currency"` // ISO 4217 currency code
+	Amount   float64 `json:"amount"`   // Value in the given currency
+	Rate     float64 `json:"rate"`     // USD -> currency rate used for conversion
+}
+
+// CurrencyPreference represents a user's display currency preference
+type CurrencyPreference struct {
+	Currency  string            `json:"currency"`
+	Supported map[string]string `json:"supported"`
+}
+
+// SetCurrencyRequest represents the request payload for updating the preferred currency
+type SetCurrencyRequest struct {
+	Currency string `json:"currency"`
+}
+
+// exchangeRateAPIResponse represents the response from the FX rate provider
+type exchangeRateAPIResponse struct {
+	Result             string             `json:"result"`
+	BaseCode           string             `json:"base_code"`
+	TimeLastUpdateUnix int64              `json:"time_last_update_unix"`
+	Rates              map[string]float64 `json:"rates"`
+}
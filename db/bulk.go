@@ -0,0 +1,59 @@
+package memgraph
+
+import (
+	"context"
+	"fmt"
+)
+
+// bulkBatchSize is how many rows one BulkWrite statement unwinds
+const bulkBatchSize = 1000
+
+// BulkSummary totals the changes of every batch a BulkWrite ran
+type BulkSummary struct {
+	Rows                 int
+	Batches              int
+	NodesCreated         int
+	NodesDeleted         int
+	RelationshipsCreated int
+	RelationshipsDeleted int
+	PropertiesSet        int
+}
+
+// BulkWrite runs statement once per entry of rows as a single UNWIND write instead of
+// one write per row. See BulkWriteContext.
+func BulkWrite(statement string, rows []map[string]any, params map[string]any) (BulkSummary, error) {
+	return BulkWriteContext(context.Background(), statement, rows, params)
+}
+
+// BulkWriteContext prefixes statement with `UNWIND $rows AS row`, so it refers to the
+// current entry as row and to params as usual ($rows is reserved). Rows are written in
+// batches of bulkBatchSize, each its own transaction: an error stops the run and the
+// summary covers the batches already committed.
+func BulkWriteContext(ctx context.Context, statement string, rows []map[string]any, params map[string]any) (BulkSummary, error) {
+	var total BulkSummary
+	query := "UNWIND $rows AS row\n" + statement
+	for start := 0; start < len(rows); start += bulkBatchSize {
+		batch := rows[start:min(start+bulkBatchSize, len(rows))]
+		batchParams := make(map[string]any, len(params)+1)
+		for k, v := range params {
+			batchParams[k] = v
+		}
+		batchParams["rows"] = batch
+
+		summary, err := ExecuteWriteContext(ctx, query, batchParams)
+		if err != nil {
+			return total, fmt.Errorf("bulk write failed after %d of %d rows: %w", total.Rows, len(rows), err)
+		}
+		total.Rows += len(batch)
+		total.Batches++
+		if summary != nil {
+			counters := summary.Counters()
+			total.NodesCreated += counters.NodesCreated()
+			total.NodesDeleted += counters.NodesDeleted()
+			total.RelationshipsCreated += counters.RelationshipsCreated()
+			total.RelationshipsDeleted += counters.RelationshipsDeleted()
+			total.PropertiesSet += counters.PropertiesSet()
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,118 @@
+package memgraph
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ErrInvalidCursor is returned for a pagination cursor that cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position after the last item of a page: its sort value (a timestamp
+// string or number) and its ID, which breaks ties between items with the same time
+type Cursor struct {
+	At any    `json:"a"`
+	ID string `json:"i"`
+}
+
+// EncodeCursor packs a cursor, or any JSON value made of cursors, into an opaque token
+func EncodeCursor(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor unpacks a token made by EncodeCursor into v. Integer sort values come
+// back as int64 so they compare equal to the stored values.
+func DecodeCursor(token string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// Keyset pages a query newest first by Sort, tie-broken by ID, without SKIP: each page
+// starts strictly after the previous page's last item, so deep pages cost the same as
+// the first and items written meanwhile neither repeat nor go missing. Sort and ID are
+// Cypher expressions over the query's variables, and Name prefixes the parameters so
+// one query can page several streams.
+type Keyset struct {
+	Name string
+	Sort string
+	ID   string
+}
+
+// Where is the condition selecting items after the cursor; it holds for every item when
+// the cursor parameter is null
+func (k Keyset) Where() string {
+	at, id := "$"+k.Name+"CursorAt", "$"+k.Name+"CursorId"
+	return fmt.Sprintf("(%s IS NULL OR %s < %s OR (%s = %s AND %s < %s))",
+		at, k.Sort, at, k.Sort, at, k.ID, id)
+}
+
+// OrderBy orders items newest first with the ID as tie-breaker
+func (k Keyset) OrderBy() string {
+	return fmt.Sprintf("ORDER BY %s DESC, %s DESC", k.Sort, k.ID)
+}
+
+// Return projects the columns Page reads the next cursor from
+func (k Keyset) Return() string {
+	return fmt.Sprintf("%s AS %sCursorAt, %s AS %sCursorId", k.Sort, k.Name, k.ID, k.Name)
+}
+
+// ReturnOrderBy orders the final rows by the columns of Return, for queries whose later
+// clauses may not keep the order of OrderBy
+func (k Keyset) ReturnOrderBy() string {
+	return fmt.Sprintf("ORDER BY %sCursorAt DESC, %sCursorId DESC", k.Name, k.Name)
+}
+
+// Limit is the LIMIT expression: one item more than the page, to tell whether another
+// page follows
+func (k Keyset) Limit() string {
+	return "$" + k.Name + "Limit"
+}
+
+// Params adds the cursor and limit parameters for a page of limit items after cursor,
+// which is nil for the first page
+func (k Keyset) Params(params map[string]any, cursor *Cursor, limit int) {
+	params[k.Name+"CursorAt"] = nil
+	params[k.Name+"CursorId"] = nil
+	if cursor != nil {
+		at := cursor.At
+		if n, ok := at.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				at = i
+			} else if f, err := n.Float64(); err == nil {
+				at = f
+			}
+		}
+		params[k.Name+"CursorAt"] = at
+		params[k.Name+"CursorId"] = cursor.ID
+	}
+	params[k.Name+"Limit"] = limit + 1
+}
+
+// Page trims the extra item fetched by Limit and returns the page with the cursor of its
+// last item, or nil when no items follow
+func (k Keyset) Page(records []*neo4j.Record, limit int) ([]*neo4j.Record, *Cursor) {
+	if len(records) <= limit {
+		return records, nil
+	}
+	records = records[:limit]
+	last := records[len(records)-1]
+	at, _ := last.Get(k.Name + "CursorAt")
+	return records, &Cursor{At: at, ID: String(last, k.Name+"CursorId")}
+}
@@ -0,0 +1,102 @@
+package memgraph
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"decentragri-app-cx-server/config"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// healthCheckTimeout bounds the health probe's round trip
+const healthCheckTimeout = 2 * time.Second
+
+// pool holds the pool settings the driver was created with
+var pool config.MemgraphPoolConfig
+
+// sessionsInUse counts sessions running a query. Each holds at most one pooled
+// connection, which the driver does not report itself.
+var sessionsInUse atomic.Int64
+
+// PoolStats describes connection pool usage
+type PoolStats struct {
+	InUse              int64   `json:"inUse"`
+	MaxConnections     int     `json:"maxConnections"`
+	Utilization        float64 `json:"utilization"` // Percent of MaxConnections in use
+	AcquisitionTimeout string  `json:"acquisitionTimeout"`
+}
+
+// Health is the database's status for operators and the readiness probe
+type Health struct {
+	Status    string    `json:"status"` // ok or unavailable
+	Connected bool      `json:"connected"`
+	LatencyMs float64   `json:"latencyMs"` // Round trip of a trivial query
+	Pool      PoolStats `json:"pool"`
+	Error     string    `json:"error,omitempty"`
+	Replica   *Probe    `json:"replica,omitempty"` // Set when MEMGRAPH_READ_URI is configured
+}
+
+// Probe is the result of one round trip to a database instance
+type Probe struct {
+	Connected bool    `json:"connected"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// trackSession counts a session as in use until the returned func is called
+func trackSession() func() {
+	sessionsInUse.Add(1)
+	return func() { sessionsInUse.Add(-1) }
+}
+
+// Stats returns the current pool usage
+func Stats() PoolStats {
+	inUse := sessionsInUse.Load()
+	stats := PoolStats{
+		InUse:              inUse,
+		MaxConnections:     pool.MaxConnections,
+		AcquisitionTimeout: pool.AcquisitionTimeout.String(),
+	}
+	if pool.MaxConnections > 0 {
+		stats.Utilization = math.Round(float64(inUse)/float64(pool.MaxConnections)*1000) / 10
+	}
+	return stats
+}
+
+// CheckHealth runs a trivial query to measure the round trip to Memgraph
+func CheckHealth(parent context.Context) Health {
+	ctx, cancel := context.WithTimeout(parent, healthCheckTimeout)
+	defer cancel()
+
+	health := Health{Status: "ok", Connected: true, Pool: Stats()}
+	if GetDriver() == nil {
+		health.Status, health.Connected, health.Error = "unavailable", false, "driver not initialized"
+		return health
+	}
+
+	primary := probe(ctx, GetDriver())
+	health.LatencyMs = primary.LatencyMs
+	if !primary.Connected {
+		health.Status, health.Connected, health.Error = "unavailable", false, primary.Error
+	}
+	// A down replica is reported but does not fail the check: reads fall back to the primary
+	if readDriver != nil {
+		replica := probe(ctx, readDriver)
+		health.Replica = &replica
+	}
+	return health
+}
+
+// probe times a trivial read on d
+func probe(ctx context.Context, d neo4j.DriverWithContext) Probe {
+	start := time.Now()
+	_, err := executeRead(d, ctx, "RETURN 1", nil)
+	result := Probe{Connected: err == nil, LatencyMs: math.Round(float64(time.Since(start).Microseconds())/10) / 100}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -3,8 +3,11 @@ package memgraph
 import (
 	"context"
 	"log"
+	"log/slog"
+	"time"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 
+	"decentragri-app-cx-server/config"
 	"decentragri-app-cx-server/utils"
 )
 
@@ -19,41 +22,73 @@ func InitMemGraph() neo4j.DriverWithContext {
 	password := utils.GetEnv("MEMGRAPH_PASSWORD")
 
 	auth := neo4j.BasicAuth(username, password, "")
-	d, err := neo4j.NewDriverWithContext(uri, auth, func(config *neo4j.Config) {
-		config.Log = nil // You can set a logger here if needed
-	})
+	pool = config.MemgraphPool()
+	d, err := newDriver(uri, auth)
 	if err != nil {
 		panic(err)
 	}
 
 	driver = d
+	queryTimeout = loadQueryTimeout()
+	slowQueryThreshold = loadSlowQueryThreshold()
 
-	log.Println("Memgraph Initialized!")
+	slog.Info("Memgraph initialized", "maxConnections", pool.MaxConnections, "acquisitionTimeout", pool.AcquisitionTimeout)
 
 	if err := driver.VerifyConnectivity(context.Background()); err != nil {
 		log.Fatalf("Failed to connect to Memgraph: %s", err)
 	}
 
+	initReplica(auth)
+
 	return driver
 }
 
+// newDriver creates a driver with the configured pool settings. A neo4j:// URI routes
+// sessions by access mode across a cluster; bolt:// talks to one instance.
+func newDriver(uri string, auth neo4j.AuthToken) (neo4j.DriverWithContext, error) {
+	return neo4j.NewDriverWithContext(uri, auth, func(config *neo4j.Config) {
+		config.Log = nil // You can set a logger here if needed
+		config.MaxConnectionPoolSize = pool.MaxConnections
+		config.ConnectionAcquisitionTimeout = pool.AcquisitionTimeout
+	})
+}
+
 // GetDriver returns the initialized Neo4j driver.
 func GetDriver() neo4j.DriverWithContext {
 	return driver
 }
 
-// CloseDriver closes the Neo4j driver if it's open.
+// CloseDriver closes the Neo4j drivers if they're open.
 func CloseDriver() {
 	if driver != nil {
 		defer driver.Close(context.Background())
 	}
+	if readDriver != nil {
+		defer readDriver.Close(context.Background())
+	}
 }
 
 // ExecuteRead is a utility to run a Cypher read query and return all records.
 func ExecuteRead(query string, params map[string]interface{}) ([]*neo4j.Record, error) {
-	ctx := context.Background()
-	session := GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
+	return ExecuteReadContext(context.Background(), query, params)
+}
+
+// ExecuteReadContext runs a Cypher read query bounded by ctx and the query timeout.
+// Pass the request context so the query stops when the request ends. It reads from
+// the primary, so it sees the caller's own writes; see ExecuteReplicaReadContext.
+func ExecuteReadContext(parent context.Context, query string, params map[string]interface{}) ([]*neo4j.Record, error) {
+	return executeRead(GetDriver(), parent, query, params)
+}
+
+// executeRead runs a read query in a read-mode session on d
+func executeRead(d neo4j.DriverWithContext, parent context.Context, query string, params map[string]interface{}) ([]*neo4j.Record, error) {
+	start := time.Now()
+	parent, span := startQuerySpan(parent, "read", d != driver)
+	ctx, cancel := queryContext(parent)
+	defer cancel()
+	defer trackSession()()
+	session := d.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(context.Background())
 
 	recordsAny, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
 		result, err := tx.Run(ctx, query, params)
@@ -67,16 +102,29 @@ func ExecuteRead(query string, params map[string]interface{}) ([]*neo4j.Record,
 		return records, nil
 	})
 	if err != nil {
-		return nil, err
+		observeQuery(span, "read", query, time.Since(start), 0, err)
+		return nil, queryError(ctx, parent, err)
 	}
-	return recordsAny.([]*neo4j.Record), nil
+	records := recordsAny.([]*neo4j.Record)
+	observeQuery(span, "read", query, time.Since(start), len(records), nil)
+	return records, nil
 }
 
 // ExecuteWrite is a utility to run a Cypher write query and return the summary or error.
 func ExecuteWrite(query string, params map[string]interface{}) (neo4j.ResultSummary, error) {
-	ctx := context.Background()
-	session := GetDriver().NewSession(ctx, neo4j.SessionConfig{})
-	defer session.Close(ctx)
+	return ExecuteWriteContext(context.Background(), query, params)
+}
+
+// ExecuteWriteContext runs a Cypher write query bounded by ctx and the query timeout.
+// A write cut short is rolled back.
+func ExecuteWriteContext(parent context.Context, query string, params map[string]interface{}) (neo4j.ResultSummary, error) {
+	start := time.Now()
+	parent, span := startQuerySpan(parent, "write", false)
+	ctx, cancel := queryContext(parent)
+	defer cancel()
+	defer trackSession()()
+	session := GetDriver().NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(context.Background())
 
 	summaryAny, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
 		result, err := tx.Run(ctx, query, params)
@@ -90,7 +138,28 @@ func ExecuteWrite(query string, params map[string]interface{}) (neo4j.ResultSumm
 		return summary, nil
 	})
 	if err != nil {
-		return nil, err
+		observeQuery(span, "write", query, time.Since(start), 0, err)
+		return nil, queryError(ctx, parent, err)
+	}
+	summary := summaryAny.(neo4j.ResultSummary)
+	observeQuery(span, "write", query, time.Since(start), changedEntities(summary), nil)
+	return summary, nil
+}
+
+// ExecuteSchema runs a schema statement (index or constraint) in an auto-commit
+// transaction, since Memgraph rejects schema changes inside explicit transactions.
+func ExecuteSchema(query string) error {
+	ctx := context.Background()
+	defer trackSession()()
+	session := GetDriver().NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	start := time.Now()
+	_, span := startQuerySpan(ctx, "schema", false)
+	result, err := session.Run(ctx, query, nil)
+	if err == nil {
+		_, err = result.Consume(ctx)
 	}
-	return summaryAny.(neo4j.ResultSummary), nil
+	observeQuery(span, "schema", query, time.Since(start), 0, err)
+	return err
 }
@@ -0,0 +1,193 @@
+package memgraph
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultSlowQueryThreshold is how long a query may run before it is logged, unless
+// MEMGRAPH_SLOW_QUERY overrides it
+const defaultSlowQueryThreshold = 500 * time.Millisecond
+
+// maxQueryFingerprints bounds the distinct queries tracked; later ones share "other"
+const maxQueryFingerprints = 500
+
+// maxQueryLabel bounds the query text kept per fingerprint for logs and metrics
+const maxQueryLabel = 200
+
+// queryDurationBuckets are the histogram upper bounds, in seconds
+var queryDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var (
+	// queryLiteral matches string and number literals, which vary between runs of one query
+	queryLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|\b\d+(?:\.\d+)?\b`)
+	// queryListLiteral collapses inlined lists so their length does not split a fingerprint
+	queryListLiteral = regexp.MustCompile(`\[\s*\?(?:\s*,\s*\?)*\s*\]`)
+)
+
+// slowQueryThreshold is set from MEMGRAPH_SLOW_QUERY by InitMemGraph
+var slowQueryThreshold = defaultSlowQueryThreshold
+
+// queryStats accumulates one fingerprint's executions
+type queryStats struct {
+	query    string
+	kind     string // read, write, or schema
+	buckets  []uint64
+	count    uint64
+	errors   uint64
+	rows     uint64
+	duration float64 // Seconds
+}
+
+var (
+	queryMetricsMu sync.Mutex
+	queryMetrics   = make(map[string]*queryStats)
+)
+
+// loadSlowQueryThreshold reads MEMGRAPH_SLOW_QUERY, defaulting to 500ms; 0 disables
+// slow query logging
+func loadSlowQueryThreshold() time.Duration {
+	if v := os.Getenv("MEMGRAPH_SLOW_QUERY"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultSlowQueryThreshold
+}
+
+// normalizeQuery collapses whitespace and replaces literals with ?, so runs of one
+// query with different inlined values share a fingerprint
+func normalizeQuery(query string) string {
+	normalized := strings.Join(strings.Fields(query), " ")
+	normalized = queryLiteral.ReplaceAllString(normalized, "?")
+	return queryListLiteral.ReplaceAllString(normalized, "[?]")
+}
+
+// queryFingerprint identifies a normalized query by a short hash
+func queryFingerprint(normalized string) string {
+	sum := sha1.Sum([]byte(normalized))
+	return hex.EncodeToString(sum[:6])
+}
+
+// observeQuery records one execution, ends its span, and logs it when it ran longer
+// than the slow query threshold. rows is the records returned by a read or the entities
+// changed by a write.
+func observeQuery(span trace.Span, kind, query string, elapsed time.Duration, rows int, err error) {
+	normalized := normalizeQuery(query)
+	fingerprint := queryFingerprint(normalized)
+	if len(normalized) > maxQueryLabel {
+		normalized = normalized[:maxQueryLabel] + "..."
+	}
+	endQuerySpan(span, kind, fingerprint, normalized, rows, err)
+	seconds := elapsed.Seconds()
+
+	queryMetricsMu.Lock()
+	stats, ok := queryMetrics[fingerprint]
+	if !ok {
+		if len(queryMetrics) >= maxQueryFingerprints {
+			fingerprint, normalized = "other", "(untracked queries)"
+			stats = queryMetrics[fingerprint]
+		}
+		if stats == nil {
+			stats = &queryStats{query: normalized, kind: kind, buckets: make([]uint64, len(queryDurationBuckets))}
+			queryMetrics[fingerprint] = stats
+		}
+	}
+	stats.count++
+	stats.duration += seconds
+	stats.rows += uint64(rows)
+	if err != nil {
+		stats.errors++
+	}
+	for i, bound := range queryDurationBuckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+		}
+	}
+	queryMetricsMu.Unlock()
+
+	if slowQueryThreshold > 0 && elapsed >= slowQueryThreshold {
+		status := "ok"
+		if err != nil {
+			status = err.Error()
+		}
+		slog.Warn("slow query", "kind", kind, "fingerprint", fingerprint, "elapsed", elapsed.Round(time.Millisecond), "rows", rows, "status", status, "query", normalized)
+	}
+}
+
+// changedEntities counts what a write changed, as the row count of its metrics
+func changedEntities(summary neo4j.ResultSummary) int {
+	if summary == nil {
+		return 0
+	}
+	counters := summary.Counters()
+	return counters.NodesCreated() + counters.NodesDeleted() + counters.RelationshipsCreated() +
+		counters.RelationshipsDeleted() + counters.PropertiesSet()
+}
+
+// WriteMetrics writes query and pool metrics in the Prometheus text format
+func WriteMetrics(w io.Writer) {
+	queryMetricsMu.Lock()
+	fingerprints := make([]string, 0, len(queryMetrics))
+	for fingerprint := range queryMetrics {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+
+	fmt.Fprintln(w, "# HELP memgraph_query_duration_seconds Cypher query duration by query fingerprint.")
+	fmt.Fprintln(w, "# TYPE memgraph_query_duration_seconds histogram")
+	for _, fingerprint := range fingerprints {
+		stats := queryMetrics[fingerprint]
+		labels := fmt.Sprintf(`fingerprint="%s",kind="%s"`, fingerprint, stats.kind)
+		for i, bound := range queryDurationBuckets {
+			fmt.Fprintf(w, "memgraph_query_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels, bound, stats.buckets[i])
+		}
+		fmt.Fprintf(w, "memgraph_query_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, stats.count)
+		fmt.Fprintf(w, "memgraph_query_duration_seconds_sum{%s} %g\n", labels, stats.duration)
+		fmt.Fprintf(w, "memgraph_query_duration_seconds_count{%s} %d\n", labels, stats.count)
+	}
+
+	fmt.Fprintln(w, "# HELP memgraph_query_rows_total Records returned by reads and entities changed by writes.")
+	fmt.Fprintln(w, "# TYPE memgraph_query_rows_total counter")
+	for _, fingerprint := range fingerprints {
+		fmt.Fprintf(w, "memgraph_query_rows_total{fingerprint=\"%s\"} %d\n", fingerprint, queryMetrics[fingerprint].rows)
+	}
+
+	fmt.Fprintln(w, "# HELP memgraph_query_errors_total Failed Cypher queries by query fingerprint.")
+	fmt.Fprintln(w, "# TYPE memgraph_query_errors_total counter")
+	for _, fingerprint := range fingerprints {
+		fmt.Fprintf(w, "memgraph_query_errors_total{fingerprint=\"%s\"} %d\n", fingerprint, queryMetrics[fingerprint].errors)
+	}
+
+	fmt.Fprintln(w, "# HELP memgraph_query_info Normalized query text of each fingerprint.")
+	fmt.Fprintln(w, "# TYPE memgraph_query_info gauge")
+	for _, fingerprint := range fingerprints {
+		fmt.Fprintf(w, "memgraph_query_info{fingerprint=\"%s\",query=\"%s\"} 1\n", fingerprint, escapeLabel(queryMetrics[fingerprint].query))
+	}
+	queryMetricsMu.Unlock()
+
+	stats := Stats()
+	fmt.Fprintln(w, "# HELP memgraph_pool_in_use Sessions holding a pooled connection.")
+	fmt.Fprintln(w, "# TYPE memgraph_pool_in_use gauge")
+	fmt.Fprintf(w, "memgraph_pool_in_use %d\n", stats.InUse)
+	fmt.Fprintln(w, "# HELP memgraph_pool_max_connections Configured connection pool size.")
+	fmt.Fprintln(w, "# TYPE memgraph_pool_max_connections gauge")
+	fmt.Fprintf(w, "memgraph_pool_max_connections %d\n", stats.MaxConnections)
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,254 @@
+package memgraph
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Migration is one versioned step of the graph schema. Prepare statements run first as
+// write transactions, to make existing nodes satisfy a constraint the step adds. Schema
+// statements (indexes and constraints) then run in auto-commit transactions as Memgraph
+// requires, and Data statements run as write transactions after them, for reshaping
+// existing nodes.
+type Migration struct {
+	Version int
+	Name    string
+	Prepare []string
+	Schema  []string
+	Data    []string
+}
+
+// migrations is the ordered schema history. Append new steps with the next version;
+// never edit or reorder a step that has shipped.
+var migrations = []Migration{
+	{
+		// Signups used to check for a user and then create one, so concurrent signups
+		// may have written the same username twice; those are merged first
+		Version: 1,
+		Name:    "user_username_unique",
+		Prepare: mergeDuplicateUsers(),
+		Schema: []string{
+			"CREATE INDEX ON :User(username)",
+			"CREATE CONSTRAINT ON (u:User) ASSERT u.username IS UNIQUE",
+		},
+	},
+	{
+		Version: 2,
+		Name:    "farm_id_unique",
+		Schema: []string{
+			"CREATE INDEX ON :Farm(id)",
+			"CREATE CONSTRAINT ON (f:Farm) ASSERT f.id IS UNIQUE",
+		},
+	},
+	{
+		// Sensor IDs are only unique within a farm (sensors are merged through the farm's
+		// HAS_SENSOR edge), so they are indexed rather than constrained
+		Version: 3,
+		Name:    "sensor_id_index",
+		Schema: []string{
+			"CREATE INDEX ON :Sensor(sensorId)",
+		},
+	},
+	{
+		Version: 4,
+		Name:    "lookup_indexes",
+		Schema: []string{
+			"CREATE INDEX ON :Farm(farmName)",
+			"CREATE INDEX ON :Listing(listingId)",
+			"CREATE INDEX ON :PlantScan(id)",
+			"CREATE INDEX ON :FarmTask(id)",
+			"CREATE INDEX ON :FarmAudit(farmId)",
+		},
+	},
+	{
+		// Identities written by concurrent logins and farm creations. Before those were
+		// merges, duplicates could be written, so they are made unique first
+		Version: 5,
+		Name:    "identity_constraints",
+		Prepare: []string{dedupeGoogleIDs, dedupeFarmNames},
+		Schema: []string{
+			"CREATE INDEX ON :User(googleId)",
+			"CREATE CONSTRAINT ON (u:User) ASSERT u.googleId IS UNIQUE",
+			"CREATE CONSTRAINT ON (f:Farm) ASSERT f.farmName IS UNIQUE",
+		},
+	},
+	{
+		// Lets the soft delete purger find expired nodes without a label scan
+		Version: 6,
+		Name:    "soft_delete_indexes",
+		Schema: []string{
+			"CREATE INDEX ON :User(deletedAt)",
+			"CREATE INDEX ON :Farm(deletedAt)",
+			"CREATE INDEX ON :PlantScan(deletedAt)",
+			"CREATE INDEX ON :FeaturedSlot(deletedAt)",
+		},
+	},
+	{
+		// Portfolio history is read through the user's HAS_PORTFOLIO_SNAPSHOT edges, so
+		// snapshots stored under the old HAS_SNAPSHOT edge, or before their user existed,
+		// are linked to it
+		Version: 7,
+		Name:    "portfolio_snapshot_links",
+		Schema: []string{
+			"CREATE INDEX ON :PortfolioSnapshot(wallet)",
+		},
+		Data: []string{
+			`MATCH (u:User)-[r:HAS_SNAPSHOT]->(:PortfolioSnapshot) DELETE r`,
+			`MATCH (s:PortfolioSnapshot)
+	MATCH (u:User {username: s.wallet})
+	MERGE (u)-[:HAS_PORTFOLIO_SNAPSHOT]->(s)`,
+		},
+	},
+	{
+		// Deleted farms now give up their name so it can be reused; farms deleted
+		// earlier still hold theirs, so they are renamed the same way
+		Version: 8,
+		Name:    "free_deleted_farm_names",
+		Data: []string{
+			`MATCH (f:Farm) WHERE f.deletedAt IS NOT NULL AND f.deletedFarmName IS NULL
+	SET f.deletedFarmName = f.farmName, f.farmName = f.farmName + '#deleted-' + f.id`,
+		},
+	},
+}
+
+// RunMigrations applies the migrations not yet recorded as (:Migration) nodes, in
+// version order, and records each one once it succeeds. It stops at the first failure
+// so later steps never run against a schema missing an earlier one; the failed step is
+// retried on the next start. Statements that find their index or constraint already in
+// place count as applied, so instances starting together converge.
+func RunMigrations() error {
+	records, err := ExecuteRead(`MATCH (m:Migration) RETURN m.version AS version`, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	applied := make(map[int64]bool, len(records))
+	for _, record := range records {
+		if version, ok := record.Get("version"); ok {
+			if v, ok := version.(int64); ok {
+				applied[v] = true
+			}
+		}
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		if applied[int64(migration.Version)] {
+			continue
+		}
+		if err := runMigration(migration); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		count++
+		slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+	}
+	if count > 0 {
+		slog.Info("graph schema up to date", "version", migrations[len(migrations)-1].Version)
+	}
+	return nil
+}
+
+// runMigration executes one migration's statements and records it
+func runMigration(migration Migration) error {
+	for _, statement := range migration.Prepare {
+		if _, err := ExecuteWrite(statement, nil); err != nil {
+			return fmt.Errorf("%s: %w", statement, err)
+		}
+	}
+	for _, statement := range migration.Schema {
+		if err := ExecuteSchema(statement); err != nil && !schemaExists(err) {
+			return fmt.Errorf("%s: %w", statement, err)
+		}
+	}
+	for _, statement := range migration.Data {
+		if _, err := ExecuteWrite(statement, nil); err != nil {
+			return fmt.Errorf("%s: %w", statement, err)
+		}
+	}
+
+	_, err := ExecuteWrite(`MERGE (m:Migration {version: $version})
+		ON CREATE SET m.name = $name, m.appliedAt = $appliedAt`, map[string]interface{}{
+		"version":   migration.Version,
+		"name":      migration.Name,
+		"appliedAt": time.Now().UnixMilli(),
+	})
+	return err
+}
+
+// duplicateUsers groups the users sharing a username into keep, the oldest, and dups,
+// the others
+const duplicateUsers = `MATCH (u:User) WHERE u.username IS NOT NULL
+	WITH u ORDER BY id(u)
+	WITH u.username AS username, collect(u) AS users
+	WHERE size(users) > 1
+	WITH head(users) AS keep, tail(users) AS dups
+	`
+
+// dedupeGoogleIDs keeps a Google ID on the oldest user holding it, so Google sign-in
+// resolves to that account, and moves it to duplicateGoogleId on the others for review
+const dedupeGoogleIDs = `MATCH (u:User) WHERE u.googleId IS NOT NULL
+	WITH u ORDER BY id(u)
+	WITH u.googleId AS googleId, collect(u) AS users
+	WHERE size(users) > 1
+	UNWIND tail(users) AS dup
+	SET dup.duplicateGoogleId = dup.googleId, dup.googleId = null`
+
+// dedupeFarmNames keeps a shared farm name on one farm, preferring live farms and then
+// the oldest, and renames the others to <name>#<id>
+const dedupeFarmNames = `MATCH (f:Farm) WHERE f.farmName IS NOT NULL
+	WITH f ORDER BY CASE WHEN f.deletedAt IS NULL THEN 0 ELSE 1 END, id(f)
+	WITH f.farmName AS farmName, collect(f) AS farms
+	WHERE size(farms) > 1
+	UNWIND tail(farms) AS dup
+	SET dup.farmName = dup.farmName + '#' + coalesce(dup.id, toString(id(dup)))`
+
+// userRelationships are the relationship types attached to users, as outgoing (true) or
+// incoming (false) from the user
+var userRelationships = []struct {
+	Type     string
+	Outgoing bool
+}{
+	{"HAS_NOTIFICATION", true},
+	{"MADE_PURCHASE", true},
+	{"WATCHES", true},
+	{"REPORTED", true},
+	{"HAS_PORTFOLIO_SNAPSHOT", true},
+	{"OWNS_FARM", true},
+	{"GRANTS", false},
+	{"ASSIGNED_TO", false},
+}
+
+// mergeDuplicateUsers returns the statements that fold every duplicate user into the
+// oldest user with its username: relationships move to the kept user with their
+// properties, the kept user gains the properties only duplicates have, and the
+// duplicates are deleted
+func mergeDuplicateUsers() []string {
+	statements := make([]string, 0, len(userRelationships)+1)
+	for _, rel := range userRelationships {
+		pattern := fmt.Sprintf("MATCH (dup)-[r:%[1]s]->(other) MERGE (keep)-[moved:%[1]s]->(other)", rel.Type)
+		if !rel.Outgoing {
+			pattern = fmt.Sprintf("MATCH (other)-[r:%[1]s]->(dup) MERGE (other)-[moved:%[1]s]->(keep)", rel.Type)
+		}
+		statements = append(statements, duplicateUsers+"UNWIND dups AS dup\n\t"+pattern+`
+	ON CREATE SET moved += properties(r)
+	DELETE r`)
+	}
+	return append(statements, duplicateUsers+`WITH keep, dups, properties(keep) AS kept
+	UNWIND dups AS dup
+	SET keep += properties(dup)
+	SET keep += kept
+	DETACH DELETE dup`)
+}
+
+// IsConstraintViolation reports whether a write failed because it would break a
+// uniqueness constraint, as when a concurrent request created the same node first
+func IsConstraintViolation(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "constraint violation")
+}
+
+// schemaExists reports whether a schema statement failed only because its index or
+// constraint is already there
+func schemaExists(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "already exists")
+}
@@ -0,0 +1,86 @@
+package memgraph
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// orphanedFarmRecord describes farm-owned nodes that no farm links to any more, as left
+// by farms deleted before purging took their records along. Owned lists the
+// relationship types leading to the nodes each one owns in turn.
+type orphanedFarmRecord struct {
+	Label string
+	Edge  string
+	Owned string
+}
+
+// orphanedFarmRecords are checked in order. Nodes a farm reaches only through a
+// property, as some legacy records do, count as orphaned too, so review the counts
+// before purging.
+var orphanedFarmRecords = []orphanedFarmRecord{
+	{Label: "Sensor", Edge: "HAS_SENSOR", Owned: "HAS_READING|INTERPRETED_AS"},
+	{Label: "PlantScan", Edge: "HAS_PLANT_SCAN"},
+	{Label: "SensorAlert", Edge: "HAS_ALERT"},
+	{Label: "Harvest", Edge: "HAS_HARVEST"},
+	{Label: "FarmPhoto", Edge: "HAS_PHOTO"},
+	{Label: "CropSeason", Edge: "HAS_SEASON", Owned: "HAS_STAGE"},
+	{Label: "FarmTask", Edge: "HAS_TASK"},
+	{Label: "FarmVerification", Edge: "HAS_VERIFICATION", Owned: "HAS_FILE"},
+}
+
+// match selects the orphaned nodes of r as n
+func (r orphanedFarmRecord) match() string {
+	return fmt.Sprintf("MATCH (n:%s) WHERE NOT (:Farm)-[:%s]->(n)\n", r.Label, r.Edge)
+}
+
+// CountOrphanedFarmRecords returns how many nodes of each farm-owned label no farm links
+// to, without changing anything
+func CountOrphanedFarmRecords() (map[string]int64, error) {
+	counts := make(map[string]int64, len(orphanedFarmRecords))
+	for _, record := range orphanedFarmRecords {
+		records, err := ExecuteRead(record.match()+"RETURN count(n) AS count", nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count orphaned %s nodes: %w", record.Label, err)
+		}
+		if len(records) > 0 {
+			counts[record.Label] = Int64(records[0], "count")
+		}
+	}
+	return counts, nil
+}
+
+// PurgeOrphanedFarmRecords permanently deletes the nodes CountOrphanedFarmRecords
+// reports, with the readings, interpretations, stages, and files they own. It returns
+// the counts found before deleting. Run it only after reviewing those counts.
+func PurgeOrphanedFarmRecords() (map[string]int64, error) {
+	counts, err := CountOrphanedFarmRecords()
+	if err != nil {
+		return nil, err
+	}
+	slog.Warn("purging orphaned farm records", "counts", counts)
+
+	for _, record := range orphanedFarmRecords {
+		if counts[record.Label] == 0 {
+			continue
+		}
+		statement := record.match() + "WITH n LIMIT $limit\n"
+		if record.Owned != "" {
+			statement += fmt.Sprintf(`OPTIONAL MATCH (n)-[:%s *1..2]->(owned)
+				WITH n, collect(DISTINCT owned) AS owned
+				FOREACH (o IN owned | DETACH DELETE o)
+				`, record.Owned)
+		}
+		statement += "DETACH DELETE n"
+
+		for {
+			summary, err := ExecuteWrite(statement, map[string]any{"limit": purgeBatchSize})
+			if err != nil {
+				return counts, fmt.Errorf("failed to purge orphaned %s nodes: %w", record.Label, err)
+			}
+			if summary == nil || summary.Counters().NodesDeleted() == 0 {
+				break
+			}
+		}
+	}
+	return counts, nil
+}
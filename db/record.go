@@ -0,0 +1,70 @@
+package memgraph
+
+import "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+// String reads a string column, returning "" when it is absent, null, or not a string
+func String(record *neo4j.Record, key string) string {
+	val, _ := record.Get(key)
+	return asString(val)
+}
+
+// Int64 reads an integer column, returning 0 when it is absent or null
+func Int64(record *neo4j.Record, key string) int64 {
+	val, _ := record.Get(key)
+	return asInt64(val)
+}
+
+// Float64 reads a numeric column as a float. ok is false when the column is absent,
+// null, or not a number, so callers can tell a stored 0 from a missing value.
+func Float64(record *neo4j.Record, key string) (float64, bool) {
+	val, _ := record.Get(key)
+	return asFloat64(val)
+}
+
+// Bool reads a boolean column, returning false when it is absent or null
+func Bool(record *neo4j.Record, key string) bool {
+	val, _ := record.Get(key)
+	b, _ := val.(bool)
+	return b
+}
+
+// PropString reads a string property of a returned node or map, like String
+func PropString(props map[string]any, key string) string {
+	return asString(props[key])
+}
+
+// PropInt64 reads an integer property of a returned node or map, like Int64
+func PropInt64(props map[string]any, key string) int64 {
+	return asInt64(props[key])
+}
+
+// PropFloat64 reads a numeric property of a returned node or map, like Float64
+func PropFloat64(props map[string]any, key string) (float64, bool) {
+	return asFloat64(props[key])
+}
+
+// asString converts a column or property value, "" unless it is a string
+func asString(val any) string {
+	s, _ := val.(string)
+	return s
+}
+
+// asInt64 converts a column or property value, 0 unless it is an integer
+func asInt64(val any) int64 {
+	n, _ := val.(int64)
+	return n
+}
+
+// asFloat64 converts a column or property value; ok is false unless it is a number
+func asFloat64(val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,68 @@
+package memgraph
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// replicaFallbackLogInterval limits how often falling back to the primary is logged
+const replicaFallbackLogInterval = time.Minute
+
+// readDriver connects to the read replica named by MEMGRAPH_READ_URI, or is nil when
+// replica reads go to the primary
+var readDriver neo4j.DriverWithContext
+
+// lastReplicaFallbackLog is when a replica fallback was last logged, in Unix nanoseconds
+var lastReplicaFallbackLog atomic.Int64
+
+// initReplica connects the read replica when MEMGRAPH_READ_URI is set, using the
+// primary's credentials. A replica that cannot be reached at startup is skipped rather
+// than failing the server.
+func initReplica(auth neo4j.AuthToken) {
+	uri := os.Getenv("MEMGRAPH_READ_URI")
+	if uri == "" {
+		return
+	}
+	d, err := newDriver(uri, auth)
+	if err != nil {
+		slog.Warn("invalid MEMGRAPH_READ_URI, replica reads go to the primary", "error", err)
+		return
+	}
+	if err := d.VerifyConnectivity(context.Background()); err != nil {
+		slog.Warn("read replica unreachable, replica reads go to the primary", "error", err)
+		d.Close(context.Background())
+		return
+	}
+	readDriver = d
+	slog.Info("Memgraph read replica connected")
+}
+
+// ExecuteReplicaRead runs a read query on the read replica; see ExecuteReplicaReadContext.
+func ExecuteReplicaRead(query string, params map[string]interface{}) ([]*neo4j.Record, error) {
+	return ExecuteReplicaReadContext(context.Background(), query, params)
+}
+
+// ExecuteReplicaReadContext runs a read query on the read replica when one is
+// configured, keeping read-heavy endpoints off the primary. Replicas can lag the
+// primary, so use it only where slightly stale results are acceptable, never to read
+// back a write just made. It falls back to the primary when the replica is unreachable.
+func ExecuteReplicaReadContext(ctx context.Context, query string, params map[string]interface{}) ([]*neo4j.Record, error) {
+	if readDriver == nil {
+		return ExecuteReadContext(ctx, query, params)
+	}
+	records, err := executeRead(readDriver, ctx, query, params)
+	if err != nil && neo4j.IsConnectivityError(err) {
+		now := time.Now().UnixNano()
+		last := lastReplicaFallbackLog.Load()
+		if now-last >= int64(replicaFallbackLogInterval) && lastReplicaFallbackLog.CompareAndSwap(last, now) {
+			slog.Warn("read replica unavailable, reading from the primary", "error", err)
+		}
+		return ExecuteReadContext(ctx, query, params)
+	}
+	return records, err
+}
@@ -0,0 +1,128 @@
+package memgraph
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	// defaultSoftDeleteRetention is how long soft-deleted nodes can be restored
+	defaultSoftDeleteRetention = 30 * 24 * time.Hour
+	// purgeBatchSize is how many expired nodes one purge write removes
+	purgeBatchSize = 500
+)
+
+// SoftDeleteLabels are the node labels deleted by setting deletedAt instead of removing
+// the node. Queries over them must filter on deletedAt IS NULL.
+var SoftDeleteLabels = []string{"User", "Farm", "PlantScan", "FeaturedSlot"}
+
+// farmOwnedRelationships lead from a farm to the nodes it owns, up to three hops deep
+// (farm, sensor, reading, interpretation). Purging a farm removes everything reachable
+// over them; its plot NFTs, collaborators and audit entries stay.
+const farmOwnedRelationships = "HAS_SENSOR|HAS_READING|INTERPRETED_AS|HAS_PLANT_SCAN|HAS_ALERT|HAS_HARVEST|" +
+	"HAS_PHOTO|HAS_SEASON|HAS_STAGE|HAS_TASK|HAS_VERIFICATION|HAS_FILE"
+
+// purgeStatements delete up to $limit nodes of a soft-delete label deleted before
+// $cutoff, together with the nodes they own
+var purgeStatements = map[string]string{
+	"Farm": `MATCH (n:Farm) WHERE n.deletedAt < $cutoff
+		WITH n LIMIT $limit
+		OPTIONAL MATCH (n)-[:` + farmOwnedRelationships + ` *1..3]->(owned)
+		WITH n, collect(DISTINCT owned) AS owned
+		FOREACH (o IN owned | DETACH DELETE o)
+		DETACH DELETE n`,
+}
+
+// SoftDelete marks the live node of label whose key property equals value as deleted by
+// actor. It reports false when no live node matched. label and key must be constants.
+func SoftDelete(label, key string, value any, actor string) (bool, error) {
+	summary, err := ExecuteWrite(fmt.Sprintf(`MATCH (n:%s {%s: $value}) WHERE n.deletedAt IS NULL
+		SET n.deletedAt = $now, n.deletedBy = $actor`, label, key),
+		map[string]any{"value": value, "now": time.Now().UnixMilli(), "actor": actor})
+	if err != nil {
+		return false, err
+	}
+	return summary != nil && summary.Counters().PropertiesSet() > 0, nil
+}
+
+// Restore clears the deleted mark of the node of label whose key property equals value.
+// It reports false when no deleted node matched. label and key must be constants.
+func Restore(label, key string, value any) (bool, error) {
+	summary, err := ExecuteWrite(fmt.Sprintf(`MATCH (n:%s {%s: $value}) WHERE n.deletedAt IS NOT NULL
+		SET n.deletedAt = null, n.deletedBy = null`, label, key), map[string]any{"value": value})
+	if err != nil {
+		return false, err
+	}
+	return summary != nil && summary.Counters().PropertiesSet() > 0, nil
+}
+
+// PurgeDeleted permanently removes nodes of every soft-delete label deleted more than
+// retention ago, along with the nodes they own, returning how many were removed
+func PurgeDeleted(retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention).UnixMilli()
+	var purged int64
+	for _, label := range SoftDeleteLabels {
+		statement, ok := purgeStatements[label]
+		if !ok {
+			statement = fmt.Sprintf(`MATCH (n:%s) WHERE n.deletedAt < $cutoff
+				WITH n LIMIT $limit
+				DETACH DELETE n`, label)
+		}
+		for {
+			summary, err := ExecuteWrite(statement, map[string]any{"cutoff": cutoff, "limit": purgeBatchSize})
+			if err != nil {
+				return purged, fmt.Errorf("failed to purge deleted %s nodes: %w", label, err)
+			}
+			deleted := int64(0)
+			if summary != nil {
+				deleted = int64(summary.Counters().NodesDeleted())
+			}
+			purged += deleted
+			if deleted < purgeBatchSize {
+				break
+			}
+		}
+	}
+	return purged, nil
+}
+
+// StartSoftDeletePurger purges soft-deleted nodes older than SOFT_DELETE_RETENTION
+// (default 30 days) every SOFT_DELETE_PURGE_INTERVAL (default 6h). Until then they can
+// be restored. It blocks, so run it in a goroutine.
+func StartSoftDeletePurger() {
+	retention := SoftDeleteRetention()
+	interval := 6 * time.Hour
+	if v := os.Getenv("SOFT_DELETE_PURGE_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("soft delete purger started", "interval", interval, "retention", retention)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := PurgeDeleted(retention)
+		if err != nil {
+			slog.Warn("soft delete purge failed", "error", err)
+		}
+		if purged > 0 {
+			slog.Info("purged soft-deleted nodes", "purged", purged)
+		}
+	}
+}
+
+// SoftDeleteRetention reads SOFT_DELETE_RETENTION, how long deleted nodes are kept for
+// restoring, defaulting to 30 days
+func SoftDeleteRetention() time.Duration {
+	if v := os.Getenv("SOFT_DELETE_RETENTION"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Hour {
+			return parsed
+		}
+	}
+	return defaultSoftDeleteRetention
+}
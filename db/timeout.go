@@ -0,0 +1,50 @@
+package memgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// defaultQueryTimeout bounds a single query unless MEMGRAPH_QUERY_TIMEOUT overrides it
+const defaultQueryTimeout = 30 * time.Second
+
+// ErrQueryTimeout is returned when a query outlives the query timeout
+var ErrQueryTimeout = errors.New("database query timed out")
+
+// queryTimeout is the per-query deadline, set from MEMGRAPH_QUERY_TIMEOUT by InitMemGraph
+var queryTimeout = defaultQueryTimeout
+
+// loadQueryTimeout reads MEMGRAPH_QUERY_TIMEOUT, defaulting to 30s
+func loadQueryTimeout() time.Duration {
+	if v := os.Getenv("MEMGRAPH_QUERY_TIMEOUT"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultQueryTimeout
+}
+
+// queryContext derives the context for one query: it ends with the caller's context or
+// after the query timeout, whichever comes first, so a slow query cannot hold its
+// goroutine and session forever
+func queryContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	return context.WithTimeout(parent, queryTimeout)
+}
+
+// queryError reports a query stopped by the timeout as ErrQueryTimeout, keeping the
+// driver's error for the log; cancellations by the caller pass through unchanged
+func queryError(ctx, parent context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && (parent == nil || parent.Err() == nil) {
+		return fmt.Errorf("%w after %s: %v", ErrQueryTimeout, queryTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package memgraph
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records a client span per query. It uses the global provider, which records
+// nothing until config.InitTracing installs one.
+var tracer = otel.Tracer("decentragri-app-cx-server/db")
+
+// startQuerySpan starts the span of one query of kind (read, write, or schema) as a
+// child of the span in ctx, so queries run with a request context nest under it
+func startQuerySpan(ctx context.Context, kind string, replica bool) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "memgraph."+kind,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "memgraph"),
+			attribute.String("db.operation.name", kind),
+			attribute.Bool("db.memgraph.replica", replica),
+		))
+}
+
+// endQuerySpan names the query on span by fingerprint and normalized text, records its
+// row count or error, and ends it
+func endQuerySpan(span trace.Span, kind, fingerprint, normalized string, rows int, err error) {
+	if !span.IsRecording() {
+		span.End()
+		return
+	}
+	rowsKey := "db.response.returned_rows"
+	if kind != "read" {
+		rowsKey = "db.memgraph.changed_entities"
+	}
+	span.SetAttributes(
+		attribute.String("db.query.summary", fingerprint),
+		attribute.String("db.query.text", normalized),
+		attribute.Int(rowsKey, rows),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
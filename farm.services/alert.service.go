@@ -0,0 +1,427 @@
+package farmservices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+	memgraph "decentragri-app-cx-server/db"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// maxAlerts caps how many alerts the feed returns
+	maxAlerts = 100
+	// maxAlertNotificationAttempts bounds how often an alert notification is retried
+	maxAlertNotificationAttempts = 3
+)
+
+// SensorAlertNotificationQueue is the Redis list new sensor alerts are queued on for
+// notifying the farm owner
+const SensorAlertNotificationQueue = "sensor_alert_notification_queue"
+
+// defaultAlertChannels are used when a farm's settings name no channels
+var defaultAlertChannels = []string{notificationServices.ChannelInApp, notificationServices.ChannelPush}
+
+// Sensor alert errors
+var (
+	ErrAlertNotFound          = errors.New("alert not found")
+	ErrInvalidAlertTransition = errors.New("alert cannot move to that status")
+)
+
+// GetAlertSettings returns the sensor thresholds configured on a farm the caller can view
+func GetAlertSettings(token, farmID string) (*FarmAlertSettings, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+	return loadAlertSettings(farmID)
+}
+
+// UpdateAlertSettings replaces the sensor thresholds and alert channels of a farm the
+// caller manages. An empty threshold list turns alerting off.
+func UpdateAlertSettings(token, farmID string, settings FarmAlertSettings) (*FarmAlertSettings, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleManager); err != nil {
+		return nil, err
+	}
+	if err := validateAlertSettings(&settings); err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode alert settings: %w", err)
+	}
+	if _, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $id}) SET f.alertSettings = $settings`,
+		map[string]any{"id": farmID, "settings": string(encoded)}); err != nil {
+		return nil, fmt.Errorf("failed to save alert settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// validateAlertSettings checks thresholds against the known metrics and fills in the
+// default channels
+func validateAlertSettings(settings *FarmAlertSettings) error {
+	seen := make(map[string]bool)
+	for _, threshold := range settings.Thresholds {
+		rng, ok := readingMetrics[threshold.Metric]
+		if !ok {
+			return invalidf("unknown metric %q", threshold.Metric)
+		}
+		if seen[threshold.Metric] {
+			return invalidf("duplicate threshold for %s", threshold.Metric)
+		}
+		seen[threshold.Metric] = true
+
+		if threshold.Min == nil && threshold.Max == nil {
+			return invalidf("threshold for %s needs a min or max", threshold.Metric)
+		}
+		for _, bound := range []*float64{threshold.Min, threshold.Max} {
+			if bound != nil && (*bound < rng.Min || *bound > rng.Max) {
+				return invalidf("%s thresholds must be between %g and %g", threshold.Metric, rng.Min, rng.Max)
+			}
+		}
+		if threshold.Min != nil && threshold.Max != nil && *threshold.Min > *threshold.Max {
+			return invalidf("%s min is above max", threshold.Metric)
+		}
+	}
+
+	if len(settings.Channels) == 0 {
+		settings.Channels = defaultAlertChannels
+	}
+	for _, channel := range settings.Channels {
+		switch channel {
+		case notificationServices.ChannelInApp, notificationServices.ChannelPush, notificationServices.ChannelEmail:
+		default:
+			return invalidf("unknown channel %q", channel)
+		}
+	}
+	if settings.Thresholds == nil {
+		settings.Thresholds = []SensorThreshold{}
+	}
+	return nil
+}
+
+// loadAlertSettings reads a farm's alert settings; farms without settings have no thresholds
+func loadAlertSettings(farmID string) (*FarmAlertSettings, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm {id: $id}) RETURN f.alertSettings AS settings`,
+		map[string]any{"id": farmID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert settings: %w", err)
+	}
+
+	settings := &FarmAlertSettings{Thresholds: []SensorThreshold{}, Channels: defaultAlertChannels}
+	if len(records) > 0 {
+		if s := memgraph.String(records[0], "settings"); s != "" {
+			if err := json.Unmarshal([]byte(s), settings); err != nil {
+				return nil, fmt.Errorf("invalid alert settings: %w", err)
+			}
+		}
+	}
+	return settings, nil
+}
+
+// evaluateThresholds raises or refreshes alerts for every threshold the reading breaks.
+// Only a new alert notifies the owner, through the alert notification queue; repeated
+// breaches update the open alert.
+func evaluateThresholds(farm *FarmList, reading *SensorReadings) {
+	settings, err := loadAlertSettings(farm.ID)
+	if err != nil {
+		slog.Warn("skipping threshold check for farm", "farm", farm.FarmName, "error", err)
+		return
+	}
+
+	for _, threshold := range settings.Thresholds {
+		value := metricValue(reading, threshold.Metric)
+		if (threshold.Min == nil || value >= *threshold.Min) && (threshold.Max == nil || value <= *threshold.Max) {
+			continue
+		}
+
+		alertID, created, err := raiseAlert(farm, reading, threshold, value)
+		if err != nil {
+			slog.Warn("failed to record alert for farm", "metric", threshold.Metric, "farm", farm.FarmName, "error", err)
+			continue
+		}
+		if !created {
+			continue
+		}
+
+		job := SensorAlertJob{
+			AlertID:  alertID,
+			FarmID:   farm.ID,
+			FarmName: farm.FarmName,
+			Owner:    farm.Owner,
+			SensorID: reading.SensorID,
+			Metric:   threshold.Metric,
+			Value:    value,
+			Range:    thresholdRange(threshold),
+			Channels: settings.Channels,
+		}
+		if err := cache.Enqueue(SensorAlertNotificationQueue, job); err != nil {
+			// Without the queue, notify now rather than lose the alert
+			slog.Warn("failed to queue alert notification; sending it now", "alertId", alertID, "error", err)
+			if err := sendAlertNotification(job); err != nil {
+				slog.Warn("failed to send alert for farm", "metric", threshold.Metric, "farm", farm.FarmName, "error", err)
+			}
+		}
+	}
+}
+
+// StartSensorAlertNotifier drains the alert notification queue, notifying each new
+// alert's farm owner on the farm's channels. It blocks, so run it in a goroutine.
+func StartSensorAlertNotifier() {
+	slog.Info("sensor alert notifier started")
+
+	for {
+		if cache.RedisClient == nil {
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		// Enqueue pushes on the left, so popping on the right takes the oldest job
+		result, err := cache.RedisClient.BRPop(context.Background(), 5*time.Second, SensorAlertNotificationQueue).Result()
+		if err != nil {
+			// redis.Nil means the wait timed out with an empty queue
+			if !errors.Is(err, redis.Nil) {
+				slog.Warn("failed to read alert notification queue", "error", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if len(result) < 2 {
+			continue
+		}
+
+		var job SensorAlertJob
+		if err := json.Unmarshal([]byte(result[1]), &job); err != nil {
+			slog.Warn("dropping malformed alert notification job", "error", err)
+			continue
+		}
+
+		if err := sendAlertNotification(job); err != nil {
+			job.Attempts++
+			slog.Warn("failed to send alert for farm", "alertId", job.AlertID, "farm", job.FarmName, "attempt", job.Attempts, "error", err)
+			if job.Attempts < maxAlertNotificationAttempts {
+				if err := cache.Enqueue(SensorAlertNotificationQueue, job); err != nil {
+					slog.Warn("failed to requeue alert notification", "alertId", job.AlertID, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// sendAlertNotification notifies the farm owner of a new alert
+func sendAlertNotification(job SensorAlertJob) error {
+	title := fmt.Sprintf("%s alert on %s", job.Metric, job.FarmName)
+	message := fmt.Sprintf("Sensor %s read %s %g, outside %s", job.SensorID, job.Metric, job.Value, job.Range)
+	data := map[string]any{"alertId": job.AlertID, "farmId": job.FarmID, "metric": job.Metric, "value": job.Value}
+	return notificationServices.NotifyVia(job.Channels, job.Owner, notificationServices.TypeSensorThreshold, title, message, data)
+}
+
+// thresholdRange describes a threshold's accepted range for alert messages
+func thresholdRange(threshold SensorThreshold) string {
+	switch {
+	case threshold.Min != nil && threshold.Max != nil:
+		return fmt.Sprintf("%g-%g", *threshold.Min, *threshold.Max)
+	case threshold.Min != nil:
+		return fmt.Sprintf("min %g", *threshold.Min)
+	default:
+		return fmt.Sprintf("max %g", *threshold.Max)
+	}
+}
+
+// raiseAlert updates the unresolved alert for the same farm, sensor, and metric, or
+// creates one, and returns its ID. created reports whether a new alert was opened.
+func raiseAlert(farm *FarmList, reading *SensorReadings, threshold SensorThreshold, value float64) (alertID string, created bool, err error) {
+	params := map[string]any{
+		"farmId":    farm.ID,
+		"farmName":  farm.FarmName,
+		"sensorId":  reading.SensorID,
+		"readingId": reading.ID,
+		"metric":    threshold.Metric,
+		"value":     value,
+		"min":       optionalFloat(threshold.Min),
+		"max":       optionalFloat(threshold.Max),
+	}
+
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[:HAS_ALERT]->(a:SensorAlert {sensorId: $sensorId, metric: $metric})
+		WHERE a.status <> 'resolved'
+		RETURN a.id AS id
+		LIMIT 1`, params)
+	if err != nil {
+		return "", false, err
+	}
+
+	created = len(records) == 0
+	query := `MATCH (:Farm {id: $farmId})-[:HAS_ALERT]->(a:SensorAlert {id: $id})
+		SET a.value = $value, a.readingId = $readingId, a.min = $min, a.max = $max,
+			a.occurrences = a.occurrences + 1, a.lastSeenAt = timestamp()`
+	if created {
+		params["id"] = uuid.NewString()
+		query = `MATCH (f:Farm {id: $farmId})
+			CREATE (f)-[:HAS_ALERT]->(a:SensorAlert {
+				id: $id,
+				farmId: $farmId,
+				farmName: $farmName,
+				sensorId: $sensorId,
+				readingId: $readingId,
+				metric: $metric,
+				value: $value,
+				min: $min,
+				max: $max,
+				status: 'open',
+				occurrences: 1,
+				createdAt: timestamp(),
+				lastSeenAt: timestamp()
+			})`
+	} else {
+		params["id"] = memgraph.String(records[0], "id")
+	}
+
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return "", false, err
+	}
+	return params["id"].(string), created, nil
+}
+
+// optionalFloat converts an optional bound to a query parameter (null when unset)
+func optionalFloat(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// alertReturn is the projection shared by the alert queries
+const alertReturn = `RETURN a.id AS id, a.farmId AS farmId, a.farmName AS farmName, a.sensorId AS sensorId,
+	a.readingId AS readingId, a.metric AS metric, a.value AS value, a.min AS min, a.max AS max,
+	a.status AS status, a.occurrences AS occurrences, a.createdAt AS createdAt,
+	a.lastSeenAt AS lastSeenAt, a.acknowledgedAt AS acknowledgedAt, a.resolvedAt AS resolvedAt`
+
+// GetSensorAlerts returns the alerts on farms the caller owns or collaborates on, most
+// recent first, optionally filtered by status
+func GetSensorAlerts(token, status string) ([]SensorAlert, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", AlertStatusOpen, AlertStatusAcknowledged, AlertStatusResolved:
+	default:
+		return nil, invalidf("status must be open, acknowledged, or resolved")
+	}
+
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:HAS_ALERT]->(a:SensorAlert)
+		WHERE `+accessibleFarmCondition+` AND ($status = '' OR a.status = $status)
+		WITH a ORDER BY a.lastSeenAt DESC LIMIT $limit
+		`+alertReturn, map[string]any{"username": username, "status": status, "limit": maxAlerts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch alerts: %w", err)
+	}
+
+	alerts := make([]SensorAlert, 0, len(records))
+	for _, record := range records {
+		alerts = append(alerts, alertFromRecord(record))
+	}
+	return alerts, nil
+}
+
+// UpdateSensorAlertStatus acknowledges or resolves an alert on a farm the caller manages.
+// Open alerts can be acknowledged; open and acknowledged alerts can be resolved.
+func UpdateSensorAlertStatus(token, alertID, status string) (*SensorAlert, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowedFrom []string
+	var stampField string
+	switch status {
+	case AlertStatusAcknowledged:
+		allowedFrom, stampField = []string{AlertStatusOpen}, "acknowledgedAt"
+	case AlertStatusResolved:
+		allowedFrom, stampField = []string{AlertStatusOpen, AlertStatusAcknowledged}, "resolvedAt"
+	default:
+		return nil, ErrInvalidAlertTransition
+	}
+
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:HAS_ALERT]->(a:SensorAlert {id: $id})
+		RETURN f.id AS farmId, a.status AS status`, map[string]any{"id": alertID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrAlertNotFound
+	}
+	if _, _, err := authorizeFarm(memgraph.String(records[0], "farmId"), username, FarmRoleManager); err != nil {
+		return nil, err
+	}
+	current := memgraph.String(records[0], "status")
+	allowed := false
+	for _, from := range allowedFrom {
+		if current == from {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, ErrInvalidAlertTransition
+	}
+
+	if _, err := memgraph.ExecuteWrite(`MATCH (a:SensorAlert {id: $id})
+		SET a.status = $status, a.`+stampField+` = timestamp()`,
+		map[string]any{"id": alertID, "status": status}); err != nil {
+		return nil, fmt.Errorf("failed to update alert: %w", err)
+	}
+
+	records, err = memgraph.ExecuteRead(`MATCH (a:SensorAlert {id: $id}) `+alertReturn, map[string]any{"id": alertID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reload alert: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrAlertNotFound
+	}
+	alert := alertFromRecord(records[0])
+	return &alert, nil
+}
+
+// alertFromRecord maps an alertReturn row to a SensorAlert
+func alertFromRecord(record *neo4j.Record) SensorAlert {
+	alert := SensorAlert{
+		ID:        memgraph.String(record, "id"),
+		FarmID:    memgraph.String(record, "farmId"),
+		FarmName:  memgraph.String(record, "farmName"),
+		SensorID:  memgraph.String(record, "sensorId"),
+		ReadingID: memgraph.String(record, "readingId"),
+		Metric:    memgraph.String(record, "metric"),
+		Status:    memgraph.String(record, "status"),
+	}
+	alert.Value, _ = memgraph.Float64(record, "value")
+	if v, ok := memgraph.Float64(record, "min"); ok {
+		alert.Min = &v
+	}
+	if v, ok := memgraph.Float64(record, "max"); ok {
+		alert.Max = &v
+	}
+	alert.Occurrences = memgraph.Int64(record, "occurrences")
+	alert.CreatedAt = memgraph.Int64(record, "createdAt")
+	alert.LastSeenAt = memgraph.Int64(record, "lastSeenAt")
+	alert.AcknowledgedAt = memgraph.Int64(record, "acknowledgedAt")
+	alert.ResolvedAt = memgraph.Int64(record, "resolvedAt")
+	return alert
+}
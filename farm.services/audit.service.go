@@ -0,0 +1,129 @@
+package farmservices
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+
+	"github.com/google/uuid"
+)
+
+// Farm audit actions
+const (
+	AuditFarmCreated         = "farm.created"
+	AuditFarmUpdated         = "farm.updated"
+	AuditFarmDeleted         = "farm.deleted"
+	AuditFarmRestored        = "farm.restored"
+	AuditSensorRegistered    = "sensor.registered"
+	AuditScanDeleted         = "scan.deleted"
+	AuditScanRestored        = "scan.restored"
+	AuditCollaboratorInvited = "collaborator.invited"
+	AuditCollaboratorUpdated = "collaborator.role_changed"
+	AuditCollaboratorRemoved = "collaborator.removed"
+)
+
+// recordFarmAudit stores who did what to a farm. Entries are keyed by farm ID rather
+// than attached to the farm node so they outlive its deletion. Failures are logged and
+// never fail the change being audited.
+func recordFarmAudit(farmID, actor, action string, details map[string]any) {
+	detailsJSON := ""
+	if len(details) > 0 {
+		encoded, err := json.Marshal(details)
+		if err != nil {
+			slog.Warn("failed to encode audit details for farm", "action", action, "farmId", farmID, "error", err)
+		} else {
+			detailsJSON = string(encoded)
+		}
+	}
+
+	if _, err := memgraph.ExecuteWrite(`CREATE (:FarmAudit {
+			id: $id, farmId: $farmId, actor: $actor, action: $action, details: $details, at: $at
+		})`, map[string]any{
+		"id":      uuid.NewString(),
+		"farmId":  farmID,
+		"actor":   actor,
+		"action":  action,
+		"details": detailsJSON,
+		"at":      time.Now().UnixMilli(),
+	}); err != nil {
+		slog.Warn("failed to record audit for farm", "action", action, "farmId", farmID, "error", err)
+	}
+}
+
+// GetFarmAudit returns a farm's audit log, newest first. The owner can read it while
+// the farm exists; admins (asAdmin) can read any farm's log, including deleted farms.
+func GetFarmAudit(token, farmID string, asAdmin bool, page, limit int) (*FarmAuditLog, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if !asAdmin {
+		if _, err := getOwnedFarm(farmID, username); err != nil {
+			return nil, err
+		}
+	}
+
+	params := map[string]any{"farmId": farmID, "offset": (page - 1) * limit, "limit": limit}
+	countRecords, err := memgraph.ExecuteRead(`MATCH (a:FarmAudit {farmId: $farmId}) RETURN count(a) AS total`, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+	total := 0
+	if len(countRecords) > 0 {
+		total = int(memgraph.Int64(countRecords[0], "total"))
+	}
+
+	records, err := memgraph.ExecuteRead(`MATCH (a:FarmAudit {farmId: $farmId})
+		WITH a ORDER BY a.at DESC SKIP $offset LIMIT $limit
+		RETURN a.id AS id, a.actor AS actor, a.action AS action, a.details AS details, a.at AS at`, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch audit entries: %w", err)
+	}
+
+	entries := make([]FarmAuditEntry, 0, len(records))
+	for _, record := range records {
+		entry := FarmAuditEntry{
+			ID:     memgraph.String(record, "id"),
+			Actor:  memgraph.String(record, "actor"),
+			Action: memgraph.String(record, "action"),
+			At:     memgraph.Int64(record, "at"),
+		}
+		if details := memgraph.String(record, "details"); details != "" {
+			if err := json.Unmarshal([]byte(details), &entry.Details); err != nil {
+				slog.Warn("unreadable details on audit entry", "entryId", entry.ID, "error", err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return &FarmAuditLog{Entries: entries, Pagination: utils.NewPaginationInfo(page, limit, total)}, nil
+}
+
+// farmChanges lists the fields an update changed, with old and new values for the
+// scalar ones
+func farmChanges(before, after *FarmList, params map[string]any) map[string]any {
+	changes := make(map[string]any)
+	diff := func(field string, from, to any) {
+		if from != to {
+			changes[field] = map[string]any{"from": from, "to": to}
+		}
+	}
+	diff("farmName", before.FarmName, after.FarmName)
+	diff("cropType", before.CropType, after.CropType)
+	diff("description", before.Description, after.Description)
+	diff("location", before.Location, after.Location)
+	diff("lat", before.Coordinates.Lat, after.Coordinates.Lat)
+	diff("lng", before.Coordinates.Lng, after.Coordinates.Lng)
+	if _, ok := params["boundary"]; ok {
+		changes["boundary"] = map[string]any{"areaHectares": after.AreaHectares}
+	}
+	if _, ok := params["image"]; ok {
+		changes["image"] = map[string]any{"to": after.Image}
+	}
+	return changes
+}
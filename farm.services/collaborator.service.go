@@ -0,0 +1,322 @@
+package farmservices
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+)
+
+// Farm collaborator errors
+var (
+	ErrFarmAccessDenied     = errors.New("you do not have access to this farm")
+	ErrCollaboratorNotFound = errors.New("collaborator not found")
+)
+
+// farmRoleRank orders roles so a stronger role satisfies a weaker requirement
+var farmRoleRank = map[string]int{
+	FarmRoleViewer:  1,
+	FarmRoleManager: 2,
+	FarmRoleOwner:   3,
+}
+
+// accessibleFarmCondition matches live farms f that $username owns or was granted access to
+const accessibleFarmCondition = `(f.deletedAt IS NULL AND (toLower(f.owner) = toLower($username) OR exists((f)-[:GRANTS]->(:User {username: $username}))))`
+
+// InviteCollaborator grants a user a role on one of the caller's farms. Inviting an
+// existing collaborator changes their role.
+func InviteCollaborator(token, farmID string, req InviteCollaboratorRequest) (*FarmCollaborator, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	farm, err := getOwnedFarm(farmID, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCollaboratorRole(req.Role); err != nil {
+		return nil, err
+	}
+
+	wallet := strings.TrimSpace(req.WalletAddress)
+	email := strings.TrimSpace(req.Email)
+	if wallet == "" && email == "" {
+		return nil, invalidf("walletAddress or email is required")
+	}
+	records, err := memgraph.ExecuteRead(`MATCH (u:User) WHERE u.deletedAt IS NULL
+		AND (($wallet <> '' AND (toLower(u.walletAddress) = toLower($wallet) OR toLower(u.username) = toLower($wallet)))
+			OR ($wallet = '' AND toLower(u.email) = toLower($email)))
+		RETURN u.username AS username
+		LIMIT 1`, map[string]any{"wallet": wallet, "email": email})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrCollaboratorNotFound
+	}
+	invitee := memgraph.String(records[0], "username")
+	if strings.EqualFold(invitee, farm.Owner) {
+		return nil, invalidf("the farm owner cannot be invited")
+	}
+
+	if _, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId}), (u:User {username: $username})
+		MERGE (f)-[g:GRANTS]->(u)
+		SET g.role = $role, g.grantedBy = $grantedBy, g.grantedAt = $grantedAt`, map[string]any{
+		"farmId":    farmID,
+		"username":  invitee,
+		"role":      req.Role,
+		"grantedBy": username,
+		"grantedAt": time.Now().UnixMilli(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to grant farm access: %w", err)
+	}
+	recordFarmAudit(farmID, username, AuditCollaboratorInvited, map[string]any{"username": invitee, "role": req.Role})
+
+	notificationServices.NotifyAsync(invitee, notificationServices.TypeFarmShared,
+		"A farm was shared with you",
+		fmt.Sprintf("You were added to %s as a %s.", farm.FarmName, req.Role),
+		map[string]any{"farmId": farm.ID, "farmName": farm.FarmName, "role": req.Role})
+
+	collaborator, err := getCollaborator(farmID, invitee)
+	if err != nil {
+		return nil, err
+	}
+	return collaborator, nil
+}
+
+// GetCollaborators lists the users a farm is shared with
+func GetCollaborators(token, farmID string) ([]FarmCollaborator, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+	return queryCollaborators(farmID, "")
+}
+
+// UpdateCollaboratorRole changes a collaborator's role on one of the caller's farms
+func UpdateCollaboratorRole(token, farmID, collaborator, role string) (*FarmCollaborator, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := getOwnedFarm(farmID, username); err != nil {
+		return nil, err
+	}
+	if err := validateCollaboratorRole(role); err != nil {
+		return nil, err
+	}
+
+	summary, err := memgraph.ExecuteWrite(`MATCH (:Farm {id: $farmId})-[g:GRANTS]->(:User {username: $username})
+		SET g.role = $role`, map[string]any{"farmId": farmID, "username": collaborator, "role": role})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update collaborator: %w", err)
+	}
+	if summary == nil || summary.Counters().PropertiesSet() == 0 {
+		return nil, ErrCollaboratorNotFound
+	}
+	recordFarmAudit(farmID, username, AuditCollaboratorUpdated, map[string]any{"username": collaborator, "role": role})
+	return getCollaborator(farmID, collaborator)
+}
+
+// RemoveCollaborator revokes a user's access to a farm. The owner can remove anyone;
+// collaborators can remove themselves to leave a farm.
+func RemoveCollaborator(token, farmID, collaborator string) error {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return err
+	}
+	farm, err := getFarm(farmID)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(farm.Owner, username) && !strings.EqualFold(collaborator, username) {
+		return ErrNotFarmOwner
+	}
+
+	summary, err := memgraph.ExecuteWrite(`MATCH (:Farm {id: $farmId})-[g:GRANTS]->(:User {username: $username})
+		DELETE g`, map[string]any{"farmId": farmID, "username": collaborator})
+	if err != nil {
+		return fmt.Errorf("failed to remove collaborator: %w", err)
+	}
+	if summary == nil || summary.Counters().RelationshipsDeleted() == 0 {
+		return ErrCollaboratorNotFound
+	}
+	recordFarmAudit(farmID, username, AuditCollaboratorRemoved, map[string]any{"username": collaborator})
+	return nil
+}
+
+// GetSharedFarms returns the farms other users have shared with the caller
+func GetSharedFarms(token string) ([]SharedFarm, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[g:GRANTS]->(:User {username: $username})
+		WHERE f.deletedAt IS NULL
+		RETURN f.id AS id, g.role AS role
+		ORDER BY f.farmName`, map[string]any{"username": username})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch shared farms: %w", err)
+	}
+
+	farms := make([]SharedFarm, 0, len(records))
+	for _, record := range records {
+		farm, err := farmDetail(memgraph.String(record, "id"))
+		if err != nil {
+			continue
+		}
+		farms = append(farms, SharedFarm{FarmList: *farm, Role: memgraph.String(record, "role")})
+	}
+	return farms, nil
+}
+
+// authorizeFarm loads a farm and checks that username holds at least minRole on it,
+// returning the farm and the user's role
+func authorizeFarm(farmID, username, minRole string) (*FarmList, string, error) {
+	farm, err := getFarm(farmID)
+	if err != nil {
+		return nil, "", err
+	}
+	return authorizeLoadedFarm(farm, username, minRole)
+}
+
+// authorizeFarmByName is authorizeFarm for the endpoints that address farms by name
+func authorizeFarmByName(farmName, username, minRole string) (*FarmList, string, error) {
+	farm, err := farms.FindByName(farmName)
+	if err != nil {
+		return nil, "", err
+	}
+	return authorizeLoadedFarm(farm, username, minRole)
+}
+
+// authorizeLoadedFarm checks that username holds at least minRole on farm
+func authorizeLoadedFarm(farm *FarmList, username, minRole string) (*FarmList, string, error) {
+	role, err := farmRole(farm, username)
+	if err != nil {
+		return nil, "", err
+	}
+	if farmRoleRank[role] < farmRoleRank[minRole] {
+		if role == "" {
+			return nil, "", ErrFarmAccessDenied
+		}
+		return nil, "", fmt.Errorf("%w: requires the %s role", ErrFarmAccessDenied, minRole)
+	}
+	return farm, role, nil
+}
+
+// farmRole returns username's role on a farm, or "" when they have no access
+func farmRole(farm *FarmList, username string) (string, error) {
+	if username == "" {
+		return "", nil
+	}
+	if strings.EqualFold(farm.Owner, username) {
+		return FarmRoleOwner, nil
+	}
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[g:GRANTS]->(:User {username: $username})
+		RETURN g.role AS role`, map[string]any{"farmId": farm.ID, "username": username})
+	if err != nil {
+		return "", fmt.Errorf("failed to check farm access: %w", err)
+	}
+	if len(records) == 0 {
+		return "", nil
+	}
+	return memgraph.String(records[0], "role"), nil
+}
+
+// viewableFarmIDs returns the IDs of the farms shared with username, at any role
+func viewableFarmIDs(username string) (map[string]bool, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:GRANTS]->(:User {username: $username})
+		RETURN f.id AS id`, map[string]any{"username": username})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch shared farms: %w", err)
+	}
+	ids := make(map[string]bool, len(records))
+	for _, record := range records {
+		ids[memgraph.String(record, "id")] = true
+	}
+	return ids, nil
+}
+
+// farmManagers returns the farm owner followed by the users granted the manager role
+func farmManagers(farm *FarmList) ([]string, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[:GRANTS {role: $role}]->(u:User)
+		RETURN u.username AS username`, map[string]any{"farmId": farm.ID, "role": FarmRoleManager})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch farm managers: %w", err)
+	}
+
+	managers := []string{farm.Owner}
+	for _, record := range records {
+		managers = append(managers, memgraph.String(record, "username"))
+	}
+	return managers, nil
+}
+
+// farmMembers returns the farm owner followed by every collaborator
+func farmMembers(farm *FarmList) ([]string, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[:GRANTS]->(u:User)
+		RETURN u.username AS username`, map[string]any{"farmId": farm.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch farm members: %w", err)
+	}
+
+	members := []string{farm.Owner}
+	for _, record := range records {
+		members = append(members, memgraph.String(record, "username"))
+	}
+	return members, nil
+}
+
+// getCollaborator reads one collaborator of a farm
+func getCollaborator(farmID, username string) (*FarmCollaborator, error) {
+	collaborators, err := queryCollaborators(farmID, username)
+	if err != nil {
+		return nil, err
+	}
+	if len(collaborators) == 0 {
+		return nil, ErrCollaboratorNotFound
+	}
+	return &collaborators[0], nil
+}
+
+// queryCollaborators reads a farm's collaborators, or only username's grant when given
+func queryCollaborators(farmID, username string) ([]FarmCollaborator, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[g:GRANTS]->(u:User)
+		WHERE $username = '' OR u.username = $username
+		RETURN u.username AS username, u.walletAddress AS walletAddress, u.email AS email, u.name AS name,
+			g.role AS role, g.grantedBy AS grantedBy, g.grantedAt AS grantedAt
+		ORDER BY g.grantedAt`, map[string]any{"farmId": farmID, "username": username})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch collaborators: %w", err)
+	}
+
+	collaborators := make([]FarmCollaborator, 0, len(records))
+	for _, record := range records {
+		collaborators = append(collaborators, FarmCollaborator{
+			Username:      memgraph.String(record, "username"),
+			WalletAddress: memgraph.String(record, "walletAddress"),
+			Email:         memgraph.String(record, "email"),
+			Name:          memgraph.String(record, "name"),
+			Role:          memgraph.String(record, "role"),
+			GrantedBy:     memgraph.String(record, "grantedBy"),
+			GrantedAt:     memgraph.Int64(record, "grantedAt"),
+		})
+	}
+	return collaborators, nil
+}
+
+// validateCollaboratorRole accepts the roles that can be granted
+func validateCollaboratorRole(role string) error {
+	if role != FarmRoleViewer && role != FarmRoleManager {
+		return invalidf("role must be viewer or manager")
+	}
+	return nil
+}
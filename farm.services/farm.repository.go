@@ -0,0 +1,139 @@
+package farmservices
+
+import (
+	"fmt"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// FarmRepository loads and checks Farm nodes. Services reach it through farms, which
+// tests can replace with a fake to run without a database.
+type FarmRepository interface {
+	// FindByID returns the farm's stored fields, or ErrFarmNotFound when it does not
+	// exist or is deleted
+	FindByID(farmID string) (*FarmList, error)
+	// FindDeleted returns a soft-deleted farm's stored fields, or ErrFarmNotFound
+	FindDeleted(farmID string) (*FarmList, error)
+	// FindByName returns the live farm named farmName, or ErrFarmNotFound
+	FindByName(farmName string) (*FarmList, error)
+	// NameTaken reports whether a farm other than exceptID uses farmName. Deleted farms
+	// give up their name (see Delete), so only live farms count.
+	NameTaken(farmName, exceptID string) (bool, error)
+	// Delete soft-deletes a live farm by actor and frees its name for new farms. It
+	// reports false when no live farm matched.
+	Delete(farmID, actor string) (bool, error)
+	// Restore undeletes a farm under its original name. It reports false when no deleted
+	// farm matched, and returns ErrFarmNameTaken when another farm took the name since.
+	Restore(farmID string) (bool, error)
+}
+
+// farms is the repository the farm services use
+var farms FarmRepository = memgraphFarmRepository{}
+
+// deletedNameSuffix is appended to a deleted farm's name, with its ID, to free the name
+// under the farmName unique constraint. Farm names cannot contain '#', so the suffixed
+// name never collides with a live one.
+const deletedNameSuffix = "#deleted-"
+
+// farmFields projects a farm matched as f into the columns mapFarm reads. A deleted farm
+// reports the name it had before deletion.
+const farmFields = `f.id AS id, coalesce(f.deletedFarmName, f.farmName) AS farmName, f.cropType AS cropType,
+	f.description AS description, f.image AS image, f.owner AS owner,
+	f.location AS location, f.lat AS lat, f.lng AS lng,
+	f.boundary AS boundary, f.areaHectares AS areaHectares, f.verified AS verified,
+	f.createdAt AS createdAt, f.updatedAt AS updatedAt`
+
+// memgraphFarmRepository is the FarmRepository backed by Memgraph
+type memgraphFarmRepository struct{}
+
+func (memgraphFarmRepository) FindByID(farmID string) (*FarmList, error) {
+	return findFarm(`MATCH (f:Farm {id: $id}) WHERE f.deletedAt IS NULL RETURN `+farmFields, farmID)
+}
+
+func (memgraphFarmRepository) FindDeleted(farmID string) (*FarmList, error) {
+	return findFarm(`MATCH (f:Farm {id: $id}) WHERE f.deletedAt IS NOT NULL RETURN `+farmFields, farmID)
+}
+
+func (memgraphFarmRepository) FindByName(farmName string) (*FarmList, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm {farmName: $farmName}) WHERE f.deletedAt IS NULL RETURN `+farmFields,
+		map[string]any{"farmName": farmName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load farm: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrFarmNotFound
+	}
+	return mapFarm(records[0]), nil
+}
+
+// findFarm runs a single-farm lookup whose parameter is $id
+func findFarm(query, farmID string) (*FarmList, error) {
+	records, err := memgraph.ExecuteRead(query, map[string]any{"id": farmID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load farm: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrFarmNotFound
+	}
+	return mapFarm(records[0]), nil
+}
+
+func (memgraphFarmRepository) Delete(farmID, actor string) (bool, error) {
+	summary, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $id}) WHERE f.deletedAt IS NULL
+		SET f.deletedAt = $now, f.deletedBy = $actor,
+			f.deletedFarmName = f.farmName, f.farmName = f.farmName + $suffix + f.id`,
+		map[string]any{"id": farmID, "now": time.Now().UnixMilli(), "actor": actor, "suffix": deletedNameSuffix})
+	if err != nil {
+		return false, err
+	}
+	return summary != nil && summary.Counters().PropertiesSet() > 0, nil
+}
+
+func (memgraphFarmRepository) Restore(farmID string) (bool, error) {
+	summary, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $id}) WHERE f.deletedAt IS NOT NULL
+		SET f.deletedAt = null, f.deletedBy = null,
+			f.farmName = coalesce(f.deletedFarmName, f.farmName), f.deletedFarmName = null`,
+		map[string]any{"id": farmID})
+	if memgraph.IsConstraintViolation(err) {
+		return false, ErrFarmNameTaken
+	}
+	if err != nil {
+		return false, err
+	}
+	return summary != nil && summary.Counters().PropertiesSet() > 0, nil
+}
+
+func (memgraphFarmRepository) NameTaken(farmName, exceptID string) (bool, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm {farmName: $farmName})
+		WHERE f.id <> $exceptId
+		RETURN count(f) AS total`, map[string]any{"farmName": farmName, "exceptId": exceptID})
+	if err != nil {
+		return false, fmt.Errorf("failed to check farm name: %w", err)
+	}
+	return len(records) > 0 && memgraph.Int64(records[0], "total") > 0, nil
+}
+
+// mapFarm builds a farm from a record projected with farmFields
+func mapFarm(record *neo4j.Record) *FarmList {
+	farm := &FarmList{
+		ID:          memgraph.String(record, "id"),
+		FarmName:    memgraph.String(record, "farmName"),
+		CropType:    memgraph.String(record, "cropType"),
+		Description: memgraph.String(record, "description"),
+		Image:       memgraph.String(record, "image"),
+		Owner:       memgraph.String(record, "owner"),
+		Location:    memgraph.String(record, "location"),
+		Verified:    memgraph.Bool(record, "verified"),
+	}
+	farm.Coordinates.Lat, _ = memgraph.Float64(record, "lat")
+	farm.Coordinates.Lng, _ = memgraph.Float64(record, "lng")
+	farm.Boundary, farm.AreaHectares = decodeBoundary(record)
+	rawCreatedAt, _ := record.Get("createdAt")
+	farm.CreatedAt = parseDate(rawCreatedAt)
+	rawUpdatedAt, _ := record.Get("updatedAt")
+	farm.UpdatedAt = parseDate(rawUpdatedAt)
+	return farm
+}
@@ -2,39 +2,76 @@ package farmservices
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
 	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
 	memgraph "decentragri-app-cx-server/db"
 	marketplaceservices "decentragri-app-cx-server/marketplace.services"
-
-	// tokenservices "decentragri-app-cx-server/token.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
-// GetFarmList fetches farms for a user, formats dates, and fetches image bytes.
-func GetFarmList() ([]FarmList, error) {
-	// Handle dev bypass token first
-	// var username string
-	// var err error
-
-	// if token == "dev_bypass_authorized" {
-	// 	fmt.Println("Dev bypass detected in farm service")
-	// 	username = "0x984785A89BF95cb3d5Df4E45F670081944d8D547" // Treasury wallet for testing
-	// } else {
-	// 	// Standard JWT token verification
-	// 	tokenService := tokenservices.NewTokenService()
-	// 	username, err = tokenService.VerifyAccessToken(token)
-	// 	if err != nil {
-	// 		return nil, fmt.Errorf("token verification failed: %w", err)
-	// 	}
-	// }
+// GetFarmList fetches the farms the caller owns or has been granted access to, formats
+// dates, and fetches image bytes. With includeImages false the image fetches are skipped
+// and farms carry only imageUrl; the two modes are cached separately.
+func GetFarmList(token string, includeImages bool) ([]FarmList, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	all, err := allFarms(includeImages)
+	if err != nil {
+		return []FarmList{}, err
+	}
+	shared, err := viewableFarmIDs(username)
+	if err != nil {
+		return []FarmList{}, err
+	}
+
+	farms := make([]FarmList, 0)
+	for _, farm := range all {
+		if strings.EqualFold(farm.Owner, username) || shared[farm.ID] {
+			farms = append(farms, farm)
+		}
+	}
+	return farms, nil
+}
+
+// allFarms returns every farm from the shared farm list cache, loading it on a miss
+func allFarms(includeImages bool) ([]FarmList, error) {
+	cacheKey := farmListCacheKey
+	if !includeImages {
+		cacheKey = farmListLiteCacheKey
+	}
+
+	var cachedFarms []FarmList
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cachedFarms); err == nil {
+			return cachedFarms, nil
+		}
+	}
+
+	farms, err := cache.Load(cacheKey, func() (interface{}, error) {
+		return loadFarmList(cacheKey, includeImages)
+	})
+	if err != nil {
+		return []FarmList{}, err
+	}
+	// Routes localize the list in place, so each caller gets its own copy of a shared load
+	return append([]FarmList(nil), farms.([]FarmList)...), nil
+}
 
+// loadFarmList reads every farm from the database, fetching images when includeImages
+// is set, and caches the list under cacheKey
+func loadFarmList(cacheKey string, includeImages bool) ([]FarmList, error) {
 	cypher := `
-        MATCH (f:Farm)
+        MATCH (f:Farm) WHERE f.deletedAt IS NULL
         RETURN f.id as id, 
                f.farmName as farmName, 
                f.cropType as cropType, 
@@ -46,7 +83,10 @@ func GetFarmList() ([]FarmList, error) {
                f.owner as owner,
                f.location as location,
                f.lat as lat, 
-               f.lng as lng
+               f.lng as lng,
+               f.boundary as boundary,
+               f.areaHectares as areaHectares,
+               f.verified as verified
     `
 
 	records, err := memgraph.ExecuteRead(cypher, map[string]interface{}{})
@@ -66,9 +106,9 @@ func GetFarmList() ([]FarmList, error) {
 
 		formattedUpdatedAt := ""
 		if !updatedAt.IsZero() {
-			formattedUpdatedAt = updatedAt.Format("January 2, 2006")
+			formattedUpdatedAt = utils.FormatDate(updatedAt, utils.DefaultLocale)
 		} else {
-			log.Printf("Zero time detected for farm updatedAt, rawUpdatedAt: %v", rawUpdatedAt)
+			slog.Debug("zero time detected for farm updatedAt", "rawUpdatedAt", rawUpdatedAt)
 			formattedUpdatedAt = "Date unavailable"
 		}
 
@@ -77,27 +117,32 @@ func GetFarmList() ([]FarmList, error) {
 
 		formattedCreatedAt := ""
 		if !createdAt.IsZero() {
-			formattedCreatedAt = createdAt.Format("January 2, 2006")
+			formattedCreatedAt = utils.FormatDate(createdAt, utils.DefaultLocale)
 		} else {
-			log.Printf("Zero time detected for farm createdAt, rawCreatedAt: %v", rawCreatedAt)
+			slog.Debug("zero time detected for farm createdAt", "rawCreatedAt", rawCreatedAt)
 			formattedCreatedAt = "Date unavailable"
 		}
 
-		// Fetch image bytes
-		imageURL, _ := record.Get("image")
-		imageBytes := ByteArray{}
-		if s, ok := imageURL.(string); ok && s != "" {
-			log.Printf("Fetching image for farm: %s", getString(record, "farmName"))
+		// Convert IPFS URL to HTTP gateway URL if needed
+		httpURL := ""
+		if s := memgraph.String(record, "image"); s != "" {
+			httpURL = marketplaceservices.BuildIpfsUri(s)
+		}
 
-			// Convert IPFS URL to HTTP gateway URL if needed
-			httpURL := marketplaceservices.BuildIpfsUri(s)
+		// Fetch image bytes
+		var imageBytes ByteArray
+		if includeImages {
+			imageBytes = ByteArray{}
+		}
+		if includeImages && httpURL != "" {
+			slog.Debug("fetching farm image", "farm", memgraph.String(record, "farmName"))
 
 			img, err := marketplaceservices.FetchImageBytes(httpURL)
 			if err != nil {
-				log.Printf("Error fetching image bytes for farm %s: %v", getString(record, "farmName"), err)
+				slog.Warn("failed to fetch farm image", "farm", memgraph.String(record, "farmName"), "error", err)
 			} else {
 				imageBytes = ByteArray(img)
-				log.Printf("Successfully fetched %d bytes for farm: %s", len(imageBytes), getString(record, "farmName"))
+				slog.Debug("fetched farm image", "bytes", len(imageBytes), "farm", memgraph.String(record, "farmName"))
 			}
 		}
 
@@ -110,24 +155,33 @@ func GetFarmList() ([]FarmList, error) {
 			}
 		}
 
+		boundary, areaHectares := decodeBoundary(record)
+
 		farm := FarmList{
-			Owner:              getString(record, "owner"),
-			FarmName:           getString(record, "farmName"),
-			ID:                 getString(record, "id"),
-			CropType:           getString(record, "cropType"),
-			Description:        getString(record, "description"),
-			Image:              getString(record, "image"),
+			Owner:              memgraph.String(record, "owner"),
+			FarmName:           memgraph.String(record, "farmName"),
+			ID:                 memgraph.String(record, "id"),
+			CropType:           memgraph.String(record, "cropType"),
+			Description:        memgraph.String(record, "description"),
+			Image:              memgraph.String(record, "image"),
+			ImageURL:           httpURL,
 			Coordinates:        coords,
 			UpdatedAt:          updatedAt,
 			CreatedAt:          createdAt,
 			FormattedUpdatedAt: formattedUpdatedAt,
 			FormattedCreatedAt: formattedCreatedAt,
 			ImageBytes:         imageBytes,
-			Location:           getString(record, "location"),
+			Location:           memgraph.String(record, "location"),
+			Boundary:           boundary,
+			AreaHectares:       areaHectares,
+			Verified:           memgraph.Bool(record, "verified"),
 		}
 		farms = append(farms, farm)
 	}
 
+	// Cache for 5 minutes; farm create/update/delete invalidates it
+	cache.Set(cacheKey, farms, config.CacheTTL(config.TTLFarmList))
+
 	return farms, nil
 }
 
@@ -167,17 +221,24 @@ func parseDate(val interface{}) time.Time {
 	default:
 		return time.Time{}
 	}
-} // getString safely gets a string from record
-func getString(record *neo4j.Record, key string) string {
-	val, _ := record.Get(key)
-	if s, ok := val.(string); ok {
-		return s
+}
+
+// GetFarmScans fetches recent farm scans with pagination (plant scans and soil readings)
+// for a caller with at least viewer access to the farm. filter narrows the scans to a
+// date window and/or one scan type.
+func GetFarmScans(token, farmName string, page, limit int, filter ScanFilter) (*FarmScanResult, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
 	}
-	return ""
+	if _, _, err := authorizeFarmByName(farmName, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+	return farmScans(farmName, page, limit, filter)
 }
 
-// GetFarmScans fetches recent farm scans with pagination (plant scans and soil readings) - OPTIMIZED VERSION
-func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
+// farmScans loads a page of a farm's scans - OPTIMIZED VERSION
+func farmScans(farmName string, page, limit int, filter ScanFilter) (*FarmScanResult, error) {
 	// Calculate offset for pagination
 	offset := (page - 1) * limit
 
@@ -191,6 +252,9 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 
 	// Check cache first - cache key includes pagination params
 	cacheKey := fmt.Sprintf("farm_scans:%s:page_%d:limit_%d", farmName, page, limit)
+	if !filter.IsZero() {
+		cacheKey += fmt.Sprintf(":type_%s:from_%s:to_%s", filter.Type, filter.From, filter.To)
+	}
 	var cachedResult FarmScanResult
 	if cache.Exists(cacheKey) {
 		err := cache.Get(cacheKey, &cachedResult)
@@ -202,7 +266,10 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 	// Query for plant scans with pagination - using the correct 'date' field
 	plantScansCypher := `
 		MATCH (f:Farm {farmName: $farmName})-[:HAS_PLANT_SCAN]->(ps:PlantScan)
-		WITH ps ORDER BY COALESCE(ps.date, ps.createdAt, ps.created_at, ps.timestamp, '1970-01-01T00:00:00Z') DESC
+		WHERE f.deletedAt IS NULL AND ps.deletedAt IS NULL
+		WITH ps, COALESCE(ps.date, ps.createdAt, ps.created_at, ps.timestamp, '1970-01-01T00:00:00Z') AS scannedAt
+		WHERE ($from = '' OR scannedAt >= $from) AND ($to = '' OR scannedAt < $to)
+		WITH ps ORDER BY scannedAt DESC
 		RETURN ps.cropType as cropType,
 			   ps.note as note,
 			   ps.date as date,
@@ -212,6 +279,7 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 			   ps.id as id,
 			   ps.interpretation as interpretation,
 			   ps.imageUri as imageUri,
+			   ps.interpretationStatus as interpretationStatus,
 			   properties(ps) as allProperties
 		SKIP $offset LIMIT $limit
 	`
@@ -219,6 +287,7 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 	// Query for soil readings with pagination - corrected relationship path
 	soilReadingsCypher := `
 		MATCH (f:Farm {farmName: $farmName})-[:HAS_SENSOR]->(s:Sensor)-[:HAS_READING]->(r:Reading)
+		WHERE f.deletedAt IS NULL AND ($from = '' OR r.createdAt >= $from) AND ($to = '' OR r.createdAt < $to)
 		OPTIONAL MATCH (r)-[:INTERPRETED_AS]->(i:Interpretation)
 		WITH r, i ORDER BY r.createdAt DESC
 		RETURN r.fertility as fertility,
@@ -237,14 +306,18 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 		SKIP $offset LIMIT $limit
 	`
 
-	// Count queries for pagination - simplified to only use farmName
+	// Count queries for pagination, applying the same date window
 	plantScansCountCypher := `
 		MATCH (f:Farm {farmName: $farmName})-[:HAS_PLANT_SCAN]->(ps:PlantScan)
-		RETURN COUNT(ps) as total
+		WHERE f.deletedAt IS NULL AND ps.deletedAt IS NULL
+		WITH COALESCE(ps.date, ps.createdAt, ps.created_at, ps.timestamp, '1970-01-01T00:00:00Z') AS scannedAt
+		WHERE ($from = '' OR scannedAt >= $from) AND ($to = '' OR scannedAt < $to)
+		RETURN COUNT(*) as total
 	`
 
 	soilReadingsCountCypher := `
 		MATCH (f:Farm {farmName: $farmName})-[:HAS_SENSOR]->(s:Sensor)-[:HAS_READING]->(r:Reading)
+		WHERE f.deletedAt IS NULL AND ($from = '' OR r.createdAt >= $from) AND ($to = '' OR r.createdAt < $to)
 		RETURN COUNT(r) as total
 	`
 
@@ -252,7 +325,16 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 		"farmName": farmName,
 		"offset":   offset,
 		"limit":    limit,
+		"from":     filter.From,
+		"to":       filter.To,
+	}
+	countParams := map[string]interface{}{
+		"farmName": farmName,
+		"from":     filter.From,
+		"to":       filter.To,
 	}
+	includePlant := filter.Type == "" || filter.Type == ScanTypePlant
+	includeSoil := filter.Type == "" || filter.Type == ScanTypeSoil
 
 	// OPTIMIZATION: Execute all 4 database queries concurrently using goroutines
 	var wg sync.WaitGroup
@@ -261,29 +343,34 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 
 	wg.Add(4)
 
-	// Concurrent query execution
+	// Concurrent query execution; a type filter skips the other type's queries. Scan
+	// pages tolerate replica lag, so they read from the replica when one is configured.
 	go func() {
 		defer wg.Done()
-		plantScanRecords, plantErr = memgraph.ExecuteRead(plantScansCypher, params)
+		if includePlant {
+			plantScanRecords, plantErr = memgraph.ExecuteReplicaRead(plantScansCypher, params)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
-		soilReadingRecords, soilErr = memgraph.ExecuteRead(soilReadingsCypher, params)
+		if includeSoil {
+			soilReadingRecords, soilErr = memgraph.ExecuteReplicaRead(soilReadingsCypher, params)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
-		plantCountRecords, plantCountErr = memgraph.ExecuteRead(plantScansCountCypher, map[string]interface{}{
-			"farmName": farmName,
-		})
+		if includePlant {
+			plantCountRecords, plantCountErr = memgraph.ExecuteReplicaRead(plantScansCountCypher, countParams)
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
-		soilCountRecords, soilCountErr = memgraph.ExecuteRead(soilReadingsCountCypher, map[string]interface{}{
-			"farmName": farmName,
-		})
+		if includeSoil {
+			soilCountRecords, soilCountErr = memgraph.ExecuteReplicaRead(soilReadingsCountCypher, countParams)
+		}
 	}()
 
 	wg.Wait()
@@ -302,13 +389,59 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 		return nil, fmt.Errorf("failed to get soil readings count: %w", soilCountErr)
 	}
 
-	// OPTIMIZATION: Process plant scans with concurrent image fetching
-	plantScans := make([]PlantScanResult, len(plantScanRecords))
-	if len(plantScanRecords) > 0 {
+	plantScans := mapPlantScans(plantScanRecords)
+	soilReadings := mapSoilReadings(soilReadingRecords)
+
+	// Calculate pagination info
+	plantTotal := 0
+	if len(plantCountRecords) > 0 {
+		if total, ok := plantCountRecords[0].Get("total"); ok {
+			if t, ok := total.(int64); ok {
+				plantTotal = int(t)
+			}
+		}
+	}
+
+	soilTotal := 0
+	if len(soilCountRecords) > 0 {
+		if total, ok := soilCountRecords[0].Get("total"); ok {
+			if t, ok := total.(int64); ok {
+				soilTotal = int(t)
+			}
+		}
+	}
+
+	// For simplicity, we'll use the max of both totals for overall pagination
+	total := plantTotal
+	if soilTotal > total {
+		total = soilTotal
+	}
+
+	tagGrowthStages(farmName, plantScans, soilReadings)
+
+	pagination := utils.NewPaginationInfo(page, limit, total)
+
+	result := &FarmScanResult{
+		PlantScans:   plantScans,
+		SoilReadings: soilReadings,
+		Pagination:   &pagination,
+	}
+
+	// Cache the result for 5 minutes to speed up subsequent requests
+	cacheFarmScans(farmName, cacheKey, *result, config.CacheTTL(config.TTLFarmScans))
+
+	return result, nil
+}
+
+// mapPlantScans builds plant scan results from scan page records, fetching their images
+// concurrently (cached images are reused)
+func mapPlantScans(records []*neo4j.Record) []PlantScanResult {
+	plantScans := make([]PlantScanResult, len(records))
+	if len(records) > 0 {
 		var imageWg sync.WaitGroup
-		imageWg.Add(len(plantScanRecords))
+		imageWg.Add(len(records))
 
-		for i, record := range plantScanRecords {
+		for i, record := range records {
 			go func(index int, rec *neo4j.Record) {
 				defer imageWg.Done()
 
@@ -328,7 +461,7 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 				createdAt := parseDate(actualDateValue)
 				formattedCreatedAt := ""
 				if !createdAt.IsZero() {
-					formattedCreatedAt = createdAt.Format("January 2, 2006 - 3:04pm")
+					formattedCreatedAt = utils.FormatDateTime(createdAt, utils.DefaultLocale)
 				} else {
 					formattedCreatedAt = "Date unavailable"
 				}
@@ -344,14 +477,15 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 				}
 
 				plantScans[index] = PlantScanResult{
-					CropType:           getString(rec, "cropType"),
-					Note:               getString(rec, "note"),
-					CreatedAt:          createdAt,
-					FormattedCreatedAt: formattedCreatedAt,
-					ID:                 getString(rec, "id"),
-					Interpretation:     parsePlantScanInterpretation(rec, "interpretation"),
-					ImageURI:           getString(rec, "imageUri"),
-					ImageBytes:         imageBytes,
+					CropType:             memgraph.String(rec, "cropType"),
+					Note:                 memgraph.String(rec, "note"),
+					CreatedAt:            createdAt,
+					FormattedCreatedAt:   formattedCreatedAt,
+					ID:                   memgraph.String(rec, "id"),
+					Interpretation:       parsePlantScanInterpretation(rec, "interpretation"),
+					ImageURI:             memgraph.String(rec, "imageUri"),
+					ImageBytes:           imageBytes,
+					InterpretationStatus: memgraph.String(rec, "interpretationStatus"),
 				}
 			}(i, record)
 		}
@@ -359,15 +493,20 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 		imageWg.Wait()
 	}
 
-	// Process soil readings (no images, so sequential processing is fine)
-	soilReadings := make([]SensorReadingsWithInterpretation, 0, len(soilReadingRecords))
-	for _, record := range soilReadingRecords {
+	return plantScans
+}
+
+// mapSoilReadings builds soil reading results from reading page records (no images, so
+// sequential processing is fine)
+func mapSoilReadings(records []*neo4j.Record) []SensorReadingsWithInterpretation {
+	soilReadings := make([]SensorReadingsWithInterpretation, 0, len(records))
+	for _, record := range records {
 		rawCreatedAt, _ := record.Get("createdAt")
 		createdAt := parseDate(rawCreatedAt)
 
 		formattedCreatedAt := ""
 		if !createdAt.IsZero() {
-			formattedCreatedAt = createdAt.Format("January 2, 2006 - 3:04pm")
+			formattedCreatedAt = utils.FormatDateTime(createdAt, utils.DefaultLocale)
 		} else {
 			formattedCreatedAt = "Date unavailable"
 		}
@@ -377,18 +516,18 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 
 		formattedSubmittedAt := ""
 		if !submittedAt.IsZero() {
-			formattedSubmittedAt = submittedAt.Format("January 2, 2006 - 3:04pm")
+			formattedSubmittedAt = utils.FormatDateTime(submittedAt, utils.DefaultLocale)
 		} else {
 			formattedSubmittedAt = "Date unavailable"
 		}
 
 		// Parse sensor reading values
-		fertility, _ := getFloat64(record, "fertility")
-		moisture, _ := getFloat64(record, "moisture")
-		ph, _ := getFloat64(record, "ph")
-		temperature, _ := getFloat64(record, "temperature")
-		sunlight, _ := getFloat64(record, "sunlight")
-		humidity, _ := getFloat64(record, "humidity")
+		fertility, _ := memgraph.Float64(record, "fertility")
+		moisture, _ := memgraph.Float64(record, "moisture")
+		ph, _ := memgraph.Float64(record, "ph")
+		temperature, _ := memgraph.Float64(record, "temperature")
+		sunlight, _ := memgraph.Float64(record, "sunlight")
+		humidity, _ := memgraph.Float64(record, "humidity")
 
 		// Parse interpretation from the connected Interpretation node
 		interpretation := parseInterpretation(record, "interpretation")
@@ -401,10 +540,10 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 				Temperature:          temperature,
 				Sunlight:             sunlight,
 				Humidity:             humidity,
-				FarmName:             getString(record, "farmName"),
-				CropType:             getString(record, "cropType"),
-				SensorID:             getString(record, "sensorId"),
-				ID:                   getString(record, "id"),
+				FarmName:             memgraph.String(record, "farmName"),
+				CropType:             memgraph.String(record, "cropType"),
+				SensorID:             memgraph.String(record, "sensorId"),
+				ID:                   memgraph.String(record, "id"),
 				CreatedAt:            createdAt,
 				SubmittedAt:          submittedAt,
 				FormattedCreatedAt:   formattedCreatedAt,
@@ -415,54 +554,28 @@ func GetFarmScans(farmName string, page, limit int) (*FarmScanResult, error) {
 		soilReadings = append(soilReadings, soilReading)
 	}
 
-	// Calculate pagination info
-	plantTotal := 0
-	if len(plantCountRecords) > 0 {
-		if total, ok := plantCountRecords[0].Get("total"); ok {
-			if t, ok := total.(int64); ok {
-				plantTotal = int(t)
-			}
-		}
-	}
+	return soilReadings
+}
 
-	soilTotal := 0
-	if len(soilCountRecords) > 0 {
-		if total, ok := soilCountRecords[0].Get("total"); ok {
-			if t, ok := total.(int64); ok {
-				soilTotal = int(t)
-			}
-		}
+// tagGrowthStages tags scans and readings with the crop season growth stage they were
+// taken in
+func tagGrowthStages(farmName string, plantScans []PlantScanResult, soilReadings []SensorReadingsWithInterpretation) {
+	if len(plantScans) == 0 && len(soilReadings) == 0 {
+		return
 	}
-
-	// For simplicity, we'll use the max of both totals for overall pagination
-	total := plantTotal
-	if soilTotal > total {
-		total = soilTotal
+	seasons, err := loadSeasonsByFarmName(farmName)
+	if err != nil {
+		slog.Warn("failed to load crop seasons for farm", "farm", farmName, "error", err)
 	}
-
-	totalPages := (total + limit - 1) / limit // Ceiling division
-	hasNext := page < totalPages
-	hasPrevious := page > 1
-
-	pagination := PaginationInfo{
-		Page:        page,
-		Limit:       limit,
-		Total:       total,
-		TotalPages:  totalPages,
-		HasNext:     hasNext,
-		HasPrevious: hasPrevious,
+	if len(seasons) == 0 {
+		return
 	}
-
-	result := &FarmScanResult{
-		PlantScans:   plantScans,
-		SoilReadings: soilReadings,
-		Pagination:   pagination,
+	for i := range plantScans {
+		plantScans[i].GrowthStage = growthStageAt(seasons, plantScans[i].CreatedAt)
+	}
+	for i := range soilReadings {
+		soilReadings[i].GrowthStage = growthStageAt(seasons, soilReadings[i].CreatedAt)
 	}
-
-	// Cache the result for 5 minutes to speed up subsequent requests
-	cache.Set(cacheKey, *result, 5*time.Minute)
-
-	return result, nil
 }
 
 // WarmFarmScansCache pre-loads farm scans data into cache for faster subsequent requests
@@ -476,7 +589,7 @@ func WarmFarmScansCache(farmName string) error {
 	}
 
 	for _, combo := range commonCombinations {
-		_, err := GetFarmScans(farmName, combo.page, combo.limit)
+		_, err := farmScans(farmName, combo.page, combo.limit, ScanFilter{})
 		if err != nil {
 			return fmt.Errorf("failed to warm cache for page %d, limit %d: %w", combo.page, combo.limit, err)
 		}
@@ -485,25 +598,6 @@ func WarmFarmScansCache(farmName string) error {
 	return nil
 }
 
-// getFloat64 safely gets a float64 from record
-func getFloat64(record *neo4j.Record, key string) (float64, bool) {
-	val, exists := record.Get(key)
-	if !exists {
-		return 0, false
-	}
-
-	switch v := val.(type) {
-	case float64:
-		return v, true
-	case int64:
-		return float64(v), true
-	case int:
-		return float64(v), true
-	default:
-		return 0, false
-	}
-}
-
 // parseInterpretation safely parses interpretation data from the database
 func parseInterpretation(record *neo4j.Record, key string) Interpretation {
 	// Default interpretation values
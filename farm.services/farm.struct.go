@@ -1,6 +1,8 @@
 package farmservices
 
 import (
+	marketplaceservices "decentragri-app-cx-server/marketplace.services"
+	"decentragri-app-cx-server/utils"
 	"encoding/json"
 	"time"
 )
@@ -17,13 +19,64 @@ type FarmList struct {
 	CropType           string          `json:"cropType"`
 	Description        string          `json:"description"`
 	Image              string          `json:"image"`
+	ImageURL           string          `json:"imageUrl,omitempty"` // HTTP gateway URL of the image
 	Coordinates        FarmCoordinates `json:"coordinates"`
 	UpdatedAt          time.Time       `json:"updatedAt"`
 	CreatedAt          time.Time       `json:"createdAt"`
 	FormattedUpdatedAt string          `json:"formattedUpdatedAt"`
 	FormattedCreatedAt string          `json:"formattedCreatedAt"`
-	ImageBytes         ByteArray       `json:"imageBytes"`
+	ImageBytes         ByteArray       `json:"imageBytes"` // null when listed with includeImages=false
 	Location           string          `json:"location"`
+	// Boundary is the farm's GeoJSON polygon, and AreaHectares its computed area
+	Boundary     *utils.GeoJSONPolygon `json:"boundary,omitempty"`
+	AreaHectares float64               `json:"areaHectares,omitempty"`
+	// Verified is set once an admin approves the farm's verification request
+	Verified bool `json:"verified"`
+}
+
+// CreateFarmRequest is the body of POST /api/farm, sent as JSON or multipart form
+// (the form variant may include an "image" file)
+type CreateFarmRequest struct {
+	FarmName    string  `json:"farmName" form:"farmName"`
+	CropType    string  `json:"cropType" form:"cropType"`
+	Description string  `json:"description" form:"description"`
+	Location    string  `json:"location" form:"location"`
+	Lat         float64 `json:"lat" form:"lat"`
+	Lng         float64 `json:"lng" form:"lng"`
+	// Boundary is an optional GeoJSON polygon; multipart requests send it as a JSON string field
+	Boundary *utils.GeoJSONPolygon `json:"boundary" form:"-"`
+}
+
+// UpdateFarmRequest is the body of PATCH /api/farm/:id. Omitted fields are left unchanged.
+type UpdateFarmRequest struct {
+	FarmName    *string  `json:"farmName" form:"farmName"`
+	CropType    *string  `json:"cropType" form:"cropType"`
+	Description *string  `json:"description" form:"description"`
+	Location    *string  `json:"location" form:"location"`
+	Lat         *float64 `json:"lat" form:"lat"`
+	Lng         *float64 `json:"lng" form:"lng"`
+	// Boundary replaces the farm's polygon; ClearBoundary removes it
+	Boundary      *utils.GeoJSONPolygon `json:"boundary" form:"-"`
+	ClearBoundary bool                  `json:"clearBoundary" form:"clearBoundary"`
+}
+
+// FarmImage is an uploaded farm picture to store on IPFS
+type FarmImage struct {
+	Data     []byte
+	FileName string
+}
+
+// FarmPhoto is one image in a farm's gallery
+type FarmPhoto struct {
+	ID           string `json:"id"`
+	FarmID       string `json:"farmId"`
+	ImageURI     string `json:"imageUri"`     // ipfs:// URI
+	ImageURL     string `json:"imageUrl"`     // HTTP gateway URL of the original
+	ThumbnailURL string `json:"thumbnailUrl"` // WebP thumbnail served by the image proxy
+	Caption      string `json:"caption,omitempty"`
+	IsCover      bool   `json:"isCover"`
+	UploadedBy   string `json:"uploadedBy"`
+	CreatedAt    int64  `json:"createdAt"`
 }
 
 // ParsedInterpretation represents the parsed interpretation of a plant scan result
@@ -44,6 +97,42 @@ type PlantScanResult struct {
 	Interpretation     interface{} `json:"interpretation"` // Can be string or ParsedInterpretation
 	ImageURI           string      `json:"imageUri"`
 	ImageBytes         ByteArray   `json:"imageBytes"`
+	// InterpretationStatus is pending until the AI interpretation of an uploaded scan is attached
+	InterpretationStatus string `json:"interpretationStatus,omitempty"`
+	// GrowthStage is the crop season stage the farm was in when the scan was taken
+	GrowthStage string `json:"growthStage,omitempty"`
+}
+
+// CreatePlantScanRequest holds the form fields of POST /api/farm/:id/scans
+type CreatePlantScanRequest struct {
+	CropType string `form:"cropType"` // Defaults to the farm's crop type
+	Note     string `form:"note"`
+}
+
+// PlantScanJob is queued for AI interpretation after a plant scan is uploaded
+type PlantScanJob struct {
+	ScanID   string `json:"scanId"`
+	FarmID   string `json:"farmId"`
+	FarmName string `json:"farmName"`
+	CropType string `json:"cropType"`
+	Note     string `json:"note"`
+	ImageURI string `json:"imageUri"`
+	Username string `json:"username"`
+	Attempts int    `json:"attempts"`
+}
+
+// SensorAlertJob is queued to notify the farm owner after a reading opens an alert
+type SensorAlertJob struct {
+	AlertID  string   `json:"alertId"`
+	FarmID   string   `json:"farmId"`
+	FarmName string   `json:"farmName"`
+	Owner    string   `json:"owner"`
+	SensorID string   `json:"sensorId"`
+	Metric   string   `json:"metric"`
+	Value    float64  `json:"value"`
+	Range    string   `json:"range"` // The breached threshold's accepted range, for the message
+	Channels []string `json:"channels"`
+	Attempts int      `json:"attempts"`
 }
 
 // ByteArray is a custom type that marshals as an array of numbers instead of base64
@@ -81,6 +170,102 @@ type SensorReadings struct {
 	SubmittedAt          time.Time `json:"submittedAt"`
 	FormattedCreatedAt   string    `json:"formattedCreatedAt"`
 	FormattedSubmittedAt string    `json:"formattedSubmittedAt"`
+	GrowthStage          string    `json:"growthStage,omitempty"` // Crop season stage when the reading was taken
+}
+
+// CreateReadingRequest is the body of POST /api/farm/:id/readings. Every metric is
+// required; SubmittedAt is when the sensor took the reading (RFC3339, defaults to now).
+type CreateReadingRequest struct {
+	SensorID    string   `json:"sensorId"`
+	Fertility   *float64 `json:"fertility"`
+	Moisture    *float64 `json:"moisture"`
+	PH          *float64 `json:"ph"`
+	Temperature *float64 `json:"temperature"`
+	Sunlight    *float64 `json:"sunlight"`
+	Humidity    *float64 `json:"humidity"`
+	SubmittedAt string   `json:"submittedAt"`
+}
+
+// FarmSensor is a sensor that has reported readings for a farm
+type FarmSensor struct {
+	SensorID   string `json:"sensorId"`
+	Status     string `json:"status"`               // online, offline, or unknown
+	LastSeenAt int64  `json:"lastSeenAt,omitempty"` // Unix ms of the latest reading taken
+	Readings   int64  `json:"readings"`
+}
+
+// FarmAuditEntry records one change made to a farm
+type FarmAuditEntry struct {
+	ID      string         `json:"id"`
+	Actor   string         `json:"actor"`  // Username of who made the change
+	Action  string         `json:"action"` // e.g. farm.updated, collaborator.removed
+	Details map[string]any `json:"details,omitempty"`
+	At      int64          `json:"at"` // Unix ms
+}
+
+// FarmAuditLog is a page of a farm's audit log, newest first
+type FarmAuditLog struct {
+	Entries    []FarmAuditEntry `json:"entries"`
+	Pagination PaginationInfo   `json:"pagination"`
+}
+
+// CreateReadingsBatchRequest is the body of POST /api/farm/:id/readings/batch
+type CreateReadingsBatchRequest struct {
+	Readings []CreateReadingRequest `json:"readings"`
+}
+
+// ReadingBatchItem is the outcome of one reading in a batch, by its position in the request
+type ReadingBatchItem struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`    // Set when the reading was stored
+	Error string `json:"error,omitempty"` // Set when the reading failed validation
+}
+
+// ReadingBatchResult summarizes a batch of readings
+type ReadingBatchResult struct {
+	Accepted int                `json:"accepted"`
+	Rejected int                `json:"rejected"`
+	Results  []ReadingBatchItem `json:"results"`
+}
+
+// SensorThreshold bounds one reading metric. Either bound may be omitted.
+type SensorThreshold struct {
+	Metric string   `json:"metric"` // fertility, moisture, ph, temperature, sunlight, or humidity
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+}
+
+// FarmAlertSettings are a farm's sensor thresholds and the channels alerts go out on
+type FarmAlertSettings struct {
+	Thresholds []SensorThreshold `json:"thresholds"`
+	Channels   []string          `json:"channels"` // in_app, push, email
+}
+
+// Sensor alert statuses
+const (
+	AlertStatusOpen         = "open"
+	AlertStatusAcknowledged = "acknowledged"
+	AlertStatusResolved     = "resolved"
+)
+
+// SensorAlert is raised when a reading breaks one of its farm's thresholds. Later
+// breaches of the same metric on the same sensor update the alert until it is resolved.
+type SensorAlert struct {
+	ID             string   `json:"id"`
+	FarmID         string   `json:"farmId"`
+	FarmName       string   `json:"farmName"`
+	SensorID       string   `json:"sensorId"`
+	ReadingID      string   `json:"readingId"`
+	Metric         string   `json:"metric"`
+	Value          float64  `json:"value"`
+	Min            *float64 `json:"min,omitempty"`
+	Max            *float64 `json:"max,omitempty"`
+	Status         string   `json:"status"`
+	Occurrences    int64    `json:"occurrences"`
+	CreatedAt      int64    `json:"createdAt"`
+	LastSeenAt     int64    `json:"lastSeenAt"`
+	AcknowledgedAt int64    `json:"acknowledgedAt,omitempty"`
+	ResolvedAt     int64    `json:"resolvedAt,omitempty"`
 }
 
 // Interpretation contains human-readable interpretations of sensor readings
@@ -105,15 +290,356 @@ type SensorReadingsWithInterpretation struct {
 type FarmScanResult struct {
 	PlantScans   []PlantScanResult                  `json:"plantScans"`
 	SoilReadings []SensorReadingsWithInterpretation `json:"soilReadings"`
-	Pagination   PaginationInfo                     `json:"pagination"`
+	Pagination   *PaginationInfo                    `json:"pagination,omitempty"` // Page mode only
+	NextCursor   string                             `json:"nextCursor,omitempty"` // Cursor mode; empty on the last page
+}
+
+// Scan types accepted by the scans type filter
+const (
+	ScanTypePlant = "plant"
+	ScanTypeSoil  = "soil"
+)
+
+// ScanFilter narrows a farm scans query. From and To are RFC3339 UTC bounds
+// (From inclusive, To exclusive); empty fields do not filter.
+type ScanFilter struct {
+	Type string
+	From string
+	To   string
+}
+
+// IsZero reports whether the filter matches everything
+func (f ScanFilter) IsZero() bool {
+	return f.Type == "" && f.From == "" && f.To == ""
+}
+
+// Crop season statuses
+const (
+	SeasonStatusActive    = "active"
+	SeasonStatusCompleted = "completed"
+)
+
+// ExpectedStage is a growth stage a season is planned to reach, with its expected date
+type ExpectedStage struct {
+	Stage        string `json:"stage"`
+	ExpectedDate string `json:"expectedDate,omitempty"` // YYYY-MM-DD
+}
+
+// StageTransition records a season entering a growth stage
+type StageTransition struct {
+	ID        string `json:"id"`
+	Stage     string `json:"stage"`
+	Date      string `json:"date"` // YYYY-MM-DD
+	Note      string `json:"note,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// CropSeason is one planting cycle on a farm
+type CropSeason struct {
+	ID             string            `json:"id"`
+	FarmID         string            `json:"farmId"`
+	CropType       string            `json:"cropType"`
+	Variety        string            `json:"variety,omitempty"`
+	PlantingDate   string            `json:"plantingDate"` // YYYY-MM-DD
+	ExpectedStages []ExpectedStage   `json:"expectedStages"`
+	CurrentStage   string            `json:"currentStage"`
+	Status         string            `json:"status"` // active or completed
+	Transitions    []StageTransition `json:"transitions"`
+	CreatedAt      int64             `json:"createdAt"`
+	CompletedAt    int64             `json:"completedAt,omitempty"`
+}
+
+// CreateSeasonRequest is the body of POST /api/farm/:id/seasons. Without expected
+// stages the default stage sequence is used.
+type CreateSeasonRequest struct {
+	CropType       string          `json:"cropType"` // Defaults to the farm's crop type
+	Variety        string          `json:"variety"`
+	PlantingDate   string          `json:"plantingDate"`
+	ExpectedStages []ExpectedStage `json:"expectedStages"`
+}
+
+// StageTransitionRequest is the body of POST /api/farm/:id/seasons/:seasonId/stages
+type StageTransitionRequest struct {
+	Stage string `json:"stage"`
+	Date  string `json:"date"` // Defaults to today (UTC)
+	Note  string `json:"note"`
+}
+
+// Harvest is a recorded harvest of a farm, linked to the crop season it came from
+type Harvest struct {
+	ID           string  `json:"id"`
+	FarmID       string  `json:"farmId"`
+	SeasonID     string  `json:"seasonId,omitempty"`
+	Date         string  `json:"date"` // YYYY-MM-DD
+	QuantityKg   float64 `json:"quantityKg"`
+	QualityGrade string  `json:"qualityGrade"` // A, B, C, or D
+	PricePerKg   float64 `json:"pricePerKg"`
+	Currency     string  `json:"currency"`
+	Revenue      float64 `json:"revenue"` // QuantityKg x PricePerKg
+	Note         string  `json:"note,omitempty"`
+	CreatedAt    int64   `json:"createdAt"`
+}
+
+// CreateHarvestRequest is the body of POST /api/farm/:id/harvests. SeasonID defaults
+// to the season planted most recently before the harvest date.
+type CreateHarvestRequest struct {
+	SeasonID     string  `json:"seasonId"`
+	Date         string  `json:"date"`
+	QuantityKg   float64 `json:"quantityKg"`
+	QualityGrade string  `json:"qualityGrade"`
+	PricePerKg   float64 `json:"pricePerKg"`
+	Currency     string  `json:"currency"` // Defaults to USD
+	Note         string  `json:"note"`
+}
+
+// SeasonYield summarizes the harvests of one crop season
+type SeasonYield struct {
+	SeasonID        string             `json:"seasonId,omitempty"` // Empty for harvests outside any season
+	CropType        string             `json:"cropType,omitempty"`
+	Variety         string             `json:"variety,omitempty"`
+	PlantingDate    string             `json:"plantingDate,omitempty"`
+	Status          string             `json:"status,omitempty"`
+	Harvests        int                `json:"harvests"`
+	TotalKg         float64            `json:"totalKg"`
+	YieldPerHectare *float64           `json:"yieldPerHectare,omitempty"` // Tonnes per hectare; needs the farm's area
+	Revenue         map[string]float64 `json:"revenue"`                   // By currency
+	GradeShare      map[string]float64 `json:"gradeShare"`                // Percent of quantity per quality grade
+}
+
+// FarmYield is a farm's yield history across crop seasons
+type FarmYield struct {
+	FarmID                 string        `json:"farmId"`
+	FarmName               string        `json:"farmName"`
+	AreaHectares           float64       `json:"areaHectares,omitempty"`
+	Seasons                []SeasonYield `json:"seasons"`
+	TotalKg                float64       `json:"totalKg"`
+	AverageYieldPerHectare *float64      `json:"averageYieldPerHectare,omitempty"` // Mean tonnes per hectare over seasons with harvests
+}
+
+// Farm task statuses
+const (
+	TaskStatusOpen      = "open"
+	TaskStatusCompleted = "completed"
+)
+
+// Farm task recurrences; completing a recurring task opens its next occurrence
+const (
+	TaskRecurrenceNone    = "none"
+	TaskRecurrenceDaily   = "daily"
+	TaskRecurrenceWeekly  = "weekly"
+	TaskRecurrenceMonthly = "monthly"
+)
+
+// FarmTask is a piece of farm work, such as irrigating a plot, optionally assigned to a user
+type FarmTask struct {
+	ID          string `json:"id"`
+	FarmID      string `json:"farmId"`
+	FarmName    string `json:"farmName"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Assignee    string `json:"assignee,omitempty"`
+	CreatedBy   string `json:"createdBy"`
+	DueAt       int64  `json:"dueAt,omitempty"` // Unix ms
+	Recurrence  string `json:"recurrence"`
+	Status      string `json:"status"`
+	CompletedBy string `json:"completedBy,omitempty"`
+	CompletedAt int64  `json:"completedAt,omitempty"`
+	NextTaskID  string `json:"nextTaskId,omitempty"` // Occurrence opened when a recurring task was completed
+	CreatedAt   int64  `json:"createdAt"`
+}
+
+// CreateTaskRequest is the body of POST /api/farm/:id/tasks
+type CreateTaskRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Assignee    string `json:"assignee"`   // Username; empty leaves the task unassigned
+	DueAt       string `json:"dueAt"`      // RFC3339
+	Recurrence  string `json:"recurrence"` // none, daily, weekly, or monthly; recurring tasks need a due date
+}
+
+// UpdateTaskRequest is the body of PATCH /api/farm/tasks/:taskId. Nil fields are left
+// unchanged; an empty assignee or dueAt clears it.
+type UpdateTaskRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Assignee    *string `json:"assignee"`
+	DueAt       *string `json:"dueAt"`
+	Recurrence  *string `json:"recurrence"`
+}
+
+// TaskFilter narrows task listings; zero fields match everything
+type TaskFilter struct {
+	Status    string
+	Assignee  string
+	DueBefore int64 // Unix ms, exclusive
+	DueAfter  int64 // Unix ms, inclusive
+}
+
+// Farm access roles, weakest first. Viewers read a farm's records; managers also record
+// scans, readings, seasons, harvests, and tasks and edit the farm; only the owner deletes
+// it and manages collaborators.
+const (
+	FarmRoleViewer  = "viewer"
+	FarmRoleManager = "manager"
+	FarmRoleOwner   = "owner"
+)
+
+// FarmCollaborator is a user granted access to a farm they don't own
+type FarmCollaborator struct {
+	Username      string `json:"username"`
+	WalletAddress string `json:"walletAddress,omitempty"`
+	Email         string `json:"email,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Role          string `json:"role"`
+	GrantedBy     string `json:"grantedBy"`
+	GrantedAt     int64  `json:"grantedAt"`
+}
+
+// InviteCollaboratorRequest is the body of POST /api/farm/:id/collaborators. The invitee
+// is looked up by wallet address or, failing that, by email.
+type InviteCollaboratorRequest struct {
+	WalletAddress string `json:"walletAddress"`
+	Email         string `json:"email"`
+	Role          string `json:"role"` // viewer or manager
+}
+
+// SharedFarm is a farm shared with the caller, with the role they were granted
+type SharedFarm struct {
+	FarmList
+	Role string `json:"role"`
+}
+
+// TimelineEvent is one entry of a farm's activity feed
+type TimelineEvent struct {
+	Type      string         `json:"type"` // plant_scan, soil_reading, alert, task_created, task_completed, harvest, plot_listed, plot_sold
+	ID        string         `json:"id"`
+	Title     string         `json:"title"`
+	Timestamp int64          `json:"timestamp"` // Unix milliseconds
+	Data      map[string]any `json:"data"`
+}
+
+// FarmTimeline is a page of a farm's activity feed, newest first
+type FarmTimeline struct {
+	FarmID     string          `json:"farmId"`
+	FarmName   string          `json:"farmName"`
+	Events     []TimelineEvent `json:"events"`
+	Pagination PaginationInfo  `json:"pagination"`
+}
+
+// FarmPlotLink is a farm plot NFT linked to the farm it tokenizes
+type FarmPlotLink struct {
+	TokenID  string `json:"tokenId"`
+	Contract string `json:"contract"`
+	FarmID   string `json:"farmId"`
+	LinkedBy string `json:"linkedBy"`
+	LinkedAt int64  `json:"linkedAt"`
+}
+
+// LinkFarmPlotRequest is the body of POST /api/farm/:id/plots
+type LinkFarmPlotRequest struct {
+	TokenID  string `json:"tokenId"`
+	Contract string `json:"contract"` // Optional; defaults to the farm plot contract
+}
+
+// PlotFarm is the farm behind a plot NFT with its latest sensor telemetry
+type PlotFarm struct {
+	TokenID       string          `json:"tokenId"`
+	Contract      string          `json:"contract"`
+	Farm          *FarmList       `json:"farm"`
+	LatestReading *SensorReadings `json:"latestReading"`
+}
+
+// Farm verification statuses
+const (
+	VerificationPending  = "pending"
+	VerificationApproved = "approved"
+	VerificationRejected = "rejected"
+)
+
+// FarmVerification is an owner's request to have a farm verified, with its review outcome
+type FarmVerification struct {
+	ID          string             `json:"id"`
+	FarmID      string             `json:"farmId"`
+	FarmName    string             `json:"farmName"`
+	Status      string             `json:"status"` // pending, approved, or rejected
+	Note        string             `json:"note,omitempty"`
+	SubmittedBy string             `json:"submittedBy"`
+	SubmittedAt int64              `json:"submittedAt"`
+	ReviewedBy  string             `json:"reviewedBy,omitempty"`
+	ReviewedAt  int64              `json:"reviewedAt,omitempty"`
+	ReviewNote  string             `json:"reviewNote,omitempty"`
+	Files       []VerificationFile `json:"files"`
+}
+
+// VerificationFile is a document or geotagged photo attached to a verification request
+type VerificationFile struct {
+	Kind       string   `json:"kind"` // document or photo
+	URI        string   `json:"uri"`
+	URL        string   `json:"url"`
+	Lat        *float64 `json:"lat,omitempty"`
+	Lng        *float64 `json:"lng,omitempty"`
+	DistanceKm *float64 `json:"distanceKm,omitempty"` // Photos only: distance from the farm's coordinates
+}
+
+// VerificationReviewRequest carries an admin's note when rejecting or revoking verification
+type VerificationReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// OutbreakMap is the heatmap dataset of diagnosed pests and diseases
+type OutbreakMap struct {
+	From        string              `json:"from"` // YYYY-MM-DD
+	To          string              `json:"to"`
+	Interval    string              `json:"interval"`    // day or week
+	CellDegrees float64             `json:"cellDegrees"` // Grid cell size cells are snapped to
+	Cells       []OutbreakCell      `json:"cells"`
+	Diagnoses   []OutbreakDiagnosis `json:"diagnoses"` // Totals per diagnosis, most scans first
+}
+
+// OutbreakCell counts one diagnosis within one grid cell and time bucket
+type OutbreakCell struct {
+	Lat       float64  `json:"lat"` // Cell center
+	Lng       float64  `json:"lng"`
+	Period    string   `json:"period"` // Start date of the day or week
+	Diagnosis string   `json:"diagnosis"`
+	Scans     int      `json:"scans"` // Heat weight
+	Farms     int      `json:"farms"`
+	CropTypes []string `json:"cropTypes"`
+}
+
+// OutbreakDiagnosis totals one diagnosis across the map
+type OutbreakDiagnosis struct {
+	Diagnosis string `json:"diagnosis"`
+	Scans     int    `json:"scans"`
+	Cells     int    `json:"cells"`
 }
 
 // PaginationInfo contains pagination metadata
-type PaginationInfo struct {
-	Page        int  `json:"page"`
-	Limit       int  `json:"limit"`
-	Total       int  `json:"total"`
-	TotalPages  int  `json:"totalPages"`
-	HasNext     bool `json:"hasNext"`
-	HasPrevious bool `json:"hasPrevious"`
+type PaginationInfo = utils.PaginationInfo
+
+// SoilProfile averages the readings of a farm's sensors
+type SoilProfile struct {
+	PH        float64 `json:"ph"`
+	Moisture  float64 `json:"moisture"`
+	Fertility float64 `json:"fertility"`
+	Readings  int64   `json:"readings"`
+}
+
+// SimilarFarm is a farm whose crop and soil resemble another farm's
+type SimilarFarm struct {
+	ID         string       `json:"id"`
+	FarmName   string       `json:"farmName"`
+	CropType   string       `json:"cropType"`
+	Location   string       `json:"location"`
+	Image      string       `json:"image"`
+	Soil       *SoilProfile `json:"soil,omitempty"` // Nil when the farm has no readings
+	Similarity float64      `json:"similarity"`     // 0-1
+	Reasons    []string     `json:"reasons"`        // same_crop, similar_soil
+}
+
+// PlotSuggestion is a listed plot whose farm resembles a farm behind one of the caller's plots
+type PlotSuggestion struct {
+	marketplaceservices.FarmPlotDirectListingsWithImageByte
+	Farm      SimilarFarm `json:"farm"`
+	SimilarTo string      `json:"similarTo"` // ID of the caller's farm it resembles
 }
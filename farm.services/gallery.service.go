@@ -0,0 +1,288 @@
+package farmservices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	portfolioServices "decentragri-app-cx-server/portfolio.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	// maxFarmPhotos caps the size of a farm's gallery
+	maxFarmPhotos = 50
+	// maxPhotosPerUpload caps how many images one upload request may carry
+	maxPhotosPerUpload = 10
+	// maxCaptionLength bounds a photo caption
+	maxCaptionLength = 500
+	// thumbnailQuality is the WebP quality of gallery thumbnails
+	thumbnailQuality = 75
+)
+
+// ErrPhotoNotFound is returned for photos that are not in the farm's gallery
+var ErrPhotoNotFound = errors.New("photo not found")
+
+// photoReturn projects a gallery photo matched as p on farm f
+const photoReturn = `RETURN p.id AS id, p.imageUri AS imageUri, p.caption AS caption,
+	p.uploadedBy AS uploadedBy, p.createdAt AS createdAt, f.coverPhotoId = p.id AS isCover`
+
+// UploadFarmPhotos stores images on IPFS and adds them to the gallery of a farm the caller
+// manages. A farm without a cover photo gets the first uploaded image as its cover.
+func UploadFarmPhotos(ctx context.Context, token, farmID, caption string, images []*FarmImage) ([]FarmPhoto, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleManager); err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, invalidf("at least one image is required")
+	}
+	if len(images) > maxPhotosPerUpload {
+		return nil, invalidf("at most %d images can be uploaded at once", maxPhotosPerUpload)
+	}
+	caption = strings.TrimSpace(caption)
+	if len(caption) > maxCaptionLength {
+		return nil, invalidf("caption exceeds %d characters", maxCaptionLength)
+	}
+
+	existing, err := loadPhotos(farmID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing)+len(images) > maxFarmPhotos {
+		return nil, invalidf("a farm gallery holds at most %d photos", maxFarmPhotos)
+	}
+
+	// Upload everything before writing so a bad image doesn't leave a partial gallery
+	imageURIs := make([]string, 0, len(images))
+	for _, image := range images {
+		imageURI, err := uploadImage(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+		imageURIs = append(imageURIs, imageURI)
+	}
+
+	photoIDs := make([]string, 0, len(imageURIs))
+	for _, imageURI := range imageURIs {
+		photoID, err := addPhoto(farmID, imageURI, caption, username)
+		if err != nil {
+			return nil, err
+		}
+		photoIDs = append(photoIDs, photoID)
+	}
+
+	InvalidateFarmListCache()
+
+	photos, err := loadPhotos(farmID)
+	if err != nil {
+		return nil, err
+	}
+	uploaded := make([]FarmPhoto, 0, len(photoIDs))
+	for _, id := range photoIDs {
+		for _, photo := range photos {
+			if photo.ID == id {
+				uploaded = append(uploaded, photo)
+			}
+		}
+	}
+	return uploaded, nil
+}
+
+// GetFarmPhotos returns a farm's gallery, cover first and then newest first
+func GetFarmPhotos(token, farmID string) ([]FarmPhoto, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	photos, err := loadPhotos(farmID)
+	if err != nil {
+		return nil, err
+	}
+	// Farms created before galleries existed only have f.image; adopt it as the cover
+	if len(photos) == 0 && farm.Image != "" {
+		if _, err := addPhoto(farmID, farm.Image, "", farm.Owner); err != nil {
+			slog.Warn("failed to migrate image of farm into its gallery", "farmId", farmID, "error", err)
+			return photos, nil
+		}
+		return loadPhotos(farmID)
+	}
+	return photos, nil
+}
+
+// SetFarmCoverPhoto makes a gallery photo the cover of a farm the caller manages. The
+// cover is mirrored to f.image, which the farm list and detail endpoints return.
+func SetFarmCoverPhoto(token, farmID, photoID string) (*FarmPhoto, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleManager); err != nil {
+		return nil, err
+	}
+
+	summary, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})-[:HAS_PHOTO]->(p:FarmPhoto {id: $photoId})
+		SET f.coverPhotoId = p.id, f.image = p.imageUri, f.updatedAt = $now`, map[string]any{
+		"farmId":  farmID,
+		"photoId": photoID,
+		"now":     time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set cover photo: %w", err)
+	}
+	if summary == nil || summary.Counters().PropertiesSet() == 0 {
+		return nil, ErrPhotoNotFound
+	}
+
+	InvalidateFarmListCache()
+
+	return getPhoto(farmID, photoID)
+}
+
+// DeleteFarmPhoto removes a photo from the gallery of a farm the caller manages. Deleting
+// the cover promotes the newest remaining photo, or clears f.image when none is left.
+// The image itself stays pinned on IPFS.
+func DeleteFarmPhoto(token, farmID, photoID string) error {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleManager); err != nil {
+		return err
+	}
+
+	summary, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})-[:HAS_PHOTO]->(p:FarmPhoto {id: $photoId})
+		DETACH DELETE p`, map[string]any{"farmId": farmID, "photoId": photoID})
+	if err != nil {
+		return fmt.Errorf("failed to delete photo: %w", err)
+	}
+	if summary == nil || summary.Counters().NodesDeleted() == 0 {
+		return ErrPhotoNotFound
+	}
+
+	if _, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		WHERE f.coverPhotoId = $photoId
+		OPTIONAL MATCH (f)-[:HAS_PHOTO]->(p:FarmPhoto)
+		WITH f, p ORDER BY p.createdAt DESC
+		WITH f, head(collect(p)) AS next
+		SET f.coverPhotoId = next.id, f.image = next.imageUri, f.updatedAt = $now`, map[string]any{
+		"farmId":  farmID,
+		"photoId": photoID,
+		"now":     time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		slog.Warn("failed to replace cover photo of farm", "farmId", farmID, "error", err)
+	}
+
+	InvalidateFarmListCache()
+
+	return nil
+}
+
+// addPhoto links an uploaded image to a farm's gallery, making it the cover when the farm
+// has none, and returns the new photo's ID
+func addPhoto(farmID, imageURI, caption, username string) (string, error) {
+	photoID := uuid.NewString()
+	_, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		CREATE (f)-[:HAS_PHOTO]->(p:FarmPhoto {
+			id: $id,
+			farmId: $farmId,
+			imageUri: $imageUri,
+			caption: $caption,
+			uploadedBy: $uploadedBy,
+			createdAt: $createdAt
+		})
+		WITH f, p
+		WHERE f.coverPhotoId IS NULL
+		SET f.coverPhotoId = p.id, f.image = p.imageUri`, map[string]any{
+		"farmId":     farmID,
+		"id":         photoID,
+		"imageUri":   imageURI,
+		"caption":    caption,
+		"uploadedBy": username,
+		"createdAt":  time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save photo: %w", err)
+	}
+	return photoID, nil
+}
+
+// setCoverImage adds a farm image uploaded through the farm create/update endpoints to
+// its gallery as the cover
+func setCoverImage(farmID, imageURI, username string) error {
+	photoID, err := addPhoto(farmID, imageURI, "", username)
+	if err != nil {
+		return err
+	}
+	if _, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		SET f.coverPhotoId = $photoId, f.image = $imageUri`,
+		map[string]any{"farmId": farmID, "photoId": photoID, "imageUri": imageURI}); err != nil {
+		return fmt.Errorf("failed to set cover photo: %w", err)
+	}
+	return nil
+}
+
+// getPhoto reads one gallery photo
+func getPhoto(farmID, photoID string) (*FarmPhoto, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm {id: $farmId})-[:HAS_PHOTO]->(p:FarmPhoto {id: $photoId}) `+photoReturn,
+		map[string]any{"farmId": farmID, "photoId": photoID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load photo: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrPhotoNotFound
+	}
+	photo := photoFromRecord(farmID, records[0])
+	return &photo, nil
+}
+
+// loadPhotos reads a farm's gallery, cover first and then newest first
+func loadPhotos(farmID string) ([]FarmPhoto, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm {id: $farmId})-[:HAS_PHOTO]->(p:FarmPhoto)
+		WITH f, p ORDER BY f.coverPhotoId = p.id DESC, p.createdAt DESC
+		`+photoReturn, map[string]any{"farmId": farmID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch photos: %w", err)
+	}
+
+	photos := make([]FarmPhoto, 0, len(records))
+	for _, record := range records {
+		photos = append(photos, photoFromRecord(farmID, record))
+	}
+	return photos, nil
+}
+
+// photoFromRecord maps a photoReturn row to a FarmPhoto with its gateway and thumbnail URLs
+func photoFromRecord(farmID string, record *neo4j.Record) FarmPhoto {
+	photo := FarmPhoto{
+		ID:         memgraph.String(record, "id"),
+		FarmID:     farmID,
+		ImageURI:   memgraph.String(record, "imageUri"),
+		Caption:    memgraph.String(record, "caption"),
+		UploadedBy: memgraph.String(record, "uploadedBy"),
+		CreatedAt:  memgraph.Int64(record, "createdAt"),
+	}
+	if v, ok := record.Get("isCover"); ok {
+		photo.IsCover, _ = v.(bool)
+	}
+	if photo.ImageURI != "" {
+		photo.ImageURL = portfolioServices.BuildIpfsUri(photo.ImageURI)
+		photo.ThumbnailURL = portfolioServices.ImageProxyURL(photo.ImageURI, thumbnailQuality)
+	}
+	return photo
+}
@@ -0,0 +1,276 @@
+package farmservices
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/google/uuid"
+)
+
+// harvestGrades are the accepted harvest quality grades, best first
+var harvestGrades = []string{"A", "B", "C", "D"}
+
+// currencyPattern matches an ISO 4217 currency code
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// maxHarvestNoteLength bounds the free-text note recorded with a harvest
+const maxHarvestNoteLength = 1000
+
+// RecordHarvest records a harvest on one of the caller's farms. The harvest is linked to
+// the requested season, or else to the season planted most recently before its date.
+func RecordHarvest(token, farmID string, req CreateHarvestRequest) (*Harvest, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleManager); err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, invalidf("date must be YYYY-MM-DD")
+	}
+	if date.After(time.Now().UTC().AddDate(0, 0, 1)) {
+		return nil, invalidf("date must not be in the future")
+	}
+	if req.QuantityKg <= 0 {
+		return nil, invalidf("quantityKg must be greater than 0")
+	}
+	if req.PricePerKg < 0 {
+		return nil, invalidf("pricePerKg must not be negative")
+	}
+	grade := strings.ToUpper(strings.TrimSpace(req.QualityGrade))
+	validGrade := false
+	for _, g := range harvestGrades {
+		if grade == g {
+			validGrade = true
+		}
+	}
+	if !validGrade {
+		return nil, invalidf("qualityGrade must be one of %s", strings.Join(harvestGrades, ", "))
+	}
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = "USD"
+	}
+	if !currencyPattern.MatchString(currency) {
+		return nil, invalidf("currency must be a 3-letter ISO code")
+	}
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxHarvestNoteLength {
+		return nil, invalidf("note exceeds %d characters", maxHarvestNoteLength)
+	}
+
+	seasons, err := loadSeasons(farmID)
+	if err != nil {
+		return nil, err
+	}
+	seasonID := req.SeasonID
+	if seasonID != "" {
+		found := false
+		for _, season := range seasons {
+			if season.ID == seasonID {
+				found = true
+				if req.Date < season.PlantingDate {
+					return nil, invalidf("date is before the season's planting date %s", season.PlantingDate)
+				}
+			}
+		}
+		if !found {
+			return nil, ErrSeasonNotFound
+		}
+	} else {
+		// Seasons are ordered by planting date, most recent first
+		for _, season := range seasons {
+			if season.PlantingDate != "" && season.PlantingDate <= req.Date {
+				seasonID = season.ID
+				break
+			}
+		}
+	}
+
+	harvest := &Harvest{
+		ID:           uuid.NewString(),
+		FarmID:       farmID,
+		SeasonID:     seasonID,
+		Date:         req.Date,
+		QuantityKg:   req.QuantityKg,
+		QualityGrade: grade,
+		PricePerKg:   req.PricePerKg,
+		Currency:     currency,
+		Revenue:      req.QuantityKg * req.PricePerKg,
+		Note:         note,
+		CreatedAt:    time.Now().UnixMilli(),
+	}
+	_, err = memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		CREATE (f)-[:HAS_HARVEST]->(h:Harvest {
+			id: $id,
+			farmId: $farmId,
+			seasonId: $seasonId,
+			date: $date,
+			quantityKg: $quantityKg,
+			qualityGrade: $qualityGrade,
+			pricePerKg: $pricePerKg,
+			currency: $currency,
+			note: $note,
+			username: $username,
+			createdAt: $createdAt
+		})
+		WITH f, h
+		OPTIONAL MATCH (f)-[:HAS_SEASON]->(s:CropSeason {id: $seasonId})
+		FOREACH (_ IN CASE WHEN s IS NULL THEN [] ELSE [1] END | CREATE (s)-[:HAS_HARVEST]->(h))`, map[string]any{
+		"farmId":       farmID,
+		"id":           harvest.ID,
+		"seasonId":     harvest.SeasonID,
+		"date":         harvest.Date,
+		"quantityKg":   harvest.QuantityKg,
+		"qualityGrade": harvest.QualityGrade,
+		"pricePerKg":   harvest.PricePerKg,
+		"currency":     harvest.Currency,
+		"note":         harvest.Note,
+		"username":     username,
+		"createdAt":    harvest.CreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record harvest: %w", err)
+	}
+
+	return harvest, nil
+}
+
+// GetHarvests returns a farm's harvests, most recent first
+func GetHarvests(token, farmID string) ([]Harvest, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+	return loadHarvests(farmID)
+}
+
+// GetFarmYield summarizes a farm's harvests per crop season. Yield per hectare (in
+// tonnes) needs the farm's area, which comes from its boundary.
+func GetFarmYield(token, farmID string) (*FarmYield, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	harvests, err := loadHarvests(farmID)
+	if err != nil {
+		return nil, err
+	}
+	seasons, err := loadSeasons(farmID)
+	if err != nil {
+		return nil, err
+	}
+
+	bySeason := make(map[string]*SeasonYield)
+	gradeKg := make(map[string]map[string]float64)
+	for _, harvest := range harvests {
+		summary, ok := bySeason[harvest.SeasonID]
+		if !ok {
+			summary = &SeasonYield{
+				SeasonID:   harvest.SeasonID,
+				Revenue:    make(map[string]float64),
+				GradeShare: make(map[string]float64),
+			}
+			bySeason[harvest.SeasonID] = summary
+			gradeKg[harvest.SeasonID] = make(map[string]float64)
+		}
+		summary.Harvests++
+		summary.TotalKg += harvest.QuantityKg
+		summary.Revenue[harvest.Currency] += harvest.Revenue
+		gradeKg[harvest.SeasonID][harvest.QualityGrade] += harvest.QuantityKg
+	}
+
+	yield := &FarmYield{
+		FarmID:       farm.ID,
+		FarmName:     farm.FarmName,
+		AreaHectares: farm.AreaHectares,
+		Seasons:      make([]SeasonYield, 0, len(bySeason)),
+	}
+	for _, season := range seasons {
+		if summary, ok := bySeason[season.ID]; ok {
+			summary.CropType = season.CropType
+			summary.Variety = season.Variety
+			summary.PlantingDate = season.PlantingDate
+			summary.Status = season.Status
+		}
+	}
+
+	var yieldSum float64
+	var yieldCount int
+	for seasonID, summary := range bySeason {
+		for grade, kg := range gradeKg[seasonID] {
+			summary.GradeShare[grade] = kg / summary.TotalKg * 100
+		}
+		if farm.AreaHectares > 0 {
+			perHectare := summary.TotalKg / 1000 / farm.AreaHectares
+			summary.YieldPerHectare = &perHectare
+			yieldSum += perHectare
+			yieldCount++
+		}
+		yield.TotalKg += summary.TotalKg
+		yield.Seasons = append(yield.Seasons, *summary)
+	}
+	if yieldCount > 0 {
+		average := yieldSum / float64(yieldCount)
+		yield.AverageYieldPerHectare = &average
+	}
+
+	// Most recent planting first; harvests outside any season last
+	sort.Slice(yield.Seasons, func(i, j int) bool {
+		a, b := yield.Seasons[i], yield.Seasons[j]
+		if (a.SeasonID == "") != (b.SeasonID == "") {
+			return b.SeasonID == ""
+		}
+		return a.PlantingDate > b.PlantingDate
+	})
+
+	return yield, nil
+}
+
+// loadHarvests reads a farm's harvests, most recent first
+func loadHarvests(farmID string) ([]Harvest, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[:HAS_HARVEST]->(h:Harvest)
+		RETURN h.id AS id, h.seasonId AS seasonId, h.date AS date, h.quantityKg AS quantityKg,
+			h.qualityGrade AS qualityGrade, h.pricePerKg AS pricePerKg, h.currency AS currency,
+			h.note AS note, h.createdAt AS createdAt
+		ORDER BY h.date DESC, h.createdAt DESC`, map[string]any{"farmId": farmID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch harvests: %w", err)
+	}
+
+	harvests := make([]Harvest, 0, len(records))
+	for _, record := range records {
+		harvest := Harvest{
+			ID:           memgraph.String(record, "id"),
+			FarmID:       farmID,
+			SeasonID:     memgraph.String(record, "seasonId"),
+			Date:         memgraph.String(record, "date"),
+			QualityGrade: memgraph.String(record, "qualityGrade"),
+			Currency:     memgraph.String(record, "currency"),
+			Note:         memgraph.String(record, "note"),
+			CreatedAt:    memgraph.Int64(record, "createdAt"),
+		}
+		harvest.QuantityKg, _ = memgraph.Float64(record, "quantityKg")
+		harvest.PricePerKg, _ = memgraph.Float64(record, "pricePerKg")
+		harvest.Revenue = harvest.QuantityKg * harvest.PricePerKg
+		harvests = append(harvests, harvest)
+	}
+	return harvests, nil
+}
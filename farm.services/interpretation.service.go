@@ -0,0 +1,370 @@
+package farmservices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+	memgraph "decentragri-app-cx-server/db"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	portfolioServices "decentragri-app-cx-server/portfolio.services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// maxInterpretationAttempts bounds how often a scan is sent to the inference service
+	maxInterpretationAttempts = 3
+	// inferenceTimeout bounds a single inference request
+	inferenceTimeout = 60 * time.Second
+	// interpretationNotifyWindow bounds how old a scan or reading can be to be announced,
+	// so interpretations stored before the notifier existed stay quiet
+	interpretationNotifyWindow = 72 * time.Hour
+	// maxInterpretationNotices caps how many interpretations one check announces per kind
+	maxInterpretationNotices = 200
+)
+
+// inferenceRequest is the body posted to the inference service for one plant scan
+type inferenceRequest struct {
+	ScanID   string `json:"scanId"`
+	CropType string `json:"cropType"`
+	Note     string `json:"note,omitempty"`
+	ImageURI string `json:"imageUri"`
+	ImageURL string `json:"imageUrl"` // HTTP gateway URL of the image
+}
+
+// StartPlantScanInterpreter drains the plant scan interpretation queue with
+// PLANT_SCAN_WORKERS (default 2) concurrent workers. Each scan is sent to the inference
+// service at PLANT_SCAN_INFERENCE_URL; the interpreter is disabled when it is unset, so
+// scans stay queued. It blocks, so run it in a goroutine.
+func StartPlantScanInterpreter() {
+	endpoint := os.Getenv("PLANT_SCAN_INFERENCE_URL")
+	if endpoint == "" {
+		slog.Info("plant scan interpreter disabled: PLANT_SCAN_INFERENCE_URL not set")
+		return
+	}
+
+	workers := 2
+	if v := os.Getenv("PLANT_SCAN_WORKERS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			workers = parsed
+		}
+	}
+
+	slog.Info("plant scan interpreter started", "workers", workers)
+
+	for i := 1; i < workers; i++ {
+		go interpretPlantScans(endpoint)
+	}
+	interpretPlantScans(endpoint)
+}
+
+// interpretPlantScans processes queued scans one at a time, oldest first
+func interpretPlantScans(endpoint string) {
+	for {
+		if cache.RedisClient == nil {
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		// Enqueue pushes on the left, so popping on the right takes the oldest job
+		result, err := cache.RedisClient.BRPop(context.Background(), 5*time.Second, PlantScanInterpretationQueue).Result()
+		if err != nil {
+			// redis.Nil means the wait timed out with an empty queue
+			if !errors.Is(err, redis.Nil) {
+				slog.Warn("failed to read plant scan queue", "error", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if len(result) < 2 {
+			continue
+		}
+
+		var job PlantScanJob
+		if err := json.Unmarshal([]byte(result[1]), &job); err != nil {
+			slog.Warn("dropping malformed plant scan job", "error", err)
+			continue
+		}
+
+		interpretation, err := requestInterpretation(endpoint, job)
+		if err != nil {
+			job.Attempts++
+			slog.Warn("interpretation of plant scan failed", "scanId", job.ScanID, "attempt", job.Attempts, "error", err)
+			if job.Attempts < maxInterpretationAttempts {
+				if err := cache.Enqueue(PlantScanInterpretationQueue, job); err != nil {
+					slog.Warn("failed to requeue plant scan", "scanId", job.ScanID, "error", err)
+				}
+				continue
+			}
+			markInterpretationFailed(job)
+			continue
+		}
+
+		if err := saveInterpretation(job, interpretation); err != nil {
+			slog.Warn("failed to save plant scan interpretation", "scanId", job.ScanID, "error", err)
+		}
+	}
+}
+
+// requestInterpretation posts a scan to the inference service and decodes its
+// diagnosis. PLANT_SCAN_INFERENCE_TOKEN, when set, is sent as a Bearer token.
+func requestInterpretation(endpoint string, job PlantScanJob) (*ParsedInterpretation, error) {
+	agent := fiber.Post(endpoint).Timeout(inferenceTimeout).JSON(inferenceRequest{
+		ScanID:   job.ScanID,
+		CropType: job.CropType,
+		Note:     job.Note,
+		ImageURI: job.ImageURI,
+		ImageURL: portfolioServices.BuildIpfsUri(job.ImageURI),
+	})
+	if token := os.Getenv("PLANT_SCAN_INFERENCE_TOKEN"); token != "" {
+		agent.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+
+	status, body, errs := agent.Bytes()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("inference request failed: %v", errs[0])
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("inference service returned status %d: %s", status, string(body))
+	}
+
+	var interpretation ParsedInterpretation
+	if err := json.Unmarshal(body, &interpretation); err != nil {
+		return nil, fmt.Errorf("failed to decode interpretation: %w", err)
+	}
+	interpretation.Diagnosis = strings.TrimSpace(interpretation.Diagnosis)
+	if interpretation.Diagnosis == "" {
+		return nil, fmt.Errorf("inference service returned no diagnosis")
+	}
+	if interpretation.Recommendations == nil {
+		interpretation.Recommendations = []string{}
+	}
+	return &interpretation, nil
+}
+
+// saveInterpretation writes a completed interpretation onto its PlantScan node and
+// notifies the farm's members. The scan is marked notified in the same write so the
+// interpretation notifier does not announce it again.
+func saveInterpretation(job PlantScanJob, interpretation *ParsedInterpretation) error {
+	if _, err := memgraph.ExecuteWrite(`MATCH (ps:PlantScan {id: $id})
+		SET ps.interpretation = {diagnosis: $diagnosis, reason: $reason, recommendations: $recommendations},
+			ps.interpretationStatus = $status, ps.interpretedAt = timestamp(),
+			ps.interpretationNotifiedAt = timestamp(), ps.searchText = $searchText`, map[string]any{
+		"id":              job.ScanID,
+		"diagnosis":       interpretation.Diagnosis,
+		"reason":          interpretation.Reason,
+		"recommendations": interpretation.Recommendations,
+		"status":          InterpretationCompleted,
+		"searchText":      scanSearchText(job.Note, interpretation),
+	}); err != nil {
+		return fmt.Errorf("failed to save interpretation of plant scan %s: %w", job.ScanID, err)
+	}
+
+	InvalidateFarmScansCache(job.FarmName)
+
+	farm, err := getFarm(job.FarmID)
+	if err != nil {
+		return fmt.Errorf("failed to load farm of plant scan %s: %w", job.ScanID, err)
+	}
+	notifyPlantScanInterpreted(farm, job.ScanID, job.CropType, interpretation.Diagnosis)
+	return nil
+}
+
+// markInterpretationFailed records that a scan could not be interpreted after all attempts
+func markInterpretationFailed(job PlantScanJob) {
+	if _, err := memgraph.ExecuteWrite(`MATCH (ps:PlantScan {id: $id}) SET ps.interpretationStatus = $status`,
+		map[string]any{"id": job.ScanID, "status": InterpretationFailed}); err != nil {
+		slog.Warn("failed to mark plant scan as failed", "scanId", job.ScanID, "error", err)
+		return
+	}
+	InvalidateFarmScansCache(job.FarmName)
+}
+
+// notifyPlantScanInterpreted tells a farm's owner and collaborators that a plant scan
+// has been diagnosed, on the farm's alert channels
+func notifyPlantScanInterpreted(farm *FarmList, scanID, cropType, diagnosis string) {
+	title := fmt.Sprintf("Plant scan analyzed on %s", farm.FarmName)
+	message := fmt.Sprintf("%s scan: %s", cropType, diagnosis)
+	data := map[string]any{
+		"farmId":    farm.ID,
+		"scanId":    scanID,
+		"diagnosis": diagnosis,
+		"deepLink":  scanDeepLink(farm.ID, "scans", scanID),
+	}
+	notifyFarmMembers(farm, notificationServices.TypeScanInterpreted, title, message, data)
+	alertNearbyFarms(farm, diagnosis)
+}
+
+// notifyFarmMembers sends a notification to a farm's owner and every collaborator on
+// the farm's alert channels
+func notifyFarmMembers(farm *FarmList, notificationType, title, message string, data map[string]any) {
+	settings, err := loadAlertSettings(farm.ID)
+	if err != nil {
+		settings = &FarmAlertSettings{Channels: defaultAlertChannels}
+	}
+	recipients, err := farmMembers(farm)
+	if err != nil {
+		slog.Warn("failed to load members of farm", "farm", farm.FarmName, "error", err)
+		recipients = []string{farm.Owner}
+	}
+	for _, recipient := range recipients {
+		if err := notificationServices.NotifyVia(settings.Channels, recipient, notificationType, title, message, data); err != nil {
+			slog.Warn("failed to send notification for farm", "type", notificationType, "farm", farm.FarmName, "error", err)
+		}
+	}
+}
+
+// scanDeepLink builds the app link to a scan or reading detail screen. The link base
+// defaults to decentragri:// and can be overridden with APP_DEEP_LINK_BASE.
+func scanDeepLink(farmID, kind, id string) string {
+	base := os.Getenv("APP_DEEP_LINK_BASE")
+	if base == "" {
+		base = "decentragri://"
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return fmt.Sprintf("%sfarms/%s/%s/%s", base, farmID, kind, id)
+}
+
+// soilProblemTerms mark a soil reading interpretation as needing attention
+var soilProblemTerms = []string{"critical", "poor"}
+
+// soilReadingProblems returns the interpretation fields that report a problem, and
+// whether any of them is critical
+func soilReadingProblems(interpretation Interpretation) (map[string]string, bool) {
+	fields := map[string]string{
+		"evaluation":  interpretation.Evaluation,
+		"fertility":   interpretation.Fertility,
+		"moisture":    interpretation.Moisture,
+		"ph":          interpretation.PH,
+		"temperature": interpretation.Temperature,
+		"sunlight":    interpretation.Sunlight,
+		"humidity":    interpretation.Humidity,
+	}
+	problems := make(map[string]string)
+	critical := false
+	for name, text := range fields {
+		lower := strings.ToLower(text)
+		for _, term := range soilProblemTerms {
+			if strings.Contains(lower, term) {
+				problems[name] = text
+				critical = critical || term == "critical"
+				break
+			}
+		}
+	}
+	return problems, critical
+}
+
+// CheckNewInterpretations announces interpretations attached to recent plant scans and
+// soil readings by other services. Each plant scan interpretation and each soil reading
+// interpretation reporting a poor or critical condition is announced once to the farm's
+// owner and collaborators.
+func CheckNewInterpretations() {
+	since := time.Now().UTC().Add(-interpretationNotifyWindow).Format(time.RFC3339)
+
+	scans, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:HAS_PLANT_SCAN]->(ps:PlantScan)
+		WHERE ps.interpretation IS NOT NULL AND ps.interpretationNotifiedAt IS NULL AND ps.deletedAt IS NULL
+			AND COALESCE(ps.date, ps.createdAt, ps.created_at, ps.timestamp, '') >= $since
+		RETURN f.id AS farmId, ps.id AS id, ps.cropType AS cropType, ps.interpretation AS interpretation
+		LIMIT $limit`, map[string]any{"since": since, "limit": maxInterpretationNotices})
+	if err != nil {
+		slog.Warn("failed to load new plant scan interpretations", "error", err)
+	}
+	for _, record := range scans {
+		scanID := memgraph.String(record, "id")
+		// Claim the scan so concurrent checks announce it once
+		summary, err := memgraph.ExecuteWrite(`MATCH (ps:PlantScan {id: $id})
+			WHERE ps.interpretationNotifiedAt IS NULL
+			SET ps.interpretationNotifiedAt = timestamp()`, map[string]any{"id": scanID})
+		if err != nil || summary == nil || summary.Counters().PropertiesSet() == 0 {
+			continue
+		}
+		farm, err := getFarm(memgraph.String(record, "farmId"))
+		if err != nil {
+			continue
+		}
+		diagnosis := "Analysis available"
+		if parsed, ok := parsePlantScanInterpretation(record, "interpretation").(ParsedInterpretation); ok && parsed.Diagnosis != "" {
+			diagnosis = parsed.Diagnosis
+		}
+		notifyPlantScanInterpreted(farm, scanID, memgraph.String(record, "cropType"), diagnosis)
+	}
+
+	readings, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:HAS_SENSOR]->(:Sensor)-[:HAS_READING]->(r:Reading)-[:INTERPRETED_AS]->(i:Interpretation)
+		WHERE i.notifiedAt IS NULL AND r.createdAt >= $since
+		RETURN f.id AS farmId, r.id AS id, r.sensorId AS sensorId, id(i) AS interpretationId, i.value AS interpretation
+		LIMIT $limit`, map[string]any{"since": since, "limit": maxInterpretationNotices})
+	if err != nil {
+		slog.Warn("failed to load new soil reading interpretations", "error", err)
+	}
+	for _, record := range readings {
+		summary, err := memgraph.ExecuteWrite(`MATCH (i:Interpretation)
+			WHERE id(i) = $id AND i.notifiedAt IS NULL
+			SET i.notifiedAt = timestamp()`, map[string]any{"id": memgraph.Int64(record, "interpretationId")})
+		if err != nil || summary == nil || summary.Counters().PropertiesSet() == 0 {
+			continue
+		}
+		problems, critical := soilReadingProblems(parseInterpretation(record, "interpretation"))
+		if len(problems) == 0 {
+			continue
+		}
+		farm, err := getFarm(memgraph.String(record, "farmId"))
+		if err != nil {
+			continue
+		}
+
+		readingID := memgraph.String(record, "id")
+		severity := "poor"
+		if critical {
+			severity = "critical"
+		}
+		metrics := make([]string, 0, len(problems))
+		for name := range problems {
+			metrics = append(metrics, name)
+		}
+		sort.Strings(metrics)
+		title := fmt.Sprintf("Soil needs attention on %s", farm.FarmName)
+		message := fmt.Sprintf("Sensor %s reports %s conditions: %s", memgraph.String(record, "sensorId"), severity, strings.Join(metrics, ", "))
+		data := map[string]any{
+			"farmId":    farm.ID,
+			"readingId": readingID,
+			"severity":  severity,
+			"problems":  problems,
+			"deepLink":  scanDeepLink(farm.ID, "readings", readingID),
+		}
+		notifyFarmMembers(farm, notificationServices.TypeSoilReadingProblem, title, message, data)
+	}
+}
+
+// StartInterpretationNotifier checks for new interpretations on a fixed interval. The
+// interval defaults to 2 minutes and can be overridden with INTERPRETATION_CHECK_INTERVAL.
+// It blocks, so run it in a goroutine.
+func StartInterpretationNotifier() {
+	interval := 2 * time.Minute
+	if v := os.Getenv("INTERPRETATION_CHECK_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("interpretation notifier started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		CheckNewInterpretations()
+	}
+}
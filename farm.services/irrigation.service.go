@@ -0,0 +1,343 @@
+package farmservices
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+)
+
+const (
+	// moistureWindow is how far back readings are averaged into the current soil moisture
+	moistureWindow = 24 * time.Hour
+	// moistureMaxAge is the age beyond which the latest moisture reading is too stale to plan on
+	moistureMaxAge = 72 * time.Hour
+	// rainEfficiency is the share of forecast rain assumed to reach the root zone
+	rainEfficiency = 0.8
+	// hotDayTemperature is the forecast maximum (°C) above which evening watering is suggested too
+	hotDayTemperature = 32
+)
+
+// ErrNoRecentMoisture is returned when a farm has no moisture reading recent enough to plan on
+var ErrNoRecentMoisture = errors.New("farm has no recent soil moisture readings")
+
+// cropWater holds the water assumptions behind irrigation recommendations
+type cropWater struct {
+	Kc        float64    // Mid-season crop coefficient (crop ET = Kc x reference ET0)
+	RootDepth float64    // Effective root zone depth (mm)
+	Moisture  [2]float64 // Target soil moisture band (%); irrigate below the lower bound
+}
+
+// defaultCropWater is used for crops without a specific profile
+var defaultCropWater = cropWater{Kc: 1.0, RootDepth: 400, Moisture: [2]float64{20, 60}}
+
+// cropWaterProfiles are FAO-56 mid-season crop coefficients and typical root depths for
+// common crops, keyed by lower-case crop type
+var cropWaterProfiles = map[string]cropWater{
+	"rice":      {Kc: 1.2, RootDepth: 300, Moisture: [2]float64{40, 80}},
+	"corn":      {Kc: 1.2, RootDepth: 800, Moisture: [2]float64{25, 60}},
+	"wheat":     {Kc: 1.15, RootDepth: 800, Moisture: [2]float64{20, 50}},
+	"soybean":   {Kc: 1.15, RootDepth: 600, Moisture: [2]float64{25, 60}},
+	"tomato":    {Kc: 1.15, RootDepth: 500, Moisture: [2]float64{30, 70}},
+	"potato":    {Kc: 1.15, RootDepth: 400, Moisture: [2]float64{30, 70}},
+	"cassava":   {Kc: 0.8, RootDepth: 600, Moisture: [2]float64{20, 60}},
+	"banana":    {Kc: 1.1, RootDepth: 500, Moisture: [2]float64{40, 80}},
+	"coffee":    {Kc: 0.95, RootDepth: 900, Moisture: [2]float64{30, 70}},
+	"cacao":     {Kc: 1.0, RootDepth: 700, Moisture: [2]float64{40, 80}},
+	"sugarcane": {Kc: 1.25, RootDepth: 1000, Moisture: [2]float64{40, 80}},
+}
+
+// cropWaterAliases maps alternative crop names to their profile
+var cropWaterAliases = map[string]string{
+	"maize":      "corn",
+	"soy":        "soybean",
+	"soybeans":   "soybean",
+	"tomatoes":   "tomato",
+	"potatoes":   "potato",
+	"bananas":    "banana",
+	"cocoa":      "cacao",
+	"sugar cane": "sugarcane",
+}
+
+// IrrigationDay is the recommendation for one forecast day
+type IrrigationDay struct {
+	Date              string   `json:"date"`
+	ET0               float64  `json:"et0"`               // Reference evapotranspiration, mm
+	CropET            float64  `json:"cropEt"`            // Crop water use, mm
+	EffectiveRain     float64  `json:"effectiveRain"`     // Forecast rain expected to reach the roots, mm
+	RainProbability   float64  `json:"rainProbability"`   // %
+	ProjectedMoisture float64  `json:"projectedMoisture"` // End-of-day soil moisture (%) after any recommended irrigation
+	Irrigate          bool     `json:"irrigate"`
+	AmountMm          float64  `json:"amountMm,omitempty"`
+	AmountLiters      *float64 `json:"amountLiters,omitempty"` // For the whole farm; needs its boundary area
+	Timing            string   `json:"timing,omitempty"`
+	Reason            string   `json:"reason"`
+}
+
+// IrrigationPlan is a farm's irrigation recommendations for the forecast week
+type IrrigationPlan struct {
+	FarmID          string          `json:"farmId"`
+	FarmName        string          `json:"farmName"`
+	CropType        string          `json:"cropType"`
+	CurrentMoisture float64         `json:"currentMoisture"` // Average of the last day's readings (%)
+	MoistureReadAt  string          `json:"moistureReadAt"`  // Latest reading used
+	ReadingsUsed    int             `json:"readingsUsed"`
+	TargetMoisture  [2]float64      `json:"targetMoisture"` // Band kept between (%)
+	AreaHectares    float64         `json:"areaHectares,omitempty"`
+	Days            []IrrigationDay `json:"days"`
+	GeneratedAt     int64           `json:"generatedAt"`
+}
+
+// GetIrrigationPlan returns daily irrigation recommendations for a farm the caller can view
+func GetIrrigationPlan(token, farmID string) (*IrrigationPlan, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return irrigationPlanFor(farm)
+}
+
+// irrigationPlanFor builds a farm's plan. Plans are cached for 30 minutes under the
+// farm's scans prefix, so new readings invalidate them.
+func irrigationPlanFor(farm *FarmList) (*IrrigationPlan, error) {
+	cacheKey := fmt.Sprintf("farm_scans:%s:irrigation", farm.FarmName)
+	var cached IrrigationPlan
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	moisture, readAt, readings, err := recentMoisture(farm.ID)
+	if err != nil {
+		return nil, err
+	}
+	forecast, err := forecastFor(farm.Coordinates.Lat, farm.Coordinates.Lng)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := cropWaterFor(farm.CropType)
+	plan := &IrrigationPlan{
+		FarmID:          farm.ID,
+		FarmName:        farm.FarmName,
+		CropType:        farm.CropType,
+		CurrentMoisture: round1(moisture),
+		MoistureReadAt:  readAt,
+		ReadingsUsed:    readings,
+		TargetMoisture:  profile.Moisture,
+		AreaHectares:    farm.AreaHectares,
+		Days:            recommendIrrigation(profile, moisture, forecast.Daily, farm.AreaHectares),
+		GeneratedAt:     time.Now().Unix(),
+	}
+
+	cacheFarmScans(farm.FarmName, cacheKey, plan, config.CacheTTL(config.TTLIrrigation))
+
+	return plan, nil
+}
+
+// recommendIrrigation runs a daily root-zone water balance over the forecast. Each day
+// the crop uses Kc x ET0 and probability-weighted rain refills the soil up to the top of
+// the band; when the day would end below the band, irrigation to its midpoint is advised.
+func recommendIrrigation(profile cropWater, moisture float64, daily []DailyForecast, areaHectares float64) []IrrigationDay {
+	// Work in millimetres of water held in the root zone
+	toMm := profile.RootDepth / 100
+	stored := moisture * toMm
+	floor := profile.Moisture[0] * toMm
+	ceiling := profile.Moisture[1] * toMm
+	target := (profile.Moisture[0] + profile.Moisture[1]) / 2 * toMm
+
+	days := make([]IrrigationDay, 0, len(daily))
+	for _, forecast := range daily {
+		cropET := profile.Kc * forecast.Evapotranspiration
+		rain := forecast.Precipitation * rainEfficiency
+		if forecast.RainProbability > 0 {
+			rain *= forecast.RainProbability / 100
+		}
+
+		day := IrrigationDay{
+			Date:            forecast.Date,
+			ET0:             round1(forecast.Evapotranspiration),
+			CropET:          round1(cropET),
+			EffectiveRain:   round1(rain),
+			RainProbability: forecast.RainProbability,
+		}
+
+		stored = math.Min(stored-cropET+rain, ceiling)
+		if stored < 0 {
+			stored = 0
+		}
+		switch {
+		case stored < floor:
+			amount := target - stored
+			day.Irrigate = true
+			day.AmountMm = round1(amount)
+			if areaHectares > 0 {
+				// 1 mm over 1 ha is 10,000 litres
+				liters := math.Round(amount * areaHectares * 10000)
+				day.AmountLiters = &liters
+			}
+			day.Timing = "Early morning (05:00-08:00)"
+			if forecast.TemperatureMax >= hotDayTemperature {
+				day.Timing = "Early morning (05:00-08:00), or split with an evening watering after 18:00"
+			}
+			day.Reason = fmt.Sprintf("Soil moisture would drop to %.0f%%, below the %.0f%% minimum", stored/toMm, profile.Moisture[0])
+			stored = target
+		case rain >= cropET && rain > 0:
+			day.Reason = fmt.Sprintf("%.1f mm of rain is expected to cover crop water use", rain)
+		default:
+			day.Reason = "Soil moisture stays within the target band"
+		}
+		day.ProjectedMoisture = round1(stored / toMm)
+		days = append(days, day)
+	}
+	return days
+}
+
+// recentMoisture averages a farm's moisture readings over the last day before its latest
+// reading, which must be newer than moistureMaxAge
+func recentMoisture(farmID string) (avg float64, readAt string, count int, err error) {
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[:HAS_SENSOR]->(:Sensor)-[:HAS_READING]->(r:Reading)
+		WHERE r.moisture IS NOT NULL AND r.createdAt >= $since
+		RETURN r.moisture AS moisture, r.createdAt AS createdAt
+		ORDER BY r.createdAt DESC
+		LIMIT 500`, map[string]any{
+		"farmId": farmID,
+		"since":  time.Now().UTC().Add(-moistureMaxAge).Format(time.RFC3339),
+	})
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("failed to fetch moisture readings: %w", err)
+	}
+	if len(records) == 0 {
+		return 0, "", 0, ErrNoRecentMoisture
+	}
+
+	rawLatest, _ := records[0].Get("createdAt")
+	latest := parseDate(rawLatest)
+	var sum float64
+	for _, record := range records {
+		rawCreatedAt, _ := record.Get("createdAt")
+		if createdAt := parseDate(rawCreatedAt); latest.Sub(createdAt) > moistureWindow {
+			break
+		}
+		if v, ok := memgraph.Float64(record, "moisture"); ok {
+			sum += v
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, "", 0, ErrNoRecentMoisture
+	}
+	return sum / float64(count), latest.Format(time.RFC3339), count, nil
+}
+
+// cropWaterFor looks up the water profile of a crop type
+func cropWaterFor(cropType string) cropWater {
+	crop := strings.ToLower(strings.TrimSpace(cropType))
+	if profile, ok := cropWaterProfiles[crop]; ok {
+		return profile
+	}
+	if profile, ok := cropWaterProfiles[cropWaterAliases[crop]]; ok {
+		return profile
+	}
+	return defaultCropWater
+}
+
+// round1 rounds to one decimal place
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// CheckIrrigationNeeds notifies the owner and managers of every farm whose plan calls for
+// irrigation today, once per farm per day, on the farm's alert channels
+func CheckIrrigationNeeds() {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:HAS_SENSOR]->(:Sensor)
+		WHERE f.deletedAt IS NULL AND (coalesce(f.lat, f.coordinates.lat, 0) <> 0 OR coalesce(f.lng, f.coordinates.lng, 0) <> 0)
+		RETURN DISTINCT f.id AS id`, nil)
+	if err != nil {
+		slog.Warn("failed to load farms for irrigation check", "error", err)
+		return
+	}
+
+	for _, record := range records {
+		farm, err := getFarm(memgraph.String(record, "id"))
+		if err != nil {
+			continue
+		}
+		plan, err := irrigationPlanFor(farm)
+		if err != nil {
+			if !errors.Is(err, ErrNoRecentMoisture) && !errors.Is(err, ErrFarmNoCoordinates) {
+				slog.Warn("irrigation check failed for farm", "farm", farm.FarmName, "error", err)
+			}
+			continue
+		}
+		if len(plan.Days) == 0 || !plan.Days[0].Irrigate {
+			continue
+		}
+		today := plan.Days[0]
+
+		// Claim today's notification so repeated checks stay quiet
+		summary, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $id})
+			WHERE coalesce(f.irrigationNotifiedFor, '') <> $date
+			SET f.irrigationNotifiedFor = $date`, map[string]any{"id": farm.ID, "date": today.Date})
+		if err != nil {
+			slog.Warn("failed to record irrigation notice for farm", "farm", farm.FarmName, "error", err)
+			continue
+		}
+		if summary == nil || summary.Counters().PropertiesSet() == 0 {
+			continue
+		}
+
+		settings, err := loadAlertSettings(farm.ID)
+		if err != nil {
+			settings = &FarmAlertSettings{Channels: defaultAlertChannels}
+		}
+		recipients, err := farmManagers(farm)
+		if err != nil {
+			slog.Warn("failed to load managers of farm", "farm", farm.FarmName, "error", err)
+			recipients = []string{farm.Owner}
+		}
+		title := fmt.Sprintf("Irrigate %s today", farm.FarmName)
+		message := fmt.Sprintf("%s Apply about %.0f mm. %s.", today.Reason, today.AmountMm, today.Timing)
+		data := map[string]any{"farmId": farm.ID, "date": today.Date, "amountMm": today.AmountMm}
+		for _, recipient := range recipients {
+			if err := notificationServices.NotifyVia(settings.Channels, recipient, notificationServices.TypeIrrigationNeeded, title, message, data); err != nil {
+				slog.Warn("failed to send irrigation notice for farm", "farm", farm.FarmName, "error", err)
+			}
+		}
+	}
+}
+
+// StartIrrigationAdvisor checks farms for irrigation needs on a fixed interval. The
+// interval defaults to 6 hours and can be overridden with IRRIGATION_CHECK_INTERVAL. It
+// blocks, so run it in a goroutine.
+func StartIrrigationAdvisor() {
+	interval := 6 * time.Hour
+	if v := os.Getenv("IRRIGATION_CHECK_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("irrigation advisor started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		CheckIrrigationNeeds()
+	}
+}
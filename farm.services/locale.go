@@ -0,0 +1,62 @@
+package farmservices
+
+import "decentragri-app-cx-server/utils"
+
+// Services format dates in utils.DefaultLocale so cached results stay locale-neutral;
+// routes rewrite the formatted fields for the caller's locale from the ISO timestamps
+// just before responding.
+
+// Localize rewrites the farm's formatted dates in locale
+func (f *FarmList) Localize(locale string) {
+	if f == nil {
+		return
+	}
+	f.FormattedCreatedAt = utils.FormatDate(f.CreatedAt, locale)
+	f.FormattedUpdatedAt = utils.FormatDate(f.UpdatedAt, locale)
+}
+
+// LocalizeFarms rewrites the formatted dates of every farm in locale
+func LocalizeFarms(farms []FarmList, locale string) {
+	for i := range farms {
+		farms[i].Localize(locale)
+	}
+}
+
+// Localize rewrites the reading's formatted dates in locale
+func (r *SensorReadings) Localize(locale string) {
+	if r == nil {
+		return
+	}
+	r.FormattedCreatedAt = utils.FormatDateTime(r.CreatedAt, locale)
+	r.FormattedSubmittedAt = utils.FormatDateTime(r.SubmittedAt, locale)
+}
+
+// Localize rewrites the scan's formatted date in locale
+func (p *PlantScanResult) Localize(locale string) {
+	if p == nil {
+		return
+	}
+	p.FormattedCreatedAt = utils.FormatDateTime(p.CreatedAt, locale)
+}
+
+// Localize rewrites the formatted dates of every scan and reading in locale
+func (s *FarmScanResult) Localize(locale string) {
+	if s == nil {
+		return
+	}
+	for i := range s.PlantScans {
+		s.PlantScans[i].Localize(locale)
+	}
+	for i := range s.SoilReadings {
+		s.SoilReadings[i].Localize(locale)
+	}
+}
+
+// Localize rewrites the dates of the plot's farm and latest reading in locale
+func (p *PlotFarm) Localize(locale string) {
+	if p == nil {
+		return
+	}
+	p.Farm.Localize(locale)
+	p.LatestReading.Localize(locale)
+}
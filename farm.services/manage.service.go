@@ -0,0 +1,467 @@
+package farmservices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// maxImageSize bounds uploaded farm and scan images
+const maxImageSize = 10 * 1024 * 1024
+
+// Farm management errors
+var (
+	ErrFarmNotFound  = errors.New("farm not found")
+	ErrNotFarmOwner  = errors.New("only the farm owner can modify this farm")
+	ErrFarmNameTaken = errors.New("a farm with this name already exists")
+)
+
+// ErrInvalidRequest is matched by errors caused by the caller's input rather than the
+// server, which routes answer with 400
+var ErrInvalidRequest = errors.New("invalid request")
+
+// invalidRequest is an input error whose message is shown to the caller as is
+type invalidRequest struct {
+	message string
+}
+
+func (e invalidRequest) Error() string { return e.message }
+
+func (e invalidRequest) Is(target error) bool { return target == ErrInvalidRequest }
+
+// invalidf formats an input error matching ErrInvalidRequest
+func invalidf(format string, args ...any) error {
+	return invalidRequest{message: fmt.Sprintf(format, args...)}
+}
+
+// Cache keys for the farm list with and without image bytes
+const (
+	farmListCacheKey     = "farm_list"
+	farmListLiteCacheKey = "farm_list:lite"
+)
+
+// InvalidateFarmListCache drops the cached farm lists so the next read hits Memgraph
+func InvalidateFarmListCache() {
+	cache.InvalidateKeys(farmListCacheKey, farmListLiteCacheKey)
+}
+
+// CreateFarm creates a farm owned by the caller, uploading its image to IPFS when given
+func CreateFarm(ctx context.Context, token string, req CreateFarmRequest, image *FarmImage) (*FarmList, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	req.FarmName = utils.SanitizeInput(req.FarmName)
+	req.CropType = utils.SanitizeInput(req.CropType)
+	req.Description = strings.TrimSpace(req.Description)
+	req.Location = utils.SanitizeInput(req.Location)
+	if !utils.ValidateFarmName(req.FarmName) {
+		return nil, invalidf("invalid farm name")
+	}
+	if err := validateCoordinates(req.Lat, req.Lng); err != nil {
+		return nil, err
+	}
+	boundary, areaHectares, err := encodeBoundary(req.Boundary)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureFarmNameAvailable(req.FarmName, ""); err != nil {
+		return nil, err
+	}
+
+	imageURI := ""
+	if image != nil {
+		if imageURI, err = uploadImage(ctx, image); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	farm := &FarmList{
+		Owner:        username,
+		FarmName:     req.FarmName,
+		ID:           uuid.NewString(),
+		CropType:     req.CropType,
+		Description:  req.Description,
+		Image:        imageURI,
+		Coordinates:  FarmCoordinates{Lat: req.Lat, Lng: req.Lng},
+		Location:     req.Location,
+		Boundary:     req.Boundary,
+		AreaHectares: areaHectares,
+	}
+
+	// MERGE on the name so a concurrent creation of the same farm cannot add a
+	// duplicate; the farm only gets an owner when this request created it
+	query := `MERGE (f:Farm {farmName: $farmName})
+		ON CREATE SET f.id = $id,
+			f.cropType = $cropType,
+			f.description = $description,
+			f.image = $image,
+			f.owner = $owner,
+			f.location = $location,
+			f.coordinates = {lat: $lat, lng: $lng},
+			f.lat = $lat,
+			f.lng = $lng,
+			f.boundary = $boundary,
+			f.areaHectares = $areaHectares,
+			f.createdAt = $now,
+			f.updatedAt = $now
+		WITH f WHERE f.id = $id
+		OPTIONAL MATCH (u:User {username: $owner})
+		FOREACH (_ IN CASE WHEN u IS NULL THEN [] ELSE [1] END | MERGE (u)-[:OWNS_FARM]->(f))`
+	params := map[string]any{
+		"id":           farm.ID,
+		"farmName":     farm.FarmName,
+		"cropType":     farm.CropType,
+		"description":  farm.Description,
+		"image":        farm.Image,
+		"owner":        farm.Owner,
+		"location":     farm.Location,
+		"lat":          req.Lat,
+		"lng":          req.Lng,
+		"boundary":     boundary,
+		"areaHectares": areaHectares,
+		"now":          now,
+	}
+	summary, err := memgraph.ExecuteWrite(query, params)
+	if memgraph.IsConstraintViolation(err) {
+		return nil, ErrFarmNameTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create farm: %w", err)
+	}
+	if summary.Counters().NodesCreated() == 0 {
+		return nil, ErrFarmNameTaken
+	}
+	if imageURI != "" {
+		if err := setCoverImage(farm.ID, imageURI, username); err != nil {
+			slog.Warn("failed to add image of farm to its gallery", "farmId", farm.ID, "error", err)
+		}
+	}
+
+	InvalidateFarmListCache()
+	recordFarmAudit(farm.ID, username, AuditFarmCreated, map[string]any{"farmName": farm.FarmName})
+
+	return withTimestamps(farm, now, now), nil
+}
+
+// UpdateFarm applies a partial update to a farm the caller owns or manages
+func UpdateFarm(ctx context.Context, token, farmID string, req UpdateFarmRequest, image *FarmImage) (*FarmList, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleManager)
+	if err != nil {
+		return nil, err
+	}
+	before := *farm
+
+	sets := make([]string, 0)
+	params := map[string]any{"id": farmID}
+	if req.FarmName != nil {
+		name := utils.SanitizeInput(*req.FarmName)
+		if !utils.ValidateFarmName(name) {
+			return nil, invalidf("invalid farm name")
+		}
+		if name != farm.FarmName {
+			if err := ensureFarmNameAvailable(name, farmID); err != nil {
+				return nil, err
+			}
+		}
+		sets = append(sets, "f.farmName = $farmName")
+		params["farmName"] = name
+		farm.FarmName = name
+	}
+	if req.CropType != nil {
+		farm.CropType = utils.SanitizeInput(*req.CropType)
+		sets = append(sets, "f.cropType = $cropType")
+		params["cropType"] = farm.CropType
+	}
+	if req.Description != nil {
+		farm.Description = strings.TrimSpace(*req.Description)
+		sets = append(sets, "f.description = $description")
+		params["description"] = farm.Description
+	}
+	if req.Location != nil {
+		farm.Location = utils.SanitizeInput(*req.Location)
+		sets = append(sets, "f.location = $location")
+		params["location"] = farm.Location
+	}
+	if req.Lat != nil || req.Lng != nil {
+		if req.Lat != nil {
+			farm.Coordinates.Lat = *req.Lat
+		}
+		if req.Lng != nil {
+			farm.Coordinates.Lng = *req.Lng
+		}
+		if err := validateCoordinates(farm.Coordinates.Lat, farm.Coordinates.Lng); err != nil {
+			return nil, err
+		}
+		sets = append(sets, "f.coordinates = {lat: $lat, lng: $lng}", "f.lat = $lat", "f.lng = $lng")
+		params["lat"] = farm.Coordinates.Lat
+		params["lng"] = farm.Coordinates.Lng
+	}
+	if req.Boundary != nil || req.ClearBoundary {
+		boundary, areaHectares, err := encodeBoundary(req.Boundary)
+		if err != nil {
+			return nil, err
+		}
+		farm.Boundary, farm.AreaHectares = req.Boundary, areaHectares
+		sets = append(sets, "f.boundary = $boundary", "f.areaHectares = $areaHectares")
+		params["boundary"] = boundary
+		params["areaHectares"] = areaHectares
+	}
+	if image != nil {
+		imageURI, err := uploadImage(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+		farm.Image = imageURI
+		sets = append(sets, "f.image = $image")
+		params["image"] = imageURI
+	}
+	if len(sets) == 0 {
+		return nil, invalidf("no fields to update")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	sets = append(sets, "f.updatedAt = $now")
+	params["now"] = now
+
+	query := `MATCH (f:Farm {id: $id}) SET ` + strings.Join(sets, ", ")
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		// A concurrent rename took the name after ensureFarmNameAvailable passed
+		if memgraph.IsConstraintViolation(err) {
+			return nil, ErrFarmNameTaken
+		}
+		return nil, fmt.Errorf("failed to update farm: %w", err)
+	}
+	if image != nil {
+		if err := setCoverImage(farmID, farm.Image, username); err != nil {
+			slog.Warn("failed to add image of farm to its gallery", "farmId", farmID, "error", err)
+		}
+	}
+
+	InvalidateFarmListCache()
+	recordFarmAudit(farmID, username, AuditFarmUpdated, farmChanges(&before, farm, params))
+
+	return withTimestamps(farm, "", now), nil
+}
+
+// DeleteFarm soft-deletes one of the caller's farms. The farm and everything attached
+// to it stay in the graph, hidden, until the purger removes it after the retention
+// period; RestoreFarm brings it back before then. The farm's name is freed at once, so
+// a new farm can use it.
+func DeleteFarm(token, farmID string) error {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return err
+	}
+
+	farm, err := getOwnedFarm(farmID, username)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := farms.Delete(farmID, username)
+	if err != nil {
+		return fmt.Errorf("failed to delete farm: %w", err)
+	}
+	if !deleted {
+		return ErrFarmNotFound
+	}
+
+	InvalidateFarmListCache()
+	recordFarmAudit(farmID, username, AuditFarmDeleted, map[string]any{"farmName": farm.FarmName})
+
+	return nil
+}
+
+// RestoreFarm undoes the deletion of a farm that has not been purged yet. Only its
+// owner can restore it, or an admin (asAdmin). The farm gets its name back, or
+// ErrFarmNameTaken is returned when another farm has used it since.
+func RestoreFarm(token, farmID string, asAdmin bool) (*FarmList, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	farm, err := farms.FindDeleted(farmID)
+	if err != nil {
+		return nil, err
+	}
+	if !asAdmin && !strings.EqualFold(farm.Owner, username) {
+		return nil, ErrNotFarmOwner
+	}
+
+	// A new farm may have taken the name while this one was deleted
+	if err := ensureFarmNameAvailable(farm.FarmName, farmID); err != nil {
+		return nil, err
+	}
+	restored, err := farms.Restore(farmID)
+	if errors.Is(err, ErrFarmNameTaken) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore farm: %w", err)
+	}
+	if !restored {
+		return nil, ErrFarmNotFound
+	}
+
+	InvalidateFarmListCache()
+	recordFarmAudit(farmID, username, AuditFarmRestored, map[string]any{"farmName": farm.FarmName})
+
+	return farm, nil
+}
+
+// getOwnedFarm loads a farm and checks that username owns it. Owners are compared
+// case-insensitively since wallet addresses may be stored checksummed.
+func getOwnedFarm(farmID, username string) (*FarmList, error) {
+	farm, err := getFarm(farmID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(farm.Owner, username) {
+		return nil, ErrNotFarmOwner
+	}
+	return farm, nil
+}
+
+// getFarm loads a farm's stored fields by ID
+func getFarm(farmID string) (*FarmList, error) {
+	return farms.FindByID(farmID)
+}
+
+// ensureFarmNameAvailable rejects names used by another farm; scans and readings
+// address farms by name, so names must stay unique
+func ensureFarmNameAvailable(farmName, exceptID string) error {
+	taken, err := farms.NameTaken(farmName, exceptID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrFarmNameTaken
+	}
+	return nil
+}
+
+// validateCoordinates checks that a latitude/longitude pair is on the globe
+func validateCoordinates(lat, lng float64) error {
+	if !utils.ValidCoordinates(lat, lng) {
+		return invalidf("coordinates out of range")
+	}
+	return nil
+}
+
+// encodeBoundary validates a farm boundary and returns it as the JSON stored on the
+// Farm node with its area in hectares. A nil boundary encodes to null.
+func encodeBoundary(boundary *utils.GeoJSONPolygon) (any, float64, error) {
+	if boundary == nil {
+		return nil, 0, nil
+	}
+	if err := utils.ValidatePolygon(boundary); err != nil {
+		return nil, 0, invalidf("%v", err)
+	}
+	encoded, err := json.Marshal(boundary)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode boundary: %w", err)
+	}
+	return string(encoded), utils.PolygonAreaHectares(boundary), nil
+}
+
+// decodeBoundary reads the boundary and areaHectares columns of a farm record
+func decodeBoundary(record *neo4j.Record) (*utils.GeoJSONPolygon, float64) {
+	raw := memgraph.String(record, "boundary")
+	if raw == "" {
+		return nil, 0
+	}
+	var boundary utils.GeoJSONPolygon
+	if err := json.Unmarshal([]byte(raw), &boundary); err != nil {
+		slog.Warn("invalid stored farm boundary", "error", err)
+		return nil, 0
+	}
+	area, _ := memgraph.Float64(record, "areaHectares")
+	return &boundary, area
+}
+
+// GetFarmDetail returns a single farm, including its GeoJSON boundary, to a caller with
+// at least viewer access to it
+func GetFarmDetail(token, farmID string) (*FarmList, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+	return farmDetail(farmID)
+}
+
+// farmDetail loads a farm with its dates formatted
+func farmDetail(farmID string) (*FarmList, error) {
+	farm, err := getFarm(farmID)
+	if err != nil {
+		return nil, err
+	}
+	if !farm.CreatedAt.IsZero() {
+		farm.FormattedCreatedAt = utils.FormatDate(farm.CreatedAt, utils.DefaultLocale)
+	}
+	if !farm.UpdatedAt.IsZero() {
+		farm.FormattedUpdatedAt = utils.FormatDate(farm.UpdatedAt, utils.DefaultLocale)
+	}
+	return farm, nil
+}
+
+// uploadImage validates an uploaded image and stores it on IPFS
+func uploadImage(parent context.Context, image *FarmImage) (string, error) {
+	if len(image.Data) == 0 {
+		return "", invalidf("image is empty")
+	}
+	if len(image.Data) > maxImageSize {
+		return "", invalidf("image exceeds %d MB", maxImageSize/(1024*1024))
+	}
+	if !strings.HasPrefix(http.DetectContentType(image.Data), "image/") {
+		return "", invalidf("file is not an image")
+	}
+
+	fileName := uuid.NewString() + strings.ToLower(filepath.Ext(image.FileName))
+	ctx, cancel := context.WithTimeout(parent, 2*time.Minute)
+	defer cancel()
+
+	uri, err := utils.UploadPicBuffer(ctx, image.Data, fileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+	return uri, nil
+}
+
+// withTimestamps fills a farm's dates in the format GetFarmList returns
+func withTimestamps(farm *FarmList, createdAt, updatedAt string) *FarmList {
+	if createdAt != "" {
+		farm.CreatedAt = parseDate(createdAt)
+	}
+	farm.UpdatedAt = parseDate(updatedAt)
+	if !farm.CreatedAt.IsZero() {
+		farm.FormattedCreatedAt = utils.FormatDate(farm.CreatedAt, utils.DefaultLocale)
+	}
+	farm.FormattedUpdatedAt = utils.FormatDate(farm.UpdatedAt, utils.DefaultLocale)
+	return farm
+}
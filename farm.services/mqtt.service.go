@@ -0,0 +1,118 @@
+package farmservices
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// defaultMQTTTopic is the reading topic subscribed to when MQTT_TOPIC is unset. The
+// shared subscription group delivers each reading to only one server instance.
+const defaultMQTTTopic = "$share/decentragri-cx/decentragri/farms/+/sensors/+/readings"
+
+// readingTopicIDs extracts the farm and sensor IDs from a topic of the form
+// .../farms/<farmId>/sensors/<sensorId>/readings
+func readingTopicIDs(topic string) (farmID, sensorID string, err error) {
+	parts := strings.Split(topic, "/")
+	for i := 0; i+4 < len(parts); i++ {
+		if parts[i] == "farms" && parts[i+2] == "sensors" && parts[i+4] == "readings" {
+			if parts[i+1] != "" && parts[i+3] != "" {
+				return parts[i+1], parts[i+3], nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("topic %q is not farms/<farmId>/sensors/<sensorId>/readings", topic)
+}
+
+// handleMQTTReading ingests one reading message. The payload is the same JSON as
+// POST /api/farm/:id/readings; the sensor ID comes from the topic and the reading is
+// attributed to the farm owner.
+func handleMQTTReading(topic string, payload []byte) error {
+	farmID, sensorID, err := readingTopicIDs(topic)
+	if err != nil {
+		return err
+	}
+
+	var req CreateReadingRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	if req.SensorID != "" && req.SensorID != sensorID {
+		return fmt.Errorf("payload sensorId %q does not match topic sensor %q", req.SensorID, sensorID)
+	}
+	req.SensorID = sensorID
+
+	farm, err := getFarm(farmID)
+	if err != nil {
+		return err
+	}
+
+	_, err = ingestReading(farm, farm.Owner, req)
+	return err
+}
+
+// StartMQTTBridge subscribes to sensor readings on the MQTT broker at MQTT_BROKER_URL
+// (e.g. tcp://broker:1883) and writes them through the same ingestion path as the REST
+// endpoint. MQTT_TOPIC, MQTT_CLIENT_ID, MQTT_USERNAME, and MQTT_PASSWORD are optional.
+// The bridge is disabled when no broker is configured. It blocks, so run it in a goroutine.
+func StartMQTTBridge() {
+	broker := os.Getenv("MQTT_BROKER_URL")
+	if broker == "" {
+		slog.Info("MQTT bridge disabled: MQTT_BROKER_URL not set")
+		return
+	}
+
+	topic := os.Getenv("MQTT_TOPIC")
+	if topic == "" {
+		topic = defaultMQTTTopic
+	}
+	clientID := os.Getenv("MQTT_CLIENT_ID")
+	if clientID == "" {
+		hostname, _ := os.Hostname()
+		clientID = "decentragri-cx-" + hostname
+	}
+
+	onMessage := func(_ mqtt.Client, msg mqtt.Message) {
+		if err := handleMQTTReading(msg.Topic(), msg.Payload()); err != nil {
+			slog.Warn("failed to ingest MQTT reading", "topic", msg.Topic(), "error", err)
+		}
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(clientID).
+		SetUsername(os.Getenv("MQTT_USERNAME")).
+		SetPassword(os.Getenv("MQTT_PASSWORD")).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(10 * time.Second).
+		SetOrderMatters(false)
+	// Subscribe on every (re)connect so the subscription survives broker restarts
+	opts.SetOnConnectHandler(func(client mqtt.Client) {
+		token := client.Subscribe(topic, 1, onMessage)
+		token.Wait()
+		if err := token.Error(); err != nil {
+			slog.Warn("MQTT subscribe failed", "topic", topic, "error", err)
+			return
+		}
+		slog.Info("MQTT bridge subscribed", "topic", topic)
+	})
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		slog.Warn("MQTT connection lost", "error", err)
+	})
+
+	client := mqtt.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		slog.Warn("MQTT bridge failed to connect", "broker", broker, "error", token.Error())
+		return
+	}
+
+	slog.Info("MQTT bridge started", "broker", broker)
+
+	select {}
+}
@@ -0,0 +1,127 @@
+package farmservices
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/utils"
+)
+
+const (
+	// defaultNearbyRadiusKm is the search radius when none is given
+	defaultNearbyRadiusKm = 25
+	// maxNearbyRadiusKm bounds the search radius
+	maxNearbyRadiusKm = 500
+	// maxNearbyFarms bounds how many farms one search returns
+	maxNearbyFarms = 200
+)
+
+// NearbyFarm is a farm within a search radius, with its distance from the search point
+type NearbyFarm struct {
+	ID           string          `json:"id"`
+	FarmName     string          `json:"farmName"`
+	CropType     string          `json:"cropType"`
+	Owner        string          `json:"owner"`
+	Location     string          `json:"location"`
+	Image        string          `json:"image"`
+	Coordinates  FarmCoordinates `json:"coordinates"`
+	AreaHectares float64         `json:"areaHectares,omitempty"`
+	DistanceKm   float64         `json:"distanceKm"`
+}
+
+// GetNearbyFarms returns farms within radiusKm of lat/lng, nearest first. A bounding box
+// narrows the candidates in Memgraph; the haversine distance then decides the radius.
+func GetNearbyFarms(lat, lng, radiusKm float64, limit int) ([]NearbyFarm, error) {
+	if !utils.ValidCoordinates(lat, lng) {
+		return nil, invalidf("coordinates out of range")
+	}
+	if radiusKm <= 0 {
+		radiusKm = defaultNearbyRadiusKm
+	}
+	if radiusKm > maxNearbyRadiusKm {
+		return nil, invalidf("radiusKm must not exceed %d", maxNearbyRadiusKm)
+	}
+	if limit <= 0 || limit > maxNearbyFarms {
+		limit = maxNearbyFarms
+	}
+
+	box := nearbyBoundingBox(lat, lng, radiusKm)
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm) WHERE f.deletedAt IS NULL
+		WITH f, COALESCE(f.lat, f.coordinates.lat) AS lat, COALESCE(f.lng, f.coordinates.lng) AS lng
+		WHERE lat IS NOT NULL AND lng IS NOT NULL AND lat >= $minLat AND lat <= $maxLat
+		RETURN f.id AS id, f.farmName AS farmName, f.cropType AS cropType, f.owner AS owner,
+			f.location AS location, f.image AS image, f.areaHectares AS areaHectares,
+			lat, lng`, map[string]any{"minLat": box.MinLat, "maxLat": box.MaxLat})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search farms: %w", err)
+	}
+
+	farms := make([]NearbyFarm, 0)
+	for _, record := range records {
+		farmLat, _ := memgraph.Float64(record, "lat")
+		farmLng, _ := memgraph.Float64(record, "lng")
+		// Farms that never set coordinates are stored at 0,0
+		if (farmLat == 0 && farmLng == 0) || !box.Contains(farmLat, farmLng) {
+			continue
+		}
+		distance := utils.HaversineKm(lat, lng, farmLat, farmLng)
+		if distance > radiusKm {
+			continue
+		}
+
+		farm := NearbyFarm{
+			ID:          memgraph.String(record, "id"),
+			FarmName:    memgraph.String(record, "farmName"),
+			CropType:    memgraph.String(record, "cropType"),
+			Owner:       memgraph.String(record, "owner"),
+			Location:    memgraph.String(record, "location"),
+			Image:       memgraph.String(record, "image"),
+			Coordinates: FarmCoordinates{Lat: farmLat, Lng: farmLng},
+			DistanceKm:  math.Round(distance*100) / 100,
+		}
+		farm.AreaHectares, _ = memgraph.Float64(record, "areaHectares")
+		farms = append(farms, farm)
+	}
+
+	sort.Slice(farms, func(i, j int) bool { return farms[i].DistanceKm < farms[j].DistanceKm })
+	if len(farms) > limit {
+		farms = farms[:limit]
+	}
+	return farms, nil
+}
+
+// nearbyBoundingBox returns a box enclosing the radius around lat/lng. Near the poles,
+// or when the box would span every longitude, it covers all longitudes.
+func nearbyBoundingBox(lat, lng, radiusKm float64) *utils.BoundingBox {
+	const kmPerDegree = 111.32
+
+	latDelta := radiusKm / kmPerDegree
+	box := &utils.BoundingBox{
+		MinLat: math.Max(lat-latDelta, -90),
+		MaxLat: math.Min(lat+latDelta, 90),
+		MinLng: -180,
+		MaxLng: 180,
+	}
+
+	cosLat := math.Cos(lat * math.Pi / 180)
+	if box.MinLat == -90 || box.MaxLat == 90 || cosLat < 0.01 {
+		return box
+	}
+	lngDelta := radiusKm / (kmPerDegree * cosLat)
+	if lngDelta >= 180 {
+		return box
+	}
+
+	box.MinLng = lng - lngDelta
+	box.MaxLng = lng + lngDelta
+	// Wrap across the antimeridian; Contains handles MinLng > MaxLng
+	if box.MinLng < -180 {
+		box.MinLng += 360
+	}
+	if box.MaxLng > 180 {
+		box.MaxLng -= 360
+	}
+	return box
+}
@@ -0,0 +1,277 @@
+package farmservices
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	"decentragri-app-cx-server/utils"
+)
+
+const (
+	// defaultOutbreakWindowDays is how far back the outbreak map looks by default
+	defaultOutbreakWindowDays = 30
+	// maxOutbreakWindowDays bounds the outbreak map window
+	maxOutbreakWindowDays = 180
+	// outbreakCellDegrees is the grid cell size detections are snapped to (about 11 km),
+	// so no cell points at a single farm's location
+	outbreakCellDegrees = 0.1
+	// defaultOutbreakAlertRadiusKm is how close a detection must be to alert a farm
+	defaultOutbreakAlertRadiusKm = 10
+	// outbreakAlertCooldown stops repeat alerts of one disease to one farm
+	outbreakAlertCooldown = 24 * time.Hour
+)
+
+// Outbreak map time buckets
+const (
+	OutbreakIntervalDay  = "day"
+	OutbreakIntervalWeek = "week"
+)
+
+// healthyDiagnosisTerms mark a diagnosis as no pest or disease found
+var healthyDiagnosisTerms = []string{"healthy", "no disease", "no pest", "no issue"}
+
+// OutbreakQuery filters the outbreak map
+type OutbreakQuery struct {
+	Days      int
+	Interval  string // day or week
+	Diagnosis string // Substring match, case-insensitive
+	Box       *utils.BoundingBox
+}
+
+// GetOutbreakMap aggregates plant scan diagnoses into grid cells per time bucket for a
+// heatmap. Cells carry counts only: no farm, owner, or exact location is exposed. The
+// aggregation query stops when ctx ends.
+func GetOutbreakMap(ctx context.Context, query OutbreakQuery) (*OutbreakMap, error) {
+	if query.Days <= 0 {
+		query.Days = defaultOutbreakWindowDays
+	}
+	if query.Days > maxOutbreakWindowDays {
+		return nil, invalidf("days must not exceed %d", maxOutbreakWindowDays)
+	}
+	switch query.Interval {
+	case "":
+		query.Interval = OutbreakIntervalWeek
+	case OutbreakIntervalDay, OutbreakIntervalWeek:
+	default:
+		return nil, invalidf("interval must be day or week")
+	}
+	query.Diagnosis = strings.ToLower(strings.TrimSpace(query.Diagnosis))
+
+	box := ""
+	if query.Box != nil {
+		box = fmt.Sprintf("%g,%g,%g,%g", query.Box.MinLng, query.Box.MinLat, query.Box.MaxLng, query.Box.MaxLat)
+	}
+	cacheKey := fmt.Sprintf("outbreaks:%d:%s:%s:%s", query.Days, query.Interval, query.Diagnosis, box)
+	var cached OutbreakMap
+	if err := cache.GetContext(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -query.Days)
+	records, err := memgraph.ExecuteReplicaReadContext(ctx, `MATCH (f:Farm)-[:HAS_PLANT_SCAN]->(ps:PlantScan)
+		WHERE ps.interpretation IS NOT NULL AND ps.deletedAt IS NULL AND f.deletedAt IS NULL
+		WITH f, ps, COALESCE(ps.date, ps.createdAt) AS scannedAt
+		WHERE scannedAt >= $since
+		WITH f, ps, scannedAt, COALESCE(f.lat, f.coordinates.lat) AS lat, COALESCE(f.lng, f.coordinates.lng) AS lng
+		WHERE lat IS NOT NULL AND lng IS NOT NULL
+		RETURN f.id AS farmId, lat, lng, ps.cropType AS cropType, ps.interpretation AS interpretation, scannedAt`,
+		map[string]any{"since": from.Format(time.RFC3339)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch plant scan diagnoses: %w", err)
+	}
+
+	type cellKey struct {
+		lat, lng  float64
+		period    string
+		diagnosis string
+	}
+	type cellStats struct {
+		scans     int
+		farms     map[string]bool
+		cropTypes map[string]bool
+	}
+	cells := make(map[cellKey]*cellStats)
+	for _, record := range records {
+		lat, _ := memgraph.Float64(record, "lat")
+		lng, _ := memgraph.Float64(record, "lng")
+		// Farms that never set coordinates are stored at 0,0
+		if lat == 0 && lng == 0 {
+			continue
+		}
+		if query.Box != nil && !query.Box.Contains(lat, lng) {
+			continue
+		}
+		diagnosis := outbreakDiagnosis(parsePlantScanInterpretation(record, "interpretation"))
+		if diagnosis == "" || (query.Diagnosis != "" && !strings.Contains(diagnosis, query.Diagnosis)) {
+			continue
+		}
+		scannedAt, _ := record.Get("scannedAt")
+		takenAt := parseDate(scannedAt)
+		if takenAt.IsZero() {
+			continue
+		}
+
+		key := cellKey{
+			lat:       outbreakCell(lat),
+			lng:       outbreakCell(lng),
+			period:    outbreakPeriod(takenAt, query.Interval),
+			diagnosis: diagnosis,
+		}
+		stats, ok := cells[key]
+		if !ok {
+			stats = &cellStats{farms: make(map[string]bool), cropTypes: make(map[string]bool)}
+			cells[key] = stats
+		}
+		stats.scans++
+		stats.farms[memgraph.String(record, "farmId")] = true
+		if cropType := memgraph.String(record, "cropType"); cropType != "" {
+			stats.cropTypes[cropType] = true
+		}
+	}
+
+	result := &OutbreakMap{
+		From:        from.Format("2006-01-02"),
+		To:          to.Format("2006-01-02"),
+		Interval:    query.Interval,
+		CellDegrees: outbreakCellDegrees,
+		Cells:       make([]OutbreakCell, 0, len(cells)),
+		Diagnoses:   make([]OutbreakDiagnosis, 0),
+	}
+	totals := make(map[string]*OutbreakDiagnosis)
+	for key, stats := range cells {
+		cropTypes := make([]string, 0, len(stats.cropTypes))
+		for cropType := range stats.cropTypes {
+			cropTypes = append(cropTypes, cropType)
+		}
+		sort.Strings(cropTypes)
+		result.Cells = append(result.Cells, OutbreakCell{
+			Lat:       key.lat,
+			Lng:       key.lng,
+			Period:    key.period,
+			Diagnosis: key.diagnosis,
+			Scans:     stats.scans,
+			Farms:     len(stats.farms),
+			CropTypes: cropTypes,
+		})
+
+		total, ok := totals[key.diagnosis]
+		if !ok {
+			total = &OutbreakDiagnosis{Diagnosis: key.diagnosis}
+			totals[key.diagnosis] = total
+		}
+		total.Scans += stats.scans
+		total.Cells++
+	}
+	for _, total := range totals {
+		result.Diagnoses = append(result.Diagnoses, *total)
+	}
+	sort.Slice(result.Cells, func(i, j int) bool {
+		a, b := result.Cells[i], result.Cells[j]
+		if a.Period != b.Period {
+			return a.Period > b.Period
+		}
+		return a.Scans > b.Scans
+	})
+	sort.Slice(result.Diagnoses, func(i, j int) bool { return result.Diagnoses[i].Scans > result.Diagnoses[j].Scans })
+
+	cache.Set(cacheKey, result, config.CacheTTL(config.TTLOutbreaks))
+	return result, nil
+}
+
+// alertNearbyFarms warns the farms within OUTBREAK_ALERT_RADIUS_KM of farm that a pest
+// or disease was just diagnosed there. The source farm is not named. Each farm hears of
+// a given diagnosis at most once a day; a radius of 0 disables the alerts.
+func alertNearbyFarms(farm *FarmList, diagnosis string) {
+	diagnosis = outbreakDiagnosis(ParsedInterpretation{Diagnosis: diagnosis})
+	if diagnosis == "" || (farm.Coordinates.Lat == 0 && farm.Coordinates.Lng == 0) {
+		return
+	}
+	radiusKm := outbreakAlertRadiusKm()
+	if radiusKm <= 0 {
+		return
+	}
+
+	nearby, err := GetNearbyFarms(farm.Coordinates.Lat, farm.Coordinates.Lng, radiusKm, 0)
+	if err != nil {
+		slog.Warn("failed to find nearby farms for outbreak alert", "farm", farm.FarmName, "error", err)
+		return
+	}
+	for _, neighbour := range nearby {
+		if neighbour.ID == farm.ID {
+			continue
+		}
+		claimed, err := cache.SetNX(fmt.Sprintf("outbreak_alert:%s:%s", neighbour.ID, diagnosis), 1, outbreakAlertCooldown)
+		if err != nil || !claimed {
+			continue
+		}
+
+		target := &FarmList{ID: neighbour.ID, FarmName: neighbour.FarmName, Owner: neighbour.Owner}
+		title := fmt.Sprintf("%s detected near %s", diagnosis, neighbour.FarmName)
+		message := fmt.Sprintf("A plant scan within %g km of %s was diagnosed with %s. Check your crops for signs of it.",
+			radiusKm, neighbour.FarmName, diagnosis)
+		notifyFarmMembers(target, notificationServices.TypeDiseaseNearby, title, message, map[string]any{
+			"farmId":    neighbour.ID,
+			"diagnosis": diagnosis,
+			"radiusKm":  radiusKm,
+		})
+	}
+}
+
+// outbreakAlertRadiusKm reads OUTBREAK_ALERT_RADIUS_KM, defaulting to 10 km
+func outbreakAlertRadiusKm() float64 {
+	if v := os.Getenv("OUTBREAK_ALERT_RADIUS_KM"); v != "" {
+		if radius, err := strconv.ParseFloat(v, 64); err == nil && radius >= 0 {
+			return math.Min(radius, maxNearbyRadiusKm)
+		}
+	}
+	return defaultOutbreakAlertRadiusKm
+}
+
+// outbreakDiagnosis returns an interpretation's diagnosis normalized for grouping, or
+// "" when there is none or it reports a healthy plant
+func outbreakDiagnosis(interpretation any) string {
+	var diagnosis string
+	switch i := interpretation.(type) {
+	case ParsedInterpretation:
+		diagnosis = i.Diagnosis
+	case *ParsedInterpretation:
+		if i != nil {
+			diagnosis = i.Diagnosis
+		}
+	}
+	diagnosis = strings.ToLower(strings.Join(strings.Fields(diagnosis), " "))
+	for _, term := range healthyDiagnosisTerms {
+		if strings.Contains(diagnosis, term) {
+			return ""
+		}
+	}
+	return diagnosis
+}
+
+// outbreakCell snaps a coordinate to the center of its grid cell
+func outbreakCell(degrees float64) float64 {
+	center := (math.Floor(degrees/outbreakCellDegrees) + 0.5) * outbreakCellDegrees
+	return math.Round(center*1000) / 1000
+}
+
+// outbreakPeriod returns the start date of the day or ISO week t falls in
+func outbreakPeriod(t time.Time, interval string) string {
+	t = t.UTC()
+	if interval == OutbreakIntervalWeek {
+		offset := (int(t.Weekday()) + 6) % 7 // Days since Monday
+		t = t.AddDate(0, 0, -offset)
+	}
+	return t.Format("2006-01-02")
+}
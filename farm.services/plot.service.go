@@ -0,0 +1,257 @@
+package farmservices
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	marketplaceservices "decentragri-app-cx-server/marketplace.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Farm plot link errors
+var (
+	ErrPlotAlreadyLinked = errors.New("farm plot is already linked to another farm")
+	ErrPlotLinkNotFound  = errors.New("farm plot is not linked to this farm")
+	ErrNotPlotOwner      = errors.New("only a holder of the farm plot can link it to a farm")
+)
+
+// plotLinkReturn projects a plot link matched as farm f, relationship t, and plot n
+const plotLinkReturn = `RETURN n.tokenId AS tokenId, n.contract AS contract, f.id AS farmId,
+	t.linkedBy AS linkedBy, t.linkedAt AS linkedAt`
+
+// LinkFarmPlot records that a farm plot NFT tokenizes one of the caller's farms. The
+// caller must hold the plot on the farm plot contract, and it can back only one farm;
+// linking it to the same farm again is a no-op.
+func LinkFarmPlot(token, farmID string, req LinkFarmPlotRequest) (*FarmPlotLink, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := getOwnedFarm(farmID, username); err != nil {
+		return nil, err
+	}
+	tokenID, contract, err := validatePlotToken(req.TokenID, req.Contract)
+	if err != nil {
+		return nil, err
+	}
+	held, err := marketplaceservices.HoldsPlot(username, tokenID, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not verify farm plot %s: %w", tokenID, err)
+	}
+	if !held {
+		return nil, ErrNotPlotOwner
+	}
+
+	// Only link plots that back no farm yet
+	if _, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		MERGE (n:FarmPlotNFT {contract: $contract, tokenId: $tokenId})
+		WITH f, n
+		WHERE NOT exists((:Farm)-[:TOKENIZED_AS]->(n))
+		CREATE (f)-[:TOKENIZED_AS {linkedBy: $linkedBy, linkedAt: $linkedAt}]->(n)`, map[string]any{
+		"farmId":   farmID,
+		"contract": contract,
+		"tokenId":  tokenID,
+		"linkedBy": username,
+		"linkedAt": time.Now().UnixMilli(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to link farm plot: %w", err)
+	}
+
+	links, err := queryPlotLinks("", contract, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, fmt.Errorf("failed to link farm plot %s", tokenID)
+	}
+	if links[0].FarmID != farmID {
+		return nil, ErrPlotAlreadyLinked
+	}
+	return &links[0], nil
+}
+
+// UnlinkFarmPlot removes a plot from one of the caller's farms
+func UnlinkFarmPlot(token, farmID, tokenID string) error {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return err
+	}
+	if _, err := getOwnedFarm(farmID, username); err != nil {
+		return err
+	}
+
+	summary, err := memgraph.ExecuteWrite(`MATCH (:Farm {id: $farmId})-[t:TOKENIZED_AS]->(:FarmPlotNFT {tokenId: $tokenId})
+		DELETE t`, map[string]any{"farmId": farmID, "tokenId": tokenID})
+	if err != nil {
+		return fmt.Errorf("failed to unlink farm plot: %w", err)
+	}
+	if summary == nil || summary.Counters().RelationshipsDeleted() == 0 {
+		return ErrPlotLinkNotFound
+	}
+	return nil
+}
+
+// GetFarmPlots lists the plot NFTs linked to a farm
+func GetFarmPlots(token, farmID string) ([]FarmPlotLink, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+	return queryPlotLinks(farmID, "", "")
+}
+
+// GetPlotFarm returns the farm a plot NFT is linked to, so portfolio and marketplace
+// views can show where a plot is. Callers with viewer access to the farm also get its
+// full detail and latest sensor reading; anyone else gets the farm's public projection.
+func GetPlotFarm(token, tokenID string) (*PlotFarm, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	tokenID, contract, err := validatePlotToken(tokenID, "")
+	if err != nil {
+		return nil, err
+	}
+	links, err := queryPlotLinks("", contract, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, fmt.Errorf("%w: no farm is linked to plot %s", ErrFarmNotFound, tokenID)
+	}
+
+	farm, err := farmDetail(links[0].FarmID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := farmRole(farm, username)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return &PlotFarm{TokenID: tokenID, Contract: contract, Farm: publicFarm(farm)}, nil
+	}
+	reading, err := latestReading(farm.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &PlotFarm{TokenID: tokenID, Contract: contract, Farm: farm, LatestReading: reading}, nil
+}
+
+// publicFarm projects the fields of a farm anyone may see, the same ones nearby search
+// returns: no description, boundary, scans, or readings
+func publicFarm(farm *FarmList) *FarmList {
+	return &FarmList{
+		ID:                 farm.ID,
+		FarmName:           farm.FarmName,
+		CropType:           farm.CropType,
+		Owner:              farm.Owner,
+		Location:           farm.Location,
+		Image:              farm.Image,
+		ImageURL:           farm.ImageURL,
+		Coordinates:        farm.Coordinates,
+		AreaHectares:       farm.AreaHectares,
+		Verified:           farm.Verified,
+		CreatedAt:          farm.CreatedAt,
+		FormattedCreatedAt: farm.FormattedCreatedAt,
+	}
+}
+
+// validatePlotToken checks a plot token ID and contract, defaulting the contract to the
+// farm plot contract, the only one plots can be linked from
+func validatePlotToken(tokenID, contract string) (string, string, error) {
+	tokenID = strings.TrimSpace(tokenID)
+	if _, err := strconv.ParseUint(tokenID, 10, 64); err != nil {
+		return "", "", invalidf("tokenId must be a non-negative integer")
+	}
+	contract = strings.TrimSpace(contract)
+	if contract == "" {
+		contract = config.FarmPlotContractAddress
+	}
+	if !strings.EqualFold(contract, config.FarmPlotContractAddress) {
+		return "", "", invalidf("contract must be the farm plot contract %s", config.FarmPlotContractAddress)
+	}
+	return tokenID, strings.ToLower(contract), nil
+}
+
+// queryPlotLinks reads plot links, filtered to one farm and/or one plot when given
+func queryPlotLinks(farmID, contract, tokenID string) ([]FarmPlotLink, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[t:TOKENIZED_AS]->(n:FarmPlotNFT)
+		WHERE f.deletedAt IS NULL AND ($farmId = '' OR f.id = $farmId)
+			AND ($contract = '' OR n.contract = $contract)
+			AND ($tokenId = '' OR n.tokenId = $tokenId)
+		WITH f, t, n ORDER BY t.linkedAt
+		`+plotLinkReturn, map[string]any{"farmId": farmID, "contract": contract, "tokenId": tokenID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch farm plots: %w", err)
+	}
+
+	links := make([]FarmPlotLink, 0, len(records))
+	for _, record := range records {
+		links = append(links, FarmPlotLink{
+			TokenID:  memgraph.String(record, "tokenId"),
+			Contract: memgraph.String(record, "contract"),
+			FarmID:   memgraph.String(record, "farmId"),
+			LinkedBy: memgraph.String(record, "linkedBy"),
+			LinkedAt: memgraph.Int64(record, "linkedAt"),
+		})
+	}
+	return links, nil
+}
+
+// latestReading returns a farm's most recent sensor reading, or nil when it has none
+func latestReading(farmID string) (*SensorReadings, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[:HAS_SENSOR]->(:Sensor)-[:HAS_READING]->(r:Reading)
+		WITH r ORDER BY r.createdAt DESC LIMIT 1
+		RETURN r.id AS id, r.sensorId AS sensorId, r.farmName AS farmName, r.cropType AS cropType,
+			r.fertility AS fertility, r.moisture AS moisture, r.ph AS ph, r.temperature AS temperature,
+			r.sunlight AS sunlight, r.humidity AS humidity, r.createdAt AS createdAt, r.submittedAt AS submittedAt`,
+		map[string]any{"farmId": farmID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest reading: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return readingFromRecord(records[0]), nil
+}
+
+// readingFromRecord maps a reading row to SensorReadings
+func readingFromRecord(record *neo4j.Record) *SensorReadings {
+	metric := func(key string) float64 {
+		v, _ := memgraph.Float64(record, key)
+		return v
+	}
+	createdVal, _ := record.Get("createdAt")
+	submittedVal, _ := record.Get("submittedAt")
+	createdAt := parseDate(createdVal)
+	submittedAt := parseDate(submittedVal)
+
+	return &SensorReadings{
+		Fertility:            metric("fertility"),
+		Moisture:             metric("moisture"),
+		PH:                   metric("ph"),
+		Temperature:          metric("temperature"),
+		Sunlight:             metric("sunlight"),
+		Humidity:             metric("humidity"),
+		FarmName:             memgraph.String(record, "farmName"),
+		CropType:             memgraph.String(record, "cropType"),
+		SensorID:             memgraph.String(record, "sensorId"),
+		ID:                   memgraph.String(record, "id"),
+		CreatedAt:            createdAt,
+		SubmittedAt:          submittedAt,
+		FormattedCreatedAt:   utils.FormatDateTime(createdAt, utils.DefaultLocale),
+		FormattedSubmittedAt: utils.FormatDateTime(submittedAt, utils.DefaultLocale),
+	}
+}
@@ -0,0 +1,324 @@
+package farmservices
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+
+	"github.com/google/uuid"
+)
+
+// sensorIDPattern restricts sensor IDs to simple identifiers
+var sensorIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,64}$`)
+
+// maxReadingBatch caps the readings accepted by one batch request
+const maxReadingBatch = 500
+
+// readingRange is the accepted range of one sensor metric
+type readingRange struct {
+	Name     string
+	Min, Max float64
+}
+
+// Accepted sensor metric ranges; values outside these indicate a faulty sensor
+var (
+	fertilityRange   = readingRange{"fertility", 0, 10000}  // µS/cm
+	moistureRange    = readingRange{"moisture", 0, 100}     // %
+	phRange          = readingRange{"ph", 0, 14}            // pH
+	temperatureRange = readingRange{"temperature", -40, 80} // °C
+	sunlightRange    = readingRange{"sunlight", 0, 200000}  // lux
+	humidityRange    = readingRange{"humidity", 0, 100}     // %
+)
+
+// readingMetrics indexes the metric ranges by name
+var readingMetrics = map[string]readingRange{
+	fertilityRange.Name:   fertilityRange,
+	moistureRange.Name:    moistureRange,
+	phRange.Name:          phRange,
+	temperatureRange.Name: temperatureRange,
+	sunlightRange.Name:    sunlightRange,
+	humidityRange.Name:    humidityRange,
+}
+
+// metricValue returns a reading's value for a metric name
+func metricValue(reading *SensorReadings, metric string) float64 {
+	switch metric {
+	case "fertility":
+		return reading.Fertility
+	case "moisture":
+		return reading.Moisture
+	case "ph":
+		return reading.PH
+	case "temperature":
+		return reading.Temperature
+	case "sunlight":
+		return reading.Sunlight
+	case "humidity":
+		return reading.Humidity
+	}
+	return 0
+}
+
+// check returns an error when value is missing or outside the range
+func (r readingRange) check(value *float64) error {
+	if value == nil {
+		return invalidf("%s is required", r.Name)
+	}
+	if *value < r.Min || *value > r.Max {
+		return invalidf("%s must be between %g and %g", r.Name, r.Min, r.Max)
+	}
+	return nil
+}
+
+// validateReading checks a reading's sensor ID and metric ranges
+func validateReading(req CreateReadingRequest) error {
+	if !sensorIDPattern.MatchString(req.SensorID) {
+		return invalidf("invalid sensorId")
+	}
+	checks := []struct {
+		rng   readingRange
+		value *float64
+	}{
+		{fertilityRange, req.Fertility},
+		{moistureRange, req.Moisture},
+		{phRange, req.PH},
+		{temperatureRange, req.Temperature},
+		{sunlightRange, req.Sunlight},
+		{humidityRange, req.Humidity},
+	}
+	for _, c := range checks {
+		if err := c.rng.check(c.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateReading records a soil sensor reading against one of the caller's farms,
+// creating the farm's Sensor node on its first reading
+func CreateReading(token, farmID string, req CreateReadingRequest) (*SensorReadings, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleManager)
+	if err != nil {
+		return nil, err
+	}
+
+	return ingestReading(farm, username, req)
+}
+
+// ingestReading validates and stores a reading for farm. It is the shared write path
+// for the REST endpoint and the MQTT bridge.
+func ingestReading(farm *FarmList, username string, req CreateReadingRequest) (*SensorReadings, error) {
+	reading, err := newReading(farm, req, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		MERGE (f)-[:HAS_SENSOR]->(s:Sensor {sensorId: $sensorId})
+		SET s.lastSeenAt = CASE WHEN coalesce(s.lastSeenAt, 0) < $seenAt THEN $seenAt ELSE s.lastSeenAt END
+		CREATE (r:Reading {
+			id: $id,
+			sensorId: $sensorId,
+			farmName: $farmName,
+			cropType: $cropType,
+			fertility: $fertility,
+			moisture: $moisture,
+			ph: $ph,
+			temperature: $temperature,
+			sunlight: $sunlight,
+			humidity: $humidity,
+			createdAt: $createdAt,
+			submittedAt: $submittedAt,
+			username: $username
+		})
+		CREATE (s)-[:HAS_READING]->(r)`, readingParams(farm, reading, username))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save sensor reading: %w", err)
+	}
+	// The reading is always created; a second node means the sensor was new
+	if summary != nil && summary.Counters().NodesCreated() > 1 {
+		recordFarmAudit(farm.ID, username, AuditSensorRegistered, map[string]any{"sensorId": reading.SensorID})
+	}
+
+	invalidateReadingCaches(farm)
+	evaluateThresholds(farm, reading)
+
+	return reading, nil
+}
+
+// CreateReadingsBatch records up to maxReadingBatch readings in one write, for gateways
+// that buffer readings while offline. Each reading is validated on its own: invalid
+// ones are reported in the results and the rest are stored. Thresholds are checked
+// against each sensor's most recent reading only, since alerts describe current
+// conditions.
+func CreateReadingsBatch(token, farmID string, reqs []CreateReadingRequest) (*ReadingBatchResult, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(reqs) == 0 {
+		return nil, invalidf("readings must not be empty")
+	}
+	if len(reqs) > maxReadingBatch {
+		return nil, invalidf("a batch holds at most %d readings", maxReadingBatch)
+	}
+
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleManager)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	result := &ReadingBatchResult{Results: make([]ReadingBatchItem, len(reqs))}
+	readings := make([]*SensorReadings, 0, len(reqs))
+	rows := make([]map[string]any, 0, len(reqs))
+	for i, req := range reqs {
+		result.Results[i].Index = i
+		reading, err := newReading(farm, req, now)
+		if err != nil {
+			result.Results[i].Error = err.Error()
+			result.Rejected++
+			continue
+		}
+		result.Results[i].ID = reading.ID
+		readings = append(readings, reading)
+		rows = append(rows, readingParams(farm, reading, username))
+	}
+	if len(readings) == 0 {
+		return result, nil
+	}
+	known, err := farmSensorIDs(farm.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := memgraph.BulkWrite(`MATCH (f:Farm {id: $farmId})
+		MERGE (f)-[:HAS_SENSOR]->(s:Sensor {sensorId: row.sensorId})
+		SET s.lastSeenAt = CASE WHEN coalesce(s.lastSeenAt, 0) < row.seenAt THEN row.seenAt ELSE s.lastSeenAt END
+		CREATE (r:Reading {
+			id: row.id,
+			sensorId: row.sensorId,
+			farmName: row.farmName,
+			cropType: row.cropType,
+			fertility: row.fertility,
+			moisture: row.moisture,
+			ph: row.ph,
+			temperature: row.temperature,
+			sunlight: row.sunlight,
+			humidity: row.humidity,
+			createdAt: row.createdAt,
+			submittedAt: row.submittedAt,
+			username: row.username
+		})
+		CREATE (s)-[:HAS_READING]->(r)`, rows, map[string]any{"farmId": farm.ID}); err != nil {
+		return nil, fmt.Errorf("failed to save sensor readings: %w", err)
+	}
+	result.Accepted = len(readings)
+	for _, reading := range readings {
+		if !known[reading.SensorID] {
+			known[reading.SensorID] = true
+			recordFarmAudit(farm.ID, username, AuditSensorRegistered, map[string]any{"sensorId": reading.SensorID})
+		}
+	}
+
+	invalidateReadingCaches(farm)
+
+	latest := make(map[string]*SensorReadings)
+	for _, reading := range readings {
+		if current, ok := latest[reading.SensorID]; !ok || reading.SubmittedAt.After(current.SubmittedAt) {
+			latest[reading.SensorID] = reading
+		}
+	}
+	for _, reading := range latest {
+		evaluateThresholds(farm, reading)
+	}
+
+	return result, nil
+}
+
+// newReading validates a reading request and builds the reading to store for farm
+func newReading(farm *FarmList, req CreateReadingRequest, now time.Time) (*SensorReadings, error) {
+	if err := validateReading(req); err != nil {
+		return nil, err
+	}
+
+	var err error
+	submittedAt := now
+	if req.SubmittedAt != "" {
+		submittedAt, err = time.Parse(time.RFC3339, req.SubmittedAt)
+		if err != nil {
+			return nil, invalidf("submittedAt must be RFC3339")
+		}
+		if submittedAt.After(now.Add(5 * time.Minute)) {
+			return nil, invalidf("submittedAt is in the future")
+		}
+		submittedAt = submittedAt.UTC()
+	}
+
+	return &SensorReadings{
+		Fertility:            *req.Fertility,
+		Moisture:             *req.Moisture,
+		PH:                   *req.PH,
+		Temperature:          *req.Temperature,
+		Sunlight:             *req.Sunlight,
+		Humidity:             *req.Humidity,
+		FarmName:             farm.FarmName,
+		CropType:             farm.CropType,
+		SensorID:             req.SensorID,
+		ID:                   uuid.NewString(),
+		CreatedAt:            now,
+		SubmittedAt:          submittedAt,
+		FormattedCreatedAt:   utils.FormatDateTime(now, utils.DefaultLocale),
+		FormattedSubmittedAt: utils.FormatDateTime(submittedAt, utils.DefaultLocale),
+	}, nil
+}
+
+// readingParams returns the Cypher parameters that store reading
+func readingParams(farm *FarmList, reading *SensorReadings, username string) map[string]any {
+	return map[string]any{
+		"farmId":      farm.ID,
+		"sensorId":    reading.SensorID,
+		"id":          reading.ID,
+		"farmName":    reading.FarmName,
+		"cropType":    reading.CropType,
+		"fertility":   reading.Fertility,
+		"moisture":    reading.Moisture,
+		"ph":          reading.PH,
+		"temperature": reading.Temperature,
+		"sunlight":    reading.Sunlight,
+		"humidity":    reading.Humidity,
+		"createdAt":   reading.CreatedAt.Format(time.RFC3339),
+		"submittedAt": reading.SubmittedAt.Format(time.RFC3339),
+		"username":    username,
+		"seenAt":      reading.SubmittedAt.UnixMilli(), // Sensor heartbeat
+	}
+}
+
+// farmSensorIDs returns the IDs of the sensors registered on a farm
+func farmSensorIDs(farmID string) (map[string]bool, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[:HAS_SENSOR]->(s:Sensor)
+		RETURN s.sensorId AS sensorId`, map[string]any{"farmId": farmID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sensors: %w", err)
+	}
+	ids := make(map[string]bool, len(records))
+	for _, record := range records {
+		ids[memgraph.String(record, "sensorId")] = true
+	}
+	return ids, nil
+}
+
+// invalidateReadingCaches drops the cached views that new readings of farm make stale,
+// including the health score portfolio yield projections take from recent readings
+func invalidateReadingCaches(farm *FarmList) {
+	InvalidateFarmScansCache(farm.FarmName)
+}
@@ -0,0 +1,280 @@
+package farmservices
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	marketplaceservices "decentragri-app-cx-server/marketplace.services"
+	portfolioservices "decentragri-app-cx-server/portfolio.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+)
+
+const (
+	// defaultSimilarFarms is how many similar farms or plot suggestions are returned by default
+	defaultSimilarFarms = 10
+	// maxSimilarFarms caps similar farm and plot suggestion responses
+	maxSimilarFarms = 50
+	// minFarmSimilarity drops candidates that share too little to be worth suggesting
+	minFarmSimilarity = 0.25
+	// cropSimilarityWeight is the share of the similarity decided by crop type; soil decides the rest
+	cropSimilarityWeight = 0.5
+)
+
+// soilTolerances is the difference in each averaged soil metric at which two farms stop
+// counting as alike on it
+var soilTolerances = struct{ PH, Moisture, Fertility float64 }{PH: 1, Moisture: 15, Fertility: 500}
+
+// farmProfile is a farm's crop and soil profile, with the plots that tokenize it
+type farmProfile struct {
+	SimilarFarm
+	TokenIDs []string
+}
+
+// GetSimilarFarms returns the farms whose crop type and average soil readings most
+// resemble a farm's, most similar first. Viewer or above.
+func GetSimilarFarms(token, farmID string, limit int) ([]SimilarFarm, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+	limit = similarFarmsLimit(limit)
+
+	cacheKey := fmt.Sprintf("similar_farms:%s", farmID)
+	var cached []SimilarFarm
+	if err := cache.Get(cacheKey, &cached); err != nil {
+		sources, err := loadFarmProfiles(`MATCH (f:Farm {id: $farmId})`, map[string]any{"farmId": farmID})
+		if err != nil {
+			return nil, err
+		}
+		if len(sources) == 0 {
+			return nil, ErrFarmNotFound
+		}
+		candidates, err := loadFarmProfiles(`MATCH (f:Farm) WHERE f.id <> $farmId`, map[string]any{"farmId": farmID})
+		if err != nil {
+			return nil, err
+		}
+
+		cached = make([]SimilarFarm, 0)
+		for _, candidate := range candidates {
+			if similarity, reasons := farmSimilarity(&sources[0], &candidate); similarity >= minFarmSimilarity {
+				farm := candidate.SimilarFarm
+				farm.Similarity, farm.Reasons = similarity, reasons
+				cached = append(cached, farm)
+			}
+		}
+		sort.SliceStable(cached, func(i, j int) bool { return cached[i].Similarity > cached[j].Similarity })
+		if len(cached) > maxSimilarFarms {
+			cached = cached[:maxSimilarFarms]
+		}
+		cache.SetWithTags(cacheKey, cached, config.CacheTTL(config.TTLRecommendations), cache.FarmTag(sources[0].FarmName))
+	}
+
+	if len(cached) > limit {
+		cached = cached[:limit]
+	}
+	return cached, nil
+}
+
+// GetPortfolioSuggestions suggests listed plots whose farms resemble the farms behind
+// the caller's plots, most similar first. Owned plots must be linked to a farm to seed
+// suggestions; without any the list is empty.
+func GetPortfolioSuggestions(token, currency, imageSize string, limit int) ([]PlotSuggestion, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	imageSize, err = utils.NormalizeImageSize(imageSize)
+	if err != nil {
+		return nil, invalidf("%v", err)
+	}
+	limit = similarFarmsLimit(limit)
+
+	tokenIDs, err := portfolioservices.OwnedPlotTokenIDs(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch owned plots: %w", err)
+	}
+	suggestions := make([]PlotSuggestion, 0)
+	if len(tokenIDs) == 0 {
+		return suggestions, nil
+	}
+	sources, err := loadFarmProfiles(`MATCH (f:Farm)-[:TOKENIZED_AS]->(n:FarmPlotNFT) WHERE n.tokenId IN $tokenIds`,
+		map[string]any{"tokenIds": tokenIDs})
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return suggestions, nil
+	}
+
+	listings, err := marketplaceservices.GetValidFarmPlotListings(token, currency, "")
+	if err != nil {
+		return nil, err
+	}
+	owned := make(map[string]bool, len(tokenIDs))
+	for _, tokenID := range tokenIDs {
+		owned[tokenID] = true
+	}
+	listed := make(map[string][]*marketplaceservices.FarmPlotDirectListingsWithImageByte)
+	listedIDs := make([]string, 0)
+	for i := range *listings {
+		listing := &(*listings)[i]
+		if owned[listing.TokenID] || strings.EqualFold(listing.Seller, username) {
+			continue
+		}
+		if len(listed[listing.TokenID]) == 0 {
+			listedIDs = append(listedIDs, listing.TokenID)
+		}
+		listed[listing.TokenID] = append(listed[listing.TokenID], listing)
+	}
+	if len(listedIDs) == 0 {
+		return suggestions, nil
+	}
+
+	candidates, err := loadFarmProfiles(`MATCH (f:Farm)-[:TOKENIZED_AS]->(n:FarmPlotNFT) WHERE n.tokenId IN $tokenIds`,
+		map[string]any{"tokenIds": listedIDs})
+	if err != nil {
+		return nil, err
+	}
+	sourceIDs := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		sourceIDs[source.ID] = true
+	}
+	for _, candidate := range candidates {
+		if sourceIDs[candidate.ID] {
+			continue
+		}
+		// Each candidate is matched to the caller's farm it most resembles
+		var best *farmProfile
+		var bestSimilarity float64
+		var bestReasons []string
+		for i := range sources {
+			if similarity, reasons := farmSimilarity(&sources[i], &candidate); similarity > bestSimilarity {
+				best, bestSimilarity, bestReasons = &sources[i], similarity, reasons
+			}
+		}
+		if best == nil || bestSimilarity < minFarmSimilarity {
+			continue
+		}
+		farm := candidate.SimilarFarm
+		farm.Similarity, farm.Reasons = bestSimilarity, bestReasons
+		for _, tokenID := range candidate.TokenIDs {
+			for _, listing := range listed[tokenID] {
+				suggestions = append(suggestions, PlotSuggestion{
+					FarmPlotDirectListingsWithImageByte: *listing,
+					Farm:                                farm,
+					SimilarTo:                           best.ID,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Farm.Similarity != suggestions[j].Farm.Similarity {
+			return suggestions[i].Farm.Similarity > suggestions[j].Farm.Similarity
+		}
+		return suggestions[i].PriceUSD < suggestions[j].PriceUSD
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	if imageSize != "" {
+		related := make(marketplaceservices.FarmPlotDirectListingsResponse, len(suggestions))
+		for i := range suggestions {
+			related[i] = suggestions[i].FarmPlotDirectListingsWithImageByte
+		}
+		marketplaceservices.ApplyImageSize(related, imageSize)
+		for i := range suggestions {
+			suggestions[i].FarmPlotDirectListingsWithImageByte = related[i]
+		}
+	}
+	return suggestions, nil
+}
+
+// loadFarmProfiles builds the profiles of the farms bound to f by match, averaging every
+// reading of their sensors
+func loadFarmProfiles(match string, params map[string]any) ([]farmProfile, error) {
+	records, err := memgraph.ExecuteReplicaRead(match+`
+		WITH DISTINCT f
+		WHERE f.deletedAt IS NULL
+		OPTIONAL MATCH (f)-[:HAS_SENSOR]->(:Sensor)-[:HAS_READING]->(r:Reading)
+		WITH f, avg(r.ph) AS ph, avg(r.moisture) AS moisture, avg(r.fertility) AS fertility, count(r) AS readings
+		OPTIONAL MATCH (f)-[:TOKENIZED_AS]->(n:FarmPlotNFT)
+		RETURN f.id AS id, f.farmName AS farmName, f.cropType AS cropType, f.location AS location, f.image AS image,
+			ph, moisture, fertility, readings, collect(n.tokenId) AS tokenIds`, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch farm profiles: %w", err)
+	}
+
+	profiles := make([]farmProfile, 0, len(records))
+	for _, record := range records {
+		profile := farmProfile{SimilarFarm: SimilarFarm{
+			ID:       memgraph.String(record, "id"),
+			FarmName: memgraph.String(record, "farmName"),
+			CropType: memgraph.String(record, "cropType"),
+			Location: memgraph.String(record, "location"),
+			Image:    memgraph.String(record, "image"),
+		}}
+		if readings := memgraph.Int64(record, "readings"); readings > 0 {
+			ph, _ := memgraph.Float64(record, "ph")
+			moisture, _ := memgraph.Float64(record, "moisture")
+			fertility, _ := memgraph.Float64(record, "fertility")
+			profile.Soil = &SoilProfile{
+				PH:        math.Round(ph*100) / 100,
+				Moisture:  math.Round(moisture*10) / 10,
+				Fertility: math.Round(fertility),
+				Readings:  readings,
+			}
+		}
+		if v, ok := record.Get("tokenIds"); ok {
+			tokenIDs, _ := v.([]any)
+			for _, tokenID := range tokenIDs {
+				if id, ok := tokenID.(string); ok {
+					profile.TokenIDs = append(profile.TokenIDs, id)
+				}
+			}
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// farmSimilarity scores how alike two farms are from 0 to 1: half for sharing a crop
+// type, half for how close their average soil readings are within soilTolerances
+func farmSimilarity(a, b *farmProfile) (float64, []string) {
+	similarity := 0.0
+	reasons := make([]string, 0, 2)
+	if a.CropType != "" && strings.EqualFold(strings.TrimSpace(a.CropType), strings.TrimSpace(b.CropType)) {
+		similarity += cropSimilarityWeight
+		reasons = append(reasons, "same_crop")
+	}
+	if a.Soil != nil && b.Soil != nil {
+		closeness := func(x, y, tolerance float64) float64 {
+			return math.Max(0, 1-math.Abs(x-y)/tolerance)
+		}
+		soil := (closeness(a.Soil.PH, b.Soil.PH, soilTolerances.PH) +
+			closeness(a.Soil.Moisture, b.Soil.Moisture, soilTolerances.Moisture) +
+			closeness(a.Soil.Fertility, b.Soil.Fertility, soilTolerances.Fertility)) / 3
+		similarity += (1 - cropSimilarityWeight) * soil
+		if soil >= 0.5 {
+			reasons = append(reasons, "similar_soil")
+		}
+	}
+	return math.Round(similarity*100) / 100, reasons
+}
+
+// similarFarmsLimit applies the default and cap to a similar farms limit
+func similarFarmsLimit(limit int) int {
+	if limit <= 0 {
+		return defaultSimilarFarms
+	}
+	return min(limit, maxSimilarFarms)
+}
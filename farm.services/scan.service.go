@@ -0,0 +1,238 @@
+package farmservices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+
+	"github.com/google/uuid"
+)
+
+// PlantScanInterpretationQueue is the Redis list uploaded plant scans are queued on
+// for AI interpretation
+const PlantScanInterpretationQueue = "plant_scan_interpretation_queue"
+
+// Plant scan interpretation statuses
+const (
+	InterpretationPending   = "pending"
+	InterpretationCompleted = "completed"
+	InterpretationFailed    = "failed"
+)
+
+// ErrScanNotFound is returned for plant scans the farm does not have, or no longer has
+var ErrScanNotFound = errors.New("plant scan not found")
+
+// maxScanNoteLength bounds the free-text note sent with a plant scan
+const maxScanNoteLength = 1000
+
+// cacheFarmScans caches a value derived from a farm's scans or readings under the farm's
+// tag so InvalidateFarmScansCache can drop it
+func cacheFarmScans(farmName, key string, value interface{}, expiration time.Duration) {
+	cache.SetWithTags(key, value, expiration, cache.FarmTag(farmName))
+}
+
+// InvalidateFarmScansCache drops everything cached under the farm's tag: scan pages,
+// series, irrigation plans, and the portfolio's health score, so new data is visible
+// immediately
+func InvalidateFarmScansCache(farmName string) {
+	if err := cache.InvalidateTag(cache.FarmTag(farmName)); err != nil {
+		slog.Warn("failed to invalidate scans cache for farm", "farm", farmName, "error", err)
+	}
+}
+
+// ParseScanFilter validates the scans query parameters. from and to accept a date
+// (YYYY-MM-DD) or an RFC3339 timestamp; a date-only to includes that whole day.
+func ParseScanFilter(scanType, from, to string) (ScanFilter, error) {
+	filter := ScanFilter{}
+
+	switch scanType {
+	case "", ScanTypePlant, ScanTypeSoil:
+		filter.Type = scanType
+	default:
+		return filter, invalidf("type must be plant or soil")
+	}
+
+	if from != "" {
+		t, _, err := parseScanBound(from)
+		if err != nil {
+			return filter, fmt.Errorf("from: %w", err)
+		}
+		filter.From = t.Format(time.RFC3339)
+	}
+	if to != "" {
+		t, dateOnly, err := parseScanBound(to)
+		if err != nil {
+			return filter, fmt.Errorf("to: %w", err)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		} else {
+			t = t.Add(time.Second).Truncate(time.Second)
+		}
+		filter.To = t.Format(time.RFC3339)
+	}
+
+	if filter.From != "" && filter.To != "" && filter.From >= filter.To {
+		return filter, invalidf("from must be before to")
+	}
+	return filter, nil
+}
+
+// parseScanBound parses a date or RFC3339 timestamp as UTC
+func parseScanBound(value string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	if t, err = time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), false, nil
+	}
+	return time.Time{}, false, invalidf("must be YYYY-MM-DD or RFC3339")
+}
+
+// UploadPlantScan stores a plant scan image on IPFS, records the scan against one of
+// the caller's farms, and queues it for AI interpretation
+func UploadPlantScan(ctx context.Context, token, farmID string, req CreatePlantScanRequest, image *FarmImage) (*PlantScanResult, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleManager)
+	if err != nil {
+		return nil, err
+	}
+	if image == nil {
+		return nil, invalidf("image is required")
+	}
+
+	cropType := utils.SanitizeInput(req.CropType)
+	if cropType == "" {
+		cropType = farm.CropType
+	}
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxScanNoteLength {
+		return nil, invalidf("note exceeds %d characters", maxScanNoteLength)
+	}
+
+	imageURI, err := uploadImage(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+
+	scan := &PlantScanResult{
+		ID:                   uuid.NewString(),
+		CropType:             cropType,
+		Note:                 note,
+		ImageURI:             imageURI,
+		InterpretationStatus: InterpretationPending,
+	}
+	now := time.Now().UTC()
+	scan.CreatedAt = now
+	scan.FormattedCreatedAt = utils.FormatDateTime(now, utils.DefaultLocale)
+
+	_, err = memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		CREATE (ps:PlantScan {
+			id: $id,
+			cropType: $cropType,
+			note: $note,
+			imageUri: $imageUri,
+			date: $date,
+			createdAt: $date,
+			username: $username,
+			interpretationStatus: $status,
+			searchText: $searchText
+		})
+		CREATE (f)-[:HAS_PLANT_SCAN]->(ps)`, map[string]any{
+		"farmId":     farmID,
+		"id":         scan.ID,
+		"cropType":   scan.CropType,
+		"note":       scan.Note,
+		"imageUri":   scan.ImageURI,
+		"date":       now.Format(time.RFC3339),
+		"username":   username,
+		"status":     InterpretationPending,
+		"searchText": scanSearchText(scan.Note, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save plant scan: %w", err)
+	}
+
+	InvalidateFarmScansCache(farm.FarmName)
+
+	// The scan stays pending on the node if queuing fails, so it can be picked up later
+	job := PlantScanJob{
+		ScanID:   scan.ID,
+		FarmID:   farmID,
+		FarmName: farm.FarmName,
+		CropType: scan.CropType,
+		Note:     scan.Note,
+		ImageURI: scan.ImageURI,
+		Username: username,
+	}
+	if err := cache.Enqueue(PlantScanInterpretationQueue, job); err != nil {
+		slog.Warn("failed to queue plant scan for interpretation", "scanId", scan.ID, "error", err)
+	}
+
+	return scan, nil
+}
+
+// DeleteFarmScan soft-deletes one of a farm's plant scans, hiding it from scan lists,
+// search, the timeline, and the outbreak map until it is restored or purged. Owner or
+// manager.
+func DeleteFarmScan(token, farmID, scanID string) error {
+	return setScanDeleted(token, farmID, scanID, true)
+}
+
+// RestoreFarmScan brings back a deleted plant scan that has not been purged yet. Owner
+// or manager.
+func RestoreFarmScan(token, farmID, scanID string) error {
+	return setScanDeleted(token, farmID, scanID, false)
+}
+
+// setScanDeleted sets or clears a farm scan's deleted mark, failing with ErrScanNotFound
+// when the scan is missing or already in the requested state
+func setScanDeleted(token, farmID, scanID string, deleted bool) error {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return err
+	}
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleManager)
+	if err != nil {
+		return err
+	}
+
+	query := `MATCH (:Farm {id: $farmId})-[:HAS_PLANT_SCAN]->(ps:PlantScan {id: $id})
+		WHERE ps.deletedAt IS NULL
+		SET ps.deletedAt = $now, ps.deletedBy = $username`
+	action := AuditScanDeleted
+	if !deleted {
+		query = `MATCH (:Farm {id: $farmId})-[:HAS_PLANT_SCAN]->(ps:PlantScan {id: $id})
+		WHERE ps.deletedAt IS NOT NULL
+		SET ps.deletedAt = null, ps.deletedBy = null`
+		action = AuditScanRestored
+	}
+	summary, err := memgraph.ExecuteWrite(query, map[string]any{
+		"farmId":   farmID,
+		"id":       scanID,
+		"now":      time.Now().UnixMilli(),
+		"username": username,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update plant scan: %w", err)
+	}
+	if summary == nil || summary.Counters().PropertiesSet() == 0 {
+		return ErrScanNotFound
+	}
+
+	InvalidateFarmScansCache(farm.FarmName)
+	recordFarmAudit(farmID, username, action, map[string]any{"scanId": scanID})
+	return nil
+}
@@ -0,0 +1,138 @@
+package farmservices
+
+import (
+	"fmt"
+	"sync"
+
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Keysets of the two scan streams. Plant scans sort by their scan time, readings by
+// their creation time; IDs break ties.
+var (
+	plantScanKeyset   = memgraph.Keyset{Name: "plant", Sort: "scannedAt", ID: "COALESCE(ps.id, '')"}
+	soilReadingKeyset = memgraph.Keyset{Name: "soil", Sort: "r.createdAt", ID: "COALESCE(r.id, '')"}
+)
+
+// scanCursor is the position in both streams. A nil position starts a stream; a done
+// stream has no more items and is not queried again.
+type scanCursor struct {
+	Plant     *memgraph.Cursor `json:"p,omitempty"`
+	Soil      *memgraph.Cursor `json:"s,omitempty"`
+	PlantDone bool             `json:"pd,omitempty"`
+	SoilDone  bool             `json:"sd,omitempty"`
+}
+
+// GetFarmScansAfter returns up to limit plant scans and limit soil readings after the
+// position in cursor ("" for the first page), newest first, with the cursor of the next
+// page. Unlike page numbers, cursors stay cheap on deep pages and do not repeat or skip
+// items when scans arrive between requests. No totals are counted. The caller needs at
+// least viewer access to the farm.
+func GetFarmScansAfter(token, farmName, cursor string, limit int, filter ScanFilter) (*FarmScanResult, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarmByName(farmName, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+
+	var position scanCursor
+	if cursor != "" {
+		if err := memgraph.DecodeCursor(cursor, &position); err != nil {
+			return nil, err
+		}
+	}
+	includePlant := (filter.Type == "" || filter.Type == ScanTypePlant) && !position.PlantDone
+	includeSoil := (filter.Type == "" || filter.Type == ScanTypeSoil) && !position.SoilDone
+
+	params := map[string]interface{}{"farmName": farmName, "from": filter.From, "to": filter.To}
+	plantScanKeyset.Params(params, position.Plant, limit)
+	soilReadingKeyset.Params(params, position.Soil, limit)
+
+	plantScansCypher := `
+		MATCH (f:Farm {farmName: $farmName})-[:HAS_PLANT_SCAN]->(ps:PlantScan)
+		WHERE f.deletedAt IS NULL AND ps.deletedAt IS NULL
+		WITH ps, COALESCE(ps.date, ps.createdAt, ps.created_at, ps.timestamp, '1970-01-01T00:00:00Z') AS scannedAt
+		WHERE ($from = '' OR scannedAt >= $from) AND ($to = '' OR scannedAt < $to)
+			AND ` + plantScanKeyset.Where() + `
+		WITH ps, scannedAt ` + plantScanKeyset.OrderBy() + `
+		LIMIT ` + plantScanKeyset.Limit() + `
+		RETURN ps.cropType as cropType,
+			   ps.note as note,
+			   ps.date as date,
+			   ps.createdAt as createdAt,
+			   ps.id as id,
+			   ps.interpretation as interpretation,
+			   ps.imageUri as imageUri,
+			   ps.interpretationStatus as interpretationStatus,
+			   ` + plantScanKeyset.Return() + `
+		` + plantScanKeyset.ReturnOrderBy()
+
+	soilReadingsCypher := `
+		MATCH (f:Farm {farmName: $farmName})-[:HAS_SENSOR]->(s:Sensor)-[:HAS_READING]->(r:Reading)
+		WHERE f.deletedAt IS NULL AND ($from = '' OR r.createdAt >= $from) AND ($to = '' OR r.createdAt < $to)
+			AND ` + soilReadingKeyset.Where() + `
+		WITH r ` + soilReadingKeyset.OrderBy() + `
+		LIMIT ` + soilReadingKeyset.Limit() + `
+		OPTIONAL MATCH (r)-[:INTERPRETED_AS]->(i:Interpretation)
+		RETURN r.fertility as fertility,
+			   r.moisture as moisture,
+			   r.ph as ph,
+			   r.temperature as temperature,
+			   r.sunlight as sunlight,
+			   r.humidity as humidity,
+			   r.farmName as farmName,
+			   r.cropType as cropType,
+			   r.sensorId as sensorId,
+			   r.id as id,
+			   r.createdAt as createdAt,
+			   r.submittedAt as submittedAt,
+			   i.value as interpretation,
+			   ` + soilReadingKeyset.Return() + `
+		` + soilReadingKeyset.ReturnOrderBy()
+
+	var wg sync.WaitGroup
+	var plantRecords, soilRecords []*neo4j.Record
+	var plantErr, soilErr error
+	if includePlant {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			plantRecords, plantErr = memgraph.ExecuteReplicaRead(plantScansCypher, params)
+		}()
+	}
+	if includeSoil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			soilRecords, soilErr = memgraph.ExecuteReplicaRead(soilReadingsCypher, params)
+		}()
+	}
+	wg.Wait()
+	if plantErr != nil {
+		return nil, fmt.Errorf("failed to fetch plant scans: %w", plantErr)
+	}
+	if soilErr != nil {
+		return nil, fmt.Errorf("failed to fetch soil readings: %w", soilErr)
+	}
+
+	next := scanCursor{PlantDone: !includePlant, SoilDone: !includeSoil}
+	plantRecords, next.Plant = plantScanKeyset.Page(plantRecords, limit)
+	soilRecords, next.Soil = soilReadingKeyset.Page(soilRecords, limit)
+	next.PlantDone = next.PlantDone || next.Plant == nil
+	next.SoilDone = next.SoilDone || next.Soil == nil
+
+	plantScans := mapPlantScans(plantRecords)
+	soilReadings := mapSoilReadings(soilRecords)
+	tagGrowthStages(farmName, plantScans, soilReadings)
+
+	result := &FarmScanResult{PlantScans: plantScans, SoilReadings: soilReadings}
+	if !next.PlantDone || !next.SoilDone {
+		result.NextCursor = memgraph.EncodeCursor(next)
+	}
+	return result, nil
+}
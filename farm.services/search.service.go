@@ -0,0 +1,181 @@
+package farmservices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+)
+
+const (
+	// plantScanSearchIndex is the Memgraph text index over plant scans
+	plantScanSearchIndex = "plant_scan_search"
+	// maxScanSearchResults bounds the hits one search returns
+	maxScanSearchResults = 50
+	// maxScanSearchTerms bounds the terms taken from one query
+	maxScanSearchTerms = 8
+	// scanSearchBackfillBatch is how many scans PrepareScanSearch indexes per write
+	scanSearchBackfillBatch = 500
+)
+
+// scanSearchReturn projects a plant scan matched as ps, after scannedAt is computed
+const scanSearchReturn = `RETURN ps.id AS id, ps.cropType AS cropType, ps.note AS note, scannedAt,
+	ps.interpretation AS interpretation, ps.imageUri AS imageUri, ps.interpretationStatus AS interpretationStatus`
+
+// PrepareScanSearch creates the plant scan text index and fills searchText on scans
+// recorded before search existed. Text indexes need Memgraph started with
+// --experimental-enabled=text-search; without it searches fall back to a substring scan.
+func PrepareScanSearch() {
+	if err := memgraph.ExecuteSchema(fmt.Sprintf("CREATE TEXT INDEX %s ON :PlantScan", plantScanSearchIndex)); err != nil {
+		slog.Info("plant scan text index not created; it may already exist or text search is disabled", "error", err)
+	}
+
+	indexed := 0
+	for {
+		records, err := memgraph.ExecuteRead(`MATCH (ps:PlantScan) WHERE ps.searchText IS NULL
+			RETURN ps.id AS id, ps.note AS note, ps.interpretation AS interpretation LIMIT $limit`,
+			map[string]any{"limit": scanSearchBackfillBatch})
+		if err != nil {
+			slog.Warn("failed to read plant scans for search backfill", "error", err)
+			return
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		rows := make([]map[string]any, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, map[string]any{
+				"id":         memgraph.String(record, "id"),
+				"searchText": scanSearchText(memgraph.String(record, "note"), parsePlantScanInterpretation(record, "interpretation")),
+			})
+		}
+		summary, err := memgraph.ExecuteWrite(`UNWIND $rows AS row
+			MATCH (ps:PlantScan {id: row.id}) SET ps.searchText = row.searchText`, map[string]any{"rows": rows})
+		if err != nil {
+			slog.Warn("failed to backfill plant scan search text", "error", err)
+			return
+		}
+		if summary == nil || summary.Counters().PropertiesSet() == 0 {
+			break
+		}
+		indexed += len(rows)
+	}
+	if indexed > 0 {
+		slog.Info("indexed existing plant scans for search", "indexed", indexed)
+	}
+}
+
+// SearchFarmScans finds a farm's plant scans whose note, diagnosis, reason, or
+// recommendations contain every term of q, newest first. The search stops when ctx ends.
+func SearchFarmScans(ctx context.Context, token, farmID, q string, limit int) ([]PlantScanResult, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		return nil, invalidf("q must contain a search term")
+	}
+	if limit <= 0 || limit > maxScanSearchResults {
+		limit = maxScanSearchResults
+	}
+
+	clauses := make([]string, len(terms))
+	for i, term := range terms {
+		clauses[i] = "data.searchText:" + term
+	}
+	params := map[string]any{
+		"index":  plantScanSearchIndex,
+		"query":  strings.Join(clauses, " AND "),
+		"terms":  terms,
+		"farmId": farmID,
+		"limit":  limit,
+	}
+
+	records, err := memgraph.ExecuteReplicaReadContext(ctx, `CALL text_search.search($index, $query) YIELD node
+		WITH node AS ps
+		MATCH (:Farm {id: $farmId})-[:HAS_PLANT_SCAN]->(ps)
+		WHERE ps.deletedAt IS NULL
+		WITH ps, COALESCE(ps.date, ps.createdAt) AS scannedAt
+		ORDER BY scannedAt DESC LIMIT $limit
+		`+scanSearchReturn, params)
+	if err != nil && ctx.Err() == nil && !errors.Is(err, memgraph.ErrQueryTimeout) {
+		slog.Info("text search unavailable for plant scans, scanning instead", "error", err)
+		records, err = memgraph.ExecuteReplicaReadContext(ctx, `MATCH (:Farm {id: $farmId})-[:HAS_PLANT_SCAN]->(ps:PlantScan)
+			WHERE ps.deletedAt IS NULL AND all(term IN $terms WHERE toLower(COALESCE(ps.searchText, ps.note, '')) CONTAINS term)
+			WITH ps, COALESCE(ps.date, ps.createdAt) AS scannedAt
+			ORDER BY scannedAt DESC LIMIT $limit
+			`+scanSearchReturn, params)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search plant scans: %w", err)
+	}
+
+	scans := make([]PlantScanResult, 0, len(records))
+	for _, record := range records {
+		scannedAt, _ := record.Get("scannedAt")
+		createdAt := parseDate(scannedAt)
+		scans = append(scans, PlantScanResult{
+			ID:                   memgraph.String(record, "id"),
+			CropType:             memgraph.String(record, "cropType"),
+			Note:                 memgraph.String(record, "note"),
+			CreatedAt:            createdAt,
+			FormattedCreatedAt:   utils.FormatDateTime(createdAt, utils.DefaultLocale),
+			Interpretation:       parsePlantScanInterpretation(record, "interpretation"),
+			ImageURI:             memgraph.String(record, "imageUri"),
+			InterpretationStatus: memgraph.String(record, "interpretationStatus"),
+		})
+	}
+	return scans, nil
+}
+
+// scanSearchText flattens a scan's note and interpretation into the lowercase text
+// stored on the scan for search
+func scanSearchText(note string, interpretation any) string {
+	parts := []string{note}
+	switch i := interpretation.(type) {
+	case ParsedInterpretation:
+		parts = append(parts, i.Diagnosis, i.Reason)
+		parts = append(parts, i.Recommendations...)
+	case *ParsedInterpretation:
+		if i != nil {
+			parts = append(parts, i.Diagnosis, i.Reason)
+			parts = append(parts, i.Recommendations...)
+		}
+	case string:
+		parts = append(parts, i)
+	}
+	return strings.ToLower(strings.Join(strings.Fields(strings.Join(parts, " ")), " "))
+}
+
+// searchTerms splits a query into distinct lowercase words of letters and digits,
+// which keeps text index query syntax out of user input
+func searchTerms(q string) []string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]bool, len(words))
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if len(word) < 2 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == maxScanSearchTerms {
+			break
+		}
+	}
+	return terms
+}
@@ -0,0 +1,371 @@
+package farmservices
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+
+	"github.com/google/uuid"
+)
+
+// defaultGrowthStages is the stage sequence for seasons created without expected stages
+var defaultGrowthStages = []string{"planting", "germination", "vegetative", "flowering", "fruiting", "maturity", "harvest"}
+
+// Crop season errors
+var (
+	ErrSeasonNotFound         = errors.New("crop season not found")
+	ErrActiveSeasonExists     = errors.New("farm already has an active crop season")
+	ErrInvalidStageTransition = errors.New("stage transition is not allowed")
+)
+
+// CreateSeason starts a crop season on one of the caller's farms. A farm has at most
+// one active season; the season starts in its first stage on the planting date.
+func CreateSeason(token, farmID string, req CreateSeasonRequest) (*CropSeason, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleManager)
+	if err != nil {
+		return nil, err
+	}
+
+	plantingDate, err := time.Parse("2006-01-02", req.PlantingDate)
+	if err != nil {
+		return nil, invalidf("plantingDate must be YYYY-MM-DD")
+	}
+	cropType := utils.SanitizeInput(req.CropType)
+	if cropType == "" {
+		cropType = farm.CropType
+	}
+	stages, err := normalizeExpectedStages(req.ExpectedStages, plantingDate)
+	if err != nil {
+		return nil, err
+	}
+
+	seasons, err := loadSeasons(farmID)
+	if err != nil {
+		return nil, err
+	}
+	for _, season := range seasons {
+		if season.Status == SeasonStatusActive {
+			return nil, ErrActiveSeasonExists
+		}
+	}
+
+	encodedStages, err := json.Marshal(stages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode stages: %w", err)
+	}
+
+	season := &CropSeason{
+		ID:             uuid.NewString(),
+		FarmID:         farmID,
+		CropType:       cropType,
+		Variety:        utils.SanitizeInput(req.Variety),
+		PlantingDate:   req.PlantingDate,
+		ExpectedStages: stages,
+		CurrentStage:   stages[0].Stage,
+		Status:         SeasonStatusActive,
+		Transitions:    []StageTransition{},
+		CreatedAt:      time.Now().UnixMilli(),
+	}
+	_, err = memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		CREATE (f)-[:HAS_SEASON]->(:CropSeason {
+			id: $id,
+			farmId: $farmId,
+			cropType: $cropType,
+			variety: $variety,
+			plantingDate: $plantingDate,
+			expectedStages: $expectedStages,
+			currentStage: $currentStage,
+			status: $status,
+			createdAt: $createdAt
+		})`, map[string]any{
+		"farmId":         farmID,
+		"id":             season.ID,
+		"cropType":       season.CropType,
+		"variety":        season.Variety,
+		"plantingDate":   season.PlantingDate,
+		"expectedStages": string(encodedStages),
+		"currentStage":   season.CurrentStage,
+		"status":         season.Status,
+		"createdAt":      season.CreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create crop season: %w", err)
+	}
+
+	InvalidateFarmScansCache(farm.FarmName)
+
+	return season, nil
+}
+
+// normalizeExpectedStages validates requested stages, or builds the default sequence
+func normalizeExpectedStages(stages []ExpectedStage, plantingDate time.Time) ([]ExpectedStage, error) {
+	if len(stages) == 0 {
+		stages = make([]ExpectedStage, 0, len(defaultGrowthStages))
+		for _, stage := range defaultGrowthStages {
+			stages = append(stages, ExpectedStage{Stage: stage})
+		}
+		stages[0].ExpectedDate = plantingDate.Format("2006-01-02")
+		return stages, nil
+	}
+	if len(stages) < 2 {
+		return nil, invalidf("expectedStages needs at least two stages")
+	}
+
+	seen := make(map[string]bool)
+	previous := plantingDate
+	for i := range stages {
+		stages[i].Stage = strings.ToLower(utils.SanitizeInput(stages[i].Stage))
+		if stages[i].Stage == "" {
+			return nil, invalidf("stage names must not be empty")
+		}
+		if seen[stages[i].Stage] {
+			return nil, invalidf("duplicate stage %q", stages[i].Stage)
+		}
+		seen[stages[i].Stage] = true
+
+		if stages[i].ExpectedDate != "" {
+			date, err := time.Parse("2006-01-02", stages[i].ExpectedDate)
+			if err != nil {
+				return nil, invalidf("expectedDate of %s must be YYYY-MM-DD", stages[i].Stage)
+			}
+			if date.Before(previous) {
+				return nil, invalidf("expected dates must not go backwards (%s)", stages[i].Stage)
+			}
+			previous = date
+		}
+	}
+	return stages, nil
+}
+
+// GetSeasons returns a farm's crop seasons, most recent planting first
+func GetSeasons(token, farmID string) ([]CropSeason, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+	return loadSeasons(farmID)
+}
+
+// LogStageTransition moves a season of one of the caller's farms into a later stage.
+// Stages only move forward; reaching the final stage completes the season.
+func LogStageTransition(token, farmID, seasonID string, req StageTransitionRequest) (*CropSeason, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleManager)
+	if err != nil {
+		return nil, err
+	}
+
+	seasons, err := loadSeasons(farmID)
+	if err != nil {
+		return nil, err
+	}
+	var season *CropSeason
+	for i := range seasons {
+		if seasons[i].ID == seasonID {
+			season = &seasons[i]
+		}
+	}
+	if season == nil {
+		return nil, ErrSeasonNotFound
+	}
+	if season.Status != SeasonStatusActive {
+		return nil, fmt.Errorf("%w: season is completed", ErrInvalidStageTransition)
+	}
+
+	stage := strings.ToLower(strings.TrimSpace(req.Stage))
+	target, current := -1, -1
+	for i, expected := range season.ExpectedStages {
+		if expected.Stage == stage {
+			target = i
+		}
+		if expected.Stage == season.CurrentStage {
+			current = i
+		}
+	}
+	if target < 0 {
+		return nil, fmt.Errorf("%w: unknown stage %q", ErrInvalidStageTransition, req.Stage)
+	}
+	if target <= current {
+		return nil, fmt.Errorf("%w: season is already at %s", ErrInvalidStageTransition, season.CurrentStage)
+	}
+
+	date := time.Now().UTC().Format("2006-01-02")
+	if req.Date != "" {
+		if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+			return nil, invalidf("date must be YYYY-MM-DD")
+		}
+		date = req.Date
+	}
+	earliest := season.PlantingDate
+	if n := len(season.Transitions); n > 0 {
+		earliest = season.Transitions[n-1].Date
+	}
+	if date < earliest {
+		return nil, fmt.Errorf("%w: date is before %s", ErrInvalidStageTransition, earliest)
+	}
+	if date > time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02") {
+		return nil, fmt.Errorf("%w: date is in the future", ErrInvalidStageTransition)
+	}
+
+	transition := StageTransition{
+		ID:        uuid.NewString(),
+		Stage:     stage,
+		Date:      date,
+		Note:      strings.TrimSpace(req.Note),
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	status := SeasonStatusActive
+	if target == len(season.ExpectedStages)-1 {
+		status = SeasonStatusCompleted
+	}
+
+	_, err = memgraph.ExecuteWrite(`MATCH (:Farm {id: $farmId})-[:HAS_SEASON]->(s:CropSeason {id: $seasonId})
+		CREATE (s)-[:HAS_STAGE]->(:StageTransition {id: $id, stage: $stage, date: $date, note: $note, createdAt: $createdAt})
+		SET s.currentStage = $stage, s.status = $status,
+			s.completedAt = CASE WHEN $status = 'completed' THEN $createdAt ELSE s.completedAt END`, map[string]any{
+		"farmId":    farmID,
+		"seasonId":  seasonID,
+		"id":        transition.ID,
+		"stage":     transition.Stage,
+		"date":      transition.Date,
+		"note":      transition.Note,
+		"createdAt": transition.CreatedAt,
+		"status":    status,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to log stage transition: %w", err)
+	}
+
+	InvalidateFarmScansCache(farm.FarmName)
+
+	season.Transitions = append(season.Transitions, transition)
+	season.CurrentStage = stage
+	season.Status = status
+	if status == SeasonStatusCompleted {
+		season.CompletedAt = transition.CreatedAt
+	}
+	return season, nil
+}
+
+// loadSeasons reads a farm's seasons with their stage transitions
+func loadSeasons(farmID string) ([]CropSeason, error) {
+	return querySeasons(`MATCH (:Farm {id: $value})-[:HAS_SEASON]->(s:CropSeason)`, farmID)
+}
+
+// loadSeasonsByFarmName reads a farm's seasons by farm name, for the scans queries
+func loadSeasonsByFarmName(farmName string) ([]CropSeason, error) {
+	return querySeasons(`MATCH (:Farm {farmName: $value})-[:HAS_SEASON]->(s:CropSeason)`, farmName)
+}
+
+// querySeasons runs a season match and collects each season's transitions in date order
+func querySeasons(match, value string) ([]CropSeason, error) {
+	records, err := memgraph.ExecuteRead(match+`
+		OPTIONAL MATCH (s)-[:HAS_STAGE]->(t:StageTransition)
+		WITH s, t ORDER BY t.date, t.createdAt
+		WITH s, collect(CASE WHEN t IS NULL THEN null
+			ELSE {id: t.id, stage: t.stage, date: t.date, note: t.note, createdAt: t.createdAt} END) AS transitions
+		RETURN s.id AS id, s.farmId AS farmId, s.cropType AS cropType, s.variety AS variety,
+			s.plantingDate AS plantingDate, s.expectedStages AS expectedStages,
+			s.currentStage AS currentStage, s.status AS status, s.createdAt AS createdAt,
+			s.completedAt AS completedAt, transitions
+		ORDER BY s.plantingDate DESC`, map[string]any{"value": value})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crop seasons: %w", err)
+	}
+
+	seasons := make([]CropSeason, 0, len(records))
+	for _, record := range records {
+		season := CropSeason{
+			ID:             memgraph.String(record, "id"),
+			FarmID:         memgraph.String(record, "farmId"),
+			CropType:       memgraph.String(record, "cropType"),
+			Variety:        memgraph.String(record, "variety"),
+			PlantingDate:   memgraph.String(record, "plantingDate"),
+			CurrentStage:   memgraph.String(record, "currentStage"),
+			Status:         memgraph.String(record, "status"),
+			CreatedAt:      memgraph.Int64(record, "createdAt"),
+			CompletedAt:    memgraph.Int64(record, "completedAt"),
+			ExpectedStages: []ExpectedStage{},
+			Transitions:    []StageTransition{},
+		}
+		if raw := memgraph.String(record, "expectedStages"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &season.ExpectedStages); err != nil {
+				slog.Warn("invalid expected stages on season", "seasonId", season.ID, "error", err)
+			}
+		}
+		if v, ok := record.Get("transitions"); ok && v != nil {
+			items, _ := v.([]any)
+			for _, item := range items {
+				m, ok := item.(map[string]any)
+				if !ok || m["id"] == nil {
+					continue
+				}
+				transition := StageTransition{}
+				transition.ID, _ = m["id"].(string)
+				transition.Stage, _ = m["stage"].(string)
+				transition.Date, _ = m["date"].(string)
+				transition.Note, _ = m["note"].(string)
+				transition.CreatedAt, _ = m["createdAt"].(int64)
+				season.Transitions = append(season.Transitions, transition)
+			}
+		}
+		seasons = append(seasons, season)
+	}
+	return seasons, nil
+}
+
+// growthStageAt returns the stage a farm was in at t: the latest transition on or before
+// that day in the season planted most recently before it. Days after a completed season
+// ended, or before any planting, have no stage.
+func growthStageAt(seasons []CropSeason, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	day := t.UTC().Format("2006-01-02")
+
+	candidates := make([]CropSeason, 0, len(seasons))
+	for _, season := range seasons {
+		if season.PlantingDate != "" && season.PlantingDate <= day {
+			candidates = append(candidates, season)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].PlantingDate > candidates[j].PlantingDate })
+	season := candidates[0]
+
+	stage := ""
+	if len(season.ExpectedStages) > 0 {
+		stage = season.ExpectedStages[0].Stage
+	}
+	for _, transition := range season.Transitions {
+		if transition.Date > day {
+			break
+		}
+		stage = transition.Stage
+	}
+	if season.Status == SeasonStatusCompleted && len(season.Transitions) > 0 &&
+		day > season.Transitions[len(season.Transitions)-1].Date {
+		return ""
+	}
+	return stage
+}
@@ -0,0 +1,187 @@
+package farmservices
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+)
+
+// Sensor statuses reported in the sensor list
+const (
+	SensorOnline  = "online"
+	SensorOffline = "offline"
+	SensorUnknown = "unknown" // No reading since heartbeats were tracked
+)
+
+// GetFarmSensors lists a farm's sensors with when each last reported and whether it has
+// gone silent for longer than the offline window
+func GetFarmSensors(token, farmID string) ([]FarmSensor, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+
+	records, err := memgraph.ExecuteRead(`MATCH (:Farm {id: $farmId})-[:HAS_SENSOR]->(s:Sensor)
+		OPTIONAL MATCH (s)-[:HAS_READING]->(r:Reading)
+		WITH s, count(r) AS readings
+		RETURN s.sensorId AS sensorId, s.lastSeenAt AS lastSeenAt, readings
+		ORDER BY sensorId`, map[string]any{"farmId": farmID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sensors: %w", err)
+	}
+
+	cutoff := time.Now().Add(-sensorOfflineAfter()).UnixMilli()
+	sensors := make([]FarmSensor, 0, len(records))
+	for _, record := range records {
+		sensor := FarmSensor{
+			SensorID:   memgraph.String(record, "sensorId"),
+			LastSeenAt: memgraph.Int64(record, "lastSeenAt"),
+			Readings:   memgraph.Int64(record, "readings"),
+			Status:     SensorUnknown,
+		}
+		switch {
+		case sensor.LastSeenAt == 0:
+		case sensor.LastSeenAt < cutoff:
+			sensor.Status = SensorOffline
+		default:
+			sensor.Status = SensorOnline
+		}
+		sensors = append(sensors, sensor)
+	}
+	return sensors, nil
+}
+
+// CheckSensorHeartbeats notifies the owner and managers of a farm when one of its sensors
+// has sent nothing for the offline window, once per silence: a new reading re-arms it
+func CheckSensorHeartbeats() {
+	window := sensorOfflineAfter()
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:HAS_SENSOR]->(s:Sensor)
+		WHERE f.deletedAt IS NULL AND s.lastSeenAt < $cutoff AND (s.offlineNotifiedAt IS NULL OR s.offlineNotifiedAt < s.lastSeenAt)
+		RETURN f.id AS farmId, id(s) AS sensor, s.sensorId AS sensorId, s.lastSeenAt AS lastSeenAt`,
+		map[string]any{"cutoff": time.Now().Add(-window).UnixMilli()})
+	if err != nil {
+		slog.Warn("failed to load silent sensors", "error", err)
+		return
+	}
+
+	for _, record := range records {
+		// Claim the notice so overlapping checks send it once
+		summary, err := memgraph.ExecuteWrite(`MATCH (s:Sensor) WHERE id(s) = $sensor
+			AND (s.offlineNotifiedAt IS NULL OR s.offlineNotifiedAt < s.lastSeenAt)
+			SET s.offlineNotifiedAt = $now`, map[string]any{"sensor": memgraph.Int64(record, "sensor"), "now": time.Now().UnixMilli()})
+		if err != nil {
+			slog.Warn("failed to record offline notice for sensor", "sensorId", memgraph.String(record, "sensorId"), "error", err)
+			continue
+		}
+		if summary == nil || summary.Counters().PropertiesSet() == 0 {
+			continue
+		}
+
+		farm, err := getFarm(memgraph.String(record, "farmId"))
+		if err != nil {
+			continue
+		}
+		sensorID := memgraph.String(record, "sensorId")
+		lastSeen := time.UnixMilli(memgraph.Int64(record, "lastSeenAt")).UTC()
+		recipients, err := farmManagers(farm)
+		if err != nil {
+			slog.Warn("failed to load managers of farm", "farm", farm.FarmName, "error", err)
+			recipients = []string{farm.Owner}
+		}
+		settings, err := loadAlertSettings(farm.ID)
+		if err != nil {
+			settings = &FarmAlertSettings{Channels: defaultAlertChannels}
+		}
+		title := fmt.Sprintf("Sensor %s on %s is offline", sensorID, farm.FarmName)
+		message := fmt.Sprintf("No readings since %s. Check its power and connection.", lastSeen.Format(time.RFC1123))
+		data := map[string]any{"farmId": farm.ID, "sensorId": sensorID, "lastSeenAt": lastSeen.UnixMilli()}
+		for _, recipient := range recipients {
+			if err := notificationServices.NotifyVia(settings.Channels, recipient, notificationServices.TypeSensorOffline, title, message, data); err != nil {
+				slog.Warn("failed to send offline notice for sensor", "sensorId", sensorID, "error", err)
+			}
+		}
+	}
+}
+
+// StartSensorHeartbeatMonitor checks for silent sensors on a fixed interval. The interval
+// defaults to 10 minutes and can be overridden with SENSOR_HEARTBEAT_INTERVAL; sensors
+// count as offline after SENSOR_OFFLINE_AFTER (default 6h). Sensors created before
+// heartbeats were tracked get their last-seen time from their latest reading first.
+// It blocks, so run it in a goroutine.
+func StartSensorHeartbeatMonitor() {
+	interval := 10 * time.Minute
+	if v := os.Getenv("SENSOR_HEARTBEAT_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("sensor heartbeat monitor started", "interval", interval, "offlineAfter", sensorOfflineAfter())
+
+	backfillSensorLastSeen()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		CheckSensorHeartbeats()
+	}
+}
+
+// sensorOfflineAfter reads SENSOR_OFFLINE_AFTER, the silence after which a sensor is
+// offline, defaulting to 6 hours
+func sensorOfflineAfter() time.Duration {
+	if v := os.Getenv("SENSOR_OFFLINE_AFTER"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			return parsed
+		}
+	}
+	return 6 * time.Hour
+}
+
+// backfillSensorLastSeen sets lastSeenAt on sensors that have readings but predate
+// heartbeat tracking
+func backfillSensorLastSeen() {
+	records, err := memgraph.ExecuteRead(`MATCH (s:Sensor)-[:HAS_READING]->(r:Reading)
+		WHERE s.lastSeenAt IS NULL
+		WITH s, max(COALESCE(r.submittedAt, r.createdAt)) AS lastReading
+		RETURN id(s) AS sensor, lastReading`, nil)
+	if err != nil {
+		slog.Warn("failed to load sensors for heartbeat backfill", "error", err)
+		return
+	}
+
+	// Sensors already silent count as notified, so long-dead hardware does not alert
+	cutoff := time.Now().Add(-sensorOfflineAfter())
+	rows := make([]map[string]any, 0, len(records))
+	for _, record := range records {
+		lastReading, _ := record.Get("lastReading")
+		seen := parseDate(lastReading)
+		if seen.IsZero() {
+			continue
+		}
+		row := map[string]any{"sensor": memgraph.Int64(record, "sensor"), "lastSeenAt": seen.UnixMilli(), "notifiedAt": nil}
+		if seen.Before(cutoff) {
+			row["notifiedAt"] = seen.UnixMilli()
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return
+	}
+	if _, err := memgraph.ExecuteWrite(`UNWIND $rows AS row
+		MATCH (s:Sensor) WHERE id(s) = row.sensor AND s.lastSeenAt IS NULL
+		SET s.lastSeenAt = row.lastSeenAt, s.offlineNotifiedAt = row.notifiedAt`, map[string]any{"rows": rows}); err != nil {
+		slog.Warn("failed to backfill sensor heartbeats", "error", err)
+		return
+	}
+	slog.Info("backfilled last-seen time of sensors", "count", len(rows))
+}
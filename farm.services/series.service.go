@@ -0,0 +1,256 @@
+package farmservices
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+)
+
+// Series bucket sizes
+const (
+	SeriesBucketRaw  = "raw"
+	SeriesBucketHour = "hour"
+	SeriesBucketDay  = "day"
+)
+
+const (
+	// defaultSeriesPoints is the per-metric point budget when none is requested
+	defaultSeriesPoints = 500
+	// maxSeriesPoints bounds the requested point budget
+	maxSeriesPoints = 2000
+	// maxSeriesRange bounds how far a series request may span
+	maxSeriesRange = 366 * 24 * time.Hour
+	// maxSeriesReadings bounds how many raw readings one series request scans
+	maxSeriesReadings = 100000
+)
+
+// SeriesPoint is one point of a chart series: a Unix timestamp and value
+type SeriesPoint struct {
+	T int64   `json:"t"`
+	V float64 `json:"v"`
+}
+
+// ReadingSeries is a farm's sensor readings downsampled for charting
+type ReadingSeries struct {
+	FarmID   string                   `json:"farmId"`
+	FarmName string                   `json:"farmName"`
+	From     string                   `json:"from"`
+	To       string                   `json:"to"`
+	Bucket   string                   `json:"bucket"`   // raw, hour, or day
+	Points   int                      `json:"points"`   // Maximum points per metric
+	RawCount int                      `json:"rawCount"` // Readings in the range before downsampling
+	Series   map[string][]SeriesPoint `json:"series"`   // Keyed by metric
+}
+
+// GetReadingSeries returns a farm's readings between from and to (RFC3339; default the
+// last 30 days) as per-metric series. Readings are averaged into buckets (hour or day;
+// auto picks by range length) and then reduced with LTTB to at most points per metric,
+// so payloads stay bounded however many readings exist.
+func GetReadingSeries(token, farmID, metric, from, to, bucket string, points int) (*ReadingSeries, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := []string{"fertility", "moisture", "ph", "temperature", "sunlight", "humidity"}
+	if metric != "" {
+		if _, ok := readingMetrics[metric]; !ok {
+			return nil, invalidf("unknown metric %q", metric)
+		}
+		metrics = []string{metric}
+	}
+	if points <= 0 {
+		points = defaultSeriesPoints
+	}
+	if points < 3 || points > maxSeriesPoints {
+		return nil, invalidf("points must be between 3 and %d", maxSeriesPoints)
+	}
+
+	end := time.Now().UTC()
+	if to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, invalidf("to must be RFC3339")
+		}
+		end = t.UTC()
+	}
+	start := end.Add(-30 * 24 * time.Hour)
+	if from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, invalidf("from must be RFC3339")
+		}
+		start = t.UTC()
+	}
+	if !start.Before(end) {
+		return nil, invalidf("from must be before to")
+	}
+	if end.Sub(start) > maxSeriesRange {
+		return nil, invalidf("range must not exceed 366 days")
+	}
+
+	switch bucket {
+	case "", "auto":
+		bucket = autoSeriesBucket(end.Sub(start))
+	case SeriesBucketRaw, SeriesBucketHour, SeriesBucketDay:
+	default:
+		return nil, invalidf("bucket must be raw, hour, day, or auto")
+	}
+
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("farm_scans:%s:series:%s:%s:%s:%s:%d",
+		farm.FarmName, metric, start.Format(time.RFC3339), end.Format(time.RFC3339), bucket, points)
+	var cached ReadingSeries
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	records, err := memgraph.ExecuteReplicaRead(`MATCH (f:Farm {id: $farmId})-[:HAS_SENSOR]->(:Sensor)-[:HAS_READING]->(r:Reading)
+		WHERE r.createdAt >= $from AND r.createdAt < $to
+		RETURN r.createdAt AS createdAt, r.fertility AS fertility, r.moisture AS moisture, r.ph AS ph,
+			r.temperature AS temperature, r.sunlight AS sunlight, r.humidity AS humidity
+		ORDER BY r.createdAt
+		LIMIT $limit`, map[string]any{
+		"farmId": farmID,
+		"from":   start.Format(time.RFC3339),
+		"to":     end.Format(time.RFC3339),
+		"limit":  maxSeriesReadings,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch readings: %w", err)
+	}
+
+	raw := make(map[string][]SeriesPoint, len(metrics))
+	for _, record := range records {
+		rawCreatedAt, _ := record.Get("createdAt")
+		createdAt := parseDate(rawCreatedAt)
+		if createdAt.IsZero() {
+			continue
+		}
+		for _, m := range metrics {
+			if v, ok := memgraph.Float64(record, m); ok {
+				raw[m] = append(raw[m], SeriesPoint{T: createdAt.Unix(), V: v})
+			}
+		}
+	}
+
+	series := &ReadingSeries{
+		FarmID:   farm.ID,
+		FarmName: farm.FarmName,
+		From:     start.Format(time.RFC3339),
+		To:       end.Format(time.RFC3339),
+		Bucket:   bucket,
+		Points:   points,
+		RawCount: len(records),
+		Series:   make(map[string][]SeriesPoint, len(metrics)),
+	}
+	for _, m := range metrics {
+		values := raw[m]
+		switch bucket {
+		case SeriesBucketHour:
+			values = bucketAverage(values, 3600)
+		case SeriesBucketDay:
+			values = bucketAverage(values, 86400)
+		}
+		if values == nil {
+			values = []SeriesPoint{}
+		}
+		series.Series[m] = lttb(values, points)
+	}
+
+	cacheFarmScans(farm.FarmName, cacheKey, series, config.CacheTTL(config.TTLFarmScans))
+
+	return series, nil
+}
+
+// autoSeriesBucket picks raw readings for short ranges, hourly averages up to two
+// weeks, and daily averages beyond
+func autoSeriesBucket(span time.Duration) string {
+	switch {
+	case span <= 2*24*time.Hour:
+		return SeriesBucketRaw
+	case span <= 14*24*time.Hour:
+		return SeriesBucketHour
+	}
+	return SeriesBucketDay
+}
+
+// bucketAverage averages time-ordered points into fixed buckets of size seconds, each
+// stamped with its bucket start
+func bucketAverage(points []SeriesPoint, size int64) []SeriesPoint {
+	sums := make(map[int64]float64)
+	counts := make(map[int64]int)
+	for _, p := range points {
+		start := p.T - p.T%size
+		sums[start] += p.V
+		counts[start]++
+	}
+
+	result := make([]SeriesPoint, 0, len(sums))
+	for start, sum := range sums {
+		result = append(result, SeriesPoint{T: start, V: sum / float64(counts[start])})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].T < result[j].T })
+	return result
+}
+
+// lttb reduces time-ordered points to at most threshold using Largest-Triangle-Three-
+// Buckets, which keeps the series' visual shape (peaks and dips) better than averaging
+func lttb(points []SeriesPoint, threshold int) []SeriesPoint {
+	if threshold >= len(points) || threshold < 3 {
+		return points
+	}
+
+	sampled := make([]SeriesPoint, 0, threshold)
+	sampled = append(sampled, points[0])
+
+	// The first and last points are kept; the rest are split into threshold-2 buckets
+	every := float64(len(points)-2) / float64(threshold-2)
+	a := 0
+	for i := 0; i < threshold-2; i++ {
+		// Average of the next bucket, used as the third triangle vertex
+		nextStart := int(math.Floor(float64(i+1)*every)) + 1
+		nextEnd := int(math.Floor(float64(i+2)*every)) + 1
+		if nextEnd > len(points) {
+			nextEnd = len(points)
+		}
+		var avgT, avgV float64
+		for _, p := range points[nextStart:nextEnd] {
+			avgT += float64(p.T)
+			avgV += p.V
+		}
+		n := float64(nextEnd - nextStart)
+		avgT /= n
+		avgV /= n
+
+		// Pick the point in this bucket forming the largest triangle with the previous pick
+		start := int(math.Floor(float64(i)*every)) + 1
+		end := int(math.Floor(float64(i+1)*every)) + 1
+		maxArea := -1.0
+		next := start
+		for j := start; j < end; j++ {
+			area := math.Abs((float64(points[a].T)-avgT)*(points[j].V-points[a].V)-
+				(float64(points[a].T)-float64(points[j].T))*(avgV-points[a].V)) / 2
+			if area > maxArea {
+				maxArea = area
+				next = j
+			}
+		}
+		sampled = append(sampled, points[next])
+		a = next
+	}
+
+	return append(sampled, points[len(points)-1])
+}
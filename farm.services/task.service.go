@@ -0,0 +1,616 @@
+package farmservices
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	// maxTasks caps how many tasks a listing returns
+	maxTasks = 200
+	// maxTaskTitleLength bounds a task's title
+	maxTaskTitleLength = 200
+	// maxTaskDescriptionLength bounds a task's description
+	maxTaskDescriptionLength = 2000
+)
+
+// Farm task errors
+var (
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrTaskCompleted    = errors.New("task is already completed")
+	ErrNotTaskAssignee  = errors.New("only a farm manager or the task's assignee can do that")
+	ErrAssigneeNotFound = errors.New("assignee not found or has no access to this farm")
+)
+
+// taskReturn projects a task matched as t on farm f
+const taskReturn = `RETURN t.id AS id, f.id AS farmId, f.farmName AS farmName, f.owner AS owner,
+	t.title AS title, t.description AS description, t.assignee AS assignee, t.createdBy AS createdBy,
+	t.dueAt AS dueAt, t.recurrence AS recurrence, t.status AS status, t.completedBy AS completedBy,
+	t.completedAt AS completedAt, t.nextTaskId AS nextTaskId, t.createdAt AS createdAt`
+
+// CreateTask adds a task to a farm the caller manages and notifies its assignee
+func CreateTask(token, farmID string, req CreateTaskRequest) (*FarmTask, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleManager)
+	if err != nil {
+		return nil, err
+	}
+
+	title, err := validateTaskTitle(req.Title)
+	if err != nil {
+		return nil, err
+	}
+	description := strings.TrimSpace(req.Description)
+	if len(description) > maxTaskDescriptionLength {
+		return nil, invalidf("description exceeds %d characters", maxTaskDescriptionLength)
+	}
+	dueAt, err := parseTaskDue(req.DueAt)
+	if err != nil {
+		return nil, err
+	}
+	recurrence, err := validateRecurrence(req.Recurrence, dueAt)
+	if err != nil {
+		return nil, err
+	}
+	assignee := strings.TrimSpace(req.Assignee)
+	if assignee != "" {
+		if err := ensureAssignable(farm, assignee); err != nil {
+			return nil, err
+		}
+	}
+
+	task := &FarmTask{
+		ID:          uuid.NewString(),
+		FarmID:      farm.ID,
+		FarmName:    farm.FarmName,
+		Title:       title,
+		Description: description,
+		Assignee:    assignee,
+		CreatedBy:   username,
+		DueAt:       dueAt,
+		Recurrence:  recurrence,
+		Status:      TaskStatusOpen,
+		CreatedAt:   time.Now().UnixMilli(),
+	}
+	if err := insertTask(task); err != nil {
+		return nil, err
+	}
+
+	notifyTaskAssigned(task, username)
+
+	return task, nil
+}
+
+// GetFarmTasks returns a farm's tasks, soonest due first
+func GetFarmTasks(token, farmID string, filter TaskFilter) ([]FarmTask, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+	return queryTasks(`MATCH (f:Farm {id: $farmId})-[:HAS_TASK]->(t:FarmTask)`,
+		map[string]any{"farmId": farmID}, filter)
+}
+
+// GetMyTasks returns the tasks assigned to the caller or on farms they own or collaborate
+// on, soonest due first
+func GetMyTasks(token string, filter TaskFilter) ([]FarmTask, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	return queryTasks(`MATCH (f:Farm)-[:HAS_TASK]->(t:FarmTask)
+		WHERE (toLower(t.assignee) = toLower($username) AND f.deletedAt IS NULL) OR `+accessibleFarmCondition,
+		map[string]any{"username": username}, filter)
+}
+
+// ParseTaskFilter validates the task listing query parameters. dueBefore and dueAfter are
+// RFC3339 timestamps.
+func ParseTaskFilter(status, assignee, dueBefore, dueAfter string) (TaskFilter, error) {
+	filter := TaskFilter{Assignee: strings.TrimSpace(assignee)}
+
+	switch status {
+	case "", TaskStatusOpen, TaskStatusCompleted:
+		filter.Status = status
+	default:
+		return filter, invalidf("status must be open or completed")
+	}
+	if dueBefore != "" {
+		t, err := time.Parse(time.RFC3339, dueBefore)
+		if err != nil {
+			return filter, invalidf("dueBefore must be RFC3339")
+		}
+		filter.DueBefore = t.UnixMilli()
+	}
+	if dueAfter != "" {
+		t, err := time.Parse(time.RFC3339, dueAfter)
+		if err != nil {
+			return filter, invalidf("dueAfter must be RFC3339")
+		}
+		filter.DueAfter = t.UnixMilli()
+	}
+	return filter, nil
+}
+
+// UpdateTask edits an open task on a farm the caller manages. Reassigning notifies the
+// new assignee; changing the due date re-arms its reminder.
+func UpdateTask(token, taskID string, req UpdateTaskRequest) (*FarmTask, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	task, err := getTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+	farm, _, err := authorizeFarm(task.FarmID, username, FarmRoleManager)
+	if err != nil {
+		return nil, err
+	}
+	if task.Status != TaskStatusOpen {
+		return nil, ErrTaskCompleted
+	}
+
+	sets := []string{}
+	params := map[string]any{"id": taskID}
+	if req.Title != nil {
+		title, err := validateTaskTitle(*req.Title)
+		if err != nil {
+			return nil, err
+		}
+		task.Title = title
+		sets = append(sets, "t.title = $title")
+		params["title"] = title
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if len(description) > maxTaskDescriptionLength {
+			return nil, invalidf("description exceeds %d characters", maxTaskDescriptionLength)
+		}
+		task.Description = description
+		sets = append(sets, "t.description = $description")
+		params["description"] = description
+	}
+	if req.DueAt != nil {
+		dueAt, err := parseTaskDue(*req.DueAt)
+		if err != nil {
+			return nil, err
+		}
+		task.DueAt = dueAt
+		sets = append(sets, "t.dueAt = $dueAt", "t.reminderSentFor = null")
+		params["dueAt"] = nullableMillis(dueAt)
+	}
+	if req.Recurrence != nil {
+		task.Recurrence = *req.Recurrence
+		sets = append(sets, "t.recurrence = $recurrence")
+	}
+	recurrence, err := validateRecurrence(task.Recurrence, task.DueAt)
+	if err != nil {
+		return nil, err
+	}
+	task.Recurrence = recurrence
+	params["recurrence"] = recurrence
+
+	reassigned := false
+	if req.Assignee != nil {
+		assignee := strings.TrimSpace(*req.Assignee)
+		if assignee != "" {
+			if err := ensureAssignable(farm, assignee); err != nil {
+				return nil, err
+			}
+		}
+		reassigned = !strings.EqualFold(assignee, task.Assignee)
+		task.Assignee = assignee
+	}
+
+	if len(sets) > 0 {
+		if _, err := memgraph.ExecuteWrite(`MATCH (t:FarmTask {id: $id}) SET `+strings.Join(sets, ", "), params); err != nil {
+			return nil, fmt.Errorf("failed to update task: %w", err)
+		}
+	}
+	if reassigned {
+		if err := assignTask(taskID, task.Assignee); err != nil {
+			return nil, err
+		}
+		notifyTaskAssigned(task, username)
+	}
+
+	return task, nil
+}
+
+// CompleteTask marks a task done. Farm managers and the assignee may complete it;
+// completing a recurring task opens its next occurrence with the same assignee.
+func CompleteTask(token, taskID string) (*FarmTask, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	task, err := getTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(task.Assignee, username) {
+		if _, _, err := authorizeFarm(task.FarmID, username, FarmRoleManager); err != nil {
+			if errors.Is(err, ErrFarmAccessDenied) {
+				return nil, ErrNotTaskAssignee
+			}
+			return nil, err
+		}
+	}
+	if task.Status != TaskStatusOpen {
+		return nil, ErrTaskCompleted
+	}
+
+	var next *FarmTask
+	if task.Recurrence != TaskRecurrenceNone && task.DueAt > 0 {
+		next = &FarmTask{
+			ID:          uuid.NewString(),
+			FarmID:      task.FarmID,
+			FarmName:    task.FarmName,
+			Title:       task.Title,
+			Description: task.Description,
+			Assignee:    task.Assignee,
+			CreatedBy:   task.CreatedBy,
+			DueAt:       nextDue(task.DueAt, task.Recurrence, time.Now()),
+			Recurrence:  task.Recurrence,
+			Status:      TaskStatusOpen,
+			CreatedAt:   time.Now().UnixMilli(),
+		}
+		task.NextTaskID = next.ID
+	}
+
+	task.Status = TaskStatusCompleted
+	task.CompletedBy = username
+	task.CompletedAt = time.Now().UnixMilli()
+	summary, err := memgraph.ExecuteWrite(`MATCH (t:FarmTask {id: $id})
+		WHERE t.status = 'open'
+		SET t.status = 'completed', t.completedBy = $completedBy, t.completedAt = $completedAt, t.nextTaskId = $nextTaskId`,
+		map[string]any{
+			"id":          taskID,
+			"completedBy": username,
+			"completedAt": task.CompletedAt,
+			"nextTaskId":  task.NextTaskID,
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete task: %w", err)
+	}
+	// Another request completed it between the read and the write
+	if summary == nil || summary.Counters().PropertiesSet() == 0 {
+		return nil, ErrTaskCompleted
+	}
+
+	if next != nil {
+		if err := insertTask(next); err != nil {
+			slog.Warn("failed to open next occurrence of task", "taskId", taskID, "error", err)
+			task.NextTaskID = ""
+		}
+	}
+
+	return task, nil
+}
+
+// DeleteTask removes a task from a farm the caller manages
+func DeleteTask(token, taskID string) error {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return err
+	}
+	task, err := getTask(taskID)
+	if err != nil {
+		return err
+	}
+	if _, _, err := authorizeFarm(task.FarmID, username, FarmRoleManager); err != nil {
+		return err
+	}
+
+	if _, err := memgraph.ExecuteWrite(`MATCH (t:FarmTask {id: $id}) DETACH DELETE t`,
+		map[string]any{"id": taskID}); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return nil
+}
+
+// CheckDueTasks reminds assignees (or the farm owner, for unassigned tasks) of open tasks
+// due within TASK_REMINDER_WINDOW (default 24h), including overdue ones. Each task is
+// reminded once per due date, so rescheduling it re-arms the reminder.
+func CheckDueTasks() {
+	window := 24 * time.Hour
+	if v := os.Getenv("TASK_REMINDER_WINDOW"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			window = parsed
+		}
+	}
+
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:HAS_TASK]->(t:FarmTask)
+		WHERE f.deletedAt IS NULL AND t.status = 'open' AND t.dueAt IS NOT NULL AND t.dueAt <= $until
+			AND coalesce(t.reminderSentFor, 0) <> t.dueAt
+		`+taskReturn+`
+		LIMIT 1000`, map[string]any{"until": time.Now().Add(window).UnixMilli()})
+	if err != nil {
+		slog.Warn("failed to load due tasks", "error", err)
+		return
+	}
+
+	for _, record := range records {
+		task := taskFromRecord(record)
+
+		// Claim the reminder for this due date so repeated checks stay quiet
+		summary, err := memgraph.ExecuteWrite(`MATCH (t:FarmTask {id: $id})
+			WHERE t.dueAt = $dueAt AND coalesce(t.reminderSentFor, 0) <> $dueAt
+			SET t.reminderSentFor = $dueAt`, map[string]any{"id": task.ID, "dueAt": task.DueAt})
+		if err != nil {
+			slog.Warn("failed to record reminder for task", "taskId", task.ID, "error", err)
+			continue
+		}
+		if summary == nil || summary.Counters().PropertiesSet() == 0 {
+			continue
+		}
+
+		recipient := task.Assignee
+		if recipient == "" {
+			recipient = memgraph.String(record, "owner")
+		}
+		due := time.UnixMilli(task.DueAt)
+		message := fmt.Sprintf("%q on %s is due %s.", task.Title, task.FarmName, due.UTC().Format("Jan 2, 15:04 MST"))
+		if due.Before(time.Now()) {
+			message = fmt.Sprintf("%q on %s was due %s and is still open.", task.Title, task.FarmName, due.UTC().Format("Jan 2, 15:04 MST"))
+		}
+		notificationServices.NotifyAsync(recipient, notificationServices.TypeTaskDue, "Task due", message,
+			map[string]any{"taskId": task.ID, "farmId": task.FarmID, "dueAt": task.DueAt})
+	}
+}
+
+// StartTaskReminderWorker sends task due reminders on a fixed interval. The interval
+// defaults to 15 minutes and can be overridden with TASK_REMINDER_INTERVAL. It blocks,
+// so run it in a goroutine.
+func StartTaskReminderWorker() {
+	interval := 15 * time.Minute
+	if v := os.Getenv("TASK_REMINDER_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("task reminder worker started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		CheckDueTasks()
+	}
+}
+
+// insertTask writes a task node on its farm and links its assignee
+func insertTask(task *FarmTask) error {
+	_, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		CREATE (f)-[:HAS_TASK]->(t:FarmTask {
+			id: $id,
+			farmId: $farmId,
+			title: $title,
+			description: $description,
+			assignee: $assignee,
+			createdBy: $createdBy,
+			dueAt: $dueAt,
+			recurrence: $recurrence,
+			status: $status,
+			createdAt: $createdAt
+		})
+		WITH t
+		OPTIONAL MATCH (u:User {username: $assignee})
+		FOREACH (_ IN CASE WHEN u IS NULL THEN [] ELSE [1] END | CREATE (t)-[:ASSIGNED_TO]->(u))`, map[string]any{
+		"farmId":      task.FarmID,
+		"id":          task.ID,
+		"title":       task.Title,
+		"description": task.Description,
+		"assignee":    task.Assignee,
+		"createdBy":   task.CreatedBy,
+		"dueAt":       nullableMillis(task.DueAt),
+		"recurrence":  task.Recurrence,
+		"status":      task.Status,
+		"createdAt":   task.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+	return nil
+}
+
+// assignTask replaces a task's assignee and ASSIGNED_TO link; an empty assignee unassigns it
+func assignTask(taskID, assignee string) error {
+	_, err := memgraph.ExecuteWrite(`MATCH (t:FarmTask {id: $id})
+		OPTIONAL MATCH (t)-[r:ASSIGNED_TO]->(:User)
+		DELETE r
+		WITH DISTINCT t
+		SET t.assignee = $assignee, t.reminderSentFor = null
+		WITH t
+		OPTIONAL MATCH (u:User {username: $assignee})
+		FOREACH (_ IN CASE WHEN u IS NULL THEN [] ELSE [1] END | CREATE (t)-[:ASSIGNED_TO]->(u))`,
+		map[string]any{"id": taskID, "assignee": assignee})
+	if err != nil {
+		return fmt.Errorf("failed to assign task: %w", err)
+	}
+	return nil
+}
+
+// getTask loads a task by ID
+func getTask(taskID string) (*FarmTask, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:HAS_TASK]->(t:FarmTask {id: $id}) `+taskReturn,
+		map[string]any{"id": taskID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrTaskNotFound
+	}
+	task := taskFromRecord(records[0])
+	return &task, nil
+}
+
+// queryTasks runs a task match with the filter applied, soonest due first and undated
+// tasks last
+func queryTasks(match string, params map[string]any, filter TaskFilter) ([]FarmTask, error) {
+	conditions := []string{}
+	if filter.Status != "" {
+		conditions = append(conditions, "t.status = $status")
+		params["status"] = filter.Status
+	}
+	if filter.Assignee != "" {
+		conditions = append(conditions, "toLower(t.assignee) = toLower($assignee)")
+		params["assignee"] = filter.Assignee
+	}
+	if filter.DueBefore > 0 {
+		conditions = append(conditions, "t.dueAt < $dueBefore")
+		params["dueBefore"] = filter.DueBefore
+	}
+	if filter.DueAfter > 0 {
+		conditions = append(conditions, "t.dueAt >= $dueAfter")
+		params["dueAfter"] = filter.DueAfter
+	}
+	params["limit"] = maxTasks
+
+	query := match
+	if len(conditions) > 0 {
+		query += "\n\t\tWITH f, t WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += "\n\t\tWITH f, t ORDER BY t.dueAt IS NULL, t.dueAt, t.createdAt LIMIT $limit\n\t\t" + taskReturn
+
+	records, err := memgraph.ExecuteRead(query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tasks: %w", err)
+	}
+
+	tasks := make([]FarmTask, 0, len(records))
+	for _, record := range records {
+		tasks = append(tasks, taskFromRecord(record))
+	}
+	return tasks, nil
+}
+
+// taskFromRecord maps a taskReturn row to a FarmTask
+func taskFromRecord(record *neo4j.Record) FarmTask {
+	task := FarmTask{
+		ID:          memgraph.String(record, "id"),
+		FarmID:      memgraph.String(record, "farmId"),
+		FarmName:    memgraph.String(record, "farmName"),
+		Title:       memgraph.String(record, "title"),
+		Description: memgraph.String(record, "description"),
+		Assignee:    memgraph.String(record, "assignee"),
+		CreatedBy:   memgraph.String(record, "createdBy"),
+		DueAt:       memgraph.Int64(record, "dueAt"),
+		Recurrence:  memgraph.String(record, "recurrence"),
+		Status:      memgraph.String(record, "status"),
+		CompletedBy: memgraph.String(record, "completedBy"),
+		CompletedAt: memgraph.Int64(record, "completedAt"),
+		NextTaskID:  memgraph.String(record, "nextTaskId"),
+		CreatedAt:   memgraph.Int64(record, "createdAt"),
+	}
+	if task.Recurrence == "" {
+		task.Recurrence = TaskRecurrenceNone
+	}
+	return task
+}
+
+// notifyTaskAssigned tells a task's assignee about it, unless they assigned it themselves
+func notifyTaskAssigned(task *FarmTask, assignedBy string) {
+	if task.Assignee == "" || strings.EqualFold(task.Assignee, assignedBy) {
+		return
+	}
+	message := fmt.Sprintf("%s assigned you %q on %s.", assignedBy, task.Title, task.FarmName)
+	notificationServices.NotifyAsync(task.Assignee, notificationServices.TypeTaskAssigned, "New farm task", message,
+		map[string]any{"taskId": task.ID, "farmId": task.FarmID, "dueAt": task.DueAt})
+}
+
+// validateTaskTitle trims and bounds a task title
+func validateTaskTitle(title string) (string, error) {
+	title = utils.SanitizeInput(title)
+	if title == "" {
+		return "", invalidf("title is required")
+	}
+	if len(title) > maxTaskTitleLength {
+		return "", invalidf("title exceeds %d characters", maxTaskTitleLength)
+	}
+	return title, nil
+}
+
+// parseTaskDue parses an optional RFC3339 due time as Unix ms; empty means no due date
+func parseTaskDue(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, invalidf("dueAt must be RFC3339")
+	}
+	return t.UnixMilli(), nil
+}
+
+// validateRecurrence normalizes a recurrence, which needs a due date to repeat from
+func validateRecurrence(recurrence string, dueAt int64) (string, error) {
+	switch recurrence {
+	case "", TaskRecurrenceNone:
+		return TaskRecurrenceNone, nil
+	case TaskRecurrenceDaily, TaskRecurrenceWeekly, TaskRecurrenceMonthly:
+		if dueAt == 0 {
+			return "", invalidf("recurring tasks need a due date")
+		}
+		return recurrence, nil
+	}
+	return "", invalidf("recurrence must be none, daily, weekly, or monthly")
+}
+
+// nextDue advances a recurring due time by its period until it is after now, so
+// completing an overdue task doesn't open occurrences that are already late
+func nextDue(dueAt int64, recurrence string, now time.Time) int64 {
+	due := time.UnixMilli(dueAt).UTC()
+	for {
+		switch recurrence {
+		case TaskRecurrenceDaily:
+			due = due.AddDate(0, 0, 1)
+		case TaskRecurrenceWeekly:
+			due = due.AddDate(0, 0, 7)
+		default:
+			due = due.AddDate(0, 1, 0)
+		}
+		if due.After(now) {
+			return due.UnixMilli()
+		}
+	}
+}
+
+// nullableMillis stores an unset (zero) timestamp as null
+func nullableMillis(ms int64) any {
+	if ms == 0 {
+		return nil
+	}
+	return ms
+}
+
+// ensureAssignable checks that a task assignee is the farm's owner or a collaborator
+func ensureAssignable(farm *FarmList, assignee string) error {
+	role, err := farmRole(farm, assignee)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return ErrAssigneeNotFound
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package farmservices
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxTimelineDepth bounds how far back the timeline can be paged, since every page
+// reads the newest page*limit events of each source
+const maxTimelineDepth = 1000
+
+// Timeline event types
+const (
+	TimelinePlantScan     = "plant_scan"
+	TimelineSoilReading   = "soil_reading"
+	TimelineAlert         = "alert"
+	TimelineTaskCreated   = "task_created"
+	TimelineTaskCompleted = "task_completed"
+	TimelineHarvest       = "harvest"
+	TimelinePlotListed    = "plot_listed"
+	TimelinePlotSold      = "plot_sold"
+)
+
+// timelineSource reads one kind of farm activity. query returns the newest $limit events
+// ordered newest first and count the total; both receive $farmId and $farmName.
+type timelineSource struct {
+	query string
+	count string
+	event func(record *neo4j.Record) TimelineEvent
+}
+
+// timelineSources are merged into the farm timeline
+var timelineSources = []timelineSource{
+	{
+		query: `MATCH (:Farm {id: $farmId})-[:HAS_PLANT_SCAN]->(ps:PlantScan)
+			WHERE ps.deletedAt IS NULL
+			WITH ps, COALESCE(ps.date, ps.createdAt, ps.created_at, ps.timestamp, '1970-01-01T00:00:00Z') AS at
+			ORDER BY at DESC LIMIT $limit
+			RETURN ps.id AS id, at, ps.cropType AS cropType, ps.note AS note, ps.imageUri AS imageUri,
+				ps.interpretationStatus AS interpretationStatus, ps.interpretation AS interpretation`,
+		count: `MATCH (:Farm {id: $farmId})-[:HAS_PLANT_SCAN]->(ps:PlantScan) WHERE ps.deletedAt IS NULL RETURN count(ps) AS total`,
+		event: func(record *neo4j.Record) TimelineEvent {
+			cropType := memgraph.String(record, "cropType")
+			return TimelineEvent{
+				Type:      TimelinePlantScan,
+				ID:        memgraph.String(record, "id"),
+				Title:     fmt.Sprintf("%s plant scan", cropType),
+				Timestamp: timelineMillis(record, "at"),
+				Data: map[string]any{
+					"cropType":             cropType,
+					"note":                 memgraph.String(record, "note"),
+					"imageUri":             memgraph.String(record, "imageUri"),
+					"interpretationStatus": memgraph.String(record, "interpretationStatus"),
+					"interpretation":       parsePlantScanInterpretation(record, "interpretation"),
+				},
+			}
+		},
+	},
+	{
+		query: `MATCH (:Farm {id: $farmId})-[:HAS_SENSOR]->(:Sensor)-[:HAS_READING]->(r:Reading)
+			WITH r ORDER BY r.createdAt DESC LIMIT $limit
+			RETURN r.id AS id, r.createdAt AS at, r.sensorId AS sensorId, r.fertility AS fertility,
+				r.moisture AS moisture, r.ph AS ph, r.temperature AS temperature, r.sunlight AS sunlight,
+				r.humidity AS humidity`,
+		count: `MATCH (:Farm {id: $farmId})-[:HAS_SENSOR]->(:Sensor)-[:HAS_READING]->(r:Reading) RETURN count(r) AS total`,
+		event: func(record *neo4j.Record) TimelineEvent {
+			data := map[string]any{"sensorId": memgraph.String(record, "sensorId")}
+			for _, metric := range []string{"fertility", "moisture", "ph", "temperature", "sunlight", "humidity"} {
+				if v, ok := memgraph.Float64(record, metric); ok {
+					data[metric] = v
+				}
+			}
+			return TimelineEvent{
+				Type:      TimelineSoilReading,
+				ID:        memgraph.String(record, "id"),
+				Title:     fmt.Sprintf("Soil reading from sensor %s", data["sensorId"]),
+				Timestamp: timelineMillis(record, "at"),
+				Data:      data,
+			}
+		},
+	},
+	{
+		query: `MATCH (:Farm {id: $farmId})-[:HAS_ALERT]->(a:SensorAlert)
+			WITH a ORDER BY a.createdAt DESC LIMIT $limit
+			RETURN a.id AS id, a.createdAt AS at, a.sensorId AS sensorId, a.metric AS metric,
+				a.value AS value, a.status AS status, a.occurrences AS occurrences`,
+		count: `MATCH (:Farm {id: $farmId})-[:HAS_ALERT]->(a:SensorAlert) RETURN count(a) AS total`,
+		event: func(record *neo4j.Record) TimelineEvent {
+			metric := memgraph.String(record, "metric")
+			value, _ := memgraph.Float64(record, "value")
+			return TimelineEvent{
+				Type:      TimelineAlert,
+				ID:        memgraph.String(record, "id"),
+				Title:     fmt.Sprintf("%s out of range", metric),
+				Timestamp: memgraph.Int64(record, "at"),
+				Data: map[string]any{
+					"sensorId":    memgraph.String(record, "sensorId"),
+					"metric":      metric,
+					"value":       value,
+					"status":      memgraph.String(record, "status"),
+					"occurrences": memgraph.Int64(record, "occurrences"),
+				},
+			}
+		},
+	},
+	{
+		query: `MATCH (:Farm {id: $farmId})-[:HAS_TASK]->(t:FarmTask)
+			WITH t ORDER BY t.createdAt DESC LIMIT $limit
+			RETURN t.id AS id, t.createdAt AS at, t.title AS title, t.createdBy AS createdBy,
+				t.assignee AS assignee, t.dueAt AS dueAt, t.status AS status`,
+		count: `MATCH (:Farm {id: $farmId})-[:HAS_TASK]->(t:FarmTask) RETURN count(t) AS total`,
+		event: func(record *neo4j.Record) TimelineEvent {
+			return TimelineEvent{
+				Type:      TimelineTaskCreated,
+				ID:        memgraph.String(record, "id"),
+				Title:     memgraph.String(record, "title"),
+				Timestamp: memgraph.Int64(record, "at"),
+				Data: map[string]any{
+					"createdBy": memgraph.String(record, "createdBy"),
+					"assignee":  memgraph.String(record, "assignee"),
+					"dueAt":     memgraph.Int64(record, "dueAt"),
+					"status":    memgraph.String(record, "status"),
+				},
+			}
+		},
+	},
+	{
+		query: `MATCH (:Farm {id: $farmId})-[:HAS_TASK]->(t:FarmTask)
+			WHERE t.completedAt IS NOT NULL
+			WITH t ORDER BY t.completedAt DESC LIMIT $limit
+			RETURN t.id AS id, t.completedAt AS at, t.title AS title, t.completedBy AS completedBy`,
+		count: `MATCH (:Farm {id: $farmId})-[:HAS_TASK]->(t:FarmTask) WHERE t.completedAt IS NOT NULL RETURN count(t) AS total`,
+		event: func(record *neo4j.Record) TimelineEvent {
+			return TimelineEvent{
+				Type:      TimelineTaskCompleted,
+				ID:        memgraph.String(record, "id"),
+				Title:     memgraph.String(record, "title"),
+				Timestamp: memgraph.Int64(record, "at"),
+				Data:      map[string]any{"completedBy": memgraph.String(record, "completedBy")},
+			}
+		},
+	},
+	{
+		query: `MATCH (:Farm {id: $farmId})-[:HAS_HARVEST]->(h:Harvest)
+			WITH h ORDER BY h.date DESC, h.createdAt DESC LIMIT $limit
+			RETURN h.id AS id, h.date AS at, h.quantityKg AS quantityKg, h.qualityGrade AS qualityGrade,
+				h.pricePerKg AS pricePerKg, h.currency AS currency`,
+		count: `MATCH (:Farm {id: $farmId})-[:HAS_HARVEST]->(h:Harvest) RETURN count(h) AS total`,
+		event: func(record *neo4j.Record) TimelineEvent {
+			quantity, _ := memgraph.Float64(record, "quantityKg")
+			data := map[string]any{
+				"quantityKg":   quantity,
+				"qualityGrade": memgraph.String(record, "qualityGrade"),
+				"currency":     memgraph.String(record, "currency"),
+			}
+			if price, ok := memgraph.Float64(record, "pricePerKg"); ok {
+				data["pricePerKg"] = price
+			}
+			var at int64
+			if date, err := time.Parse("2006-01-02", memgraph.String(record, "at")); err == nil {
+				at = date.UnixMilli()
+			}
+			return TimelineEvent{
+				Type:      TimelineHarvest,
+				ID:        memgraph.String(record, "id"),
+				Title:     fmt.Sprintf("Harvested %.1f kg", quantity),
+				Timestamp: at,
+				Data:      data,
+			}
+		},
+	},
+	{
+		// The farm's plots are those linked to it plus those listed under its name
+		query: `OPTIONAL MATCH (:Farm {id: $farmId})-[:TOKENIZED_AS]->(n:FarmPlotNFT)
+			WITH collect(n.tokenId) AS linked
+			OPTIONAL MATCH (l:PlotPriceEvent {type: 'LISTING', farmName: $farmName})
+			WITH linked + collect(l.tokenId) AS tokenIds
+			MATCH (e:PlotPriceEvent) WHERE e.tokenId IN tokenIds
+			WITH e ORDER BY e.timestamp DESC LIMIT $limit
+			RETURN coalesce(e.txHash, e.listingId + ':' + toString(e.price)) AS id, e.timestamp AS at,
+				e.type AS type, e.tokenId AS tokenId, e.listingId AS listingId, e.price AS price,
+				e.currencySymbol AS currencySymbol, e.priceUSD AS priceUSD, e.buyer AS buyer, e.seller AS seller`,
+		count: `OPTIONAL MATCH (:Farm {id: $farmId})-[:TOKENIZED_AS]->(n:FarmPlotNFT)
+			WITH collect(n.tokenId) AS linked
+			OPTIONAL MATCH (l:PlotPriceEvent {type: 'LISTING', farmName: $farmName})
+			WITH linked + collect(l.tokenId) AS tokenIds
+			MATCH (e:PlotPriceEvent) WHERE e.tokenId IN tokenIds
+			RETURN count(e) AS total`,
+		event: func(record *neo4j.Record) TimelineEvent {
+			tokenID := memgraph.String(record, "tokenId")
+			price, _ := memgraph.Float64(record, "price")
+			priceUSD, _ := memgraph.Float64(record, "priceUSD")
+			event := TimelineEvent{
+				Type:      TimelinePlotListed,
+				ID:        memgraph.String(record, "id"),
+				Title:     fmt.Sprintf("Plot #%s listed", tokenID),
+				Timestamp: memgraph.Int64(record, "at") * 1000, // Price events are stored in seconds
+				Data: map[string]any{
+					"tokenId":        tokenID,
+					"listingId":      memgraph.String(record, "listingId"),
+					"price":          price,
+					"currencySymbol": memgraph.String(record, "currencySymbol"),
+					"priceUSD":       priceUSD,
+				},
+			}
+			if memgraph.String(record, "type") == "SALE" {
+				event.Type = TimelinePlotSold
+				event.Title = fmt.Sprintf("Plot #%s sold", tokenID)
+				event.Data["buyer"] = memgraph.String(record, "buyer")
+				event.Data["seller"] = memgraph.String(record, "seller")
+			}
+			return event
+		},
+	},
+}
+
+// GetFarmTimeline merges a farm's plant scans, soil readings, alerts, tasks, harvests,
+// and plot marketplace events into one feed, newest first
+func GetFarmTimeline(token, farmID string, page, limit int) (*FarmTimeline, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	offset := (page - 1) * limit
+	if offset+limit > maxTimelineDepth {
+		return nil, invalidf("the timeline can be paged back at most %d events", maxTimelineDepth)
+	}
+
+	// A page of the merged feed lies within the newest offset+limit events of each source
+	params := map[string]any{"farmId": farm.ID, "farmName": farm.FarmName, "limit": offset + limit}
+	events := make([][]TimelineEvent, len(timelineSources))
+	totals := make([]int64, len(timelineSources))
+
+	var g errgroup.Group
+	for i, source := range timelineSources {
+		g.Go(func() error {
+			records, err := memgraph.ExecuteReplicaRead(source.query, params)
+			if err != nil {
+				return fmt.Errorf("failed to fetch farm timeline: %w", err)
+			}
+			for _, record := range records {
+				events[i] = append(events[i], source.event(record))
+			}
+			return nil
+		})
+		g.Go(func() error {
+			records, err := memgraph.ExecuteReplicaRead(source.count, params)
+			if err != nil {
+				return fmt.Errorf("failed to count farm timeline: %w", err)
+			}
+			if len(records) > 0 {
+				totals[i] = memgraph.Int64(records[0], "total")
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var merged []TimelineEvent
+	var total int64
+	for i := range timelineSources {
+		merged = append(merged, events[i]...)
+		total += totals[i]
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Timestamp != merged[j].Timestamp {
+			return merged[i].Timestamp > merged[j].Timestamp
+		}
+		return merged[i].ID < merged[j].ID
+	})
+
+	pageEvents := []TimelineEvent{}
+	if offset < len(merged) {
+		pageEvents = merged[offset:min(offset+limit, len(merged))]
+	}
+
+	return &FarmTimeline{
+		FarmID:     farm.ID,
+		FarmName:   farm.FarmName,
+		Events:     pageEvents,
+		Pagination: utils.NewPaginationInfo(page, limit, int(total)),
+	}, nil
+}
+
+// timelineMillis converts an RFC3339 timestamp column to Unix milliseconds
+func timelineMillis(record *neo4j.Record, key string) int64 {
+	val, _ := record.Get(key)
+	at := parseDate(val)
+	if at.IsZero() {
+		return 0
+	}
+	return at.UnixMilli()
+}
@@ -0,0 +1,416 @@
+package farmservices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	memgraph "decentragri-app-cx-server/db"
+	marketplaceservices "decentragri-app-cx-server/marketplace.services"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	portfolioServices "decentragri-app-cx-server/portfolio.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	// maxVerificationDocuments caps the documents attached to one verification request
+	maxVerificationDocuments = 5
+	// maxVerificationPhotos caps the geotagged photos attached to one verification request
+	maxVerificationPhotos = 10
+	// maxVerificationPhotoDistanceKm is how far from the farm's coordinates a photo may be taken
+	maxVerificationPhotoDistanceKm = 5.0
+	// maxVerificationNoteLength bounds owner and reviewer notes
+	maxVerificationNoteLength = 2000
+)
+
+// Farm verification errors
+var (
+	ErrVerificationNotFound = errors.New("verification request not found")
+	ErrVerificationPending  = errors.New("a verification request for this farm is already pending")
+	ErrVerificationReviewed = errors.New("verification request has already been reviewed")
+	ErrFarmAlreadyVerified  = errors.New("farm is already verified")
+)
+
+// verificationFiles collects the files of a verification request matched as v on farm f
+const verificationFiles = `OPTIONAL MATCH (v)-[:HAS_FILE]->(d:VerificationFile)
+	WITH f, v, collect(CASE WHEN d IS NULL THEN null
+		ELSE {kind: d.kind, uri: d.uri, lat: d.lat, lng: d.lng, distanceKm: d.distanceKm} END) AS files`
+
+// verificationReturn projects a verification request matched as v on farm f, after verificationFiles
+const verificationReturn = `RETURN v.id AS id, f.id AS farmId, f.farmName AS farmName, v.status AS status,
+	v.note AS note, v.submittedBy AS submittedBy, v.submittedAt AS submittedAt,
+	v.reviewedBy AS reviewedBy, v.reviewedAt AS reviewedAt, v.reviewNote AS reviewNote, files`
+
+// SubmitFarmVerification asks admins to verify one of the caller's farms. Documents
+// (images or PDFs) prove ownership or registration; photos must carry the coordinates
+// they were taken at, which have to lie within 5 km of the farm. A farm has at most one
+// pending request.
+func SubmitFarmVerification(ctx context.Context, token, farmID, note string, documents, photos []*FarmImage, geotags []FarmCoordinates) (*FarmVerification, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	farm, err := getOwnedFarm(farmID, username)
+	if err != nil {
+		return nil, err
+	}
+	if farm.Verified {
+		return nil, ErrFarmAlreadyVerified
+	}
+	if farm.Coordinates.Lat == 0 && farm.Coordinates.Lng == 0 {
+		return nil, ErrFarmNoCoordinates
+	}
+
+	note = strings.TrimSpace(note)
+	if len(note) > maxVerificationNoteLength {
+		return nil, invalidf("note exceeds %d characters", maxVerificationNoteLength)
+	}
+	if len(photos) == 0 {
+		return nil, invalidf("at least one geotagged photo is required")
+	}
+	if len(photos) > maxVerificationPhotos {
+		return nil, invalidf("at most %d photos can be submitted", maxVerificationPhotos)
+	}
+	if len(documents) > maxVerificationDocuments {
+		return nil, invalidf("at most %d documents can be submitted", maxVerificationDocuments)
+	}
+	if len(geotags) != len(photos) {
+		return nil, invalidf("photoLocations must give the coordinates of each photo")
+	}
+
+	files := make([]VerificationFile, 0, len(documents)+len(photos))
+	for i, geotag := range geotags {
+		if !utils.ValidCoordinates(geotag.Lat, geotag.Lng) {
+			return nil, invalidf("photo %d has invalid coordinates", i+1)
+		}
+		distance := round1(utils.HaversineKm(farm.Coordinates.Lat, farm.Coordinates.Lng, geotag.Lat, geotag.Lng))
+		if distance > maxVerificationPhotoDistanceKm {
+			return nil, invalidf("photo %d was taken %.1f km from the farm; photos must be within %.0f km",
+				i+1, distance, maxVerificationPhotoDistanceKm)
+		}
+		lat, lng := geotag.Lat, geotag.Lng
+		files = append(files, VerificationFile{Kind: "photo", Lat: &lat, Lng: &lng, DistanceKm: &distance})
+	}
+
+	pending, err := queryVerifications(farmID, VerificationPending, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, ErrVerificationPending
+	}
+
+	// Upload everything before writing so a bad file doesn't leave a partial request
+	for i, photo := range photos {
+		uri, err := uploadImage(ctx, photo)
+		if err != nil {
+			return nil, fmt.Errorf("photo %d: %w", i+1, err)
+		}
+		files[i].URI = uri
+	}
+	for i, document := range documents {
+		uri, err := uploadDocument(ctx, document)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i+1, err)
+		}
+		files = append(files, VerificationFile{Kind: "document", URI: uri})
+	}
+
+	fileParams := make([]map[string]any, 0, len(files))
+	for _, file := range files {
+		fileParams = append(fileParams, map[string]any{
+			"kind":       file.Kind,
+			"uri":        file.URI,
+			"lat":        optionalFloat(file.Lat),
+			"lng":        optionalFloat(file.Lng),
+			"distanceKm": optionalFloat(file.DistanceKm),
+		})
+	}
+	verificationID := uuid.NewString()
+	if _, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		CREATE (f)-[:HAS_VERIFICATION]->(v:FarmVerification {
+			id: $id,
+			farmId: $farmId,
+			status: $status,
+			note: $note,
+			submittedBy: $submittedBy,
+			submittedAt: $submittedAt
+		})
+		WITH v
+		UNWIND $files AS file
+		CREATE (v)-[:HAS_FILE]->(:VerificationFile {
+			kind: file.kind, uri: file.uri, lat: file.lat, lng: file.lng, distanceKm: file.distanceKm
+		})`, map[string]any{
+		"farmId":      farmID,
+		"id":          verificationID,
+		"status":      VerificationPending,
+		"note":        note,
+		"submittedBy": username,
+		"submittedAt": time.Now().UnixMilli(),
+		"files":       fileParams,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save verification request: %w", err)
+	}
+
+	go utils.SendOperatorAlert("info", "Farm verification submitted",
+		fmt.Sprintf("%s submitted %s for verification with %d photos and %d documents",
+			username, farm.FarmName, len(photos), len(documents)),
+		map[string]any{"farmId": farmID, "verificationId": verificationID})
+
+	return getVerification(verificationID)
+}
+
+// GetFarmVerification returns a farm's most recent verification request
+func GetFarmVerification(token, farmID string) (*FarmVerification, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := authorizeFarm(farmID, username, FarmRoleViewer); err != nil {
+		return nil, err
+	}
+
+	verifications, err := queryVerifications(farmID, "", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(verifications) == 0 {
+		return nil, ErrVerificationNotFound
+	}
+	return &verifications[0], nil
+}
+
+// GetFarmVerifications lists verification requests for admin review, oldest first so
+// the queue is worked in order. status filters to pending, approved, or rejected.
+func GetFarmVerifications(status string) ([]FarmVerification, error) {
+	switch status {
+	case "", VerificationPending, VerificationApproved, VerificationRejected:
+	default:
+		return nil, invalidf("status must be pending, approved, or rejected")
+	}
+	return queryVerifications("", status, 0)
+}
+
+// ReviewFarmVerification approves or rejects a pending verification request. Approval
+// marks the farm verified; rejections need a note telling the owner what to fix. The
+// owner is notified either way.
+func ReviewFarmVerification(adminWallet, verificationID string, approve bool, note string) (*FarmVerification, error) {
+	note = strings.TrimSpace(note)
+	if len(note) > maxVerificationNoteLength {
+		return nil, invalidf("note exceeds %d characters", maxVerificationNoteLength)
+	}
+	if !approve && note == "" {
+		return nil, invalidf("a note is required when rejecting")
+	}
+
+	verification, err := getVerification(verificationID)
+	if err != nil {
+		return nil, err
+	}
+	if verification.Status != VerificationPending {
+		return nil, ErrVerificationReviewed
+	}
+
+	status := VerificationRejected
+	if approve {
+		status = VerificationApproved
+	}
+	now := time.Now().UnixMilli()
+	summary, err := memgraph.ExecuteWrite(`MATCH (f:Farm)-[:HAS_VERIFICATION]->(v:FarmVerification {id: $id})
+		WHERE v.status = $pending
+		SET v.status = $status, v.reviewedBy = $admin, v.reviewedAt = $now, v.reviewNote = $note
+		FOREACH (_ IN CASE WHEN $approve THEN [1] ELSE [] END |
+			SET f.verified = true, f.verifiedAt = $now, f.verifiedBy = $admin)`, map[string]any{
+		"id":      verificationID,
+		"pending": VerificationPending,
+		"status":  status,
+		"admin":   adminWallet,
+		"now":     now,
+		"note":    note,
+		"approve": approve,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review verification request: %w", err)
+	}
+	if summary == nil || summary.Counters().PropertiesSet() == 0 {
+		return nil, ErrVerificationReviewed
+	}
+
+	if approve {
+		InvalidateFarmListCache()
+		marketplaceservices.InvalidateVerifiedFarms()
+	}
+
+	farm, err := getFarm(verification.FarmID)
+	if err == nil {
+		title := fmt.Sprintf("%s is now verified", farm.FarmName)
+		message := "Buyers will see the verified badge on your farm and its plots."
+		if !approve {
+			title = fmt.Sprintf("Verification of %s was declined", farm.FarmName)
+			message = note
+		}
+		notificationServices.NotifyAsync(farm.Owner, notificationServices.TypeFarmVerification, title, message,
+			map[string]any{"farmId": farm.ID, "verificationId": verificationID, "status": status})
+	}
+
+	return getVerification(verificationID)
+}
+
+// RevokeFarmVerification removes a farm's verified badge, e.g. after a fraud report
+func RevokeFarmVerification(adminWallet, farmID, note string) error {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return invalidf("a note is required when revoking verification")
+	}
+	if len(note) > maxVerificationNoteLength {
+		return invalidf("note exceeds %d characters", maxVerificationNoteLength)
+	}
+	farm, err := getFarm(farmID)
+	if err != nil {
+		return err
+	}
+	if !farm.Verified {
+		return invalidf("farm is not verified")
+	}
+
+	if _, err := memgraph.ExecuteWrite(`MATCH (f:Farm {id: $farmId})
+		SET f.verified = false, f.verificationRevokedBy = $admin, f.verificationRevokedAt = $now,
+			f.verificationRevokedReason = $note`, map[string]any{
+		"farmId": farmID,
+		"admin":  adminWallet,
+		"now":    time.Now().UnixMilli(),
+		"note":   note,
+	}); err != nil {
+		return fmt.Errorf("failed to revoke verification: %w", err)
+	}
+
+	InvalidateFarmListCache()
+	marketplaceservices.InvalidateVerifiedFarms()
+
+	notificationServices.NotifyAsync(farm.Owner, notificationServices.TypeFarmVerification,
+		fmt.Sprintf("Verification of %s was revoked", farm.FarmName), note,
+		map[string]any{"farmId": farm.ID, "status": "revoked"})
+	return nil
+}
+
+// uploadDocument validates an uploaded verification document (an image or PDF) and
+// stores it on IPFS
+func uploadDocument(parent context.Context, document *FarmImage) (string, error) {
+	if len(document.Data) == 0 {
+		return "", invalidf("document is empty")
+	}
+	if len(document.Data) > maxImageSize {
+		return "", invalidf("document exceeds %d MB", maxImageSize/(1024*1024))
+	}
+	contentType := http.DetectContentType(document.Data)
+	if !strings.HasPrefix(contentType, "image/") && contentType != "application/pdf" {
+		return "", invalidf("document must be an image or PDF")
+	}
+
+	fileName := uuid.NewString() + strings.ToLower(filepath.Ext(document.FileName))
+	ctx, cancel := context.WithTimeout(parent, 2*time.Minute)
+	defer cancel()
+
+	uri, err := utils.UploadPicBuffer(ctx, document.Data, fileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload document: %w", err)
+	}
+	return uri, nil
+}
+
+// getVerification reads one verification request
+func getVerification(verificationID string) (*FarmVerification, error) {
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm)-[:HAS_VERIFICATION]->(v:FarmVerification {id: $id})
+		`+verificationFiles+`
+		`+verificationReturn,
+		map[string]any{"id": verificationID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load verification request: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrVerificationNotFound
+	}
+	verification := verificationFromRecord(records[0])
+	return &verification, nil
+}
+
+// queryVerifications reads verification requests, optionally for one farm and/or one
+// status. A farm's requests come newest first; the admin queue (no farm) oldest first.
+// limit 0 returns them all.
+func queryVerifications(farmID, status string, limit int) ([]FarmVerification, error) {
+	order := "v.submittedAt"
+	if farmID != "" {
+		order = "v.submittedAt DESC"
+	}
+	query := `MATCH (f:Farm)-[:HAS_VERIFICATION]->(v:FarmVerification)
+		WHERE f.deletedAt IS NULL AND ($farmId = '' OR f.id = $farmId) AND ($status = '' OR v.status = $status)
+		` + verificationFiles + ` ORDER BY ` + order
+	if limit > 0 {
+		query += ` LIMIT $limit`
+	}
+	records, err := memgraph.ExecuteRead(query+"\n"+verificationReturn, map[string]any{
+		"farmId": farmID,
+		"status": status,
+		"limit":  limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch verification requests: %w", err)
+	}
+
+	verifications := make([]FarmVerification, 0, len(records))
+	for _, record := range records {
+		verifications = append(verifications, verificationFromRecord(record))
+	}
+	return verifications, nil
+}
+
+// verificationFromRecord maps a verificationReturn row to a FarmVerification
+func verificationFromRecord(record *neo4j.Record) FarmVerification {
+	verification := FarmVerification{
+		ID:          memgraph.String(record, "id"),
+		FarmID:      memgraph.String(record, "farmId"),
+		FarmName:    memgraph.String(record, "farmName"),
+		Status:      memgraph.String(record, "status"),
+		Note:        memgraph.String(record, "note"),
+		SubmittedBy: memgraph.String(record, "submittedBy"),
+		SubmittedAt: memgraph.Int64(record, "submittedAt"),
+		ReviewedBy:  memgraph.String(record, "reviewedBy"),
+		ReviewedAt:  memgraph.Int64(record, "reviewedAt"),
+		ReviewNote:  memgraph.String(record, "reviewNote"),
+		Files:       []VerificationFile{},
+	}
+
+	raw, _ := record.Get("files")
+	items, _ := raw.([]any)
+	for _, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		file := VerificationFile{}
+		file.Kind, _ = fields["kind"].(string)
+		file.URI, _ = fields["uri"].(string)
+		if file.URI != "" {
+			file.URL = portfolioServices.BuildIpfsUri(file.URI)
+		}
+		if v, ok := fields["lat"].(float64); ok {
+			file.Lat = &v
+		}
+		if v, ok := fields["lng"].(float64); ok {
+			file.Lng = &v
+		}
+		if v, ok := fields["distanceKm"].(float64); ok {
+			file.DistanceKm = &v
+		}
+		verification.Files = append(verification.Files, file)
+	}
+	return verification
+}
@@ -0,0 +1,221 @@
+package farmservices
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// weatherGridSize is the coordinate grid (degrees, about 11 km) forecasts are cached
+// per, so nearby farms share one provider request
+const weatherGridSize = 0.1
+
+// ErrFarmNoCoordinates is returned for weather requests on farms without a location
+var ErrFarmNoCoordinates = errors.New("farm has no coordinates")
+
+// CurrentWeather is the current conditions at a farm
+type CurrentWeather struct {
+	Time          string  `json:"time"`
+	Temperature   float64 `json:"temperature"`   // °C
+	Humidity      float64 `json:"humidity"`      // %
+	Precipitation float64 `json:"precipitation"` // mm
+	WindSpeed     float64 `json:"windSpeed"`     // km/h
+	WeatherCode   int     `json:"weatherCode"`   // WMO code
+	Description   string  `json:"description"`
+}
+
+// DailyForecast is one day of a farm's forecast
+type DailyForecast struct {
+	Date               string  `json:"date"`
+	TemperatureMax     float64 `json:"temperatureMax"`     // °C
+	TemperatureMin     float64 `json:"temperatureMin"`     // °C
+	Precipitation      float64 `json:"precipitation"`      // mm
+	RainProbability    float64 `json:"rainProbability"`    // % (daily maximum)
+	Evapotranspiration float64 `json:"evapotranspiration"` // Reference ET0, mm
+	WeatherCode        int     `json:"weatherCode"`
+	Description        string  `json:"description"`
+}
+
+// FarmWeather is the current conditions and 7-day forecast for a farm's coordinates
+type FarmWeather struct {
+	FarmID      string          `json:"farmId"`
+	FarmName    string          `json:"farmName"`
+	Latitude    float64         `json:"latitude"`
+	Longitude   float64         `json:"longitude"`
+	Timezone    string          `json:"timezone"`
+	Current     CurrentWeather  `json:"current"`
+	Daily       []DailyForecast `json:"daily"`
+	LastUpdated int64           `json:"lastUpdated"`
+}
+
+// weatherForecast is the cached, farm-independent part of FarmWeather
+type weatherForecast struct {
+	Latitude    float64         `json:"latitude"`
+	Longitude   float64         `json:"longitude"`
+	Timezone    string          `json:"timezone"`
+	Current     CurrentWeather  `json:"current"`
+	Daily       []DailyForecast `json:"daily"`
+	LastUpdated int64           `json:"lastUpdated"`
+}
+
+// openMeteoResponse is the subset of the Open-Meteo forecast API response used here
+type openMeteoResponse struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+	Current   struct {
+		Time          string  `json:"time"`
+		Temperature   float64 `json:"temperature_2m"`
+		Humidity      float64 `json:"relative_humidity_2m"`
+		Precipitation float64 `json:"precipitation"`
+		WindSpeed     float64 `json:"wind_speed_10m"`
+		WeatherCode   int     `json:"weather_code"`
+	} `json:"current"`
+	Daily struct {
+		Time               []string  `json:"time"`
+		TemperatureMax     []float64 `json:"temperature_2m_max"`
+		TemperatureMin     []float64 `json:"temperature_2m_min"`
+		Precipitation      []float64 `json:"precipitation_sum"`
+		RainProbability    []float64 `json:"precipitation_probability_max"`
+		Evapotranspiration []float64 `json:"et0_fao_evapotranspiration"`
+		WeatherCode        []int     `json:"weather_code"`
+	} `json:"daily"`
+}
+
+// weatherDescriptions names the WMO weather codes returned by Open-Meteo
+var weatherDescriptions = map[int]string{
+	0: "Clear sky", 1: "Mainly clear", 2: "Partly cloudy", 3: "Overcast",
+	45: "Fog", 48: "Depositing rime fog",
+	51: "Light drizzle", 53: "Drizzle", 55: "Dense drizzle",
+	56: "Light freezing drizzle", 57: "Freezing drizzle",
+	61: "Slight rain", 63: "Rain", 65: "Heavy rain",
+	66: "Light freezing rain", 67: "Freezing rain",
+	71: "Slight snow", 73: "Snow", 75: "Heavy snow", 77: "Snow grains",
+	80: "Slight rain showers", 81: "Rain showers", 82: "Violent rain showers",
+	85: "Slight snow showers", 86: "Heavy snow showers",
+	95: "Thunderstorm", 96: "Thunderstorm with slight hail", 99: "Thunderstorm with heavy hail",
+}
+
+// GetFarmWeather returns the current weather and 7-day forecast at a farm's coordinates
+func GetFarmWeather(token, farmID string) (*FarmWeather, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	farm, _, err := authorizeFarm(farmID, username, FarmRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast, err := forecastFor(farm.Coordinates.Lat, farm.Coordinates.Lng)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FarmWeather{
+		FarmID:      farm.ID,
+		FarmName:    farm.FarmName,
+		Latitude:    forecast.Latitude,
+		Longitude:   forecast.Longitude,
+		Timezone:    forecast.Timezone,
+		Current:     forecast.Current,
+		Daily:       forecast.Daily,
+		LastUpdated: forecast.LastUpdated,
+	}, nil
+}
+
+// forecastFor returns the forecast for the grid cell containing lat/lng. Forecasts are
+// cached for 30 minutes per cell; Open-Meteo updates its models hourly.
+func forecastFor(lat, lng float64) (*weatherForecast, error) {
+	if lat == 0 && lng == 0 {
+		return nil, ErrFarmNoCoordinates
+	}
+
+	cellLat := math.Round(lat/weatherGridSize) * weatherGridSize
+	cellLng := math.Round(lng/weatherGridSize) * weatherGridSize
+	cacheKey := fmt.Sprintf("weather:%.1f:%.1f", cellLat, cellLng)
+
+	var cached weatherForecast
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	baseURL := os.Getenv("WEATHER_API_URL")
+	if baseURL == "" {
+		baseURL = "https://api.open-meteo.com/v1/forecast"
+	}
+	url := fmt.Sprintf("%s?latitude=%.4f&longitude=%.4f&timezone=auto&forecast_days=7"+
+		"&current=temperature_2m,relative_humidity_2m,precipitation,wind_speed_10m,weather_code"+
+		"&daily=temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max,et0_fao_evapotranspiration,weather_code",
+		baseURL, cellLat, cellLng)
+
+	status, body, errs := fiber.Get(url).Timeout(10 * time.Second).Bytes()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to fetch weather: %v", errs[0])
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("weather request failed with status %d: %s", status, string(body))
+	}
+
+	var apiResp openMeteoResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode weather: %w", err)
+	}
+
+	forecast := &weatherForecast{
+		Latitude:  apiResp.Latitude,
+		Longitude: apiResp.Longitude,
+		Timezone:  apiResp.Timezone,
+		Current: CurrentWeather{
+			Time:          apiResp.Current.Time,
+			Temperature:   apiResp.Current.Temperature,
+			Humidity:      apiResp.Current.Humidity,
+			Precipitation: apiResp.Current.Precipitation,
+			WindSpeed:     apiResp.Current.WindSpeed,
+			WeatherCode:   apiResp.Current.WeatherCode,
+			Description:   weatherDescriptions[apiResp.Current.WeatherCode],
+		},
+		Daily:       make([]DailyForecast, 0, len(apiResp.Daily.Time)),
+		LastUpdated: time.Now().Unix(),
+	}
+	daily := apiResp.Daily
+	for i, date := range daily.Time {
+		day := DailyForecast{Date: date}
+		if i < len(daily.TemperatureMax) {
+			day.TemperatureMax = daily.TemperatureMax[i]
+		}
+		if i < len(daily.TemperatureMin) {
+			day.TemperatureMin = daily.TemperatureMin[i]
+		}
+		if i < len(daily.Precipitation) {
+			day.Precipitation = daily.Precipitation[i]
+		}
+		if i < len(daily.RainProbability) {
+			day.RainProbability = daily.RainProbability[i]
+		}
+		if i < len(daily.Evapotranspiration) {
+			day.Evapotranspiration = daily.Evapotranspiration[i]
+		}
+		if i < len(daily.WeatherCode) {
+			day.WeatherCode = daily.WeatherCode[i]
+			day.Description = weatherDescriptions[day.WeatherCode]
+		}
+		forecast.Daily = append(forecast.Daily, day)
+	}
+
+	cache.Set(cacheKey, forecast, config.CacheTTL(config.TTLWeather))
+
+	return forecast, nil
+}
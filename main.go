@@ -14,18 +14,25 @@
 package main
 
 import (
+	"context"
+	backupServices "decentragri-app-cx-server/backup.services"
 	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
 	memgraph "decentragri-app-cx-server/db"
+	farmServices "decentragri-app-cx-server/farm.services"
+	marketplaceServices "decentragri-app-cx-server/marketplace.services"
 	"decentragri-app-cx-server/middleware"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	portfolioServices "decentragri-app-cx-server/portfolio.services"
 	"decentragri-app-cx-server/routes"
+	"decentragri-app-cx-server/utils"
+	walletServices "decentragri-app-cx-server/wallet.services"
 	"log"
+	"log/slog"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/joho/godotenv"
 )
 
@@ -35,10 +42,45 @@ func main() {
 	} else {
 		log.Println("Environment variables loaded successfully")
 	}
+	utils.InitLogging()
+
+	shutdownTracing := config.InitTracing()
+	defer shutdownTracing(context.Background())
 
 	memgraph.InitMemGraph()
+	if err := memgraph.RunMigrations(); err != nil {
+		// Signups and farm creation rely on the uniqueness constraints for correctness,
+		// so never serve against a schema missing them; the step is retried on restart
+		log.Fatalf("Graph schema migrations failed: %v", err)
+	}
 	cache.InitRedis()
 
+	// Cache invalidation
+	portfolioServices.RegisterCacheInvalidation()
+	marketplaceServices.RegisterCacheInvalidation()
+	go cache.StartInvalidationRelay()
+
+	// Background workers
+	go walletServices.StartPriceHistoryRecorder()
+	go walletServices.StartBackendWalletMonitor()
+	go marketplaceServices.StartWatchlistMonitor()
+	go marketplaceServices.StartPurchaseReconciler()
+	go marketplaceServices.StartListingExpiryMonitor()
+	go notificationServices.StartDeliveryWorker()
+	go portfolioServices.StartPortfolioSnapshotter()
+	go portfolioServices.StartPortfolioEventRelay()
+	go portfolioServices.StartPortfolioValueWatcher()
+	go farmServices.StartMQTTBridge()
+	go farmServices.StartTaskReminderWorker()
+	go farmServices.StartIrrigationAdvisor()
+	go farmServices.StartPlantScanInterpreter()
+	go farmServices.StartInterpretationNotifier()
+	go farmServices.StartSensorAlertNotifier()
+	go farmServices.PrepareScanSearch()
+	go farmServices.StartSensorHeartbeatMonitor()
+	go memgraph.StartSoftDeletePurger()
+	go backupServices.StartBackupScheduler()
+
 	app := fiber.New(fiber.Config{
 		AppName:      "Decentragri App CX Server", // Application identifier
 		ServerHeader: "Decentragri App CX Server", // HTTP server header
@@ -46,10 +88,14 @@ func main() {
 		Prefork:      false,
 		// Security: Disable server header in production
 		DisableStartupMessage: os.Getenv("NODE_ENV") == "production",
-		// Enable proxy support for proper IP detection behind Nginx
+		// Enable proxy support for proper IP detection behind Nginx. c.IP() reads the
+		// header only from trusted proxies, and X-Real-IP rather than X-Forwarded-For
+		// since the proxy overwrites it with the peer address, while the first
+		// X-Forwarded-For entry is whatever the client sent.
 		EnableTrustedProxyCheck: true,
 		TrustedProxies:          []string{"127.0.0.1", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"},
-		ProxyHeader:             "X-Forwarded-For",
+		ProxyHeader:             "X-Real-IP",
+		EnableIPValidation:      true,
 		// Error handling
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
@@ -57,11 +103,18 @@ func main() {
 				code = e.Code
 			}
 
-			log.Printf("Fiber error (%d): %v", code, err)
+			level := slog.LevelWarn
+			if code >= fiber.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			slog.Log(c.UserContext(), level, "request error", "status", code, "error", err)
+
+			requestID, _ := c.Locals("requestId").(string)
 
 			return c.Status(code).JSON(fiber.Map{
-				"error": "An error occurred processing your request",
-				"code":  code,
+				"error":     "An error occurred processing your request",
+				"code":      code,
+				"requestId": requestID,
 			})
 		},
 	})
@@ -69,50 +122,43 @@ func main() {
 	// Setup security middleware
 	middleware.SetupSecurityMiddleware(app)
 
-	// Configure rate limiting to prevent abuse with proxy-aware IP detection
-	rateLimiter := limiter.New(limiter.Config{
-		Max:        30,              // 30 requests per window
-		Expiration: 1 * time.Minute, // 1 minute window
-		KeyGenerator: func(c *fiber.Ctx) string {
-			// Get real client IP, handling proxy headers
-			clientIP := c.IP()
-
-			// Check for forwarded IP headers (for Nginx proxy)
-			if forwardedFor := c.Get("X-Forwarded-For"); forwardedFor != "" {
-				// X-Forwarded-For can contain multiple IPs: "client, proxy1, proxy2"
-				// Take the first one (original client)
-				if parts := strings.Split(forwardedFor, ","); len(parts) > 0 {
-					clientIP = strings.TrimSpace(parts[0])
-				}
-			} else if realIP := c.Get("X-Real-IP"); realIP != "" {
-				// Alternative header used by some proxies
-				clientIP = realIP
-			}
+	// Give each request a context that ends with it, for database and cache calls
+	app.Use(middleware.RequestContext())
 
-			log.Printf("Rate limiting key for IP: %s", clientIP)
-			return clientIP
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error": "Rate limit exceeded. Please try again later.",
-			})
-		},
-	})
+	// Tag each request with an X-Request-ID for logs, error responses, and Engine/IPFS calls
+	app.Use(middleware.RequestID())
+
+	// Trace each request; database queries run with its context become child spans
+	app.Use(middleware.Tracing())
 
-	// Add CORS middleware with security-focused configuration
+	// Resolve the caller's locale for formatted dates
+	app.Use(middleware.LocaleMiddleware())
 
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     "*", // Environment-driven origins for security
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Dev-Bypass-Token",
-		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
-		AllowCredentials: false, // Enable credentials for authenticated requests
-	}))
+	// Rate limiting per route group, counted in Redis so limits hold across instances.
+	// Each budget defaults to 30 requests a minute and can be tuned with RATE_LIMIT_<NAME>.
+	routeLimiter := func(name string, perUser bool) fiber.Handler {
+		return middleware.RateLimit(middleware.RateLimitConfig{
+			Name:       name,
+			Max:        30,              // 30 requests per window
+			Expiration: 1 * time.Minute, // 1 minute window
+			PerUser:    perUser,
+		})
+	}
 
-	routes.AuthRoutes(app, rateLimiter)
-	routes.PortfolioRoutes(app, rateLimiter)
-	routes.MarketplaceRoutes(app, rateLimiter)
-	routes.WalletRoutes(app, rateLimiter)
-	routes.FarmRoutes(app, rateLimiter)
+	// CORS allowlist from CORS_ALLOWED_ORIGINS; production rejects unlisted origins
+	app.Use(middleware.CORS())
+
+	routes.AuthRoutes(app, routeLimiter("auth", false))
+	routes.PortfolioRoutes(app, routeLimiter("portfolio", false))
+	routes.MarketplaceRoutes(app, routeLimiter("marketplace", false))
+	routes.WalletRoutes(app, routeLimiter("wallet", true))
+	routes.FarmRoutes(app, routeLimiter("farm", false))
+	routes.AdminRoutes(app, routeLimiter("admin", true))
+	routes.NotificationRoutes(app, routeLimiter("notifications", false))
+	routes.InsightsRoutes(app, routeLimiter("insights", false))
+	routes.WebhookRoutes(app)
+	routes.HealthRoutes(app)
+	routes.MetricsRoutes(app)
 
 	// Configure server with environment-driven settings
 	port := os.Getenv("PORT")
@@ -120,8 +166,7 @@ func main() {
 		port = "9085" // Default port
 	}
 
-	log.Printf("Starting HTTP server on port %s...", port)
-	log.Printf("Server endpoints available at: http://localhost:%s", port)
+	slog.Info("starting HTTP server", "port", port, "url", "http://localhost:"+port)
 
 	if err := app.Listen(":" + port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
@@ -0,0 +1,139 @@
+package marketplaceservices
+
+import (
+	memgraph "decentragri-app-cx-server/db"
+	"fmt"
+
+	notificationServices "decentragri-app-cx-server/notification.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// watchAlert identifies the kind of watchlist alert being sent
+type watchAlert int
+
+const (
+	alertPriceChange watchAlert = iota
+	alertExpiring
+	alertSold
+	alertStatusChange
+)
+
+// defaultAlertChannels are used until a user chooses their own
+var defaultAlertChannels = []string{notificationServices.ChannelInApp, notificationServices.ChannelPush}
+
+// alertPreferenceFields returns the Cypher projection of a user's alert preferences,
+// applying defaults for users who never saved any. The variable name is the User node.
+func alertPreferenceFields(user string) string {
+	return fmt.Sprintf(`coalesce(%[1]s.alertPriceChange, true) AS priceChange,
+		coalesce(%[1]s.alertExpiring, true) AS expiring,
+		coalesce(%[1]s.alertSold, true) AS sold,
+		coalesce(%[1]s.alertStatusChange, true) AS statusChange,
+		%[1]s.alertChannels AS channels`, user)
+}
+
+// alertPreferencesFromRecord reads the fields projected by alertPreferenceFields
+func alertPreferencesFromRecord(record *neo4j.Record) AlertPreferences {
+	// The projection coalesces every flag to its default, so a missing value is never read as false
+	prefs := AlertPreferences{
+		PriceChange:  memgraph.Bool(record, "priceChange"),
+		Expiring:     memgraph.Bool(record, "expiring"),
+		Sold:         memgraph.Bool(record, "sold"),
+		StatusChange: memgraph.Bool(record, "statusChange"),
+	}
+	if v, _ := record.Get("channels"); v != nil {
+		channels, _ := v.([]any)
+		for _, channel := range channels {
+			if s, ok := channel.(string); ok {
+				prefs.Channels = append(prefs.Channels, s)
+			}
+		}
+	} else {
+		prefs.Channels = append([]string{}, defaultAlertChannels...)
+	}
+	return prefs
+}
+
+// wants reports whether the preferences enable the given alert
+func (p AlertPreferences) wants(alert watchAlert) bool {
+	switch alert {
+	case alertPriceChange:
+		return p.PriceChange
+	case alertExpiring:
+		return p.Expiring
+	case alertSold:
+		return p.Sold
+	case alertStatusChange:
+		return p.StatusChange
+	}
+	return false
+}
+
+// GetAlertPreferences returns the authenticated user's watchlist alert preferences
+func GetAlertPreferences(token string) (*AlertPreferences, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	query := `MATCH (u:User {username: $username}) RETURN ` + alertPreferenceFields("u")
+	records, err := memgraph.ExecuteRead(query, map[string]any{"username": username})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch alert preferences: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	prefs := alertPreferencesFromRecord(records[0])
+	return &prefs, nil
+}
+
+// UpdateAlertPreferences saves the fields present in the request and returns the result
+func UpdateAlertPreferences(token string, req *UpdateAlertPreferencesRequest) (*AlertPreferences, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	fields := map[string]any{}
+	if req.PriceChange != nil {
+		fields["alertPriceChange"] = *req.PriceChange
+	}
+	if req.Expiring != nil {
+		fields["alertExpiring"] = *req.Expiring
+	}
+	if req.Sold != nil {
+		fields["alertSold"] = *req.Sold
+	}
+	if req.StatusChange != nil {
+		fields["alertStatusChange"] = *req.StatusChange
+	}
+	if req.Channels != nil {
+		channels := make([]string, 0, len(req.Channels))
+		seen := make(map[string]bool)
+		for _, channel := range req.Channels {
+			switch channel {
+			case notificationServices.ChannelInApp, notificationServices.ChannelPush, notificationServices.ChannelEmail:
+			default:
+				return nil, fmt.Errorf("unsupported channel %q (use in_app, push, or email)", channel)
+			}
+			if !seen[channel] {
+				seen[channel] = true
+				channels = append(channels, channel)
+			}
+		}
+		fields["alertChannels"] = channels
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("nothing to update")
+	}
+
+	query := `MATCH (u:User {username: $username}) SET u += $fields`
+	if _, err := memgraph.ExecuteWrite(query, map[string]any{"username": username, "fields": fields}); err != nil {
+		return nil, fmt.Errorf("failed to save alert preferences: %w", err)
+	}
+
+	return GetAlertPreferences(token)
+}
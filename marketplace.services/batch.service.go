@@ -0,0 +1,154 @@
+package marketplaceservices
+
+import (
+	"context"
+	"decentragri-app-cx-server/config"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	tokenServices "decentragri-app-cx-server/token.services"
+)
+
+// maxBatchItems caps how many listings one batch purchase may contain
+const maxBatchItems = 10
+
+// batchEntry tracks a batch item through validation and submission
+type batchEntry struct {
+	listing  *DirectListing
+	purchase *Purchase
+}
+
+// BuyBatch purchases several listings for the authenticated user. Every item is
+// validated first, and the combined cost per currency is checked against the paying
+// wallet's balance and allowance, so an underfunded cart is rejected before anything is
+// bought. Valid items are then submitted to Engine one after another and waited on
+// together; each item's result is reported separately so partial failures are clear.
+func BuyBatch(ctx context.Context, token string, req *BuyBatchRequest) (*BuyBatchResponse, error) {
+	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("no items to purchase")
+	}
+	if len(req.Items) > maxBatchItems {
+		return nil, fmt.Errorf("a batch may contain at most %d items", maxBatchItems)
+	}
+
+	results := make([]BuyBatchItemResult, len(req.Items))
+	entries := make([]*batchEntry, len(req.Items))
+	seen := make(map[string]bool)
+
+	// Validate each item and total the cost per currency
+	totals := make(map[string]*big.Int)
+	currencies := make(map[string]*CurrencyValuePerToken)
+	for i, item := range req.Items {
+		quantity := item.Quantity
+		if quantity == "" {
+			quantity = "1"
+		}
+		results[i] = BuyBatchItemResult{ListingID: item.ListingID, Quantity: quantity}
+
+		if _, err := strconv.ParseUint(item.ListingID, 10, 64); err != nil {
+			results[i].Error = "invalid listing ID"
+			continue
+		}
+		if qty, err := strconv.ParseUint(quantity, 10, 64); err != nil || qty == 0 {
+			results[i].Error = "invalid quantity"
+			continue
+		}
+		if seen[item.ListingID] {
+			results[i].Error = "listing appears more than once in the batch"
+			continue
+		}
+		seen[item.ListingID] = true
+
+		listing, err := GetListing(item.ListingID)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if listing.Status != StatusActive && listing.Status != StatusCreated {
+			results[i].Error = fmt.Sprintf("listing is %s", strings.ToLower(string(listing.Status)))
+			continue
+		}
+		if err := ensureListingVisible(listing.ID); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if err := ensureReservedBuyer(listing, walletAddr); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		total, err := listingTotal(listing, quantity)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		currency := strings.ToLower(listing.CurrencyContractAddress)
+		if totals[currency] == nil {
+			totals[currency] = new(big.Int)
+			currencies[currency] = listing.CurrencyValuePerToken
+		}
+		totals[currency].Add(totals[currency], total)
+		entries[i] = &batchEntry{listing: listing}
+	}
+
+	// Engine pays from the backend wallet, so the whole cart must be covered up front
+	for currency, total := range totals {
+		if err := checkFunds(config.AdminWallet, currency, total, currencies[currency]); err != nil {
+			return nil, err
+		}
+	}
+
+	// Submit sequentially so Engine assigns nonces in cart order
+	response := &BuyBatchResponse{Items: results}
+	for i, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		purchase, err := submitPurchase(ctx, walletAddr, entry.listing, results[i].Quantity)
+		if err != nil {
+			if errors.Is(err, ErrPurchaseUnconfirmed) {
+				response.Unconfirmed++
+			}
+			results[i].Error = err.Error()
+			entries[i] = nil
+			continue
+		}
+		entry.purchase = purchase
+	}
+
+	// Share one confirmation window across the batch
+	deadline := time.Now().Add(buyConfirmWait())
+	for i, entry := range entries {
+		if entry == nil {
+			response.Failed++
+			continue
+		}
+
+		engineStatus := awaitPurchase(entry.purchase, time.Until(deadline))
+		receipt := purchaseReceipt(entry.purchase, engineStatus)
+		results[i].Purchase = receipt
+
+		switch {
+		case entry.purchase.State == PurchaseFailed:
+			results[i].Error = entry.purchase.Error
+			response.Failed++
+		case receipt.Mined():
+			results[i].Success = true
+			response.Succeeded++
+		default:
+			results[i].Success = true
+			response.Pending++
+		}
+	}
+
+	return response, nil
+}
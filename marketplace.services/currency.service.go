@@ -0,0 +1,167 @@
+package marketplaceservices
+
+import (
+	"bytes"
+	"decentragri-app-cx-server/config"
+	"decentragri-app-cx-server/utils"
+	walletServices "decentragri-app-cx-server/wallet.services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInsufficientAllowance is returned when the paying wallet has not approved the marketplace for enough of an ERC20 currency
+	ErrInsufficientAllowance = errors.New("insufficient token allowance for the marketplace")
+	// ErrInsufficientBalance is returned when the paying wallet does not hold enough of the listing currency
+	ErrInsufficientBalance = errors.New("insufficient token balance for this purchase")
+)
+
+// stablecoins are listing currencies pegged to USD. They are shown with cent precision
+// and priced at $1 when the price API has no quote.
+var stablecoins = map[string]bool{
+	strings.ToLower(config.USDCContractAddress): true,
+}
+
+// isNativeCurrency reports whether a listing currency address is the chain's native token
+func isNativeCurrency(currencyAddress string) bool {
+	return currencyAddress == "" || strings.EqualFold(currencyAddress, NativeTokenAddress)
+}
+
+// isStablecoin reports whether a listing currency is a USD stablecoin
+func isStablecoin(currencyAddress string) bool {
+	return stablecoins[strings.ToLower(currencyAddress)]
+}
+
+// listingUnitPrice returns the per-token price of a listing in whole currency units.
+// The raw value is scaled by the currency's own decimals (6 for USDC, 18 for ETH/DAGRI);
+// DisplayValue is only used when the raw value is missing.
+func listingUnitPrice(value *CurrencyValuePerToken) (float64, error) {
+	if value == nil {
+		return 0, fmt.Errorf("listing has no currency information")
+	}
+	if raw, ok := new(big.Int).SetString(value.Value, 10); ok {
+		return strconv.ParseFloat(utils.FormatUnits(raw, value.Decimals), 64)
+	}
+	return strconv.ParseFloat(value.DisplayValue, 64)
+}
+
+// formatListingPrice renders a listing price for display, e.g. "12.50 USDC" or "0.0015 ETH".
+// Stablecoins use two decimals; other currencies keep up to six significant decimals.
+func formatListingPrice(currencyAddress string, value *CurrencyValuePerToken) string {
+	amount, err := listingUnitPrice(value)
+	if err != nil {
+		return ""
+	}
+
+	var formatted string
+	if isStablecoin(currencyAddress) {
+		formatted = strconv.FormatFloat(amount, 'f', 2, 64)
+	} else {
+		formatted = strconv.FormatFloat(amount, 'f', -1, 64)
+		if dot := strings.IndexByte(formatted, '.'); dot >= 0 && len(formatted)-dot-1 > 6 {
+			formatted = strings.TrimRight(strconv.FormatFloat(amount, 'f', 6, 64), "0")
+			formatted = strings.TrimSuffix(formatted, ".")
+		}
+	}
+
+	if value.Symbol == "" {
+		return formatted
+	}
+	return formatted + " " + value.Symbol
+}
+
+// listingTotal returns the cost in base units of buying quantity tokens from a listing
+func listingTotal(listing *DirectListing, quantity string) (*big.Int, error) {
+	if listing.CurrencyValuePerToken == nil {
+		return nil, fmt.Errorf("listing has no currency information")
+	}
+	perToken, ok := new(big.Int).SetString(listing.CurrencyValuePerToken.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid listing price")
+	}
+	qty, ok := new(big.Int).SetString(quantity, 10)
+	if !ok || qty.Sign() <= 0 {
+		qty = big.NewInt(1)
+	}
+	return new(big.Int).Mul(perToken, qty), nil
+}
+
+// checkPurchaseFunds verifies that payer can cover quantity tokens of a listing
+func checkPurchaseFunds(payer string, listing *DirectListing, quantity string) error {
+	total, err := listingTotal(listing, quantity)
+	if err != nil {
+		return err
+	}
+	return checkFunds(payer, listing.CurrencyContractAddress, total, listing.CurrencyValuePerToken)
+}
+
+// checkFunds verifies that payer holds total base units of a currency. For ERC20
+// currencies the marketplace must also be approved to pull the amount; native-currency
+// purchases are paid with the transaction value and need no allowance.
+func checkFunds(payer, currencyAddress string, total *big.Int, currency *CurrencyValuePerToken) error {
+	decimals, symbol := 18, ""
+	if currency != nil {
+		decimals, symbol = currency.Decimals, currency.Symbol
+	}
+
+	if isNativeCurrency(currencyAddress) {
+		balanceResp, err := walletServices.GetBalance(config.CHAIN, payer)
+		if err != nil {
+			return fmt.Errorf("failed to read native balance: %w", err)
+		}
+		balance, ok := new(big.Int).SetString(balanceResp.Result.Value, 10)
+		if !ok {
+			return fmt.Errorf("unexpected native balance: %q", balanceResp.Result.Value)
+		}
+		if balance.Cmp(total) < 0 {
+			return fmt.Errorf("%w: need %s %s, have %s", ErrInsufficientBalance,
+				utils.FormatUnits(total, decimals), symbol, utils.FormatUnits(balance, decimals))
+		}
+		return nil
+	}
+
+	balance, err := readUint(currencyAddress, "balanceOf", payer)
+	if err != nil {
+		return fmt.Errorf("failed to read %s balance: %w", symbol, err)
+	}
+	if balance.Cmp(total) < 0 {
+		return fmt.Errorf("%w: need %s %s, have %s", ErrInsufficientBalance,
+			utils.FormatUnits(total, decimals), symbol, utils.FormatUnits(balance, decimals))
+	}
+
+	allowance, err := readUint(currencyAddress, "allowance", payer+","+config.MarketPlaceContractAddress)
+	if err != nil {
+		return fmt.Errorf("failed to read %s allowance: %w", symbol, err)
+	}
+	if allowance.Cmp(total) < 0 {
+		return fmt.Errorf("%w: need %s %s approved, have %s", ErrInsufficientAllowance,
+			utils.FormatUnits(total, decimals), symbol, utils.FormatUnits(allowance, decimals))
+	}
+
+	return nil
+}
+
+// readUint reads a single uint256 view result from a contract, bypassing the fallback cache
+func readUint(contractAddress, functionName, args string) (*big.Int, error) {
+	raw, err := ReadContract(contractAddress, functionName, args, false)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode %s result: %w", functionName, err)
+	}
+
+	result, ok := new(big.Int).SetString(fmt.Sprint(value), 10)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s result: %s", functionName, string(raw))
+	}
+	return result, nil
+}
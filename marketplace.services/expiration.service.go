@@ -0,0 +1,229 @@
+package marketplaceservices
+
+import (
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/utils"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	notificationServices "decentragri-app-cx-server/notification.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+)
+
+const (
+	// defaultListingDuration is used when relisting a listing whose original duration is unknown
+	defaultListingDuration = 30 * 24 * time.Hour
+	// maxListingDuration caps how far out a relisted listing may end
+	maxListingDuration = 365 * 24 * time.Hour
+)
+
+// dropExpiredListings removes listings whose end time has passed. Listings are cached
+// for minutes, so a listing can expire while its cached copy is still being served.
+func dropExpiredListings(listings FarmPlotDirectListingsResponse) FarmPlotDirectListingsResponse {
+	now := time.Now().Unix()
+	valid := listings[:0]
+	for _, listing := range listings {
+		if listing.EndTimeInSeconds > 0 && listing.EndTimeInSeconds <= now {
+			continue
+		}
+		valid = append(valid, listing)
+	}
+	return valid
+}
+
+// CheckExpiringListings notifies sellers whose listings end within LISTING_EXPIRY_WARNING
+// (default 48h). Each seller is notified once per end time, so extending a listing re-arms
+// the warning.
+func CheckExpiringListings() {
+	window := 48 * time.Hour
+	if v := os.Getenv("LISTING_EXPIRY_WARNING"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			window = parsed
+		}
+	}
+
+	listings, err := GetAllValidFarmPlotListings("", "")
+	if err != nil {
+		slog.Warn("failed to load listings for expiry check", "error", err)
+		return
+	}
+
+	for _, listing := range *listings {
+		endsIn := time.Until(time.Unix(listing.EndTimeInSeconds, 0))
+		if listing.Seller == "" || endsIn <= 0 || endsIn > window {
+			continue
+		}
+
+		// Claim the warning for this end time so repeated checks stay quiet
+		summary, err := memgraph.ExecuteWrite(`MERGE (l:Listing {listingId: $listingId})
+			WITH l
+			WHERE coalesce(l.sellerExpiryAlertedFor, 0) <> $endTime
+			SET l.sellerExpiryAlertedFor = $endTime, l.endTimeInSeconds = $endTime`,
+			map[string]any{"listingId": listing.ID, "endTime": listing.EndTimeInSeconds})
+		if err != nil {
+			slog.Warn("failed to record expiry warning for listing", "listingId", listing.ID, "error", err)
+			continue
+		}
+		if summary == nil || summary.Counters().PropertiesSet() == 0 {
+			continue
+		}
+
+		notificationServices.NotifyAsync(listing.Seller, notificationServices.TypeListingExpiring,
+			"Your listing ends soon",
+			fmt.Sprintf("Your listing for farm plot #%s ends in %s. Relist it to keep it on the marketplace.",
+				listing.TokenID, endsIn.Round(time.Hour)),
+			map[string]any{
+				"listingId":        listing.ID,
+				"tokenId":          listing.TokenID,
+				"endTimeInSeconds": listing.EndTimeInSeconds,
+				"relistUrl":        "/api/marketplace/listings/" + listing.ID + "/relist",
+			})
+	}
+}
+
+// StartListingExpiryMonitor warns sellers about expiring listings on a fixed interval.
+// The interval defaults to 1 hour and can be overridden with LISTING_EXPIRY_CHECK_INTERVAL.
+// It blocks, so run it in a goroutine.
+func StartListingExpiryMonitor() {
+	interval := time.Hour
+	if v := os.Getenv("LISTING_EXPIRY_CHECK_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("listing expiry monitor started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		CheckExpiringListings()
+	}
+}
+
+// RelistListing keeps a seller's plot on the marketplace in one call. Open listings are
+// extended in place; expired or cancelled listings are recreated with the same asset,
+// currency, and quantity. Sold listings cannot be relisted.
+func RelistListing(token, listingID string, req *RelistRequest) (*RelistResponse, error) {
+	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	if _, err := strconv.ParseUint(listingID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid listing ID")
+	}
+
+	listing, err := GetListing(listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(listing.Seller, walletAddr) {
+		return nil, ErrNotListingSeller
+	}
+	if listing.Status == StatusCompleted {
+		return nil, fmt.Errorf("listing has sold and cannot be relisted")
+	}
+
+	duration := time.Duration(req.DurationSeconds) * time.Second
+	if req.DurationSeconds == 0 {
+		duration = time.Duration(listing.EndTimeInSeconds-listing.StartTimeInSeconds) * time.Second
+		if duration <= 0 || duration > maxListingDuration {
+			duration = defaultListingDuration
+		}
+	}
+	if duration <= 0 || duration > maxListingDuration {
+		return nil, fmt.Errorf("duration must be between 1 second and %d days", int(maxListingDuration.Hours()/24))
+	}
+	endTime := time.Now().Add(duration).Unix()
+
+	open := (listing.Status == StatusActive || listing.Status == StatusCreated) &&
+		listing.EndTimeInSeconds > time.Now().Unix()
+	if open {
+		update, err := UpdateListing(token, listingID, &UpdateListingRequest{
+			PricePerToken:    req.PricePerToken,
+			EndTimeInSeconds: endTime,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &RelistResponse{
+			ListingID:        listingID,
+			Action:           "extended",
+			QueueID:          update.QueueID,
+			EndTimeInSeconds: endTime,
+			Message:          "Listing extension queued",
+		}, nil
+	}
+
+	// Recreating the listing requires the seller to still own the plot
+	held, err := HoldsPlot(walletAddr, listing.TokenID, listing.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	if !held {
+		return nil, fmt.Errorf("you no longer own farm plot #%s", listing.TokenID)
+	}
+
+	pricePerToken := listing.PricePerToken
+	if listing.CurrencyValuePerToken != nil && listing.CurrencyValuePerToken.DisplayValue != "" {
+		pricePerToken = listing.CurrencyValuePerToken.DisplayValue
+	}
+	if req.PricePerToken != "" {
+		price, err := strconv.ParseFloat(req.PricePerToken, 64)
+		if err != nil || price <= 0 {
+			return nil, fmt.Errorf("invalid price per token")
+		}
+		pricePerToken = req.PricePerToken
+	}
+
+	url := fmt.Sprintf("%s/marketplace/%s/%s/direct-listings/create-listing",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		config.MarketPlaceContractAddress,
+	)
+
+	reqBody := map[string]any{
+		"assetContractAddress":    listing.AssetContractAddress,
+		"tokenId":                 listing.TokenID,
+		"quantity":                listing.Quantity,
+		"currencyContractAddress": listing.CurrencyContractAddress,
+		"pricePerToken":           pricePerToken,
+		"isReservedListing":       listing.IsReservedListing,
+		"startTimestamp":          time.Now().Unix(),
+		"endTimestamp":            endTime,
+	}
+
+	status, body, err := utils.Engine().Post(url, map[string]string{
+		"Authorization":            "Bearer " + os.Getenv("SECRET_KEY"),
+		"X-Backend-Wallet-Address": walletAddr,
+	}, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
+	var engineResp EngineResponse
+	if err := json.Unmarshal(body, &engineResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	InvalidateListingsCache()
+
+	return &RelistResponse{
+		ListingID:        listingID,
+		Action:           "created",
+		QueueID:          engineResp.Result.QueueID,
+		EndTimeInSeconds: endTime,
+		Message:          "New listing queued",
+	}, nil
+}
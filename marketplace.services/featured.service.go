@@ -0,0 +1,209 @@
+package marketplaceservices
+
+import (
+	memgraph "decentragri-app-cx-server/db"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultFeaturedDuration is the "feature of the week" window
+	defaultFeaturedDuration = 7 * 24 * time.Hour
+	// freshnessHalfLife controls how quickly a listing's freshness score decays
+	freshnessHalfLife = 7 * 24 * time.Hour
+)
+
+// ScheduleFeatured adds a listing to the curated featured queue. The listing must be
+// currently valid; overlapping slots are allowed and the most recently started wins.
+func ScheduleFeatured(adminWallet string, req *SetFeaturedRequest) (*FeaturedSlot, error) {
+	if _, err := strconv.ParseUint(req.ListingID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid listing ID")
+	}
+
+	now := time.Now()
+	startsAt := req.StartsAt
+	if startsAt == 0 {
+		startsAt = now.Unix()
+	}
+	endsAt := req.EndsAt
+	if endsAt == 0 {
+		endsAt = time.Unix(startsAt, 0).Add(defaultFeaturedDuration).Unix()
+	}
+	if endsAt <= startsAt || endsAt <= now.Unix() {
+		return nil, fmt.Errorf("featured window must end in the future and after it starts")
+	}
+
+	listing, err := GetListing(req.ListingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Status != StatusActive && listing.Status != StatusCreated {
+		return nil, fmt.Errorf("listing is %s and cannot be featured", listing.Status)
+	}
+
+	slot := &FeaturedSlot{
+		ID:        uuid.NewString(),
+		ListingID: req.ListingID,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Note:      req.Note,
+		CreatedBy: adminWallet,
+		CreatedAt: now.Unix(),
+	}
+
+	query := `CREATE (:FeaturedSlot {
+		id: $id,
+		listingId: $listingId,
+		startsAt: $startsAt,
+		endsAt: $endsAt,
+		note: $note,
+		createdBy: $createdBy,
+		createdAt: $createdAt
+	})`
+	params := map[string]any{
+		"id":        slot.ID,
+		"listingId": slot.ListingID,
+		"startsAt":  slot.StartsAt,
+		"endsAt":    slot.EndsAt,
+		"note":      slot.Note,
+		"createdBy": slot.CreatedBy,
+		"createdAt": slot.CreatedAt,
+	}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return nil, fmt.Errorf("failed to schedule featured listing: %w", err)
+	}
+
+	return slot, nil
+}
+
+// GetFeaturedSchedule returns current and upcoming featured slots, soonest first
+func GetFeaturedSchedule() ([]FeaturedSlot, error) {
+	query := `MATCH (f:FeaturedSlot)
+		WHERE f.endsAt > $now AND f.deletedAt IS NULL
+		RETURN f.id AS id, f.listingId AS listingId, f.startsAt AS startsAt, f.endsAt AS endsAt,
+			f.note AS note, f.createdBy AS createdBy, f.createdAt AS createdAt
+		ORDER BY f.startsAt ASC`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"now": time.Now().Unix()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch featured schedule: %w", err)
+	}
+
+	slots := make([]FeaturedSlot, 0, len(records))
+	for _, record := range records {
+		slot := FeaturedSlot{
+			ID:        memgraph.String(record, "id"),
+			ListingID: memgraph.String(record, "listingId"),
+			StartsAt:  memgraph.Int64(record, "startsAt"),
+			EndsAt:    memgraph.Int64(record, "endsAt"),
+			Note:      memgraph.String(record, "note"),
+			CreatedBy: memgraph.String(record, "createdBy"),
+			CreatedAt: memgraph.Int64(record, "createdAt"),
+		}
+		slots = append(slots, slot)
+	}
+
+	return slots, nil
+}
+
+// DeleteFeatured removes a slot from the featured queue. The slot is soft-deleted so
+// RestoreFeatured can bring it back until it is purged.
+func DeleteFeatured(adminWallet, slotID string) error {
+	deleted, err := memgraph.SoftDelete("FeaturedSlot", "id", slotID, adminWallet)
+	if err != nil {
+		return fmt.Errorf("failed to delete featured slot: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("featured slot not found")
+	}
+	return nil
+}
+
+// RestoreFeatured puts a deleted slot back in the featured queue
+func RestoreFeatured(slotID string) error {
+	restored, err := memgraph.Restore("FeaturedSlot", "id", slotID)
+	if err != nil {
+		return fmt.Errorf("failed to restore featured slot: %w", err)
+	}
+	if !restored {
+		return fmt.Errorf("deleted featured slot not found")
+	}
+	return nil
+}
+
+// selectFeatured picks the featured listing: the most recently started curated slot
+// whose listing is still valid, or otherwise the best-scoring listing.
+func selectFeatured(listings FarmPlotDirectListingsResponse) int {
+	byID := make(map[string]int, len(listings))
+	for i, listing := range listings {
+		byID[listing.ID] = i
+	}
+
+	query := `MATCH (f:FeaturedSlot)
+		WHERE f.startsAt <= $now AND f.endsAt > $now AND f.deletedAt IS NULL
+		RETURN f.listingId AS listingId
+		ORDER BY f.startsAt DESC`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"now": time.Now().Unix()})
+	if err != nil {
+		slog.Warn("failed to read featured queue, using heuristic", "error", err)
+	} else {
+		for _, record := range records {
+			v, _ := record.Get("listingId")
+			if listingID, ok := v.(string); ok {
+				if idx, ok := byID[listingID]; ok {
+					return idx
+				}
+			}
+		}
+	}
+
+	return bestScoredListing(listings)
+}
+
+// bestScoredListing ranks listings by freshness and price. Freshness decays with a
+// one-week half-life from the listing start time; price favors listings below the
+// median USD price. Listings with images are preferred since they are shown large.
+func bestScoredListing(listings FarmPlotDirectListingsResponse) int {
+	prices := make([]float64, 0, len(listings))
+	for _, listing := range listings {
+		if listing.PriceUSD > 0 {
+			prices = append(prices, listing.PriceUSD)
+		}
+	}
+	median := 0.0
+	if len(prices) > 0 {
+		sort.Float64s(prices)
+		median = prices[len(prices)/2]
+	}
+
+	now := time.Now()
+	best, bestScore := 0, math.Inf(-1)
+	for i, listing := range listings {
+		age := now.Sub(time.Unix(listing.StartTimeInSeconds, 0))
+		if age < 0 {
+			age = 0
+		}
+		freshness := math.Pow(0.5, age.Hours()/freshnessHalfLife.Hours())
+
+		priceScore := 0.5
+		if median > 0 && listing.PriceUSD > 0 {
+			// 1.0 at half the median or below, 0.0 at twice the median or above
+			priceScore = math.Max(0, math.Min(1, 1-math.Log2(listing.PriceUSD/median*2)/2))
+		}
+
+		score := 0.6*freshness + 0.4*priceScore
+		if len(listing.ImageBytes) > 0 {
+			score += 0.1
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	return best
+}
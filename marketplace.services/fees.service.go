@@ -0,0 +1,237 @@
+package marketplaceservices
+
+import (
+	"bytes"
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	"decentragri-app-cx-server/utils"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	tokenServices "decentragri-app-cx-server/token.services"
+)
+
+// defaultBuyGasLimit is used for gas estimates when BUY_GAS_LIMIT is not set.
+// It covers a direct-listing purchase including royalty and platform fee transfers.
+const defaultBuyGasLimit = 300000
+
+// platformFeeInfo is the marketplace contract's platform fee configuration
+type platformFeeInfo struct {
+	Recipient   string `json:"recipient"`
+	BasisPoints int64  `json:"basisPoints"`
+}
+
+// GetListingFees returns the checkout summary for buying quantity tokens from a listing:
+// subtotal, marketplace platform fee, creator royalty, net proceeds for the seller, and
+// an estimate of the gas the buyer pays.
+func GetListingFees(token, listingID, quantity string) (*FeeBreakdown, error) {
+	if _, err := tokenServices.NewTokenService().VerifyAccessToken(token); err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+	if _, err := strconv.ParseUint(listingID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid listing ID")
+	}
+
+	if quantity == "" {
+		quantity = "1"
+	}
+	qty, ok := new(big.Int).SetString(quantity, 10)
+	if !ok || qty.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid quantity")
+	}
+
+	listing, err := GetListing(listingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.CurrencyValuePerToken == nil {
+		return nil, fmt.Errorf("listing has no currency information")
+	}
+	if available, ok := new(big.Int).SetString(listing.Quantity, 10); ok && qty.Cmp(available) > 0 {
+		return nil, fmt.Errorf("only %s tokens available in this listing", listing.Quantity)
+	}
+
+	perToken, ok := new(big.Int).SetString(listing.CurrencyValuePerToken.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid listing price")
+	}
+	decimals := listing.CurrencyValuePerToken.Decimals
+	subtotal := new(big.Int).Mul(perToken, qty)
+
+	prices := make(map[string]float64)
+	currencyUSD := currencyPriceUSD(listing.CurrencyContractAddress, prices)
+	toFeeAmount := func(amount *big.Int, recipient string) FeeAmount {
+		display := utils.FormatUnits(amount, decimals)
+		value, _ := strconv.ParseFloat(display, 64)
+		var bps int64
+		if subtotal.Sign() > 0 {
+			bps = new(big.Int).Div(new(big.Int).Mul(amount, big.NewInt(10000)), subtotal).Int64()
+		}
+		return FeeAmount{
+			Amount:      display,
+			AmountUSD:   value * currencyUSD,
+			BasisPoints: bps,
+			Recipient:   recipient,
+		}
+	}
+
+	// Platform fee is a share of the subtotal configured on the marketplace contract
+	platformFee := new(big.Int)
+	feeInfo, err := getPlatformFeeInfo()
+	if err != nil {
+		slog.Warn("failed to read platform fee info", "error", err)
+		feeInfo = &platformFeeInfo{}
+	}
+	if feeInfo.BasisPoints > 0 {
+		platformFee.Mul(subtotal, big.NewInt(feeInfo.BasisPoints))
+		platformFee.Div(platformFee, big.NewInt(10000))
+	}
+
+	// Royalty comes from the farm plot contract's ERC-2981 royaltyInfo for this sale price
+	royalty := new(big.Int)
+	royaltyRecipient := ""
+	if result, err := ReadContract(listing.AssetContractAddress, "royaltyInfo",
+		listing.TokenID+","+subtotal.String(), true); err != nil {
+		slog.Warn("failed to read royalty info for token", "tokenId", listing.TokenID, "error", err)
+	} else if values, err := decodeTuple(result); err == nil && len(values) >= 2 {
+		royaltyRecipient = values[0]
+		if amount, ok := new(big.Int).SetString(values[1], 10); ok {
+			royalty = amount
+		}
+	}
+
+	netToSeller := new(big.Int).Sub(subtotal, platformFee)
+	netToSeller.Sub(netToSeller, royalty)
+
+	breakdown := &FeeBreakdown{
+		ListingID:      listingID,
+		TokenID:        listing.TokenID,
+		Quantity:       qty.String(),
+		CurrencySymbol: listing.CurrencyValuePerToken.Symbol,
+		Subtotal:       toFeeAmount(subtotal, ""),
+		PlatformFee:    toFeeAmount(platformFee, feeInfo.Recipient),
+		Royalty:        toFeeAmount(royalty, royaltyRecipient),
+		NetToSeller:    toFeeAmount(netToSeller, listing.Seller),
+		Gas:            estimateBuyGas(prices),
+	}
+	breakdown.TotalCostUSD = breakdown.Subtotal.AmountUSD + breakdown.Gas.CostUSD
+
+	return breakdown, nil
+}
+
+// getPlatformFeeInfo reads the marketplace platform fee, cached for an hour since it rarely changes
+func getPlatformFeeInfo() (*platformFeeInfo, error) {
+	cacheKey := fmt.Sprintf("marketplace_platform_fee:%s:%s", config.CHAIN, config.MarketPlaceContractAddress)
+
+	var cached platformFeeInfo
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	result, err := ReadContract(config.MarketPlaceContractAddress, "getPlatformFeeInfo", "", true)
+	if err != nil {
+		return nil, err
+	}
+	values, err := decodeTuple(result)
+	if err != nil || len(values) < 2 {
+		return nil, fmt.Errorf("unexpected getPlatformFeeInfo result: %s", string(result))
+	}
+	bps, err := strconv.ParseInt(values[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid platform fee: %w", err)
+	}
+
+	info := &platformFeeInfo{Recipient: values[0], BasisPoints: bps}
+	cache.Set(cacheKey, info, config.CacheTTL(config.TTLPlatformFees))
+
+	return info, nil
+}
+
+// estimateBuyGas estimates the buyer's gas cost from the current gas price and a fixed
+// gas limit (BUY_GAS_LIMIT). A zero estimate is returned when the gas price is unavailable.
+func estimateBuyGas(prices map[string]float64) GasEstimate {
+	gasLimit := uint64(defaultBuyGasLimit)
+	if v := os.Getenv("BUY_GAS_LIMIT"); v != "" {
+		if parsed, err := strconv.ParseUint(v, 10, 64); err == nil && parsed > 0 {
+			gasLimit = parsed
+		}
+	}
+
+	estimate := GasEstimate{GasLimit: gasLimit, GasPrice: "0", Cost: "0"}
+
+	gasPrice, err := getGasPrice()
+	if err != nil {
+		slog.Warn("failed to fetch gas price", "error", err)
+		return estimate
+	}
+
+	cost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit))
+	estimate.GasPrice = gasPrice.String()
+	estimate.Cost = utils.FormatUnits(cost, 18)
+	costFloat, _ := strconv.ParseFloat(estimate.Cost, 64)
+	estimate.CostUSD = costFloat * currencyPriceUSD(NativeTokenAddress, prices)
+
+	return estimate
+}
+
+// getGasPrice queries eth_gasPrice from the thirdweb RPC for the configured chain
+func getGasPrice() (*big.Int, error) {
+	rpcURL := fmt.Sprintf("https://%s.rpc.thirdweb.com/%s", config.CHAIN, thirdwebClientID())
+
+	status, body, err := utils.Engine().Post(rpcURL, nil, map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_gasPrice",
+		"params":  []any{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("RPC request failed with status %d: %s", status, string(body))
+	}
+
+	var response struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode RPC response: %w", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("RPC error: %s", response.Error.Message)
+	}
+
+	gasPrice, ok := new(big.Int).SetString(strings.TrimPrefix(response.Result, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid gas price: %s", response.Result)
+	}
+	return gasPrice, nil
+}
+
+// decodeTuple decodes a multi-value contract read result into strings, keeping
+// numeric values exact
+func decodeTuple(raw json.RawMessage) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var values []any
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = fmt.Sprint(v)
+	}
+	return result, nil
+}
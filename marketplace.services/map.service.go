@@ -0,0 +1,117 @@
+package marketplaceservices
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	currencyServices "decentragri-app-cx-server/currency.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+)
+
+// maxMapRadiusKm caps radius searches to keep result sets map-sized
+const maxMapRadiusKm = 500
+
+// GetListingMapPins returns valid listings whose farm coordinates fall inside a bounding
+// box, or within a radius of a point (sorted by distance). Pins omit image bytes so the
+// payload stays small enough for map rendering.
+func GetListingMapPins(token string, q ListingMapQuery) ([]ListingMapPin, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	var box *utils.BoundingBox
+	radiusSearch := q.BBox == ""
+	if radiusSearch {
+		if q.RadiusKm <= 0 || q.RadiusKm > maxMapRadiusKm {
+			return nil, fmt.Errorf("provide bbox, or lat, lng and a radius between 0 and %d km", maxMapRadiusKm)
+		}
+		if !utils.ValidCoordinates(q.Lat, q.Lng) {
+			return nil, fmt.Errorf("invalid coordinates")
+		}
+	} else {
+		box, err = utils.ParseBoundingBox(q.BBox)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	currency, err := currencyServices.ResolveCurrency(q.Currency, username)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := currencyServices.GetRate(currency)
+	if err != nil {
+		slog.Warn("failed to get FX rate", "currency", currency, "error", err)
+		rate = 0
+	}
+
+	listings, err := GetAllValidFarmPlotListings("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	pins := make([]ListingMapPin, 0)
+	for _, listing := range filterReservedListings(*listings, username) {
+		attr, ok := listingLocation(listing.Asset)
+		if !ok {
+			continue
+		}
+		lat, lng := attr.Coordinates.Latitude, attr.Coordinates.Longitude
+
+		var distance *float64
+		if radiusSearch {
+			d := utils.HaversineKm(q.Lat, q.Lng, lat, lng)
+			if d > q.RadiusKm {
+				continue
+			}
+			distance = &d
+		} else if !box.Contains(lat, lng) {
+			continue
+		}
+
+		pin := ListingMapPin{
+			ListingID:     listing.ID,
+			TokenID:       listing.TokenID,
+			FarmName:      attr.FarmName,
+			CropType:      attr.CropType,
+			Location:      attr.Location,
+			Lat:           lat,
+			Lng:           lng,
+			PricePerToken: listing.PricePerToken,
+			PriceUSD:      listing.PriceUSD,
+			DistanceKm:    distance,
+		}
+		if listing.CurrencyValuePerToken != nil {
+			pin.PricePerToken = listing.CurrencyValuePerToken.DisplayValue
+			pin.CurrencySymbol = listing.CurrencyValuePerToken.Symbol
+		}
+		if rate > 0 && listing.PriceUSD > 0 {
+			pin.FiatPrice = &currencyServices.FiatAmount{
+				Currency: currency,
+				Amount:   listing.PriceUSD * rate,
+				Rate:     rate,
+			}
+		}
+		pins = append(pins, pin)
+	}
+
+	if radiusSearch {
+		sort.Slice(pins, func(i, j int) bool { return *pins[i].DistanceKm < *pins[j].DistanceKm })
+	}
+
+	return pins, nil
+}
+
+// listingLocation returns the first farm plot attribute carrying usable coordinates
+func listingLocation(asset FarmPlotMetadata) (FarmPlotAttributes, bool) {
+	for _, attr := range asset.Attributes {
+		c := attr.Coordinates
+		if (c.Latitude != 0 || c.Longitude != 0) && utils.ValidCoordinates(c.Latitude, c.Longitude) {
+			return attr, true
+		}
+	}
+	return FarmPlotAttributes{}, false
+}
@@ -1,27 +1,39 @@
 package marketplaceservices
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"decentragri-app-cx-server/config"
+	currencyServices "decentragri-app-cx-server/currency.services"
 	tokenServices "decentragri-app-cx-server/token.services"
-
-	"github.com/gofiber/fiber/v2"
+	"decentragri-app-cx-server/utils"
 )
 
-func GetValidFarmPlotListings(token string) (*FarmPlotDirectListingsResponse, error) {
-	// Check for dev bypass token first
-	if token == "dev_bypass_authorized" {
-		fmt.Println("Dev bypass detected in marketplace service")
-	} else {
-		_, err := tokenServices.NewTokenService().VerifyAccessToken(token)
-		if err != nil {
-			return nil, err
-		}
+// GetValidFarmPlotListings returns all valid farm plot listings with fiat prices.
+// imageSize selects a resized image variant (thumb, small, medium, large); empty or
+// "full" keeps the original images.
+func GetValidFarmPlotListings(token, currency, imageSize string) (*FarmPlotDirectListingsResponse, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+
+	imageSize, err = utils.NormalizeImageSize(imageSize)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use the marketplace contract address to get listings, not the farm plot contract
@@ -30,19 +42,31 @@ func GetValidFarmPlotListings(token string) (*FarmPlotDirectListingsResponse, er
 		return nil, err
 	}
 
+	// Reserved listings are only shown to their seller and approved buyers
+	visible := filterReservedListings(*farmPlotListing, username)
+	farmPlotListing = &visible
+
+	// Fiat prices are computed per request since they depend on the caller's currency
+	if err := ApplyFiatPrices(*farmPlotListing, currency); err != nil {
+		slog.Warn("failed to apply fiat prices", "error", err)
+	}
+
 	// The farmPlotListing already contains ImageBytes populated by GetAllValidFarmPlotListings
+	if imageSize != "" {
+		ApplyImageSize(*farmPlotListing, imageSize)
+	}
 	return farmPlotListing, nil
 }
 
-func FeaturedProperty(token string) (*FarmPlotDirectListingsWithImageByte, error) {
-	// Check for dev bypass token first
-	if token == "dev_bypass_authorized" {
-		fmt.Println("Dev bypass detected in marketplace service")
-	} else {
-		_, err := tokenServices.NewTokenService().VerifyAccessToken(token)
-		if err != nil {
-			return nil, err
-		}
+func FeaturedProperty(token, currency string) (*FarmPlotDirectListingsWithImageByte, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use the marketplace contract address to get listings
@@ -51,30 +75,90 @@ func FeaturedProperty(token string) (*FarmPlotDirectListingsWithImageByte, error
 		return nil, err
 	}
 
+	// Reserved listings are never featured to buyers who cannot purchase them
+	var listings FarmPlotDirectListingsResponse
+	if farmPlotListing != nil {
+		listings = filterReservedListings(*farmPlotListing, username)
+	}
+
 	// Check if there are any listings
-	if farmPlotListing == nil || len(*farmPlotListing) == 0 {
+	if len(listings) == 0 {
 		return nil, fmt.Errorf("no farm plot listings available")
 	}
 
-	// Get a random listing from the array
-	listings := *farmPlotListing
+	// Prefer the admin-curated featured queue, falling back to the scoring heuristic
+	index := selectFeatured(listings)
 
-	// Create a new random generator with a time-based seed
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	randomIndex := rng.Intn(len(listings))
+	featured := listings[index : index+1]
+	if err := ApplyFiatPrices(featured, currency); err != nil {
+		slog.Warn("failed to apply fiat prices", "error", err)
+	}
 
-	return &listings[randomIndex], nil
+	return &featured[0], nil
 }
 
-// BuyFromListing purchases a token from a direct listing
-func BuyFromListing(token string, req *BuyFromListingRequest) (*BuyFromListingResponse, error) {
+// BuyFromListing purchases a token from a direct listing. The request ID carried by ctx
+// is stored on the purchase and forwarded to Engine.
+func BuyFromListing(ctx context.Context, token string, req *BuyFromListingRequest) (*BuyFromListingResponse, error) {
 
 	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
 	if err != nil {
 		return nil, fmt.Errorf("unauthorized: %w", err)
 	}
-	// Set the buyer to the authenticated wallet address
-	req.Buyer = walletAddr
+
+	// Look up the listing so the purchase records what was bought and at what price. The
+	// reserved buyer check below is the only one that tests the user: Engine buys as the
+	// backend wallet, so the contract checks that wallet instead. Without the listing
+	// nothing is bought.
+	listing, err := GetListing(req.ListingID)
+	if err != nil {
+		slog.WarnContext(ctx, "listing lookup failed, not buying", "listingId", req.ListingID, "error", err)
+		return nil, fmt.Errorf("%w: %v", ErrListingUnavailable, err)
+	}
+
+	if err := ensureListingVisible(listing.ID); err != nil {
+		return nil, err
+	}
+	if err := ensureReservedBuyer(listing, walletAddr); err != nil {
+		return nil, err
+	}
+
+	// Engine pays from the backend wallet, so it needs the balance (and, for ERC20 listings such as USDC, the allowance)
+	if err := checkPurchaseFunds(config.AdminWallet, listing, req.Quantity); err != nil {
+		return nil, err
+	}
+
+	purchase, err := submitPurchase(ctx, walletAddr, listing, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait briefly for the transaction to be mined; slow mines are handed back for polling
+	engineStatus := awaitPurchase(purchase, buyConfirmWait())
+	if purchase.State == PurchaseFailed {
+		return nil, fmt.Errorf("purchase failed: %s", purchase.Error)
+	}
+
+	return purchaseReceipt(purchase, engineStatus), nil
+}
+
+// ErrListingUnavailable is returned when a listing cannot be loaded to check a purchase
+var ErrListingUnavailable = errors.New("listing is unavailable right now; try again shortly")
+
+// ErrPurchaseUnconfirmed is returned when a purchase was sent to Engine but Engine did not
+// confirm queueing it, so it may still go through
+var ErrPurchaseUnconfirmed = errors.New("engine did not confirm the purchase; check your purchases before retrying")
+
+// submitPurchase records a purchase and queues the buy-from-listing transaction on Engine
+// for the buyer wallet. The purchase is marked failed if Engine rejects it (4xx). When
+// the outcome is unknown (no response, a 5xx, or an unreadable reply) it returns
+// ErrPurchaseUnconfirmed and leaves the purchase initiated, for the reconciler to fail
+// once it goes stale.
+func submitPurchase(ctx context.Context, buyer string, listing *DirectListing, quantity string) (*Purchase, error) {
+	purchase, err := createPurchase(buyer, listing, quantity, utils.RequestID(ctx))
+	if err != nil {
+		return nil, err
+	}
 
 	// Prepare the request URL
 	url := fmt.Sprintf("%s/marketplace/%s/%s/direct-listings/buy-from-listing",
@@ -83,36 +167,249 @@ func BuyFromListing(token string, req *BuyFromListingRequest) (*BuyFromListingRe
 		config.MarketPlaceContractAddress,
 	)
 
-	// Create the request using Fiber's client
-	fiberReq := fiber.Post(url)
-	fiberReq.Set("Content-Type", "application/json")
-	fiberReq.Set("Authorization", "Bearer "+os.Getenv("SECRET_KEY"))
-	fiberReq.Set("X-Backend-Wallet-Address", config.AdminWallet)
-	fiberReq.JSON(req) // Set JSON body
+	reqBody := BuyFromListingRequest{
+		ListingID: listing.ID,
+		Quantity:  purchase.Quantity,
+		Buyer:     buyer,
+	}
 
-	// Send the request
-	status, body, errs := fiberReq.Bytes()
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("failed to send request: %v", errs[0])
+	// Execute the request through the shared Engine client (timeouts, retries, circuit breaker)
+	status, body, err := utils.Engine().PostContext(ctx, url, map[string]string{
+		"Authorization":            "Bearer " + os.Getenv("SECRET_KEY"),
+		"X-Backend-Wallet-Address": config.AdminWallet,
+	}, reqBody)
+	if err != nil || status >= 500 {
+		slog.WarnContext(ctx, "purchase outcome unknown", "purchaseId", purchase.ID, "status", status, "error", err)
+		return nil, ErrPurchaseUnconfirmed
 	}
 
 	// Check response status
 	if status < 200 || status >= 300 {
+		failPurchase(purchase, fmt.Sprintf("engine rejected purchase with status %d", status))
 		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
 	}
 
 	// Parse the engine response
+	var engineResp EngineResponse
+	if err := json.Unmarshal(body, &engineResp); err != nil || engineResp.Result.QueueID == "" {
+		slog.WarnContext(ctx, "purchase outcome unknown: no queue ID in Engine response", "purchaseId", purchase.ID, "error", err)
+		return nil, ErrPurchaseUnconfirmed
+	}
+	queueID := engineResp.Result.QueueID
+
+	if err := transitionPurchase(purchase, PurchaseQueued, map[string]any{"queueId": queueID}); err != nil {
+		slog.WarnContext(ctx, "failed to mark purchase as queued", "purchaseId", purchase.ID, "queueId", queueID, "error", err)
+	}
+
+	return purchase, nil
+}
+
+// awaitPurchase waits up to timeout for a queued purchase to be mined, applies the
+// result, and returns the last Engine status
+func awaitPurchase(purchase *Purchase, timeout time.Duration) string {
+	tx, err := utils.WaitForTransaction(purchase.QueueID, timeout, 2*time.Second)
+	if err != nil {
+		slog.WarnContext(purchaseContext(purchase), "failed to check purchase", "purchaseId", purchase.ID, "queueId", purchase.QueueID, "error", err)
+		tx = &utils.TransactionStatus{QueueId: purchase.QueueID, Status: "queued"}
+	}
+	applyTransactionStatus(purchase, tx)
+	return tx.Status
+}
+
+// GetPurchaseStatus returns the current state of a purchase made by the authenticated user,
+// reconciling it with Engine first when it has not reached a final state
+func GetPurchaseStatus(token, purchaseID string) (*BuyFromListingResponse, error) {
+	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	purchase, err := getPurchase(purchaseID)
+	if err != nil || !strings.EqualFold(purchase.Buyer, walletAddr) {
+		return nil, fmt.Errorf("purchase not found")
+	}
+
+	engineStatus := reconcilePurchase(purchase)
+
+	return purchaseReceipt(purchase, engineStatus), nil
+}
+
+// ErrNotListingSeller is returned when a user tries to modify a listing they did not create
+var ErrNotListingSeller = errors.New("only the seller can modify this listing")
+
+// GetListing fetches a single direct listing from the marketplace contract.
+// The lookup always goes to Engine so authorization checks see the current seller and status.
+func GetListing(listingID string) (*DirectListing, error) {
+	url := fmt.Sprintf("%s/marketplace/%s/%s/direct-listings/get-listing?listingId=%s",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		config.MarketPlaceContractAddress,
+		listingID,
+	)
+
+	status, body, err := utils.Engine().GetLive(url, map[string]string{
+		"Authorization": "Bearer " + os.Getenv("SECRET_KEY"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
+	var response struct {
+		Result DirectListing `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response.Result, nil
+}
+
+// UpdateListing changes the price, quantity, or end time of an existing direct listing.
+// Only the listing's seller may update it; the update is sent from the seller's smart
+// account so the marketplace contract accepts it.
+func UpdateListing(token, listingID string, req *UpdateListingRequest) (*UpdateListingResponse, error) {
+	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	if _, err := strconv.ParseUint(listingID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid listing ID")
+	}
+	if req.PricePerToken == "" && req.Quantity == "" && req.EndTimeInSeconds == 0 {
+		return nil, fmt.Errorf("nothing to update: provide pricePerToken, quantity, or endTimeInSeconds")
+	}
+
+	listing, err := GetListing(listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(listing.Seller, walletAddr) {
+		return nil, ErrNotListingSeller
+	}
+	if listing.Status != StatusActive && listing.Status != StatusCreated {
+		return nil, fmt.Errorf("listing is %s and can no longer be updated", strings.ToLower(string(listing.Status)))
+	}
+
+	// Start from the current listing values and apply the requested changes
+	pricePerToken := listing.PricePerToken
+	if listing.CurrencyValuePerToken != nil && listing.CurrencyValuePerToken.DisplayValue != "" {
+		pricePerToken = listing.CurrencyValuePerToken.DisplayValue
+	}
+	if req.PricePerToken != "" {
+		price, err := strconv.ParseFloat(req.PricePerToken, 64)
+		if err != nil || price <= 0 {
+			return nil, fmt.Errorf("invalid price per token")
+		}
+		pricePerToken = req.PricePerToken
+	}
+
+	quantity := listing.Quantity
+	if req.Quantity != "" {
+		qty, err := strconv.ParseUint(req.Quantity, 10, 64)
+		if err != nil || qty == 0 {
+			return nil, fmt.Errorf("invalid quantity")
+		}
+		quantity = req.Quantity
+	}
+
+	endTime := listing.EndTimeInSeconds
+	if req.EndTimeInSeconds != 0 {
+		if req.EndTimeInSeconds <= time.Now().Unix() || req.EndTimeInSeconds <= listing.StartTimeInSeconds {
+			return nil, fmt.Errorf("end time must be in the future and after the listing start time")
+		}
+		endTime = req.EndTimeInSeconds
+	}
+
+	url := fmt.Sprintf("%s/marketplace/%s/%s/direct-listings/update-listing",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		config.MarketPlaceContractAddress,
+	)
+
+	reqBody := map[string]any{
+		"listingId": listingID,
+		"listing": map[string]any{
+			"assetContractAddress":    listing.AssetContractAddress,
+			"tokenId":                 listing.TokenID,
+			"quantity":                quantity,
+			"currencyContractAddress": listing.CurrencyContractAddress,
+			"pricePerToken":           pricePerToken,
+			"isReservedListing":       listing.IsReservedListing,
+			"startTimestamp":          listing.StartTimeInSeconds,
+			"endTimestamp":            endTime,
+		},
+	}
+
+	status, body, err := utils.Engine().Post(url, map[string]string{
+		"Authorization":            "Bearer " + os.Getenv("SECRET_KEY"),
+		"X-Backend-Wallet-Address": walletAddr,
+	}, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
 	var engineResp EngineResponse
 	if err := json.Unmarshal(body, &engineResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// For now we'll return immediately after getting the response
-	// In a production environment, you might want to implement transaction mining check here
+	// Drop cached listings so the new price and duration show up on the next fetch
+	InvalidateListingsCache()
 
-	// Create the final response
+	return &UpdateListingResponse{
+		ListingID: listingID,
+		QueueID:   engineResp.Result.QueueID,
+		Message:   "Listing update queued",
+	}, nil
+}
+
+// defaultBuyConfirmWait is how long BuyFromListing waits for mining when BUY_CONFIRM_WAIT is unset
+const defaultBuyConfirmWait = 15 * time.Second
+
+// buyConfirmWait returns the configured mining wait for purchases (BUY_CONFIRM_WAIT)
+func buyConfirmWait() time.Duration {
+	if v := os.Getenv("BUY_CONFIRM_WAIT"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultBuyConfirmWait
+}
+
+// purchaseReceipt builds the client-facing receipt for a purchase
+func purchaseReceipt(purchase *Purchase, engineStatus string) *BuyFromListingResponse {
 	result := &BuyFromListingResponse{
-		Message: "Purchase successful",
+		PurchaseID:  purchase.ID,
+		State:       purchase.State,
+		QueueID:     purchase.QueueID,
+		Status:      engineStatus,
+		TxHash:      purchase.TxHash,
+		BlockNumber: purchase.BlockNumber,
+	}
+
+	switch purchase.State {
+	case PurchaseMined, PurchaseSettled:
+		result.Message = "Purchase successful"
+		result.OnChainStatus = "success"
+	case PurchaseFailed:
+		result.Message = "Purchase failed"
+		if purchase.Error != "" {
+			result.Message = "Purchase failed: " + purchase.Error
+		}
+		if purchase.TxHash != "" {
+			result.OnChainStatus = "reverted"
+		}
+	default:
+		result.Message = "Purchase submitted; awaiting confirmation"
+		result.StatusURL = "/api/marketplace/purchases/" + purchase.ID + "/status"
 	}
-	return result, nil
+
+	return result
 }
@@ -1,6 +1,7 @@
 package marketplaceservices
 
 import (
+	currencyServices "decentragri-app-cx-server/currency.services"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -22,8 +23,56 @@ type EngineResponse struct {
 	} `json:"result"`
 }
 
+// BuyFromListingResponse is the purchase receipt. When the transaction has not been
+// mined within the wait window, clients poll StatusURL until State is final.
 type BuyFromListingResponse struct {
-	Message string `json:"message"`
+	Message       string        `json:"message"`
+	PurchaseID    string        `json:"purchaseId"`
+	State         PurchaseState `json:"state"`
+	QueueID       string        `json:"queueId,omitempty"`
+	Status        string        `json:"status,omitempty"` // Engine status: queued, sent, mined, errored, cancelled
+	TxHash        string        `json:"txHash,omitempty"`
+	BlockNumber   int64         `json:"blockNumber,omitempty"`
+	OnChainStatus string        `json:"onChainStatus,omitempty"` // success or reverted, once mined
+	StatusURL     string        `json:"statusUrl,omitempty"`
+}
+
+// Mined reports whether the purchase has a successful on-chain receipt
+func (r *BuyFromListingResponse) Mined() bool {
+	return r.State == PurchaseMined || r.State == PurchaseSettled
+}
+
+// PurchaseState is a step in the purchase lifecycle:
+// initiated -> queued -> mined -> settled, with failed reachable from any non-final state
+type PurchaseState string
+
+const (
+	PurchaseInitiated PurchaseState = "initiated" // Recorded, not yet accepted by Engine
+	PurchaseQueued    PurchaseState = "queued"    // Accepted by Engine, awaiting mining
+	PurchaseMined     PurchaseState = "mined"     // Transaction mined successfully
+	PurchaseSettled   PurchaseState = "settled"   // Ownership confirmed and sale recorded
+	PurchaseFailed    PurchaseState = "failed"    // Rejected, errored, cancelled, or reverted
+)
+
+// Purchase is a buy-from-listing attempt persisted as a (:Purchase) node
+type Purchase struct {
+	ID                      string        `json:"id"`
+	ListingID               string        `json:"listingId"`
+	TokenID                 string        `json:"tokenId"`
+	Buyer                   string        `json:"buyer"`
+	Seller                  string        `json:"seller,omitempty"`
+	Quantity                string        `json:"quantity"`
+	State                   PurchaseState `json:"state"`
+	QueueID                 string        `json:"queueId,omitempty"`
+	Price                   float64       `json:"price"`
+	CurrencyContractAddress string        `json:"currencyContractAddress"`
+	CurrencySymbol          string        `json:"currencySymbol,omitempty"`
+	TxHash                  string        `json:"txHash,omitempty"`
+	BlockNumber             int64         `json:"blockNumber,omitempty"`
+	Error                   string        `json:"error,omitempty"`
+	RequestID               string        `json:"requestId,omitempty"`
+	CreatedAt               int64         `json:"createdAt"`
+	UpdatedAt               int64         `json:"updatedAt"`
 }
 
 // CurrencyValuePerToken represents the token currency information and value
@@ -75,8 +124,12 @@ func (b ByteArray) MarshalJSON() ([]byte, error) {
 
 type FarmPlotDirectListingsWithImageByte struct {
 	DirectListing
-	Asset      FarmPlotMetadata `json:"asset"`
-	ImageBytes ByteArray        `json:"imageBytes,omitempty"`
+	Asset        FarmPlotMetadata             `json:"asset"`
+	ImageBytes   ByteArray                    `json:"imageBytes,omitempty"`
+	PriceDisplay string                       `json:"priceDisplay,omitempty"` // Per-token price formatted with the currency's decimals, e.g. "12.50 USDC"
+	PriceUSD     float64                      `json:"priceUSD"`               // Price per token in USD, computed when listings are cached
+	FiatPrice    *currencyServices.FiatAmount `json:"fiatPrice,omitempty"`    // Price per token in the requested fiat currency
+	FarmVerified bool                         `json:"farmVerified"`           // The plot's farm passed admin verification
 }
 
 type ListingStatus string
@@ -306,3 +359,316 @@ func (ls *ListingStatus) UnmarshalJSON(data []byte) error {
 	}
 	return fmt.Errorf("invalid ListingStatus: %s", string(data))
 }
+
+// UpdateListingRequest represents the fields a seller may change on an existing listing.
+// Omitted fields keep their current on-chain values.
+type UpdateListingRequest struct {
+	PricePerToken    string `json:"pricePerToken,omitempty"`    // Display value in the listing currency, e.g. "0.25"
+	Quantity         string `json:"quantity,omitempty"`         // Number of tokens offered
+	EndTimeInSeconds int64  `json:"endTimeInSeconds,omitempty"` // New listing end time (unix seconds)
+}
+
+// UpdateListingResponse represents the queued listing update transaction
+type UpdateListingResponse struct {
+	ListingID string `json:"listingId"`
+	QueueID   string `json:"queueId"`
+	Message   string `json:"message"`
+}
+
+// MakeOfferRequest represents a buyer's offer on a farm plot that may not be listed
+type MakeOfferRequest struct {
+	TokenID                 string `json:"tokenId"`
+	TotalPrice              string `json:"totalPrice"`                        // Display value in the offer currency, e.g. "150"
+	CurrencyContractAddress string `json:"currencyContractAddress,omitempty"` // ERC20 used for the offer (defaults to DAGRI)
+	ExpiresInSeconds        int64  `json:"expiresInSeconds,omitempty"`        // Offer lifetime (defaults to 7 days)
+}
+
+// OfferActionRequest identifies an offer to accept or cancel
+type OfferActionRequest struct {
+	OfferID string `json:"offerId"`
+}
+
+// Offer represents an offer on a farm plot as returned by the Engine offers extension
+type Offer struct {
+	ID                      string                 `json:"id"`
+	OfferorAddress          string                 `json:"offerorAddress"`
+	AssetContractAddress    string                 `json:"assetContractAddress"`
+	TokenID                 string                 `json:"tokenId"`
+	Quantity                string                 `json:"quantity"`
+	CurrencyContractAddress string                 `json:"currencyContractAddress"`
+	CurrencyValue           *CurrencyValuePerToken `json:"currencyValue,omitempty"`
+	TotalPrice              string                 `json:"totalPrice"`
+	EndTimeInSeconds        int64                  `json:"endTimeInSeconds"`
+	Status                  ListingStatus          `json:"status"`
+}
+
+// OfferTransactionResponse represents a queued offer transaction
+type OfferTransactionResponse struct {
+	OfferID string `json:"offerId,omitempty"`
+	QueueID string `json:"queueId"`
+	Message string `json:"message"`
+}
+
+// WatchlistItem is a listing the user is watching. Listing is populated while the
+// listing is still valid on the marketplace; otherwise only the last known status is set.
+type WatchlistItem struct {
+	ListingID string                               `json:"listingId"`
+	TokenID   string                               `json:"tokenId"`
+	Status    ListingStatus                        `json:"status"`
+	WatchedAt int64                                `json:"watchedAt"`
+	Listing   *FarmPlotDirectListingsWithImageByte `json:"listing,omitempty"`
+}
+
+// AlertPreferences controls which watchlist alerts a user receives and on which channels
+// (in_app, push, email). Every alert type is enabled by default.
+type AlertPreferences struct {
+	PriceChange  bool     `json:"priceChange"`  // Price of a watched listing changed
+	Expiring     bool     `json:"expiring"`     // Watched listing ends soon
+	Sold         bool     `json:"sold"`         // Watched listing sold
+	StatusChange bool     `json:"statusChange"` // Watched listing was cancelled or expired
+	Channels     []string `json:"channels"`
+}
+
+// UpdateAlertPreferencesRequest changes alert preferences; omitted fields keep their value
+type UpdateAlertPreferencesRequest struct {
+	PriceChange  *bool    `json:"priceChange"`
+	Expiring     *bool    `json:"expiring"`
+	Sold         *bool    `json:"sold"`
+	StatusChange *bool    `json:"statusChange"`
+	Channels     []string `json:"channels"`
+}
+
+// Plot price event types
+const (
+	PriceEventListing = "LISTING"
+	PriceEventSale    = "SALE"
+)
+
+// PlotPriceEvent is a single recorded listing or sale price for a farm plot
+type PlotPriceEvent struct {
+	Type                    string                       `json:"type"` // LISTING or SALE
+	ListingID               string                       `json:"listingId,omitempty"`
+	Price                   float64                      `json:"price"` // Total price in the listing/offer currency
+	CurrencyContractAddress string                       `json:"currencyContractAddress"`
+	CurrencySymbol          string                       `json:"currencySymbol,omitempty"`
+	PriceUSD                float64                      `json:"priceUSD"`
+	FiatPrice               *currencyServices.FiatAmount `json:"fiatPrice,omitempty"`
+	TxHash                  string                       `json:"txHash,omitempty"`
+	Buyer                   string                       `json:"buyer,omitempty"`  // Sales only
+	Seller                  string                       `json:"seller,omitempty"` // Sales only
+	Timestamp               int64                        `json:"timestamp"`
+}
+
+// PlotPriceHistory is the listing and sale history of a farm plot, oldest first
+type PlotPriceHistory struct {
+	TokenID         string           `json:"tokenId"`
+	Currency        string           `json:"currency"`
+	Events          []PlotPriceEvent `json:"events"`
+	SaleCount       int              `json:"saleCount"`
+	LastSaleUSD     float64          `json:"lastSaleUSD,omitempty"`
+	AppreciationPct *float64         `json:"appreciationPct,omitempty"` // First sale to last sale, in USD
+}
+
+// FeeAmount is one line of a checkout fee breakdown
+type FeeAmount struct {
+	Amount      string  `json:"amount"`              // Display value in the listing currency
+	AmountUSD   float64 `json:"amountUSD"`           // USD value (0 when no price is available)
+	BasisPoints int64   `json:"basisPoints"`         // Share of the subtotal in basis points
+	Recipient   string  `json:"recipient,omitempty"` // Address receiving the fee
+}
+
+// GasEstimate is the estimated network cost of a purchase, paid in the native token
+type GasEstimate struct {
+	GasLimit uint64  `json:"gasLimit"`
+	GasPrice string  `json:"gasPrice"` // Wei
+	Cost     string  `json:"cost"`     // Display value in the native token
+	CostUSD  float64 `json:"costUSD"`
+}
+
+// FeeBreakdown is the checkout summary for buying from a listing.
+// Platform fee and royalty are deducted from the seller's proceeds; the buyer pays
+// the subtotal plus gas.
+type FeeBreakdown struct {
+	ListingID      string      `json:"listingId"`
+	TokenID        string      `json:"tokenId"`
+	Quantity       string      `json:"quantity"`
+	CurrencySymbol string      `json:"currencySymbol"`
+	Subtotal       FeeAmount   `json:"subtotal"`
+	PlatformFee    FeeAmount   `json:"platformFee"`
+	Royalty        FeeAmount   `json:"royalty"`
+	NetToSeller    FeeAmount   `json:"netToSeller"`
+	Gas            GasEstimate `json:"gas"`
+	TotalCostUSD   float64     `json:"totalCostUSD"` // Subtotal plus gas, in USD
+}
+
+// SaleEvent is a completed marketplace sale reported by the chain (NewSale event)
+type SaleEvent struct {
+	ListingID      string
+	TokenID        string
+	Buyer          string
+	Seller         string
+	Quantity       string
+	TotalPricePaid string // Base units of the listing currency
+	TxHash         string
+}
+
+// FeaturedSlot schedules a listing as the featured property for a time window
+type FeaturedSlot struct {
+	ID        string `json:"id"`
+	ListingID string `json:"listingId"`
+	StartsAt  int64  `json:"startsAt"` // Unix seconds
+	EndsAt    int64  `json:"endsAt"`   // Unix seconds
+	Note      string `json:"note,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// SetFeaturedRequest is the admin request to schedule a featured listing.
+// StartsAt defaults to now and EndsAt to one week after StartsAt.
+type SetFeaturedRequest struct {
+	ListingID string `json:"listingId"`
+	StartsAt  int64  `json:"startsAt,omitempty"`
+	EndsAt    int64  `json:"endsAt,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+// ListingMapPin is a lightweight listing marker for map rendering (no image bytes)
+type ListingMapPin struct {
+	ListingID      string                       `json:"listingId"`
+	TokenID        string                       `json:"tokenId"`
+	FarmName       string                       `json:"farmName,omitempty"`
+	CropType       string                       `json:"cropType,omitempty"`
+	Location       string                       `json:"location,omitempty"`
+	Lat            float64                      `json:"lat"`
+	Lng            float64                      `json:"lng"`
+	PricePerToken  string                       `json:"pricePerToken"`
+	CurrencySymbol string                       `json:"currencySymbol,omitempty"`
+	PriceUSD       float64                      `json:"priceUSD"`
+	FiatPrice      *currencyServices.FiatAmount `json:"fiatPrice,omitempty"`
+	DistanceKm     *float64                     `json:"distanceKm,omitempty"` // Set for radius searches
+}
+
+// ListingMapQuery selects listings by bounding box or by radius around a point
+type ListingMapQuery struct {
+	BBox     string  // minLng,minLat,maxLng,maxLat
+	Lat      float64 // Radius search center
+	Lng      float64
+	RadiusKm float64
+	Currency string
+}
+
+// ApproveBuyerRequest whitelists a buyer for a reserved listing
+type ApproveBuyerRequest struct {
+	Buyer string `json:"buyer"`
+}
+
+// ReservedBuyersResponse lists the buyers approved for a reserved listing
+type ReservedBuyersResponse struct {
+	ListingID         string   `json:"listingId"`
+	IsReservedListing bool     `json:"isReservedListing"`
+	ApprovedBuyers    []string `json:"approvedBuyers"`
+	QueueID           string   `json:"queueId,omitempty"` // Engine queue ID of the approval/revocation transaction
+}
+
+// BuyBatchItem is one listing in a batch purchase
+type BuyBatchItem struct {
+	ListingID string `json:"listingId"`
+	Quantity  string `json:"quantity"`
+}
+
+// BuyBatchRequest purchases several listings in one call
+type BuyBatchRequest struct {
+	Items []BuyBatchItem `json:"items"`
+}
+
+// BuyBatchItemResult reports the outcome of one item in a batch purchase
+type BuyBatchItemResult struct {
+	ListingID string                  `json:"listingId"`
+	Quantity  string                  `json:"quantity"`
+	Success   bool                    `json:"success"`
+	Error     string                  `json:"error,omitempty"`
+	Purchase  *BuyFromListingResponse `json:"purchase,omitempty"`
+}
+
+// BuyBatchResponse lists per-item results in request order
+type BuyBatchResponse struct {
+	Items       []BuyBatchItemResult `json:"items"`
+	Succeeded   int                  `json:"succeeded"`
+	Failed      int                  `json:"failed"`
+	Pending     int                  `json:"pending"`               // Submitted but not yet mined; poll each purchase's statusUrl
+	Unconfirmed int                  `json:"unconfirmed,omitempty"` // Failed items Engine may still have queued; check purchases before retrying
+}
+
+// RelistRequest relists an expiring or expired listing. DurationSeconds defaults to the
+// listing's original duration; PricePerToken defaults to the current price.
+type RelistRequest struct {
+	DurationSeconds int64  `json:"durationSeconds,omitempty"`
+	PricePerToken   string `json:"pricePerToken,omitempty"` // Display value in the listing currency, e.g. "0.25"
+}
+
+// RelistResponse reports how a listing was relisted: an open listing is extended in
+// place, while an expired or cancelled one is recreated as a new listing.
+type RelistResponse struct {
+	ListingID        string `json:"listingId"`
+	Action           string `json:"action"` // extended or created
+	QueueID          string `json:"queueId"`
+	EndTimeInSeconds int64  `json:"endTimeInSeconds"`
+	Message          string `json:"message"`
+}
+
+// SimilarListing is a listing related to another through shared graph features
+type SimilarListing struct {
+	FarmPlotDirectListingsWithImageByte
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"` // same_crop, same_region, same_price_band, shared_owner
+}
+
+// Listing report reasons
+const (
+	ReportFraud          = "fraud"
+	ReportMiscategorized = "miscategorized"
+	ReportInappropriate  = "inappropriate"
+	ReportOther          = "other"
+)
+
+// ReportListingRequest flags a listing for moderator review
+type ReportListingRequest struct {
+	Reason  string `json:"reason"`            // fraud, miscategorized, inappropriate, or other
+	Details string `json:"details,omitempty"` // Free-text context for moderators
+}
+
+// ListingReport is a single user report stored on a (:Report) node
+type ListingReport struct {
+	ID         string `json:"id"`
+	ListingID  string `json:"listingId"`
+	Reporter   string `json:"reporter"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details,omitempty"`
+	Status     string `json:"status"` // open, actioned, or dismissed
+	CreatedAt  int64  `json:"createdAt"`
+	ResolvedBy string `json:"resolvedBy,omitempty"`
+	ResolvedAt int64  `json:"resolvedAt,omitempty"`
+}
+
+// ModerationQueueItem groups the open reports against one listing
+type ModerationQueueItem struct {
+	ListingID    string          `json:"listingId"`
+	TokenID      string          `json:"tokenId,omitempty"`
+	Hidden       bool            `json:"hidden"`
+	OpenReports  int64           `json:"openReports"`
+	LastReportAt int64           `json:"lastReportAt"`
+	Reports      []ListingReport `json:"reports"`
+}
+
+// ModerationActionRequest carries the moderator's note for hide/unhide/dismiss actions
+type ModerationActionRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// RecommendedListing is a listing suggested from what other buyers purchased, with the
+// signals that surfaced it
+type RecommendedListing struct {
+	FarmPlotDirectListingsWithImageByte
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"` // also_bought, also_bought_region, popular_region
+}
@@ -0,0 +1,289 @@
+package marketplaceservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/utils"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// hiddenListingsCacheKey holds the IDs of listings hidden by moderators
+	hiddenListingsCacheKey = "hidden_listings"
+	// reportAlertThreshold is the number of open reports that pages operators about a listing
+	reportAlertThreshold = 3
+	// maxReportDetails caps the free-text part of a report
+	maxReportDetails = 1000
+)
+
+// ErrListingHidden is returned when a hidden listing is requested for purchase
+var ErrListingHidden = errors.New("this listing has been removed by moderators")
+
+// validReportReasons lists the accepted report reasons
+var validReportReasons = map[string]bool{
+	ReportFraud:          true,
+	ReportMiscategorized: true,
+	ReportInappropriate:  true,
+	ReportOther:          true,
+}
+
+// ReportListing records a user's report against a listing for moderator review.
+// A user has at most one open report per listing; reporting again updates it.
+func ReportListing(token, listingID string, req *ReportListingRequest) (*ListingReport, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	if _, err := strconv.ParseUint(listingID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid listing ID")
+	}
+	reason := strings.ToLower(strings.TrimSpace(req.Reason))
+	if !validReportReasons[reason] {
+		return nil, fmt.Errorf("reason must be one of fraud, miscategorized, inappropriate, or other")
+	}
+	details := strings.TrimSpace(req.Details)
+	if len(details) > maxReportDetails {
+		return nil, fmt.Errorf("details must be at most %d characters", maxReportDetails)
+	}
+
+	listing, err := GetListing(listingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveListingSnapshot(listing); err != nil {
+		return nil, err
+	}
+
+	report := &ListingReport{
+		ID:        uuid.NewString(),
+		ListingID: listingID,
+		Reporter:  username,
+		Reason:    reason,
+		Details:   details,
+		Status:    "open",
+		CreatedAt: time.Now().Unix(),
+	}
+
+	query := `MATCH (u:User {username: $username}), (l:Listing {listingId: $listingId})
+		OPTIONAL MATCH (u)-[:REPORTED]->(existing:Report {status: 'open'})-[:ABOUT]->(l)
+		FOREACH (_ IN CASE WHEN existing IS NULL THEN [1] ELSE [] END |
+			CREATE (u)-[:REPORTED]->(:Report {
+				id: $id,
+				listingId: $listingId,
+				reporter: $username,
+				reason: $reason,
+				details: $details,
+				status: 'open',
+				createdAt: $createdAt
+			})-[:ABOUT]->(l))
+		FOREACH (_ IN CASE WHEN existing IS NULL THEN [] ELSE [1] END |
+			SET existing.reason = $reason, existing.details = $details, existing.createdAt = $createdAt)`
+	params := map[string]any{
+		"username":  username,
+		"listingId": listingID,
+		"id":        report.ID,
+		"reason":    report.Reason,
+		"details":   report.Details,
+		"createdAt": report.CreatedAt,
+	}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return nil, fmt.Errorf("failed to save report: %w", err)
+	}
+
+	// Page operators once a listing collects enough reports
+	records, err := memgraph.ExecuteRead(`MATCH (:Report {status: 'open'})-[:ABOUT]->(:Listing {listingId: $listingId})
+		RETURN count(*) AS openReports`, map[string]any{"listingId": listingID})
+	if err == nil && len(records) > 0 {
+		if memgraph.Int64(records[0], "openReports") == reportAlertThreshold {
+			go utils.SendOperatorAlert("warning", "Listing reported",
+				fmt.Sprintf("Listing %s (farm plot #%s) has %d open reports", listingID, listing.TokenID, reportAlertThreshold),
+				map[string]any{"listingId": listingID, "tokenId": listing.TokenID, "latestReason": reason})
+		}
+	}
+
+	return report, nil
+}
+
+// GetModerationQueue returns listings with reports, most-reported first. status filters
+// reports by open (default), actioned, dismissed, or all.
+func GetModerationQueue(status string) ([]ModerationQueueItem, error) {
+	if status == "" {
+		status = "open"
+	}
+	switch status {
+	case "open", "actioned", "dismissed", "all":
+	default:
+		return nil, fmt.Errorf("status must be open, actioned, dismissed, or all")
+	}
+
+	query := `MATCH (r:Report)-[:ABOUT]->(l:Listing)
+		WHERE $status = 'all' OR r.status = $status
+		RETURN l.listingId AS listingId, l.tokenId AS tokenId, coalesce(l.hidden, false) AS hidden,
+			r.id AS id, r.reporter AS reporter, r.reason AS reason, r.details AS details,
+			r.status AS status, r.createdAt AS createdAt, r.resolvedBy AS resolvedBy, r.resolvedAt AS resolvedAt
+		ORDER BY r.createdAt DESC`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"status": status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch moderation queue: %w", err)
+	}
+
+	items := make([]ModerationQueueItem, 0)
+	index := make(map[string]int)
+	for _, record := range records {
+		report := ListingReport{
+			ID:         memgraph.String(record, "id"),
+			ListingID:  memgraph.String(record, "listingId"),
+			Reporter:   memgraph.String(record, "reporter"),
+			Reason:     memgraph.String(record, "reason"),
+			Details:    memgraph.String(record, "details"),
+			Status:     memgraph.String(record, "status"),
+			CreatedAt:  memgraph.Int64(record, "createdAt"),
+			ResolvedBy: memgraph.String(record, "resolvedBy"),
+			ResolvedAt: memgraph.Int64(record, "resolvedAt"),
+		}
+
+		i, ok := index[report.ListingID]
+		if !ok {
+			item := ModerationQueueItem{
+				ListingID:    report.ListingID,
+				TokenID:      memgraph.String(record, "tokenId"),
+				Hidden:       memgraph.Bool(record, "hidden"),
+				LastReportAt: report.CreatedAt,
+			}
+			items = append(items, item)
+			i = len(items) - 1
+			index[report.ListingID] = i
+		}
+		if report.Status == "open" {
+			items[i].OpenReports++
+		}
+		items[i].Reports = append(items[i].Reports, report)
+	}
+
+	// Most-reported listings first; ties go to the most recent report
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].OpenReports != items[j].OpenReports {
+			return items[i].OpenReports > items[j].OpenReports
+		}
+		return items[i].LastReportAt > items[j].LastReportAt
+	})
+
+	return items, nil
+}
+
+// HideListing pulls a listing from marketplace results without touching the chain and
+// marks its open reports as actioned
+func HideListing(adminWallet, listingID, reason string) error {
+	return setListingHidden(adminWallet, listingID, reason, true)
+}
+
+// UnhideListing restores a hidden listing to marketplace results
+func UnhideListing(adminWallet, listingID, reason string) error {
+	return setListingHidden(adminWallet, listingID, reason, false)
+}
+
+// setListingHidden sets the moderation flag on a listing and refreshes the hidden set
+func setListingHidden(adminWallet, listingID, reason string, hidden bool) error {
+	if _, err := strconv.ParseUint(listingID, 10, 64); err != nil {
+		return fmt.Errorf("invalid listing ID")
+	}
+
+	query := `MERGE (l:Listing {listingId: $listingId})
+		SET l.hidden = $hidden, l.moderatedBy = $admin, l.moderatedAt = $now, l.moderationReason = $reason
+		WITH l
+		OPTIONAL MATCH (r:Report {status: 'open'})-[:ABOUT]->(l)
+		FOREACH (_ IN CASE WHEN $hidden AND r IS NOT NULL THEN [1] ELSE [] END |
+			SET r.status = 'actioned', r.resolvedBy = $admin, r.resolvedAt = $now)`
+	params := map[string]any{
+		"listingId": listingID,
+		"hidden":    hidden,
+		"admin":     adminWallet,
+		"now":       time.Now().Unix(),
+		"reason":    reason,
+	}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return fmt.Errorf("failed to update listing visibility: %w", err)
+	}
+
+	cache.InvalidateKeys(hiddenListingsCacheKey)
+	return nil
+}
+
+// DismissReports closes a listing's open reports without hiding it
+func DismissReports(adminWallet, listingID string) (int64, error) {
+	query := `MATCH (r:Report {status: 'open'})-[:ABOUT]->(:Listing {listingId: $listingId})
+		SET r.status = 'dismissed', r.resolvedBy = $admin, r.resolvedAt = $now`
+	summary, err := memgraph.ExecuteWrite(query, map[string]any{
+		"listingId": listingID,
+		"admin":     adminWallet,
+		"now":       time.Now().Unix(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to dismiss reports: %w", err)
+	}
+	if summary == nil {
+		return 0, nil
+	}
+	// Three properties are set per dismissed report
+	return int64(summary.Counters().PropertiesSet() / 3), nil
+}
+
+// hiddenListingIDs returns the set of listings hidden by moderators, cached briefly in Redis
+func hiddenListingIDs() map[string]bool {
+	var ids []string
+	if err := cache.Get(hiddenListingsCacheKey, &ids); err != nil {
+		records, err := memgraph.ExecuteRead(`MATCH (l:Listing) WHERE l.hidden = true RETURN l.listingId AS listingId`, nil)
+		if err != nil {
+			slog.Warn("failed to load hidden listings", "error", err)
+			return nil
+		}
+		ids = make([]string, 0, len(records))
+		for _, record := range records {
+			if id := memgraph.String(record, "listingId"); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		cache.Set(hiddenListingsCacheKey, ids, config.CacheTTL(config.TTLListingFlags))
+	}
+
+	hidden := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		hidden[id] = true
+	}
+	return hidden
+}
+
+// dropHiddenListings removes listings hidden by moderators
+func dropHiddenListings(listings FarmPlotDirectListingsResponse) FarmPlotDirectListingsResponse {
+	hidden := hiddenListingIDs()
+	if len(hidden) == 0 {
+		return listings
+	}
+	visible := listings[:0]
+	for _, listing := range listings {
+		if !hidden[listing.ID] {
+			visible = append(visible, listing)
+		}
+	}
+	return visible
+}
+
+// ensureListingVisible rejects purchases of listings hidden by moderators
+func ensureListingVisible(listingID string) error {
+	if hiddenListingIDs()[listingID] {
+		return ErrListingHidden
+	}
+	return nil
+}
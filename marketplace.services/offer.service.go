@@ -0,0 +1,306 @@
+package marketplaceservices
+
+import (
+	"decentragri-app-cx-server/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	notificationServices "decentragri-app-cx-server/notification.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+)
+
+const (
+	// defaultOfferLifetime is used when the buyer does not pick an expiry
+	defaultOfferLifetime = 7 * 24 * time.Hour
+	// maxOfferLifetime caps how long an offer may stay open
+	maxOfferLifetime = 90 * 24 * time.Hour
+)
+
+var (
+	// ErrNotPlotOwner is returned when a user tries to accept an offer on a plot they do not own
+	ErrNotPlotOwner = errors.New("only the plot owner can accept this offer")
+	// ErrNotOfferor is returned when a user tries to cancel an offer they did not make
+	ErrNotOfferor = errors.New("only the offeror can cancel this offer")
+)
+
+// MakeOffer places an offer on a farm plot from the buyer's smart account.
+// Offers are escrow-free: the marketplace pulls the ERC20 amount from the buyer when
+// the owner accepts, so the buyer must have approved the marketplace for the currency.
+// The current plot owner is notified of the new offer.
+func MakeOffer(token string, req *MakeOfferRequest) (*OfferTransactionResponse, error) {
+	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	if _, err := strconv.ParseUint(req.TokenID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid token ID")
+	}
+	price, err := strconv.ParseFloat(req.TotalPrice, 64)
+	if err != nil || price <= 0 {
+		return nil, fmt.Errorf("invalid total price")
+	}
+
+	currencyAddress := req.CurrencyContractAddress
+	if currencyAddress == "" {
+		currencyAddress = config.DAGRIContractAddress
+	}
+	if !utils.ValidateContractAddress(currencyAddress) || strings.EqualFold(currencyAddress, NativeTokenAddress) {
+		return nil, fmt.Errorf("offers must use an ERC20 currency")
+	}
+
+	lifetime := defaultOfferLifetime
+	if req.ExpiresInSeconds != 0 {
+		lifetime = time.Duration(req.ExpiresInSeconds) * time.Second
+		if lifetime <= 0 || lifetime > maxOfferLifetime {
+			return nil, fmt.Errorf("offer expiry must be between 1 second and %d days", int(maxOfferLifetime.Hours()/24))
+		}
+	}
+	endTime := time.Now().Add(lifetime).Unix()
+
+	held, err := HoldsPlot(walletAddr, req.TokenID, "")
+	if err != nil {
+		return nil, err
+	}
+	if held {
+		return nil, fmt.Errorf("cannot make an offer on your own plot")
+	}
+
+	reqBody := map[string]any{
+		"assetContractAddress":    config.FarmPlotContractAddress,
+		"tokenId":                 req.TokenID,
+		"quantity":                "1",
+		"currencyContractAddress": currencyAddress,
+		"totalPrice":              req.TotalPrice,
+		"endTimestamp":            endTime,
+	}
+
+	queueID, err := postOfferTransaction("make-offer", walletAddr, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plots are ERC1155 and may have several holders; notify the ones on record
+	for _, holder := range plotHolders(req.TokenID) {
+		notificationServices.NotifyAsync(holder, notificationServices.TypeOfferReceived,
+			"New offer on your farm plot",
+			fmt.Sprintf("You received an offer of %s for farm plot #%s", req.TotalPrice, req.TokenID),
+			map[string]any{
+				"tokenId":                 req.TokenID,
+				"offeror":                 walletAddr,
+				"totalPrice":              req.TotalPrice,
+				"currencyContractAddress": currencyAddress,
+				"endTimeInSeconds":        endTime,
+				"queueId":                 queueID,
+			})
+	}
+
+	return &OfferTransactionResponse{
+		QueueID: queueID,
+		Message: "Offer queued",
+	}, nil
+}
+
+// AcceptOffer accepts an offer on a farm plot owned by the authenticated user.
+// The plot is transferred from the owner's smart account and the offeror is notified.
+func AcceptOffer(token, offerID string) (*OfferTransactionResponse, error) {
+	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	offer, err := getActiveOffer(offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := HoldsPlot(walletAddr, offer.TokenID, offer.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	if !held {
+		return nil, ErrNotPlotOwner
+	}
+
+	queueID, err := postOfferTransaction("accept-offer", walletAddr, map[string]any{"offerId": offerID})
+	if err != nil {
+		return nil, err
+	}
+
+	sale := PlotPriceEvent{
+		CurrencyContractAddress: offer.CurrencyContractAddress,
+		Buyer:                   offer.OfferorAddress,
+		Seller:                  walletAddr,
+	}
+	if offer.CurrencyValue != nil {
+		sale.CurrencySymbol = offer.CurrencyValue.Symbol
+		sale.Price, _ = strconv.ParseFloat(offer.CurrencyValue.DisplayValue, 64)
+	}
+	go RecordSaleWhenMined(queueID, sale, offer.TokenID)
+
+	notificationServices.NotifyAsync(offer.OfferorAddress, notificationServices.TypeOfferAccepted,
+		"Your offer was accepted",
+		fmt.Sprintf("Your offer on farm plot #%s was accepted", offer.TokenID),
+		map[string]any{
+			"offerId": offerID,
+			"tokenId": offer.TokenID,
+			"queueId": queueID,
+		})
+
+	// Ownership changes invalidate cached listings for this plot
+	InvalidateListingsCache()
+
+	return &OfferTransactionResponse{
+		OfferID: offerID,
+		QueueID: queueID,
+		Message: "Offer acceptance queued",
+	}, nil
+}
+
+// CancelOffer cancels an open offer made by the authenticated user
+func CancelOffer(token, offerID string) (*OfferTransactionResponse, error) {
+	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	offer, err := getActiveOffer(offerID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(offer.OfferorAddress, walletAddr) {
+		return nil, ErrNotOfferor
+	}
+
+	queueID, err := postOfferTransaction("cancel-offer", walletAddr, map[string]any{"offerId": offerID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OfferTransactionResponse{
+		OfferID: offerID,
+		QueueID: queueID,
+		Message: "Offer cancellation queued",
+	}, nil
+}
+
+// GetPlotOffers returns the valid (open, unexpired) offers on a farm plot
+func GetPlotOffers(token, tokenID string) ([]Offer, error) {
+	if _, err := tokenServices.NewTokenService().VerifyAccessToken(token); err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+	if _, err := strconv.ParseUint(tokenID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid token ID")
+	}
+
+	endpoint := fmt.Sprintf("%s/marketplace/%s/%s/offers/get-all-valid?tokenId=%s",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		config.MarketPlaceContractAddress,
+		url.QueryEscape(tokenID),
+	)
+
+	status, body, err := utils.Engine().Get(endpoint, map[string]string{
+		"Authorization": "Bearer " + os.Getenv("SECRET_KEY"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
+	var response struct {
+		Result []Offer `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// The offers extension is shared by all assets; keep only farm plot offers
+	offers := make([]Offer, 0, len(response.Result))
+	for _, offer := range response.Result {
+		if strings.EqualFold(offer.AssetContractAddress, config.FarmPlotContractAddress) {
+			offers = append(offers, offer)
+		}
+	}
+
+	return offers, nil
+}
+
+// getActiveOffer fetches an offer and checks it can still be accepted or cancelled
+func getActiveOffer(offerID string) (*Offer, error) {
+	if _, err := strconv.ParseUint(offerID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid offer ID")
+	}
+
+	endpoint := fmt.Sprintf("%s/marketplace/%s/%s/offers/get-offer?offerId=%s",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		config.MarketPlaceContractAddress,
+		offerID,
+	)
+
+	status, body, err := utils.Engine().GetLive(endpoint, map[string]string{
+		"Authorization": "Bearer " + os.Getenv("SECRET_KEY"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
+	var response struct {
+		Result Offer `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	offer := &response.Result
+	if offer.Status != StatusActive && offer.Status != StatusCreated {
+		return nil, fmt.Errorf("offer is %s", strings.ToLower(string(offer.Status)))
+	}
+	if offer.EndTimeInSeconds > 0 && offer.EndTimeInSeconds <= time.Now().Unix() {
+		return nil, fmt.Errorf("offer has expired")
+	}
+
+	return offer, nil
+}
+
+// postOfferTransaction sends an offers-extension write from the user's smart account
+// and returns the Engine queue ID
+func postOfferTransaction(action, walletAddr string, reqBody any) (string, error) {
+	endpoint := fmt.Sprintf("%s/marketplace/%s/%s/offers/%s",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		config.MarketPlaceContractAddress,
+		action,
+	)
+
+	status, body, err := utils.Engine().Post(endpoint, map[string]string{
+		"Authorization":            "Bearer " + os.Getenv("SECRET_KEY"),
+		"X-Backend-Wallet-Address": walletAddr,
+	}, reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return "", fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
+	var engineResp EngineResponse
+	if err := json.Unmarshal(body, &engineResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return engineResp.Result.QueueID, nil
+}
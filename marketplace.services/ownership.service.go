@@ -0,0 +1,107 @@
+package marketplaceservices
+
+import (
+	memgraph "decentragri-app-cx-server/db"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxConcurrentOwnerChecks limits parallel balanceOf reads against Engine
+const maxConcurrentOwnerChecks = 10
+
+// staleListing describes a listing that no longer reflects on-chain state
+type staleListing struct {
+	ListingID string
+	Reason    string
+}
+
+// validateListingOwnership drops listings that can no longer be bought: the listing is
+// not CREATED/ACTIVE, or the seller no longer holds the listed quantity of the plot
+// (transferred out-of-band). Listings whose seller balance cannot be read are kept, so
+// an Engine hiccup does not empty the marketplace.
+// Dropped listings are marked stale on their (:Listing) node.
+func validateListingOwnership(listings []FarmPlotDirectListing) []FarmPlotDirectListing {
+	keep := make([]bool, len(listings))
+	var (
+		stale []staleListing
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+	)
+	semaphore := make(chan struct{}, maxConcurrentOwnerChecks)
+
+	for i := range listings {
+		listing := &listings[i]
+		if listing.Status != StatusActive && listing.Status != StatusCreated {
+			mu.Lock()
+			stale = append(stale, staleListing{ListingID: listing.ID, Reason: "status " + strings.ToLower(string(listing.Status))})
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(idx int, listing *FarmPlotDirectListing) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			held, err := HoldsPlot(listing.Seller, listing.TokenID, listing.Quantity)
+			if err != nil {
+				slog.Warn("could not verify owner of plot for listing", "tokenId", listing.TokenID, "listingId", listing.ID, "error", err)
+				keep[idx] = true
+				return
+			}
+			if held {
+				keep[idx] = true
+				return
+			}
+
+			mu.Lock()
+			stale = append(stale, staleListing{ListingID: listing.ID, Reason: "seller no longer owns the plot"})
+			mu.Unlock()
+		}(i, listing)
+	}
+	wg.Wait()
+
+	valid := make([]FarmPlotDirectListing, 0, len(listings))
+	validIDs := make([]string, 0, len(listings))
+	for i, listing := range listings {
+		if keep[i] {
+			valid = append(valid, listing)
+			validIDs = append(validIDs, listing.ID)
+		}
+	}
+
+	if len(stale) > 0 {
+		slog.Info("excluding stale listings from marketplace results", "count", len(stale))
+	}
+	go markStaleListings(stale, validIDs)
+
+	return valid
+}
+
+// markStaleListings flags stale listings and clears the flag on listings that validated again
+func markStaleListings(stale []staleListing, validIDs []string) {
+	if len(stale) > 0 {
+		items := make([]map[string]any, len(stale))
+		for i, s := range stale {
+			items[i] = map[string]any{"listingId": s.ListingID, "reason": s.Reason}
+		}
+		query := `UNWIND $items AS item
+			MERGE (l:Listing {listingId: item.listingId})
+			SET l.stale = true, l.staleReason = item.reason, l.staleAt = $now`
+		if _, err := memgraph.ExecuteWrite(query, map[string]any{"items": items, "now": time.Now().Unix()}); err != nil {
+			slog.Warn("failed to mark stale listings", "error", err)
+		}
+	}
+
+	if len(validIDs) > 0 {
+		query := `MATCH (l:Listing)
+			WHERE l.listingId IN $listingIds AND l.stale = true
+			SET l.stale = false`
+		if _, err := memgraph.ExecuteWrite(query, map[string]any{"listingIds": validIDs}); err != nil {
+			slog.Warn("failed to clear stale listings", "error", err)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package marketplaceservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/utils"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	currencyServices "decentragri-app-cx-server/currency.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	walletServices "decentragri-app-cx-server/wallet.services"
+)
+
+const (
+	// saleConfirmPollInterval is how often a queued purchase is checked before its sale is recorded
+	saleConfirmPollInterval = 5 * time.Second
+	// saleConfirmTimeout bounds how long a queued purchase is watched
+	saleConfirmTimeout = 10 * time.Minute
+	// listingPriceMemoTTL is how long a recorded listing price is remembered, so cache
+	// misses within it skip rewriting the same event
+	listingPriceMemoTTL = 24 * time.Hour
+)
+
+// RecordListingPrices stores the asking price of each listing as a (:PlotPriceEvent).
+// Events are keyed by listing and price, so re-reading the same listing is a no-op
+// while a price update on the listing adds a new point. Listing and price pairs already
+// recorded are remembered in Redis and skipped, so a listings cache miss only writes
+// new or repriced listings. Each event carries the name of the farm the plot belongs
+// to, which ties the plot's sales to the farm timeline.
+func RecordListingPrices(listings []FarmPlotDirectListing) {
+	memoKeys := make([]string, len(listings))
+	for i, listing := range listings {
+		memoKeys[i] = listingPriceMemoKey(listing.ID)
+	}
+	recorded, err := cache.GetBytesMulti(memoKeys)
+	if err != nil {
+		recorded = nil
+	}
+
+	prices := make(map[string]float64)
+	rows := make([]map[string]any, 0, len(listings))
+	memo := make(map[string][]byte, len(listings))
+	now := time.Now().Unix()
+	for i, listing := range listings {
+		perToken, err := listingUnitPrice(listing.CurrencyValuePerToken)
+		if err != nil {
+			continue
+		}
+		quantity, err := strconv.ParseFloat(listing.Quantity, 64)
+		if err != nil || quantity <= 0 {
+			quantity = 1
+		}
+		price := perToken * quantity
+
+		priceKey := []byte(strconv.FormatFloat(price, 'g', -1, 64))
+		if last, ok := recorded[memoKeys[i]]; ok && string(last) == string(priceKey) {
+			continue
+		}
+		memo[memoKeys[i]] = priceKey
+
+		rows = append(rows, map[string]any{
+			"farmName":                listingFarmName(listing.Asset),
+			"listingId":               listing.ID,
+			"price":                   price,
+			"tokenId":                 listing.TokenID,
+			"currencyContractAddress": listing.CurrencyContractAddress,
+			"currencySymbol":          listing.CurrencyValuePerToken.Symbol,
+			"priceUSD":                price * currencyPriceUSD(listing.CurrencyContractAddress, prices),
+		})
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	query := `MERGE (e:PlotPriceEvent {type: $type, listingId: row.listingId, price: row.price})
+		ON CREATE SET e.tokenId = row.tokenId,
+			e.currencyContractAddress = row.currencyContractAddress,
+			e.currencySymbol = row.currencySymbol,
+			e.priceUSD = row.priceUSD,
+			e.timestamp = $timestamp
+		SET e.farmName = CASE WHEN row.farmName = '' THEN e.farmName ELSE row.farmName END`
+	if _, err := memgraph.BulkWrite(query, rows, map[string]any{"type": PriceEventListing, "timestamp": now}); err != nil {
+		slog.Warn("failed to record listing prices", "error", err)
+		return
+	}
+	for key, price := range memo {
+		cache.SetBytes(key, price, listingPriceMemoTTL)
+	}
+}
+
+// listingPriceMemoKey is the Redis key remembering the last price recorded for a listing
+func listingPriceMemoKey(listingID string) string {
+	return "plot_price_listing:" + listingID
+}
+
+// listingFarmName returns the farm name carried by a plot's metadata, or ""
+func listingFarmName(asset FarmPlotMetadata) string {
+	for _, attr := range asset.Attributes {
+		if name := strings.TrimSpace(attr.FarmName); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// RecordSaleWhenMined waits for a queued purchase to be mined and then records the sale.
+// The status is read from the Engine Cloud instance that queued it. Failed or cancelled
+// transactions are dropped. It blocks, so run it in a goroutine.
+func RecordSaleWhenMined(queueID string, sale PlotPriceEvent, tokenID string) {
+	if queueID == "" {
+		return
+	}
+
+	tx, err := utils.WaitForTransaction(queueID, saleConfirmTimeout, saleConfirmPollInterval)
+	if err != nil || tx.Status != "mined" {
+		if err == nil && tx.Status != "errored" && tx.Status != "cancelled" {
+			slog.Warn("purchase not mined in time; sale not recorded", "queueId", queueID, "timeout", saleConfirmTimeout)
+		}
+		return
+	}
+
+	sale.TxHash = tx.TxHash
+	cache.InvalidatePlotOwnership(sale.Buyer, sale.Seller)
+	if err := recordSale(tokenID, sale); err != nil {
+		slog.Warn("failed to record sale", "tokenId", tokenID, "queueId", queueID, "error", err)
+	}
+}
+
+// listingSaleEvent builds the sale event for buying quantity tokens from a listing
+func listingSaleEvent(listing *DirectListing, quantity string) PlotPriceEvent {
+	sale := PlotPriceEvent{
+		ListingID:               listing.ID,
+		CurrencyContractAddress: listing.CurrencyContractAddress,
+		Seller:                  listing.Seller,
+	}
+	if listing.CurrencyValuePerToken != nil {
+		sale.CurrencySymbol = listing.CurrencyValuePerToken.Symbol
+		perToken, _ := listingUnitPrice(listing.CurrencyValuePerToken)
+		qty, err := strconv.ParseFloat(quantity, 64)
+		if err != nil || qty <= 0 {
+			qty = 1
+		}
+		sale.Price = perToken * qty
+	}
+	return sale
+}
+
+// recordSale stores a completed sale as a (:PlotPriceEvent). Sales are keyed by
+// transaction hash, so the purchase flow and the Engine webhook can both report the
+// same sale without duplicating it; whichever reports a buyer or seller first fills it in.
+func recordSale(tokenID string, sale PlotPriceEvent) error {
+	if sale.TxHash == "" {
+		return fmt.Errorf("cannot record sale for plot %s without a transaction hash", tokenID)
+	}
+
+	query := `MERGE (e:PlotPriceEvent {type: $type, txHash: $txHash})
+		ON CREATE SET e.tokenId = $tokenId,
+			e.listingId = $listingId,
+			e.price = $price,
+			e.currencyContractAddress = $currencyContractAddress,
+			e.currencySymbol = $currencySymbol,
+			e.priceUSD = $priceUSD,
+			e.timestamp = $timestamp
+		SET e.buyer = CASE WHEN e.buyer IS NULL AND $buyer <> '' THEN $buyer ELSE e.buyer END,
+			e.seller = CASE WHEN e.seller IS NULL AND $seller <> '' THEN $seller ELSE e.seller END`
+	params := map[string]any{
+		"type":                    PriceEventSale,
+		"tokenId":                 tokenID,
+		"listingId":               sale.ListingID,
+		"price":                   sale.Price,
+		"currencyContractAddress": sale.CurrencyContractAddress,
+		"currencySymbol":          sale.CurrencySymbol,
+		"priceUSD":                sale.Price * currencyPriceUSD(sale.CurrencyContractAddress, nil),
+		"txHash":                  sale.TxHash,
+		"buyer":                   sale.Buyer,
+		"seller":                  sale.Seller,
+		"timestamp":               time.Now().Unix(),
+	}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return fmt.Errorf("failed to record sale for plot %s: %w", tokenID, err)
+	}
+	return nil
+}
+
+// GetPlotPriceHistory returns every recorded listing and sale price for a farm plot,
+// converted into the caller's fiat currency, with appreciation between the first and last sale.
+func GetPlotPriceHistory(token, tokenID, currency string) (*PlotPriceHistory, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+	if _, err := strconv.ParseUint(tokenID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid token ID")
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := currencyServices.GetRate(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `MATCH (e:PlotPriceEvent {tokenId: $tokenId})
+		RETURN e.type AS type, e.listingId AS listingId, e.price AS price,
+			e.currencyContractAddress AS currencyContractAddress, e.currencySymbol AS currencySymbol,
+			e.priceUSD AS priceUSD, e.txHash AS txHash, e.timestamp AS timestamp
+		ORDER BY e.timestamp ASC`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"tokenId": tokenID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch plot price history: %w", err)
+	}
+
+	history := &PlotPriceHistory{
+		TokenID:  tokenID,
+		Currency: currency,
+		Events:   make([]PlotPriceEvent, 0, len(records)),
+	}
+
+	var firstSaleUSD float64
+	for _, record := range records {
+		event := PlotPriceEvent{}
+		if v, ok := record.Get("type"); ok && v != nil {
+			event.Type = v.(string)
+		}
+		if v, ok := record.Get("listingId"); ok && v != nil {
+			event.ListingID = v.(string)
+		}
+		if v, ok := record.Get("price"); ok && v != nil {
+			event.Price = v.(float64)
+		}
+		if v, ok := record.Get("currencyContractAddress"); ok && v != nil {
+			event.CurrencyContractAddress = v.(string)
+		}
+		if v, ok := record.Get("currencySymbol"); ok && v != nil {
+			event.CurrencySymbol = v.(string)
+		}
+		if v, ok := record.Get("priceUSD"); ok && v != nil {
+			event.PriceUSD = v.(float64)
+		}
+		if v, ok := record.Get("txHash"); ok && v != nil {
+			event.TxHash = v.(string)
+		}
+		if v, ok := record.Get("timestamp"); ok && v != nil {
+			event.Timestamp = v.(int64)
+		}
+
+		if event.PriceUSD > 0 {
+			event.FiatPrice = &currencyServices.FiatAmount{
+				Currency: currency,
+				Amount:   event.PriceUSD * rate,
+				Rate:     rate,
+			}
+		}
+
+		if event.Type == PriceEventSale && event.PriceUSD > 0 {
+			if history.SaleCount == 0 {
+				firstSaleUSD = event.PriceUSD
+			}
+			history.SaleCount++
+			history.LastSaleUSD = event.PriceUSD
+		}
+
+		history.Events = append(history.Events, event)
+	}
+
+	if history.SaleCount > 1 && firstSaleUSD > 0 {
+		pct := (history.LastSaleUSD - firstSaleUSD) / firstSaleUSD * 100
+		history.AppreciationPct = &pct
+	}
+
+	return history, nil
+}
+
+// currencyPriceUSD returns the USD price of one unit of a listing currency, or 0 when
+// unavailable. Prices are memoized in the given map when one is provided.
+func currencyPriceUSD(currencyAddress string, memo map[string]float64) float64 {
+	currencyAddress = strings.ToLower(currencyAddress)
+	if currencyAddress == "" {
+		currencyAddress = NativeTokenAddress
+	}
+	if memo != nil {
+		if price, ok := memo[currencyAddress]; ok {
+			return price
+		}
+	}
+
+	chainInt, err := strconv.Atoi(config.CHAIN)
+	if err != nil {
+		return 0
+	}
+	price, err := walletServices.GetTokenPriceUSD(chainInt, currencyAddress)
+	if err != nil {
+		slog.Warn("failed to fetch price for currency", "currency", currencyAddress, "error", err)
+		price = 0
+	}
+	if price == 0 && isStablecoin(currencyAddress) {
+		price = 1
+	}
+	if memo != nil {
+		memo[currencyAddress] = price
+	}
+	return price
+}
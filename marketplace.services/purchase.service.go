@@ -0,0 +1,397 @@
+package marketplaceservices
+
+import (
+	"context"
+	"decentragri-app-cx-server/cache"
+	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/utils"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	portfolioServices "decentragri-app-cx-server/portfolio.services"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// stalePurchaseTimeout fails purchases that never got an Engine queue ID (e.g. a crash mid-request)
+	stalePurchaseTimeout = 10 * time.Minute
+	// settleGracePeriod settles mined purchases even if the buyer has already moved the plot on
+	settleGracePeriod = 10 * time.Minute
+	// reconcileBatchSize bounds how many open purchases one reconciler pass handles
+	reconcileBatchSize = 100
+)
+
+// purchaseTransitions lists the states each purchase state may move to
+var purchaseTransitions = map[PurchaseState][]PurchaseState{
+	PurchaseInitiated: {PurchaseQueued, PurchaseFailed},
+	PurchaseQueued:    {PurchaseMined, PurchaseFailed},
+	PurchaseMined:     {PurchaseSettled},
+}
+
+// createPurchase records a new purchase in the initiated state, linked to the buyer and
+// listing. requestID is the ID of the request that made it, for tracing it through logs.
+func createPurchase(buyer string, listing *DirectListing, quantity, requestID string) (*Purchase, error) {
+	if quantity == "" {
+		quantity = "1"
+	}
+
+	sale := listingSaleEvent(listing, quantity)
+	now := time.Now().Unix()
+	purchase := &Purchase{
+		ID:                      uuid.NewString(),
+		ListingID:               listing.ID,
+		TokenID:                 listing.TokenID,
+		Buyer:                   buyer,
+		Seller:                  sale.Seller,
+		Quantity:                quantity,
+		State:                   PurchaseInitiated,
+		Price:                   sale.Price,
+		CurrencyContractAddress: sale.CurrencyContractAddress,
+		CurrencySymbol:          sale.CurrencySymbol,
+		RequestID:               requestID,
+		CreatedAt:               now,
+		UpdatedAt:               now,
+	}
+
+	query := `MERGE (l:Listing {listingId: $listingId})
+		CREATE (p:Purchase {
+			id: $id,
+			listingId: $listingId,
+			tokenId: $tokenId,
+			buyer: $buyer,
+			seller: $seller,
+			quantity: $quantity,
+			state: $state,
+			price: $price,
+			currencyContractAddress: $currencyContractAddress,
+			currencySymbol: $currencySymbol,
+			requestId: $requestId,
+			createdAt: $createdAt,
+			updatedAt: $updatedAt
+		})-[:FOR_LISTING]->(l)
+		WITH p
+		OPTIONAL MATCH (u:User {username: $buyer})
+		FOREACH (_ IN CASE WHEN u IS NULL THEN [] ELSE [1] END | CREATE (u)-[:MADE_PURCHASE]->(p))`
+	params := map[string]any{
+		"id":                      purchase.ID,
+		"listingId":               purchase.ListingID,
+		"tokenId":                 purchase.TokenID,
+		"buyer":                   purchase.Buyer,
+		"seller":                  purchase.Seller,
+		"quantity":                purchase.Quantity,
+		"state":                   string(purchase.State),
+		"price":                   purchase.Price,
+		"currencyContractAddress": purchase.CurrencyContractAddress,
+		"currencySymbol":          purchase.CurrencySymbol,
+		"requestId":               purchase.RequestID,
+		"createdAt":               purchase.CreatedAt,
+		"updatedAt":               purchase.UpdatedAt,
+	}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return nil, fmt.Errorf("failed to record purchase: %w", err)
+	}
+
+	return purchase, nil
+}
+
+// transitionPurchase moves a purchase to a new state and sets the given fields. The
+// update only applies if the stored state still matches, so the request path and the
+// reconciler cannot overwrite each other.
+func transitionPurchase(purchase *Purchase, to PurchaseState, fields map[string]any) error {
+	allowed := false
+	for _, next := range purchaseTransitions[purchase.State] {
+		if next == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("invalid purchase transition %s -> %s", purchase.State, to)
+	}
+
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	fields["state"] = string(to)
+	fields["updatedAt"] = time.Now().Unix()
+
+	query := `MATCH (p:Purchase {id: $id})
+		WHERE p.state = $from
+		SET p += $fields`
+	params := map[string]any{
+		"id":     purchase.ID,
+		"from":   string(purchase.State),
+		"fields": fields,
+	}
+	summary, err := memgraph.ExecuteWrite(query, params)
+	if err != nil {
+		return fmt.Errorf("failed to update purchase %s: %w", purchase.ID, err)
+	}
+	if summary != nil && summary.Counters().PropertiesSet() == 0 {
+		return fmt.Errorf("purchase %s is no longer %s", purchase.ID, purchase.State)
+	}
+
+	purchase.State = to
+	purchase.UpdatedAt = fields["updatedAt"].(int64)
+	if v, ok := fields["queueId"].(string); ok {
+		purchase.QueueID = v
+	}
+	if v, ok := fields["txHash"].(string); ok {
+		purchase.TxHash = v
+	}
+	if v, ok := fields["blockNumber"].(int64); ok {
+		purchase.BlockNumber = v
+	}
+	if v, ok := fields["error"].(string); ok {
+		purchase.Error = v
+	}
+	return nil
+}
+
+// failPurchase moves a purchase to failed with a reason, logging any update error
+func failPurchase(purchase *Purchase, reason string) {
+	if err := transitionPurchase(purchase, PurchaseFailed, map[string]any{"error": reason}); err != nil {
+		slog.WarnContext(purchaseContext(purchase), "failed to mark purchase as failed", "purchaseId", purchase.ID, "error", err)
+	}
+}
+
+// applyTransactionStatus advances a queued purchase from an Engine transaction status
+func applyTransactionStatus(purchase *Purchase, tx *utils.TransactionStatus) {
+	if purchase.State != PurchaseQueued || tx == nil {
+		return
+	}
+
+	switch tx.Status {
+	case "mined":
+		fields := map[string]any{"txHash": tx.TxHash, "blockNumber": tx.BlockNumber}
+		if tx.OnChainTxStatus == 0 {
+			fields["error"] = "transaction reverted"
+			if err := transitionPurchase(purchase, PurchaseFailed, fields); err != nil {
+				slog.WarnContext(purchaseContext(purchase), "failed to mark reverted purchase as failed", "purchaseId", purchase.ID, "error", err)
+			}
+			return
+		}
+		if err := transitionPurchase(purchase, PurchaseMined, fields); err != nil {
+			slog.WarnContext(purchaseContext(purchase), "failed to mark purchase as mined", "purchaseId", purchase.ID, "error", err)
+			return
+		}
+		cache.InvalidatePlotOwnership(purchase.Buyer, purchase.Seller)
+		portfolioServices.PublishPortfolioEvent(purchase.Buyer, portfolioServices.EventNFTReceived, map[string]any{
+			"tokenId":    purchase.TokenID,
+			"listingId":  purchase.ListingID,
+			"purchaseId": purchase.ID,
+			"txHash":     purchase.TxHash,
+		})
+	case "errored", "cancelled":
+		reason := tx.ErrorMessage
+		if reason == "" {
+			reason = "transaction " + tx.Status
+		}
+		failPurchase(purchase, reason)
+	}
+}
+
+// settlePurchase confirms a mined purchase once the buyer owns the plot, records the sale
+// price, and moves it to settled
+func settlePurchase(purchase *Purchase) {
+	if purchase.State != PurchaseMined {
+		return
+	}
+	if purchase.TokenID == "" {
+		if err := completePurchaseListing(purchase); err != nil {
+			slog.WarnContext(purchaseContext(purchase), "failed to complete purchase listing", "purchaseId", purchase.ID, "error", err)
+			return
+		}
+	}
+
+	held, err := HoldsPlot(purchase.Buyer, purchase.TokenID, "")
+	if err != nil {
+		slog.WarnContext(purchaseContext(purchase), "failed to confirm ownership for purchase", "purchaseId", purchase.ID, "error", err)
+		return
+	}
+	if !held && time.Since(time.Unix(purchase.UpdatedAt, 0)) < settleGracePeriod {
+		// Ownership not visible yet; try again on the next pass
+		return
+	}
+
+	if err := recordSale(purchase.TokenID, PlotPriceEvent{
+		ListingID:               purchase.ListingID,
+		Price:                   purchase.Price,
+		CurrencyContractAddress: purchase.CurrencyContractAddress,
+		CurrencySymbol:          purchase.CurrencySymbol,
+		TxHash:                  purchase.TxHash,
+		Buyer:                   purchase.Buyer,
+		Seller:                  purchase.Seller,
+	}); err != nil {
+		slog.WarnContext(purchaseContext(purchase), "failed to record sale", "purchaseId", purchase.ID, "tokenId", purchase.TokenID, "error", err)
+	}
+
+	recordPlotOwner(purchase.Buyer, purchase.TokenID)
+
+	if err := transitionPurchase(purchase, PurchaseSettled, nil); err != nil {
+		slog.WarnContext(purchaseContext(purchase), "failed to mark purchase as settled", "purchaseId", purchase.ID, "error", err)
+	}
+}
+
+// reconcilePurchase advances a non-final purchase as far as Engine and the chain allow
+// and returns the latest Engine status (empty when Engine was not consulted)
+func reconcilePurchase(purchase *Purchase) string {
+	engineStatus := ""
+
+	switch purchase.State {
+	case PurchaseInitiated:
+		if time.Since(time.Unix(purchase.CreatedAt, 0)) > stalePurchaseTimeout {
+			failPurchase(purchase, "purchase was never queued")
+		}
+	case PurchaseQueued:
+		tx, err := utils.GetTransactionStatus(purchase.QueueID)
+		if err != nil {
+			slog.WarnContext(purchaseContext(purchase), "failed to check purchase", "purchaseId", purchase.ID, "queueId", purchase.QueueID, "error", err)
+			return engineStatus
+		}
+		engineStatus = tx.Status
+		applyTransactionStatus(purchase, tx)
+	}
+
+	if purchase.State == PurchaseMined {
+		settlePurchase(purchase)
+	}
+
+	return engineStatus
+}
+
+// completePurchaseListing fills in the plot, seller, and price of a purchase made while
+// its listing could not be looked up, so it can be settled and its sale recorded
+func completePurchaseListing(purchase *Purchase) error {
+	listing, err := GetListing(purchase.ListingID)
+	if err != nil {
+		return fmt.Errorf("failed to look up listing of purchase %s: %w", purchase.ID, err)
+	}
+
+	sale := listingSaleEvent(listing, purchase.Quantity)
+	query := `MATCH (p:Purchase {id: $id})
+		SET p.tokenId = $tokenId,
+			p.seller = $seller,
+			p.price = $price,
+			p.currencyContractAddress = $currencyContractAddress,
+			p.currencySymbol = $currencySymbol`
+	params := map[string]any{
+		"id":                      purchase.ID,
+		"tokenId":                 listing.TokenID,
+		"seller":                  sale.Seller,
+		"price":                   sale.Price,
+		"currencyContractAddress": sale.CurrencyContractAddress,
+		"currencySymbol":          sale.CurrencySymbol,
+	}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return fmt.Errorf("failed to update purchase %s: %w", purchase.ID, err)
+	}
+
+	purchase.TokenID = listing.TokenID
+	purchase.Seller = sale.Seller
+	purchase.Price = sale.Price
+	purchase.CurrencyContractAddress = sale.CurrencyContractAddress
+	purchase.CurrencySymbol = sale.CurrencySymbol
+	return nil
+}
+
+// purchaseContext carries the ID of the request that made a purchase, so reconciler
+// logs about it can be matched to that request
+func purchaseContext(purchase *Purchase) context.Context {
+	return utils.WithRequestID(context.Background(), purchase.RequestID)
+}
+
+// getPurchase loads a purchase by ID
+func getPurchase(purchaseID string) (*Purchase, error) {
+	query := `MATCH (p:Purchase {id: $id}) RETURN p`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"id": purchaseID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch purchase: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("purchase not found")
+	}
+
+	return purchaseFromRecord(records[0].Values[0])
+}
+
+// purchaseFromRecord converts a returned (:Purchase) node into a Purchase
+func purchaseFromRecord(value any) (*Purchase, error) {
+	node, ok := value.(interface{ GetProperties() map[string]any })
+	if !ok {
+		return nil, fmt.Errorf("unexpected purchase record")
+	}
+	props := node.GetProperties()
+
+	purchase := &Purchase{
+		ID:                      memgraph.PropString(props, "id"),
+		ListingID:               memgraph.PropString(props, "listingId"),
+		TokenID:                 memgraph.PropString(props, "tokenId"),
+		Buyer:                   memgraph.PropString(props, "buyer"),
+		Seller:                  memgraph.PropString(props, "seller"),
+		Quantity:                memgraph.PropString(props, "quantity"),
+		State:                   PurchaseState(memgraph.PropString(props, "state")),
+		QueueID:                 memgraph.PropString(props, "queueId"),
+		CurrencyContractAddress: memgraph.PropString(props, "currencyContractAddress"),
+		CurrencySymbol:          memgraph.PropString(props, "currencySymbol"),
+		TxHash:                  memgraph.PropString(props, "txHash"),
+		BlockNumber:             memgraph.PropInt64(props, "blockNumber"),
+		Error:                   memgraph.PropString(props, "error"),
+		RequestID:               memgraph.PropString(props, "requestId"),
+		CreatedAt:               memgraph.PropInt64(props, "createdAt"),
+		UpdatedAt:               memgraph.PropInt64(props, "updatedAt"),
+	}
+	if v, ok := memgraph.PropFloat64(props, "price"); ok {
+		purchase.Price = v
+	}
+
+	return purchase, nil
+}
+
+// ReconcilePurchases advances every open purchase: queued purchases are checked against
+// Engine, mined purchases are settled, and purchases stuck in initiated are failed.
+func ReconcilePurchases() {
+	query := `MATCH (p:Purchase)
+		WHERE p.state IN ['initiated', 'queued', 'mined']
+		RETURN p
+		ORDER BY p.updatedAt ASC
+		LIMIT $limit`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"limit": reconcileBatchSize})
+	if err != nil {
+		slog.Warn("failed to load open purchases", "error", err)
+		return
+	}
+
+	for _, record := range records {
+		purchase, err := purchaseFromRecord(record.Values[0])
+		if err != nil {
+			slog.Warn("skipping unreadable purchase", "error", err)
+			continue
+		}
+		reconcilePurchase(purchase)
+	}
+}
+
+// StartPurchaseReconciler reconciles open purchases on a fixed interval.
+// The interval defaults to 1 minute and can be overridden with PURCHASE_RECONCILE_INTERVAL.
+// It blocks, so run it in a goroutine.
+func StartPurchaseReconciler() {
+	interval := 1 * time.Minute
+	if v := os.Getenv("PURCHASE_RECONCILE_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("purchase reconciler started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ReconcilePurchases()
+	}
+}
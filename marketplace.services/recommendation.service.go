@@ -0,0 +1,217 @@
+package marketplaceservices
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	currencyServices "decentragri-app-cx-server/currency.services"
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+)
+
+const (
+	// defaultRecommendedListings is how many recommendations are returned by default
+	defaultRecommendedListings = 10
+	// maxRecommendedListings caps the recommendations response
+	maxRecommendedListings = 20
+	// popularRegionCount is how many of the most purchased regions seed cold-start recommendations
+	popularRegionCount = 3
+)
+
+// recommendationWeights scores each buyer who connects a candidate listing to the seed
+// regions, per signal
+var recommendationWeights = map[string]int{
+	"also_bought":        3,
+	"also_bought_region": 1,
+}
+
+// peerBuyersMatch binds peer to every other user with a settled purchase in a seed region
+const peerBuyersMatch = `MATCH (r:Region)<-[:IN_REGION]-(:Listing)<-[:FOR_LISTING]-(:Purchase {state: $settled})<-[:MADE_PURCHASE]-(peer:User)
+	WHERE r.name IN $regions AND peer.username <> $username
+	WITH DISTINCT peer
+	`
+
+// recommendationQueries find listings connected to the seed regions through other buyers,
+// with the number of such buyers as weight
+var recommendationQueries = map[string]string{
+	// Plots those buyers also bought that are listed again
+	"also_bought": peerBuyersMatch + `MATCH (peer)-[:MADE_PURCHASE]->(:Purchase {state: $settled})-[:FOR_LISTING]->(:Listing)-[:OF_PLOT]->(:Plot)<-[:OF_PLOT]-(l:Listing)
+		RETURN l.listingId AS listingId, count(DISTINCT peer) AS weight`,
+	// Listings in the other regions those buyers bought in
+	"also_bought_region": peerBuyersMatch + `MATCH (peer)-[:MADE_PURCHASE]->(:Purchase {state: $settled})-[:FOR_LISTING]->(:Listing)-[:IN_REGION]->(other:Region)<-[:IN_REGION]-(l:Listing)
+		WHERE NOT other.name IN $regions
+		RETURN l.listingId AS listingId, count(DISTINCT peer) AS weight`,
+}
+
+// listingScores holds graph scores and reasons per listing ID
+type listingScores struct {
+	Scores  map[string]int      `json:"scores"`
+	Reasons map[string][]string `json:"reasons"`
+}
+
+// add credits a listing with score for reason
+func (s *listingScores) add(listingID, reason string, score int) {
+	s.Scores[listingID] += score
+	s.Reasons[listingID] = append(s.Reasons[listingID], reason)
+}
+
+// GetRecommendedListings suggests valid listings from what buyers in the same regions
+// also bought: plots they bought that are listed again, and listings in the other regions
+// they bought in. The seed regions are region when given, else the regions of the
+// caller's settled purchases. Callers with no purchases get listings from the most
+// purchased regions. The caller's own listings are left out.
+func GetRecommendedListings(token, region, currency, imageSize string, limit int) ([]RecommendedListing, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+	imageSize, err = utils.NormalizeImageSize(imageSize)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultRecommendedListings
+	}
+	if limit > maxRecommendedListings {
+		limit = maxRecommendedListings
+	}
+	region = strings.ToLower(strings.TrimSpace(region))
+
+	scores, err := recommendationScores(username, region)
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := GetAllValidFarmPlotListings("", "")
+	if err != nil {
+		return nil, err
+	}
+	visible := filterReservedListings(*listings, username)
+
+	byID := make(map[string]*FarmPlotDirectListingsWithImageByte, len(visible))
+	for i := range visible {
+		if strings.EqualFold(visible[i].Seller, username) {
+			continue
+		}
+		byID[visible[i].ID] = &visible[i]
+	}
+
+	ids := make([]string, 0, len(scores.Scores))
+	for id := range scores.Scores {
+		if byID[id] != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores.Scores[ids[i]] != scores.Scores[ids[j]] {
+			return scores.Scores[ids[i]] > scores.Scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	related := make(FarmPlotDirectListingsResponse, len(ids))
+	for i, id := range ids {
+		related[i] = *byID[id]
+	}
+	if err := ApplyFiatPrices(related, currency); err != nil {
+		slog.Warn("failed to apply fiat prices", "error", err)
+	}
+	if imageSize != "" {
+		ApplyImageSize(related, imageSize)
+	}
+
+	result := make([]RecommendedListing, len(related))
+	for i := range related {
+		result[i] = RecommendedListing{
+			FarmPlotDirectListingsWithImageByte: related[i],
+			Score:                               scores.Scores[related[i].ID],
+			Reasons:                             scores.Reasons[related[i].ID],
+		}
+	}
+	return result, nil
+}
+
+// recommendationScores runs the recommendation traversals for a user's seed regions.
+// Scores are cached per user and region; listing validity is applied by the caller.
+func recommendationScores(username, region string) (*listingScores, error) {
+	cacheKey := fmt.Sprintf("recommendations:%s:%s", strings.ToLower(username), region)
+	var cached listingScores
+	if err := cache.Get(cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	regions := []string{region}
+	if region == "" {
+		var err error
+		if regions, err = purchasedRegions(username); err != nil {
+			return nil, err
+		}
+	}
+
+	scores := &listingScores{Scores: make(map[string]int), Reasons: make(map[string][]string)}
+	params := map[string]any{"regions": regions, "username": username, "settled": string(PurchaseSettled)}
+	if len(regions) > 0 {
+		for reason, query := range recommendationQueries {
+			records, err := memgraph.ExecuteReplicaRead(query, params)
+			if err != nil {
+				slog.Warn("recommendation query failed", "reason", reason, "error", err)
+				continue
+			}
+			for _, record := range records {
+				if id := memgraph.String(record, "listingId"); id != "" {
+					scores.add(id, reason, recommendationWeights[reason]*int(memgraph.Int64(record, "weight")))
+				}
+			}
+		}
+	}
+
+	// Cold start: no seed regions, or nobody else bought in them
+	if len(scores.Scores) == 0 && region == "" {
+		records, err := memgraph.ExecuteReplicaRead(`MATCH (:Purchase {state: $settled})-[:FOR_LISTING]->(:Listing)-[:IN_REGION]->(r:Region)
+			WITH r, count(*) AS purchases
+			ORDER BY purchases DESC LIMIT $regionCount
+			MATCH (r)<-[:IN_REGION]-(l:Listing)
+			RETURN l.listingId AS listingId, purchases AS weight`,
+			map[string]any{"settled": string(PurchaseSettled), "regionCount": popularRegionCount})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch popular regions: %w", err)
+		}
+		for _, record := range records {
+			if id := memgraph.String(record, "listingId"); id != "" {
+				scores.add(id, "popular_region", int(memgraph.Int64(record, "weight")))
+			}
+		}
+	}
+
+	cache.SetWithTags(cacheKey, scores, config.CacheTTL(config.TTLRecommendations), cache.UserTag(username))
+	return scores, nil
+}
+
+// purchasedRegions lists the regions of the listings a user completed purchases of
+func purchasedRegions(username string) ([]string, error) {
+	records, err := memgraph.ExecuteReplicaRead(`MATCH (:User {username: $username})-[:MADE_PURCHASE]->(:Purchase {state: $settled})-[:FOR_LISTING]->(:Listing)-[:IN_REGION]->(r:Region)
+		RETURN DISTINCT r.name AS region`, map[string]any{"username": username, "settled": string(PurchaseSettled)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch purchased regions: %w", err)
+	}
+	regions := make([]string, 0, len(records))
+	for _, record := range records {
+		if name := memgraph.String(record, "region"); name != "" {
+			regions = append(regions, name)
+		}
+	}
+	return regions, nil
+}
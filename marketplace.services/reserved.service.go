@@ -0,0 +1,256 @@
+package marketplaceservices
+
+import (
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ErrBuyerNotApproved is returned when a wallet tries to buy a reserved listing it is not approved for
+var ErrBuyerNotApproved = errors.New("this listing is reserved for approved buyers")
+
+// ApproveReservedBuyer whitelists a buyer for one of the seller's reserved listings.
+// The approval is sent on-chain from the seller's smart account and, once mined,
+// mirrored on the (:Listing) node so marketplace reads can filter without an Engine
+// call per listing.
+func ApproveReservedBuyer(token, listingID, buyer string) (*ReservedBuyersResponse, error) {
+	return setReservedBuyer(token, listingID, buyer, true)
+}
+
+// RevokeReservedBuyer removes a buyer's approval for one of the seller's reserved listings
+func RevokeReservedBuyer(token, listingID, buyer string) (*ReservedBuyersResponse, error) {
+	return setReservedBuyer(token, listingID, buyer, false)
+}
+
+// GetReservedBuyers lists the approved buyers of a reserved listing (seller only)
+func GetReservedBuyers(token, listingID string) (*ReservedBuyersResponse, error) {
+	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	listing, err := GetListing(listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(listing.Seller, walletAddr) {
+		return nil, ErrNotListingSeller
+	}
+
+	approvals, err := approvedBuyers([]string{listingID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReservedBuyersResponse{
+		ListingID:         listingID,
+		IsReservedListing: listing.IsReservedListing,
+		ApprovedBuyers:    approvals[listingID],
+	}, nil
+}
+
+// setReservedBuyer approves or revokes a buyer on a reserved listing owned by the caller.
+// The returned approved buyers are the ones mirrored so far; the change joins them when
+// its transaction is mined.
+func setReservedBuyer(token, listingID, buyer string, approve bool) (*ReservedBuyersResponse, error) {
+	walletAddr, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	if !utils.ValidateContractAddress(buyer) {
+		return nil, fmt.Errorf("invalid buyer address")
+	}
+	buyer = strings.ToLower(buyer)
+
+	listing, err := GetListing(listingID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(listing.Seller, walletAddr) {
+		return nil, ErrNotListingSeller
+	}
+	if !listing.IsReservedListing {
+		return nil, fmt.Errorf("listing %s is not a reserved listing", listingID)
+	}
+
+	action := "approve-buyer-for-reserved-listing"
+	reqBody := map[string]any{"listingId": listingID, "buyer": buyer}
+	if !approve {
+		action = "revoke-buyer-approval-for-reserved-listing"
+		reqBody = map[string]any{"listingId": listingID, "buyerAddress": buyer}
+	}
+
+	url := fmt.Sprintf("%s/marketplace/%s/%s/direct-listings/%s",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		config.MarketPlaceContractAddress,
+		action,
+	)
+
+	status, body, err := utils.Engine().Post(url, map[string]string{
+		"Authorization":            "Bearer " + os.Getenv("SECRET_KEY"),
+		"X-Backend-Wallet-Address": walletAddr,
+	}, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
+	var engineResp EngineResponse
+	if err := json.Unmarshal(body, &engineResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if engineResp.Result.QueueID == "" {
+		return nil, fmt.Errorf("engine did not return a queue ID")
+	}
+
+	// Mirror the change only once it is on-chain, so a reverted approval never shows the listing
+	go mirrorBuyerApprovalWhenMined(engineResp.Result.QueueID, listingID, buyer, approve)
+
+	approvals, err := approvedBuyers([]string{listingID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReservedBuyersResponse{
+		ListingID:         listingID,
+		IsReservedListing: true,
+		ApprovedBuyers:    approvals[listingID],
+		QueueID:           engineResp.Result.QueueID,
+	}, nil
+}
+
+// mirrorBuyerApprovalWhenMined waits for a queued approval or revocation to be mined and
+// then mirrors it on the (:Listing) node. The status is read from the Engine Cloud
+// instance that queued it. Failed, cancelled, or reverted transactions leave the mirror
+// unchanged. It blocks, so run it in a goroutine.
+func mirrorBuyerApprovalWhenMined(queueID, listingID, buyer string, approve bool) {
+	tx, err := utils.WaitForTransaction(queueID, saleConfirmTimeout, saleConfirmPollInterval)
+	if err != nil || tx.Status != "mined" || tx.OnChainTxStatus == 0 {
+		if err == nil && tx.Status != "errored" && tx.Status != "cancelled" && tx.Status != "mined" {
+			slog.Warn("buyer approval not mined in time; not mirrored", "queueId", queueID, "listingId", listingID, "timeout", saleConfirmTimeout)
+		}
+		return
+	}
+
+	query := `MERGE (l:Listing {listingId: $listingId})
+		SET l.approvedBuyers = CASE
+			WHEN $approve AND NOT $buyer IN coalesce(l.approvedBuyers, []) THEN coalesce(l.approvedBuyers, []) + $buyer
+			WHEN $approve THEN l.approvedBuyers
+			ELSE [b IN coalesce(l.approvedBuyers, []) WHERE b <> $buyer]
+		END`
+	if _, err := memgraph.ExecuteWrite(query, map[string]any{
+		"listingId": listingID,
+		"buyer":     buyer,
+		"approve":   approve,
+	}); err != nil {
+		slog.Warn("failed to save buyer approval", "listingId", listingID, "buyer", buyer, "approve", approve, "error", err)
+		return
+	}
+
+	// Approved buyers see the listing in their results on the next read
+	InvalidateListingsCache()
+}
+
+// approvedBuyers loads the approved buyer addresses (lower-case) for the given listings
+func approvedBuyers(listingIDs []string) (map[string][]string, error) {
+	approvals := make(map[string][]string)
+	if len(listingIDs) == 0 {
+		return approvals, nil
+	}
+
+	query := `MATCH (l:Listing)
+		WHERE l.listingId IN $listingIds AND l.approvedBuyers IS NOT NULL
+		RETURN l.listingId AS listingId, l.approvedBuyers AS approvedBuyers`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"listingIds": listingIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reserved listing approvals: %w", err)
+	}
+
+	for _, record := range records {
+		id, _ := record.Get("listingId")
+		list, _ := record.Get("approvedBuyers")
+		listingID, ok := id.(string)
+		if !ok {
+			continue
+		}
+		buyers := make([]string, 0)
+		if values, ok := list.([]any); ok {
+			for _, v := range values {
+				if s, ok := v.(string); ok {
+					buyers = append(buyers, s)
+				}
+			}
+		}
+		approvals[listingID] = buyers
+	}
+
+	return approvals, nil
+}
+
+// isApprovedBuyer reports whether wallet may see and buy a listing: the listing is public,
+// the wallet is its seller, or the seller approved the wallet
+func isApprovedBuyer(listing *DirectListing, wallet string, approvals map[string][]string) bool {
+	if !listing.IsReservedListing || strings.EqualFold(listing.Seller, wallet) {
+		return true
+	}
+	for _, buyer := range approvals[listing.ID] {
+		if strings.EqualFold(buyer, wallet) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterReservedListings drops reserved listings the wallet is not approved for.
+// If approvals cannot be loaded, all reserved listings are hidden.
+func filterReservedListings(listings FarmPlotDirectListingsResponse, wallet string) FarmPlotDirectListingsResponse {
+	var reservedIDs []string
+	for _, listing := range listings {
+		if listing.IsReservedListing {
+			reservedIDs = append(reservedIDs, listing.ID)
+		}
+	}
+	if len(reservedIDs) == 0 {
+		return listings
+	}
+
+	approvals, err := approvedBuyers(reservedIDs)
+	if err != nil {
+		slog.Warn("failed to load approved buyers", "error", err)
+		approvals = map[string][]string{}
+	}
+
+	visible := make(FarmPlotDirectListingsResponse, 0, len(listings))
+	for _, listing := range listings {
+		if isApprovedBuyer(&listing.DirectListing, wallet, approvals) {
+			visible = append(visible, listing)
+		}
+	}
+	return visible
+}
+
+// ensureReservedBuyer returns ErrBuyerNotApproved when wallet may not buy a reserved listing
+func ensureReservedBuyer(listing *DirectListing, wallet string) error {
+	if !listing.IsReservedListing {
+		return nil
+	}
+	approvals, err := approvedBuyers([]string{listing.ID})
+	if err != nil {
+		return err
+	}
+	if !isApprovedBuyer(listing, wallet, approvals) {
+		return ErrBuyerNotApproved
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package marketplaceservices
+
+import (
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+
+	notificationServices "decentragri-app-cx-server/notification.services"
+	portfolioServices "decentragri-app-cx-server/portfolio.services"
+	"decentragri-app-cx-server/utils"
+)
+
+// ProcessSale applies a confirmed on-chain sale: it drops the cached listings, records
+// the sale price for the plot, refreshes the listing snapshot used by watchlists, and
+// notifies the buyer, the seller, and anyone watching the listing.
+func ProcessSale(sale SaleEvent) error {
+	InvalidateListingsCache()
+
+	listing, err := GetListing(sale.ListingID)
+	if err != nil {
+		return fmt.Errorf("failed to load listing %s for sale: %w", sale.ListingID, err)
+	}
+	if sale.TokenID == "" {
+		sale.TokenID = listing.TokenID
+	}
+	if sale.Seller == "" {
+		sale.Seller = listing.Seller
+	}
+
+	event := PlotPriceEvent{
+		ListingID:               sale.ListingID,
+		CurrencyContractAddress: listing.CurrencyContractAddress,
+		TxHash:                  sale.TxHash,
+		Buyer:                   sale.Buyer,
+		Seller:                  sale.Seller,
+	}
+	decimals := 18
+	if listing.CurrencyValuePerToken != nil {
+		event.CurrencySymbol = listing.CurrencyValuePerToken.Symbol
+		decimals = listing.CurrencyValuePerToken.Decimals
+	}
+	if total, ok := new(big.Int).SetString(sale.TotalPricePaid, 10); ok {
+		event.Price, _ = strconv.ParseFloat(utils.FormatUnits(total, decimals), 64)
+	}
+	if err := recordSale(sale.TokenID, event); err != nil {
+		slog.Warn("failed to record sale", "tokenId", sale.TokenID, "listingId", listing.ID, "error", err)
+	}
+
+	if err := saveListingSnapshot(listing); err != nil {
+		slog.Warn("failed to save listing snapshot", "listingId", listing.ID, "error", err)
+	}
+	if listing.Status == StatusCompleted {
+		go notifyWatchersOfSale(listing)
+	}
+
+	data := map[string]any{
+		"listingId": sale.ListingID,
+		"tokenId":   sale.TokenID,
+		"txHash":    sale.TxHash,
+		"price":     event.Price,
+		"currency":  event.CurrencySymbol,
+	}
+	if sale.Seller != "" {
+		portfolioServices.PublishPortfolioEvent(sale.Seller, portfolioServices.EventListingSold, data)
+		notificationServices.NotifyAsync(sale.Seller, notificationServices.TypePlotSold,
+			"Your farm plot sold",
+			fmt.Sprintf("Farm plot #%s sold for %g %s", sale.TokenID, event.Price, event.CurrencySymbol),
+			data)
+	}
+	if sale.Buyer != "" {
+		notificationServices.NotifyAsync(sale.Buyer, notificationServices.TypePlotPurchased,
+			"Purchase complete",
+			fmt.Sprintf("Farm plot #%s is now in your portfolio", sale.TokenID),
+			data)
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package marketplaceservices
+
+import (
+	memgraph "decentragri-app-cx-server/db"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+
+	currencyServices "decentragri-app-cx-server/currency.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	"decentragri-app-cx-server/utils"
+)
+
+const (
+	// minSimilarListings is padded up to with price-nearest listings when the graph has few matches
+	minSimilarListings = 5
+	// maxSimilarListings caps the similar listings response
+	maxSimilarListings = 10
+)
+
+// similarityWeights scores each relationship a candidate shares with the source listing
+var similarityWeights = map[string]int{
+	"same_crop":       3,
+	"same_region":     2,
+	"shared_owner":    2,
+	"same_price_band": 1,
+}
+
+// similarityQueries find listings sharing a feature node with the source listing
+var similarityQueries = map[string]string{
+	"same_crop": `MATCH (:Listing {listingId: $listingId})-[:GROWS]->(:Crop)<-[:GROWS]-(l:Listing)
+		RETURN DISTINCT l.listingId AS listingId`,
+	"same_region": `MATCH (:Listing {listingId: $listingId})-[:IN_REGION]->(:Region)<-[:IN_REGION]-(l:Listing)
+		RETURN DISTINCT l.listingId AS listingId`,
+	"same_price_band": `MATCH (:Listing {listingId: $listingId})-[:IN_PRICE_BAND]->(:PriceBand)<-[:IN_PRICE_BAND]-(l:Listing)
+		RETURN DISTINCT l.listingId AS listingId`,
+	// Plots that have been held by someone who also held this plot
+	"shared_owner": `MATCH (:Listing {listingId: $listingId})-[:OF_PLOT]->(:Plot)<-[:OWNED]-(:Owner)-[:OWNED]->(:Plot)<-[:OF_PLOT]-(l:Listing)
+		RETURN DISTINCT l.listingId AS listingId`,
+}
+
+// priceBand buckets a USD price so listings in the same range can be related
+func priceBand(priceUSD float64) string {
+	switch {
+	case priceUSD <= 0:
+		return ""
+	case priceUSD < 100:
+		return "<100"
+	case priceUSD < 500:
+		return "100-500"
+	case priceUSD < 1000:
+		return "500-1k"
+	case priceUSD < 5000:
+		return "1k-5k"
+	case priceUSD < 25000:
+		return "5k-25k"
+	}
+	return "25k+"
+}
+
+// plotAttributes returns the first attribute block describing the farm
+func plotAttributes(asset FarmPlotMetadata) FarmPlotAttributes {
+	for _, attr := range asset.Attributes {
+		if attr.CropType != "" || attr.Location != "" {
+			return attr
+		}
+	}
+	return FarmPlotAttributes{}
+}
+
+// indexListingGraph links each listing to its crop, region, price band, and plot, and
+// records the seller on the listing and as an owner of the plot. Feature edges are
+// replaced on every run so price and metadata changes are reflected. The stored seller
+// is what portfolio activity matches a wallet's own listings on.
+func indexListingGraph(listings FarmPlotDirectListingsResponse) {
+	rows := make([]map[string]any, 0, len(listings))
+	// Index access avoids copying listings whose images may still be loading
+	for i := range listings {
+		listing := &listings[i]
+		attr := plotAttributes(listing.Asset)
+		rows = append(rows, map[string]any{
+			"listingId": listing.ID,
+			"tokenId":   listing.TokenID,
+			"seller":    strings.ToLower(listing.Seller),
+			"priceUSD":  listing.PriceUSD,
+			"crop":      strings.ToLower(strings.TrimSpace(attr.CropType)),
+			"region":    utils.RegionFromLocation(attr.Location),
+			"priceBand": priceBand(listing.PriceUSD),
+		})
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	query := `MERGE (l:Listing {listingId: row.listingId})
+		SET l.tokenId = row.tokenId, l.priceUSD = row.priceUSD,
+			l.seller = CASE WHEN row.seller = '' THEN l.seller ELSE row.seller END
+		WITH l, row
+		OPTIONAL MATCH (l)-[old:GROWS|IN_REGION|IN_PRICE_BAND]->()
+		DELETE old
+		WITH DISTINCT l, row
+		MERGE (p:Plot {tokenId: row.tokenId})
+		MERGE (l)-[:OF_PLOT]->(p)
+		FOREACH (_ IN CASE WHEN row.seller = '' THEN [] ELSE [1] END |
+			MERGE (o:Owner {address: row.seller}) MERGE (o)-[:OWNED]->(p))
+		FOREACH (crop IN CASE WHEN row.crop = '' THEN [] ELSE [row.crop] END |
+			MERGE (c:Crop {name: crop}) MERGE (l)-[:GROWS]->(c))
+		FOREACH (region IN CASE WHEN row.region = '' THEN [] ELSE [row.region] END |
+			MERGE (r:Region {name: region}) MERGE (l)-[:IN_REGION]->(r))
+		FOREACH (band IN CASE WHEN row.priceBand = '' THEN [] ELSE [row.priceBand] END |
+			MERGE (b:PriceBand {band: band}) MERGE (l)-[:IN_PRICE_BAND]->(b))`
+	if _, err := memgraph.BulkWrite(query, rows, nil); err != nil {
+		slog.Warn("failed to index listing graph", "error", err)
+	}
+}
+
+// recordPlotOwner links an owner to a plot so co-ownership can relate listings
+func recordPlotOwner(owner, tokenID string) {
+	if owner == "" || tokenID == "" {
+		return
+	}
+	query := `MERGE (o:Owner {address: $owner})
+		MERGE (p:Plot {tokenId: $tokenId})
+		MERGE (o)-[:OWNED]->(p)`
+	if _, err := memgraph.ExecuteWrite(query, map[string]any{"owner": strings.ToLower(owner), "tokenId": tokenID}); err != nil {
+		slog.Warn("failed to record owner of plot", "tokenId", tokenID, "error", err)
+	}
+}
+
+// GetSimilarListings returns up to 10 valid listings related to a listing by crop type,
+// region, price band, and shared owners, strongest matches first. When the graph yields
+// fewer than 5, the list is padded with the listings closest in USD price.
+func GetSimilarListings(token, listingID, currency, imageSize string) ([]SimilarListing, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+	imageSize, err = utils.NormalizeImageSize(imageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := GetAllValidFarmPlotListings("", "")
+	if err != nil {
+		return nil, err
+	}
+	visible := filterReservedListings(*listings, username)
+
+	var source *FarmPlotDirectListingsWithImageByte
+	byID := make(map[string]*FarmPlotDirectListingsWithImageByte, len(visible))
+	for i := range visible {
+		byID[visible[i].ID] = &visible[i]
+		if visible[i].ID == listingID {
+			source = &visible[i]
+		}
+	}
+	if source == nil {
+		return nil, fmt.Errorf("listing %s not found", listingID)
+	}
+
+	scores := make(map[string]int)
+	reasons := make(map[string][]string)
+	for reason, query := range similarityQueries {
+		records, err := memgraph.ExecuteRead(query, map[string]any{"listingId": listingID})
+		if err != nil {
+			slog.Warn("similar listings query failed", "reason", reason, "error", err)
+			continue
+		}
+		for _, record := range records {
+			v, _ := record.Get("listingId")
+			id, _ := v.(string)
+			if id == "" || id == listingID || byID[id] == nil {
+				continue
+			}
+			scores[id] += similarityWeights[reason]
+			reasons[id] = append(reasons[id], reason)
+		}
+	}
+
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return priceDistance(source, byID[ids[i]]) < priceDistance(source, byID[ids[j]])
+	})
+	if len(ids) > maxSimilarListings {
+		ids = ids[:maxSimilarListings]
+	}
+
+	// Pad sparse results with the listings nearest in price
+	if len(ids) < minSimilarListings {
+		var rest []string
+		for id := range byID {
+			if id != listingID && scores[id] == 0 {
+				rest = append(rest, id)
+			}
+		}
+		sort.Slice(rest, func(i, j int) bool {
+			return priceDistance(source, byID[rest[i]]) < priceDistance(source, byID[rest[j]])
+		})
+		for _, id := range rest {
+			if len(ids) >= minSimilarListings {
+				break
+			}
+			ids = append(ids, id)
+			reasons[id] = []string{"similar_price"}
+		}
+	}
+
+	related := make(FarmPlotDirectListingsResponse, len(ids))
+	for i, id := range ids {
+		related[i] = *byID[id]
+	}
+	if err := ApplyFiatPrices(related, currency); err != nil {
+		slog.Warn("failed to apply fiat prices", "error", err)
+	}
+	if imageSize != "" {
+		ApplyImageSize(related, imageSize)
+	}
+
+	result := make([]SimilarListing, len(related))
+	for i := range related {
+		result[i] = SimilarListing{
+			FarmPlotDirectListingsWithImageByte: related[i],
+			Score:                               scores[related[i].ID],
+			Reasons:                             reasons[related[i].ID],
+		}
+	}
+	return result, nil
+}
+
+// priceDistance is the absolute USD price difference between two listings
+func priceDistance(a, b *FarmPlotDirectListingsWithImageByte) float64 {
+	return math.Abs(a.PriceUSD - b.PriceUSD)
+}
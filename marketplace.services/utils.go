@@ -4,14 +4,17 @@ import (
 	"crypto/md5"
 	"decentragri-app-cx-server/cache"
 	"decentragri-app-cx-server/config"
+	currencyServices "decentragri-app-cx-server/currency.services"
+	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/utils"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"math/big"
 	"os"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -33,11 +36,33 @@ func GetAllValidFarmPlotListings(chainID, contractAddress string) (*FarmPlotDire
 	if cache.Exists(cacheKey) {
 		err := cache.Get(cacheKey, &cachedResult)
 		if err == nil {
+			// Listings can expire or be hidden while the cached copy is still within its TTL
+			cachedResult = dropHiddenListings(dropExpiredListings(cachedResult))
+			markVerifiedFarms(cachedResult)
 			return &cachedResult, nil
 		}
 	}
 
-	// If not in cache or cache error, fetch from API
+	// Concurrent misses share one Engine fetch and image download
+	shared, err := cache.Load(cacheKey, func() (interface{}, error) {
+		return fetchValidFarmPlotListings(chainID, contractAddress, cacheKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Moderation is applied on read so hiding takes effect immediately; it filters in
+	// place, so work on a copy of the shared result
+	result := append(FarmPlotDirectListingsResponse(nil), shared.(FarmPlotDirectListingsResponse)...)
+	result = dropHiddenListings(result)
+	markVerifiedFarms(result)
+
+	return &result, nil
+}
+
+// fetchValidFarmPlotListings loads the valid listings from Engine, checks ownership,
+// prices them, downloads their images, and caches the result under cacheKey
+func fetchValidFarmPlotListings(chainID, contractAddress, cacheKey string) (FarmPlotDirectListingsResponse, error) {
 	// Prepare the request URL
 	url := fmt.Sprintf("%s/marketplace/%s/%s/direct-listings/get-all-valid",
 		config.EngineCloudBaseURL,
@@ -45,14 +70,13 @@ func GetAllValidFarmPlotListings(chainID, contractAddress string) (*FarmPlotDire
 		contractAddress,
 	)
 
-	req := fiber.Get(url)
-	req.Set("Authorization", "Bearer "+os.Getenv("SECRET_KEY"))
-	req.Set("X-Backend-Wallet-Address", config.AdminWallet)
-
-	// Send the request
-	status, body, errs := req.Bytes()
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("error sending request: %v", errs[0])
+	// Execute the request through the shared Engine client (timeouts, retries, circuit breaker)
+	status, body, err := utils.Engine().Get(url, map[string]string{
+		"Authorization":            "Bearer " + os.Getenv("SECRET_KEY"),
+		"X-Backend-Wallet-Address": config.AdminWallet,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
 	}
 
 	if status < 200 || status >= 300 {
@@ -67,10 +91,15 @@ func GetAllValidFarmPlotListings(chainID, contractAddress string) (*FarmPlotDire
 		return nil, fmt.Errorf("error parsing response JSON: %w", err)
 	}
 
+	// Only cache listings the seller can still deliver
+	apiResponse.Result = validateListingOwnership(apiResponse.Result)
+
+	// Record asking prices for plot price history in the background
+	go RecordListingPrices(apiResponse.Result)
+
 	// Early return if no listings
 	if len(apiResponse.Result) == 0 {
-		result := make(FarmPlotDirectListingsResponse, 0)
-		return &result, nil
+		return make(FarmPlotDirectListingsResponse, 0), nil
 	}
 
 	// Pre-allocate result with exact capacity
@@ -94,9 +123,35 @@ func GetAllValidFarmPlotListings(chainID, contractAddress string) (*FarmPlotDire
 		}
 	}
 
+	// USD prices are computed once here and cached with the listings
+	applyUSDPrices(result)
+
+	// Keep the crop/region/price graph behind similar listings current
+	go indexListingGraph(result)
+
 	// Only fetch images if there are listings with image URIs
 	if len(listingsWithImages) == 0 {
-		return &result, nil
+		cache.SetGob(cacheKey, result, config.CacheTTL(config.TTLListings), cache.TagListings)
+		return result, nil
+	}
+
+	// Resolve each image's URL (we already know it exists from pre-filtering), then read
+	// every cached image in one round trip so only misses are downloaded
+	imageURLs := make([]string, len(listingsWithImages))
+	cacheKeys := make([]string, len(listingsWithImages))
+	for i, index := range listingsWithImages {
+		for _, attr := range result[index].Asset.Attributes {
+			if attr.Image != "" {
+				// Convert IPFS URI to HTTP URL if needed
+				imageURLs[i] = BuildIpfsUri(attr.Image)
+				break
+			}
+		}
+		cacheKeys[i] = imageCacheKey(imageURLs[i])
+	}
+	cached, err := cache.GetBytesMulti(cacheKeys)
+	if err != nil {
+		cached = nil
 	}
 
 	// Limit concurrent image fetches to prevent overwhelming the server
@@ -106,9 +161,14 @@ func GetAllValidFarmPlotListings(chainID, contractAddress string) (*FarmPlotDire
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	for _, index := range listingsWithImages {
+	for i, index := range listingsWithImages {
+		if imageBytes, hit := cached[cacheKeys[i]]; hit {
+			result[index].ImageBytes = ByteArray(imageBytes)
+			continue
+		}
+
 		wg.Add(1)
-		go func(idx int) {
+		go func(idx int, httpURL string) {
 			defer wg.Done()
 
 			// Acquire semaphore
@@ -117,25 +177,13 @@ func GetAllValidFarmPlotListings(chainID, contractAddress string) (*FarmPlotDire
 
 			listing := &result[idx]
 
-			// Extract image URI (we already know it exists from pre-filtering)
-			var imageURI string
-			for _, attr := range listing.Asset.Attributes {
-				if attr.Image != "" {
-					imageURI = attr.Image
-					break
-				}
-			}
-
-			log.Printf("Processing image for listing %s", listing.ID)
-
-			// Convert IPFS URI to HTTP URL if needed
-			httpURL := BuildIpfsUri(imageURI)
+			slog.Debug("processing listing image", "listingId", listing.ID)
 
 			// Fetch image bytes
 			imageBytes, err := FetchImageBytes(httpURL)
 			if err != nil {
 				// Log error but don't fail the entire request
-				log.Printf("Warning: Failed to fetch image for listing %s: %v", listing.ID, err)
+				slog.Warn("failed to fetch image for listing", "listingId", listing.ID, "error", err)
 				return
 			}
 
@@ -143,16 +191,16 @@ func GetAllValidFarmPlotListings(chainID, contractAddress string) (*FarmPlotDire
 			mu.Lock()
 			listing.ImageBytes = ByteArray(imageBytes)
 			mu.Unlock()
-		}(index)
+		}(index, imageURLs[i])
 	}
 
 	// Wait for all image fetches to complete
 	wg.Wait()
 
 	// Cache the result for 5 minutes
-	cache.Set(cacheKey, result, 5*time.Minute)
+	cache.SetGob(cacheKey, result, config.CacheTTL(config.TTLListings), cache.TagListings)
 
-	return &result, nil
+	return result, nil
 }
 
 func FetchImageBytes(imageURI string) ([]uint8, error) {
@@ -161,46 +209,59 @@ func FetchImageBytes(imageURI string) ([]uint8, error) {
 	}
 
 	// Create cache key for image
-	hasher := md5.New()
-	hasher.Write([]byte(imageURI))
-	cacheKey := fmt.Sprintf("image:%s", hex.EncodeToString(hasher.Sum(nil)))
+	cacheKey := imageCacheKey(imageURI)
 
 	// Try to get from cache first
-	var cachedImage []uint8
-	if cache.Exists(cacheKey) {
-		err := cache.Get(cacheKey, &cachedImage)
-		if err == nil && len(cachedImage) > 0 {
-			return cachedImage, nil
-		}
+	if cachedImage, err := cache.GetBytes(cacheKey); err == nil && len(cachedImage) > 0 {
+		return cachedImage, nil
 	}
 
-	// If not in cache, fetch from URL
-	req := fiber.Get(imageURI)
-	status, resp, errs := req.Bytes()
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("failed to fetch image: %w", errs[0])
-	}
+	// If not in cache, fetch from URL; concurrent misses for one image share the download
+	image, err := cache.Load(cacheKey, func() (interface{}, error) {
+		req := fiber.Get(imageURI)
+		status, resp, errs := req.Bytes()
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("failed to fetch image: %w", errs[0])
+		}
 
-	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("HTTP request failed with status %d", status)
-	}
+		if status < 200 || status >= 300 {
+			return nil, fmt.Errorf("HTTP request failed with status %d", status)
+		}
 
-	if len(resp) == 0 {
-		return nil, fmt.Errorf("image data is empty")
-	}
+		if len(resp) == 0 {
+			return nil, fmt.Errorf("image data is empty")
+		}
 
-	// Cache the image for 1 hour
-	cache.Set(cacheKey, resp, 1*time.Hour)
+		// Cache the image for 1 hour
+		cache.SetBytes(cacheKey, resp, config.CacheTTL(config.TTLImages))
 
-	return resp, nil
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return image.([]uint8), nil
 }
 
-func BuildIpfsUri(ipfsURI string) string {
+// imageCacheKey is the Redis key holding an image's bytes, keyed by the MD5 of its URL;
+// resized variants append their size
+func imageCacheKey(httpURL string) string {
+	hash := md5.Sum([]byte(httpURL))
+	return "image:" + hex.EncodeToString(hash[:])
+}
+
+// thirdwebClientID returns the thirdweb client ID used for IPFS gateway and RPC URLs
+func thirdwebClientID() string {
 	clientID := os.Getenv("CLIENT_ID")
 	if clientID == "" {
 		// Fallback to the new client ID if environment variable is not set
 		clientID = "758a938bc85320ceb23c40418e01618a"
 	}
+	return clientID
+}
+
+func BuildIpfsUri(ipfsURI string) string {
+	clientID := thirdwebClientID()
 
 	// Check if this is already an HTTPS URL with ipfscdn.io pattern
 	if strings.HasPrefix(ipfsURI, "https://") && strings.Contains(ipfsURI, ".ipfscdn.io/ipfs/") {
@@ -230,3 +291,226 @@ func BuildIpfsUri(ipfsURI string) string {
 	// If it doesn't match any expected format, return as is
 	return ipfsURI
 }
+
+// NativeTokenAddress is the placeholder address Engine uses for the chain's native currency
+const NativeTokenAddress = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+
+// applyUSDPrices sets PriceDisplay and PriceUSD on each listing from its per-token price
+// and the USD price of the listing currency. Token prices are fetched once per currency contract.
+func applyUSDPrices(listings FarmPlotDirectListingsResponse) {
+	prices := make(map[string]float64)
+	for i := range listings {
+		listing := &listings[i]
+		amount, err := listingUnitPrice(listing.CurrencyValuePerToken)
+		if err != nil {
+			continue
+		}
+
+		listing.PriceDisplay = formatListingPrice(listing.CurrencyContractAddress, listing.CurrencyValuePerToken)
+		listing.PriceUSD = amount * currencyPriceUSD(listing.CurrencyContractAddress, prices)
+	}
+}
+
+// ApplyFiatPrices sets FiatPrice on each listing by converting its cached PriceUSD
+// with the requested FX rate.
+func ApplyFiatPrices(listings FarmPlotDirectListingsResponse, currency string) error {
+	if len(listings) == 0 {
+		return nil
+	}
+
+	rate, err := currencyServices.GetRate(currency)
+	if err != nil {
+		return err
+	}
+
+	for i := range listings {
+		listing := &listings[i]
+		if listing.PriceUSD == 0 {
+			continue
+		}
+
+		listing.FiatPrice = &currencyServices.FiatAmount{
+			Currency: currency,
+			Amount:   listing.PriceUSD * rate,
+			Rate:     rate,
+		}
+	}
+
+	return nil
+}
+
+// InvalidateListingsCache drops the cached marketplace listings of every chain and
+// contract so the next read hits Engine
+func InvalidateListingsCache() {
+	if err := cache.InvalidateTag(cache.TagListings); err != nil {
+		slog.Warn("failed to invalidate listings cache", "error", err)
+	}
+}
+
+// RegisterCacheInvalidation drops the cached listings whenever plots change hands,
+// since a sold or transferred plot can no longer be bought from its listing
+func RegisterCacheInvalidation() {
+	cache.OnInvalidate(cache.TopicPlotOwnership, func(cache.InvalidationEvent) {
+		InvalidateListingsCache()
+	})
+}
+
+// PlotBalance returns how many units of a farm plot NFT a wallet holds. Farm plots are
+// ERC1155, so a plot can have several holders and there is no single owner to look up.
+func PlotBalance(walletAddress, tokenID string) (*big.Int, error) {
+	// Ownership drives authorization, so readUint never serves it from the fallback cache
+	balance, err := readUint(config.FarmPlotContractAddress, "balanceOf", walletAddress+","+tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch farm plot %s balance: %w", tokenID, err)
+	}
+	return balance, nil
+}
+
+// HoldsPlot reports whether a wallet holds at least quantity units of a farm plot. An
+// empty quantity means one unit.
+func HoldsPlot(walletAddress, tokenID, quantity string) (bool, error) {
+	required := big.NewInt(1)
+	if quantity != "" {
+		if _, ok := required.SetString(quantity, 10); !ok {
+			return false, fmt.Errorf("invalid quantity %q", quantity)
+		}
+	}
+	balance, err := PlotBalance(walletAddress, tokenID)
+	if err != nil {
+		return false, err
+	}
+	return balance.Sign() > 0 && balance.Cmp(required) >= 0, nil
+}
+
+// plotHolders returns the wallets recorded as owners of a plot that still hold it
+func plotHolders(tokenID string) []string {
+	records, err := memgraph.ExecuteRead(`MATCH (o:Owner)-[:OWNED]->(:Plot {tokenId: $tokenId})
+		RETURN o.address AS address
+		LIMIT 20`, map[string]any{"tokenId": tokenID})
+	if err != nil {
+		slog.Warn("failed to read recorded owners of plot", "tokenId", tokenID, "error", err)
+		return nil
+	}
+
+	var holders []string
+	for _, record := range records {
+		address := memgraph.String(record, "address")
+		if held, err := HoldsPlot(address, tokenID, ""); err == nil && held {
+			holders = append(holders, address)
+		}
+	}
+	return holders
+}
+
+// ReadContract calls a view function through Engine and returns the raw "result" value.
+// args is a comma-separated argument list. When cached is true the response may be
+// served from the Engine client's fallback cache during an outage.
+func ReadContract(contractAddress, functionName, args string, cached bool) (json.RawMessage, error) {
+	endpoint := fmt.Sprintf("%s/contract/%s/%s/read?functionName=%s",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		contractAddress,
+		functionName,
+	)
+	if args != "" {
+		endpoint += "&args=" + args
+	}
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + os.Getenv("SECRET_KEY"),
+	}
+
+	var (
+		status int
+		body   []byte
+		err    error
+	)
+	if cached {
+		status, body, err = utils.Engine().Get(endpoint, headers)
+	} else {
+		status, body, err = utils.Engine().GetLive(endpoint, headers)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("contract read %s failed with status %d: %s", functionName, status, string(body))
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %w", functionName, err)
+	}
+
+	return response.Result, nil
+}
+
+// ApplyImageSize replaces each listing's ImageBytes with a resized variant. Variants are
+// generated from the cached full-size image on first use and cached per size, so grid
+// views can request small images while detail pages keep full resolution.
+func ApplyImageSize(listings FarmPlotDirectListingsResponse, size string) {
+	maxEdge, ok := utils.ImageSizes[size]
+	if !ok {
+		return
+	}
+
+	// Read every cached variant in one round trip; only misses are resized below
+	variantKeys := make(map[int]string, len(listings))
+	cacheKeys := make([]string, 0, len(listings))
+	for i := range listings {
+		listing := &listings[i]
+		if len(listing.ImageBytes) == 0 {
+			continue
+		}
+
+		imageURI := ""
+		for _, attr := range listing.Asset.Attributes {
+			if attr.Image != "" {
+				imageURI = BuildIpfsUri(attr.Image)
+				break
+			}
+		}
+		if imageURI == "" {
+			continue
+		}
+		variantKeys[i] = imageCacheKey(imageURI) + ":" + size
+		cacheKeys = append(cacheKeys, variantKeys[i])
+	}
+	cached, err := cache.GetBytesMulti(cacheKeys)
+	if err != nil {
+		cached = nil
+	}
+
+	const maxConcurrentResizes = 8
+	semaphore := make(chan struct{}, maxConcurrentResizes)
+	var wg sync.WaitGroup
+
+	for i, cacheKey := range variantKeys {
+		listing := &listings[i]
+		if variant, hit := cached[cacheKey]; hit {
+			listing.ImageBytes = ByteArray(variant)
+			continue
+		}
+
+		wg.Add(1)
+		go func(listing *FarmPlotDirectListingsWithImageByte, cacheKey string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			resized, err := utils.ResizeImage(listing.ImageBytes, maxEdge)
+			if err != nil {
+				slog.Warn("failed to resize image for listing", "listingId", listing.ID, "error", err)
+				return
+			}
+
+			// Variants are derived data, so they can outlive the source image cache
+			cache.SetBytes(cacheKey, resized, config.CacheTTL(config.TTLImageVariants))
+			listing.ImageBytes = ByteArray(resized)
+		}(listing, cacheKey)
+	}
+
+	wg.Wait()
+}
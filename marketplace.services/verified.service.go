@@ -0,0 +1,74 @@
+package marketplaceservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"log/slog"
+	"strings"
+)
+
+const (
+	// verifiedFarmsCacheKey holds the names and linked plots of verified farms
+	verifiedFarmsCacheKey = "verified_farms"
+)
+
+// verifiedFarms is the cached set of verified farms listings are matched against
+type verifiedFarms struct {
+	FarmNames []string `json:"farmNames"`
+	TokenIDs  []string `json:"tokenIds"` // Plots linked to a verified farm
+}
+
+// InvalidateVerifiedFarms drops the cached verified set after a farm is verified or revoked
+func InvalidateVerifiedFarms() {
+	cache.InvalidateKeys(verifiedFarmsCacheKey)
+}
+
+// loadVerifiedFarms returns the verified farm names and plot token IDs, cached briefly in Redis
+func loadVerifiedFarms() (map[string]bool, map[string]bool) {
+	var verified verifiedFarms
+	if err := cache.Get(verifiedFarmsCacheKey, &verified); err != nil {
+		records, err := memgraph.ExecuteRead(`MATCH (f:Farm) WHERE f.verified = true AND f.deletedAt IS NULL
+			OPTIONAL MATCH (f)-[:TOKENIZED_AS]->(n:FarmPlotNFT)
+			RETURN f.farmName AS farmName, collect(n.tokenId) AS tokenIds`, nil)
+		if err != nil {
+			slog.Warn("failed to load verified farms", "error", err)
+			return nil, nil
+		}
+		verified = verifiedFarms{FarmNames: []string{}, TokenIDs: []string{}}
+		for _, record := range records {
+			if name := memgraph.String(record, "farmName"); name != "" {
+				verified.FarmNames = append(verified.FarmNames, name)
+			}
+			if v, _ := record.Get("tokenIds"); v != nil {
+				tokenIDs, _ := v.([]any)
+				for _, tokenID := range tokenIDs {
+					if s, ok := tokenID.(string); ok {
+						verified.TokenIDs = append(verified.TokenIDs, s)
+					}
+				}
+			}
+		}
+		cache.Set(verifiedFarmsCacheKey, verified, config.CacheTTL(config.TTLListingFlags))
+	}
+
+	names := make(map[string]bool, len(verified.FarmNames))
+	for _, name := range verified.FarmNames {
+		names[strings.ToLower(name)] = true
+	}
+	tokens := make(map[string]bool, len(verified.TokenIDs))
+	for _, tokenID := range verified.TokenIDs {
+		tokens[tokenID] = true
+	}
+	return names, tokens
+}
+
+// markVerifiedFarms sets the verified badge on listings whose plot is linked to, or whose
+// metadata names, a verified farm. It runs on read so verification shows immediately.
+func markVerifiedFarms(listings FarmPlotDirectListingsResponse) {
+	names, tokens := loadVerifiedFarms()
+	for i := range listings {
+		listing := &listings[i]
+		listing.FarmVerified = tokens[listing.TokenID] || names[strings.ToLower(listingFarmName(listing.Asset))]
+	}
+}
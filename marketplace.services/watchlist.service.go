@@ -0,0 +1,314 @@
+package marketplaceservices
+
+import (
+	memgraph "decentragri-app-cx-server/db"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	currencyServices "decentragri-app-cx-server/currency.services"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+)
+
+// AddToWatchlist stores a favorite as (:User)-[:WATCHES]->(:Listing).
+// The listing node keeps a snapshot of the on-chain state so status changes can be detected.
+func AddToWatchlist(token, listingID string) error {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return fmt.Errorf("unauthorized: %w", err)
+	}
+	if _, err := strconv.ParseUint(listingID, 10, 64); err != nil {
+		return fmt.Errorf("invalid listing ID")
+	}
+
+	listing, err := GetListing(listingID)
+	if err != nil {
+		return err
+	}
+
+	if err := saveListingSnapshot(listing); err != nil {
+		return err
+	}
+
+	query := `MATCH (u:User {username: $username}), (l:Listing {listingId: $listingId})
+		MERGE (u)-[r:WATCHES]->(l)
+		ON CREATE SET r.createdAt = timestamp()`
+	params := map[string]any{"username": username, "listingId": listingID}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return fmt.Errorf("failed to add listing to watchlist: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveFromWatchlist deletes the user's WATCHES relationship to a listing
+func RemoveFromWatchlist(token, listingID string) error {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return fmt.Errorf("unauthorized: %w", err)
+	}
+
+	query := `MATCH (:User {username: $username})-[r:WATCHES]->(:Listing {listingId: $listingId})
+		DELETE r`
+	params := map[string]any{"username": username, "listingId": listingID}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return fmt.Errorf("failed to remove listing from watchlist: %w", err)
+	}
+
+	return nil
+}
+
+// GetWatchlist returns the user's watched listings, newest first. Listings that are
+// still valid include full listing data (with fiat price); others carry their last status.
+func GetWatchlist(token, currency string) ([]WatchlistItem, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %w", err)
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `MATCH (:User {username: $username})-[r:WATCHES]->(l:Listing)
+		RETURN l.listingId AS listingId, l.tokenId AS tokenId, l.status AS status, r.createdAt AS watchedAt
+		ORDER BY r.createdAt DESC`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"username": username})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch watchlist: %w", err)
+	}
+
+	items := make([]WatchlistItem, 0, len(records))
+	if len(records) == 0 {
+		return items, nil
+	}
+
+	validListings, err := GetAllValidFarmPlotListings("", "")
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*FarmPlotDirectListingsWithImageByte, len(*validListings))
+	for i := range *validListings {
+		byID[(*validListings)[i].ID] = &(*validListings)[i]
+	}
+
+	watched := make(FarmPlotDirectListingsResponse, 0, len(records))
+	for _, record := range records {
+		item := WatchlistItem{
+			ListingID: memgraph.String(record, "listingId"),
+			TokenID:   memgraph.String(record, "tokenId"),
+			Status:    ListingStatus(memgraph.String(record, "status")),
+			WatchedAt: memgraph.Int64(record, "watchedAt"),
+		}
+		if listing, ok := byID[item.ListingID]; ok {
+			watched = append(watched, *listing)
+			item.Status = listing.Status
+		}
+		items = append(items, item)
+	}
+
+	// Fiat prices depend on the caller's currency, so apply them to a copy of the cached listings
+	if err := ApplyFiatPrices(watched, currency); err != nil {
+		slog.Warn("failed to apply fiat prices", "error", err)
+	}
+	next := 0
+	for i := range items {
+		if next < len(watched) && watched[next].ID == items[i].ListingID {
+			items[i].Listing = &watched[next]
+			next++
+		}
+	}
+
+	return items, nil
+}
+
+// saveListingSnapshot upserts the (:Listing) node with the listing's current on-chain state
+func saveListingSnapshot(listing *DirectListing) error {
+	query := `MERGE (l:Listing {listingId: $listingId})
+		SET l.tokenId = $tokenId,
+			l.seller = $seller,
+			l.status = $status,
+			l.pricePerToken = $pricePerToken,
+			l.currencyContractAddress = $currencyContractAddress,
+			l.endTimeInSeconds = $endTimeInSeconds,
+			l.updatedAt = timestamp()`
+	params := map[string]any{
+		"listingId":               listing.ID,
+		"tokenId":                 listing.TokenID,
+		"seller":                  listing.Seller,
+		"status":                  string(listing.Status),
+		"pricePerToken":           listing.PricePerToken,
+		"currencyContractAddress": listing.CurrencyContractAddress,
+		"endTimeInSeconds":        listing.EndTimeInSeconds,
+	}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return fmt.Errorf("failed to save listing: %w", err)
+	}
+	return nil
+}
+
+// CheckWatchedListings refreshes every watched listing that is still open and alerts its
+// watchers when the price changes, the listing is about to expire (WATCHLIST_EXPIRY_WINDOW,
+// default 24h), it sells, or it is cancelled or expires. Each watcher's alert preferences
+// decide which alerts they get and on which channels.
+func CheckWatchedListings() {
+	query := `MATCH (:User)-[:WATCHES]->(l:Listing)
+		WHERE l.status IN ['CREATED', 'ACTIVE']
+		RETURN DISTINCT l.listingId AS listingId, l.status AS status,
+			l.pricePerToken AS pricePerToken, l.expiryAlertedFor AS expiryAlertedFor`
+	records, err := memgraph.ExecuteRead(query, nil)
+	if err != nil {
+		slog.Warn("failed to load watched listings", "error", err)
+		return
+	}
+
+	expiryWindow := 24 * time.Hour
+	if v := os.Getenv("WATCHLIST_EXPIRY_WINDOW"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			expiryWindow = parsed
+		}
+	}
+
+	for _, record := range records {
+		listingIDVal, _ := record.Get("listingId")
+		statusVal, _ := record.Get("status")
+		priceVal, _ := record.Get("pricePerToken")
+		alertedVal, _ := record.Get("expiryAlertedFor")
+		listingID, _ := listingIDVal.(string)
+		previous, _ := statusVal.(string)
+		previousPrice, _ := priceVal.(string)
+		expiryAlertedFor, _ := alertedVal.(int64)
+		if listingID == "" {
+			continue
+		}
+
+		listing, err := GetListing(listingID)
+		if err != nil {
+			slog.Warn("failed to refresh watched listing", "listingId", listingID, "error", err)
+			continue
+		}
+		if err := saveListingSnapshot(listing); err != nil {
+			slog.Warn("failed to save listing snapshot", "listingId", listingID, "error", err)
+			continue
+		}
+
+		if string(listing.Status) != previous {
+			if listing.Status == StatusCompleted {
+				notifyWatchersOfSale(listing)
+			} else {
+				notifyWatchers(listing, alertStatusChange, notificationServices.TypeWatchedListingStatus,
+					"Watched listing updated", fmt.Sprintf("Farm plot #%s is now %s", listing.TokenID, strings.ToLower(string(listing.Status))), nil)
+			}
+			continue
+		}
+
+		if previousPrice != "" && listing.PricePerToken != previousPrice {
+			oldPrice := previousPrice
+			if listing.CurrencyValuePerToken != nil {
+				old := *listing.CurrencyValuePerToken
+				old.Value = previousPrice
+				oldPrice = formatListingPrice(listing.CurrencyContractAddress, &old)
+			}
+			newPrice := formatListingPrice(listing.CurrencyContractAddress, listing.CurrencyValuePerToken)
+			notifyWatchers(listing, alertPriceChange, notificationServices.TypeWatchedListingPrice,
+				"Price changed on a watched listing",
+				fmt.Sprintf("Farm plot #%s changed price from %s to %s", listing.TokenID, oldPrice, newPrice),
+				map[string]any{"previousPrice": oldPrice, "price": newPrice})
+		}
+
+		// Alert once per end time, so extending the listing re-arms the alert
+		endsIn := time.Until(time.Unix(listing.EndTimeInSeconds, 0))
+		if endsIn > 0 && endsIn <= expiryWindow && expiryAlertedFor != listing.EndTimeInSeconds {
+			notifyWatchers(listing, alertExpiring, notificationServices.TypeWatchedListingExpiry,
+				"Watched listing ending soon",
+				fmt.Sprintf("Farm plot #%s ends in %s", listing.TokenID, endsIn.Round(time.Minute)),
+				map[string]any{"endTimeInSeconds": listing.EndTimeInSeconds})
+
+			if _, err := memgraph.ExecuteWrite(`MATCH (l:Listing {listingId: $listingId}) SET l.expiryAlertedFor = $endTime`,
+				map[string]any{"listingId": listing.ID, "endTime": listing.EndTimeInSeconds}); err != nil {
+				slog.Warn("failed to mark expiry alert for listing", "listingId", listing.ID, "error", err)
+			}
+		}
+	}
+}
+
+// notifyWatchersOfSale sends the sold alert for a listing once. Both the monitor and the
+// sale webhook can observe the same sale, so the first caller claims the alert.
+func notifyWatchersOfSale(listing *DirectListing) {
+	summary, err := memgraph.ExecuteWrite(`MATCH (l:Listing {listingId: $listingId})
+		WHERE l.soldAlertedAt IS NULL
+		SET l.soldAlertedAt = timestamp()`, map[string]any{"listingId": listing.ID})
+	if err != nil {
+		slog.Warn("failed to claim sold alert for listing", "listingId", listing.ID, "error", err)
+		return
+	}
+	if summary == nil || summary.Counters().PropertiesSet() == 0 {
+		return
+	}
+
+	notifyWatchers(listing, alertSold, notificationServices.TypeWatchedListingSold,
+		"Watched listing sold", fmt.Sprintf("Farm plot #%s has been sold", listing.TokenID), nil)
+}
+
+// notifyWatchers alerts every user watching the listing who has the alert enabled,
+// on the channels they chose
+func notifyWatchers(listing *DirectListing, alert watchAlert, notificationType, title, message string, extra map[string]any) {
+	query := `MATCH (u:User)-[:WATCHES]->(:Listing {listingId: $listingId})
+		WHERE u.deletedAt IS NULL
+		RETURN u.username AS username, ` + alertPreferenceFields("u")
+	records, err := memgraph.ExecuteRead(query, map[string]any{"listingId": listing.ID})
+	if err != nil {
+		slog.Warn("failed to load watchers for listing", "listingId", listing.ID, "error", err)
+		return
+	}
+
+	data := map[string]any{
+		"listingId": listing.ID,
+		"tokenId":   listing.TokenID,
+		"status":    listing.Status,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	for _, record := range records {
+		v, _ := record.Get("username")
+		username, _ := v.(string)
+		if username == "" {
+			continue
+		}
+		prefs := alertPreferencesFromRecord(record)
+		if !prefs.wants(alert) {
+			continue
+		}
+		if err := notificationServices.NotifyVia(prefs.Channels, username, notificationType, title, message, data); err != nil {
+			slog.Warn("failed to send watchlist alert", "username", username, "listingId", listing.ID, "type", notificationType, "error", err)
+		}
+	}
+}
+
+// StartWatchlistMonitor checks watched listings for alerts on a fixed interval.
+// The interval defaults to 5 minutes and can be overridden with WATCHLIST_CHECK_INTERVAL.
+// It blocks, so run it in a goroutine.
+func StartWatchlistMonitor() {
+	interval := 5 * time.Minute
+	if v := os.Getenv("WATCHLIST_CHECK_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("watchlist monitor started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		CheckWatchedListings()
+	}
+}
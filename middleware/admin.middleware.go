@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IsAdmin reports whether the given wallet address is listed in ADMIN_WALLETS
+// (comma-separated, case-insensitive)
+func IsAdmin(username string) bool {
+	if username == "" {
+		return false
+	}
+	for _, admin := range strings.Split(os.Getenv("ADMIN_WALLETS"), ",") {
+		if strings.EqualFold(strings.TrimSpace(admin), username) {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminMiddleware restricts access to wallets listed in ADMIN_WALLETS.
+// It must be registered after AuthMiddleware, which populates the username local.
+// Dev bypass requests are accepted outside production only.
+func AdminMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if isDev, ok := c.Locals("isDev").(bool); ok && isDev {
+			if os.Getenv("NODE_ENV") != "production" {
+				return c.Next()
+			}
+			slog.WarnContext(c.UserContext(), "dev bypass rejected for admin path in production", "path", c.Path())
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Admin access required",
+			})
+		}
+
+		username, _ := c.Locals("username").(string)
+		if !IsAdmin(username) {
+			slog.WarnContext(c.UserContext(), "admin access denied", "username", username, "path", c.Path())
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Admin access required",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -2,32 +2,32 @@ package middleware
 
 import (
 	authservices "decentragri-app-cx-server/auth.services"
+	"decentragri-app-cx-server/config"
 	tokenServices "decentragri-app-cx-server/token.services"
-	"log"
+	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// AuthMiddleware validates JWT tokens or allows dev bypass
+// AuthMiddleware validates JWT tokens or allows dev bypass, then applies the per-user
+// rate limit of the verified wallet
 func AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		log.Printf("Auth middleware processing request for path: %s", c.Path())
+		slog.DebugContext(c.UserContext(), "authenticating request", "path", c.Path())
 
 		// Check for dev bypass first
 		if authservices.CheckDevBypass(c) {
-			log.Println("Dev bypass activated - allowing access")
+			slog.DebugContext(c.UserContext(), "dev bypass accepted", "path", c.Path())
 			// Just set minimal required context and allow access
 			c.Locals("isDev", true)
-			c.Locals("username", "dev_user")
-			return c.Next()
+			c.Locals("username", config.DevBypassWallet())
+			return userRateLimit()(c)
 		}
 
-		log.Println("Dev bypass not activated, checking JWT token")
-
 		// Extract token from Authorization header
 		token := c.Get("Authorization")
 		if token == "" {
-			log.Println("No Authorization header found")
+			slog.DebugContext(c.UserContext(), "authorization header missing", "path", c.Path())
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Authorization header is required",
 			})
@@ -39,25 +39,26 @@ func AuthMiddleware() fiber.Handler {
 		}
 
 		// Security: Never log the actual token, just its length
-		log.Printf("Validating JWT token (length: %d)", len(token))
+		slog.DebugContext(c.UserContext(), "validating JWT", "tokenLength", len(token))
 
 		// Validate the token
 		tokenService := tokenServices.NewTokenService()
 		username, err := tokenService.VerifyAccessToken(token)
 		if err != nil {
-			log.Printf("JWT validation failed: %v", err)
+			slog.WarnContext(c.UserContext(), "JWT validation failed", "path", c.Path(), "ip", c.IP(), "error", err)
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid or expired token",
 			})
 		}
 
-		log.Printf("JWT validation successful for user: %s", username)
+		slog.DebugContext(c.UserContext(), "JWT validated", "username", username)
 
 		// Store user info in context for use in handlers
 		c.Locals("username", username)
 		c.Locals("isDev", false)
 
-		return c.Next()
+		// Count the request against the caller's own read or write budget
+		return userRateLimit()(c)
 	}
 }
 
@@ -65,8 +66,8 @@ func AuthMiddleware() fiber.Handler {
 func ExtractToken(c *fiber.Ctx) string {
 	// Check if this is a dev bypass request
 	if isDev, ok := c.Locals("isDev").(bool); ok && isDev {
-		log.Println("Dev bypass - returning dummy token for services")
-		return "dev_bypass_authorized" // Simple placeholder that indicates dev bypass
+		slog.DebugContext(c.UserContext(), "dev bypass token passed to services")
+		return config.DevBypassToken // Placeholder that services resolve to the dev bypass wallet
 	}
 
 	// Extract real token from Authorization header for normal authentication
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestContext gives each request a context that handlers reach with c.UserContext()
+// and pass to database and cache calls. It is canceled when the handler returns and
+// when the server shuts down, so queries started for a request never outlive it.
+//
+// fasthttp does not report a client disconnect while a handler runs, so work for a
+// client that has gone away stops at the query timeout rather than immediately.
+func RequestContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// The fasthttp request context is done once the server begins shutting down
+		ctx, cancel := context.WithCancel(c.Context())
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
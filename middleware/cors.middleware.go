@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// CORS allows browsers on the origins listed in CORS_ALLOWED_ORIGINS (comma separated,
+// e.g. https://app.decentragri.com) to call the API with credentials. Outside production
+// an empty list or "*" allows every origin, but without credentials, so no other site
+// can make credentialed requests to a dev or staging server. In production "*" is
+// ignored and requests from any other origin are rejected with 403. Requests without an
+// Origin header (the mobile app, webhooks, server-to-server calls) are unaffected.
+func CORS() fiber.Handler {
+	production := os.Getenv("NODE_ENV") == "production"
+	origins, allowAll := corsOrigins(os.Getenv("CORS_ALLOWED_ORIGINS"), production)
+	allowed := func(origin string) bool {
+		return allowAll || origins[normalizeOrigin(origin)]
+	}
+
+	config := cors.Config{
+		AllowHeaders:  "Origin,Content-Type,Accept,Authorization,X-Dev-Bypass-Token,Idempotency-Key,If-None-Match,X-Request-ID",
+		AllowMethods:  "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
+		ExposeHeaders: "ETag,X-Request-ID",
+	}
+	if allowAll {
+		config.AllowOrigins = "*"
+	} else {
+		// Only listed origins may send the web app's cookies and Authorization headers
+		config.AllowOriginsFunc = allowed
+		config.AllowCredentials = true
+	}
+	handler := cors.New(config)
+	if !production {
+		return handler
+	}
+
+	return func(c *fiber.Ctx) error {
+		if origin := c.Get(fiber.HeaderOrigin); origin != "" && !allowed(origin) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Origin not allowed",
+			})
+		}
+		return handler(c)
+	}
+}
+
+// corsOrigins parses the allowlist into a set of normalized origins, reporting whether
+// every origin is allowed instead
+func corsOrigins(raw string, production bool) (map[string]bool, bool) {
+	origins := make(map[string]bool)
+	for _, origin := range strings.Split(raw, ",") {
+		origin = strings.TrimSpace(origin)
+		switch {
+		case origin == "":
+		case origin == "*" && production:
+			slog.Warn("ignoring CORS_ALLOWED_ORIGINS=* in production; list origins explicitly")
+		case origin == "*":
+			return nil, true
+		case !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://"):
+			slog.Warn("ignoring CORS origin: must start with http:// or https://", "origin", origin)
+		default:
+			origins[normalizeOrigin(origin)] = true
+		}
+	}
+
+	if len(origins) == 0 {
+		if production {
+			slog.Warn("CORS_ALLOWED_ORIGINS not set; cross-origin browser requests will be rejected")
+			return origins, false
+		}
+		slog.Info("CORS_ALLOWED_ORIGINS not set; allowing all origins without credentials outside production")
+		return nil, true
+	}
+	slog.Info("CORS origins configured", "count", len(origins))
+	return origins, false
+}
+
+// normalizeOrigin lowercases an origin and drops a trailing slash, so configured and
+// requested origins compare equal
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
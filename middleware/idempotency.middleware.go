@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"decentragri-app-cx-server/cache"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IdempotencyHeader is the request header clients use to make a write safe to retry
+const IdempotencyHeader = "Idempotency-Key"
+
+// idempotencyTTL is how long a stored response can be replayed
+const idempotencyTTL = 24 * time.Hour
+
+// idempotencyRecord is the Redis entry for an Idempotency-Key
+type idempotencyRecord struct {
+	Completed   bool   `json:"completed"`
+	BodyHash    string `json:"bodyHash"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Response    []byte `json:"response,omitempty"`
+}
+
+// IdempotencyMiddleware replays the stored response when a request repeats an
+// Idempotency-Key, so client retries on flaky networks cannot repeat a write.
+//
+// Keys are scoped to the authenticated user and route. A 4xx or 503 response means the
+// request was rejected before anything was submitted, so it releases the key and the
+// client can retry. Every other response is stored, other 5xx included: a server error
+// may come after the purchase reached Engine, so a retry replays it rather than buying
+// again. A duplicate
+// that arrives while the first request is still running gets 409, and reusing a key with
+// a different body gets 422. Requests without the header pass through unchanged.
+func IdempotencyMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Idempotency-Key must be at most 255 characters",
+			})
+		}
+
+		username, _ := c.Locals("username").(string)
+		cacheKey := fmt.Sprintf("idempotency:%s:%s:%s:%s", username, c.Method(), c.Path(), key)
+
+		bodySum := sha256.Sum256(c.Body())
+		bodyHash := hex.EncodeToString(bodySum[:])
+
+		// Cache calls use the request context, bounded by the cache operation timeout
+		ctx := c.UserContext()
+		stored, err := cache.SetNXContext(ctx, cacheKey, idempotencyRecord{BodyHash: bodyHash}, idempotencyTTL)
+		if err != nil {
+			// Without Redis there is nothing to deduplicate against; process normally
+			slog.WarnContext(ctx, "idempotency store unavailable", "error", err)
+			return c.Next()
+		}
+
+		if !stored {
+			var record idempotencyRecord
+			if err := cache.GetContext(ctx, cacheKey, &record); err != nil {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "A request with this Idempotency-Key is still in progress",
+				})
+			}
+			if record.BodyHash != bodyHash {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error": "Idempotency-Key was already used with a different request body",
+				})
+			}
+			if !record.Completed {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "A request with this Idempotency-Key is still in progress",
+				})
+			}
+
+			slog.InfoContext(ctx, "replaying stored idempotent response", "path", c.Path())
+			c.Set("Idempotent-Replayed", "true")
+			if record.ContentType != "" {
+				c.Set(fiber.HeaderContentType, record.ContentType)
+			}
+			return c.Status(record.Status).Send(record.Response)
+		}
+
+		// Render a returned error now, so its status decides whether the key is kept
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status >= 400 && status < 500 || status == fiber.StatusServiceUnavailable {
+			cache.DeleteContext(ctx, cacheKey)
+			return nil
+		}
+
+		record := idempotencyRecord{
+			Completed:   true,
+			BodyHash:    bodyHash,
+			Status:      status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Response:    append([]byte(nil), c.Response().Body()...),
+		}
+		if err := cache.SetContext(ctx, cacheKey, record, idempotencyTTL); err != nil {
+			slog.WarnContext(ctx, "failed to store idempotent response", "path", c.Path(), "error", err)
+		}
+
+		return nil
+	}
+}
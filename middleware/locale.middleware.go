@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"decentragri-app-cx-server/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocaleMiddleware resolves the request's locale from Accept-Language and stores it in
+// the "locale" local. Responses carry the locale in Content-Language as a hint for
+// clients that format the ISO timestamps themselves.
+func LocaleMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		locale := utils.MatchLocale(c.Get(fiber.HeaderAcceptLanguage))
+		c.Locals("locale", locale)
+		c.Set(fiber.HeaderContentLanguage, locale)
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
+
+// Locale returns the locale resolved by LocaleMiddleware, or the default locale
+func Locale(c *fiber.Ctx) string {
+	if locale, ok := c.Locals("locale").(string); ok {
+		return locale
+	}
+	return utils.DefaultLocale
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"decentragri-app-cx-server/cache"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// RateLimitConfig describes one rate limit budget
+type RateLimitConfig struct {
+	// Name separates this budget's counters from other limiters' and names its
+	// RATE_LIMIT_<NAME> override, e.g. RATE_LIMIT_FARM=60/1m
+	Name       string
+	Max        int
+	Expiration time.Duration
+	// PerUser counts requests per authenticated wallet instead of per client IP. The
+	// limiter must run after AuthMiddleware; unauthenticated requests fall back to IP.
+	// Client IPs come from c.IP(), which only reads the proxy header set by a trusted
+	// proxy (see the app's ProxyHeader and TrustedProxies).
+	PerUser bool
+}
+
+// RateLimit returns a sliding-window limiter for cfg. Counters are kept in Redis so the
+// limit holds across instances, falling back to memory when Redis is unavailable.
+func RateLimit(cfg RateLimitConfig) fiber.Handler {
+	cfg = rateLimitOverride(cfg)
+
+	return limiter.New(limiter.Config{
+		Max:               cfg.Max,
+		Expiration:        cfg.Expiration,
+		LimiterMiddleware: limiter.SlidingWindow{},
+		Storage:           cache.NewStorage("ratelimit:" + cfg.Name + ":"),
+		KeyGenerator: func(c *fiber.Ctx) string {
+			if cfg.PerUser {
+				if username, ok := c.Locals("username").(string); ok && username != "" {
+					return "user:" + strings.ToLower(username)
+				}
+			}
+			return "ip:" + c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Rate limit exceeded. Please try again later.",
+			})
+		},
+	})
+}
+
+// userRateLimit is the per-wallet limiter AuthMiddleware runs once it has verified who is
+// calling. Reads (GET, HEAD, OPTIONS) and writes draw on separate budgets, tunable with
+// RATE_LIMIT_USER_READ and RATE_LIMIT_USER_WRITE, that are shared by every route group.
+// Unlike the per-group limiters they never fall back to the client IP, so users behind
+// one carrier NAT address do not exhaust each other's quota and a single abusive account
+// is throttled on its own.
+var userRateLimit = sync.OnceValue(func() fiber.Handler {
+	read := RateLimit(RateLimitConfig{Name: "user-read", Max: 300, Expiration: time.Minute, PerUser: true})
+	write := RateLimit(RateLimitConfig{Name: "user-write", Max: 60, Expiration: time.Minute, PerUser: true})
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return read(c)
+		default:
+			return write(c)
+		}
+	}
+})
+
+// rateLimitOverride applies RATE_LIMIT_<NAME>, written as max/window ("60/1m"), to cfg
+func rateLimitOverride(cfg RateLimitConfig) RateLimitConfig {
+	env := "RATE_LIMIT_" + strings.ToUpper(strings.ReplaceAll(cfg.Name, "-", "_"))
+	v := os.Getenv(env)
+	if v == "" {
+		return cfg
+	}
+
+	maxPart, windowPart, _ := strings.Cut(v, "/")
+	limit, err := strconv.Atoi(strings.TrimSpace(maxPart))
+	if err != nil || limit <= 0 {
+		slog.Warn("ignoring rate limit override: max must be a positive integer", "env", env, "value", v)
+		return cfg
+	}
+	cfg.Max = limit
+	if windowPart != "" {
+		window, err := time.ParseDuration(strings.TrimSpace(windowPart))
+		if err != nil || window < time.Second {
+			slog.Warn("ignoring rate limit override: window must be a duration of at least 1s", "env", env, "value", v)
+			return cfg
+		}
+		cfg.Expiration = window
+	}
+	return cfg
+}
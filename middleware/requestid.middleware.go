@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"decentragri-app-cx-server/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength bounds a caller-supplied request ID
+const maxRequestIDLength = 128
+
+// RequestID gives each request an ID: the caller's X-Request-ID when it is a plausible
+// ID, else a new UUID. The ID is echoed in the X-Request-ID response header, stored in
+// c.Locals("requestId"), and carried by the request context, from which logs and
+// outbound Engine and IPFS calls pick it up. Register it after RequestContext.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(utils.RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		c.Locals("requestId", id)
+		c.Set(utils.RequestIDHeader, id)
+		c.SetUserContext(utils.WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
+
+// validRequestID accepts 1 to maxRequestIDLength letters, digits, and . _ : - so a
+// caller's ID cannot inject text into log lines or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == ':', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"os"
+	"strings"
 
 	"time"
 
+	"decentragri-app-cx-server/cache"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/helmet"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
@@ -37,17 +40,21 @@ func SetupSecurityMiddleware(app *fiber.App) {
 	// Request logging (only in development)
 	if os.Getenv("NODE_ENV") != "production" {
 		app.Use(logger.New(logger.Config{
-			Format: "[${time}] ${status} - ${method} ${path} - ${ip} - ${latency}\n",
+			Format: "[${time}] ${status} - ${method} ${path} - ${ip} - ${latency} - ${respHeader:X-Request-ID}\n",
 		}))
 	}
 
-	// Rate limiting
+	// Rate limiting; health checks and metrics are polled by probes and scrapers and skip it
 	app.Use(limiter.New(limiter.Config{
+		Next: func(c *fiber.Ctx) bool {
+			return strings.HasPrefix(c.Path(), "/health") || c.Path() == "/metrics"
+		},
 		Max:               100,              // requests
 		Expiration:        15 * time.Minute, // per 15 minutes
 		LimiterMiddleware: limiter.SlidingWindow{},
+		Storage:           cache.NewStorage("ratelimit:global:"),
 		KeyGenerator: func(c *fiber.Ctx) string {
-			return c.Get("x-forwarded-for", c.IP())
+			return c.IP()
 		},
 		LimitReached: func(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
@@ -64,6 +71,7 @@ func SetupAPIRateLimit() fiber.Handler {
 		Max:               50,               // requests
 		Expiration:        10 * time.Minute, // per 10 minutes
 		LimiterMiddleware: limiter.SlidingWindow{},
+		Storage:           cache.NewStorage("ratelimit:api:"),
 		KeyGenerator: func(c *fiber.Ctx) string {
 			// Use IP + User-Agent for more specific limiting
 			return c.Get("x-forwarded-for", c.IP()) + c.Get("User-Agent")
@@ -0,0 +1,198 @@
+package notificationservices
+
+import (
+	"context"
+	"decentragri-app-cx-server/cache"
+	memgraph "decentragri-app-cx-server/db"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// deliveryQueueKey is the Redis list holding pending push/email deliveries
+	deliveryQueueKey = "notification_delivery_queue"
+	// maxDeliveryAttempts bounds retries for a failed delivery
+	maxDeliveryAttempts = 3
+)
+
+// Delivery is a queued push or email delivery of a notification
+type Delivery struct {
+	Recipient string         `json:"recipient"`
+	Channel   string         `json:"channel"`
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Message   string         `json:"message"`
+	Data      map[string]any `json:"data,omitempty"`
+	Attempts  int            `json:"attempts"`
+}
+
+// NotifyVia delivers a notification on each requested channel. The in-app notification
+// is created immediately; push and email deliveries are queued for the delivery worker.
+func NotifyVia(channels []string, recipient, notificationType, title, message string, data map[string]any) error {
+	var firstErr error
+	for _, channel := range channels {
+		var err error
+		switch channel {
+		case ChannelInApp:
+			err = Notify(recipient, notificationType, title, message, data)
+		case ChannelPush, ChannelEmail:
+			err = enqueueDelivery(Delivery{
+				Recipient: recipient,
+				Channel:   channel,
+				Type:      notificationType,
+				Title:     title,
+				Message:   message,
+				Data:      data,
+			})
+		default:
+			err = fmt.Errorf("unknown notification channel %q", channel)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// enqueueDelivery appends a delivery to the Redis queue
+func enqueueDelivery(delivery Delivery) error {
+	if cache.RedisClient == nil {
+		return fmt.Errorf("redis is not initialized")
+	}
+	payload, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery: %w", err)
+	}
+	if err := cache.RedisClient.RPush(context.Background(), deliveryQueueKey, payload).Err(); err != nil {
+		return fmt.Errorf("failed to queue %s notification: %w", delivery.Channel, err)
+	}
+	return nil
+}
+
+// StartDeliveryWorker drains the push/email delivery queue. Failed deliveries are
+// requeued up to maxDeliveryAttempts times. It blocks, so run it in a goroutine.
+func StartDeliveryWorker() {
+	slog.Info("notification delivery worker started")
+
+	for {
+		if cache.RedisClient == nil {
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		result, err := cache.RedisClient.BLPop(context.Background(), 5*time.Second, deliveryQueueKey).Result()
+		if err != nil {
+			// redis.Nil means the wait timed out with an empty queue
+			if !errors.Is(err, redis.Nil) {
+				slog.Warn("failed to read notification queue", "error", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if len(result) < 2 {
+			continue
+		}
+
+		var delivery Delivery
+		if err := json.Unmarshal([]byte(result[1]), &delivery); err != nil {
+			slog.Warn("dropping malformed notification delivery", "error", err)
+			continue
+		}
+
+		if err := deliver(delivery); err != nil {
+			delivery.Attempts++
+			slog.Warn("notification delivery failed", "channel", delivery.Channel, "recipient", delivery.Recipient, "attempt", delivery.Attempts, "error", err)
+			if delivery.Attempts < maxDeliveryAttempts {
+				if err := enqueueDelivery(delivery); err != nil {
+					slog.Warn("failed to requeue notification delivery", "channel", delivery.Channel, "recipient", delivery.Recipient, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// deliver sends a single queued delivery on its channel
+func deliver(delivery Delivery) error {
+	switch delivery.Channel {
+	case ChannelPush:
+		return sendPush(delivery)
+	case ChannelEmail:
+		return sendEmail(delivery)
+	}
+	return fmt.Errorf("unknown notification channel %q", delivery.Channel)
+}
+
+// sendPush posts the notification to the push gateway at PUSH_WEBHOOK_URL, which resolves
+// the recipient's registered devices. Deliveries are skipped when no gateway is configured.
+func sendPush(delivery Delivery) error {
+	webhookURL := os.Getenv("PUSH_WEBHOOK_URL")
+	if webhookURL == "" {
+		return nil
+	}
+
+	agent := fiber.Post(webhookURL)
+	agent.Timeout(10 * time.Second)
+	if secret := os.Getenv("PUSH_WEBHOOK_SECRET"); secret != "" {
+		agent.Set("Authorization", "Bearer "+secret)
+	}
+	agent.JSON(map[string]any{
+		"recipient": delivery.Recipient,
+		"type":      delivery.Type,
+		"title":     delivery.Title,
+		"message":   delivery.Message,
+		"data":      delivery.Data,
+	})
+	status, body, errs := agent.Bytes()
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("push gateway returned status %d: %s", status, string(body))
+	}
+	return nil
+}
+
+// sendEmail emails the notification to the recipient's address on their User node
+// via SMTP_HOST. Deliveries are skipped when SMTP is not configured or the user has no email.
+func sendEmail(delivery Delivery) error {
+	host := os.Getenv("SMTP_HOST")
+	from := os.Getenv("SMTP_FROM")
+	if host == "" || from == "" {
+		return nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	records, err := memgraph.ExecuteRead(`MATCH (u:User {username: $username}) RETURN u.email AS email`,
+		map[string]any{"username": delivery.Recipient})
+	if err != nil {
+		return fmt.Errorf("failed to look up email: %w", err)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	v, _ := records[0].Get("email")
+	to, _ := v.(string)
+	if to == "" {
+		return nil
+	}
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		from, to, delivery.Title, delivery.Message)
+	return smtp.SendMail(host+":"+port, auth, from, []string{to}, []byte(msg))
+}
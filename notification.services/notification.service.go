@@ -0,0 +1,145 @@
+// Package notificationservices stores and serves in-app notifications for Decentragri users.
+// Notifications are (:Notification) nodes attached to the recipient with
+// (:User)-[:HAS_NOTIFICATION]->(:Notification). Other services call Notify to create
+// them; the app reads them through the /api/notifications routes.
+package notificationservices
+
+import (
+	memgraph "decentragri-app-cx-server/db"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/google/uuid"
+)
+
+// maxNotifications caps how many notifications are returned per request
+const maxNotifications = 100
+
+// Notify creates a notification for the recipient. Recipients without a User node are skipped.
+// Errors are returned so callers can decide whether a failed notification matters; most
+// callers only log them.
+func Notify(recipient, notificationType, title, message string, data map[string]any) error {
+	payload := ""
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode notification data: %w", err)
+		}
+		payload = string(encoded)
+	}
+
+	query := `MATCH (u:User {username: $username})
+		CREATE (u)-[:HAS_NOTIFICATION]->(:Notification {
+			id: $id,
+			type: $type,
+			title: $title,
+			message: $message,
+			data: $data,
+			read: false,
+			createdAt: timestamp()
+		})`
+	params := map[string]any{
+		"username": recipient,
+		"id":       uuid.NewString(),
+		"type":     notificationType,
+		"title":    title,
+		"message":  message,
+		"data":     payload,
+	}
+
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// NotifyAsync creates a notification in the background and logs any failure
+func NotifyAsync(recipient, notificationType, title, message string, data map[string]any) {
+	go func() {
+		if err := Notify(recipient, notificationType, title, message, data); err != nil {
+			slog.Warn("failed to notify", "recipient", recipient, "type", notificationType, "error", err)
+		}
+	}()
+}
+
+// GetNotifications returns the authenticated user's most recent notifications
+func GetNotifications(token string, unreadOnly bool) (*NotificationsResponse, error) {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired token: %w", err)
+	}
+
+	query := `MATCH (:User {username: $username})-[:HAS_NOTIFICATION]->(n:Notification)
+		WHERE $unreadOnly = false OR n.read = false
+		RETURN n.id AS id, n.type AS type, n.title AS title, n.message AS message,
+			n.data AS data, n.read AS read, n.createdAt AS createdAt
+		ORDER BY n.createdAt DESC
+		LIMIT $limit`
+	params := map[string]any{
+		"username":   username,
+		"unreadOnly": unreadOnly,
+		"limit":      maxNotifications,
+	}
+
+	records, err := memgraph.ExecuteRead(query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+
+	notifications := make([]Notification, 0, len(records))
+	for _, record := range records {
+		n := Notification{
+			ID:        memgraph.String(record, "id"),
+			Type:      memgraph.String(record, "type"),
+			Title:     memgraph.String(record, "title"),
+			Message:   memgraph.String(record, "message"),
+			Read:      memgraph.Bool(record, "read"),
+			CreatedAt: memgraph.Int64(record, "createdAt"),
+		}
+		if data := memgraph.String(record, "data"); data != "" {
+			n.Data = json.RawMessage(data)
+		}
+		notifications = append(notifications, n)
+	}
+
+	countQuery := `MATCH (:User {username: $username})-[:HAS_NOTIFICATION]->(n:Notification {read: false})
+		RETURN count(n) AS unread`
+	countRecords, err := memgraph.ExecuteRead(countQuery, map[string]any{"username": username})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+
+	var unread int64
+	if len(countRecords) > 0 {
+		unread = memgraph.Int64(countRecords[0], "unread")
+	}
+
+	return &NotificationsResponse{
+		Notifications: notifications,
+		UnreadCount:   unread,
+	}, nil
+}
+
+// MarkNotificationRead marks one of the authenticated user's notifications as read.
+// Passing "all" as the ID marks every notification as read.
+func MarkNotificationRead(token, notificationID string) error {
+	username, err := tokenServices.NewTokenService().VerifyAccessToken(token)
+	if err != nil {
+		return fmt.Errorf("invalid or expired token: %w", err)
+	}
+
+	query := `MATCH (:User {username: $username})-[:HAS_NOTIFICATION]->(n:Notification)
+		WHERE $id = 'all' OR n.id = $id
+		SET n.read = true`
+	summary, err := memgraph.ExecuteWrite(query, map[string]any{"username": username, "id": notificationID})
+	if err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+	if notificationID != "all" && summary != nil && summary.Counters().PropertiesSet() == 0 {
+		return fmt.Errorf("notification not found")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package notificationservices
+
+import "encoding/json"
+
+// Notification types
+const (
+	TypeOfferReceived        = "OFFER_RECEIVED"
+	TypeOfferAccepted        = "OFFER_ACCEPTED"
+	TypeWatchedListingStatus = "WATCHED_LISTING_STATUS"
+	TypeWatchedListingPrice  = "WATCHED_LISTING_PRICE"
+	TypeWatchedListingExpiry = "WATCHED_LISTING_EXPIRING"
+	TypeWatchedListingSold   = "WATCHED_LISTING_SOLD"
+	TypePlotSold             = "PLOT_SOLD"
+	TypePlotPurchased        = "PLOT_PURCHASED"
+	TypeListingExpiring      = "LISTING_EXPIRING"
+	TypeSensorThreshold      = "SENSOR_THRESHOLD"
+	TypeTaskAssigned         = "TASK_ASSIGNED"
+	TypeTaskDue              = "TASK_DUE"
+	TypeFarmShared           = "FARM_SHARED"
+	TypeIrrigationNeeded     = "IRRIGATION_NEEDED"
+	TypeScanInterpreted      = "SCAN_INTERPRETED"
+	TypeSoilReadingProblem   = "SOIL_READING_PROBLEM"
+	TypeFarmVerification     = "FARM_VERIFICATION"
+	TypeDiseaseNearby        = "DISEASE_NEARBY"
+	TypeSensorOffline        = "SENSOR_OFFLINE"
+)
+
+// Delivery channels
+const (
+	ChannelInApp = "in_app"
+	ChannelPush  = "push"
+	ChannelEmail = "email"
+)
+
+// Notification represents an in-app notification stored on a (:Notification) node
+type Notification struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Title     string          `json:"title"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Read      bool            `json:"read"`
+	CreatedAt int64           `json:"createdAt"`
+}
+
+// NotificationsResponse is returned by the notification listing endpoint
+type NotificationsResponse struct {
+	Notifications []Notification `json:"notifications"`
+	UnreadCount   int64          `json:"unreadCount"`
+}
@@ -0,0 +1,310 @@
+package portfolioservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	currencyServices "decentragri-app-cx-server/currency.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Activity item types
+const (
+	ActivityPurchase    = "purchase"     // Bought a plot (pending purchases carry their state in Status)
+	ActivitySale        = "sale"         // Sold a plot
+	ActivityListing     = "listing"      // Listed a plot or changed a listing's price
+	ActivityTransferIn  = "transfer_in"  // Received a plot outside the marketplace
+	ActivityTransferOut = "transfer_out" // Sent a plot outside the marketplace
+	ActivityMint        = "mint"         // Plot minted to the wallet
+)
+
+// zeroAddress is the ERC1155 TransferSingle/TransferBatch sender for mints
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// activitySourceLimit bounds how many of the most recent events each source contributes
+const activitySourceLimit = 500
+
+// ActivityItem is one entry in a wallet's portfolio activity feed
+type ActivityItem struct {
+	Type           string   `json:"type"`
+	TokenID        string   `json:"tokenId"`
+	ListingID      string   `json:"listingId,omitempty"`
+	Status         string   `json:"status,omitempty"` // Purchase state for purchases that have not settled
+	Price          float64  `json:"price,omitempty"`  // In the listing/offer currency
+	CurrencySymbol string   `json:"currencySymbol,omitempty"`
+	PriceUSD       float64  `json:"priceUSD,omitempty"`
+	Value          *float64 `json:"value,omitempty"` // PriceUSD in the response currency
+	Counterparty   string   `json:"counterparty,omitempty"`
+	TxHash         string   `json:"txHash,omitempty"`
+	Timestamp      int64    `json:"timestamp"`
+}
+
+// ActivityFeed is a page of a wallet's activity, newest first
+type ActivityFeed struct {
+	Wallet     string               `json:"wallet"`
+	Currency   string               `json:"currency"`
+	Items      []ActivityItem       `json:"items"`
+	Pagination utils.PaginationInfo `json:"pagination"`
+}
+
+// RecordPlotTransfer stores a farm plot Transfer event as a (:PlotTransfer) so it shows
+// up in both parties' activity. Transfers are keyed by transaction and token, so
+// redelivered webhooks are no-ops.
+func RecordPlotTransfer(tokenID, from, to, txHash string, blockNumber int64) error {
+	if tokenID == "" || txHash == "" {
+		return fmt.Errorf("transfer is missing token ID or transaction hash")
+	}
+
+	query := `MERGE (t:PlotTransfer {txHash: $txHash, tokenId: $tokenId})
+		ON CREATE SET t.from = $from,
+			t.to = $to,
+			t.blockNumber = $blockNumber,
+			t.timestamp = $timestamp`
+	params := map[string]any{
+		"txHash":      txHash,
+		"tokenId":     tokenID,
+		"from":        from,
+		"to":          to,
+		"blockNumber": blockNumber,
+		"timestamp":   time.Now().Unix(),
+	}
+	if _, err := memgraph.ExecuteWrite(query, params); err != nil {
+		return fmt.Errorf("failed to record transfer of plot %s: %w", tokenID, err)
+	}
+	return nil
+}
+
+// GetPortfolioActivity returns a page of the caller's purchases, sales, transfers, and
+// listing events across their farm plots, newest first.
+//
+// Parameters:
+//   - token: JWT authentication token
+//   - types: Optional comma-separated activity types to include (default: all)
+//   - currency: Optional fiat currency (defaults to the user's preference)
+//   - page, limit: 1-based page of items
+func GetPortfolioActivity(token, types, currency string, page, limit int) (*ActivityFeed, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := currencyServices.GetRate(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			wanted[t] = true
+		}
+	}
+
+	items, err := walletActivity(username)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := items
+	if len(wanted) > 0 {
+		filtered = make([]ActivityItem, 0, len(items))
+		for _, item := range items {
+			if wanted[item.Type] {
+				filtered = append(filtered, item)
+			}
+		}
+	}
+
+	feed := &ActivityFeed{
+		Wallet:     username,
+		Currency:   currency,
+		Pagination: utils.NewPaginationInfo(page, limit, len(filtered)),
+	}
+	start := (page - 1) * limit
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	end := start + limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+	feed.Items = make([]ActivityItem, 0, end-start)
+	for _, item := range filtered[start:end] {
+		if item.PriceUSD > 0 {
+			value := item.PriceUSD * rate
+			item.Value = &value
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed, nil
+}
+
+// walletActivity loads every activity source for a wallet, merged newest first.
+// The merged list is cached briefly in USD and paged and converted per request.
+func walletActivity(walletAddress string) ([]ActivityItem, error) {
+	cacheKey := fmt.Sprintf("portfolio_activity:%s", walletAddress)
+	var cached []ActivityItem
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	wallet := strings.ToLower(walletAddress)
+	params := map[string]any{"wallet": wallet, "limit": activitySourceLimit}
+	items := make([]ActivityItem, 0)
+
+	// Completed marketplace sales the wallet bought or sold in
+	saleTxs := make(map[string]bool)
+	records, err := memgraph.ExecuteRead(`MATCH (e:PlotPriceEvent {type: 'SALE'})
+		WHERE toLower(e.buyer) = $wallet OR toLower(e.seller) = $wallet
+		RETURN e.tokenId AS tokenId, e.listingId AS listingId, e.price AS price,
+			e.currencySymbol AS currencySymbol, e.priceUSD AS priceUSD, e.buyer AS buyer,
+			e.seller AS seller, e.txHash AS txHash, e.timestamp AS timestamp
+		ORDER BY e.timestamp DESC
+		LIMIT $limit`, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sales activity: %w", err)
+	}
+	for _, record := range records {
+		item := activityFromRecord(record)
+		buyer, _ := record.Get("buyer")
+		seller, _ := record.Get("seller")
+		if b, ok := buyer.(string); ok && strings.ToLower(b) == wallet {
+			item.Type = ActivityPurchase
+			item.Counterparty, _ = seller.(string)
+		} else {
+			item.Type = ActivitySale
+			item.Counterparty, _ = buyer.(string)
+		}
+		saleTxs[item.TxHash] = true
+		items = append(items, item)
+	}
+
+	// Purchases that have not settled yet (settled ones appear as sales above)
+	records, err = memgraph.ExecuteRead(`MATCH (p:Purchase)
+		WHERE toLower(p.buyer) = $wallet AND p.state <> 'settled'
+		RETURN p.tokenId AS tokenId, p.listingId AS listingId, p.price AS price,
+			p.currencySymbol AS currencySymbol, p.state AS status, p.seller AS counterparty,
+			p.txHash AS txHash, p.createdAt AS timestamp
+		ORDER BY p.createdAt DESC
+		LIMIT $limit`, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch purchase activity: %w", err)
+	}
+	for _, record := range records {
+		item := activityFromRecord(record)
+		if item.TxHash != "" && saleTxs[item.TxHash] {
+			// Mined purchase whose sale the webhook already recorded
+			continue
+		}
+		item.Type = ActivityPurchase
+		items = append(items, item)
+	}
+
+	// Listing prices recorded for the wallet's own listings; every indexed listing
+	// carries its seller
+	records, err = memgraph.ExecuteRead(`MATCH (l:Listing)
+		WHERE toLower(l.seller) = $wallet
+		MATCH (e:PlotPriceEvent {type: 'LISTING', listingId: l.listingId})
+		RETURN e.tokenId AS tokenId, e.listingId AS listingId, e.price AS price,
+			e.currencySymbol AS currencySymbol, e.priceUSD AS priceUSD, e.timestamp AS timestamp
+		ORDER BY e.timestamp DESC
+		LIMIT $limit`, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch listing activity: %w", err)
+	}
+	for _, record := range records {
+		item := activityFromRecord(record)
+		item.Type = ActivityListing
+		items = append(items, item)
+	}
+
+	// Transfers outside the marketplace; sale transfers are already covered above
+	records, err = memgraph.ExecuteRead(`MATCH (t:PlotTransfer)
+		WHERE toLower(t.from) = $wallet OR toLower(t.to) = $wallet
+		RETURN t.tokenId AS tokenId, t.from AS from, t.to AS to,
+			t.txHash AS txHash, t.timestamp AS timestamp
+		ORDER BY t.timestamp DESC
+		LIMIT $limit`, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transfer activity: %w", err)
+	}
+	for _, record := range records {
+		item := activityFromRecord(record)
+		if item.TxHash != "" && saleTxs[item.TxHash] {
+			continue
+		}
+		fromValue, _ := record.Get("from")
+		toValue, _ := record.Get("to")
+		from, _ := fromValue.(string)
+		to, _ := toValue.(string)
+		switch {
+		case strings.ToLower(to) == wallet && strings.EqualFold(from, zeroAddress):
+			item.Type = ActivityMint
+		case strings.ToLower(to) == wallet:
+			item.Type = ActivityTransferIn
+			item.Counterparty = from
+		default:
+			item.Type = ActivityTransferOut
+			item.Counterparty = to
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Timestamp > items[j].Timestamp
+	})
+
+	// Cache for the activity TTL; purchases and transfers invalidate it through
+	// InvalidatePortfolioCache
+	cache.SetWithTags(cacheKey, items, config.CacheTTL(config.TTLPortfolioActivity), cache.UserTag(walletAddress))
+
+	return items, nil
+}
+
+// activityFromRecord reads the columns shared by every activity query
+func activityFromRecord(record *neo4j.Record) ActivityItem {
+	var item ActivityItem
+	if v, ok := record.Get("tokenId"); ok && v != nil {
+		item.TokenID, _ = v.(string)
+	}
+	if v, ok := record.Get("listingId"); ok && v != nil {
+		item.ListingID, _ = v.(string)
+	}
+	if v, ok := record.Get("price"); ok && v != nil {
+		item.Price, _ = v.(float64)
+	}
+	if v, ok := record.Get("currencySymbol"); ok && v != nil {
+		item.CurrencySymbol, _ = v.(string)
+	}
+	if v, ok := record.Get("priceUSD"); ok && v != nil {
+		item.PriceUSD, _ = v.(float64)
+	}
+	if v, ok := record.Get("status"); ok && v != nil {
+		item.Status, _ = v.(string)
+	}
+	if v, ok := record.Get("counterparty"); ok && v != nil {
+		item.Counterparty, _ = v.(string)
+	}
+	if v, ok := record.Get("txHash"); ok && v != nil {
+		item.TxHash, _ = v.(string)
+	}
+	if v, ok := record.Get("timestamp"); ok && v != nil {
+		item.Timestamp, _ = v.(int64)
+	}
+	return item
+}
@@ -0,0 +1,202 @@
+package portfolioservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	"decentragri-app-cx-server/utils"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	currencyServices "decentragri-app-cx-server/currency.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	walletServices "decentragri-app-cx-server/wallet.services"
+)
+
+// Asset classes in the allocation breakdown
+const (
+	AssetClassFarmPlots = "farm_plots"
+	AssetClassNative    = "native"
+	AssetClassDAGRI     = "dagri"
+)
+
+// unknownAllocation labels plots whose metadata has no crop type or location
+const unknownAllocation = "unknown"
+
+// AllocationSlice is one segment of an allocation breakdown
+type AllocationSlice struct {
+	Label   string  `json:"label"`
+	Value   float64 `json:"value"`   // In the response currency
+	Percent float64 `json:"percent"` // Share of the breakdown's total
+	Count   int     `json:"count,omitempty"`
+}
+
+// PortfolioAllocation is the value distribution of a wallet's portfolio. ByAssetClass
+// covers plots and token balances; ByCropType and ByRegion split the plot value only.
+type PortfolioAllocation struct {
+	Wallet       string            `json:"wallet"`
+	Currency     string            `json:"currency"`
+	ExchangeRate float64           `json:"exchangeRate"`
+	TotalValue   float64           `json:"totalValue"`
+	PlotsValue   float64           `json:"plotsValue"`
+	ByAssetClass []AllocationSlice `json:"byAssetClass"`
+	ByCropType   []AllocationSlice `json:"byCropType"`
+	ByRegion     []AllocationSlice `json:"byRegion"`
+	LastUpdated  int64             `json:"lastUpdated"`
+}
+
+// GetPortfolioAllocation returns how the caller's portfolio value is distributed by
+// asset class, crop type, and region, for pie-chart rendering.
+//
+// Parameters:
+//   - token: JWT authentication token
+//   - currency: Optional fiat currency (defaults to the user's preference)
+func GetPortfolioAllocation(token, currency string) (*PortfolioAllocation, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("portfolio_allocation:%s:%s", username, currency)
+	var cached PortfolioAllocation
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	rate, err := currencyServices.GetRate(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	nfts, err := ownedFarmPlots(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch owned plots: %w", err)
+	}
+	tokenIDs := make([]string, 0, len(nfts))
+	for _, nft := range nfts {
+		tokenIDs = append(tokenIDs, nft.Metadata.ID)
+	}
+	plotPrices, floor, err := plotValuesUSD(tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	crops := make(map[string]*AllocationSlice)
+	regions := make(map[string]*AllocationSlice)
+	var plotsUSD float64
+	for _, nft := range nfts {
+		quantity, err := strconv.ParseFloat(nft.QuantityOwned, 64)
+		if err != nil || quantity <= 0 {
+			quantity = 1
+		}
+		price, ok := plotPrices[nft.Metadata.ID]
+		if !ok {
+			price = floor
+		}
+		value := price * quantity
+		plotsUSD += value
+
+		crop, region := plotCropAndRegion(nft.Metadata)
+		addToSlice(crops, crop, value)
+		addToSlice(regions, region, value)
+	}
+
+	nativeUSD, dagriUSD := tokenValuesUSD(username)
+	totalUSD := plotsUSD + nativeUSD + dagriUSD
+
+	allocation := &PortfolioAllocation{
+		Wallet:       username,
+		Currency:     currency,
+		ExchangeRate: rate,
+		TotalValue:   totalUSD * rate,
+		PlotsValue:   plotsUSD * rate,
+		ByAssetClass: finalizeSlices(map[string]*AllocationSlice{
+			AssetClassFarmPlots: {Label: AssetClassFarmPlots, Value: plotsUSD, Count: len(nfts)},
+			AssetClassNative:    {Label: AssetClassNative, Value: nativeUSD},
+			AssetClassDAGRI:     {Label: AssetClassDAGRI, Value: dagriUSD},
+		}, totalUSD, rate),
+		ByCropType:  finalizeSlices(crops, plotsUSD, rate),
+		ByRegion:    finalizeSlices(regions, plotsUSD, rate),
+		LastUpdated: time.Now().Unix(),
+	}
+
+	// Cache for 3 minutes, matching the portfolio summary
+	cache.SetWithTags(cacheKey, allocation, config.CacheTTL(config.TTLPortfolio), cache.UserTag(username))
+
+	return allocation, nil
+}
+
+// plotCropAndRegion reads a plot's crop type and region from either attribute style:
+// an object-style farm attribute block or trait_type/value pairs
+func plotCropAndRegion(metadata walletServices.NFTMetadata) (crop, region string) {
+	var location string
+	for _, attr := range metadata.Attributes {
+		if crop == "" && attr.CropType != "" {
+			crop = attr.CropType
+		}
+		if location == "" && attr.Location != "" {
+			location = attr.Location
+		}
+		switch attr.TraitType {
+		case "cropType":
+			if crop == "" {
+				crop = attr.Value
+			}
+		case "location":
+			if location == "" {
+				location = attr.Value
+			}
+		}
+	}
+
+	crop = strings.ToLower(strings.TrimSpace(crop))
+	if crop == "" {
+		crop = unknownAllocation
+	}
+	region = utils.RegionFromLocation(location)
+	if region == "" {
+		region = unknownAllocation
+	}
+	return crop, region
+}
+
+// addToSlice adds one plot's USD value to the slice for label
+func addToSlice(slices map[string]*AllocationSlice, label string, valueUSD float64) {
+	slice, ok := slices[label]
+	if !ok {
+		slice = &AllocationSlice{Label: label}
+		slices[label] = slice
+	}
+	slice.Value += valueUSD
+	slice.Count++
+}
+
+// finalizeSlices converts USD slices into the response currency with percentages of
+// totalUSD, largest first
+func finalizeSlices(slices map[string]*AllocationSlice, totalUSD, rate float64) []AllocationSlice {
+	result := make([]AllocationSlice, 0, len(slices))
+	for _, slice := range slices {
+		s := *slice
+		if totalUSD > 0 {
+			s.Percent = s.Value / totalUSD * 100
+		}
+		s.Value *= rate
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Value != result[j].Value {
+			return result[i].Value > result[j].Value
+		}
+		return result[i].Label < result[j].Label
+	})
+	return result
+}
@@ -0,0 +1,85 @@
+package portfolioservices
+
+import (
+	memgraph "decentragri-app-cx-server/db"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxComparePeriod bounds how far back a summary comparison may look
+const maxComparePeriod = 365 * 24 * time.Hour
+
+// ErrInvalidComparePeriod is returned for compare periods that are not Nd (up to 365d) or 1y
+var ErrInvalidComparePeriod = errors.New("compare must be Nd up to 365d, or 1y")
+
+// PortfolioComparison compares the current portfolio value with the daily snapshot
+// from the start of the period. Available is false when no snapshot is that old yet.
+type PortfolioComparison struct {
+	Period                string  `json:"period"`
+	Available             bool    `json:"available"`
+	PreviousDate          string  `json:"previousDate,omitempty"` // Snapshot day compared against (UTC, YYYY-MM-DD)
+	PreviousPlotCount     int     `json:"previousPlotCount"`
+	PreviousPlotsValueUSD float64 `json:"previousPlotsValueUSD"`
+	PreviousTotalValueUSD float64 `json:"previousTotalValueUSD"`
+	ChangeUSD             float64 `json:"changeUSD"`
+	ChangePct             float64 `json:"changePct"`
+}
+
+// parseComparePeriod accepts the history ranges (7d, 30d, 90d, 1y) or any number of
+// days as "Nd", up to a year
+func parseComparePeriod(period string) (time.Duration, error) {
+	if window, ok := historyRanges[period]; ok && window > 0 {
+		return window, nil
+	}
+	if days, err := strconv.Atoi(strings.TrimSuffix(period, "d")); err == nil && strings.HasSuffix(period, "d") && days > 0 {
+		if window := time.Duration(days) * 24 * time.Hour; window <= maxComparePeriod {
+			return window, nil
+		}
+	}
+	return 0, ErrInvalidComparePeriod
+}
+
+// comparePortfolio builds the comparison of summary against the wallet's snapshot from
+// window ago, using the latest snapshot taken on or before that day
+func comparePortfolio(walletAddress, period string, window time.Duration, summary PortfolioSummary) (*PortfolioComparison, error) {
+	comparison := &PortfolioComparison{Period: period}
+
+	since := time.Now().Add(-window).UTC().Format("2006-01-02")
+	records, err := memgraph.ExecuteRead(`MATCH (:User {username: $wallet})-[:HAS_PORTFOLIO_SNAPSHOT]->(s:PortfolioSnapshot)
+		WHERE s.date <= $date
+		RETURN s.date AS date, s.plotCount AS plotCount, s.plotsValueUSD AS plotsValueUSD,
+			s.totalValueUSD AS totalValueUSD
+		ORDER BY s.date DESC
+		LIMIT 1`, map[string]any{"wallet": walletAddress, "date": since})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch portfolio snapshot: %w", err)
+	}
+	if len(records) == 0 {
+		return comparison, nil
+	}
+
+	record := records[0]
+	comparison.Available = true
+	if v, ok := record.Get("date"); ok && v != nil {
+		comparison.PreviousDate, _ = v.(string)
+	}
+	if v, ok := record.Get("plotCount"); ok && v != nil {
+		count, _ := v.(int64)
+		comparison.PreviousPlotCount = int(count)
+	}
+	if v, ok := record.Get("plotsValueUSD"); ok && v != nil {
+		comparison.PreviousPlotsValueUSD, _ = v.(float64)
+	}
+	if v, ok := record.Get("totalValueUSD"); ok && v != nil {
+		comparison.PreviousTotalValueUSD, _ = v.(float64)
+	}
+
+	comparison.ChangeUSD = summary.TotalValueUSD - comparison.PreviousTotalValueUSD
+	if comparison.PreviousTotalValueUSD > 0 {
+		comparison.ChangePct = comparison.ChangeUSD / comparison.PreviousTotalValueUSD * 100
+	}
+	return comparison, nil
+}
@@ -0,0 +1,92 @@
+package portfolioservices
+
+import (
+	"decentragri-app-cx-server/config"
+	"log/slog"
+	"os"
+
+	walletServices "decentragri-app-cx-server/wallet.services"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Portfolio NFT collections
+const (
+	CollectionFarmPlots = "farm_plots"
+	CollectionSoilScans = "soil_scans"
+)
+
+// Contract fetch statuses
+const (
+	ContractStatusOK     = "ok"
+	ContractStatusFailed = "failed"
+)
+
+// maxConcurrentContracts bounds how many NFT contracts are queried at once
+const maxConcurrentContracts = 4
+
+// portfolioContract is an NFT contract whose holdings appear in the portfolio
+type portfolioContract struct {
+	Collection string
+	Address    string
+}
+
+// ContractStatus reports how fetching one contract's NFTs went
+type ContractStatus struct {
+	Collection string `json:"collection"`
+	Address    string `json:"address"`
+	Status     string `json:"status"` // ok or failed
+	Count      int    `json:"count"`
+	Error      string `json:"error,omitempty"`
+}
+
+// portfolioContracts lists the contracts included in the entire portfolio: farm plots,
+// plus soil scan NFTs when SOIL_SCAN_NFT is configured
+func portfolioContracts() []portfolioContract {
+	contracts := []portfolioContract{
+		{Collection: CollectionFarmPlots, Address: config.FarmPlotContractAddress},
+	}
+	if address := os.Getenv("SOIL_SCAN_NFT"); address != "" {
+		contracts = append(contracts, portfolioContract{Collection: CollectionSoilScans, Address: address})
+	}
+	return contracts
+}
+
+// fetchPortfolioContracts fetches a wallet's NFTs from every portfolio contract
+// concurrently. A failing contract is reported in its status rather than failing the
+// others; callers decide what to do when nothing succeeded.
+func fetchPortfolioContracts(walletAddress string) (map[string][]walletServices.NFTItem, []ContractStatus) {
+	contracts := portfolioContracts()
+	results := make([][]walletServices.NFTItem, len(contracts))
+	statuses := make([]ContractStatus, len(contracts))
+
+	var g errgroup.Group
+	g.SetLimit(maxConcurrentContracts)
+	for i, contract := range contracts {
+		g.Go(func() error {
+			status := ContractStatus{Collection: contract.Collection, Address: contract.Address, Status: ContractStatusOK}
+
+			response, err := walletServices.NewWalletService().GetOwnedNFTsForWallet(contract.Address, walletAddress)
+			if err != nil {
+				slog.Warn("failed to fetch NFTs", "collection", contract.Collection, "wallet", walletAddress, "error", err)
+				status.Status = ContractStatusFailed
+				status.Error = err.Error()
+			} else {
+				results[i] = response.Result
+				status.Count = len(response.Result)
+			}
+
+			statuses[i] = status
+			return nil
+		})
+	}
+	g.Wait()
+
+	nfts := make(map[string][]walletServices.NFTItem, len(contracts))
+	for i, contract := range contracts {
+		if statuses[i].Status == ContractStatusOK {
+			nfts[contract.Collection] = results[i]
+		}
+	}
+	return nfts, statuses
+}
@@ -0,0 +1,232 @@
+package portfolioservices
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Supported portfolio export formats
+const (
+	ExportFormatCSV = "csv"
+	ExportFormatPDF = "pdf"
+)
+
+// StatementHolding is one farm plot line on a portfolio statement
+type StatementHolding struct {
+	TokenID          string  `json:"tokenId"`
+	Name             string  `json:"name"`
+	Quantity         string  `json:"quantity"`
+	AcquisitionPrice float64 `json:"acquisitionPrice"` // 0 when HasCostBasis is false
+	HasCostBasis     bool    `json:"hasCostBasis"`
+	AcquiredAt       int64   `json:"acquiredAt,omitempty"`
+	CurrentValue     float64 `json:"currentValue"`
+	UnrealizedGain   float64 `json:"unrealizedGain"`
+}
+
+// PortfolioStatement is a point-in-time record of a wallet's holdings for export
+type PortfolioStatement struct {
+	Wallet         string             `json:"wallet"`
+	Currency       string             `json:"currency"`
+	GeneratedAt    int64              `json:"generatedAt"`
+	Holdings       []StatementHolding `json:"holdings"`
+	TotalValue     float64            `json:"totalValue"`
+	TotalCostBasis float64            `json:"totalCostBasis"`
+	UnrealizedGain float64            `json:"unrealizedGain"`
+	RealizedGain   float64            `json:"realizedGain"`
+}
+
+// statementColumns are the holding columns shared by the CSV and PDF statements
+var statementColumns = []string{"Token ID", "Name", "Quantity", "Acquired", "Acquisition Price", "Current Value", "Unrealized Gain"}
+
+// GetPortfolioStatement builds a statement of the caller's currently held plots
+// with acquisition prices and current values in their fiat currency
+func GetPortfolioStatement(token, currency string) (*PortfolioStatement, error) {
+	pnl, err := GetPortfolioPnL(token, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &PortfolioStatement{
+		Wallet:         pnl.Wallet,
+		Currency:       pnl.Currency,
+		GeneratedAt:    time.Now().Unix(),
+		Holdings:       make([]StatementHolding, 0, len(pnl.Assets)),
+		TotalCostBasis: pnl.CostBasis,
+		UnrealizedGain: pnl.UnrealizedGain,
+		RealizedGain:   pnl.RealizedGain,
+	}
+	for _, asset := range pnl.Assets {
+		if !asset.Held {
+			continue
+		}
+		quantity := asset.Quantity
+		if quantity == "" {
+			quantity = "1"
+		}
+		statement.Holdings = append(statement.Holdings, StatementHolding{
+			TokenID:          asset.TokenID,
+			Name:             asset.Name,
+			Quantity:         quantity,
+			AcquisitionPrice: asset.CostBasis,
+			HasCostBasis:     asset.HasCostBasis,
+			AcquiredAt:       asset.LastAcquiredAt,
+			CurrentValue:     asset.CurrentValue,
+			UnrealizedGain:   asset.UnrealizedGain,
+		})
+		statement.TotalValue += asset.CurrentValue
+	}
+
+	return statement, nil
+}
+
+// StatementFilename returns the download filename for a statement in the given format
+func StatementFilename(statement *PortfolioStatement, format string) string {
+	return fmt.Sprintf("portfolio-statement-%s.%s", time.Unix(statement.GeneratedAt, 0).UTC().Format("2006-01-02"), format)
+}
+
+// statementRow formats a holding as the cells of a statement row
+func statementRow(h StatementHolding) []string {
+	acquired, acquisitionPrice, gain := "", "", ""
+	if h.AcquiredAt > 0 {
+		acquired = time.Unix(h.AcquiredAt, 0).UTC().Format("2006-01-02")
+	}
+	if h.HasCostBasis {
+		acquisitionPrice = strconv.FormatFloat(h.AcquisitionPrice, 'f', 2, 64)
+		gain = strconv.FormatFloat(h.UnrealizedGain, 'f', 2, 64)
+	}
+	return []string{
+		h.TokenID,
+		h.Name,
+		h.Quantity,
+		acquired,
+		acquisitionPrice,
+		strconv.FormatFloat(h.CurrentValue, 'f', 2, 64),
+		gain,
+	}
+}
+
+// WriteStatementCSV writes a statement as CSV: one row per holding followed by totals.
+// Holdings without a recorded purchase leave the acquisition columns empty.
+func WriteStatementCSV(w io.Writer, statement *PortfolioStatement) error {
+	writer := csv.NewWriter(w)
+
+	header := append(append([]string{}, statementColumns...), "Currency")
+	if err := writeCSVRow(writer, header); err != nil {
+		return err
+	}
+	for _, h := range statement.Holdings {
+		if err := writeCSVRow(writer, append(statementRow(h), statement.Currency)); err != nil {
+			return err
+		}
+	}
+
+	totals := [][]string{
+		{},
+		{"Total current value", strconv.FormatFloat(statement.TotalValue, 'f', 2, 64), statement.Currency},
+		{"Total cost basis", strconv.FormatFloat(statement.TotalCostBasis, 'f', 2, 64), statement.Currency},
+		{"Unrealized gain", strconv.FormatFloat(statement.UnrealizedGain, 'f', 2, 64), statement.Currency},
+		{"Realized gain", strconv.FormatFloat(statement.RealizedGain, 'f', 2, 64), statement.Currency},
+		{"Wallet", statement.Wallet},
+		{"Generated", time.Unix(statement.GeneratedAt, 0).UTC().Format(time.RFC3339)},
+	}
+	for _, row := range totals {
+		if err := writeCSVRow(writer, row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeCSVRow writes a statement row with every cell passed through csvCell
+func writeCSVRow(writer *csv.Writer, row []string) error {
+	cells := make([]string, len(row))
+	for i, value := range row {
+		cells[i] = csvCell(value)
+	}
+	return writer.Write(cells)
+}
+
+// csvCell formats a value for a statement CSV cell. Text starting with =, +, - or @
+// is prefixed with ' so spreadsheets show it instead of evaluating it as a formula;
+// numbers such as a negative gain are left as they are.
+func csvCell(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+// WriteStatementPDF renders a statement as a single-table landscape PDF
+func WriteStatementPDF(w io.Writer, statement *PortfolioStatement) error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle("Decentragri Portfolio Statement", false)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.Cell(0, 10, "Decentragri Portfolio Statement")
+	pdf.Ln(10)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 6, "Wallet: "+statement.Wallet)
+	pdf.Ln(6)
+	pdf.Cell(0, 6, fmt.Sprintf("Generated: %s  |  Currency: %s",
+		time.Unix(statement.GeneratedAt, 0).UTC().Format("2006-01-02 15:04 MST"), statement.Currency))
+	pdf.Ln(10)
+
+	widths := []float64{25, 75, 20, 30, 40, 40, 40}
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 240, 230)
+	for i, col := range statementColumns {
+		pdf.CellFormat(widths[i], 7, col, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, h := range statement.Holdings {
+		for i, cell := range statementRow(h) {
+			align := "L"
+			if i >= 4 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, tr(cell), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	if len(statement.Holdings) == 0 {
+		pdf.CellFormat(270, 6, "No farm plots held", "1", 0, "C", false, 0, "")
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "B", 10)
+	for _, line := range [][2]string{
+		{"Total current value", fmt.Sprintf("%.2f %s", statement.TotalValue, statement.Currency)},
+		{"Total cost basis", fmt.Sprintf("%.2f %s", statement.TotalCostBasis, statement.Currency)},
+		{"Unrealized gain", fmt.Sprintf("%.2f %s", statement.UnrealizedGain, statement.Currency)},
+		{"Realized gain", fmt.Sprintf("%.2f %s", statement.RealizedGain, statement.Currency)},
+	} {
+		pdf.CellFormat(60, 6, line[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(50, 6, line[1], "", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "I", 8)
+	pdf.MultiCell(0, 4, "Acquisition prices come from marketplace purchases recorded by Decentragri. "+
+		"Plots acquired outside the marketplace have no acquisition price. Current values use the "+
+		"latest recorded sale or listing price for each plot, or the collection floor.", "", "L", false)
+
+	return pdf.Output(w)
+}
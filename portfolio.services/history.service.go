@@ -0,0 +1,480 @@
+package portfolioservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	currencyServices "decentragri-app-cx-server/currency.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	walletServices "decentragri-app-cx-server/wallet.services"
+)
+
+const (
+	// floorPriceWindow is how far back listing events count toward the collection floor
+	floorPriceWindow = 30 * 24 * time.Hour
+	// snapshotConcurrency bounds how many wallets are valued at once during a snapshot run
+	snapshotConcurrency = 5
+	// snapshotBatchSize is how many users are read per page during a snapshot run
+	snapshotBatchSize = 500
+)
+
+// historyRanges maps supported range parameters to their lookback window (0 = all snapshots)
+var historyRanges = map[string]time.Duration{
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+	"1y":  365 * 24 * time.Hour,
+	"all": 0,
+}
+
+// PortfolioValuation is the USD value of a wallet's holdings at a point in time.
+// Plots are valued at their last recorded listing or sale price, falling back to
+// the collection floor for plots that have never been priced.
+type PortfolioValuation struct {
+	Wallet         string  `json:"wallet"`
+	PlotCount      int     `json:"plotCount"`
+	PlotsValueUSD  float64 `json:"plotsValueUSD"`
+	NativeValueUSD float64 `json:"nativeValueUSD"`
+	DAGRIValueUSD  float64 `json:"dagriValueUSD"`
+	TotalValueUSD  float64 `json:"totalValueUSD"`
+	Timestamp      int64   `json:"timestamp"`
+}
+
+// PortfolioHistoryPoint is one daily snapshot converted into the caller's currency
+type PortfolioHistoryPoint struct {
+	Date        string  `json:"date"`        // Snapshot day (UTC, YYYY-MM-DD)
+	Timestamp   int64   `json:"timestamp"`   // When the snapshot was taken
+	PlotCount   int     `json:"plotCount"`   // Plots held on that day
+	PlotsValue  float64 `json:"plotsValue"`  // Plot holdings value
+	TokensValue float64 `json:"tokensValue"` // Native + DAGRI balance value
+	TotalValue  float64 `json:"totalValue"`  // Plots + tokens
+	TotalUSD    float64 `json:"totalUSD"`    // Total in USD
+}
+
+// PortfolioHistory is a value-over-time series for a wallet's portfolio
+type PortfolioHistory struct {
+	Wallet       string                  `json:"wallet"`
+	Range        string                  `json:"range"`
+	Currency     string                  `json:"currency"`
+	ExchangeRate float64                 `json:"exchangeRate"`
+	Points       []PortfolioHistoryPoint `json:"points"`
+	Change       float64                 `json:"change"`        // Last total minus first total
+	ChangePct    float64                 `json:"changePercent"` // Change relative to the first total
+}
+
+// ValuePortfolio computes the current USD value of a wallet's farm plots and token balances
+func ValuePortfolio(walletAddress string) (*PortfolioValuation, error) {
+	nfts, err := walletServices.NewWalletService().GetOwnedNFTsForWallet(config.FarmPlotContractAddress, walletAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch owned plots: %w", err)
+	}
+
+	tokenIDs := make([]string, 0, len(nfts.Result))
+	for _, nft := range nfts.Result {
+		tokenIDs = append(tokenIDs, nft.Metadata.ID)
+	}
+	plotPrices, floor, err := plotValuesUSD(tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	valuation := &PortfolioValuation{
+		Wallet:    walletAddress,
+		PlotCount: len(nfts.Result),
+		Timestamp: time.Now().Unix(),
+	}
+	for _, nft := range nfts.Result {
+		quantity, err := strconv.ParseFloat(nft.QuantityOwned, 64)
+		if err != nil || quantity <= 0 {
+			quantity = 1
+		}
+		price, ok := plotPrices[nft.Metadata.ID]
+		if !ok {
+			price = floor
+		}
+		valuation.PlotsValueUSD += price * quantity
+	}
+
+	valuation.NativeValueUSD, valuation.DAGRIValueUSD = tokenValuesUSD(walletAddress)
+	valuation.TotalValueUSD = valuation.PlotsValueUSD + valuation.NativeValueUSD + valuation.DAGRIValueUSD
+	return valuation, nil
+}
+
+// tokenValuesUSD returns the USD value of a wallet's native and DAGRI balances.
+// Balances are best-effort; a failed read values that token at zero.
+func tokenValuesUSD(walletAddress string) (nativeUSD, dagriUSD float64) {
+	chainInt, err := strconv.Atoi(config.CHAIN)
+	if err != nil {
+		slog.Warn("invalid chain ID", "chainId", config.CHAIN, "error", err)
+		return 0, 0
+	}
+
+	if balance, err := walletServices.GetBalance(config.CHAIN, walletAddress); err == nil {
+		amount, _ := strconv.ParseFloat(balance.Result.DisplayValue, 64)
+		if price, err := walletServices.GetTokenPriceUSD(chainInt, ""); err == nil {
+			nativeUSD = amount * price
+		}
+	} else {
+		slog.Warn("failed to read native balance", "wallet", walletAddress, "error", err)
+	}
+	if balance, err := walletServices.GetERC20Balance(config.CHAIN, config.DAGRIContractAddress, walletAddress); err == nil {
+		amount, _ := strconv.ParseFloat(balance.Result.DisplayValue, 64)
+		if price, err := walletServices.GetTokenPriceUSD(chainInt, config.DAGRIContractAddress); err == nil {
+			dagriUSD = amount * price
+		}
+	} else {
+		slog.Warn("failed to read DAGRI balance", "wallet", walletAddress, "error", err)
+	}
+	return nativeUSD, dagriUSD
+}
+
+// plotValuesUSD returns the latest recorded USD price per plot and the collection
+// floor (cheapest recent listing) used for plots without any price history
+func plotValuesUSD(tokenIDs []string) (map[string]float64, float64, error) {
+	prices := make(map[string]float64)
+	if len(tokenIDs) == 0 {
+		return prices, 0, nil
+	}
+
+	query := `MATCH (e:PlotPriceEvent)
+		WHERE e.tokenId IN $tokenIds AND e.priceUSD > 0
+		WITH e ORDER BY e.timestamp DESC
+		WITH e.tokenId AS tokenId, collect(e.priceUSD)[0] AS priceUSD
+		RETURN tokenId, priceUSD`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"tokenIds": tokenIDs})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read plot prices: %w", err)
+	}
+	for _, record := range records {
+		tokenID, _ := record.Get("tokenId")
+		price, _ := record.Get("priceUSD")
+		id, ok := tokenID.(string)
+		if !ok {
+			continue
+		}
+		if p, ok := price.(float64); ok {
+			prices[id] = p
+		}
+	}
+
+	floorQuery := `MATCH (e:PlotPriceEvent {type: 'LISTING'})
+		WHERE e.timestamp >= $since AND e.priceUSD > 0
+		RETURN min(e.priceUSD) AS floor`
+	records, err = memgraph.ExecuteRead(floorQuery, map[string]any{
+		"since": time.Now().Add(-floorPriceWindow).Unix(),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read floor price: %w", err)
+	}
+	var floor float64
+	if len(records) > 0 {
+		if v, ok := records[0].Get("floor"); ok && v != nil {
+			floor, _ = v.(float64)
+		}
+	}
+
+	return prices, floor, nil
+}
+
+// snapshotStatement stores a snapshot row as the (:PortfolioSnapshot) of its wallet and
+// day, linked to the wallet's user by HAS_PORTFOLIO_SNAPSHOT. Snapshots are keyed by
+// wallet and UTC day, so repeated runs on the same day overwrite that day's point
+// rather than adding new ones.
+const snapshotStatement = `MATCH (u:User {username: row.wallet})
+	MERGE (s:PortfolioSnapshot {wallet: row.wallet, date: row.date})
+	SET s.plotCount = row.plotCount,
+		s.plotsValueUSD = row.plotsValueUSD,
+		s.nativeValueUSD = row.nativeValueUSD,
+		s.dagriValueUSD = row.dagriValueUSD,
+		s.totalValueUSD = row.totalValueUSD,
+		s.timestamp = row.timestamp
+	MERGE (u)-[:HAS_PORTFOLIO_SNAPSHOT]->(s)`
+
+// SnapshotPortfolio values a wallet and stores the result as today's (:PortfolioSnapshot)
+func SnapshotPortfolio(walletAddress string) (*PortfolioValuation, error) {
+	valuation, err := ValuePortfolio(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := memgraph.BulkWrite(snapshotStatement, []map[string]any{snapshotRow(walletAddress, valuation)}, nil); err != nil {
+		return nil, fmt.Errorf("failed to store portfolio snapshot: %w", err)
+	}
+	return valuation, nil
+}
+
+// snapshotRow returns the snapshotStatement row that stores a wallet's valuation
+func snapshotRow(walletAddress string, valuation *PortfolioValuation) map[string]any {
+	return map[string]any{
+		"wallet":         walletAddress,
+		"date":           time.Unix(valuation.Timestamp, 0).UTC().Format("2006-01-02"),
+		"plotCount":      valuation.PlotCount,
+		"plotsValueUSD":  valuation.PlotsValueUSD,
+		"nativeValueUSD": valuation.NativeValueUSD,
+		"dagriValueUSD":  valuation.DAGRIValueUSD,
+		"totalValueUSD":  valuation.TotalValueUSD,
+		"timestamp":      valuation.Timestamp,
+	}
+}
+
+// SnapshotAllPortfolios takes today's snapshot for every registered user. Users are
+// read in batches so large user bases are not loaded into memory at once; each batch is
+// valued concurrently and stored in one bulk write.
+func SnapshotAllPortfolios() error {
+	start := time.Now()
+	var total, failed int
+
+	for offset := 0; ; offset += snapshotBatchSize {
+		records, err := memgraph.ExecuteRead(`MATCH (u:User) WHERE u.username IS NOT NULL
+			RETURN u.username AS username
+			ORDER BY u.username
+			SKIP $offset LIMIT $limit`, map[string]any{"offset": offset, "limit": snapshotBatchSize})
+		if err != nil {
+			return fmt.Errorf("failed to list users for portfolio snapshot: %w", err)
+		}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		rows := make([]map[string]any, 0, len(records))
+		semaphore := make(chan struct{}, snapshotConcurrency)
+		for _, record := range records {
+			v, _ := record.Get("username")
+			wallet, ok := v.(string)
+			if !ok || wallet == "" {
+				continue
+			}
+
+			total++
+			wg.Add(1)
+			semaphore <- struct{}{}
+			go func(wallet string) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+				valuation, err := ValuePortfolio(wallet)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					slog.Warn("portfolio snapshot failed", "wallet", wallet, "error", err)
+					failed++
+					return
+				}
+				rows = append(rows, snapshotRow(wallet, valuation))
+			}(wallet)
+		}
+		wg.Wait()
+
+		if _, err := memgraph.BulkWrite(snapshotStatement, rows, nil); err != nil {
+			slog.Warn("failed to store portfolio snapshots", "count", len(rows), "error", err)
+			failed += len(rows)
+		}
+
+		if len(records) < snapshotBatchSize {
+			break
+		}
+	}
+
+	slog.Info("portfolio snapshot run complete", "users", total, "failed", failed, "elapsed", time.Since(start).Round(time.Second))
+	return nil
+}
+
+// snapshotSchedule returns the UTC hour and minute of the nightly snapshot run
+// (PORTFOLIO_SNAPSHOT_TIME as HH:MM, default 00:05)
+func snapshotSchedule() (hour, minute int) {
+	hour, minute = 0, 5
+	if v := os.Getenv("PORTFOLIO_SNAPSHOT_TIME"); v != "" {
+		if t, err := time.Parse("15:04", v); err == nil {
+			hour, minute = t.Hour(), t.Minute()
+		} else {
+			slog.Warn("invalid PORTFOLIO_SNAPSHOT_TIME, using 00:05", "value", v)
+		}
+	}
+	return hour, minute
+}
+
+// nextSnapshotRun returns the first scheduled run time after now
+func nextSnapshotRun(now time.Time, hour, minute int) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// runDailySnapshot snapshots every portfolio unless another instance already did so
+// for today; the Redis claim makes the nightly run happen once across instances
+func runDailySnapshot() {
+	date := time.Now().UTC().Format("2006-01-02")
+	claimed, err := cache.SetNX("portfolio_snapshot_run:"+date, true, 25*time.Hour)
+	if err == nil && !claimed {
+		slog.Info("portfolio snapshot already taken, skipping", "date", date)
+		return
+	}
+
+	if err := SnapshotAllPortfolios(); err != nil {
+		slog.Warn("portfolio snapshot run failed", "date", date, "error", err)
+	}
+}
+
+// StartPortfolioSnapshotter snapshots every user's portfolio nightly at
+// PORTFOLIO_SNAPSHOT_TIME (UTC, default 00:05). If today's run is missing when the
+// server starts, it runs immediately. It blocks, so run it in a goroutine.
+func StartPortfolioSnapshotter() {
+	hour, minute := snapshotSchedule()
+	slog.Info("portfolio snapshotter started", "hour", hour, "minute", minute)
+
+	// Catch up after downtime; snapshots are keyed per day so this never duplicates points
+	runDailySnapshot()
+
+	for {
+		next := nextSnapshotRun(time.Now(), hour, minute)
+		time.Sleep(time.Until(next))
+		runDailySnapshot()
+	}
+}
+
+// GetPortfolioHistory returns the caller's daily portfolio snapshots over a range,
+// converted into their fiat currency. Snapshots come from the nightly snapshotter;
+// only wallets without any snapshot in the range get one taken on demand, so new
+// users see a first point immediately.
+//
+// Parameters:
+//   - token: JWT authentication token
+//   - rangeParam: One of 7d, 30d, 90d, 1y, all (defaults to 30d)
+//   - currency: Optional fiat currency (defaults to the user's preference)
+func GetPortfolioHistory(token, rangeParam, currency string) (*PortfolioHistory, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if rangeParam == "" {
+		rangeParam = "30d"
+	}
+	window, ok := historyRanges[rangeParam]
+	if !ok {
+		return nil, fmt.Errorf("unsupported range: %s", rangeParam)
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("portfolio_history:%s:%s:%s", username, rangeParam, currency)
+	var cached PortfolioHistory
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	rate, err := currencyServices.GetRate(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := readSnapshots(username, window)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		if _, err := SnapshotPortfolio(username); err != nil {
+			slog.Warn("on-demand portfolio snapshot failed", "username", username, "error", err)
+		} else if records, err = readSnapshots(username, window); err != nil {
+			return nil, err
+		}
+	}
+
+	history := &PortfolioHistory{
+		Wallet:       username,
+		Range:        rangeParam,
+		Currency:     currency,
+		ExchangeRate: rate,
+		Points:       make([]PortfolioHistoryPoint, 0, len(records)),
+	}
+	for _, s := range records {
+		tokensUSD := s.NativeValueUSD + s.DAGRIValueUSD
+		history.Points = append(history.Points, PortfolioHistoryPoint{
+			Date:        s.Date,
+			Timestamp:   s.Timestamp,
+			PlotCount:   s.PlotCount,
+			PlotsValue:  s.PlotsValueUSD * rate,
+			TokensValue: tokensUSD * rate,
+			TotalValue:  s.TotalValueUSD * rate,
+			TotalUSD:    s.TotalValueUSD,
+		})
+	}
+
+	if n := len(history.Points); n > 1 {
+		first, last := history.Points[0].TotalValue, history.Points[n-1].TotalValue
+		history.Change = last - first
+		if first > 0 {
+			history.ChangePct = (last - first) / first * 100
+		}
+	}
+
+	// Snapshots change at most daily; a short TTL keeps currency switches responsive
+	cache.SetWithTags(cacheKey, history, config.CacheTTL(config.TTLPortfolioHistory), cache.UserTag(username))
+
+	return history, nil
+}
+
+// portfolioSnapshot is a stored daily valuation read back from Memgraph
+type portfolioSnapshot struct {
+	PortfolioValuation
+	Date string
+}
+
+// readSnapshots loads the snapshots linked to a wallet's user within the window, oldest first
+func readSnapshots(walletAddress string, window time.Duration) ([]portfolioSnapshot, error) {
+	var from int64
+	if window > 0 {
+		from = time.Now().Add(-window).Unix()
+	}
+
+	query := `MATCH (:User {username: $wallet})-[:HAS_PORTFOLIO_SNAPSHOT]->(s:PortfolioSnapshot)
+		WHERE s.timestamp >= $from
+		RETURN s.date AS date, s.timestamp AS timestamp, s.plotCount AS plotCount,
+			s.plotsValueUSD AS plotsValueUSD, s.nativeValueUSD AS nativeValueUSD,
+			s.dagriValueUSD AS dagriValueUSD, s.totalValueUSD AS totalValueUSD
+		ORDER BY s.date ASC`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"wallet": walletAddress, "from": from})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch portfolio history: %w", err)
+	}
+
+	snapshots := make([]portfolioSnapshot, 0, len(records))
+	for _, record := range records {
+		s := portfolioSnapshot{PortfolioValuation: PortfolioValuation{Wallet: walletAddress}}
+		if v, ok := record.Get("date"); ok && v != nil {
+			s.Date = v.(string)
+		}
+		if v, ok := record.Get("timestamp"); ok && v != nil {
+			s.Timestamp = v.(int64)
+		}
+		if v, ok := record.Get("plotCount"); ok && v != nil {
+			s.PlotCount = int(v.(int64))
+		}
+		if v, ok := record.Get("plotsValueUSD"); ok && v != nil {
+			s.PlotsValueUSD = v.(float64)
+		}
+		if v, ok := record.Get("nativeValueUSD"); ok && v != nil {
+			s.NativeValueUSD = v.(float64)
+		}
+		if v, ok := record.Get("dagriValueUSD"); ok && v != nil {
+			s.DAGRIValueUSD = v.(float64)
+		}
+		if v, ok := record.Get("totalValueUSD"); ok && v != nil {
+			s.TotalValueUSD = v.(float64)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,149 @@
+package portfolioservices
+
+import (
+	"crypto/md5"
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	"decentragri-app-cx-server/utils"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	walletServices "decentragri-app-cx-server/wallet.services"
+)
+
+// Image delivery modes for portfolio responses
+const (
+	ImagesModeBytes = "bytes" // Embed image bytes in the response (default, backward compatible)
+	ImagesModeURL   = "url"   // Return proxy URLs served by GET /api/images/:key
+)
+
+// thumbnailEdge is the longest edge of WebP thumbnails, in pixels
+var thumbnailEdge = utils.ImageSizes["small"]
+
+// imageSourceTTL is how long a proxy key stays resolvable after it was last handed out
+const imageSourceTTL = 7 * 24 * time.Hour
+
+// ErrImageNotFound is returned for proxy keys that were never issued or have expired
+var ErrImageNotFound = errors.New("image not found")
+
+// imageKey returns the proxy key for an image URL; it matches the image byte cache key
+func imageKey(httpURL string) string {
+	hash := md5.Sum([]byte(httpURL))
+	return hex.EncodeToString(hash[:])
+}
+
+// imageCacheKey is the Redis key holding an image's original bytes
+func imageCacheKey(httpURL string) string {
+	return "image:" + imageKey(httpURL)
+}
+
+// thumbnailCacheKey is the Redis key holding an image's WebP thumbnail at a quality
+func thumbnailCacheKey(httpURL string, quality int) string {
+	return fmt.Sprintf("image:%s:webp:%d", imageKey(httpURL), quality)
+}
+
+// ImageProxyURL registers an image URI with the proxy and returns the URL clients use
+// to load it. Only registered URIs can be fetched through the proxy, so it cannot be
+// used to relay arbitrary URLs. The URL is relative unless PUBLIC_BASE_URL is set.
+// A non-zero quality requests a WebP thumbnail instead of the original image.
+func ImageProxyURL(imageURI string, quality int) string {
+	httpURL := BuildIpfsUri(imageURI)
+	key := imageKey(httpURL)
+	cache.Set("image_source:"+key, httpURL, imageSourceTTL)
+
+	proxyURL := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/") + "/api/images/" + key
+	if quality > 0 {
+		proxyURL += "?quality=" + strconv.Itoa(quality)
+	}
+	return proxyURL
+}
+
+// FetchWebPThumbnail returns a WebP thumbnail of the image at httpURL. Thumbnails are
+// generated from the cached original on first use and cached per quality.
+func FetchWebPThumbnail(httpURL string, quality int) ([]byte, error) {
+	cacheKey := thumbnailCacheKey(httpURL, quality)
+	if cached, err := cache.GetBytes(cacheKey); err == nil && len(cached) > 0 {
+		return cached, nil
+	}
+
+	original, err := FetchImageBytes(httpURL)
+	if err != nil {
+		return nil, err
+	}
+	thumbnail, err := utils.WebPThumbnail(original, thumbnailEdge, quality)
+	if err != nil {
+		return nil, err
+	}
+
+	// Thumbnails are derived data, so they can outlive the source image cache
+	cache.SetBytes(cacheKey, thumbnail, config.CacheTTL(config.TTLImageVariants))
+
+	return thumbnail, nil
+}
+
+// GetProxiedImage returns the bytes and content type for a proxy key, served from
+// the image cache when possible. A non-zero quality returns a WebP thumbnail.
+func GetProxiedImage(key string, quality int) ([]byte, string, error) {
+	if len(key) != md5.Size*2 {
+		return nil, "", ErrImageNotFound
+	}
+
+	var httpURL string
+	if err := cache.Get("image_source:"+key, &httpURL); err != nil || httpURL == "" {
+		return nil, "", ErrImageNotFound
+	}
+
+	if quality > 0 {
+		thumbnail, err := FetchWebPThumbnail(httpURL, quality)
+		if err == nil {
+			return thumbnail, "image/webp", nil
+		}
+		// Formats the decoder does not support are served as-is
+		slog.Warn("failed to build WebP thumbnail", "key", key, "error", err)
+	}
+
+	imageBytes, err := FetchImageBytes(httpURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load image: %w", err)
+	}
+
+	return imageBytes, http.DetectContentType(imageBytes), nil
+}
+
+// ConvertNFTsWithImageURLs is the URL-mode counterpart of ConvertNFTsWithImages: each
+// NFT gets an ImageURL pointing at the image proxy instead of embedded bytes, so no
+// images are fetched while building the response. A non-zero quality makes the URLs
+// serve WebP thumbnails.
+func ConvertNFTsWithImageURLs(nftItems []walletServices.NFTItem, quality int) []NFTItemWithImageBytes {
+	result := make([]NFTItemWithImageBytes, len(nftItems))
+	for i, item := range nftItems {
+		result[i] = NFTItemWithImageBytes{
+			Metadata:      item.Metadata,
+			Owner:         item.Owner,
+			Type:          item.Type,
+			Supply:        item.Supply,
+			QuantityOwned: item.QuantityOwned,
+		}
+		if imageURI := nftImageURI(item.Metadata); imageURI != "" {
+			result[i].ImageURL = ImageProxyURL(imageURI, quality)
+		}
+	}
+	return result
+}
+
+// nftImageURI returns the image URI for an NFT: the "image" attribute first, then the metadata URI
+func nftImageURI(metadata walletServices.NFTMetadata) string {
+	for _, attr := range metadata.Attributes {
+		if attr.TraitType == "image" && attr.Value != "" {
+			return attr.Value
+		}
+	}
+	return metadata.URI
+}
@@ -0,0 +1,238 @@
+package portfolioservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	currencyServices "decentragri-app-cx-server/currency.services"
+	tokenServices "decentragri-app-cx-server/token.services"
+	walletServices "decentragri-app-cx-server/wallet.services"
+)
+
+// PlotPnL is the cost basis and gain for one farm plot the wallet holds or has held.
+// Amounts are in the response currency. Plots acquired outside the marketplace
+// (mints, transfers) have no cost basis and are left out of unrealized totals.
+type PlotPnL struct {
+	TokenID        string   `json:"tokenId"`
+	Name           string   `json:"name,omitempty"`
+	Quantity       string   `json:"quantity,omitempty"`       // Quantity currently owned
+	Held           bool     `json:"held"`                     // Currently owned by the wallet
+	HasCostBasis   bool     `json:"hasCostBasis"`             // A marketplace purchase was recorded
+	CostBasis      float64  `json:"costBasis"`                // Price paid for the current holding
+	CurrentValue   float64  `json:"currentValue"`             // Last sale/listing price or floor; 0 once sold
+	UnrealizedGain float64  `json:"unrealizedGain"`           // CurrentValue - CostBasis while held
+	UnrealizedPct  *float64 `json:"unrealizedPct,omitempty"`  // Relative to CostBasis
+	RealizedGain   float64  `json:"realizedGain"`             // Sum of (sale - purchase) over closed positions
+	Proceeds       float64  `json:"proceeds"`                 // Total received from sales
+	LastAcquiredAt int64    `json:"lastAcquiredAt,omitempty"` // Timestamp of the latest recorded purchase
+}
+
+// PortfolioPnL is the wallet-wide profit and loss with a per-plot breakdown
+type PortfolioPnL struct {
+	Wallet         string    `json:"wallet"`
+	Currency       string    `json:"currency"`
+	ExchangeRate   float64   `json:"exchangeRate"`
+	CostBasis      float64   `json:"costBasis"`      // Cost of held plots with a known basis
+	CurrentValue   float64   `json:"currentValue"`   // Value of held plots with a known basis
+	UnrealizedGain float64   `json:"unrealizedGain"` // CurrentValue - CostBasis
+	RealizedGain   float64   `json:"realizedGain"`
+	TotalGain      float64   `json:"totalGain"`
+	TotalGainPct   *float64  `json:"totalGainPct,omitempty"` // TotalGain relative to all capital spent on purchases
+	Assets         []PlotPnL `json:"assets"`
+	LastUpdated    int64     `json:"lastUpdated"`
+}
+
+// plotTrade is a recorded marketplace sale the wallet took part in
+type plotTrade struct {
+	TokenID   string
+	Buyer     string
+	Seller    string
+	PriceUSD  float64
+	Timestamp int64
+}
+
+// GetPortfolioPnL computes realized and unrealized gains for the caller's farm plots
+// from recorded marketplace sales. Each plot's cost basis is the price of the
+// wallet's most recent purchase; selling closes the position and realizes the
+// difference between the sale and that purchase.
+//
+// Parameters:
+//   - token: JWT authentication token
+//   - currency: Optional fiat currency (defaults to the user's preference)
+func GetPortfolioPnL(token, currency string) (*PortfolioPnL, error) {
+	username, err := tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("portfolio_pnl:%s:%s", username, currency)
+	var cached PortfolioPnL
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	rate, err := currencyServices.GetRate(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	nfts, err := walletServices.NewWalletService().GetOwnedNFTsForWallet(config.FarmPlotContractAddress, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch owned plots: %w", err)
+	}
+
+	trades, err := walletTrades(username)
+	if err != nil {
+		return nil, err
+	}
+
+	held := make(map[string]walletServices.NFTItem, len(nfts.Result))
+	tokenIDs := make([]string, 0, len(nfts.Result))
+	for _, nft := range nfts.Result {
+		held[nft.Metadata.ID] = nft
+		tokenIDs = append(tokenIDs, nft.Metadata.ID)
+	}
+	plotPrices, floor, err := plotValuesUSD(tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay trades per plot, oldest first, tracking the open position's cost
+	assets := make(map[string]*PlotPnL)
+	openCost := make(map[string]float64)
+	var capitalUSD float64
+	wallet := strings.ToLower(username)
+	for _, t := range trades {
+		asset, ok := assets[t.TokenID]
+		if !ok {
+			asset = &PlotPnL{TokenID: t.TokenID}
+			assets[t.TokenID] = asset
+		}
+
+		switch {
+		case strings.ToLower(t.Buyer) == wallet:
+			openCost[t.TokenID] = t.PriceUSD
+			asset.LastAcquiredAt = t.Timestamp
+			capitalUSD += t.PriceUSD
+		case strings.ToLower(t.Seller) == wallet:
+			asset.Proceeds += t.PriceUSD
+			if cost, ok := openCost[t.TokenID]; ok {
+				asset.RealizedGain += t.PriceUSD - cost
+				delete(openCost, t.TokenID)
+			}
+		}
+	}
+
+	for tokenID := range held {
+		if _, ok := assets[tokenID]; !ok {
+			assets[tokenID] = &PlotPnL{TokenID: tokenID}
+		}
+	}
+
+	pnl := &PortfolioPnL{
+		Wallet:       username,
+		Currency:     currency,
+		ExchangeRate: rate,
+		Assets:       make([]PlotPnL, 0, len(assets)),
+		LastUpdated:  time.Now().Unix(),
+	}
+	for tokenID, asset := range assets {
+		nft, isHeld := held[tokenID]
+		asset.Held = isHeld
+		asset.Name = nft.Metadata.Name
+		asset.Quantity = nft.QuantityOwned
+
+		if isHeld {
+			value, ok := plotPrices[tokenID]
+			if !ok {
+				value = floor
+			}
+			asset.CurrentValue = value
+		}
+		cost, hasOpen := openCost[tokenID]
+		asset.HasCostBasis = isHeld && hasOpen
+		if asset.HasCostBasis {
+			asset.CostBasis = cost
+			asset.UnrealizedGain = asset.CurrentValue - cost
+			if cost > 0 {
+				pct := asset.UnrealizedGain / cost * 100
+				asset.UnrealizedPct = &pct
+			}
+			pnl.CostBasis += cost
+			pnl.CurrentValue += asset.CurrentValue
+		}
+		pnl.RealizedGain += asset.RealizedGain
+
+		// Convert the per-asset figures into the response currency
+		asset.CostBasis *= rate
+		asset.CurrentValue *= rate
+		asset.UnrealizedGain *= rate
+		asset.RealizedGain *= rate
+		asset.Proceeds *= rate
+		pnl.Assets = append(pnl.Assets, *asset)
+	}
+
+	pnl.UnrealizedGain = (pnl.CurrentValue - pnl.CostBasis) * rate
+	pnl.RealizedGain *= rate
+	pnl.CostBasis *= rate
+	pnl.CurrentValue *= rate
+	pnl.TotalGain = pnl.UnrealizedGain + pnl.RealizedGain
+	if capitalUSD > 0 {
+		pct := pnl.TotalGain / (capitalUSD * rate) * 100
+		pnl.TotalGainPct = &pct
+	}
+
+	// Held plots first, then by token ID for a stable order
+	sort.Slice(pnl.Assets, func(i, j int) bool {
+		if pnl.Assets[i].Held != pnl.Assets[j].Held {
+			return pnl.Assets[i].Held
+		}
+		return pnl.Assets[i].TokenID < pnl.Assets[j].TokenID
+	})
+
+	// Cache for 3 minutes, matching the portfolio summary
+	cache.SetWithTags(cacheKey, pnl, config.CacheTTL(config.TTLPortfolio), cache.UserTag(username))
+
+	return pnl, nil
+}
+
+// walletTrades loads every recorded plot sale the wallet bought or sold in, oldest first
+func walletTrades(walletAddress string) ([]plotTrade, error) {
+	query := `MATCH (e:PlotPriceEvent {type: 'SALE'})
+		WHERE toLower(e.buyer) = $wallet OR toLower(e.seller) = $wallet
+		RETURN e.tokenId AS tokenId, e.buyer AS buyer, e.seller AS seller,
+			e.priceUSD AS priceUSD, e.timestamp AS timestamp
+		ORDER BY e.timestamp ASC`
+	records, err := memgraph.ExecuteRead(query, map[string]any{"wallet": strings.ToLower(walletAddress)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trade history: %w", err)
+	}
+
+	trades := make([]plotTrade, 0, len(records))
+	for _, record := range records {
+		t := plotTrade{
+			TokenID:   memgraph.String(record, "tokenId"),
+			Buyer:     memgraph.String(record, "buyer"),
+			Seller:    memgraph.String(record, "seller"),
+			Timestamp: memgraph.Int64(record, "timestamp"),
+		}
+		t.PriceUSD, _ = memgraph.Float64(record, "priceUSD")
+		if t.TokenID == "" {
+			continue
+		}
+		trades = append(trades, t)
+	}
+	return trades, nil
+}
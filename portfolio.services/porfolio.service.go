@@ -20,12 +20,13 @@
 package portfolioservices
 
 import (
-	"crypto/md5"
 	"decentragri-app-cx-server/cache"
 	"decentragri-app-cx-server/config"
-	"encoding/hex"
+	"decentragri-app-cx-server/utils"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -47,6 +48,10 @@ type ByteArray []uint8
 //
 // Fields:
 //   - FarmPlotNFTCount: Total number of farm plot NFTs owned by the user
+//   - Native, DAGRI: Token balances with USD values (omitted when balances are unavailable)
+//   - PlotsValueUSD: Farm plots at their last sale/listing price, or the collection floor
+//   - TotalValueUSD: Plots plus token balances
+//   - Comparison: Value change against a past snapshot (only with ?compare=)
 //
 // Usage:
 //   - Dashboard summary displays
@@ -54,7 +59,12 @@ type ByteArray []uint8
 //   - Portfolio health indicators
 //   - Performance tracking
 type PortfolioSummary struct {
-	FarmPlotNFTCount int `json:"farmPlotNFTCount"`
+	FarmPlotNFTCount int                          `json:"farmPlotNFTCount"`
+	Native           *walletServices.TokenBalance `json:"native,omitempty"`
+	DAGRI            *walletServices.TokenBalance `json:"dagri,omitempty"`
+	PlotsValueUSD    float64                      `json:"plotsValueUSD"`
+	TotalValueUSD    float64                      `json:"totalValueUSD"`
+	Comparison       *PortfolioComparison         `json:"comparison,omitempty"` // Set when compare was requested
 }
 
 // NFTItemWithImageBytes extends the standard NFT item structure with image data.
@@ -80,6 +90,8 @@ type NFTItemWithImageBytes struct {
 	Supply        string                     `json:"supply"`               // Total token supply
 	QuantityOwned string                     `json:"quantityOwned"`        // User's owned quantity
 	ImageBytes    ByteArray                  `json:"imageBytes,omitempty"` // Binary image data
+	ImageURL      string                     `json:"imageUrl,omitempty"`   // Image proxy URL (images=url mode)
+	Projection    *YieldProjection           `json:"projection,omitempty"` // Projected annual yield and income
 }
 
 // EntirePortfolio represents a user's complete NFT portfolio with enhanced data.
@@ -88,6 +100,10 @@ type NFTItemWithImageBytes struct {
 //
 // Portfolio Categories:
 //   - FarmPlotNFTs: Agricultural plot NFTs with farming utility
+//   - SoilScanNFTs: Soil scan NFTs (when SOIL_SCAN_NFT is configured)
+//
+// Contracts reports per-contract fetch status, so a failing contract leaves its
+// category empty instead of failing the whole portfolio.
 //
 // Features:
 //   - Complete portfolio aggregation
@@ -95,7 +111,11 @@ type NFTItemWithImageBytes struct {
 //   - Performance-optimized structure
 //   - Category-based organization
 type EntirePortfolio struct {
-	FarmPlotNFTs []NFTItemWithImageBytes `json:"farmPlotNFTs"`
+	FarmPlotNFTs       []NFTItemWithImageBytes `json:"farmPlotNFTs"`
+	SoilScanNFTs       []NFTItemWithImageBytes `json:"soilScanNFTs,omitempty"`
+	ProjectedIncomeUSD float64                 `json:"projectedIncomeUSD"`   // Projected annual income of the returned plots
+	Contracts          []ContractStatus        `json:"contracts,omitempty"`  // Per-contract fetch status (whole-portfolio requests)
+	Pagination         *utils.PaginationInfo   `json:"pagination,omitempty"` // Set when a page was requested
 }
 
 // GetPortFolioSummary retrieves high-level portfolio statistics for an authenticated user.
@@ -105,7 +125,7 @@ type EntirePortfolio struct {
 // The function performs the following operations:
 //  1. Validates the JWT token or handles development bypass
 //  2. Fetches NFT ownership data from the farm plot contract
-//  3. Aggregates portfolio statistics
+//  3. Values the plots and fetches native and DAGRI balances
 //  4. Returns summary metrics
 //
 // Authentication:
@@ -119,14 +139,15 @@ type EntirePortfolio struct {
 //   - Cached results where applicable
 //
 // Parameters:
-//   - token: JWT authentication token or "dev_bypass_authorized" for development
+//   - token: JWT authentication token or the dev bypass placeholder for development
+//   - compare: Optional period (e.g. 7d, 30d) to compare the total value against
 //
 // Returns:
 //   - PortfolioSummary: Aggregated portfolio statistics
 //   - error: Any error encountered during data retrieval or authentication
 //
 // Development Features:
-//   - Dev bypass token uses hardcoded treasury wallet for testing
+//   - Dev bypass token acts as the configured DEV_BYPASS_WALLET for testing
 //   - Debug logging for development environment
 //   - Flexible authentication for different environments
 //
@@ -135,22 +156,23 @@ type EntirePortfolio struct {
 //   - Network connectivity issues
 //   - Contract interaction failures
 //   - NFT API failures
-func GetPortFolioSummary(token string) (PortfolioSummary, error) {
+func GetPortFolioSummary(token, compare string) (PortfolioSummary, error) {
 	var username string
 	var err error
 
-	// Handle authentication with development bypass support
-	if token == "dev_bypass_authorized" {
-		fmt.Println("Dev bypass detected in portfolio service")
-		username = "0x984785A89BF95cb3d5Df4E45F670081944d8D547" // Treasury wallet for testing
-	} else {
-		// Standard JWT token verification
-		username, err = tokenServices.NewTokenService().VerifyAccessToken(token)
-		if err != nil {
+	var compareWindow time.Duration
+	if compare != "" {
+		if compareWindow, err = parseComparePeriod(compare); err != nil {
 			return PortfolioSummary{}, err
 		}
 	}
 
+	// Resolve the wallet, including the dev bypass identity
+	username, err = tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return PortfolioSummary{}, err
+	}
+
 	// Create cache key for portfolio summary optimization
 	cacheKey := fmt.Sprintf("portfolio:%s", username)
 
@@ -159,7 +181,7 @@ func GetPortFolioSummary(token string) (PortfolioSummary, error) {
 	if cache.Exists(cacheKey) {
 		err := cache.Get(cacheKey, &cachedSummary)
 		if err == nil {
-			return cachedSummary, nil
+			return withComparison(cachedSummary, username, compare, compareWindow)
 		}
 	}
 
@@ -177,9 +199,56 @@ func GetPortFolioSummary(token string) (PortfolioSummary, error) {
 		FarmPlotNFTCount: farmPlotNFTCount,
 	}
 
+	// Value the plots the same way portfolio history does
+	tokenIDs := make([]string, 0, farmPlotNFTCount)
+	for _, nft := range farmPlotNFTs.Result {
+		tokenIDs = append(tokenIDs, nft.Metadata.ID)
+	}
+	plotPrices, floor, err := plotValuesUSD(tokenIDs)
+	if err != nil {
+		slog.Warn("failed to value plots", "username", username, "error", err)
+	} else {
+		for _, nft := range farmPlotNFTs.Result {
+			quantity, err := strconv.ParseFloat(nft.QuantityOwned, 64)
+			if err != nil || quantity <= 0 {
+				quantity = 1
+			}
+			price, ok := plotPrices[nft.Metadata.ID]
+			if !ok {
+				price = floor
+			}
+			summary.PlotsValueUSD += price * quantity
+		}
+	}
+	summary.TotalValueUSD = summary.PlotsValueUSD
+
+	// Token balances are best effort; the summary still reports plots without them
+	balances, err := walletService.GetUserBalances(token, "USD")
+	if err != nil {
+		slog.Warn("failed to fetch balances for portfolio summary", "username", username, "error", err)
+	} else {
+		summary.Native = &balances.Native
+		summary.DAGRI = &balances.DAGRI
+		summary.TotalValueUSD += balances.Native.ValueUSD + balances.DAGRI.ValueUSD
+	}
+
 	// Cache the portfolio summary for performance optimization (3 minutes)
-	cache.Set(cacheKey, summary, 3*time.Minute)
+	cache.SetWithTags(cacheKey, summary, config.CacheTTL(config.TTLPortfolio), cache.UserTag(username))
+
+	return withComparison(summary, username, compare, compareWindow)
+}
 
+// withComparison attaches the period comparison to a summary when one was requested.
+// Comparisons are computed per request so the cached summary stays period-independent.
+func withComparison(summary PortfolioSummary, username, compare string, window time.Duration) (PortfolioSummary, error) {
+	if compare == "" {
+		return summary, nil
+	}
+	comparison, err := comparePortfolio(username, compare, window, summary)
+	if err != nil {
+		return PortfolioSummary{}, err
+	}
+	summary.Comparison = comparison
 	return summary, nil
 }
 
@@ -190,7 +259,7 @@ func GetPortFolioSummary(token string) (PortfolioSummary, error) {
 // The function performs the following operations:
 //  1. Authenticates the user and extracts wallet address
 //  2. Checks cache for existing portfolio data
-//  3. Fetches NFT ownership data from contracts
+//  3. Fetches NFT ownership data from all portfolio contracts concurrently
 //  4. Processes and fetches image data concurrently
 //  5. Aggregates complete portfolio information
 //  6. Caches results for performance optimization
@@ -216,7 +285,10 @@ func GetPortFolioSummary(token string) (PortfolioSummary, error) {
 //   - Efficient memory management for large portfolios
 //
 // Parameters:
-//   - token: JWT authentication token or "dev_bypass_authorized" for development
+//   - token: JWT authentication token or the dev bypass placeholder for development
+//   - imagesMode: "bytes" (default) embeds image data; "url" returns image proxy URLs instead
+//   - quality: WebP thumbnail quality (1-100) for images; 0 keeps the original images
+//   - page, limit: 1-based page of plots to return; page 0 returns the whole portfolio
 //
 // Returns:
 //   - EntirePortfolio: Complete portfolio with NFTs and image data
@@ -233,24 +305,35 @@ func GetPortFolioSummary(token string) (PortfolioSummary, error) {
 //   - Contract interaction failures
 //   - Image processing failures
 //   - Cache system failures (non-blocking)
-func GetEntirePortfolio(token string) (EntirePortfolio, error) {
+func GetEntirePortfolio(token, imagesMode string, quality, page, limit int) (EntirePortfolio, error) {
 	var username string
 	var err error
 
-	// Handle authentication with development bypass support
-	if token == "dev_bypass_authorized" {
-		fmt.Println("Dev bypass detected in portfolio service")
-		username = "0x984785A89BF95cb3d5Df4E45F670081944d8D547" // Treasury wallet for testing
-	} else {
-		// Standard JWT token verification
-		username, err = tokenServices.NewTokenService().VerifyAccessToken(token)
-		if err != nil {
-			return EntirePortfolio{}, err
-		}
+	// Resolve the wallet, including the dev bypass identity
+	username, err = tokenServices.ResolveIdentity(token)
+	if err != nil {
+		return EntirePortfolio{}, err
+	}
+
+	if imagesMode == "" {
+		imagesMode = ImagesModeBytes
+	}
+	if imagesMode != ImagesModeBytes && imagesMode != ImagesModeURL {
+		return EntirePortfolio{}, fmt.Errorf("images must be %s or %s", ImagesModeBytes, ImagesModeURL)
+	}
+
+	if page > 0 {
+		return getPortfolioPage(username, imagesMode, quality, page, limit)
 	}
 
 	// Create cache key for complete portfolio data
 	cacheKey := fmt.Sprintf("entire_portfolio:%s", username)
+	if imagesMode == ImagesModeURL {
+		cacheKey = fmt.Sprintf("entire_portfolio_urls:%s", username)
+	}
+	if quality > 0 {
+		cacheKey = fmt.Sprintf("%s:webp:%d", cacheKey, quality)
+	}
 
 	// Attempt to retrieve cached portfolio data for performance
 	var cachedPortfolio EntirePortfolio
@@ -261,30 +344,131 @@ func GetEntirePortfolio(token string) (EntirePortfolio, error) {
 		}
 	}
 
-	// Fetch NFT ownership data from the farm plot contract
-	walletService := walletServices.NewWalletService()
-	farmPlotNFTs, err := walletService.GetOwnedNFTs(config.FarmPlotContractAddress, token)
-	if err != nil {
-		return EntirePortfolio{}, err
+	// Fetch NFT ownership data from every portfolio contract concurrently
+	nftsByCollection, statuses := fetchPortfolioContracts(username)
+	if len(nftsByCollection) == 0 {
+		return EntirePortfolio{}, fmt.Errorf("failed to fetch NFTs from any portfolio contract: %s", statuses[0].Error)
 	}
 
-	// Process NFTs concurrently with image data fetching
-	farmPlotNFTsWithImages, err := ConvertNFTsWithImages(farmPlotNFTs.Result)
-	if err != nil {
-		return EntirePortfolio{}, err
+	withImages := make(map[string][]NFTItemWithImageBytes, len(nftsByCollection))
+	for collection, nfts := range nftsByCollection {
+		if imagesMode == ImagesModeURL {
+			withImages[collection] = ConvertNFTsWithImageURLs(nfts, quality)
+			continue
+		}
+		// Process NFTs concurrently with image data fetching
+		converted, err := ConvertNFTsWithImages(nfts, quality)
+		if err != nil {
+			return EntirePortfolio{}, err
+		}
+		withImages[collection] = converted
 	}
 
 	// Prepare the complete portfolio response
+	farmPlotNFTsWithImages := withImages[CollectionFarmPlots]
+	if farmPlotNFTsWithImages == nil {
+		farmPlotNFTsWithImages = []NFTItemWithImageBytes{}
+	}
 	entirePortfolio := EntirePortfolio{
-		FarmPlotNFTs: farmPlotNFTsWithImages,
+		FarmPlotNFTs:       farmPlotNFTsWithImages,
+		SoilScanNFTs:       withImages[CollectionSoilScans],
+		ProjectedIncomeUSD: attachYieldProjections(farmPlotNFTsWithImages),
+		Contracts:          statuses,
 	}
 
-	// Cache the complete portfolio for performance optimization (5 minutes)
-	cache.Set(cacheKey, entirePortfolio, 5*time.Minute)
+	// Cache the complete portfolio for performance optimization. Partial results are
+	// only cached briefly so failed contracts are retried soon.
+	ttl := config.CacheTTL(config.TTLPortfolioFull)
+	if len(nftsByCollection) < len(statuses) {
+		ttl = config.CacheTTL(config.TTLPortfolioPartial)
+	}
+	// Gob keeps the embedded image bytes compact and decodes faster than JSON
+	cache.SetGob(cacheKey, entirePortfolio, ttl, cache.UserTag(username))
 
 	return entirePortfolio, nil
 }
 
+// getPortfolioPage returns one page of a wallet's farm plots, fetching images only for
+// that page. Pages are not cached themselves; the owned plot list and individual
+// images are, so paging through a portfolio stays cheap.
+func getPortfolioPage(username, imagesMode string, quality, page, limit int) (EntirePortfolio, error) {
+	nfts, err := ownedFarmPlots(username)
+	if err != nil {
+		return EntirePortfolio{}, err
+	}
+
+	pagination := utils.NewPaginationInfo(page, limit, len(nfts))
+	start := (page - 1) * limit
+	if start > len(nfts) {
+		start = len(nfts)
+	}
+	end := start + limit
+	if end > len(nfts) {
+		end = len(nfts)
+	}
+
+	var items []NFTItemWithImageBytes
+	if imagesMode == ImagesModeURL {
+		items = ConvertNFTsWithImageURLs(nfts[start:end], quality)
+	} else {
+		items, err = ConvertNFTsWithImages(nfts[start:end], quality)
+		if err != nil {
+			return EntirePortfolio{}, err
+		}
+	}
+
+	return EntirePortfolio{
+		FarmPlotNFTs:       items,
+		ProjectedIncomeUSD: attachYieldProjections(items),
+		Pagination:         &pagination,
+	}, nil
+}
+
+// ownedFarmPlots returns a wallet's farm plot NFTs (without images) ordered by token ID,
+// so page boundaries stay stable between requests
+func ownedFarmPlots(username string) ([]walletServices.NFTItem, error) {
+	cacheKey := fmt.Sprintf("portfolio_nfts:%s", username)
+	var cached []walletServices.NFTItem
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	response, err := walletServices.NewWalletService().GetOwnedNFTsForWallet(config.FarmPlotContractAddress, username)
+	if err != nil {
+		return nil, err
+	}
+
+	nfts := response.Result
+	sort.SliceStable(nfts, func(i, j int) bool {
+		a, errA := strconv.ParseUint(nfts[i].Metadata.ID, 10, 64)
+		b, errB := strconv.ParseUint(nfts[j].Metadata.ID, 10, 64)
+		if errA != nil || errB != nil {
+			return nfts[i].Metadata.ID < nfts[j].Metadata.ID
+		}
+		return a < b
+	})
+
+	// Cache the plot list for 5 minutes, matching the full portfolio
+	cache.SetWithTags(cacheKey, nfts, config.CacheTTL(config.TTLPortfolioNFTs), cache.UserTag(username))
+
+	return nfts, nil
+}
+
+// OwnedPlotTokenIDs returns the token IDs of a wallet's farm plot NFTs, ordered by token ID
+func OwnedPlotTokenIDs(walletAddress string) ([]string, error) {
+	nfts, err := ownedFarmPlots(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	tokenIDs := make([]string, 0, len(nfts))
+	for _, nft := range nfts {
+		tokenIDs = append(tokenIDs, nft.Metadata.ID)
+	}
+	return tokenIDs, nil
+}
+
 // ConvertNFTsWithImages processes a slice of NFTs and concurrently fetches image data.
 // This function enhances standard NFT items with their associated image bytes,
 // enabling client applications to display images without additional requests.
@@ -310,6 +494,7 @@ func GetEntirePortfolio(token string) (EntirePortfolio, error) {
 //
 // Parameters:
 //   - nfts: Slice of NFTItem structures to process with image data
+//   - quality: WebP thumbnail quality (1-100); 0 embeds the original images
 //
 // Returns:
 //   - []NFTItemWithImageBytes: Enhanced NFT items with image data
@@ -346,6 +531,7 @@ func GetEntirePortfolio(token string) (EntirePortfolio, error) {
 //
 // Parameters:
 //   - nfts: Slice of NFTItem structures to process with image data
+//   - quality: WebP thumbnail quality (1-100); 0 embeds the original images
 //
 // Returns:
 //   - []NFTItemWithImageBytes: Enhanced NFT items with image data
@@ -361,7 +547,7 @@ func GetEntirePortfolio(token string) (EntirePortfolio, error) {
 //   - Individual image fetch failures don't stop overall processing
 //   - Detailed error logging for debugging
 //   - Fallback to empty image data if processing fails
-func ConvertNFTsWithImages(nftItems []walletServices.NFTItem) ([]NFTItemWithImageBytes, error) {
+func ConvertNFTsWithImages(nftItems []walletServices.NFTItem, quality int) ([]NFTItemWithImageBytes, error) {
 	result := make([]NFTItemWithImageBytes, len(nftItems))
 
 	// Pre-filter NFTs that have image URIs
@@ -406,6 +592,47 @@ func ConvertNFTsWithImages(nftItems []walletServices.NFTItem) ([]NFTItemWithImag
 		return result, nil
 	}
 
+	// Resolve each image's URL and cache key, as a WebP thumbnail when a quality was requested
+	imageURLs := make(map[int]string, len(nftsWithImages))
+	imageKeys := make(map[int]string, len(nftsWithImages))
+	cacheKeys := make([]string, 0, len(nftsWithImages))
+	for _, idx := range nftsWithImages {
+		nftItem := &result[idx]
+
+		// First check attributes for image
+		var imageURI string
+		for _, attr := range nftItem.Metadata.Attributes {
+			if attr.TraitType == "image" && attr.Value != "" {
+				imageURI = attr.Value
+				break
+			}
+		}
+
+		// If no image in attributes, use URI
+		if imageURI == "" && nftItem.Metadata.URI != "" {
+			imageURI = nftItem.Metadata.URI
+		}
+
+		if imageURI == "" {
+			continue
+		}
+
+		// Convert IPFS URI to HTTP URL if needed
+		httpURL := BuildIpfsUri(imageURI)
+		imageURLs[idx] = httpURL
+		imageKeys[idx] = imageCacheKey(httpURL)
+		if quality > 0 {
+			imageKeys[idx] = thumbnailCacheKey(httpURL, quality)
+		}
+		cacheKeys = append(cacheKeys, imageKeys[idx])
+	}
+
+	// Read every cached image in one round trip; only misses are fetched below
+	cached, err := cache.GetBytesMulti(cacheKeys)
+	if err != nil {
+		cached = nil
+	}
+
 	// Limit concurrent image fetches
 	const maxConcurrentFetches = 20
 	semaphore := make(chan struct{}, maxConcurrentFetches)
@@ -414,8 +641,17 @@ func ConvertNFTsWithImages(nftItems []walletServices.NFTItem) ([]NFTItemWithImag
 	var mu sync.Mutex
 
 	for _, index := range nftsWithImages {
+		httpURL, ok := imageURLs[index]
+		if !ok {
+			continue
+		}
+		if imageBytes, hit := cached[imageKeys[index]]; hit {
+			result[index].ImageBytes = ByteArray(imageBytes)
+			continue
+		}
+
 		wg.Add(1)
-		go func(idx int) {
+		go func(idx int, httpURL string) {
 			defer wg.Done()
 
 			// Acquire semaphore
@@ -424,35 +660,22 @@ func ConvertNFTsWithImages(nftItems []walletServices.NFTItem) ([]NFTItemWithImag
 
 			nftItem := &result[idx]
 
-			// Extract image URI
-			var imageURI string
+			slog.Debug("processing NFT image", "tokenId", nftItem.Metadata.ID)
 
-			// First check attributes for image
-			for _, attr := range nftItem.Metadata.Attributes {
-				if attr.TraitType == "image" && attr.Value != "" {
-					imageURI = attr.Value
-					break
+			// Fetch image bytes, as a WebP thumbnail when a quality was requested
+			var imageBytes []uint8
+			var err error
+			if quality > 0 {
+				imageBytes, err = FetchWebPThumbnail(httpURL, quality)
+				if err != nil {
+					slog.Warn("failed to build WebP thumbnail for NFT, embedding original", "tokenId", nftItem.Metadata.ID, "error", err)
+					imageBytes, err = FetchImageBytes(httpURL)
 				}
+			} else {
+				imageBytes, err = FetchImageBytes(httpURL)
 			}
-
-			// If no image in attributes, use URI
-			if imageURI == "" && nftItem.Metadata.URI != "" {
-				imageURI = nftItem.Metadata.URI
-			}
-
-			if imageURI == "" {
-				return
-			}
-
-			log.Printf("Processing image for NFT %s", nftItem.Metadata.ID)
-
-			// Convert IPFS URI to HTTP URL if needed
-			httpURL := BuildIpfsUri(imageURI)
-
-			// Fetch image bytes
-			imageBytes, err := FetchImageBytes(httpURL)
 			if err != nil {
-				log.Printf("Warning: Failed to fetch image for NFT %s: %v", nftItem.Metadata.ID, err)
+				slog.Warn("failed to fetch image for NFT", "tokenId", nftItem.Metadata.ID, "error", err)
 				return
 			}
 
@@ -460,7 +683,7 @@ func ConvertNFTsWithImages(nftItems []walletServices.NFTItem) ([]NFTItemWithImag
 			mu.Lock()
 			nftItem.ImageBytes = ByteArray(imageBytes)
 			mu.Unlock()
-		}(index)
+		}(index, httpURL)
 	}
 
 	// Wait for all image fetches to complete
@@ -518,40 +741,41 @@ func FetchImageBytes(imageURI string) ([]uint8, error) {
 	}
 
 	// Generate cache key using MD5 hash of the URI for uniqueness and consistency
-	hasher := md5.New()
-	hasher.Write([]byte(imageURI))
-	cacheKey := fmt.Sprintf("image:%s", hex.EncodeToString(hasher.Sum(nil)))
+	cacheKey := imageCacheKey(imageURI)
 
 	// Attempt to retrieve cached image data for performance optimization
-	var cachedImage []uint8
-	if cache.Exists(cacheKey) {
-		err := cache.Get(cacheKey, &cachedImage)
-		if err == nil && len(cachedImage) > 0 {
-			return cachedImage, nil
-		}
+	if cachedImage, err := cache.GetBytes(cacheKey); err == nil && len(cachedImage) > 0 {
+		return cachedImage, nil
 	}
 
-	// Fetch image data from the network if not cached or cache failed
-	req := fiber.Get(imageURI)
-	status, resp, errs := req.Bytes()
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("failed to fetch image: %w", errs[0])
-	}
+	// Fetch image data from the network if not cached or cache failed. The key matches
+	// the marketplace image cache, so concurrent misses from either share one download.
+	image, err := cache.Load(cacheKey, func() (interface{}, error) {
+		req := fiber.Get(imageURI)
+		status, resp, errs := req.Bytes()
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("failed to fetch image: %w", errs[0])
+		}
 
-	// Validate HTTP response status
-	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("HTTP request failed with status %d", status)
-	}
+		// Validate HTTP response status
+		if status < 200 || status >= 300 {
+			return nil, fmt.Errorf("HTTP request failed with status %d", status)
+		}
 
-	// Ensure response contains image data
-	if len(resp) == 0 {
-		return nil, fmt.Errorf("image data is empty")
-	}
+		// Ensure response contains image data
+		if len(resp) == 0 {
+			return nil, fmt.Errorf("image data is empty")
+		}
 
-	// Cache the successfully fetched image data for future requests (1 hour)
-	cache.Set(cacheKey, resp, 1*time.Hour)
+		// Cache the successfully fetched image data for future requests (1 hour)
+		cache.SetBytes(cacheKey, resp, config.CacheTTL(config.TTLImages))
 
-	return resp, nil
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return image.([]uint8), nil
 }
 
 // BuildIpfsUri converts IPFS URIs to accessible HTTP gateway URLs.
@@ -622,3 +846,25 @@ func BuildIpfsUri(ipfsURI string) string {
 	// Fallback: assume it's already a proper URL and return as-is
 	return ipfsURI
 }
+
+// InvalidatePortfolioCache removes the cached portfolio summary, full portfolio, and
+// derived views (activity, P&L, allocation, history) for a wallet: everything cached
+// under its user tag, whichever form of the address the keys were written with
+func InvalidatePortfolioCache(walletAddress string) {
+	if walletAddress == "" {
+		return
+	}
+	if err := cache.InvalidateTag(cache.UserTag(walletAddress)); err != nil {
+		slog.Warn("failed to invalidate portfolio cache", "wallet", walletAddress, "error", err)
+	}
+}
+
+// RegisterCacheInvalidation subscribes the portfolio caches to the invalidation bus so
+// every party to a plot ownership change sees it on their next read
+func RegisterCacheInvalidation() {
+	cache.OnInvalidate(cache.TopicPlotOwnership, func(event cache.InvalidationEvent) {
+		for _, wallet := range event.Wallets {
+			InvalidatePortfolioCache(wallet)
+		}
+	})
+}
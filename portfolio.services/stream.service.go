@@ -0,0 +1,199 @@
+package portfolioservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Portfolio event types pushed to connected clients
+const (
+	EventNFTReceived = "nft_received" // A plot arrived in the wallet (purchase, transfer, or mint)
+	EventListingSold = "listing_sold" // One of the wallet's listings sold
+	EventValueChange = "value_change" // Portfolio value moved beyond PORTFOLIO_VALUE_CHANGE_THRESHOLD
+)
+
+// portfolioEventsChannel is the Redis pub/sub channel that fans events out to every instance
+const portfolioEventsChannel = "portfolio_events"
+
+// subscriberBuffer is how many undelivered events a slow client may queue before events are dropped
+const subscriberBuffer = 16
+
+// PortfolioEvent is a real-time change to a wallet's portfolio
+type PortfolioEvent struct {
+	Type      string         `json:"type"`
+	Wallet    string         `json:"wallet"`
+	Data      map[string]any `json:"data,omitempty"`
+	Timestamp int64          `json:"timestamp"`
+}
+
+// streamHub tracks the portfolio event subscribers connected to this instance, by wallet
+var streamHub = struct {
+	sync.RWMutex
+	subscribers map[string]map[chan PortfolioEvent]struct{}
+}{subscribers: make(map[string]map[chan PortfolioEvent]struct{})}
+
+// SubscribePortfolio registers a subscriber for a wallet's portfolio events. The returned
+// function unsubscribes and must be called when the client disconnects.
+func SubscribePortfolio(walletAddress string) (<-chan PortfolioEvent, func()) {
+	wallet := strings.ToLower(walletAddress)
+	ch := make(chan PortfolioEvent, subscriberBuffer)
+
+	streamHub.Lock()
+	if streamHub.subscribers[wallet] == nil {
+		streamHub.subscribers[wallet] = make(map[chan PortfolioEvent]struct{})
+	}
+	streamHub.subscribers[wallet][ch] = struct{}{}
+	streamHub.Unlock()
+
+	return ch, func() {
+		streamHub.Lock()
+		delete(streamHub.subscribers[wallet], ch)
+		if len(streamHub.subscribers[wallet]) == 0 {
+			delete(streamHub.subscribers, wallet)
+		}
+		streamHub.Unlock()
+	}
+}
+
+// PublishPortfolioEvent pushes an event to every client subscribed to the wallet, on any
+// instance when Redis is available and on this instance otherwise
+func PublishPortfolioEvent(walletAddress, eventType string, data map[string]any) {
+	if walletAddress == "" {
+		return
+	}
+
+	event := PortfolioEvent{
+		Type:      eventType,
+		Wallet:    strings.ToLower(walletAddress),
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+	if err := cache.Publish(portfolioEventsChannel, event); err != nil {
+		dispatchPortfolioEvent(event)
+	}
+}
+
+// dispatchPortfolioEvent delivers an event to this instance's subscribers. Subscribers
+// that are not keeping up miss the event rather than blocking the publisher.
+func dispatchPortfolioEvent(event PortfolioEvent) {
+	streamHub.RLock()
+	defer streamHub.RUnlock()
+
+	for ch := range streamHub.subscribers[event.Wallet] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// connectedWallets lists the wallets with at least one subscriber on this instance
+func connectedWallets() []string {
+	streamHub.RLock()
+	defer streamHub.RUnlock()
+
+	wallets := make([]string, 0, len(streamHub.subscribers))
+	for wallet := range streamHub.subscribers {
+		wallets = append(wallets, wallet)
+	}
+	return wallets
+}
+
+// StartPortfolioEventRelay forwards events published on Redis to this instance's
+// subscribers. Without Redis, events are dispatched locally by PublishPortfolioEvent
+// and the relay exits. It blocks, so run it in a goroutine.
+func StartPortfolioEventRelay() {
+	sub := cache.Subscribe(portfolioEventsChannel)
+	if sub == nil {
+		slog.Info("portfolio event relay disabled: Redis unavailable, events stay on this instance")
+		return
+	}
+	defer sub.Close()
+
+	slog.Info("portfolio event relay started")
+
+	for msg := range sub.Channel() {
+		var event PortfolioEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			slog.Warn("invalid portfolio event", "error", err)
+			continue
+		}
+		dispatchPortfolioEvent(event)
+	}
+}
+
+// CheckPortfolioValues revalues the portfolios of connected wallets and publishes a
+// value_change event when the total moved by at least the threshold since the last
+// event (PORTFOLIO_VALUE_CHANGE_THRESHOLD, percent, default 5).
+func CheckPortfolioValues(interval time.Duration) {
+	threshold := 5.0
+	if v := os.Getenv("PORTFOLIO_VALUE_CHANGE_THRESHOLD"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 {
+			threshold = parsed
+		}
+	}
+
+	for _, wallet := range connectedWallets() {
+		// Several instances may hold streams for the same wallet; only one checks per interval
+		claimed, err := cache.SetNX("portfolio_value_check:"+wallet, true, interval-time.Second)
+		if err == nil && !claimed {
+			continue
+		}
+
+		valuation, err := ValuePortfolio(wallet)
+		if err != nil {
+			slog.Warn("failed to value portfolio", "wallet", wallet, "error", err)
+			continue
+		}
+
+		lastKey := "portfolio_last_value:" + wallet
+		var last float64
+		if err := cache.Get(lastKey, &last); err != nil || last <= 0 {
+			// First observation is the baseline for later changes
+			cache.Set(lastKey, valuation.TotalValueUSD, 24*time.Hour)
+			continue
+		}
+
+		changePct := (valuation.TotalValueUSD - last) / last * 100
+		if math.Abs(changePct) < threshold {
+			continue
+		}
+
+		cache.Set(lastKey, valuation.TotalValueUSD, 24*time.Hour)
+		PublishPortfolioEvent(wallet, EventValueChange, map[string]any{
+			"previousValueUSD": last,
+			"totalValueUSD":    valuation.TotalValueUSD,
+			"changePercent":    changePct,
+			"plotsValueUSD":    valuation.PlotsValueUSD,
+			"tokensValueUSD":   valuation.NativeValueUSD + valuation.DAGRIValueUSD,
+		})
+	}
+}
+
+// StartPortfolioValueWatcher checks connected wallets for value changes on a fixed interval.
+// The interval defaults to 5 minutes and can be overridden with PORTFOLIO_VALUE_CHECK_INTERVAL.
+// It blocks, so run it in a goroutine.
+func StartPortfolioValueWatcher() {
+	interval := 5 * time.Minute
+	if v := os.Getenv("PORTFOLIO_VALUE_CHECK_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("portfolio value watcher started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		CheckPortfolioValues(interval)
+	}
+}
@@ -0,0 +1,266 @@
+package portfolioservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	walletServices "decentragri-app-cx-server/wallet.services"
+)
+
+// healthReadingWindow is how many of a farm's most recent sensor readings feed its health score
+const healthReadingWindow = 50
+
+// cropProfile holds the agronomic assumptions behind a yield projection
+type cropProfile struct {
+	YieldPerHectare  float64    // Expected annual yield in tonnes per hectare under healthy conditions
+	PricePerTonneUSD float64    // Reference farm-gate price
+	Moisture         [2]float64 // Optimal soil moisture range (%)
+	PH               [2]float64 // Optimal soil pH range
+	Temperature      [2]float64 // Optimal temperature range (°C)
+}
+
+// defaultCropProfile is used for crops without a specific profile
+var defaultCropProfile = cropProfile{
+	YieldPerHectare:  5,
+	PricePerTonneUSD: 300,
+	Moisture:         [2]float64{20, 60},
+	PH:               [2]float64{5.5, 7.0},
+	Temperature:      [2]float64{18, 30},
+}
+
+// cropProfiles are reference annual yields and prices for common crops, keyed by
+// lower-case crop type
+var cropProfiles = map[string]cropProfile{
+	"rice":      {YieldPerHectare: 4.5, PricePerTonneUSD: 400, Moisture: [2]float64{40, 80}, PH: [2]float64{5.5, 6.5}, Temperature: [2]float64{20, 35}},
+	"corn":      {YieldPerHectare: 6, PricePerTonneUSD: 200, Moisture: [2]float64{25, 60}, PH: [2]float64{5.8, 7.0}, Temperature: [2]float64{18, 32}},
+	"wheat":     {YieldPerHectare: 3.5, PricePerTonneUSD: 250, Moisture: [2]float64{20, 50}, PH: [2]float64{6.0, 7.5}, Temperature: [2]float64{12, 25}},
+	"soybean":   {YieldPerHectare: 2.8, PricePerTonneUSD: 450, Moisture: [2]float64{25, 60}, PH: [2]float64{6.0, 7.0}, Temperature: [2]float64{20, 30}},
+	"tomato":    {YieldPerHectare: 40, PricePerTonneUSD: 500, Moisture: [2]float64{30, 70}, PH: [2]float64{6.0, 6.8}, Temperature: [2]float64{18, 29}},
+	"potato":    {YieldPerHectare: 20, PricePerTonneUSD: 250, Moisture: [2]float64{30, 70}, PH: [2]float64{5.0, 6.5}, Temperature: [2]float64{15, 24}},
+	"cassava":   {YieldPerHectare: 15, PricePerTonneUSD: 100, Moisture: [2]float64{20, 60}, PH: [2]float64{5.5, 7.0}, Temperature: [2]float64{25, 35}},
+	"banana":    {YieldPerHectare: 30, PricePerTonneUSD: 400, Moisture: [2]float64{40, 80}, PH: [2]float64{5.5, 7.0}, Temperature: [2]float64{24, 32}},
+	"coffee":    {YieldPerHectare: 1, PricePerTonneUSD: 4000, Moisture: [2]float64{30, 70}, PH: [2]float64{5.0, 6.5}, Temperature: [2]float64{15, 24}},
+	"cacao":     {YieldPerHectare: 0.5, PricePerTonneUSD: 3000, Moisture: [2]float64{40, 80}, PH: [2]float64{5.0, 7.0}, Temperature: [2]float64{21, 32}},
+	"sugarcane": {YieldPerHectare: 70, PricePerTonneUSD: 40, Moisture: [2]float64{40, 80}, PH: [2]float64{6.0, 7.5}, Temperature: [2]float64{20, 35}},
+}
+
+// cropAliases maps alternative crop names to their profile
+var cropAliases = map[string]string{
+	"maize":      "corn",
+	"soy":        "soybean",
+	"soybeans":   "soybean",
+	"tomatoes":   "tomato",
+	"potatoes":   "potato",
+	"bananas":    "banana",
+	"cocoa":      "cacao",
+	"sugar cane": "sugarcane",
+}
+
+// YieldProjection is the projected annual output of a farm plot NFT. Health scales the
+// reference yield between 50% (poor conditions) and 100% (all readings in range).
+type YieldProjection struct {
+	CropType             string   `json:"cropType"`
+	FarmName             string   `json:"farmName,omitempty"`
+	AreaHectares         float64  `json:"areaHectares"`
+	AreaEstimated        bool     `json:"areaEstimated"`         // The plot has no area attribute; PLOT_DEFAULT_AREA_HECTARES was used
+	HealthScore          *float64 `json:"healthScore,omitempty"` // 0-100 from recent sensor readings; omitted when the farm has none
+	ReadingsUsed         int      `json:"readingsUsed"`
+	YieldPerHectare      float64  `json:"yieldPerHectare"` // Reference tonnes per hectare per year
+	ProjectedYieldTonnes float64  `json:"projectedYieldTonnes"`
+	PricePerTonneUSD     float64  `json:"pricePerTonneUSD"`
+	ProjectedIncomeUSD   float64  `json:"projectedIncomeUSD"`
+}
+
+// farmHealthData is the average of a farm's recent sensor readings
+type farmHealthData struct {
+	Readings    int     `json:"readings"`
+	Moisture    float64 `json:"moisture"`
+	PH          float64 `json:"ph"`
+	Temperature float64 `json:"temperature"`
+}
+
+// defaultPlotArea returns the area assumed for plots without an area attribute
+// (PLOT_DEFAULT_AREA_HECTARES, default 1)
+func defaultPlotArea() float64 {
+	if v := os.Getenv("PLOT_DEFAULT_AREA_HECTARES"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return 1
+}
+
+// attachYieldProjections sets the yield projection on each plot and returns the total
+// projected annual income in USD. Sensor data is loaded once per farm.
+func attachYieldProjections(items []NFTItemWithImageBytes) float64 {
+	health := make(map[string]*farmHealthData)
+	var totalIncome float64
+
+	for i := range items {
+		farmName, crop, area := plotFarmCropAndArea(items[i].Metadata)
+		areaEstimated := area <= 0
+		if areaEstimated {
+			area = defaultPlotArea()
+		}
+
+		profile, ok := cropProfiles[crop]
+		if !ok {
+			profile, ok = cropProfiles[cropAliases[crop]]
+		}
+		if !ok {
+			profile = defaultCropProfile
+		}
+
+		quantity, err := strconv.ParseFloat(items[i].QuantityOwned, 64)
+		if err != nil || quantity <= 0 {
+			quantity = 1
+		}
+
+		projection := &YieldProjection{
+			CropType:         crop,
+			FarmName:         farmName,
+			AreaHectares:     area,
+			AreaEstimated:    areaEstimated,
+			YieldPerHectare:  profile.YieldPerHectare,
+			PricePerTonneUSD: profile.PricePerTonneUSD,
+		}
+
+		factor := 1.0
+		if farmName != "" {
+			data, loaded := health[farmName]
+			if !loaded {
+				data, err = farmHealth(farmName)
+				if err != nil {
+					slog.Warn("failed to load sensor health for farm", "farm", farmName, "error", err)
+				}
+				health[farmName] = data
+			}
+			if data != nil && data.Readings > 0 {
+				score := healthScore(data, profile)
+				projection.HealthScore = &score
+				projection.ReadingsUsed = data.Readings
+				factor = 0.5 + score/200
+			}
+		}
+
+		projection.ProjectedYieldTonnes = profile.YieldPerHectare * area * factor * quantity
+		projection.ProjectedIncomeUSD = projection.ProjectedYieldTonnes * profile.PricePerTonneUSD
+		totalIncome += projection.ProjectedIncomeUSD
+		items[i].Projection = projection
+	}
+
+	return totalIncome
+}
+
+// plotFarmCropAndArea reads a plot's farm name, crop type, and area (hectares, 0 when
+// absent) from either attribute style
+func plotFarmCropAndArea(metadata walletServices.NFTMetadata) (farmName, crop string, area float64) {
+	crop, _ = plotCropAndRegion(metadata)
+	if crop == unknownAllocation {
+		crop = ""
+	}
+
+	for _, attr := range metadata.Attributes {
+		if farmName == "" && attr.FarmName != "" {
+			farmName = attr.FarmName
+		}
+		if area <= 0 && attr.Area != "" {
+			area, _ = attr.Area.Float64()
+		}
+		switch attr.TraitType {
+		case "farmName":
+			if farmName == "" {
+				farmName = attr.Value
+			}
+		case "area":
+			if area <= 0 {
+				area, _ = strconv.ParseFloat(strings.TrimSpace(attr.Value), 64)
+			}
+		}
+	}
+	return strings.TrimSpace(farmName), crop, area
+}
+
+// farmHealth averages a farm's most recent sensor readings. Results are cached for
+// 30 minutes since readings arrive far less often than portfolios are viewed.
+func farmHealth(farmName string) (*farmHealthData, error) {
+	cacheKey := fmt.Sprintf("farm_health:%s", farmName)
+	var cached farmHealthData
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	records, err := memgraph.ExecuteRead(`MATCH (f:Farm {farmName: $farmName})-[:HAS_SENSOR]->(:Sensor)-[:HAS_READING]->(r:Reading)
+		WHERE f.deletedAt IS NULL
+		WITH r ORDER BY r.createdAt DESC LIMIT $limit
+		RETURN count(r) AS readings, avg(r.moisture) AS moisture, avg(r.ph) AS ph,
+			avg(r.temperature) AS temperature`, map[string]any{
+		"farmName": farmName,
+		"limit":    healthReadingWindow,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := &farmHealthData{}
+	if len(records) > 0 {
+		record := records[0]
+		if v, ok := record.Get("readings"); ok && v != nil {
+			readings, _ := v.(int64)
+			data.Readings = int(readings)
+		}
+		if v, ok := record.Get("moisture"); ok && v != nil {
+			data.Moisture, _ = v.(float64)
+		}
+		if v, ok := record.Get("ph"); ok && v != nil {
+			data.PH, _ = v.(float64)
+		}
+		if v, ok := record.Get("temperature"); ok && v != nil {
+			data.Temperature, _ = v.(float64)
+		}
+	}
+
+	// Tagged with the farm so new readings drop it
+	cache.SetWithTags(cacheKey, data, config.CacheTTL(config.TTLFarmHealth), cache.FarmTag(farmName))
+
+	return data, nil
+}
+
+// healthScore rates average conditions against a crop's optimal ranges on a 0-100 scale.
+// Each metric scores 1 inside its range and falls to 0 one range-width outside it.
+func healthScore(data *farmHealthData, profile cropProfile) float64 {
+	metrics := []struct {
+		value float64
+		band  [2]float64
+	}{
+		{data.Moisture, profile.Moisture},
+		{data.PH, profile.PH},
+		{data.Temperature, profile.Temperature},
+	}
+
+	var total float64
+	for _, m := range metrics {
+		width := m.band[1] - m.band[0]
+		var distance float64
+		switch {
+		case m.value < m.band[0]:
+			distance = m.band[0] - m.value
+		case m.value > m.band[1]:
+			distance = m.value - m.band[1]
+		}
+		score := 1 - distance/width
+		if score < 0 {
+			score = 0
+		}
+		total += score
+	}
+	return total / float64(len(metrics)) * 100
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	memgraph "decentragri-app-cx-server/db"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ErrUserNotFound is returned when no User node matches
+var ErrUserNotFound = errors.New("user does not exist")
+
+// User is a stored account. Username is the wallet address the account acts as.
+type User struct {
+	Username      string
+	WalletAddress string
+	GoogleID      string
+	Email         string
+	Name          string
+	Picture       string
+	DeviceID      string
+	AuthProvider  string // google, dev_bypass, or empty for wallet sign-in
+	CreatedAt     int64  // Unix milliseconds
+}
+
+// GoogleProfile holds the profile fields refreshed on each Google sign-in
+type GoogleProfile struct {
+	Email    string
+	Name     string
+	Picture  string
+	DeviceID string
+}
+
+// UserRepository loads and stores User nodes. Services hold one in a package variable
+// so tests can swap in a fake.
+type UserRepository interface {
+	// FindByUsername returns the user with username, or ErrUserNotFound when there is
+	// none or it is deleted
+	FindByUsername(username string) (*User, error)
+	// FindByGoogleID returns the user linked to a Google account, or ErrUserNotFound
+	// when there is none or it is deleted
+	FindByGoogleID(googleID string) (*User, error)
+	// CreateByUsername stores user unless an account with its username exists and
+	// reports whether it was created, so concurrent sign-ins share one node. A deleted
+	// account with the username is restored instead.
+	CreateByUsername(user User) (bool, error)
+	// CreateByGoogleID stores user unless an account linked to its Google ID exists,
+	// returning the stored account and whether it was created
+	CreateByGoogleID(user User) (*User, bool, error)
+	// UpdateGoogleProfile refreshes a Google user's profile and returns the user, or
+	// ErrUserNotFound
+	UpdateGoogleProfile(googleID string, profile GoogleProfile) (*User, error)
+	// Delete soft-deletes the user, recording actor, or returns ErrUserNotFound
+	Delete(username, actor string) error
+	// Restore undoes the deletion of a user that has not been purged, or returns
+	// ErrUserNotFound
+	Restore(username string) error
+	// RestoreByGoogleID restores the deleted user linked to a Google account, or
+	// returns ErrUserNotFound
+	RestoreByGoogleID(googleID string) error
+}
+
+// NewUserRepository returns the UserRepository backed by Memgraph
+func NewUserRepository() UserRepository {
+	return memgraphUserRepository{}
+}
+
+// userFields projects a user matched as u into the columns mapUser reads
+const userFields = `u.username AS username, u.walletAddress AS walletAddress, u.googleId AS googleId,
+	u.email AS email, u.name AS name, u.picture AS picture, u.deviceId AS deviceId,
+	u.authProvider AS authProvider, u.createdAt AS createdAt`
+
+type memgraphUserRepository struct{}
+
+func (memgraphUserRepository) FindByUsername(username string) (*User, error) {
+	return findUser(`MATCH (u:User {username: $value}) WHERE u.deletedAt IS NULL RETURN `+userFields+` LIMIT 1`, username)
+}
+
+func (memgraphUserRepository) FindByGoogleID(googleID string) (*User, error) {
+	return findUser(`MATCH (u:User {googleId: $value}) WHERE u.deletedAt IS NULL RETURN `+userFields+` LIMIT 1`, googleID)
+}
+
+func (memgraphUserRepository) CreateByUsername(user User) (bool, error) {
+	return mergeUser(`MERGE (u:User {username: $username})
+		ON CREATE SET `+userCreateFields+`
+		ON MATCH SET u.deletedAt = null, u.deletedBy = null`, user)
+}
+
+func (r memgraphUserRepository) CreateByGoogleID(user User) (*User, bool, error) {
+	created, err := mergeUser(`MERGE (u:User {googleId: $googleId})
+		ON CREATE SET u.username = $username, `+userCreateFields, user)
+	if err != nil {
+		return nil, false, err
+	}
+	stored, err := r.FindByGoogleID(user.GoogleID)
+	if err != nil {
+		return nil, false, err
+	}
+	return stored, created, nil
+}
+
+func (r memgraphUserRepository) UpdateGoogleProfile(googleID string, profile GoogleProfile) (*User, error) {
+	_, err := memgraph.ExecuteWrite(`MATCH (u:User {googleId: $googleId})
+		SET u.email = $email, u.name = $name, u.picture = $picture, u.deviceId = $deviceId`, map[string]any{
+		"googleId": googleID,
+		"email":    profile.Email,
+		"name":     profile.Name,
+		"picture":  profile.Picture,
+		"deviceId": profile.DeviceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return r.FindByGoogleID(googleID)
+}
+
+func (memgraphUserRepository) Delete(username, actor string) error {
+	deleted, err := memgraph.SoftDelete("User", "username", username, actor)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if !deleted {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (memgraphUserRepository) Restore(username string) error {
+	return restoreUser("username", username)
+}
+
+func (memgraphUserRepository) RestoreByGoogleID(googleID string) error {
+	return restoreUser("googleId", googleID)
+}
+
+// restoreUser clears the deleted mark of the user whose key property equals value
+func restoreUser(key, value string) error {
+	restored, err := memgraph.Restore("User", key, value)
+	if err != nil {
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+	if !restored {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// userCreateFields sets the fields of a user created by MERGE, stamping its creation time
+const userCreateFields = `u.walletAddress = $walletAddress, u.deviceId = $deviceId, u.googleId = $googleId,
+	u.email = $email, u.name = $name, u.picture = $picture, u.authProvider = $authProvider,
+	u.createdAt = timestamp()`
+
+// mergeUser runs a MERGE of user and reports whether it created the node. Losing a
+// race to a concurrent MERGE of the same identity trips a uniqueness constraint, which
+// means the node exists.
+func mergeUser(query string, user User) (bool, error) {
+	summary, err := memgraph.ExecuteWrite(query, map[string]any{
+		"username":      user.Username,
+		"walletAddress": user.WalletAddress,
+		"deviceId":      user.DeviceID,
+		"googleId":      nullable(user.GoogleID),
+		"email":         nullable(user.Email),
+		"name":          nullable(user.Name),
+		"picture":       nullable(user.Picture),
+		"authProvider":  nullable(user.AuthProvider),
+	})
+	if memgraph.IsConstraintViolation(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+	return summary.Counters().NodesCreated() > 0, nil
+}
+
+// findUser runs a single-user lookup whose parameter is $value
+func findUser(query, value string) (*User, error) {
+	records, err := memgraph.ExecuteRead(query, map[string]any{"value": value})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrUserNotFound
+	}
+	return mapUser(records[0]), nil
+}
+
+// mapUser builds a user from a record projected with userFields
+func mapUser(record *neo4j.Record) *User {
+	return &User{
+		Username:      memgraph.String(record, "username"),
+		WalletAddress: memgraph.String(record, "walletAddress"),
+		GoogleID:      memgraph.String(record, "googleId"),
+		Email:         memgraph.String(record, "email"),
+		Name:          memgraph.String(record, "name"),
+		Picture:       memgraph.String(record, "picture"),
+		DeviceID:      memgraph.String(record, "deviceId"),
+		AuthProvider:  memgraph.String(record, "authProvider"),
+		CreatedAt:     memgraph.Int64(record, "createdAt"),
+	}
+}
+
+// nullable stores empty optional fields as absent properties rather than ""
+func nullable(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,426 @@
+package routes
+
+import (
+	authservices "decentragri-app-cx-server/auth.services"
+	backupservices "decentragri-app-cx-server/backup.services"
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	farmservices "decentragri-app-cx-server/farm.services"
+	marketplaceServices "decentragri-app-cx-server/marketplace.services"
+	"decentragri-app-cx-server/middleware"
+	"decentragri-app-cx-server/repository"
+	walletServices "decentragri-app-cx-server/wallet.services"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminRoutes registers operator-only endpoints under /api/admin.
+// All routes require a valid JWT and a wallet listed in ADMIN_WALLETS.
+func AdminRoutes(app *fiber.App, limiter fiber.Handler) {
+	admin := app.Group("/api/admin")
+	admin.Use(middleware.AuthMiddleware())
+	admin.Use(limiter) // Counted per admin wallet
+	admin.Use(middleware.AdminMiddleware())
+
+	// GET /api/admin/backend-wallet/health - Engine backend wallet and treasury status
+	admin.Get("/backend-wallet/health", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		health, err := walletServices.GetBackendWalletHealth()
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(health)
+	})
+
+	// GET /api/admin/featured - Current and upcoming featured listings
+	admin.Get("/featured", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		slots, err := marketplaceServices.GetFeaturedSchedule()
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(slots)
+	})
+
+	// POST /api/admin/featured - Schedule a featured listing (defaults to one week from now)
+	admin.Post("/featured", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceServices.SetFeaturedRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		adminWallet, _ := c.Locals("username").(string)
+		slot, err := marketplaceServices.ScheduleFeatured(adminWallet, &req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusCreated).JSON(slot)
+	})
+
+	// DELETE /api/admin/featured/:id - Remove a featured slot
+	admin.Delete("/featured/:id", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		adminWallet, _ := c.Locals("username").(string)
+		err := marketplaceServices.DeleteFeatured(adminWallet, c.Params("id"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"success": true})
+	})
+
+	// POST /api/admin/featured/:id/restore - Put a deleted featured slot back in the queue
+	admin.Post("/featured/:id/restore", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		err := marketplaceServices.RestoreFeatured(c.Params("id"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"success": true})
+	})
+
+	// POST /api/admin/users/:username/restore - Restore a deleted account before it is purged
+	admin.Post("/users/:username/restore", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		err := authservices.RestoreAccount(c.Params("username"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"success": true})
+	})
+
+	// POST /api/admin/backups?format=json - Export users, farms, plant scans, and purchases to the backup bucket
+	admin.Post("/backups", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		adminWallet, _ := c.Locals("username").(string)
+		manifest, err := backupservices.ExportGraph(c.UserContext(), c.Query("format"), adminWallet)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			status := fiber.StatusInternalServerError
+			switch {
+			case errors.Is(err, backupservices.ErrBackupDisabled):
+				status = fiber.StatusServiceUnavailable
+			case errors.Is(err, backupservices.ErrBackupInProgress):
+				status = fiber.StatusConflict
+			case errors.Is(err, backupservices.ErrInvalidBackupFormat):
+				status = fiber.StatusBadRequest
+			}
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusCreated).JSON(manifest)
+	})
+
+	// GET /api/admin/moderation/reports?status=open - Reported listings, most-reported first
+	admin.Get("/moderation/reports", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		queue, err := marketplaceServices.GetModerationQueue(c.Query("status"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(queue)
+	})
+
+	// POST /api/admin/moderation/listings/:id/hide - Pull a listing from marketplace results
+	admin.Post("/moderation/listings/:id/hide", func(c *fiber.Ctx) error {
+		return moderateListing(c, marketplaceServices.HideListing)
+	})
+
+	// POST /api/admin/moderation/listings/:id/unhide - Restore a hidden listing
+	admin.Post("/moderation/listings/:id/unhide", func(c *fiber.Ctx) error {
+		return moderateListing(c, marketplaceServices.UnhideListing)
+	})
+
+	// POST /api/admin/moderation/listings/:id/dismiss - Close open reports without hiding the listing
+	admin.Post("/moderation/listings/:id/dismiss", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		adminWallet, _ := c.Locals("username").(string)
+		dismissed, err := marketplaceServices.DismissReports(adminWallet, c.Params("id"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"success": true, "dismissed": dismissed})
+	})
+
+	// GET /api/admin/farm-verifications?status=pending - Farm verification requests, oldest first
+	admin.Get("/farm-verifications", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		verifications, err := farmservices.GetFarmVerifications(c.Query("status"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return farmErrorResponse(c, err)
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"verifications": verifications})
+	})
+
+	// POST /api/admin/farm-verifications/:id/approve - Verify the farm, optionally with a note
+	admin.Post("/farm-verifications/:id/approve", func(c *fiber.Ctx) error {
+		return reviewFarmVerification(c, true)
+	})
+
+	// POST /api/admin/farm-verifications/:id/reject - Decline the request with a note for the owner
+	admin.Post("/farm-verifications/:id/reject", func(c *fiber.Ctx) error {
+		return reviewFarmVerification(c, false)
+	})
+
+	// POST /api/admin/farms/:id/unverify - Revoke a farm's verified badge
+	admin.Post("/farms/:id/unverify", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req farmservices.VerificationReviewRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		adminWallet, _ := c.Locals("username").(string)
+		err := farmservices.RevokeFarmVerification(adminWallet, c.Params("id"), req.Note)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return farmErrorResponse(c, err)
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"success": true})
+	})
+
+	// GET /api/admin/orphaned-farm-records - Count farm records no farm links to any more
+	admin.Get("/orphaned-farm-records", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		counts, err := memgraph.CountOrphanedFarmRecords()
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"counts": counts})
+	})
+
+	// DELETE /api/admin/orphaned-farm-records - Permanently delete the records counted above
+	admin.Delete("/orphaned-farm-records", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		adminWallet, _ := c.Locals("username").(string)
+		counts, err := memgraph.PurgeOrphanedFarmRecords()
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.InfoContext(c.UserContext(), "orphaned farm records purged", "admin", adminWallet, "counts", counts)
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"success": true, "deleted": counts})
+	})
+
+	// GET /api/admin/cache/keys?prefix=image: - Key counts under a prefix, grouped by the next segment
+	admin.Get("/cache/keys", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		stats, err := cache.CountKeys(c.UserContext(), c.Query("prefix"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return cacheErrorResponse(c, err)
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(stats)
+	})
+
+	// GET /api/admin/cache/key?key=farm_list - A key's type, TTL, and size
+	admin.Get("/cache/key", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		key := c.Query("key")
+		if key == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "key is required"})
+		}
+
+		info, err := cache.InspectKey(c.UserContext(), key)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return cacheErrorResponse(c, err)
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(info)
+	})
+
+	// GET /api/admin/cache/ttl - Effective cache TTL of every data class
+	admin.Get("/cache/ttl", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"policy": config.CacheTTLPolicy()})
+	})
+
+	// DELETE /api/admin/cache/keys?prefix=farm_scans: - Flush every key under a prefix
+	admin.Delete("/cache/keys", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		adminWallet, _ := c.Locals("username").(string)
+		prefix := c.Query("prefix")
+		deleted, err := cache.FlushPrefix(c.UserContext(), prefix)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return cacheErrorResponse(c, err)
+		}
+		slog.InfoContext(c.UserContext(), "cache keys flushed", "admin", adminWallet, "prefix", prefix, "deleted", deleted)
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"success": true, "prefix": prefix, "deleted": deleted})
+	})
+}
+
+// cacheErrorResponse maps cache administration errors to HTTP statuses
+func cacheErrorResponse(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, cache.ErrPrefixRequired):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, cache.ErrKeyNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, cache.ErrCacheUnavailable):
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+}
+
+// reviewFarmVerification approves or rejects the verification request in the route params
+func reviewFarmVerification(c *fiber.Ctx, approve bool) error {
+	start := time.Now()
+	path := c.Path()
+	method := c.Method()
+	slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+	var req farmservices.VerificationReviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+	}
+
+	adminWallet, _ := c.Locals("username").(string)
+	verification, err := farmservices.ReviewFarmVerification(adminWallet, c.Params("id"), approve, req.Note)
+	elapsed := time.Since(start)
+	if err != nil {
+		slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+		return farmErrorResponse(c, err)
+	}
+	slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+	return c.JSON(verification)
+}
+
+// moderateListing runs a hide/unhide action for the listing in the route params
+func moderateListing(c *fiber.Ctx, action func(adminWallet, listingID, reason string) error) error {
+	start := time.Now()
+	path := c.Path()
+	method := c.Method()
+	slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+	var req marketplaceServices.ModerationActionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+	}
+
+	adminWallet, _ := c.Locals("username").(string)
+	err := action(adminWallet, c.Params("id"), req.Reason)
+	elapsed := time.Since(start)
+	if err != nil {
+		slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+	return c.JSON(fiber.Map{"success": true})
+}
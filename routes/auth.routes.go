@@ -2,27 +2,29 @@ package routes
 
 import (
 	authservices "decentragri-app-cx-server/auth.services"
-	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/config"
+	"decentragri-app-cx-server/middleware"
+	"decentragri-app-cx-server/repository"
 	tokenServices "decentragri-app-cx-server/token.services"
-	"fmt"
+	"errors"
+	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func AuthRoutes(app *fiber.App, limiter fiber.Handler) {
+	// Routes span /api/auth and /api/renew, so the limiter is attached to each route;
+	// authGroup.Use would apply it to every /api route registered after these
 	authGroup := app.Group("/api")
 
-	// Apply rate limiting to auth routes
-	authGroup.Use(limiter)
-
 	//** WALLET AUTHENTICATION ROUTES **//
-	authGroup.Post("/auth/nonce", func(c *fiber.Ctx) error {
+	authGroup.Post("/auth/nonce", limiter, func(c *fiber.Ctx) error {
 		var req authservices.GetNonceRequest
 		if err := c.BodyParser(&req); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 		}
 
-		fmt.Printf("Received nonce request: %+v\n", req)
+		slog.DebugContext(c.UserContext(), "nonce requested")
 
 		response, err := authservices.GetNonce(req.WalletAddress)
 		if err != nil {
@@ -32,13 +34,13 @@ func AuthRoutes(app *fiber.App, limiter fiber.Handler) {
 		return c.JSON(response)
 	})
 
-	authGroup.Post("/auth/authenticate/wallet", func(c *fiber.Ctx) error {
+	authGroup.Post("/auth/authenticate/wallet", limiter, func(c *fiber.Ctx) error {
 		var req authservices.AuthenticateWalletRequest
 		if err := c.BodyParser(&req); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 		}
 
-		fmt.Printf("Received authentication data: %+v\n", req)
+		slog.DebugContext(c.UserContext(), "wallet authentication requested")
 
 		response, err := authservices.AuthenticateWallet(req)
 		if err != nil {
@@ -49,44 +51,29 @@ func AuthRoutes(app *fiber.App, limiter fiber.Handler) {
 	})
 
 	//** DEV BYPASS ROUTE - REMOVE IN PRODUCTION **//
-	authGroup.Post("/auth/dev-bypass", func(c *fiber.Ctx) error {
+	authGroup.Post("/auth/dev-bypass", limiter, func(c *fiber.Ctx) error {
 		// Check if dev bypass is enabled
 		if !authservices.CheckDevBypass(c) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Dev bypass not enabled"})
 		}
 
-		fmt.Println("Dev bypass authentication used")
+		slog.WarnContext(c.UserContext(), "dev bypass authentication used")
 
-		// Use a dev user wallet address
-		devWalletAddress := "0x984785A89BF95cb3d5Df4E45F670081944d8D547"
+		// Use the configured dev bypass wallet
+		devWalletAddress := config.DevBypassWallet()
 
-		// Check if dev user exists, create if not
-		query := `MATCH (u:User {username: $username}) RETURN u.username AS username`
-		params := map[string]any{"username": devWalletAddress}
-		records, err := memgraph.ExecuteRead(query, params)
+		// Create the dev user unless it exists
+		isNewUser, err := repository.NewUserRepository().CreateByUsername(repository.User{
+			Username:      devWalletAddress,
+			WalletAddress: devWalletAddress,
+			DeviceID:      "dev_device_001",
+			AuthProvider:  "dev_bypass",
+		})
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Database error: " + err.Error()})
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create dev user: " + err.Error()})
 		}
-
-		// Create dev user if it doesn't exist
-		if len(records) == 0 {
-			createQuery := `CREATE (u:User {
-				username: $username,
-				createdAt: timestamp(),
-				walletAddress: $walletAddress,
-				deviceId: $deviceId,
-				authProvider: 'dev_bypass'
-			}) RETURN u.username AS username`
-			createParams := map[string]any{
-				"username":      devWalletAddress,
-				"walletAddress": devWalletAddress,
-				"deviceId":      "dev_device_001",
-			}
-			_, err = memgraph.ExecuteWrite(createQuery, createParams)
-			if err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create dev user: " + err.Error()})
-			}
-			fmt.Println("Dev user created in database")
+		if isNewUser {
+			slog.InfoContext(c.UserContext(), "dev user created")
 		}
 
 		// Generate tokens for the dev user
@@ -99,7 +86,7 @@ func AuthRoutes(app *fiber.App, limiter fiber.Handler) {
 		response := authservices.AuthenticateWalletResponse{
 			WalletAddress: devWalletAddress,
 			Tokens:        *tokens,
-			IsNewUser:     len(records) == 0,
+			IsNewUser:     isNewUser,
 			Message:       "Dev bypass authentication successful",
 			LoginType:     "dev_bypass",
 		}
@@ -108,13 +95,13 @@ func AuthRoutes(app *fiber.App, limiter fiber.Handler) {
 	})
 
 	//** GOOGLE AUTHENTICATION ROUTES **//
-	authGroup.Post("/auth/authenticate/google", func(c *fiber.Ctx) error {
+	authGroup.Post("/auth/authenticate/google", limiter, func(c *fiber.Ctx) error {
 		var req authservices.AuthenticateGoogleRequest
 		if err := c.BodyParser(&req); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 		}
 
-		fmt.Printf("Received Google authentication request: Device ID: %s\n", req.DeviceId)
+		slog.DebugContext(c.UserContext(), "google authentication requested")
 
 		response, err := authservices.AuthenticateGoogle(req)
 		if err != nil {
@@ -124,13 +111,13 @@ func AuthRoutes(app *fiber.App, limiter fiber.Handler) {
 		return c.JSON(response)
 	})
 
-	authGroup.Post("/renew/access/decentra", func(c *fiber.Ctx) error {
+	authGroup.Post("/renew/access/decentra", limiter, func(c *fiber.Ctx) error {
 		var req authservices.RefreshTokenRequest
 		if err := c.BodyParser(&req); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 		}
 
-		fmt.Printf("Received refresh token request: %+v\n", req)
+		slog.DebugContext(c.UserContext(), "token refresh requested")
 
 		tokens, err := authservices.RefreshSession(req.RefreshToken)
 		if err != nil {
@@ -140,4 +127,18 @@ func AuthRoutes(app *fiber.App, limiter fiber.Handler) {
 		return c.JSON(tokens)
 	})
 
+	// DELETE /api/auth/account - Delete the caller's account; signing in again before the purge restores it
+	authGroup.Delete("/auth/account", limiter, middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		username, _ := c.Locals("username").(string)
+
+		if err := authservices.DeleteAccount(username); err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
 }
@@ -1,45 +1,58 @@
 package routes
 
 import (
-	"log"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
 
+	memgraph "decentragri-app-cx-server/db"
 	farmservices "decentragri-app-cx-server/farm.services"
+	"decentragri-app-cx-server/middleware"
 	"decentragri-app-cx-server/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func FarmRoutes(app *fiber.App, limiter fiber.Handler) {
-	api := app.Group("/api")
+	// Farm routes, rate limited on their own budget
+	farmGroup := app.Group("/api/farm", limiter)
 
-	// Apply rate limiting to farm routes
-	api.Use(limiter)
+	// GET /api/farm/list?includeImages=false - Get user's farms with formatted dates and image
+	// bytes; includeImages=false skips the image fetches and returns image URLs only
+	farmGroup.Get("/list", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
 
-	// Define farm group for farm-specific routes
-	farmGroup := api.Group("/farm")
+		slog.DebugContext(c.UserContext(), "farm list requested")
 
-	// GET /api/farm/list - Get user's farms with formatted dates and image bytes
-	farmGroup.Get("/list", func(c *fiber.Ctx) error {
-		// token := middleware.ExtractToken(c)
-
-		log.Println("Processing farm list request")
+		includeImages := true
+		if raw := c.Query("includeImages"); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				return utils.HandleValidationError(c, "includeImages")
+			}
+			includeImages = parsed
+		}
 
-		response, err := farmservices.GetFarmList()
+		response, err := farmservices.GetFarmList(token, includeImages)
 		if err != nil {
-			log.Printf("Error fetching farm list: %v", err)
+			slog.WarnContext(c.UserContext(), "failed to fetch farm list", "error", err)
 			return utils.HandleInternalError(c, err, "fetching farm list")
 		}
 
+		farmservices.LocalizeFarms(response, middleware.Locale(c))
 		return c.JSON(response)
 	})
 
-	// GET /api/farm/scans/:farmName - Get recent farm scans with pagination
-	farmGroup.Get("/scans/:farmName", func(c *fiber.Ctx) error {
+	// GET /api/farm/scans/:farmName?type=plant|soil&from=2025-01-01&to=2025-03-31 - Get recent farm scans with pagination
+	farmGroup.Get("/scans/:farmName", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
 		farmName := utils.SanitizeInput(c.Params("farmName"))
 
 		// Validate farm name input
 		if !utils.ValidateFarmName(farmName) {
-			log.Printf("Invalid farm name provided: %s", farmName)
+			slog.DebugContext(c.UserContext(), "invalid farm name", "farm", farmName)
 			return utils.HandleValidationError(c, "farmName")
 		}
 
@@ -49,14 +62,941 @@ func FarmRoutes(app *fiber.App, limiter fiber.Handler) {
 			return utils.HandleValidationError(c, err.Error())
 		}
 
-		log.Printf("Processing farm scans request for farm: %s, page: %d, limit: %d", farmName, page, limit)
+		// Optional date window and scan type filter
+		filter, err := farmservices.ParseScanFilter(c.Query("type"), c.Query("from"), c.Query("to"))
+		if err != nil {
+			return utils.HandleValidationError(c, err.Error())
+		}
+
+		// A cursor parameter, even empty for the first page, selects cursor pagination
+		if c.Context().QueryArgs().Has("cursor") {
+			slog.DebugContext(c.UserContext(), "farm scans requested", "farm", farmName, "cursor", c.Query("cursor"), "limit", limit)
+			response, err := farmservices.GetFarmScansAfter(token, farmName, c.Query("cursor"), limit, filter)
+			if errors.Is(err, memgraph.ErrInvalidCursor) {
+				return utils.HandleValidationError(c, "cursor")
+			}
+			if err != nil {
+				slog.WarnContext(c.UserContext(), "failed to fetch farm scans", "error", err)
+				return farmErrorResponse(c, err)
+			}
+			response.Localize(middleware.Locale(c))
+			return c.JSON(response)
+		}
 
-		response, err := farmservices.GetFarmScans(farmName, page, limit)
+		slog.DebugContext(c.UserContext(), "farm scans requested", "farm", farmName, "page", page, "limit", limit)
+
+		response, err := farmservices.GetFarmScans(token, farmName, page, limit, filter)
 		if err != nil {
-			log.Printf("Error fetching farm scans: %v", err)
-			return utils.HandleInternalError(c, err, "fetching farm scans")
+			slog.WarnContext(c.UserContext(), "failed to fetch farm scans", "error", err)
+			return farmErrorResponse(c, err)
 		}
 
+		response.Localize(middleware.Locale(c))
 		return c.JSON(response)
 	})
+
+	// POST /api/farm - Create a farm owned by the caller (JSON, or multipart with an "image" file)
+	farmGroup.Post("/", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.CreateFarmRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+		if err := boundaryFromForm(c, &req.Boundary); err != nil {
+			return utils.HandleValidationError(c, "boundary")
+		}
+
+		image, err := farmImageFromRequest(c)
+		if err != nil {
+			return utils.HandleValidationError(c, "image")
+		}
+
+		farm, err := farmservices.CreateFarm(c.UserContext(), token, req, image)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to create farm", "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		farm.Localize(middleware.Locale(c))
+		return c.Status(fiber.StatusCreated).JSON(farm)
+	})
+
+	// PATCH /api/farm/:id - Update one of the caller's farms; omitted fields are unchanged
+	farmGroup.Patch("/:id", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.UpdateFarmRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+		if err := boundaryFromForm(c, &req.Boundary); err != nil {
+			return utils.HandleValidationError(c, "boundary")
+		}
+
+		image, err := farmImageFromRequest(c)
+		if err != nil {
+			return utils.HandleValidationError(c, "image")
+		}
+
+		farm, err := farmservices.UpdateFarm(c.UserContext(), token, c.Params("id"), req, image)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to update farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		farm.Localize(middleware.Locale(c))
+		return c.JSON(farm)
+	})
+
+	// DELETE /api/farm/:id - Delete one of the caller's farms
+	farmGroup.Delete("/:id", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		if err := farmservices.DeleteFarm(token, c.Params("id")); err != nil {
+			slog.WarnContext(c.UserContext(), "failed to delete farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// POST /api/farm/:id/restore - Restore a deleted farm before it is purged (owner, or admins)
+	farmGroup.Post("/:id/restore", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		username, _ := c.Locals("username").(string)
+		farm, err := farmservices.RestoreFarm(token, c.Params("id"), middleware.IsAdmin(username))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to restore farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		farm.Localize(middleware.Locale(c))
+		return c.JSON(farm)
+	})
+
+	// POST /api/farm/:id/scans - Upload a plant scan (multipart: image, cropType, note) for AI interpretation
+	farmGroup.Post("/:id/scans", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.CreatePlantScanRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		image, err := farmImageFromRequest(c)
+		if err != nil || image == nil {
+			return utils.HandleValidationError(c, "image")
+		}
+
+		scan, err := farmservices.UploadPlantScan(c.UserContext(), token, c.Params("id"), req, image)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to upload plant scan for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		scan.Localize(middleware.Locale(c))
+		return c.Status(fiber.StatusAccepted).JSON(scan)
+	})
+
+	// GET /api/farm/:id/scans/search?q=leaf+rust&limit=20 - Plant scans whose note or
+	// interpretation mention every term, newest first
+	farmGroup.Get("/:id/scans/search", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		q := c.Query("q")
+		if q == "" {
+			return utils.HandleValidationError(c, "q")
+		}
+
+		scans, err := farmservices.SearchFarmScans(c.UserContext(), token, c.Params("id"), q, c.QueryInt("limit", 0))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to search scans of farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		for i := range scans {
+			scans[i].Localize(middleware.Locale(c))
+		}
+		return c.JSON(fiber.Map{"scans": scans})
+	})
+
+	// DELETE /api/farm/:id/scans/:scanId - Delete a plant scan (restorable until purged)
+	farmGroup.Delete("/:id/scans/:scanId", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		if err := farmservices.DeleteFarmScan(token, c.Params("id"), c.Params("scanId")); err != nil {
+			slog.WarnContext(c.UserContext(), "failed to delete scan of farm", "scanId", c.Params("scanId"), "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// POST /api/farm/:id/scans/:scanId/restore - Restore a deleted plant scan
+	farmGroup.Post("/:id/scans/:scanId/restore", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		if err := farmservices.RestoreFarmScan(token, c.Params("id"), c.Params("scanId")); err != nil {
+			slog.WarnContext(c.UserContext(), "failed to restore scan of farm", "scanId", c.Params("scanId"), "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"success": true})
+	})
+
+	// GET /api/farm/:id/sensors - The farm's sensors with last-seen time and online status
+	farmGroup.Get("/:id/sensors", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		sensors, err := farmservices.GetFarmSensors(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch sensors for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"sensors": sensors})
+	})
+
+	// GET /api/farm/:id/similar?limit=10 - Farms with a similar crop and soil profile
+	farmGroup.Get("/:id/similar", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		farms, err := farmservices.GetSimilarFarms(token, c.Params("id"), c.QueryInt("limit", 0))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to find similar farms", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"farms": farms})
+	})
+
+	// POST /api/farm/:id/readings - Record a soil sensor reading
+	farmGroup.Post("/:id/readings", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.CreateReadingRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		reading, err := farmservices.CreateReading(token, c.Params("id"), req)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to record reading for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		reading.Localize(middleware.Locale(c))
+		return c.Status(fiber.StatusCreated).JSON(reading)
+	})
+
+	// POST /api/farm/:id/readings/batch - Record up to 500 buffered readings at once; the
+	// response reports each reading's ID or validation error by index
+	farmGroup.Post("/:id/readings/batch", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.CreateReadingsBatchRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		result, err := farmservices.CreateReadingsBatch(token, c.Params("id"), req.Readings)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to record reading batch for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		if result.Accepted == 0 {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(result)
+		}
+		return c.Status(fiber.StatusCreated).JSON(result)
+	})
+
+	// GET /api/farm/:id/readings/series?metric=moisture&from=...&to=...&bucket=auto&points=500 - Downsampled reading series for charts
+	farmGroup.Get("/:id/readings/series", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		series, err := farmservices.GetReadingSeries(token, c.Params("id"), c.Query("metric"),
+			c.Query("from"), c.Query("to"), c.Query("bucket"), c.QueryInt("points", 0))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch reading series for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(series)
+	})
+
+	// GET /api/farm/:id/irrigation - Daily irrigation recommendations for the forecast week
+	farmGroup.Get("/:id/irrigation", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		plan, err := farmservices.GetIrrigationPlan(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to build irrigation plan for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(plan)
+	})
+
+	// GET /api/farm/:id/timeline?page=1&limit=10 - Scans, readings, alerts, tasks, harvests, and plot sales, newest first
+	farmGroup.Get("/:id/timeline", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		page, limit, err := utils.ValidatePagination(c.Query("page"), c.Query("limit"))
+		if err != nil {
+			return utils.HandleValidationError(c, err.Error())
+		}
+
+		timeline, err := farmservices.GetFarmTimeline(token, c.Params("id"), page, limit)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch timeline for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(timeline)
+	})
+
+	// GET /api/farm/:id/audit?page=1&limit=10 - Who changed what on the farm, newest first
+	// (owner, or admins for any farm including deleted ones)
+	farmGroup.Get("/:id/audit", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		page, limit, err := utils.ValidatePagination(c.Query("page"), c.Query("limit"))
+		if err != nil {
+			return utils.HandleValidationError(c, err.Error())
+		}
+
+		username, _ := c.Locals("username").(string)
+		audit, err := farmservices.GetFarmAudit(token, c.Params("id"), middleware.IsAdmin(username), page, limit)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch audit log for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(audit)
+	})
+
+	// GET /api/farm/:id/seasons - Crop seasons with their stage transitions, most recent first
+	farmGroup.Get("/:id/seasons", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		seasons, err := farmservices.GetSeasons(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch seasons for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"seasons": seasons})
+	})
+
+	// POST /api/farm/:id/seasons - Start a crop season (planting date, variety, expected stages)
+	farmGroup.Post("/:id/seasons", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.CreateSeasonRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		season, err := farmservices.CreateSeason(token, c.Params("id"), req)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to create season for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(season)
+	})
+
+	// POST /api/farm/:id/seasons/:seasonId/stages - Log a growth stage transition
+	farmGroup.Post("/:id/seasons/:seasonId/stages", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.StageTransitionRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		season, err := farmservices.LogStageTransition(token, c.Params("id"), c.Params("seasonId"), req)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to log stage for season", "seasonId", c.Params("seasonId"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(season)
+	})
+
+	// GET /api/farm/:id/harvests - Recorded harvests, most recent first
+	farmGroup.Get("/:id/harvests", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		harvests, err := farmservices.GetHarvests(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch harvests for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"harvests": harvests})
+	})
+
+	// POST /api/farm/:id/harvests - Record a harvest (date, quantity, quality grade, price received)
+	farmGroup.Post("/:id/harvests", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.CreateHarvestRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		harvest, err := farmservices.RecordHarvest(token, c.Params("id"), req)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to record harvest for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(harvest)
+	})
+
+	// GET /api/farm/:id/yield - Yield per hectare and revenue per crop season
+	farmGroup.Get("/:id/yield", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		yield, err := farmservices.GetFarmYield(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch yield for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(yield)
+	})
+
+	// GET /api/farm/:id/photos - Farm gallery with thumbnail URLs, cover first
+	farmGroup.Get("/:id/photos", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		photos, err := farmservices.GetFarmPhotos(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch photos for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"photos": photos})
+	})
+
+	// POST /api/farm/:id/photos - Upload gallery photos (multipart: one or more "images" files, optional caption)
+	farmGroup.Post("/:id/photos", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		images, err := farmImagesFromRequest(c, "images")
+		if err != nil || len(images) == 0 {
+			return utils.HandleValidationError(c, "images")
+		}
+
+		photos, err := farmservices.UploadFarmPhotos(c.UserContext(), token, c.Params("id"), c.FormValue("caption"), images)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to upload photos for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"photos": photos})
+	})
+
+	// PUT /api/farm/:id/photos/:photoId/cover - Make a gallery photo the farm's cover image
+	farmGroup.Put("/:id/photos/:photoId/cover", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		photo, err := farmservices.SetFarmCoverPhoto(token, c.Params("id"), c.Params("photoId"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to set cover photo of farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(photo)
+	})
+
+	// DELETE /api/farm/:id/photos/:photoId - Remove a photo from the gallery
+	farmGroup.Delete("/:id/photos/:photoId", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		if err := farmservices.DeleteFarmPhoto(token, c.Params("id"), c.Params("photoId")); err != nil {
+			slog.WarnContext(c.UserContext(), "failed to delete photo of farm", "photoId", c.Params("photoId"), "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// GET /api/farm/:id/verification - The farm's latest verification request and its outcome
+	farmGroup.Get("/:id/verification", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		verification, err := farmservices.GetFarmVerification(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch verification of farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(verification)
+	})
+
+	// POST /api/farm/:id/verification - Request verification (multipart: "documents" and "photos" files,
+	// "photoLocations" as a JSON array of {lat, lng} matching the photos, optional note)
+	farmGroup.Post("/:id/verification", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		documents, err := farmImagesFromRequest(c, "documents")
+		if err != nil {
+			return utils.HandleValidationError(c, "documents")
+		}
+		photos, err := farmImagesFromRequest(c, "photos")
+		if err != nil {
+			return utils.HandleValidationError(c, "photos")
+		}
+		var locations []farmservices.FarmCoordinates
+		if raw := c.FormValue("photoLocations"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &locations); err != nil {
+				return utils.HandleValidationError(c, "photoLocations")
+			}
+		}
+
+		verification, err := farmservices.SubmitFarmVerification(c.UserContext(), token, c.Params("id"), c.FormValue("note"), documents, photos, locations)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to submit verification of farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(verification)
+	})
+
+	// GET /api/farm/:id/collaborators - Users the farm is shared with
+	farmGroup.Get("/:id/collaborators", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		collaborators, err := farmservices.GetCollaborators(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch collaborators for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"collaborators": collaborators})
+	})
+
+	// POST /api/farm/:id/collaborators - Share the farm with a user by wallet address or email
+	farmGroup.Post("/:id/collaborators", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.InviteCollaboratorRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		collaborator, err := farmservices.InviteCollaborator(token, c.Params("id"), req)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to invite collaborator to farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(collaborator)
+	})
+
+	// PUT /api/farm/:id/collaborators/:username - Change a collaborator's role
+	farmGroup.Put("/:id/collaborators/:username", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req struct {
+			Role string `json:"role"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		collaborator, err := farmservices.UpdateCollaboratorRole(token, c.Params("id"), c.Params("username"), req.Role)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to update collaborator on farm", "username", c.Params("username"), "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(collaborator)
+	})
+
+	// DELETE /api/farm/:id/collaborators/:username - Revoke a collaborator's access, or leave a shared farm
+	farmGroup.Delete("/:id/collaborators/:username", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		if err := farmservices.RemoveCollaborator(token, c.Params("id"), c.Params("username")); err != nil {
+			slog.WarnContext(c.UserContext(), "failed to remove collaborator from farm", "username", c.Params("username"), "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// GET /api/farm/:id/plots - Farm plot NFTs linked to the farm
+	farmGroup.Get("/:id/plots", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		plots, err := farmservices.GetFarmPlots(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch plots of farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"plots": plots})
+	})
+
+	// POST /api/farm/:id/plots - Link a farm plot NFT to the farm it tokenizes
+	farmGroup.Post("/:id/plots", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.LinkFarmPlotRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		plot, err := farmservices.LinkFarmPlot(token, c.Params("id"), req)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to link plot to farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(plot)
+	})
+
+	// DELETE /api/farm/:id/plots/:tokenId - Unlink a farm plot NFT from the farm
+	farmGroup.Delete("/:id/plots/:tokenId", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		if err := farmservices.UnlinkFarmPlot(token, c.Params("id"), c.Params("tokenId")); err != nil {
+			slog.WarnContext(c.UserContext(), "failed to unlink plot from farm", "tokenId", c.Params("tokenId"), "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// GET /api/farm/:id/tasks?status=open&assignee=&dueBefore=&dueAfter= - A farm's tasks, soonest due first
+	farmGroup.Get("/:id/tasks", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		filter, err := farmservices.ParseTaskFilter(c.Query("status"), c.Query("assignee"), c.Query("dueBefore"), c.Query("dueAfter"))
+		if err != nil {
+			return utils.HandleValidationError(c, err.Error())
+		}
+
+		tasks, err := farmservices.GetFarmTasks(token, c.Params("id"), filter)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch tasks for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"tasks": tasks})
+	})
+
+	// POST /api/farm/:id/tasks - Create a task (title, assignee, due date, recurrence)
+	farmGroup.Post("/:id/tasks", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.CreateTaskRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		task, err := farmservices.CreateTask(token, c.Params("id"), req)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to create task for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(task)
+	})
+
+	// GET /api/farm/:id/alert-settings - Sensor thresholds and alert channels of a farm
+	farmGroup.Get("/:id/alert-settings", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		settings, err := farmservices.GetAlertSettings(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch alert settings for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(settings)
+	})
+
+	// PUT /api/farm/:id/alert-settings - Replace a farm's sensor thresholds and alert channels
+	farmGroup.Put("/:id/alert-settings", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var settings farmservices.FarmAlertSettings
+		if err := c.BodyParser(&settings); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		updated, err := farmservices.UpdateAlertSettings(token, c.Params("id"), settings)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to update alert settings for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(updated)
+	})
+
+	// GET /api/farm/:id/weather - Current weather and 7-day forecast at the farm's coordinates
+	farmGroup.Get("/:id/weather", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		weather, err := farmservices.GetFarmWeather(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch weather for farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(weather)
+	})
+
+	// GET /api/farm/shared - Farms other users have shared with the caller, with the caller's role
+	farmGroup.Get("/shared", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		farms, err := farmservices.GetSharedFarms(token)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch shared farms", "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		for i := range farms {
+			farms[i].Localize(middleware.Locale(c))
+		}
+		return c.JSON(fiber.Map{"farms": farms})
+	})
+
+	// GET /api/farm/tasks?status=open&assignee=&dueBefore=&dueAfter= - Tasks assigned to the caller or on the caller's farms
+	farmGroup.Get("/tasks", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		filter, err := farmservices.ParseTaskFilter(c.Query("status"), c.Query("assignee"), c.Query("dueBefore"), c.Query("dueAfter"))
+		if err != nil {
+			return utils.HandleValidationError(c, err.Error())
+		}
+
+		tasks, err := farmservices.GetMyTasks(token, filter)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch tasks", "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"tasks": tasks})
+	})
+
+	// PATCH /api/farm/tasks/:taskId - Edit or reassign an open task
+	farmGroup.Patch("/tasks/:taskId", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		var req farmservices.UpdateTaskRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.HandleValidationError(c, "body")
+		}
+
+		task, err := farmservices.UpdateTask(token, c.Params("taskId"), req)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to update task", "taskId", c.Params("taskId"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(task)
+	})
+
+	// POST /api/farm/tasks/:taskId/complete - Complete a task, opening the next occurrence of recurring ones
+	farmGroup.Post("/tasks/:taskId/complete", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		task, err := farmservices.CompleteTask(token, c.Params("taskId"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to complete task", "taskId", c.Params("taskId"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(task)
+	})
+
+	// DELETE /api/farm/tasks/:taskId - Delete a task
+	farmGroup.Delete("/tasks/:taskId", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		if err := farmservices.DeleteTask(token, c.Params("taskId")); err != nil {
+			slog.WarnContext(c.UserContext(), "failed to delete task", "taskId", c.Params("taskId"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// GET /api/farm/alerts?status=open - Sensor alerts across the caller's farms
+	farmGroup.Get("/alerts", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		alerts, err := farmservices.GetSensorAlerts(token, c.Query("status"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch sensor alerts", "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"alerts": alerts})
+	})
+
+	// POST /api/farm/alerts/:alertId/acknowledge - Acknowledge an open alert
+	farmGroup.Post("/alerts/:alertId/acknowledge", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		return updateAlertStatus(c, farmservices.AlertStatusAcknowledged)
+	})
+
+	// POST /api/farm/alerts/:alertId/resolve - Resolve an open or acknowledged alert
+	farmGroup.Post("/alerts/:alertId/resolve", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		return updateAlertStatus(c, farmservices.AlertStatusResolved)
+	})
+
+	// GET /api/farm/nearby?lat=16.46&lng=120.59&radiusKm=25&limit=50 - Farms near a point, nearest
+	// first, as a public projection without descriptions, boundaries, scans, or readings
+	farmGroup.Get("/nearby", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+		if err != nil {
+			return utils.HandleValidationError(c, "lat")
+		}
+		lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+		if err != nil {
+			return utils.HandleValidationError(c, "lng")
+		}
+		radiusKm := 0.0
+		if v := c.Query("radiusKm"); v != "" {
+			if radiusKm, err = strconv.ParseFloat(v, 64); err != nil || radiusKm <= 0 {
+				return utils.HandleValidationError(c, "radiusKm")
+			}
+		}
+
+		farms, err := farmservices.GetNearbyFarms(lat, lng, radiusKm, c.QueryInt("limit", 0))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to search nearby farms", "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{"farms": farms})
+	})
+
+	// GET /api/farm/plots/:tokenId - The farm a plot NFT is linked to; farm members also get
+	// its detail and latest sensor reading
+	farmGroup.Get("/plots/:tokenId", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		plot, err := farmservices.GetPlotFarm(token, c.Params("tokenId"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch farm of plot", "tokenId", c.Params("tokenId"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		plot.Localize(middleware.Locale(c))
+		return c.JSON(plot)
+	})
+
+	// GET /api/farm/:id - Farm detail including its GeoJSON boundary; registered last so
+	// fixed paths like /list take precedence
+	farmGroup.Get("/:id", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		farm, err := farmservices.GetFarmDetail(token, c.Params("id"))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "failed to fetch farm", "farmId", c.Params("id"), "error", err)
+			return farmErrorResponse(c, err)
+		}
+
+		farm.Localize(middleware.Locale(c))
+		return c.JSON(farm)
+	})
+}
+
+// updateAlertStatus moves the alert named in the path to status
+func updateAlertStatus(c *fiber.Ctx, status string) error {
+	token := middleware.ExtractToken(c)
+
+	alert, err := farmservices.UpdateSensorAlertStatus(token, c.Params("alertId"), status)
+	if err != nil {
+		slog.WarnContext(c.UserContext(), "failed to update alert", "alertId", c.Params("alertId"), "status", status, "error", err)
+		return farmErrorResponse(c, err)
+	}
+
+	return c.JSON(alert)
+}
+
+// boundaryFromForm decodes the "boundary" field of a multipart request, which carries
+// the GeoJSON polygon as a JSON string; JSON bodies are decoded by BodyParser
+func boundaryFromForm(c *fiber.Ctx, boundary **utils.GeoJSONPolygon) error {
+	if *boundary != nil {
+		return nil
+	}
+	form, err := c.MultipartForm()
+	if err != nil || len(form.Value["boundary"]) == 0 || form.Value["boundary"][0] == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(form.Value["boundary"][0]), boundary)
+}
+
+// farmImageFromRequest reads the optional "image" file of a multipart request
+func farmImageFromRequest(c *fiber.Ctx) (*farmservices.FarmImage, error) {
+	images, err := farmImagesFromRequest(c, "image")
+	if err != nil || len(images) == 0 {
+		return nil, err
+	}
+	return images[0], nil
+}
+
+// farmImagesFromRequest reads every multipart file sent under field. Requests without a
+// multipart body yield no images.
+func farmImagesFromRequest(c *fiber.Ctx, field string) ([]*farmservices.FarmImage, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, nil
+	}
+
+	images := make([]*farmservices.FarmImage, 0, len(form.File[field]))
+	for _, header := range form.File[field] {
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, &farmservices.FarmImage{Data: data, FileName: header.Filename})
+	}
+	return images, nil
+}
+
+// farmErrorResponse maps farm management errors to HTTP statuses. Input errors are 400;
+// anything unrecognised is logged and answered with a generic 500.
+func farmErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, farmservices.ErrFarmNotFound), errors.Is(err, farmservices.ErrAlertNotFound),
+		errors.Is(err, farmservices.ErrSeasonNotFound), errors.Is(err, farmservices.ErrTaskNotFound),
+		errors.Is(err, farmservices.ErrCollaboratorNotFound), errors.Is(err, farmservices.ErrPhotoNotFound),
+		errors.Is(err, farmservices.ErrPlotLinkNotFound), errors.Is(err, farmservices.ErrVerificationNotFound),
+		errors.Is(err, farmservices.ErrScanNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, farmservices.ErrNotFarmOwner), errors.Is(err, farmservices.ErrNotTaskAssignee),
+		errors.Is(err, farmservices.ErrFarmAccessDenied), errors.Is(err, farmservices.ErrNotPlotOwner):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, farmservices.ErrFarmNameTaken), errors.Is(err, farmservices.ErrInvalidAlertTransition),
+		errors.Is(err, farmservices.ErrActiveSeasonExists), errors.Is(err, farmservices.ErrInvalidStageTransition),
+		errors.Is(err, farmservices.ErrTaskCompleted), errors.Is(err, farmservices.ErrPlotAlreadyLinked),
+		errors.Is(err, farmservices.ErrVerificationPending), errors.Is(err, farmservices.ErrVerificationReviewed),
+		errors.Is(err, farmservices.ErrFarmAlreadyVerified):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, farmservices.ErrFarmNoCoordinates), errors.Is(err, farmservices.ErrNoRecentMoisture):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, farmservices.ErrInvalidRequest):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, memgraph.ErrQueryTimeout):
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"error": memgraph.ErrQueryTimeout.Error()})
+	}
+	return utils.HandleInternalError(c, err, "farm request")
 }
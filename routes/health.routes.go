@@ -0,0 +1,23 @@
+package routes
+
+import (
+	memgraph "decentragri-app-cx-server/db"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthRoutes registers unauthenticated health checks under /health for operators and
+// readiness probes. They are exempt from rate limiting.
+func HealthRoutes(app *fiber.App) {
+	group := app.Group("/health")
+
+	// GET /health/db - Memgraph connectivity, round-trip latency, and pool utilization;
+	// 503 when the database cannot be reached
+	group.Get("/db", func(c *fiber.Ctx) error {
+		health := memgraph.CheckHealth(c.UserContext())
+		if !health.Connected {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(health)
+		}
+		return c.JSON(health)
+	})
+}
@@ -0,0 +1,50 @@
+package routes
+
+import (
+	farmservices "decentragri-app-cx-server/farm.services"
+	"decentragri-app-cx-server/middleware"
+	"decentragri-app-cx-server/utils"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// InsightsRoutes registers aggregate, anonymized farm insights under /api/insights.
+// All routes require a valid JWT.
+func InsightsRoutes(app *fiber.App, limiter fiber.Handler) {
+	group := app.Group("/api/insights")
+	group.Use(limiter)
+	group.Use(middleware.AuthMiddleware())
+
+	// GET /api/insights/outbreaks?days=30&interval=week&diagnosis=rust&bbox=minLng,minLat,maxLng,maxLat
+	// - Heatmap cells of diagnosed pests and diseases
+	group.Get("/outbreaks", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		query := farmservices.OutbreakQuery{
+			Days:      c.QueryInt("days", 0),
+			Interval:  c.Query("interval"),
+			Diagnosis: c.Query("diagnosis"),
+		}
+		if bbox := c.Query("bbox"); bbox != "" {
+			box, err := utils.ParseBoundingBox(bbox)
+			if err != nil {
+				return utils.HandleValidationError(c, "bbox")
+			}
+			query.Box = box
+		}
+
+		outbreaks, err := farmservices.GetOutbreakMap(c.UserContext(), query)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return farmErrorResponse(c, err)
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(outbreaks)
+	})
+}
@@ -3,20 +3,16 @@ package routes
 import (
 	marketplaceservices "decentragri-app-cx-server/marketplace.services"
 	"decentragri-app-cx-server/middleware"
-	"fmt"
+	"errors"
+	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func MarketplaceRoutes(app *fiber.App, limiter fiber.Handler) {
-	api := app.Group("/api")
-
-	// Apply rate limiting to marketplace routes
-	api.Use(limiter)
-
-	// Protected marketplace group requiring authentication
-	group := api.Group("/marketplace")
+	// Protected marketplace group requiring authentication, rate limited on its own budget
+	group := app.Group("/api/marketplace", limiter)
 	group.Use(middleware.AuthMiddleware())
 
 	// GET /api/marketplace/valid-farmplots
@@ -25,20 +21,18 @@ func MarketplaceRoutes(app *fiber.App, limiter fiber.Handler) {
 		path := c.Path()
 		method := c.Method()
 
-		fmt.Printf("[%s] Starting %s request to %s\n", start.Format(time.RFC3339), method, path)
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
 
 		token := middleware.ExtractToken(c)
-		result, err := marketplaceservices.GetValidFarmPlotListings(token)
+		result, err := marketplaceservices.GetValidFarmPlotListings(token, c.Query("currency"), c.Query("imageSize"))
 
 		elapsed := time.Since(start)
 		if err != nil {
-			fmt.Printf("[%s] %s request to %s failed after %s: %v\n",
-				time.Now().Format(time.RFC3339), method, path, elapsed, err)
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		fmt.Printf("[%s] Completed %s request to %s successfully in %s\n",
-			time.Now().Format(time.RFC3339), method, path, elapsed)
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
 		return c.JSON(result)
 	})
 
@@ -48,30 +42,29 @@ func MarketplaceRoutes(app *fiber.App, limiter fiber.Handler) {
 		path := c.Path()
 		method := c.Method()
 
-		fmt.Printf("[%s] Starting %s request to %s\n", start.Format(time.RFC3339), method, path)
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
 
 		token := middleware.ExtractToken(c)
-		result, err := marketplaceservices.FeaturedProperty(token)
+		result, err := marketplaceservices.FeaturedProperty(token, c.Query("currency"))
 
 		elapsed := time.Since(start)
 		if err != nil {
-			fmt.Printf("[%s] %s request to %s failed after %s: %v\n",
-				time.Now().Format(time.RFC3339), method, path, elapsed, err)
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		fmt.Printf("[%s] Completed %s request to %s successfully in %s\n",
-			time.Now().Format(time.RFC3339), method, path, elapsed)
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
 		return c.JSON(result)
 	})
 
 	// POST /api/marketplace/buy-from-listing
-	group.Post("/buy-from-listing", func(c *fiber.Ctx) error {
+	// Clients should send an Idempotency-Key header so retries cannot double-purchase
+	group.Post("/buy-from-listing", middleware.IdempotencyMiddleware(), func(c *fiber.Ctx) error {
 		start := time.Now() // Start timing
 		path := c.Path()
 		method := c.Method()
 
-		fmt.Printf("[%s] Starting %s request to %s\n", start.Format(time.RFC3339), method, path)
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
 
 		var req marketplaceservices.BuyFromListingRequest
 		if err := c.BodyParser(&req); err != nil {
@@ -79,16 +72,566 @@ func MarketplaceRoutes(app *fiber.App, limiter fiber.Handler) {
 		}
 
 		token := middleware.ExtractToken(c)
-		result, err := marketplaceservices.BuyFromListing(token, &req)
+		result, err := marketplaceservices.BuyFromListing(c.UserContext(), token, &req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, marketplaceservices.ErrListingHidden) {
+				return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+			}
+			if errors.Is(err, marketplaceservices.ErrBuyerNotApproved) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+			}
+			if errors.Is(err, marketplaceservices.ErrInsufficientBalance) || errors.Is(err, marketplaceservices.ErrInsufficientAllowance) {
+				return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{"error": err.Error()})
+			}
+			if errors.Is(err, marketplaceservices.ErrPurchaseUnconfirmed) {
+				return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": err.Error()})
+			}
+			if errors.Is(err, marketplaceservices.ErrListingUnavailable) {
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": marketplaceservices.ErrListingUnavailable.Error()})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+
+		// Not mined yet: the client polls statusUrl for the final receipt
+		if !result.Mined() {
+			return c.Status(fiber.StatusAccepted).JSON(result)
+		}
+		return c.JSON(result)
+	})
+
+	// POST /api/marketplace/buy-batch
+	// Returns 207 when some items failed, 202 when some are still being mined
+	group.Post("/buy-batch", middleware.IdempotencyMiddleware(), func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceservices.BuyBatchRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.BuyBatch(c.UserContext(), token, &req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, marketplaceservices.ErrInsufficientBalance) || errors.Is(err, marketplaceservices.ErrInsufficientAllowance) {
+				return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed,
+			"succeeded", result.Succeeded, "pending", result.Pending, "failed", result.Failed)
+
+		switch {
+		case result.Failed == len(result.Items) && result.Unconfirmed > 0:
+			return c.Status(fiber.StatusBadGateway).JSON(result)
+		case result.Failed == len(result.Items):
+			return c.Status(fiber.StatusBadRequest).JSON(result)
+		case result.Failed > 0:
+			return c.Status(fiber.StatusMultiStatus).JSON(result)
+		case result.Pending > 0:
+			return c.Status(fiber.StatusAccepted).JSON(result)
+		}
+		return c.JSON(result)
+	})
+
+	// GET /api/marketplace/purchases/:id/status
+	group.Get("/purchases/:id/status", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetPurchaseStatus(token, c.Params("id"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// GET /api/marketplace/listings/map?bbox=minLng,minLat,maxLng,maxLat or ?lat=&lng=&radius=
+	group.Get("/listings/map", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		query := marketplaceservices.ListingMapQuery{
+			BBox:     c.Query("bbox"),
+			Lat:      c.QueryFloat("lat"),
+			Lng:      c.QueryFloat("lng"),
+			RadiusKm: c.QueryFloat("radius"),
+			Currency: c.Query("currency"),
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetListingMapPins(token, query)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// PATCH /api/marketplace/listings/:id
+	group.Patch("/listings/:id", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceservices.UpdateListingRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.UpdateListing(token, c.Params("id"), &req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, marketplaceservices.ErrNotListingSeller) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	})
+
+	// GET /api/marketplace/recommendations?region=benguet,+philippines&currency=PHP&imageSize=thumb&limit=10
+	group.Get("/recommendations", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetRecommendedListings(token, c.Query("region"), c.Query("currency"), c.Query("imageSize"), c.QueryInt("limit", 0))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// GET /api/marketplace/listings/:id/similar?currency=PHP&imageSize=thumb
+	group.Get("/listings/:id/similar", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetSimilarListings(token, c.Params("id"), c.Query("currency"), c.Query("imageSize"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// POST /api/marketplace/listings/:id/relist
+	group.Post("/listings/:id/relist", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceservices.RelistRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+			}
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.RelistListing(token, c.Params("id"), &req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, marketplaceservices.ErrNotListingSeller) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	})
+
+	// POST /api/marketplace/listings/:id/report
+	group.Post("/listings/:id/report", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceservices.ReportListingRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.ReportListing(token, c.Params("id"), &req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusCreated).JSON(result)
+	})
+
+	// GET /api/marketplace/listings/:id/approved-buyers
+	group.Get("/listings/:id/approved-buyers", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetReservedBuyers(token, c.Params("id"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, marketplaceservices.ErrNotListingSeller) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// POST /api/marketplace/listings/:id/approved-buyers
+	group.Post("/listings/:id/approved-buyers", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceservices.ApproveBuyerRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.ApproveReservedBuyer(token, c.Params("id"), req.Buyer)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, marketplaceservices.ErrNotListingSeller) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	})
+
+	// DELETE /api/marketplace/listings/:id/approved-buyers/:buyer
+	group.Delete("/listings/:id/approved-buyers/:buyer", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.RevokeReservedBuyer(token, c.Params("id"), c.Params("buyer"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, marketplaceservices.ErrNotListingSeller) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	})
+
+	// GET /api/marketplace/offers?tokenId=
+	group.Get("/offers", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetPlotOffers(token, c.Query("tokenId"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// POST /api/marketplace/make-offer
+	group.Post("/make-offer", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceservices.MakeOfferRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.MakeOffer(token, &req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	})
+
+	// POST /api/marketplace/accept-offer
+	group.Post("/accept-offer", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceservices.OfferActionRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.AcceptOffer(token, req.OfferID)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, marketplaceservices.ErrNotPlotOwner) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	})
+
+	// POST /api/marketplace/cancel-offer
+	group.Post("/cancel-offer", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceservices.OfferActionRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.CancelOffer(token, req.OfferID)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			if errors.Is(err, marketplaceservices.ErrNotOfferor) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	})
+
+	// GET /api/marketplace/watchlist
+	group.Get("/watchlist", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetWatchlist(token, c.Query("currency"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// POST /api/marketplace/watchlist/:listingId
+	group.Post("/watchlist/:listingId", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		err := marketplaceservices.AddToWatchlist(token, c.Params("listingId"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true})
+	})
+
+	// DELETE /api/marketplace/watchlist/:listingId
+	group.Delete("/watchlist/:listingId", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		err := marketplaceservices.RemoveFromWatchlist(token, c.Params("listingId"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"success": true})
+	})
+
+	// GET /api/marketplace/alerts
+	group.Get("/alerts", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetAlertPreferences(token)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// PUT /api/marketplace/alerts
+	group.Put("/alerts", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req marketplaceservices.UpdateAlertPreferencesRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.UpdateAlertPreferences(token, &req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// GET /api/marketplace/plots/:tokenId/price-history
+	group.Get("/plots/:tokenId/price-history", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetPlotPriceHistory(token, c.Params("tokenId"), c.Query("currency"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// GET /api/marketplace/listings/:id/fees?quantity=1
+	group.Get("/listings/:id/fees", func(c *fiber.Ctx) error {
+		start := time.Now() // Start timing
+		path := c.Path()
+		method := c.Method()
+
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := marketplaceservices.GetListingFees(token, c.Params("id"), c.Query("quantity"))
 		elapsed := time.Since(start)
 		if err != nil {
-			fmt.Printf("[%s] %s request to %s failed after %s: %v\n",
-				time.Now().Format(time.RFC3339), method, path, elapsed, err)
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		fmt.Printf("[%s] Completed %s request to %s successfully in %s\n",
-			time.Now().Format(time.RFC3339), method, path, elapsed)
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
 		return c.JSON(result)
 	})
 }
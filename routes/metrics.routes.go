@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"os"
+
+	memgraph "decentragri-app-cx-server/db"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MetricsRoutes registers the Prometheus scrape endpoint at /metrics. When METRICS_TOKEN
+// is set, scrapers must send it as a bearer token. It is exempt from rate limiting.
+func MetricsRoutes(app *fiber.App) {
+	// GET /metrics - Query duration histograms, row and error counts per query
+	// fingerprint, and connection pool gauges
+	app.Get("/metrics", func(c *fiber.Ctx) error {
+		if token := os.Getenv("METRICS_TOKEN"); token != "" {
+			if subtle.ConstantTimeCompare([]byte(c.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid metrics token"})
+			}
+		}
+
+		var body bytes.Buffer
+		memgraph.WriteMetrics(&body)
+		c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		return c.Send(body.Bytes())
+	})
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"decentragri-app-cx-server/middleware"
+	notificationServices "decentragri-app-cx-server/notification.services"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationRoutes registers the in-app notification endpoints under /api/notifications.
+// All routes require a valid JWT; users only ever see their own notifications.
+func NotificationRoutes(app *fiber.App, limiter fiber.Handler) {
+	group := app.Group("/api/notifications")
+	group.Use(limiter)
+	group.Use(middleware.AuthMiddleware())
+
+	// GET /api/notifications?unread=true - Most recent notifications and unread count
+	group.Get("/", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		result, err := notificationServices.GetNotifications(token, c.QueryBool("unread", false))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(result)
+	})
+
+	// PUT /api/notifications/:id/read - Mark one notification (or "all") as read
+	group.Put("/:id/read", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+		err := notificationServices.MarkNotificationRead(token, c.Params("id"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"success": true})
+	})
+}
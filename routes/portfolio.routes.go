@@ -1,46 +1,257 @@
 package routes
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
+	farmservices "decentragri-app-cx-server/farm.services"
 	"decentragri-app-cx-server/middleware"
 	portfolioservices "decentragri-app-cx-server/portfolio.services"
+	"decentragri-app-cx-server/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func PortfolioRoutes(app *fiber.App, limiter fiber.Handler) {
-	api := app.Group("/api")
-
-	// Apply rate limiting to portfolio routes
-	api.Use(limiter)
-
-	// Protected portfolio group requiring authentication
-	portfolioGroup := api.Group("/portfolio")
+	// Protected portfolio group requiring authentication, rate limited on its own budget
+	portfolioGroup := app.Group("/api/portfolio", limiter)
 	portfolioGroup.Use(middleware.AuthMiddleware())
 
 	portfolioGroup.Get("/summary", func(c *fiber.Ctx) error {
 		token := middleware.ExtractToken(c)
 
-		fmt.Println("tae: ", token)
-		fmt.Printf("Received portfolio summary request with token\n")
-
-		response, err := portfolioservices.GetPortFolioSummary(token)
+		response, err := portfolioservices.GetPortFolioSummary(token, c.Query("compare"))
+		if errors.Is(err, portfolioservices.ErrInvalidComparePeriod) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		return c.JSON(response)
+		return jsonWithETag(c, response)
 	})
 
 	portfolioGroup.Get("/entire", func(c *fiber.Ctx) error {
 		token := middleware.ExtractToken(c)
 
-		response, err := portfolioservices.GetEntirePortfolio(token)
+		// Paging is opt-in: without page/limit the whole portfolio is returned as before
+		page, limit := 0, 0
+		if c.Query("page") != "" || c.Query("limit") != "" {
+			var err error
+			page, limit, err = utils.ValidatePagination(c.Query("page"), c.Query("limit"))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+
+		quality, err := utils.ParseImageQuality(c.Query("quality"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		response, err := portfolioservices.GetEntirePortfolio(token, c.Query("images"), quality, page, limit)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		return c.JSON(response)
+		return jsonWithETag(c, response)
+	})
+
+	// GET /api/portfolio/history?range=30d&currency=PHP - Daily portfolio value over time
+	portfolioGroup.Get("/history", func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		response, err := portfolioservices.GetPortfolioHistory(token, c.Query("range"), c.Query("currency"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return jsonWithETag(c, response)
+	})
+
+	// GET /api/portfolio/pnl?currency=PHP - Cost basis and realized/unrealized gains per plot
+	portfolioGroup.Get("/pnl", func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		response, err := portfolioservices.GetPortfolioPnL(token, c.Query("currency"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return jsonWithETag(c, response)
+	})
+
+	// GET /api/portfolio/allocation?currency=PHP - Value split by asset class, crop type, and region
+	portfolioGroup.Get("/allocation", func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		response, err := portfolioservices.GetPortfolioAllocation(token, c.Query("currency"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return jsonWithETag(c, response)
+	})
+
+	// GET /api/portfolio/suggestions?currency=PHP&imageSize=thumb&limit=10 - Listed plots from farms like the ones behind the user's plots
+	portfolioGroup.Get("/suggestions", func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		response, err := farmservices.GetPortfolioSuggestions(token, c.Query("currency"), c.Query("imageSize"), c.QueryInt("limit", 0))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.JSON(fiber.Map{"suggestions": response})
+	})
+
+	// GET /api/portfolio/activity?type=sale,purchase&page=1&limit=20 - Purchases, sales, transfers, and listings, newest first
+	portfolioGroup.Get("/activity", func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		page, limit, err := utils.ValidatePagination(c.Query("page"), c.Query("limit"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		response, err := portfolioservices.GetPortfolioActivity(token, c.Query("type"), c.Query("currency"), page, limit)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return jsonWithETag(c, response)
+	})
+
+	// GET /api/portfolio/stream - Server-Sent Events: nft_received, listing_sold, value_change
+	portfolioGroup.Get("/stream", func(c *fiber.Ctx) error {
+		wallet, _ := c.Locals("username").(string)
+		events, unsubscribe := portfolioservices.SubscribePortfolio(wallet)
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no") // Disable Nginx response buffering for this stream
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer unsubscribe()
+
+			heartbeat := time.NewTicker(25 * time.Second)
+			defer heartbeat.Stop()
+
+			fmt.Fprintf(w, "retry: 5000\n: connected\n\n")
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			for {
+				select {
+				case event := <-events:
+					payload, err := json.Marshal(event)
+					if err != nil {
+						continue
+					}
+					fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload)
+				case <-heartbeat.C:
+					fmt.Fprintf(w, ": ping\n\n")
+				}
+				// A failed flush means the client went away
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		})
+		return nil
+	})
+
+	// GET /api/portfolio/export?format=csv|pdf&currency=PHP - Downloadable statement of holdings
+	portfolioGroup.Get("/export", func(c *fiber.Ctx) error {
+		token := middleware.ExtractToken(c)
+
+		format := c.Query("format", portfolioservices.ExportFormatCSV)
+		if format != portfolioservices.ExportFormatCSV && format != portfolioservices.ExportFormatPDF {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be csv or pdf"})
+		}
+
+		statement, err := portfolioservices.GetPortfolioStatement(token, c.Query("currency"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		write := portfolioservices.WriteStatementCSV
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		if format == portfolioservices.ExportFormatPDF {
+			write = portfolioservices.WriteStatementPDF
+			c.Set(fiber.HeaderContentType, "application/pdf")
+		}
+		c.Set(fiber.HeaderContentDisposition,
+			fmt.Sprintf(`attachment; filename="%s"`, portfolioservices.StatementFilename(statement, format)))
+		c.Set(fiber.HeaderCacheControl, "no-store")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			if err := write(w, statement); err != nil {
+				slog.WarnContext(c.UserContext(), "portfolio export failed", "wallet", statement.Wallet, "error", err)
+				return
+			}
+			w.Flush()
+		})
+		return nil
+	})
+
+	// Public image proxy for images=url responses. Keys are issued by the server, so
+	// this only serves images the API has already handed out; no auth header is needed
+	// so the URLs work directly in <img> tags.
+	images := app.Group("/api/images", limiter)
+
+	// GET /api/images/:key?quality=75 - Cached NFT image bytes with the detected content
+	// type, or a WebP thumbnail when quality is given
+	images.Get("/:key", func(c *fiber.Ctx) error {
+		quality, err := utils.ParseImageQuality(c.Query("quality"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		imageBytes, contentType, err := portfolioservices.GetProxiedImage(c.Params("key"), quality)
+		if err != nil {
+			if errors.Is(err, portfolioservices.ErrImageNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+			}
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		c.Set(fiber.HeaderContentType, contentType)
+		c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+		return c.Send(imageBytes)
 	})
 }
+
+// jsonWithETag sends v as JSON with a strong ETag derived from the body. Clients that
+// send a matching If-None-Match get 304 Not Modified without the (often image-heavy)
+// payload. Cache-Control forces revalidation so a stale portfolio is never reused blindly.
+func jsonWithETag(c *fiber.Ctx, v any) error {
+	body, err := c.App().Config().JSONEncoder(v)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+
+	for _, candidate := range strings.Split(c.Get(fiber.HeaderIfNoneMatch), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
@@ -12,6 +12,11 @@
 //   - POST /api/wallet/create: Create new smart wallets
 //   - GET /api/wallet/balances: Retrieve comprehensive token balances
 //   - GET /api/wallet/nfts/:contract: Query NFT ownership from specific contracts
+//   - GET/PUT /api/wallet/currency: Read or update the preferred fiat currency
+//   - GET /api/wallet/qr: Receive-address QR code (PNG/SVG, optional EIP-681)
+//   - POST/GET /api/wallet/session-keys: Grant or list smart account session keys
+//   - DELETE /api/wallet/session-keys/:signer: Revoke a session key
+//   - GET /api/wallet/prices/:token/history: Time-bucketed token price history
 //
 // Security Features:
 //   - JWT authentication middleware on all routes
@@ -21,9 +26,10 @@
 package routes
 
 import (
+	currencyServices "decentragri-app-cx-server/currency.services"
 	"decentragri-app-cx-server/middleware"
 	walletServices "decentragri-app-cx-server/wallet.services"
-	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -41,8 +47,12 @@ import (
 //
 // Registered Endpoints:
 //   - POST /create: Smart wallet creation with ThirdWeb integration
-//   - GET /balances: Multi-token balance queries with USD pricing
+//   - GET /balances: Multi-token balance queries with USD and fiat pricing
 //   - GET /nfts/:contract: NFT ownership queries for specific contracts
+//   - GET/PUT /currency: Preferred fiat currency management
+//   - GET /qr: Receive-address QR code generation
+//   - POST/GET/DELETE /session-keys: Smart account session key management
+//   - GET /prices/:token/history: Historical token prices for charts
 //
 // Performance Monitoring:
 //   - Request start time tracking
@@ -64,8 +74,8 @@ func WalletRoutes(app *fiber.App, limiter fiber.Handler) {
 
 	// Create wallet API route group with rate limiting and authentication middleware
 	wallet := app.Group("/api/wallet")
-	wallet.Use(limiter)
 	wallet.Use(middleware.AuthMiddleware())
+	wallet.Use(limiter) // Counted per wallet, so it runs after authentication
 
 	// POST /api/wallet/create - Create new smart wallet
 	// This endpoint creates a new ThirdWeb smart wallet for the authenticated user
@@ -75,7 +85,7 @@ func WalletRoutes(app *fiber.App, limiter fiber.Handler) {
 		start := time.Now()
 		path := c.Path()
 		method := c.Method()
-		fmt.Printf("[%s] Starting %s request to %s\n", start.Format(time.RFC3339), method, path)
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
 
 		// Extract JWT token for user identification
 		token := middleware.ExtractToken(c)
@@ -84,34 +94,35 @@ func WalletRoutes(app *fiber.App, limiter fiber.Handler) {
 		walletResponse, err := walletService.CreateWallet(token)
 		elapsed := time.Since(start)
 		if err != nil {
-			fmt.Printf("[%s] %s request to %s failed after %s: %v\n", time.Now().Format(time.RFC3339), method, path, elapsed, err)
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
-		fmt.Printf("[%s] Completed %s request to %s successfully in %s\n", time.Now().Format(time.RFC3339), method, path, elapsed)
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
 		return c.Status(fiber.StatusCreated).JSON(walletResponse)
 	})
 
 	// GET /api/wallet/balances - Retrieve comprehensive token balances
 	// This endpoint fetches native and ERC20 token balances with USD pricing
 	// Authentication: JWT token required
-	// Response: Complete balance information with USD values
+	// Parameters: currency (query, optional) - Fiat currency code (defaults to user preference)
+	// Response: Complete balance information with USD and fiat values
 	wallet.Get("/balances", func(c *fiber.Ctx) error {
 		start := time.Now()
 		path := c.Path()
 		method := c.Method()
-		fmt.Printf("[%s] Starting %s request to %s\n", start.Format(time.RFC3339), method, path)
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
 
 		// Extract JWT token for user identification
 		token := middleware.ExtractToken(c)
 
 		// Fetch comprehensive user balance information
-		balances, err := walletService.GetUserBalances(token)
+		balances, err := walletService.GetUserBalances(token, c.Query("currency"))
 		elapsed := time.Since(start)
 		if err != nil {
-			fmt.Printf("[%s] %s request to %s failed after %s: %v\n", time.Now().Format(time.RFC3339), method, path, elapsed, err)
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
-		fmt.Printf("[%s] Completed %s request to %s successfully in %s\n", time.Now().Format(time.RFC3339), method, path, elapsed)
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
 		return c.JSON(balances)
 	})
 
@@ -124,12 +135,12 @@ func WalletRoutes(app *fiber.App, limiter fiber.Handler) {
 		start := time.Now()
 		path := c.Path()
 		method := c.Method()
-		fmt.Printf("[%s] Starting %s request to %s\n", start.Format(time.RFC3339), method, path)
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
 
 		// Extract contract address from URL parameters
 		contract := c.Params("contract")
 		if contract == "" {
-			fmt.Printf("[%s] %s request to %s failed: contract parameter is required\n", time.Now().Format(time.RFC3339), method, path)
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "error", "contract parameter is required")
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "contract parameter is required"})
 		}
 
@@ -140,10 +151,190 @@ func WalletRoutes(app *fiber.App, limiter fiber.Handler) {
 		nfts, err := walletService.GetOwnedNFTs(contract, token)
 		elapsed := time.Since(start)
 		if err != nil {
-			fmt.Printf("[%s] %s request to %s failed after %s: %v\n", time.Now().Format(time.RFC3339), method, path, elapsed, err)
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
-		fmt.Printf("[%s] Completed %s request to %s successfully in %s\n", time.Now().Format(time.RFC3339), method, path, elapsed)
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
 		return c.JSON(nfts)
 	})
+
+	// GET /api/wallet/currency - Retrieve the preferred fiat currency
+	// Authentication: JWT token required
+	// Response: Current preference and the list of supported currencies
+	wallet.Get("/currency", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+
+		preference, err := currencyServices.GetPreferredCurrency(token)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(preference)
+	})
+
+	// PUT /api/wallet/currency - Update the preferred fiat currency
+	// Authentication: JWT token required
+	// Body: { "currency": "EUR" }
+	// Response: Updated preference and the list of supported currencies
+	wallet.Put("/currency", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req currencyServices.SetCurrencyRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		token := middleware.ExtractToken(c)
+
+		preference, err := currencyServices.SetPreferredCurrency(token, req.Currency)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(preference)
+	})
+
+	// GET /api/wallet/qr - Generate a receive-address QR code
+	// This endpoint renders the user's wallet address (or an EIP-681 payment URI) as a QR code
+	// Authentication: JWT token required
+	// Parameters (query, all optional):
+	//   - format: "png" (default) or "svg"
+	//   - size: Image size in pixels, 64-1024 (default 256)
+	//   - eip681: "true" to encode an EIP-681 payment URI
+	//   - token: "native" (default), "dagri", or an ERC20 contract address
+	//   - amount: Requested amount in token units (e.g. "1.5")
+	// Response: Raw image bytes with the matching Content-Type
+	wallet.Get("/qr", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		req := walletServices.ReceiveQRRequest{
+			Format: c.Query("format"),
+			Size:   c.QueryInt("size"),
+			EIP681: c.QueryBool("eip681"),
+			Token:  c.Query("token"),
+			Amount: c.Query("amount"),
+		}
+
+		token := middleware.ExtractToken(c)
+
+		qr, err := walletService.GenerateReceiveQR(token, req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+
+		c.Set(fiber.HeaderContentType, qr.ContentType)
+		c.Set("X-QR-Content", qr.Content)
+		return c.Send(qr.Image)
+	})
+
+	// POST /api/wallet/session-keys - Grant a session key on the user's smart account
+	// Authentication: JWT token required
+	// Body: signerAddress, contractAddresses, nativeTokenLimitPerTransaction, startDate, expirationDate
+	// Response: Engine queue ID for the grant transaction
+	wallet.Post("/session-keys", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		var req walletServices.CreateSessionKeyRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		token := middleware.ExtractToken(c)
+
+		result, err := walletService.CreateSessionKey(token, req)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	})
+
+	// GET /api/wallet/session-keys - List session keys on the user's smart account
+	// Authentication: JWT token required
+	// Response: Array of session keys with approved targets and validity windows
+	wallet.Get("/session-keys", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+
+		sessionKeys, err := walletService.GetSessionKeys(token)
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(sessionKeys)
+	})
+
+	// DELETE /api/wallet/session-keys/:signer - Revoke a session key
+	// Authentication: JWT token required
+	// Parameters: signer (path) - The session key signer address to revoke
+	// Response: Engine queue ID for the revoke transaction
+	wallet.Delete("/session-keys/:signer", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		token := middleware.ExtractToken(c)
+
+		result, err := walletService.RevokeSessionKey(token, c.Params("signer"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	})
+
+	// GET /api/wallet/prices/:token/history - Retrieve historical token prices
+	// This endpoint returns time-bucketed USD prices for price charts
+	// Authentication: JWT token required
+	// Parameters:
+	//   - token (path): "native" or "dagri"
+	//   - range (query, optional): 24h, 7d (default), 30d, 90d, or 1y
+	// Response: Price buckets with open/high/low/close/average values
+	wallet.Get("/prices/:token/history", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		history, err := walletServices.GetTokenPriceHistory(c.Params("token"), c.Query("range"))
+		elapsed := time.Since(start)
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(history)
+	})
 }
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	webhookServices "decentragri-app-cx-server/webhook.services"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookRoutes registers inbound webhook endpoints under /api/webhooks.
+// These routes are called by external services rather than the app, so they use
+// signature validation instead of JWT authentication.
+func WebhookRoutes(app *fiber.App) {
+	group := app.Group("/api/webhooks")
+
+	// POST /api/webhooks/engine - ThirdWeb Engine contract subscription events
+	group.Post("/engine", func(c *fiber.Ctx) error {
+		start := time.Now()
+		path := c.Path()
+		method := c.Method()
+		slog.DebugContext(c.UserContext(), "request started", "method", method, "path", path)
+
+		body := c.Body()
+		if err := webhookServices.VerifyEngineSignature(body, c.Get("X-Engine-Timestamp"), c.Get("X-Engine-Signature")); err != nil {
+			slog.WarnContext(c.UserContext(), "request rejected", "method", method, "path", path, "error", err)
+			if errors.Is(err, webhookServices.ErrInvalidSignature) {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid signature"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "webhook not configured"})
+		}
+
+		err := webhookServices.HandleEngineWebhook(body)
+		elapsed := time.Since(start)
+		if err != nil {
+			// A non-2xx response makes Engine retry the delivery
+			slog.WarnContext(c.UserContext(), "request failed", "method", method, "path", path, "elapsed", elapsed, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		slog.DebugContext(c.UserContext(), "request completed", "method", method, "path", path, "elapsed", elapsed)
+		return c.JSON(fiber.Map{"received": true})
+	})
+}
@@ -5,7 +5,8 @@ import (
 	"os"
 	"time"
 
-	memgraph "decentragri-app-cx-server/db"
+	"decentragri-app-cx-server/config"
+	"decentragri-app-cx-server/repository"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -15,6 +16,9 @@ const (
 	REFRESH_TOKEN_EXPIRY = 30 * 24 * time.Hour
 )
 
+// users resolves token subjects to stored accounts
+var users = repository.NewUserRepository()
+
 // TokenScheme represents the structure of JWT tokens returned to clients.
 // It includes both access and refresh tokens along with the associated username.
 type TokenScheme struct {
@@ -70,8 +74,8 @@ func (ts *TokenService) generateToken(username string, expiry time.Duration) (st
 // Returns the username if verification is successful, or an error if the token is invalid or the user doesn't exist.
 func (ts *TokenService) VerifyAccessToken(tokenStr string) (string, error) {
 	// Check for dev bypass token first
-	if tokenStr == "dev_bypass_authorized" {
-		return "0x984785A89BF95cb3d5Df4E45F670081944d8D547", nil
+	if tokenStr == config.DevBypassToken {
+		return config.DevBypassWallet(), nil
 	}
 
 	secret := os.Getenv("JWT_SECRET_KEY")
@@ -93,26 +97,25 @@ func (ts *TokenService) VerifyAccessToken(tokenStr string) (string, error) {
 		return "", errors.New("username not found in token")
 	}
 
-	query := "MATCH (u:User {username: $userName}) RETURN u.username AS username"
-	params := map[string]any{"userName": userName}
-
-	records, err := memgraph.ExecuteRead(query, params)
-	if err != nil {
+	if _, err := users.FindByUsername(userName); err != nil {
 		return "", err
 	}
-	if len(records) == 0 {
-		return "", errors.New("user does not exist")
-	}
 	return userName, nil
 }
 
+// ResolveIdentity returns the wallet address a request token acts as: the configured
+// dev bypass wallet for the dev bypass placeholder, otherwise the verified JWT's user.
+func ResolveIdentity(tokenStr string) (string, error) {
+	return NewTokenService().VerifyAccessToken(tokenStr)
+}
+
 // VerifyRefreshToken validates a refresh token and generates new tokens if valid.
 // It checks the token's signature and expiration, then creates a new token pair.
 // Returns a new TokenScheme with fresh tokens if verification is successful, or an error if the token is invalid.
 func (ts *TokenService) VerifyRefreshToken(tokenStr string) (*TokenScheme, error) {
 	// Check for dev bypass token first
-	if tokenStr == "dev_bypass_authorized" {
-		return ts.GenerateTokens("0x984785A89BF95cb3d5Df4E45F670081944d8D547")
+	if tokenStr == config.DevBypassToken {
+		return ts.GenerateTokens(config.DevBypassWallet())
 	}
 
 	secret := os.Getenv("JWT_SECRET_KEY")
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OperatorAlert represents an operational alert sent to the on-call channel
+type OperatorAlert struct {
+	Severity  string         `json:"severity"` // "warning" or "critical"
+	Subject   string         `json:"subject"`
+	Message   string         `json:"message"`
+	Details   map[string]any `json:"details,omitempty"`
+	Timestamp int64          `json:"timestamp"`
+}
+
+// SendOperatorAlert logs an operational alert and, when ALERT_WEBHOOK_URL is set,
+// posts it as JSON to the webhook (Slack/Discord-compatible relays, PagerDuty, etc.)
+func SendOperatorAlert(severity, subject, message string, details map[string]any) {
+	alert := OperatorAlert{
+		Severity:  severity,
+		Subject:   subject,
+		Message:   message,
+		Details:   details,
+		Timestamp: time.Now().Unix(),
+	}
+
+	slog.Warn("operator alert", "severity", severity, "subject", subject, "message", message)
+
+	webhookURL := os.Getenv("ALERT_WEBHOOK_URL")
+	if webhookURL == "" {
+		return
+	}
+
+	agent := fiber.Post(webhookURL)
+	agent.JSON(alert)
+	status, body, errs := agent.Bytes()
+	if len(errs) > 0 {
+		slog.Error("failed to deliver operator alert", "subject", subject, "error", errs[0])
+		return
+	}
+	if status < 200 || status >= 300 {
+		slog.Error("operator alert webhook rejected the alert", "subject", subject, "status", status, "body", string(body))
+	}
+}
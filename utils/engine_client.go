@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"context"
+	"crypto/md5"
+	"decentragri-app-cx-server/cache"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker for a host is open and no cached data is available
+var ErrCircuitOpen = errors.New("upstream temporarily unavailable (circuit open)")
+
+// EngineClient is the shared HTTP client for all ThirdWeb Engine and Insight calls.
+// It applies a request timeout, retries transient failures with exponential backoff,
+// and trips a per-host circuit breaker after repeated failures. While a circuit is
+// open, GET requests are served from the last successful response cached in Redis.
+//
+// Configuration (environment):
+//   - ENGINE_HTTP_TIMEOUT: Per-attempt timeout (default 15s)
+//   - ENGINE_MAX_RETRIES: Retries after the first attempt (default 3)
+//   - ENGINE_RETRY_BASE_DELAY: Initial backoff delay, doubled per retry (default 200ms)
+//   - ENGINE_CB_FAILURE_THRESHOLD: Consecutive failures that open the circuit (default 5)
+//   - ENGINE_CB_RESET_TIMEOUT: How long the circuit stays open before a trial request (default 30s)
+//   - ENGINE_FALLBACK_TTL: How long successful GET responses are kept for fallback (default 24h)
+type EngineClient struct {
+	timeout          time.Duration
+	maxRetries       int
+	baseDelay        time.Duration
+	failureThreshold int
+	resetTimeout     time.Duration
+	fallbackTTL      time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// circuitBreaker tracks consecutive failures for a single upstream host
+type circuitBreaker struct {
+	failures  int
+	openUntil time.Time
+	halfOpen  bool
+}
+
+var (
+	engineClient     *EngineClient
+	engineClientOnce sync.Once
+)
+
+// Engine returns the shared EngineClient, configured from the environment on first use
+func Engine() *EngineClient {
+	engineClientOnce.Do(func() {
+		engineClient = &EngineClient{
+			timeout:          envDuration("ENGINE_HTTP_TIMEOUT", 15*time.Second),
+			maxRetries:       envInt("ENGINE_MAX_RETRIES", 3),
+			baseDelay:        envDuration("ENGINE_RETRY_BASE_DELAY", 200*time.Millisecond),
+			failureThreshold: envInt("ENGINE_CB_FAILURE_THRESHOLD", 5),
+			resetTimeout:     envDuration("ENGINE_CB_RESET_TIMEOUT", 30*time.Second),
+			fallbackTTL:      envDuration("ENGINE_FALLBACK_TTL", 24*time.Hour),
+			breakers:         make(map[string]*circuitBreaker),
+		}
+	})
+	return engineClient
+}
+
+// Get performs a GET request. Successful responses are cached so they can be
+// served while the upstream is unavailable.
+func (ec *EngineClient) Get(rawURL string, headers map[string]string) (int, []byte, error) {
+	return ec.GetContext(context.Background(), rawURL, headers)
+}
+
+// GetContext is Get forwarding the request ID carried by ctx
+func (ec *EngineClient) GetContext(ctx context.Context, rawURL string, headers map[string]string) (int, []byte, error) {
+	return ec.do(ctx, fiber.MethodGet, rawURL, headers, nil, true)
+}
+
+// GetLive performs a GET request without the cached fallback. Use it for status
+// polling and health checks, where stale data would be misleading.
+func (ec *EngineClient) GetLive(rawURL string, headers map[string]string) (int, []byte, error) {
+	return ec.GetLiveContext(context.Background(), rawURL, headers)
+}
+
+// GetLiveContext is GetLive forwarding the request ID carried by ctx
+func (ec *EngineClient) GetLiveContext(ctx context.Context, rawURL string, headers map[string]string) (int, []byte, error) {
+	return ec.do(ctx, fiber.MethodGet, rawURL, headers, nil, false)
+}
+
+// Post performs a POST request with a JSON body. Writes are only retried when
+// the upstream signals the request was not processed (429/503), so a purchase
+// is never submitted twice.
+func (ec *EngineClient) Post(rawURL string, headers map[string]string, body any) (int, []byte, error) {
+	return ec.PostContext(context.Background(), rawURL, headers, body)
+}
+
+// PostContext is Post forwarding the request ID carried by ctx
+func (ec *EngineClient) PostContext(ctx context.Context, rawURL string, headers map[string]string, body any) (int, []byte, error) {
+	return ec.do(ctx, fiber.MethodPost, rawURL, headers, body, false)
+}
+
+// do executes a request with retries, circuit breaking, and optional GET fallback. The
+// request ID carried by ctx is sent as X-Request-ID so Engine logs can be matched to ours.
+func (ec *EngineClient) do(ctx context.Context, method, rawURL string, headers map[string]string, body any, useFallback bool) (int, []byte, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+	}
+
+	host := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	fallbackKey := engineFallbackKey(rawURL)
+
+	if !ec.allow(host) {
+		if useFallback {
+			if cached, ok := ec.fallback(fallbackKey); ok {
+				slog.Warn("circuit open, serving cached response", "host", host)
+				return fiber.StatusOK, cached, nil
+			}
+		}
+		return 0, nil, ErrCircuitOpen
+	}
+
+	var (
+		status   int
+		respBody []byte
+		lastErr  error
+	)
+
+	for attempt := 0; attempt <= ec.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(ec.backoff(attempt))
+		}
+
+		var agent *fiber.Agent
+		if method == fiber.MethodPost {
+			agent = fiber.Post(rawURL)
+		} else {
+			agent = fiber.Get(rawURL)
+		}
+		agent.Timeout(ec.timeout)
+		for k, v := range headers {
+			agent.Set(k, v)
+		}
+		if requestID := RequestID(ctx); requestID != "" {
+			agent.Set(RequestIDHeader, requestID)
+		}
+		if payload != nil {
+			agent.Set("Content-Type", "application/json")
+			agent.Body(payload)
+		}
+
+		var errs []error
+		status, respBody, errs = agent.Bytes()
+		if len(errs) > 0 {
+			lastErr = errs[0]
+			// A POST that failed at the transport level may still have reached Engine
+			if method == fiber.MethodPost {
+				break
+			}
+			continue
+		}
+
+		if !ec.retryable(method, status) {
+			lastErr = nil
+			break
+		}
+		lastErr = fmt.Errorf("upstream returned status %d", status)
+	}
+
+	if lastErr != nil || status >= 500 {
+		ec.recordFailure(host)
+		if useFallback {
+			if cached, ok := ec.fallback(fallbackKey); ok {
+				slog.WarnContext(ctx, "Engine request failed, serving cached response", "host", host, "error", lastErr)
+				return fiber.StatusOK, cached, nil
+			}
+		}
+		return status, respBody, lastErr
+	}
+
+	ec.recordSuccess(host)
+	if useFallback && status >= 200 && status < 300 {
+		cache.Set(fallbackKey, respBody, ec.fallbackTTL)
+	}
+
+	return status, respBody, nil
+}
+
+// retryable reports whether a response status should be retried for the method
+func (ec *EngineClient) retryable(method string, status int) bool {
+	if status == fiber.StatusTooManyRequests || status == fiber.StatusServiceUnavailable {
+		return true
+	}
+	return method == fiber.MethodGet && status >= 500
+}
+
+// backoff returns the exponential backoff delay (with jitter) for an attempt
+func (ec *EngineClient) backoff(attempt int) time.Duration {
+	delay := ec.baseDelay * time.Duration(1<<(attempt-1))
+	jitter := time.Duration(rand.Int63n(int64(ec.baseDelay) + 1))
+	return delay + jitter
+}
+
+// allow reports whether a request to the host may proceed under the circuit breaker
+func (ec *EngineClient) allow(host string) bool {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	cb, ok := ec.breakers[host]
+	if !ok || cb.openUntil.IsZero() {
+		return true
+	}
+	if time.Now().Before(cb.openUntil) {
+		return false
+	}
+	// Reset timeout elapsed: allow a single trial request
+	if cb.halfOpen {
+		return false
+	}
+	cb.halfOpen = true
+	return true
+}
+
+// recordSuccess closes the circuit for the host
+func (ec *EngineClient) recordSuccess(host string) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	delete(ec.breakers, host)
+}
+
+// recordFailure counts a failure and opens the circuit once the threshold is reached
+func (ec *EngineClient) recordFailure(host string) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	cb, ok := ec.breakers[host]
+	if !ok {
+		cb = &circuitBreaker{}
+		ec.breakers[host] = cb
+	}
+
+	cb.failures++
+	if cb.halfOpen || cb.failures >= ec.failureThreshold {
+		cb.openUntil = time.Now().Add(ec.resetTimeout)
+		cb.halfOpen = false
+		slog.Warn("circuit opened after consecutive failures", "host", host, "failures", cb.failures)
+	}
+}
+
+// fallback returns the last successful response cached for a GET request
+func (ec *EngineClient) fallback(key string) ([]byte, bool) {
+	var cached []byte
+	if err := cache.Get(key, &cached); err != nil || len(cached) == 0 {
+		return nil, false
+	}
+	return cached, true
+}
+
+// engineFallbackKey builds the Redis key holding the fallback response for a URL
+func engineFallbackKey(rawURL string) string {
+	hasher := md5.New()
+	hasher.Write([]byte(rawURL))
+	return fmt.Sprintf("engine_fallback:%s", hex.EncodeToString(hasher.Sum(nil)))
+}
+
+// envDuration reads a duration from the environment with a default
+func envDuration(name string, def time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return def
+}
+
+// envInt reads a non-negative integer from the environment with a default
+func envInt(name string, def int) int {
+	if v := os.Getenv(name); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return def
+}
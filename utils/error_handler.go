@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"log"
+	"log/slog"
 	"runtime"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,7 +19,7 @@ func HandleError(c *fiber.Ctx, err error, userMessage string, statusCode int) er
 	// Log internal error with context
 	pc, file, line, _ := runtime.Caller(1)
 	funcName := runtime.FuncForPC(pc).Name()
-	log.Printf("Error in %s (%s:%d): %v", funcName, file, line, err)
+	slog.ErrorContext(c.UserContext(), "request failed", "func", funcName, "file", file, "line", line, "status", statusCode, "error", err)
 
 	// Return sanitized error to client
 	return c.Status(statusCode).JSON(ErrorResponse{
@@ -30,7 +30,7 @@ func HandleError(c *fiber.Ctx, err error, userMessage string, statusCode int) er
 
 // HandleValidationError handles input validation errors
 func HandleValidationError(c *fiber.Ctx, fieldName string) error {
-	log.Printf("Validation error: invalid %s provided by client %s", fieldName, c.IP())
+	slog.WarnContext(c.UserContext(), "validation failed", "field", fieldName, "ip", c.IP())
 
 	return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
 		Error: "Invalid input provided",
@@ -40,7 +40,7 @@ func HandleValidationError(c *fiber.Ctx, fieldName string) error {
 
 // HandleAuthError handles authentication and authorization errors
 func HandleAuthError(c *fiber.Ctx, err error) error {
-	log.Printf("Authentication error from client %s: %v", c.IP(), err)
+	slog.WarnContext(c.UserContext(), "authentication failed", "ip", c.IP(), "error", err)
 
 	return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
 		Error: "Authentication failed",
@@ -52,7 +52,7 @@ func HandleAuthError(c *fiber.Ctx, err error) error {
 func HandleInternalError(c *fiber.Ctx, err error, operation string) error {
 	pc, file, line, _ := runtime.Caller(1)
 	funcName := runtime.FuncForPC(pc).Name()
-	log.Printf("Internal error in %s (%s:%d) during %s: %v", funcName, file, line, operation, err)
+	slog.ErrorContext(c.UserContext(), "internal error", "func", funcName, "file", file, "line", line, "operation", operation, "error", err)
 
 	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 		Error: "Internal server error",
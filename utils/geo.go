@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// earthRadiusKm is the mean Earth radius used for great-circle distances
+const earthRadiusKm = 6371.0
+
+// BoundingBox is a geographic rectangle in degrees. MinLng may be greater than MaxLng
+// when the box crosses the antimeridian.
+type BoundingBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// ParseBoundingBox parses "minLng,minLat,maxLng,maxLat" (GeoJSON order)
+func ParseBoundingBox(bbox string) (*BoundingBox, error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox must be minLng,minLat,maxLng,maxLat")
+	}
+
+	values := make([]float64, 4)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bbox value %q", part)
+		}
+		values[i] = v
+	}
+
+	box := &BoundingBox{MinLng: values[0], MinLat: values[1], MaxLng: values[2], MaxLat: values[3]}
+	if !ValidCoordinates(box.MinLat, box.MinLng) || !ValidCoordinates(box.MaxLat, box.MaxLng) {
+		return nil, fmt.Errorf("bbox coordinates out of range")
+	}
+	if box.MinLat > box.MaxLat {
+		return nil, fmt.Errorf("bbox minLat must not exceed maxLat")
+	}
+	return box, nil
+}
+
+// Contains reports whether the point lies inside the box
+func (b *BoundingBox) Contains(lat, lng float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLng <= b.MaxLng {
+		return lng >= b.MinLng && lng <= b.MaxLng
+	}
+	// Box crosses the antimeridian
+	return lng >= b.MinLng || lng <= b.MaxLng
+}
+
+// ValidCoordinates reports whether lat/lng are within valid degree ranges
+func ValidCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// HaversineKm returns the great-circle distance between two points in kilometres
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// RegionFromLocation normalizes a farm location to a region by dropping the most specific
+// component, e.g. "La Trinidad, Benguet, Philippines" -> "benguet, philippines"
+func RegionFromLocation(location string) string {
+	parts := strings.Split(strings.ToLower(location), ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) >= 3 {
+		parts = parts[1:]
+	}
+	return strings.Trim(strings.Join(parts, ", "), ", ")
+}
+
+// earthRadiusM is the WGS84 equatorial radius used for polygon areas
+const earthRadiusM = 6378137.0
+
+// maxRingVertices bounds the vertices accepted per polygon ring
+const maxRingVertices = 1000
+
+// GeoJSONPolygon is a GeoJSON Polygon geometry. Positions are [lng, lat]; the first
+// ring is the outer boundary and any further rings are holes.
+type GeoJSONPolygon struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// ValidatePolygon checks that a polygon is a GeoJSON Polygon whose rings are closed,
+// have at least three distinct vertices, stay within coordinate ranges, and do not
+// cross themselves
+func ValidatePolygon(polygon *GeoJSONPolygon) error {
+	if polygon.Type != "Polygon" {
+		return fmt.Errorf("boundary type must be Polygon")
+	}
+	if len(polygon.Coordinates) == 0 {
+		return fmt.Errorf("boundary has no rings")
+	}
+
+	for i, ring := range polygon.Coordinates {
+		name := "outer ring"
+		if i > 0 {
+			name = fmt.Sprintf("hole %d", i)
+		}
+		if len(ring) < 4 {
+			return fmt.Errorf("%s needs at least 4 positions", name)
+		}
+		if len(ring) > maxRingVertices {
+			return fmt.Errorf("%s exceeds %d positions", name, maxRingVertices)
+		}
+		if ring[0] != ring[len(ring)-1] {
+			return fmt.Errorf("%s is not closed (first and last positions differ)", name)
+		}
+		for _, position := range ring {
+			if !ValidCoordinates(position[1], position[0]) {
+				return fmt.Errorf("%s has coordinates out of range", name)
+			}
+		}
+		if ringSelfIntersects(ring) {
+			return fmt.Errorf("%s crosses itself", name)
+		}
+		if ringAreaM2(ring) == 0 {
+			return fmt.Errorf("%s has no area", name)
+		}
+	}
+	return nil
+}
+
+// PolygonAreaHectares returns the area of a valid polygon, excluding holes, in hectares
+func PolygonAreaHectares(polygon *GeoJSONPolygon) float64 {
+	if len(polygon.Coordinates) == 0 {
+		return 0
+	}
+	area := ringAreaM2(polygon.Coordinates[0])
+	for _, hole := range polygon.Coordinates[1:] {
+		area -= ringAreaM2(hole)
+	}
+	if area < 0 {
+		area = 0
+	}
+	return area / 10000
+}
+
+// ringAreaM2 returns the area of a closed ring on the sphere in square metres
+// (Chamberlain & Duquette, "Some Algorithms for Polygons on a Sphere")
+func ringAreaM2(ring [][2]float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	var sum float64
+	for i := 0; i < len(ring)-1; i++ {
+		p1, p2 := ring[i], ring[i+1]
+		sum += toRad(p2[0]-p1[0]) * (2 + math.Sin(toRad(p1[1])) + math.Sin(toRad(p2[1])))
+	}
+	return math.Abs(sum * earthRadiusM * earthRadiusM / 2)
+}
+
+// ringSelfIntersects reports whether any two non-adjacent edges of a closed ring cross
+func ringSelfIntersects(ring [][2]float64) bool {
+	edges := len(ring) - 1
+	for i := 0; i < edges; i++ {
+		for j := i + 1; j < edges; j++ {
+			// Adjacent edges share a vertex, including the last and first edge
+			if j == i+1 || (i == 0 && j == edges-1) {
+				continue
+			}
+			if segmentsIntersect(ring[i], ring[i+1], ring[j], ring[j+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// segmentsIntersect reports whether segments p1-p2 and p3-p4 touch or cross
+func segmentsIntersect(p1, p2, p3, p4 [2]float64) bool {
+	cross := func(a, b, c [2]float64) float64 {
+		return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+	}
+	onSegment := func(a, b, c [2]float64) bool {
+		return math.Min(a[0], b[0]) <= c[0] && c[0] <= math.Max(a[0], b[0]) &&
+			math.Min(a[1], b[1]) <= c[1] && c[1] <= math.Max(a[1], b[1])
+	}
+
+	d1 := cross(p3, p4, p1)
+	d2 := cross(p3, p4, p2)
+	d3 := cross(p1, p2, p3)
+	d4 := cross(p1, p2, p4)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(p3, p4, p1)) || (d2 == 0 && onSegment(p3, p4, p2)) ||
+		(d3 == 0 && onSegment(p1, p2, p3)) || (d4 == 0 && onSegment(p1, p2, p4))
+}
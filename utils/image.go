@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strconv"
+	"strings"
+
+	// Register decoders for formats commonly served from IPFS
+	_ "image/gif"
+
+	"github.com/gen2brain/webp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ImageSizes maps the supported ?imageSize values to their maximum edge length in pixels.
+// "full" (or an empty value) keeps the original image.
+var ImageSizes = map[string]int{
+	"thumb":  160,
+	"small":  320,
+	"medium": 640,
+	"large":  1280,
+}
+
+// NormalizeImageSize validates an ?imageSize value, returning "" for the original image
+func NormalizeImageSize(size string) (string, error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	if size == "" || size == "full" || size == "original" {
+		return "", nil
+	}
+	if _, ok := ImageSizes[size]; !ok {
+		return "", fmt.Errorf("unsupported image size: %s (use thumb, small, medium, large, or full)", size)
+	}
+	return size, nil
+}
+
+// ResizeImage scales an image so its longest edge is at most maxEdge pixels, preserving
+// the aspect ratio. Images with transparency are re-encoded as PNG, everything else as
+// JPEG. Images already within the limit are returned unchanged.
+func ResizeImage(data []byte, maxEdge int) ([]byte, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	if bounds.Dx() <= maxEdge && bounds.Dy() <= maxEdge {
+		return data, nil
+	}
+
+	dst := scaleToFit(src, maxEdge)
+
+	var buf bytes.Buffer
+	if format == "png" && !dst.Opaque() {
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 82})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resized image: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// scaleToFit returns src scaled so its longest edge is at most maxEdge pixels
+func scaleToFit(src image.Image, maxEdge int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxEdge && height <= maxEdge {
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+		return dst
+	}
+
+	if width >= height {
+		height = height * maxEdge / width
+		width = maxEdge
+	} else {
+		width = width * maxEdge / height
+		height = maxEdge
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst
+}
+
+// ParseImageQuality validates a ?quality value (1-100). An empty value returns 0,
+// meaning no transcoding was requested.
+func ParseImageQuality(quality string) (int, error) {
+	quality = strings.TrimSpace(quality)
+	if quality == "" {
+		return 0, nil
+	}
+	q, err := strconv.Atoi(quality)
+	if err != nil || q < 1 || q > 100 {
+		return 0, fmt.Errorf("quality must be between 1 and 100")
+	}
+	return q, nil
+}
+
+// WebPThumbnail scales an image so its longest edge is at most maxEdge pixels and
+// re-encodes it as lossy WebP at the given quality (1-100). Transparency is kept.
+func WebPThumbnail(data []byte, maxEdge, quality int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, scaleToFit(src, maxEdge), webp.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode WebP thumbnail: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLocale is used when a request names no supported locale
+const DefaultLocale = "en-US"
+
+// dateLocale describes how one locale writes dates. Layouts use Go's English month
+// names, which are swapped for Months after formatting.
+type dateLocale struct {
+	Date        string
+	DateTime    string
+	Months      [12]string
+	Unavailable string
+}
+
+// dateLocales holds the supported locales, keyed by canonical tag
+var dateLocales = map[string]dateLocale{
+	"en-US": {
+		Date:     "January 2, 2006",
+		DateTime: "January 2, 2006 - 3:04pm",
+		Months: [12]string{"January", "February", "March", "April", "May", "June",
+			"July", "August", "September", "October", "November", "December"},
+		Unavailable: "Date unavailable",
+	},
+	"en-GB": {
+		Date:     "2 January 2006",
+		DateTime: "2 January 2006 - 15:04",
+		Months: [12]string{"January", "February", "March", "April", "May", "June",
+			"July", "August", "September", "October", "November", "December"},
+		Unavailable: "Date unavailable",
+	},
+	"fil": {
+		Date:     "January 2, 2006",
+		DateTime: "January 2, 2006 - 3:04pm",
+		Months: [12]string{"Enero", "Pebrero", "Marso", "Abril", "Mayo", "Hunyo",
+			"Hulyo", "Agosto", "Setyembre", "Oktubre", "Nobyembre", "Disyembre"},
+		Unavailable: "Walang petsa",
+	},
+	"es": {
+		Date:     "2 de January de 2006",
+		DateTime: "2 de January de 2006 - 15:04",
+		Months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio",
+			"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		Unavailable: "Fecha no disponible",
+	},
+	"pt": {
+		Date:     "2 de January de 2006",
+		DateTime: "2 de January de 2006 - 15:04",
+		Months: [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+			"julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		Unavailable: "Data indisponível",
+	},
+	"fr": {
+		Date:     "2 January 2006",
+		DateTime: "2 January 2006 - 15:04",
+		Months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin",
+			"juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		Unavailable: "Date indisponible",
+	},
+	"de": {
+		Date:     "2. January 2006",
+		DateTime: "2. January 2006 - 15:04",
+		Months: [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni",
+			"Juli", "August", "September", "Oktober", "November", "Dezember"},
+		Unavailable: "Datum nicht verfügbar",
+	},
+	"id": {
+		Date:     "2 January 2006",
+		DateTime: "2 January 2006 - 15.04",
+		Months: [12]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+			"Juli", "Agustus", "September", "Oktober", "November", "Desember"},
+		Unavailable: "Tanggal tidak tersedia",
+	},
+}
+
+// localeAliases maps other tags to a supported locale
+var localeAliases = map[string]string{
+	"en": "en-US",
+	"tl": "fil",
+}
+
+// MatchLocale picks the supported locale that best fits an Accept-Language header,
+// honoring q-values and falling back from a regional tag to its language
+// ("es-MX" → "es"). Returns DefaultLocale when nothing matches.
+func MatchLocale(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	tags := make([]weighted, 0)
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if locale, ok := lookupLocale(t.tag); ok {
+			return locale
+		}
+		if base, _, found := strings.Cut(t.tag, "-"); found {
+			if locale, ok := lookupLocale(base); ok {
+				return locale
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// lookupLocale resolves a language tag case-insensitively to a supported locale
+func lookupLocale(tag string) (string, bool) {
+	for locale := range dateLocales {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+	}
+	for alias, locale := range localeAliases {
+		if strings.EqualFold(alias, tag) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// FormatDate writes a date ("January 2, 2006" in en-US) in the given locale
+func FormatDate(t time.Time, locale string) string {
+	l := dateLocaleFor(locale)
+	return l.format(t, l.Date)
+}
+
+// FormatDateTime writes a date and time ("January 2, 2006 - 3:04pm" in en-US) in the given locale
+func FormatDateTime(t time.Time, locale string) string {
+	l := dateLocaleFor(locale)
+	return l.format(t, l.DateTime)
+}
+
+// dateLocaleFor returns the locale's rules, or the default locale's when unsupported
+func dateLocaleFor(locale string) dateLocale {
+	if l, ok := dateLocales[locale]; ok {
+		return l
+	}
+	return dateLocales[DefaultLocale]
+}
+
+// format renders t with layout, substituting the locale's month name. Zero times
+// render as the locale's "date unavailable" text.
+func (l dateLocale) format(t time.Time, layout string) string {
+	if t.IsZero() {
+		return l.Unavailable
+	}
+	return strings.Replace(t.Format(layout), t.Month().String(), l.Months[t.Month()-1], 1)
+}
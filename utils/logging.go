@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InitLogging installs the process-wide structured logger. Production (NODE_ENV=production)
+// logs JSON at info level; elsewhere text at debug level. LOG_FORMAT (json, text) and
+// LOG_LEVEL (debug, info, warn, error) override either. Records logged with a request
+// context carry its request_id, and log.Printf output goes through the same handler at
+// info level. Call it first thing in main, after the environment is loaded.
+func InitLogging() {
+	production := os.Getenv("NODE_ENV") == "production"
+
+	level := slog.LevelDebug
+	if production {
+		level = slog.LevelInfo
+	}
+	if raw := os.Getenv("LOG_LEVEL"); raw != "" {
+		if err := level.UnmarshalText([]byte(raw)); err != nil {
+			log.Printf("Warning: invalid LOG_LEVEL %q, using %s", raw, level)
+		}
+	}
+
+	format := "text"
+	if production {
+		format = "json"
+	}
+	if raw := strings.ToLower(os.Getenv("LOG_FORMAT")); raw == "json" || raw == "text" {
+		format = raw
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, options)
+	if format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	}
+	handler = &contextHandler{Handler: handler}
+	if burst, every := samplingFromEnv(); every > 1 {
+		handler = &samplingHandler{Handler: handler, sampler: newSampler(burst, every)}
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// contextHandler adds the request ID carried by a record's context as request_id
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// samplingFromEnv reads LOG_SAMPLE_BURST (default 100) and LOG_SAMPLE_EVERY (default 1,
+// sampling off)
+func samplingFromEnv() (burst, every int) {
+	burst, every = 100, 1
+	if n, err := strconv.Atoi(os.Getenv("LOG_SAMPLE_BURST")); err == nil && n >= 0 {
+		burst = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LOG_SAMPLE_EVERY")); err == nil && n > 0 {
+		every = n
+	}
+	return burst, every
+}
+
+// samplingHandler thins hot-path records below warn level: per message and second, the
+// first burst records pass and then one in every. Warnings and errors always pass.
+type samplingHandler struct {
+	slog.Handler
+	sampler *sampler
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level < slog.LevelWarn && !h.sampler.allow(record.Message, record.Time) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
+
+// sampler counts records per message within the current one-second window
+type sampler struct {
+	burst, every int
+
+	mu     sync.Mutex
+	window int64
+	counts map[string]int
+}
+
+func newSampler(burst, every int) *sampler {
+	return &sampler{burst: burst, every: every, counts: make(map[string]int)}
+}
+
+func (s *sampler) allow(message string, at time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if window := at.Unix(); window != s.window {
+		s.window = window
+		clear(s.counts)
+	}
+	s.counts[message]++
+	n := s.counts[message]
+	return n <= s.burst || (n-s.burst)%s.every == 0
+}
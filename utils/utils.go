@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"decentragri-app-cx-server/config"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -14,7 +15,6 @@ import (
 	"math/big"
 	"strings"
 
-	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
 )
 
@@ -73,13 +73,11 @@ func EnginePost(uri string, body any) (string, error) {
 	
 	engineAccessToken := GetEnv("ENGINE_ACCESS_TOKEN")
 
-	agent := fiber.Post(engineUri + uri)
-	agent.Set("Authorization", "Bearer "+ engineAccessToken) // set Authorization header
-	agent.JSON(body)                                        // set JSON body
-
-	_, respBody, errs := agent.Bytes()
-	if len(errs) > 0 {
-		return "", errs[0]
+	_, respBody, err := Engine().Post(engineUri+uri, map[string]string{
+		"Authorization": "Bearer " + engineAccessToken,
+	}, body)
+	if err != nil {
+		return "", err
 	}
 
 	return string(respBody), nil
@@ -88,13 +86,11 @@ func EnginePost(uri string, body any) (string, error) {
 func EngineGet(uri string) (string, error) {
 	engineUri := GetEnv("ENGINE_URI")
 	engineAccessToken := os.Getenv("ENGINE_ACCESS_TOKEN")
-	fmt.Println("engine access token:", engineAccessToken)
-	agent := fiber.Get(engineUri + uri)
-	agent.Set("Authorization", "Bearer "+engineAccessToken) // set Authorization header
-
-	_, respBody, errs := agent.Bytes()
-	if len(errs) > 0 {
-		return "", errs[0]
+	_, respBody, err := Engine().Get(engineUri+uri, map[string]string{
+		"Authorization": "Bearer " + engineAccessToken,
+	})
+	if err != nil {
+		return "", err
 	}
 
 	return string(respBody), nil
@@ -103,17 +99,23 @@ func EngineGet(uri string) (string, error) {
 // ParseEther converts a string representation of Ether (e.g., "1.23") to its value in Wei as *big.Int.
 // It assumes 18 decimals (1 Ether = 10^18 Wei).
 func ParseEther(ether string) (*big.Int, error) {
-	parts := strings.SplitN(ether, ".", 2)
+	return ParseUnits(ether, 18)
+}
+
+// ParseUnits converts a decimal amount (e.g. "1.5") into base units of a token with the
+// given number of decimals (e.g. 1500000 for 6). Extra fractional digits are dropped.
+func ParseUnits(amount string, decimals int) (*big.Int, error) {
+	parts := strings.SplitN(amount, ".", 2)
 	intPart := parts[0]
 	decPart := ""
 	if len(parts) == 2 {
 		decPart = parts[1]
-		if len(decPart) > 18 {
-			decPart = decPart[:18] // trim to 18 decimals
+		if len(decPart) > decimals {
+			decPart = decPart[:decimals] // trim to the token's decimals
 		}
 	}
-	// Pad decimal part to 18 digits
-	decPart = decPart + strings.Repeat("0", 18-len(decPart))
+	// Pad decimal part to the token's decimals
+	decPart = decPart + strings.Repeat("0", decimals-len(decPart))
 
 	weiStr := intPart + decPart
 	wei := new(big.Int)
@@ -126,6 +128,31 @@ func ParseEther(ether string) (*big.Int, error) {
 
 var ErrInvalidEtherString = fmt.Errorf("invalid ether string")
 
+// FormatUnits converts a base-unit amount (e.g. wei) into a decimal string with the given
+// number of decimals, trimming trailing zeros (e.g. 1500000000000000000, 18 -> "1.5").
+func FormatUnits(value *big.Int, decimals int) string {
+	if value == nil {
+		return "0"
+	}
+	negative := value.Sign() < 0
+	digits := new(big.Int).Abs(value).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	intPart := digits[:len(digits)-decimals]
+	decPart := strings.TrimRight(digits[len(digits)-decimals:], "0")
+
+	result := intPart
+	if decPart != "" {
+		result += "." + decPart
+	}
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
+
 // uploadPicBuffer uploads an image buffer to IPFS via thirdweb storage and returns the resulting URI.
 func UploadPicBuffer(ctx context.Context, buffer []byte, fileName string) (string, error) {
 	// Prepare multipart form
@@ -147,6 +174,9 @@ func UploadPicBuffer(ctx context.Context, buffer []byte, fileName string) (strin
 		return "", err
 	}
 	req.Header.Set("Content-Type", w.FormDataContentType())
+	if requestID := RequestID(ctx); requestID != "" {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
 
 	// Optionally: set thirdweb clientId and secretKey if required
 	clientId := os.Getenv("THIRDWEB_CLIENT_ID")
@@ -183,14 +213,28 @@ func UploadPicBuffer(ctx context.Context, buffer []byte, fileName string) (strin
 }
 
 
-// EnsureTransactionMined checks the status of a transaction by queueId using Fiber HTTP client
+// EnsureTransactionMined checks the status of a transaction by queueId on the Engine at
+// ENGINE_URI. Transactions queued on the Engine Cloud instance are read with
+// GetTransactionStatus instead.
 func EnsureTransactionMined(queueId string) (*TransactionStatus, error) {
-	url := os.Getenv("ENGINE_URI") + "/transaction/status/" + queueId
-	req := fiber.Get(url)
-	req.Set("Authorization", "Bearer "+os.Getenv("ENGINE_ACCESS_TOKEN"))
-	status, body, errs := req.Bytes()
-	if len(errs) > 0 {
-		return nil, errs[0]
+	return readTransactionStatus(os.Getenv("ENGINE_URI"), os.Getenv("ENGINE_ACCESS_TOKEN"), queueId)
+}
+
+// GetTransactionStatus returns the status of a transaction queued on the Engine Cloud
+// instance (config.EngineCloudBaseURL), where marketplace purchases and offers are
+// submitted with SECRET_KEY. Only that Engine knows their queue IDs.
+func GetTransactionStatus(queueId string) (*TransactionStatus, error) {
+	return readTransactionStatus(config.EngineCloudBaseURL, os.Getenv("SECRET_KEY"), queueId)
+}
+
+// readTransactionStatus reads a transaction's status from the Engine at baseURL
+func readTransactionStatus(baseURL, accessToken, queueId string) (*TransactionStatus, error) {
+	url := baseURL + "/transaction/status/" + queueId
+	status, body, err := Engine().GetLive(url, map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	})
+	if err != nil {
+		return nil, err
 	}
 	if status < 200 || status >= 300 {
 		return nil, fmt.Errorf("engine transaction status failed: %s", string(body))
@@ -210,4 +254,36 @@ func EnsureTransactionMined(queueId string) (*TransactionStatus, error) {
 	}
 
 	return result.Result, nil
-}
\ No newline at end of file
+}
+
+// WaitForTransaction polls GetTransactionStatus until the transaction reaches a final
+// state (mined, errored, or cancelled) or the timeout elapses. On timeout the last seen
+// status is returned so callers can hand the queueId back for polling.
+func WaitForTransaction(queueId string, timeout, interval time.Duration) (*TransactionStatus, error) {
+	deadline := time.Now().Add(timeout)
+	var last *TransactionStatus
+	var lastErr error
+
+	for {
+		status, err := GetTransactionStatus(queueId)
+		if err == nil {
+			last = status
+			switch status.Status {
+			case "mined", "errored", "cancelled":
+				return status, nil
+			}
+		} else {
+			lastErr = err
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			break
+		}
+		time.Sleep(interval)
+	}
+
+	if last == nil {
+		return nil, lastErr
+	}
+	return last, nil
+}
@@ -61,6 +61,29 @@ func ValidatePagination(pageStr, limitStr string) (int, int, error) {
 	return page, limit, nil
 }
 
+// PaginationInfo contains pagination metadata
+type PaginationInfo struct {
+	Page        int  `json:"page"`
+	Limit       int  `json:"limit"`
+	Total       int  `json:"total"`
+	TotalPages  int  `json:"totalPages"`
+	HasNext     bool `json:"hasNext"`
+	HasPrevious bool `json:"hasPrevious"`
+}
+
+// NewPaginationInfo builds pagination metadata for a page of a result set of total items
+func NewPaginationInfo(page, limit, total int) PaginationInfo {
+	totalPages := (total + limit - 1) / limit // Ceiling division
+	return PaginationInfo{
+		Page:        page,
+		Limit:       limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+}
+
 // SanitizeInput removes potentially dangerous characters and trims whitespace
 func SanitizeInput(input string) string {
 	// Remove null bytes and control characters
@@ -0,0 +1,201 @@
+package walletservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	memgraph "decentragri-app-cx-server/db"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nativeTokenAddress is the placeholder address used by the price provider for native tokens
+const nativeTokenAddress = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+
+// historyRanges maps supported range parameters to their window and bucket width
+var historyRanges = map[string]struct {
+	window time.Duration
+	bucket time.Duration
+}{
+	"24h": {24 * time.Hour, 1 * time.Hour},
+	"7d":  {7 * 24 * time.Hour, 4 * time.Hour},
+	"30d": {30 * 24 * time.Hour, 24 * time.Hour},
+	"90d": {90 * 24 * time.Hour, 24 * time.Hour},
+	"1y":  {365 * 24 * time.Hour, 7 * 24 * time.Hour},
+}
+
+// trackedTokens lists the tokens whose prices are recorded for history charts
+var trackedTokens = map[string]string{
+	"native": nativeTokenAddress,
+	"dagri":  config.DAGRIContractAddress,
+}
+
+// RecordTokenPrices fetches current USD prices for all tracked tokens and
+// persists them as (:TokenPrice) samples in Memgraph.
+func RecordTokenPrices() error {
+	chainInt, err := strconv.Atoi(config.CHAIN)
+	if err != nil {
+		return fmt.Errorf("invalid chain ID: %w", err)
+	}
+
+	for symbol, address := range trackedTokens {
+		price, err := GetTokenPriceUSD(chainInt, address)
+		if err != nil {
+			slog.Warn("failed to fetch price for history", "symbol", symbol, "error", err)
+			continue
+		}
+		if err := recordTokenPrice(symbol, price); err != nil {
+			slog.Warn("failed to record price sample", "symbol", symbol, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// recordTokenPrice writes a single price sample for a token
+func recordTokenPrice(symbol string, priceUSD float64) error {
+	query := `CREATE (:TokenPrice {
+		token: $token,
+		chainId: $chainId,
+		priceUSD: $priceUSD,
+		timestamp: $timestamp
+	})`
+	params := map[string]any{
+		"token":     symbol,
+		"chainId":   config.CHAIN,
+		"priceUSD":  priceUSD,
+		"timestamp": time.Now().Unix(),
+	}
+	_, err := memgraph.ExecuteWrite(query, params)
+	return err
+}
+
+// StartPriceHistoryRecorder samples tracked token prices on a fixed interval.
+// The interval defaults to 15 minutes and can be overridden with PRICE_SAMPLE_INTERVAL
+// (a Go duration string such as "5m"). It blocks, so run it in a goroutine.
+func StartPriceHistoryRecorder() {
+	interval := 15 * time.Minute
+	if v := os.Getenv("PRICE_SAMPLE_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("token price history recorder started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	RecordTokenPrices()
+	for range ticker.C {
+		RecordTokenPrices()
+	}
+}
+
+// GetTokenPriceHistory returns time-bucketed historical USD prices for a token.
+//
+// Parameters:
+//   - tokenSymbol: "native" or "dagri"
+//   - rangeParam: One of 24h, 7d, 30d, 90d, 1y (defaults to 7d)
+//
+// Returns:
+//   - *PriceHistory: OHLC-style buckets over the requested window
+//   - error: Invalid parameters or database failures
+func GetTokenPriceHistory(tokenSymbol, rangeParam string) (*PriceHistory, error) {
+	tokenSymbol = strings.ToLower(tokenSymbol)
+	tokenAddress, ok := trackedTokens[tokenSymbol]
+	if !ok {
+		return nil, fmt.Errorf("unsupported token: %s", tokenSymbol)
+	}
+
+	if rangeParam == "" {
+		rangeParam = "7d"
+	}
+	spec, ok := historyRanges[rangeParam]
+	if !ok {
+		return nil, fmt.Errorf("unsupported range: %s", rangeParam)
+	}
+
+	cacheKey := fmt.Sprintf("price_history:%s:%s:%s", config.CHAIN, tokenSymbol, rangeParam)
+	var cached PriceHistory
+	if cache.Exists(cacheKey) {
+		if err := cache.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	now := time.Now()
+	from := now.Add(-spec.window).Unix()
+
+	query := `MATCH (p:TokenPrice {token: $token, chainId: $chainId})
+		WHERE p.timestamp >= $from
+		RETURN p.timestamp AS timestamp, p.priceUSD AS priceUSD
+		ORDER BY p.timestamp ASC`
+	params := map[string]any{
+		"token":   tokenSymbol,
+		"chainId": config.CHAIN,
+		"from":    from,
+	}
+
+	records, err := memgraph.ExecuteRead(query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price history: %w", err)
+	}
+
+	bucketSeconds := int64(spec.bucket.Seconds())
+	points := make([]PricePoint, 0)
+	for _, record := range records {
+		rawTs, _ := record.Get("timestamp")
+		rawPrice, _ := record.Get("priceUSD")
+		ts, ok := rawTs.(int64)
+		if !ok {
+			continue
+		}
+		price, ok := rawPrice.(float64)
+		if !ok {
+			continue
+		}
+
+		bucketStart := ts - ts%bucketSeconds
+		if n := len(points); n > 0 && points[n-1].Timestamp == bucketStart {
+			p := &points[n-1]
+			if price > p.High {
+				p.High = price
+			}
+			if price < p.Low {
+				p.Low = price
+			}
+			p.Close = price
+			p.Average = (p.Average*float64(p.Samples) + price) / float64(p.Samples+1)
+			p.Samples++
+			continue
+		}
+
+		points = append(points, PricePoint{
+			Timestamp: bucketStart,
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+			Average:   price,
+			Samples:   1,
+		})
+	}
+
+	history := &PriceHistory{
+		Token:         tokenSymbol,
+		TokenAddress:  tokenAddress,
+		Range:         rangeParam,
+		BucketSeconds: bucketSeconds,
+		Points:        points,
+		LastUpdated:   now.Unix(),
+	}
+
+	// Cache the bucketed series for 5 minutes; new samples arrive every sampling interval
+	cache.Set(cacheKey, history, config.CacheTTL(config.TTLPriceHistory))
+
+	return history, nil
+}
@@ -0,0 +1,234 @@
+package walletservices
+
+import (
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	"decentragri-app-cx-server/utils"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMinGasBalance is the native balance below which the admin wallet is considered low on gas
+const defaultMinGasBalance = 0.01
+
+// lowGasAlertCooldown prevents the monitor from re-alerting on every check
+const lowGasAlertCooldown = 1 * time.Hour
+
+// GetBackendWalletHealth reports the Engine backend wallet's nonce, gas balance,
+// pending queue depth, and recent failed transactions. All marketplace writes are
+// sent from this wallet, so a low gas balance or a growing queue blocks purchases.
+//
+// Individual Engine calls that fail are reported as warnings rather than failing
+// the whole check, so operators still get a partial picture during outages.
+//
+// Returns:
+//   - *BackendWalletHealth: Aggregated wallet health with a derived status
+//   - error: Only returned when no health data at all could be collected
+func GetBackendWalletHealth() (*BackendWalletHealth, error) {
+	walletAddress := config.AdminWallet
+	health := &BackendWalletHealth{
+		WalletAddress: walletAddress,
+		ChainID:       config.CHAIN,
+		MinGasBalance: minGasBalance(),
+		RecentFailed:  []EngineTransaction{},
+		CheckedAt:     time.Now().Unix(),
+	}
+
+	failures := 0
+
+	nonce, err := getBackendWalletNonce(config.CHAIN, walletAddress)
+	if err != nil {
+		failures++
+		health.Warnings = append(health.Warnings, "nonce unavailable: "+err.Error())
+	} else {
+		health.Nonce = nonce
+	}
+
+	balance, err := GetBalance(config.CHAIN, walletAddress)
+	if err != nil {
+		failures++
+		health.Warnings = append(health.Warnings, "gas balance unavailable: "+err.Error())
+	} else {
+		health.GasBalance = balance.Result.DisplayValue
+		health.GasBalanceRaw = balance.Result.Value
+		if value, err := strconv.ParseFloat(balance.Result.DisplayValue, 64); err == nil {
+			health.LowGas = value < health.MinGasBalance
+		}
+	}
+
+	queued, err := getEngineTransactions("queued", 100)
+	if err != nil {
+		failures++
+		health.Warnings = append(health.Warnings, "queue depth unavailable: "+err.Error())
+	} else {
+		sent, err := getEngineTransactions("sent", 100)
+		if err != nil {
+			health.Warnings = append(health.Warnings, "sent transactions unavailable: "+err.Error())
+		}
+		health.PendingQueueDepth = len(filterByWallet(queued, walletAddress)) + len(filterByWallet(sent, walletAddress))
+	}
+
+	errored, err := getEngineTransactions("errored", 20)
+	if err != nil {
+		failures++
+		health.Warnings = append(health.Warnings, "failed transactions unavailable: "+err.Error())
+	} else {
+		health.RecentFailed = filterByWallet(errored, walletAddress)
+	}
+
+	if failures == 4 {
+		return nil, fmt.Errorf("unable to reach Engine for backend wallet health")
+	}
+
+	switch {
+	case health.LowGas:
+		health.Status = "critical"
+	case failures > 0 || len(health.RecentFailed) > 0:
+		health.Status = "degraded"
+	default:
+		health.Status = "healthy"
+	}
+
+	return health, nil
+}
+
+// CheckBackendWalletHealth runs a health check and sends an operator alert when
+// the admin wallet is low on gas. Alerts are rate-limited via Redis.
+func CheckBackendWalletHealth() {
+	health, err := GetBackendWalletHealth()
+	if err != nil {
+		utils.SendOperatorAlert("critical", "Backend wallet health check failed", err.Error(), nil)
+		return
+	}
+
+	if !health.LowGas {
+		return
+	}
+
+	alertKey := fmt.Sprintf("alert:low_gas:%s", strings.ToLower(health.WalletAddress))
+	if cache.Exists(alertKey) {
+		return
+	}
+
+	utils.SendOperatorAlert("critical", "Admin wallet low on gas",
+		fmt.Sprintf("Backend wallet %s has %s native balance (threshold %g); marketplace writes will fail",
+			health.WalletAddress, health.GasBalance, health.MinGasBalance),
+		map[string]any{
+			"walletAddress":     health.WalletAddress,
+			"chainId":           health.ChainID,
+			"gasBalance":        health.GasBalance,
+			"pendingQueueDepth": health.PendingQueueDepth,
+		})
+
+	cache.Set(alertKey, true, lowGasAlertCooldown)
+}
+
+// StartBackendWalletMonitor periodically checks backend wallet health and alerts on low gas.
+// The interval defaults to 10 minutes and can be overridden with WALLET_HEALTH_INTERVAL.
+// It blocks, so run it in a goroutine.
+func StartBackendWalletMonitor() {
+	interval := 10 * time.Minute
+	if v := os.Getenv("WALLET_HEALTH_INTERVAL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed >= time.Minute {
+			interval = parsed
+		}
+	}
+
+	slog.Info("backend wallet monitor started", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	CheckBackendWalletHealth()
+	for range ticker.C {
+		CheckBackendWalletHealth()
+	}
+}
+
+// minGasBalance returns the low-gas threshold from ADMIN_WALLET_MIN_GAS
+func minGasBalance() float64 {
+	if v := os.Getenv("ADMIN_WALLET_MIN_GAS"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultMinGasBalance
+}
+
+// getBackendWalletNonce fetches the current nonce of a backend wallet from Engine
+func getBackendWalletNonce(chainID, walletAddress string) (int64, error) {
+	url := fmt.Sprintf("%s/backend-wallet/%s/%s/get-nonce",
+		config.EngineCloudBaseURL,
+		chainID,
+		walletAddress,
+	)
+
+	// Health data must be live, so skip the cached fallback
+	status, body, err := utils.Engine().GetLive(url, map[string]string{
+		"Authorization": "Bearer " + os.Getenv("SECRET_KEY"),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return 0, fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
+	var response struct {
+		Result struct {
+			Nonce int64 `json:"nonce"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return response.Result.Nonce, nil
+}
+
+// getEngineTransactions lists Engine transactions with the given status
+func getEngineTransactions(status string, limit int) ([]EngineTransaction, error) {
+	url := fmt.Sprintf("%s/transaction/get-all?page=1&limit=%d&status=%s",
+		config.EngineCloudBaseURL,
+		limit,
+		status,
+	)
+
+	// Health data must be live, so skip the cached fallback
+	code, body, err := utils.Engine().GetLive(url, map[string]string{
+		"Authorization": "Bearer " + os.Getenv("SECRET_KEY"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	if code < 200 || code >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", code, string(body))
+	}
+
+	var response struct {
+		Result struct {
+			Transactions []EngineTransaction `json:"transactions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return response.Result.Transactions, nil
+}
+
+// filterByWallet keeps only transactions sent from the given wallet
+func filterByWallet(transactions []EngineTransaction, walletAddress string) []EngineTransaction {
+	filtered := make([]EngineTransaction, 0, len(transactions))
+	for _, tx := range transactions {
+		if strings.EqualFold(tx.FromAddress, walletAddress) || strings.EqualFold(tx.WalletAddress, walletAddress) {
+			filtered = append(filtered, tx)
+		}
+	}
+	return filtered
+}
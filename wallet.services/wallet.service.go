@@ -15,16 +15,21 @@
 package walletservices
 
 import (
+	"decentragri-app-cx-server/cache"
 	"decentragri-app-cx-server/config"
+	currencyServices "decentragri-app-cx-server/currency.services"
+	"decentragri-app-cx-server/utils"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	tokenServices "decentragri-app-cx-server/token.services"
 
-	"github.com/gofiber/fiber/v2"
+	qrcode "github.com/skip2/go-qrcode"
 )
 
 // WalletService provides wallet management operations using ThirdWeb Engine.
@@ -84,22 +89,12 @@ func (ws *WalletService) CreateWallet(token string) (*CreateWalletResponse, erro
 		Type: "smart:local", // Smart wallet type for enhanced security
 	}
 
-	// Marshal the request body to JSON
-	bodyBytes, err := json.Marshal(reqBody)
+	// Execute the request through the shared Engine client (timeouts, retries, circuit breaker)
+	status, body, err := utils.Engine().Post(url, map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", ws.secretKey),
+	}, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("error marshalling request: %v", err)
-	}
-
-	// Create and configure the HTTP request
-	req := fiber.Post(url)
-	req.Set("Content-Type", "application/json")
-	req.Set("Authorization", fmt.Sprintf("Bearer %s", ws.secretKey))
-	req.Body(bodyBytes)
-
-	// Execute the HTTP request
-	status, body, errs := req.Bytes()
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("error making request: %v", errs[0])
+		return nil, fmt.Errorf("error making request: %w", err)
 	}
 	if status < 200 || status >= 300 {
 		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
@@ -149,14 +144,12 @@ func GetBalance(chainID, walletAddress string) (BalanceResponse, error) {
 		walletAddress,
 	)
 
-	// Create and configure the HTTP request with proper authorization
-	req := fiber.Get(url)
-	req.Set("Authorization", "Bearer "+os.Getenv("SECRET_KEY"))
-
-	// Execute the request and handle potential errors
-	status, body, errs := req.Bytes()
-	if len(errs) > 0 {
-		return BalanceResponse{}, fmt.Errorf("failed to make request: %v", errs[0])
+	// Execute the request through the shared Engine client (timeouts, retries, circuit breaker)
+	status, body, err := utils.Engine().Get(url, map[string]string{
+		"Authorization": "Bearer " + os.Getenv("SECRET_KEY"),
+	})
+	if err != nil {
+		return BalanceResponse{}, fmt.Errorf("failed to make request: %w", err)
 	}
 
 	// Validate the HTTP response status
@@ -209,14 +202,12 @@ func GetERC20Balance(chainID, contractAddress, walletAddress string) (BalanceRes
 		walletAddress,
 	)
 
-	// Create and configure the HTTP request with proper authorization
-	req := fiber.Get(url)
-	req.Set("Authorization", "Bearer "+os.Getenv("SECRET_KEY"))
-
-	// Execute the request and handle potential network errors
-	status, body, errs := req.Bytes()
-	if len(errs) > 0 {
-		return BalanceResponse{}, fmt.Errorf("failed to make request: %v", errs[0])
+	// Execute the request through the shared Engine client (timeouts, retries, circuit breaker)
+	status, body, err := utils.Engine().Get(url, map[string]string{
+		"Authorization": "Bearer " + os.Getenv("SECRET_KEY"),
+	})
+	if err != nil {
+		return BalanceResponse{}, fmt.Errorf("failed to make request: %w", err)
 	}
 
 	// Validate the HTTP response status
@@ -235,6 +226,49 @@ func GetERC20Balance(chainID, contractAddress, walletAddress string) (BalanceRes
 	return response.Result, nil
 }
 
+// erc20DecimalsTTL is how long a token's decimals are cached; they never change
+const erc20DecimalsTTL = 7 * 24 * time.Hour
+
+// GetERC20Decimals returns the number of decimals of an ERC20 token contract, read
+// through ThirdWeb Engine and cached in Redis.
+//
+// The function uses the ThirdWeb Engine REST API endpoint:
+// GET /contract/{chainId}/{contractAddress}/erc20/get
+func GetERC20Decimals(chainID, contractAddress string) (int, error) {
+	cacheKey := fmt.Sprintf("erc20_decimals:%s:%s", chainID, strings.ToLower(contractAddress))
+	var decimals int
+	if err := cache.Get(cacheKey, &decimals); err == nil {
+		return decimals, nil
+	}
+
+	url := fmt.Sprintf("%s/contract/%s/%s/erc20/get", config.EngineCloudBaseURL, chainID, contractAddress)
+	status, body, err := utils.Engine().Get(url, map[string]string{
+		"Authorization": "Bearer " + os.Getenv("SECRET_KEY"),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up token decimals: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return 0, fmt.Errorf("token decimals lookup failed with status %d: %s", status, string(body))
+	}
+
+	var response struct {
+		Result struct {
+			Decimals json.Number `json:"decimals"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("failed to decode token metadata: %w", err)
+	}
+	parsed, err := strconv.Atoi(response.Result.Decimals.String())
+	if err != nil || parsed < 0 || parsed > 255 {
+		return 0, fmt.Errorf("token %s reported invalid decimals %q", contractAddress, response.Result.Decimals)
+	}
+
+	cache.Set(cacheKey, parsed, erc20DecimalsTTL)
+	return parsed, nil
+}
+
 // GetUserBalances retrieves comprehensive token balances for an authenticated user.
 // This function is the main entry point for balance queries and aggregates multiple
 // token balances including native tokens and ERC20 tokens like DAGRI.
@@ -258,6 +292,7 @@ func GetERC20Balance(chainID, contractAddress, walletAddress string) (BalanceRes
 //
 // Parameters:
 //   - token: JWT authentication token containing the user's wallet address
+//   - currency: Fiat currency code for valuation (empty uses the user's preference, then USD)
 //
 // Returns:
 //   - *UserBalances: Complete balance information including native and DAGRI tokens
@@ -280,7 +315,7 @@ func GetERC20Balance(chainID, contractAddress, walletAddress string) (BalanceRes
 //   - CoinGecko API rate limiting or failures
 //   - Contract interaction failures
 //   - JSON parsing errors
-func (ws *WalletService) GetUserBalances(token string) (*UserBalances, error) {
+func (ws *WalletService) GetUserBalances(token, currency string) (*UserBalances, error) {
 	// Extract and validate the user identity from the JWT token
 	tokenService := tokenServices.NewTokenService()
 	username, err := tokenService.VerifyAccessToken(token)
@@ -288,6 +323,12 @@ func (ws *WalletService) GetUserBalances(token string) (*UserBalances, error) {
 		return nil, fmt.Errorf("invalid or expired token: %w", err)
 	}
 
+	// Resolve the fiat currency used for valuation
+	currency, err = currencyServices.ResolveCurrency(currency, username)
+	if err != nil {
+		return nil, err
+	}
+
 	// Use hardcoded chain ID for consistency (421614 = Arb Sepolia)
 	chainID := config.CHAIN
 	chainInt, err := strconv.Atoi(chainID)
@@ -322,6 +363,12 @@ func (ws *WalletService) GetUserBalances(token string) (*UserBalances, error) {
 	nativeBalanceFloat, _ := strconv.ParseFloat(nativeBalance.Result.DisplayValue, 64)
 	dagriBalanceFloat, _ := strconv.ParseFloat(dagriBalance.Result.DisplayValue, 64)
 
+	// Fetch the USD -> fiat exchange rate for the requested currency
+	rate, err := currencyServices.GetRate(currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rate: %w", err)
+	}
+
 	// Prepare the comprehensive balance response
 	return &UserBalances{
 		WalletAddress: username,
@@ -330,14 +377,20 @@ func (ws *WalletService) GetUserBalances(token string) (*UserBalances, error) {
 			RawBalance: nativeBalance.Result.Value,
 			PriceUSD:   nativePrice,
 			ValueUSD:   nativeBalanceFloat * nativePrice,
+			Price:      nativePrice * rate,
+			Value:      nativeBalanceFloat * nativePrice * rate,
 		},
 		DAGRI: TokenBalance{
 			Balance:    dagriBalance.Result.DisplayValue,
 			RawBalance: dagriBalance.Result.Value,
 			PriceUSD:   dagriPrice,
 			ValueUSD:   dagriBalanceFloat * dagriPrice,
+			Price:      dagriPrice * rate,
+			Value:      dagriBalanceFloat * dagriPrice * rate,
 		},
-		LastUpdated: time.Now().Unix(),
+		Currency:     currency,
+		ExchangeRate: rate,
+		LastUpdated:  time.Now().Unix(),
 	}, nil
 }
 
@@ -370,19 +423,17 @@ func (ws *WalletService) GetUserBalances(token string) (*UserBalances, error) {
 //   - Token not found or not supported
 func GetTokenPriceUSD(chainID int, tokenAddress string) (float64, error) {
 	if tokenAddress == "" {
-		tokenAddress = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee" // Native token
+		tokenAddress = nativeTokenAddress // Native token
 	}
 
 	url := fmt.Sprintf("https://%d.insight.thirdweb.com/v1/tokens/price?address=%s", chainID, tokenAddress)
 
-	// Create the request using Fiber's client
-	req := fiber.Get(url)
-	req.Set("x-secret-key", os.Getenv("SECRET_KEY"))
-
-	// Send the request
-	status, body, errs := req.Bytes()
-	if len(errs) > 0 {
-		return 0, fmt.Errorf("failed to make request: %v", errs[0])
+	// Execute the request through the shared Engine client (timeouts, retries, circuit breaker)
+	status, body, err := utils.Engine().Get(url, map[string]string{
+		"x-secret-key": os.Getenv("SECRET_KEY"),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
 	}
 
 	if status < 200 || status >= 300 {
@@ -448,6 +499,13 @@ func (ws *WalletService) GetOwnedNFTs(contractAddress, token string) (NFTRespons
 		return NFTResponse{}, fmt.Errorf("invalid or expired token: %w", err)
 	}
 
+	return ws.GetOwnedNFTsForWallet(contractAddress, username)
+}
+
+// GetOwnedNFTsForWallet retrieves the NFTs a wallet owns in a contract. Unlike
+// GetOwnedNFTs it takes the wallet address directly, for background jobs that act
+// on behalf of users without a request token.
+func (ws *WalletService) GetOwnedNFTsForWallet(contractAddress, username string) (NFTResponse, error) {
 	// Construct the ThirdWeb Engine API URL for NFT ownership query
 	url := fmt.Sprintf("%s/contract/%s/%s/erc1155/get-owned?walletAddress=%s",
 		config.EngineCloudBaseURL,
@@ -455,16 +513,14 @@ func (ws *WalletService) GetOwnedNFTs(contractAddress, token string) (NFTRespons
 		contractAddress,
 		username,
 	)
-	println("Fetching NFTs from URL:", url)
-
-	// Create and configure the HTTP request with proper authorization
-	req := fiber.Get(url)
-	req.Set("Authorization", "Bearer "+ws.secretKey)
+	slog.Debug("fetching owned NFTs", "contract", contractAddress)
 
-	// Execute the request and handle potential network errors
-	status, body, errs := req.Bytes()
-	if len(errs) > 0 {
-		return NFTResponse{}, fmt.Errorf("failed to make request: %v", errs[0])
+	// Execute the request through the shared Engine client (timeouts, retries, circuit breaker)
+	status, body, err := utils.Engine().Get(url, map[string]string{
+		"Authorization": "Bearer " + ws.secretKey,
+	})
+	if err != nil {
+		return NFTResponse{}, fmt.Errorf("failed to make request: %w", err)
 	}
 
 	// Validate the HTTP response status
@@ -480,3 +536,338 @@ func (ws *WalletService) GetOwnedNFTs(contractAddress, token string) (NFTRespons
 
 	return nftResp, nil
 }
+
+// GenerateReceiveQR renders a QR code for the authenticated user's wallet address.
+// Generating codes server-side guarantees every client (mobile, web) renders an
+// identical, scannable payload for the same request.
+//
+// The function performs the following operations:
+//  1. Validates the JWT token and extracts the wallet address
+//  2. Builds the payload (bare address or EIP-681 payment URI)
+//  3. Renders the payload as a PNG or SVG image
+//
+// EIP-681 Encoding:
+//   - Native token: ethereum:{wallet}@{chainId}?value={wei}
+//   - ERC20 token:  ethereum:{token}@{chainId}/transfer?address={wallet}&uint256={wei}
+//
+// Parameters:
+//   - token: JWT authentication token containing the user's wallet address
+//   - req: Output format, size, and optional EIP-681 token/amount
+//
+// Returns:
+//   - *ReceiveQRCode: Encoded content, MIME type, and rendered image
+//   - error: Any error encountered during validation or rendering
+func (ws *WalletService) GenerateReceiveQR(token string, req ReceiveQRRequest) (*ReceiveQRCode, error) {
+	// Extract and validate the user identity from the JWT token
+	tokenService := tokenServices.NewTokenService()
+	username, err := tokenService.VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired token: %w", err)
+	}
+
+	if req.Size == 0 {
+		req.Size = 256
+	}
+	if req.Size < 64 || req.Size > 1024 {
+		return nil, fmt.Errorf("size must be between 64 and 1024")
+	}
+
+	content := username
+	if req.EIP681 {
+		content, err = buildEIP681URI(username, req.Token, req.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	qr, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	switch strings.ToLower(req.Format) {
+	case "", "png":
+		png, err := qr.PNG(req.Size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render QR code: %w", err)
+		}
+		return &ReceiveQRCode{Content: content, ContentType: "image/png", Image: png}, nil
+	case "svg":
+		return &ReceiveQRCode{Content: content, ContentType: "image/svg+xml", Image: renderQRSVG(qr.Bitmap(), req.Size)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", req.Format)
+	}
+}
+
+// buildEIP681URI builds an EIP-681 payment request URI for the given wallet.
+// Amounts are converted to the token's smallest unit: 18 decimals for the native
+// token, and the contract's own decimals (e.g. 6 for USDC) for ERC20 tokens.
+func buildEIP681URI(walletAddress, tokenParam, amount string) (string, error) {
+	var tokenAddress string
+	switch strings.ToLower(tokenParam) {
+	case "", "native":
+		tokenAddress = ""
+	case "dagri":
+		tokenAddress = config.DAGRIContractAddress
+	default:
+		if !utils.ValidateContractAddress(tokenParam) {
+			return "", fmt.Errorf("invalid token address: %s", tokenParam)
+		}
+		tokenAddress = tokenParam
+	}
+
+	var wei string
+	if amount != "" {
+		decimals := 18
+		if tokenAddress != "" {
+			var err error
+			if decimals, err = GetERC20Decimals(config.CHAIN, tokenAddress); err != nil {
+				return "", err
+			}
+		}
+		value, err := utils.ParseUnits(amount, decimals)
+		if err != nil || value.Sign() < 0 {
+			return "", fmt.Errorf("invalid amount: %s", amount)
+		}
+		wei = value.String()
+	}
+
+	if tokenAddress == "" {
+		uri := fmt.Sprintf("ethereum:%s@%s", walletAddress, config.CHAIN)
+		if wei != "" {
+			uri += "?value=" + wei
+		}
+		return uri, nil
+	}
+
+	uri := fmt.Sprintf("ethereum:%s@%s/transfer?address=%s", tokenAddress, config.CHAIN, walletAddress)
+	if wei != "" {
+		uri += "&uint256=" + wei
+	}
+	return uri, nil
+}
+
+// renderQRSVG renders a QR bitmap (including its quiet zone) as a square SVG document
+func renderQRSVG(bitmap [][]bool, size int) []byte {
+	modules := len(bitmap)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="#ffffff"/>`, modules, modules)
+	sb.WriteString(`<path fill="#000000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&sb, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	sb.WriteString(`"/></svg>`)
+
+	return []byte(sb.String())
+}
+
+// maxSessionKeyLifetime caps how long a session key may remain valid
+const maxSessionKeyLifetime = 30 * 24 * time.Hour
+
+// CreateSessionKey grants a scoped session key on the user's ThirdWeb smart account.
+// Session keys let the mobile app perform limited actions (calls to specific contracts
+// under a per-transaction spend limit) without prompting for a signature each time.
+//
+// The function uses the ThirdWeb Engine REST API endpoint:
+// POST /contract/{chainId}/{accountAddress}/account/sessions/create
+//
+// Parameters:
+//   - token: JWT authentication token containing the user's smart account address
+//   - req: Signer address, approved contracts, spend limit, and validity window
+//
+// Returns:
+//   - *SessionKeyTransactionResponse: Engine queue ID for the grant transaction
+//   - error: Validation failures or Engine API errors
+func (ws *WalletService) CreateSessionKey(token string, req CreateSessionKeyRequest) (*SessionKeyTransactionResponse, error) {
+	// Extract and validate the user identity from the JWT token
+	tokenService := tokenServices.NewTokenService()
+	username, err := tokenService.VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired token: %w", err)
+	}
+
+	// Validate the session key scope before sending anything to Engine
+	if !utils.ValidateEthereumAddress(req.SignerAddress) {
+		return nil, fmt.Errorf("invalid signer address")
+	}
+	if len(req.ContractAddresses) == 0 {
+		return nil, fmt.Errorf("at least one contract address is required")
+	}
+	for _, contract := range req.ContractAddresses {
+		if !utils.ValidateContractAddress(contract) {
+			return nil, fmt.Errorf("invalid contract address: %s", contract)
+		}
+	}
+	if req.NativeTokenLimitPerTransaction == "" {
+		req.NativeTokenLimitPerTransaction = "0"
+	}
+	if limit, err := strconv.ParseFloat(req.NativeTokenLimitPerTransaction, 64); err != nil || limit < 0 {
+		return nil, fmt.Errorf("invalid native token limit")
+	}
+
+	startDate := time.Now().UTC()
+	if req.StartDate != "" {
+		startDate, err = time.Parse(time.RFC3339, req.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start date: %w", err)
+		}
+	}
+	expirationDate, err := time.Parse(time.RFC3339, req.ExpirationDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiration date: %w", err)
+	}
+	if !expirationDate.After(startDate) || !expirationDate.After(time.Now()) {
+		return nil, fmt.Errorf("expiration date must be in the future and after the start date")
+	}
+	if expirationDate.Sub(startDate) > maxSessionKeyLifetime {
+		return nil, fmt.Errorf("session keys may be valid for at most %d days", int(maxSessionKeyLifetime.Hours()/24))
+	}
+
+	url := fmt.Sprintf("%s/contract/%s/%s/account/sessions/create",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		username,
+	)
+
+	reqBody := map[string]any{
+		"signerAddress":                  req.SignerAddress,
+		"contractAddresses":              req.ContractAddresses,
+		"nativeTokenLimitPerTransaction": req.NativeTokenLimitPerTransaction,
+		"startDate":                      startDate.Format(time.RFC3339),
+		"expirationDate":                 expirationDate.Format(time.RFC3339),
+	}
+
+	queueID, err := ws.postAccountTransaction(url, username, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionKeyTransactionResponse{
+		QueueID:       queueID,
+		SignerAddress: req.SignerAddress,
+		Message:       "Session key grant queued",
+	}, nil
+}
+
+// GetSessionKeys lists all session keys currently registered on the user's smart account.
+//
+// The function uses the ThirdWeb Engine REST API endpoint:
+// GET /contract/{chainId}/{accountAddress}/account/sessions/get-all
+//
+// Parameters:
+//   - token: JWT authentication token containing the user's smart account address
+//
+// Returns:
+//   - []SessionKey: Active session keys with their scope and validity window
+//   - error: Any error encountered during the Engine query
+func (ws *WalletService) GetSessionKeys(token string) ([]SessionKey, error) {
+	// Extract and validate the user identity from the JWT token
+	tokenService := tokenServices.NewTokenService()
+	username, err := tokenService.VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired token: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/contract/%s/%s/account/sessions/get-all",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		username,
+	)
+
+	// Execute the request through the shared Engine client (timeouts, retries, circuit breaker)
+	status, body, err := utils.Engine().Get(url, map[string]string{
+		"Authorization": "Bearer " + ws.secretKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
+	var response SessionKeysResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if response.Result == nil {
+		response.Result = []SessionKey{}
+	}
+
+	return response.Result, nil
+}
+
+// RevokeSessionKey revokes a session key from the user's smart account.
+//
+// The function uses the ThirdWeb Engine REST API endpoint:
+// POST /contract/{chainId}/{accountAddress}/account/sessions/revoke
+//
+// Parameters:
+//   - token: JWT authentication token containing the user's smart account address
+//   - signerAddress: The session key signer to revoke
+//
+// Returns:
+//   - *SessionKeyTransactionResponse: Engine queue ID for the revoke transaction
+//   - error: Validation failures or Engine API errors
+func (ws *WalletService) RevokeSessionKey(token, signerAddress string) (*SessionKeyTransactionResponse, error) {
+	// Extract and validate the user identity from the JWT token
+	tokenService := tokenServices.NewTokenService()
+	username, err := tokenService.VerifyAccessToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired token: %w", err)
+	}
+
+	if !utils.ValidateEthereumAddress(signerAddress) {
+		return nil, fmt.Errorf("invalid signer address")
+	}
+
+	url := fmt.Sprintf("%s/contract/%s/%s/account/sessions/revoke",
+		config.EngineCloudBaseURL,
+		config.CHAIN,
+		username,
+	)
+
+	queueID, err := ws.postAccountTransaction(url, username, map[string]any{"walletAddress": signerAddress})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionKeyTransactionResponse{
+		QueueID:       queueID,
+		SignerAddress: signerAddress,
+		Message:       "Session key revocation queued",
+	}, nil
+}
+
+// postAccountTransaction sends a write request against the user's smart account
+// and returns the Engine queue ID. The smart account acts as its own backend wallet.
+func (ws *WalletService) postAccountTransaction(url, accountAddress string, reqBody any) (string, error) {
+	// Execute the request through the shared Engine client (timeouts, retries, circuit breaker)
+	status, body, err := utils.Engine().Post(url, map[string]string{
+		"Authorization":            "Bearer " + ws.secretKey,
+		"X-Backend-Wallet-Address": accountAddress,
+	}, reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	if status < 200 || status >= 300 {
+		return "", fmt.Errorf("API request failed with status %d: %s", status, string(body))
+	}
+
+	var response struct {
+		Result struct {
+			QueueID string `json:"queueId"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return response.Result.QueueID, nil
+}
@@ -1,19 +1,25 @@
 package walletservices
 
+import "encoding/json"
+
 // TokenBalance represents the balance and price information for a token
 type TokenBalance struct {
 	Balance    string  `json:"balance"`    // Display value of the balance
 	RawBalance string  `json:"rawBalance"` // Raw value of the balance
 	PriceUSD   float64 `json:"priceUSD"`   // Current price in USD
 	ValueUSD   float64 `json:"valueUSD"`   // Total value in USD (balance * price)
+	Price      float64 `json:"price"`      // Current price in the requested fiat currency
+	Value      float64 `json:"value"`      // Total value in the requested fiat currency
 }
 
 // UserBalances represents comprehensive balance information for a user
 type UserBalances struct {
 	WalletAddress string       `json:"walletAddress"`
-	Native        TokenBalance `json:"native"`      // Native token (ETH) balance and price
-	DAGRI         TokenBalance `json:"dagri"`       // DAGRI token balance (no price yet)
-	LastUpdated   int64        `json:"lastUpdated"` // Unix timestamp of last update
+	Native        TokenBalance `json:"native"`       // Native token (ETH) balance and price
+	DAGRI         TokenBalance `json:"dagri"`        // DAGRI token balance (no price yet)
+	Currency      string       `json:"currency"`     // Fiat currency used for price/value fields
+	ExchangeRate  float64      `json:"exchangeRate"` // USD -> currency rate applied
+	LastUpdated   int64        `json:"lastUpdated"`  // Unix timestamp of last update
 }
 
 // BalanceResponse represents the response from thirdweb balance API
@@ -45,10 +51,15 @@ type NFTItem struct {
 	QuantityOwned string      `json:"quantityOwned"` // Quantity owned by the user
 }
 
-// NFTAttribute represents an NFT attribute
+// NFTAttribute represents an NFT attribute. Farm plots may instead carry a single
+// object-style attribute block; its farm, crop type, location, and area are kept as well.
 type NFTAttribute struct {
-	TraitType string `json:"trait_type"`
-	Value     string `json:"value"`
+	TraitType string      `json:"trait_type"`
+	Value     string      `json:"value"`
+	FarmName  string      `json:"farmName,omitempty"`
+	CropType  string      `json:"cropType,omitempty"`
+	Location  string      `json:"location,omitempty"`
+	Area      json.Number `json:"area,omitempty"` // Plot area in hectares
 }
 
 // NFTMetadata represents the metadata of an NFT
@@ -72,3 +83,100 @@ type CreateWalletResponse struct {
 	Status        string `json:"status"`
 	Message       string `json:"message"`
 }
+
+// ReceiveQRRequest represents the options for generating a receive-address QR code
+type ReceiveQRRequest struct {
+	Format string // "png" (default) or "svg"
+	Size   int    // Image size in pixels (PNG) or viewBox units (SVG)
+	EIP681 bool   // Encode as an EIP-681 payment URI instead of a bare address
+	Token  string // "native" (default), "dagri", or an ERC20 contract address
+	Amount string // Optional human-readable amount (e.g. "1.5")
+}
+
+// ReceiveQRCode represents a generated QR code image
+type ReceiveQRCode struct {
+	Content     string // Encoded payload (address or EIP-681 URI)
+	ContentType string // MIME type of Image
+	Image       []byte // Rendered QR code
+}
+
+// CreateSessionKeyRequest represents the request to grant a session key on the user's smart account
+type CreateSessionKeyRequest struct {
+	SignerAddress                  string   `json:"signerAddress"`                  // Address of the session key signer
+	ContractAddresses              []string `json:"contractAddresses"`              // Contracts the session key may call
+	NativeTokenLimitPerTransaction string   `json:"nativeTokenLimitPerTransaction"` // Max native token spend per transaction (e.g. "0.01")
+	StartDate                      string   `json:"startDate,omitempty"`            // RFC3339 start time (defaults to now)
+	ExpirationDate                 string   `json:"expirationDate"`                 // RFC3339 expiration time
+}
+
+// SessionKey represents an active session key on a smart account
+type SessionKey struct {
+	SignerAddress                  string   `json:"signerAddress"`
+	StartDate                      string   `json:"startDate"`
+	ExpirationDate                 string   `json:"expirationDate"`
+	NativeTokenLimitPerTransaction string   `json:"nativeTokenLimitPerTransaction"`
+	ApprovedCallTargets            []string `json:"approvedCallTargets"`
+}
+
+// SessionKeysResponse represents the response from the Engine session key listing API
+type SessionKeysResponse struct {
+	Result []SessionKey `json:"result"`
+}
+
+// SessionKeyTransactionResponse represents a queued session key create/revoke transaction
+type SessionKeyTransactionResponse struct {
+	QueueID       string `json:"queueId"`
+	SignerAddress string `json:"signerAddress"`
+	Message       string `json:"message"`
+}
+
+// PricePoint represents a single time bucket in a token price history
+type PricePoint struct {
+	Timestamp int64   `json:"timestamp"` // Bucket start (Unix seconds)
+	Open      float64 `json:"open"`      // First sampled price in the bucket
+	High      float64 `json:"high"`      // Highest sampled price in the bucket
+	Low       float64 `json:"low"`       // Lowest sampled price in the bucket
+	Close     float64 `json:"close"`     // Last sampled price in the bucket
+	Average   float64 `json:"average"`   // Mean of sampled prices in the bucket
+	Samples   int     `json:"samples"`   // Number of samples in the bucket
+}
+
+// PriceHistory represents time-bucketed historical USD prices for a token
+type PriceHistory struct {
+	Token         string       `json:"token"`         // "native" or "dagri"
+	TokenAddress  string       `json:"tokenAddress"`  // Contract address (0xeeee... for native)
+	Range         string       `json:"range"`         // Requested range (e.g. "7d")
+	BucketSeconds int64        `json:"bucketSeconds"` // Width of each bucket
+	Points        []PricePoint `json:"points"`
+	LastUpdated   int64        `json:"lastUpdated"`
+}
+
+// EngineTransaction represents a transaction entry from the Engine transaction queue
+type EngineTransaction struct {
+	QueueID          string `json:"queueId"`
+	Status           string `json:"status"`
+	FromAddress      string `json:"fromAddress"`
+	WalletAddress    string `json:"walletAddress"`
+	ToAddress        string `json:"toAddress"`
+	FunctionName     string `json:"functionName"`
+	ErrorMessage     string `json:"errorMessage"`
+	TxHash           string `json:"transactionHash"`
+	QueuedAt         string `json:"queuedAt"`
+	CreatedTimestamp string `json:"createdTimestamp"`
+}
+
+// BackendWalletHealth reports the operational status of the Engine backend (admin) wallet
+type BackendWalletHealth struct {
+	WalletAddress     string              `json:"walletAddress"`
+	ChainID           string              `json:"chainId"`
+	Nonce             int64               `json:"nonce"`
+	GasBalance        string              `json:"gasBalance"`         // Native balance (display value)
+	GasBalanceRaw     string              `json:"gasBalanceRaw"`      // Native balance in wei
+	MinGasBalance     float64             `json:"minGasBalance"`      // Alert threshold
+	LowGas            bool                `json:"lowGas"`             // True when balance is below threshold
+	PendingQueueDepth int                 `json:"pendingQueueDepth"`  // Queued + sent transactions not yet mined
+	RecentFailed      []EngineTransaction `json:"recentFailed"`       // Most recent errored transactions
+	Status            string              `json:"status"`             // "healthy", "degraded", or "critical"
+	Warnings          []string            `json:"warnings,omitempty"` // Non-fatal problems encountered during the check
+	CheckedAt         int64               `json:"checkedAt"`
+}
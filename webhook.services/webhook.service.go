@@ -0,0 +1,214 @@
+// Package webhookservices receives ThirdWeb Engine webhooks for the Decentragri platform.
+// Engine contract subscriptions push marketplace and farm plot events as soon as they
+// are mined, so cached listings and portfolios are refreshed immediately instead of
+// waiting for their TTL to expire.
+//
+// Handled events:
+//   - NewSale (marketplace contract): record the sale, refresh listings, notify buyer and seller
+//   - TransferSingle, TransferBatch (ERC1155 farm plot contract): record each transferred plot,
+//     refresh listings and both parties' portfolios, and push an nft_received event per plot
+//     to the recipient's portfolio stream
+package webhookservices
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"decentragri-app-cx-server/cache"
+	"decentragri-app-cx-server/config"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	marketplaceServices "decentragri-app-cx-server/marketplace.services"
+	portfolioServices "decentragri-app-cx-server/portfolio.services"
+)
+
+// maxSignatureAge rejects webhook deliveries whose timestamp is too old to prevent replays
+const maxSignatureAge = 5 * time.Minute
+
+// ErrInvalidSignature is returned when a webhook fails signature validation
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// VerifyEngineSignature validates an Engine webhook delivery. Engine signs
+// "<timestamp>:<body>" with HMAC-SHA256 using the webhook secret (ENGINE_WEBHOOK_SECRET)
+// and sends the hex digest in X-Engine-Signature with the timestamp in X-Engine-Timestamp.
+func VerifyEngineSignature(body []byte, timestamp, signature string) error {
+	secret := os.Getenv("ENGINE_WEBHOOK_SECRET")
+	if secret == "" {
+		return fmt.Errorf("ENGINE_WEBHOOK_SECRET is not configured")
+	}
+	if timestamp == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	age := time.Since(time.Unix(ts, 0))
+	if age > maxSignatureAge || age < -maxSignatureAge {
+		return fmt.Errorf("%w: timestamp outside allowed window", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + ":"))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// HandleEngineWebhook dispatches a verified Engine webhook payload.
+// Unknown payload types and events are acknowledged and ignored.
+func HandleEngineWebhook(body []byte) error {
+	var payload EngineWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	if payload.Type != "event-log" {
+		slog.Info("ignoring Engine webhook", "type", payload.Type)
+		return nil
+	}
+
+	var event EngineEventLog
+	if err := json.Unmarshal(payload.Data, &event); err != nil {
+		return fmt.Errorf("invalid event log: %w", err)
+	}
+	if event.ChainID.String() != "" && event.ChainID.String() != config.CHAIN {
+		slog.Info("ignoring Engine event from another chain", "chainId", event.ChainID)
+		return nil
+	}
+
+	switch {
+	case event.EventName == "NewSale" && strings.EqualFold(event.ContractAddress, config.MarketPlaceContractAddress):
+		return handleNewSale(event)
+	case (event.EventName == "TransferSingle" || event.EventName == "TransferBatch") &&
+		strings.EqualFold(event.ContractAddress, config.FarmPlotContractAddress):
+		return handleTransfer(event)
+	default:
+		slog.Info("ignoring Engine event", "event", event.EventName, "contract", event.ContractAddress)
+		return nil
+	}
+}
+
+// handleNewSale processes a marketplace NewSale event
+func handleNewSale(event EngineEventLog) error {
+	sale := marketplaceServices.SaleEvent{
+		ListingID:      decodedValue(event.DecodedLog, "listingId"),
+		TokenID:        decodedValue(event.DecodedLog, "tokenId"),
+		Buyer:          decodedValue(event.DecodedLog, "buyer"),
+		Seller:         decodedValue(event.DecodedLog, "listingCreator"),
+		Quantity:       decodedValue(event.DecodedLog, "quantityBought"),
+		TotalPricePaid: decodedValue(event.DecodedLog, "totalPricePaid"),
+		TxHash:         event.TransactionHash,
+	}
+	if sale.ListingID == "" {
+		return fmt.Errorf("NewSale event missing listingId")
+	}
+
+	slog.Info("Engine webhook: listing sold", "listingId", sale.ListingID, "buyer", sale.Buyer, "txHash", sale.TxHash)
+
+	cache.InvalidatePlotOwnership(sale.Buyer, sale.Seller)
+
+	return marketplaceServices.ProcessSale(sale)
+}
+
+// handleTransfer processes a farm plot ERC1155 TransferSingle or TransferBatch event
+// (sales, gifts, and mints). A batch moves several plots between the same two wallets.
+func handleTransfer(event EngineEventLog) error {
+	from := decodedValue(event.DecodedLog, "from")
+	to := decodedValue(event.DecodedLog, "to")
+
+	var tokenIDs []string
+	if event.EventName == "TransferBatch" {
+		tokenIDs = decodedValues(event.DecodedLog, "ids")
+	} else if id := decodedValue(event.DecodedLog, "id"); id != "" {
+		tokenIDs = []string{id}
+	}
+	if len(tokenIDs) == 0 {
+		return fmt.Errorf("%s event missing token ids", event.EventName)
+	}
+
+	for _, tokenID := range tokenIDs {
+		slog.Info("Engine webhook: farm plot transferred", "tokenId", tokenID, "from", from, "to", to)
+
+		if err := portfolioServices.RecordPlotTransfer(tokenID, from, to, event.TransactionHash, event.BlockNumber); err != nil {
+			slog.Warn("failed to record plot transfer", "tokenId", tokenID, "error", err)
+		}
+		portfolioServices.PublishPortfolioEvent(to, portfolioServices.EventNFTReceived, map[string]any{
+			"tokenId": tokenID,
+			"from":    from,
+			"txHash":  event.TransactionHash,
+		})
+	}
+
+	// A plot that moves out-of-band can no longer be bought from its old listing
+	cache.InvalidatePlotOwnership(from, to)
+
+	return nil
+}
+
+// decodedValue reads an argument from an Engine decoded log. Engine reports each
+// argument either as a plain value or as {"type": ..., "value": ...}.
+func decodedValue(decoded map[string]json.RawMessage, name string) string {
+	raw, ok := decoded[name]
+	if !ok {
+		return ""
+	}
+	return scalarValue(unwrapTyped(raw))
+}
+
+// decodedValues reads an array argument, such as TransferBatch ids, from an Engine
+// decoded log
+func decodedValues(decoded map[string]json.RawMessage, name string) []string {
+	raw, ok := decoded[name]
+	if !ok {
+		return nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(unwrapTyped(raw), &items); err != nil {
+		return nil
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if value := scalarValue(unwrapTyped(item)); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// unwrapTyped returns the value of a {"type": ..., "value": ...} argument, or raw as is
+func unwrapTyped(raw json.RawMessage) json.RawMessage {
+	var typed struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &typed); err == nil && len(typed.Value) > 0 {
+		return typed.Value
+	}
+	return raw
+}
+
+// scalarValue decodes a string or number argument as a string
+func scalarValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+	return strings.Trim(string(raw), `"`)
+}
@@ -0,0 +1,19 @@
+package webhookservices
+
+import "encoding/json"
+
+// EngineWebhookPayload is the envelope Engine sends for contract subscription events
+type EngineWebhookPayload struct {
+	Type string          `json:"type"` // "event-log" for contract subscriptions
+	Data json.RawMessage `json:"data"`
+}
+
+// EngineEventLog is a decoded contract event delivered by an Engine contract subscription
+type EngineEventLog struct {
+	ChainID         json.Number                `json:"chainId"`
+	ContractAddress string                     `json:"contractAddress"`
+	BlockNumber     int64                      `json:"blockNumber"`
+	TransactionHash string                     `json:"transactionHash"`
+	EventName       string                     `json:"eventName"`
+	DecodedLog      map[string]json.RawMessage `json:"decodedLog"`
+}